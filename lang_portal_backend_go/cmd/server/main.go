@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"lang_portal/internal/auth"
+	"lang_portal/internal/config"
+	"lang_portal/internal/handlers"
+	"lang_portal/internal/https"
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/service"
+	"lang_portal/internal/tracing"
+	"lang_portal/web"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	// Load the settings from the config file, environment and flags
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Initialize services
+	log.Printf("Starting server initialization...\n")
+	svc, err := service.NewServiceFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create service: %v", err)
+	}
+	if cfg.Storage.Backend == config.StorageLocal && cfg.Storage.SigningKey == "" {
+		log.Printf("%s is not set, signing media URLs with a random key\n", config.StorageSigningKeyEnv)
+	}
+
+	// Run the jobs queued for the background, such as backups and imports
+	if err := svc.StartJobs(); err != nil {
+		log.Fatalf("Failed to start background jobs: %v", err)
+	}
+
+	// Roll up review stats for the dashboard every night
+	svc.StartStatsRollup()
+
+	// Sync groups from their sheets when due
+	svc.StartGroupSyncs()
+
+	// Purge guests and their history once they expire
+	svc.StartGuestPurge()
+
+	// Optimize and check the database every day
+	svc.StartMaintenance()
+
+	// Email the notifications users asked for every hour
+	svc.StartNotifications()
+
+	// Send the daily quiz questions to linked Telegram and Discord chats
+	svc.StartBotQuizzes()
+
+	// Push reviews due and streak reminders to subscribed browsers every hour
+	svc.StartPush()
+
+	// Forget deletions older than offline clients sync every day
+	svc.StartTombstonePrune()
+
+	// Sign tokens with the configured key, or a random one that invalidates
+	// every token when the server restarts
+	secret := []byte(os.Getenv(auth.SecretEnv))
+	if len(secret) == 0 {
+		log.Printf("%s is not set, signing tokens with a random key\n", auth.SecretEnv)
+		secret, err = auth.RandomSecret()
+		if err != nil {
+			log.Fatalf("Failed to generate token key: %v", err)
+		}
+	}
+	issuer := auth.NewIssuer(secret)
+
+	if password := os.Getenv(service.DefaultUserPasswordEnv); password != "" {
+		if err := svc.SetUserPassword(context.Background(), service.DefaultUserID, password); err != nil {
+			log.Fatalf("Failed to set default user password: %v", err)
+		}
+	}
+
+	// Export traces of requests if a collector is configured
+	tracer := tracing.New(cfg.Tracing.OTLPEndpoint, cfg.Tracing.ServiceName)
+	if tracer != nil {
+		log.Printf("Exporting traces to %s\n", cfg.Tracing.OTLPEndpoint)
+	}
+
+	// Setup router
+	log.Printf("Setting up router...\n")
+	if cfg.LogLevel != config.LogDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	
+	// Add middleware
+	log.Printf("Adding middleware...\n")
+	// Compression comes first so the other middleware see the plain body
+	r.Use(middleware.Gzip())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing(tracer))
+	r.Use(middleware.Logger(cfg.LogLevel))
+	// The latest server errors are shown on the admin dashboard
+	r.Use(middleware.ServerErrors(svc.RecordServerError))
+	r.Use(middleware.CORS(cfg.CORSOrigins))
+	r.Use(middleware.RateLimit(cfg.RateLimit, svc.Cache()))
+	r.Use(middleware.BodyLimit(cfg.MaxBodyBytes, cfg.MaxUploadBytes))
+	r.Use(middleware.ErrorHandler())
+	r.Use(gin.Recovery())
+
+	// Health checks sit outside /api so probes need no access token
+	health := handlers.NewHealth(svc)
+	handlers.RegisterHealthRoutes(r, health)
+
+	// Synthesized audio is played by audio elements, and pictures of words
+	// shown by img elements, which send no token. Their files redirect to
+	// signed URLs of the media storage, which locally are served by the
+	// server too.
+	handlers.RegisterAudioFileRoutes(r, svc)
+	handlers.RegisterWordImageFileRoutes(r, svc)
+	handlers.RegisterMediaRoutes(r, svc.Media())
+
+	// The API is served under /api/v1. The unversioned /api paths are a
+	// deprecated alias kept for clients written before versioning.
+	google := auth.GoogleFromEnv()
+	log.Printf("Registering routes...\n")
+	registerAPI(r.Group("/api/v1", middleware.APIVersion(middleware.APIv1)), r, svc, issuer, google)
+	registerAPI(r.Group("/api", middleware.Deprecated("/api", "/api/v1"), middleware.NegotiateAPIVersion(middleware.APIv1)), r, svc, issuer, google)
+
+	// Serve the frontend under / if it was built into the binary
+	if files, ok := web.FS(); ok {
+		if err := handlers.RegisterSPARoutes(r, files); err != nil {
+			log.Fatalf("Failed to serve frontend: %v", err)
+		}
+		log.Printf("Serving the frontend under /\n")
+	}
+
+	// Start server
+	// Timeouts keep slow or stalled clients from holding connections, and
+	// the requests queued behind them on SQLite, indefinitely
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	// Serve HTTPS directly if TLS is configured, optionally redirecting
+	// HTTP to it from a second port
+	var redirect *http.Server
+	if cfg.TLS.Enabled() {
+		tlsConfig, redirectHandler, err := https.New(cfg.TLS, cfg.Port)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		srv.TLSConfig = tlsConfig
+		if cfg.TLS.RedirectPort != 0 {
+			redirect = &http.Server{
+				Addr:         fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+				Handler:      redirectHandler,
+				ReadTimeout:  cfg.ReadTimeout,
+				WriteTimeout: cfg.WriteTimeout,
+				IdleTimeout:  cfg.IdleTimeout,
+			}
+		}
+	}
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on port %d: %v", cfg.Port, err)
+	}
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			log.Printf("Starting HTTPS server on port %d...\n", cfg.Port)
+			err = srv.ServeTLS(listener, "", "")
+		} else {
+			log.Printf("Starting server on port %d...\n", cfg.Port)
+			err = srv.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+	if redirect != nil {
+		log.Printf("Redirecting HTTP on port %d to HTTPS...\n", cfg.TLS.RedirectPort)
+		go func() {
+			if err := redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Redirect server failed: %v", err)
+			}
+		}()
+	}
+	health.SetReady(true)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+
+	// Stop accepting connections and let the requests in flight finish
+	log.Printf("Shutting down, waiting up to %s for requests to finish...\n", cfg.ShutdownTimeout)
+	health.SetReady(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Requests were still running when the server stopped: %v", err)
+	}
+	if redirect != nil {
+		redirect.Shutdown(shutdownCtx)
+	}
+
+	// Let background jobs finish before closing the database
+	if err := svc.Close(); err != nil {
+		log.Printf("Failed to close service: %v", err)
+	}
+
+	// Export the traces of the last requests
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to export traces: %v", err)
+	}
+	log.Printf("Server stopped\n")
+}
+
+// registerAPI registers the routes of the API on api, and its docs, which
+// describe the routes of r
+func registerAPI(api *gin.RouterGroup, r *gin.Engine, svc *service.Service, issuer *auth.Issuer, google *auth.Google) {
+	// Requests select the language of the words and statistics they are
+	// served with ?language=
+	api.Use(middleware.Language(svc.KnownLanguage))
+	handlers.RegisterAuthRoutes(api, svc, issuer, google)
+	handlers.RegisterDocsRoutes(api, r.Routes)
+	// Chat platforms sign their webhook requests rather than send a token
+	handlers.RegisterBotWebhookRoutes(api, svc)
+	// Calendar apps fetch feeds with the calendar token in the URL
+	handlers.RegisterCalendarFeedRoutes(api, svc)
+	// External automations send words to the inbox with an API key
+	handlers.RegisterInboxRoutes(api, svc)
+
+	// Everything else requires an access token
+	api = api.Group("")
+	api.Use(middleware.Auth(issuer))
+	// Writes sent with an Idempotency-Key are run once per key and user
+	api.Use(middleware.Idempotency(svc.Cache()))
+	// Handlers ask which experimental features the user has turned on
+	api.Use(middleware.FeatureFlags(func(c *gin.Context) (map[string]bool, error) {
+		userID, _ := middleware.CurrentUserID(c)
+		return svc.ForUser(userID).FeatureFlags(c.Request.Context())
+	}))
+	handlers.RegisterDashboardRoutes(api, svc)
+	handlers.RegisterStudyActivitiesRoutes(api, svc)
+	handlers.RegisterWordsRoutes(api, svc)
+	handlers.RegisterGroupsRoutes(api, svc)
+	handlers.RegisterGroupPermissionRoutes(api, svc)
+	handlers.RegisterGroupDraftRoutes(api, svc)
+	handlers.RegisterAudioRoutes(api, svc)
+	handlers.RegisterWordImageRoutes(api, svc)
+	handlers.RegisterWordRenderingRoutes(api, svc)
+	handlers.RegisterSuggestionRoutes(api, svc)
+	handlers.RegisterEnrichmentRoutes(api, svc)
+	handlers.RegisterImportRoutes(api, svc)
+	handlers.RegisterGroupSyncRoutes(api, svc)
+	handlers.RegisterSearchRoutes(api, svc)
+	handlers.RegisterPronunciationRoutes(api, svc)
+	handlers.RegisterStudySessionsRoutes(api, svc)
+	handlers.RegisterResetRoutes(api, svc)
+	handlers.RegisterActivityRoutes(api, svc)
+	handlers.RegisterReviewQueueRoutes(api, svc)
+	handlers.RegisterQuestionRoutes(api, svc)
+	handlers.RegisterFlashcardRoutes(api, svc)
+	handlers.RegisterListeningRoutes(api, svc)
+	handlers.RegisterWordGameRoutes(api, svc)
+	handlers.RegisterConjugationRoutes(api, svc)
+	handlers.RegisterSentenceRoutes(api, svc)
+	handlers.RegisterClozeRoutes(api, svc)
+	handlers.RegisterPassageRoutes(api, svc)
+	handlers.RegisterGrammarRoutes(api, svc)
+	handlers.RegisterGoalRoutes(api, svc)
+	handlers.RegisterClassRoutes(api, svc)
+	handlers.RegisterLeaderboardRoutes(api, svc)
+	handlers.RegisterNotificationRoutes(api, svc)
+	handlers.RegisterBotRoutes(api, svc)
+	handlers.RegisterPushRoutes(api, svc)
+	handlers.RegisterCalendarRoutes(api, svc)
+	handlers.RegisterAPIKeyRoutes(api, svc)
+	handlers.RegisterSyncRoutes(api, svc)
+	handlers.RegisterGuestRoutes(api, svc, issuer)
+	handlers.RegisterJobRoutes(api, svc)
+	handlers.RegisterFeatureFlagRoutes(api, svc)
+	handlers.RegisterLanguageRoutes(api, svc)
+
+	// Routes that change the deployment for every user are for admins only
+	admin := api.Group("")
+	admin.Use(handlers.RequireAdmin(svc))
+	handlers.RegisterSystemAdminRoutes(admin, svc)
+	handlers.RegisterBackupRoutes(admin, svc)
+	handlers.RegisterMaintenanceRoutes(admin, svc)
+	handlers.RegisterAdminRoutes(admin, svc)
+	handlers.RegisterGrammarAdminRoutes(admin, svc)
+	handlers.RegisterFeatureFlagAdminRoutes(admin, svc)
+	handlers.RegisterLanguageAdminRoutes(admin, svc)
+}
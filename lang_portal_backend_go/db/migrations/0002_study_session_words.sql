@@ -0,0 +1,15 @@
+-- Words attached to a study session. Review items are only written when a
+-- word is actually answered, so unanswered words are no longer stored as
+-- wrong answers.
+CREATE TABLE IF NOT EXISTS study_session_words (
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    PRIMARY KEY (study_session_id, word_id)
+);
+
+-- Sessions created before this migration kept their word list as
+-- pre-filled review items
+INSERT OR IGNORE INTO study_session_words (study_session_id, word_id)
+SELECT study_session_id, word_id FROM word_review_items;
@@ -0,0 +1,15 @@
+-- Spaced repetition (SM-2) schedule per word
+CREATE TABLE IF NOT EXISTS word_learning_state (
+    word_id INTEGER PRIMARY KEY,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    lapses INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME,
+    relearning_step INTEGER NOT NULL DEFAULT 0,
+    lapsed_interval_days INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_learning_state_due_at ON word_learning_state(due_at);
@@ -0,0 +1,2 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES 
+    (4, 'Daily Review', '/apps/daily-review', '/images/thumbnails/flashcards.svg', 'Review the words that are due today, mixing overdue, lapsed and new words.');
@@ -0,0 +1,6 @@
+-- Audio recordings linked to words, e.g. from an installed content pack
+CREATE TABLE IF NOT EXISTS word_audio (
+    word_id INTEGER PRIMARY KEY,
+    url TEXT NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
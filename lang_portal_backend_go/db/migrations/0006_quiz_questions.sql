@@ -0,0 +1,15 @@
+-- Questions served in quiz sessions, so answers are checked on the server
+CREATE TABLE IF NOT EXISTS quiz_questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    direction TEXT NOT NULL,
+    answer_mode TEXT NOT NULL DEFAULT 'multiple_choice',
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    hints_used INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    UNIQUE(study_session_id, word_id)
+);
@@ -0,0 +1,9 @@
+-- Precomputed embedding vectors per word, used to pick similar distractors
+CREATE TABLE IF NOT EXISTS word_embeddings (
+    word_id INTEGER PRIMARY KEY,
+    model TEXT NOT NULL,
+    dimensions INTEGER NOT NULL,
+    vector TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
@@ -0,0 +1,9 @@
+-- Answers submitted to quiz questions, used for scoring and review
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    quiz_question_id INTEGER PRIMARY KEY,
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    near_miss BOOLEAN NOT NULL DEFAULT 0,
+    answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (quiz_question_id) REFERENCES quiz_questions(id)
+);
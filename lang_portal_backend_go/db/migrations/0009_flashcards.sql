@@ -0,0 +1,16 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (5, 'Flashcards', '/apps/flashcards', '/images/thumbnails/flashcards.svg', 'Flip through the words of a group that are due and rate how well you knew them.');
+
+-- Cards of a flashcard deck, with how often they were flipped and how the
+-- learner rated their recall
+CREATE TABLE IF NOT EXISTS flashcards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    flips INTEGER NOT NULL DEFAULT 0,
+    rating TEXT,
+    rated_at DATETIME,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    UNIQUE(study_session_id, word_id)
+);
@@ -0,0 +1,38 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (6, 'Listening Practice', '/apps/listening-practice', '/images/thumbnails/listening.svg', 'Listen to a clip and answer questions about what you heard.');
+
+-- Audio clips for listening practice. Questions ask about the words of the
+-- clip's group that are heard in it.
+CREATE TABLE IF NOT EXISTS listening_clips (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    audio_url TEXT NOT NULL,
+    transcript TEXT NOT NULL,
+    difficulty TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+
+CREATE TABLE IF NOT EXISTS listening_questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    clip_id INTEGER NOT NULL,
+    word_id INTEGER,
+    prompt TEXT NOT NULL,
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    FOREIGN KEY (clip_id) REFERENCES listening_clips(id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+-- Answers given in listening practice sessions
+CREATE TABLE IF NOT EXISTS listening_answers (
+    study_session_id INTEGER NOT NULL,
+    question_id INTEGER NOT NULL,
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (study_session_id, question_id),
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (question_id) REFERENCES listening_questions(id)
+);
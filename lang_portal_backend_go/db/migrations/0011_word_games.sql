@@ -0,0 +1,19 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (7, 'Word Scramble', '/apps/word-scramble', '/images/thumbnails/vocabulary.svg', 'Unscramble the letters of the words you find hardest.'),
+    (8, 'Hangman', '/apps/hangman', '/images/thumbnails/vocabulary.svg', 'Guess the words you find hardest one letter at a time.');
+
+-- Rounds of word scramble and hangman games, one per word of a session
+CREATE TABLE IF NOT EXISTS word_game_rounds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    game TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    puzzle TEXT NOT NULL DEFAULT '',
+    guesses TEXT NOT NULL DEFAULT '[]',
+    wrong_guesses INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'playing',
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+    FOREIGN KEY (word_id) REFERENCES words(id),
+    UNIQUE(study_session_id, word_id)
+);
@@ -0,0 +1,38 @@
+-- Daily review stats rolled up from word_review_items by the nightly stats
+-- job. learned marks the first day a word was answered correctly.
+CREATE TABLE IF NOT EXISTS daily_word_stats (
+    day TEXT NOT NULL,
+    word_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    learned BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, word_id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_word_stats_word ON daily_word_stats(word_id, learned);
+
+CREATE TABLE IF NOT EXISTS daily_group_stats (
+    day TEXT NOT NULL,
+    group_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (group_id, day),
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+    day TEXT PRIMARY KEY,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL
+);
+
+-- Days before rolled_up_to have been rolled up
+CREATE TABLE IF NOT EXISTS stats_rollup_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    rolled_up_to TEXT NOT NULL
+);
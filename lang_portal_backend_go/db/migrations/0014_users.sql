@@ -0,0 +1,108 @@
+-- Users own study history. History recorded before users existed belongs to
+-- the default user.
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO users (id, username) VALUES (1, 'default');
+
+ALTER TABLE study_sessions ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE word_review_items ADD COLUMN user_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user ON study_sessions(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_user ON word_review_items(user_id, created_at);
+
+-- Learning state is kept per user and word
+ALTER TABLE word_learning_state RENAME TO word_learning_state_single_user;
+
+CREATE TABLE word_learning_state (
+    user_id INTEGER NOT NULL DEFAULT 1,
+    word_id INTEGER NOT NULL,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    lapses INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME,
+    relearning_step INTEGER NOT NULL DEFAULT 0,
+    lapsed_interval_days INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+INSERT INTO word_learning_state (user_id, word_id, ease_factor, interval_days, repetitions, lapses,
+    due_at, last_reviewed_at, relearning_step, lapsed_interval_days)
+SELECT 1, word_id, ease_factor, interval_days, repetitions, lapses,
+    due_at, last_reviewed_at, relearning_step, lapsed_interval_days
+FROM word_learning_state_single_user;
+
+DROP TABLE word_learning_state_single_user;
+
+CREATE INDEX IF NOT EXISTS idx_word_learning_state_due_at ON word_learning_state(user_id, due_at);
+
+-- Each user has at most one goal per kind
+ALTER TABLE goals RENAME TO goals_single_user;
+
+CREATE TABLE goals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL DEFAULT 1,
+    kind TEXT NOT NULL,
+    target REAL NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE(user_id, kind)
+);
+
+INSERT INTO goals (user_id, id, kind, target, created_at, updated_at)
+SELECT 1, id, kind, target, created_at, updated_at FROM goals_single_user;
+
+DROP TABLE goals_single_user;
+
+-- Rollups are kept per user. They are rebuilt from word_review_items by the
+-- next stats rollup.
+DROP TABLE daily_word_stats;
+DROP TABLE daily_group_stats;
+DROP TABLE daily_stats;
+DELETE FROM stats_rollup_state;
+
+CREATE TABLE daily_word_stats (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    word_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    learned BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (word_id) REFERENCES words(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_word_stats_word ON daily_word_stats(user_id, word_id, learned);
+
+CREATE TABLE daily_group_stats (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    group_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, group_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+
+CREATE TABLE daily_stats (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
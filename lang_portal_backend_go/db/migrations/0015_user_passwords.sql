@@ -0,0 +1,3 @@
+-- bcrypt hash of the password users log in with. Users without one, like
+-- the default user, can't log in with a password.
+ALTER TABLE users ADD COLUMN password_hash TEXT;
@@ -0,0 +1,4 @@
+-- Lower-cased email address, used to link Google logins to users
+ALTER TABLE users ADD COLUMN email TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
@@ -0,0 +1,5 @@
+-- Guests are users without a password that are purged, with their history,
+-- once they expire
+ALTER TABLE users ADD COLUMN guest_expires_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_guest_expires_at ON users(guest_expires_at);
@@ -0,0 +1,4 @@
+-- Users only appear on leaderboards once they opt in, under their username
+-- or a display name of their choosing
+ALTER TABLE users ADD COLUMN leaderboard_opt_in BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN leaderboard_name TEXT;
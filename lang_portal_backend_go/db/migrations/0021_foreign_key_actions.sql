@@ -0,0 +1,421 @@
+-- foreign_keys: off
+-- Explicit ON DELETE rules for every foreign key. SQLite can't change a
+-- foreign key in place, so each table is rebuilt, parents before children.
+-- Rows whose parent no longer exists are dropped while copying, and optional
+-- references to missing rows are cleared.
+--
+--   CASCADE   rows owned by the parent: a user's history, goals and stats,
+--             a session's answers, a word's audio and memberships, a
+--             group's clips and a class's students and assignments
+--   RESTRICT  study history referring to content: a word, group, activity
+--             or listening question can't be deleted while it has history
+--   SET NULL  optional references: a session's parent, a question's word
+
+CREATE TABLE study_sessions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    study_activity_id INTEGER NOT NULL,
+    difficulty TEXT,
+    parent_session_id INTEGER,
+    user_id INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT,
+    FOREIGN KEY (study_activity_id) REFERENCES study_activities(id) ON DELETE RESTRICT,
+    FOREIGN KEY (parent_session_id) REFERENCES study_sessions(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO study_sessions_new (id, group_id, created_at, study_activity_id, difficulty, parent_session_id, user_id)
+SELECT id, group_id, created_at, study_activity_id, difficulty, parent_session_id, user_id
+FROM study_sessions
+WHERE group_id IN (SELECT id FROM groups)
+  AND study_activity_id IN (SELECT id FROM study_activities)
+  AND user_id IN (SELECT id FROM users);
+DELETE FROM sqlite_sequence WHERE name = 'study_sessions_new';
+UPDATE sqlite_sequence SET name = 'study_sessions_new' WHERE name = 'study_sessions';
+DROP TABLE study_sessions;
+ALTER TABLE study_sessions_new RENAME TO study_sessions;
+UPDATE study_sessions SET parent_session_id = NULL
+WHERE parent_session_id IS NOT NULL AND parent_session_id NOT IN (SELECT id FROM study_sessions);
+CREATE INDEX idx_study_sessions_user ON study_sessions(user_id, created_at);
+CREATE INDEX idx_study_sessions_group ON study_sessions(group_id);
+
+CREATE TABLE classes_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    teacher_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    invite_code TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (teacher_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO classes_new (id, teacher_id, name, invite_code, created_at)
+SELECT id, teacher_id, name, invite_code, created_at
+FROM classes
+WHERE teacher_id IN (SELECT id FROM users);
+DELETE FROM sqlite_sequence WHERE name = 'classes_new';
+UPDATE sqlite_sequence SET name = 'classes_new' WHERE name = 'classes';
+DROP TABLE classes;
+ALTER TABLE classes_new RENAME TO classes;
+
+CREATE TABLE listening_clips_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    audio_url TEXT NOT NULL,
+    transcript TEXT NOT NULL,
+    difficulty TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO listening_clips_new (id, group_id, title, audio_url, transcript, difficulty, created_at)
+SELECT id, group_id, title, audio_url, transcript, difficulty, created_at
+FROM listening_clips
+WHERE group_id IN (SELECT id FROM groups);
+DELETE FROM sqlite_sequence WHERE name = 'listening_clips_new';
+UPDATE sqlite_sequence SET name = 'listening_clips_new' WHERE name = 'listening_clips';
+DROP TABLE listening_clips;
+ALTER TABLE listening_clips_new RENAME TO listening_clips;
+
+CREATE TABLE listening_questions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    clip_id INTEGER NOT NULL,
+    word_id INTEGER,
+    prompt TEXT NOT NULL,
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    FOREIGN KEY (clip_id) REFERENCES listening_clips(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE SET NULL
+);
+INSERT INTO listening_questions_new (id, clip_id, word_id, prompt, options, correct_answer)
+SELECT id, clip_id,
+       CASE WHEN word_id IN (SELECT id FROM words) THEN word_id END,
+       prompt, options, correct_answer
+FROM listening_questions
+WHERE clip_id IN (SELECT id FROM listening_clips);
+DELETE FROM sqlite_sequence WHERE name = 'listening_questions_new';
+UPDATE sqlite_sequence SET name = 'listening_questions_new' WHERE name = 'listening_questions';
+DROP TABLE listening_questions;
+ALTER TABLE listening_questions_new RENAME TO listening_questions;
+
+CREATE TABLE quiz_questions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    direction TEXT NOT NULL,
+    answer_mode TEXT NOT NULL DEFAULT 'multiple_choice',
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    hints_used INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    UNIQUE(study_session_id, word_id)
+);
+INSERT INTO quiz_questions_new (id, study_session_id, word_id, direction, answer_mode, options, correct_answer, hints_used, created_at)
+SELECT id, study_session_id, word_id, direction, answer_mode, options, correct_answer, hints_used, created_at
+FROM quiz_questions
+WHERE study_session_id IN (SELECT id FROM study_sessions)
+  AND word_id IN (SELECT id FROM words);
+DELETE FROM sqlite_sequence WHERE name = 'quiz_questions_new';
+UPDATE sqlite_sequence SET name = 'quiz_questions_new' WHERE name = 'quiz_questions';
+DROP TABLE quiz_questions;
+ALTER TABLE quiz_questions_new RENAME TO quiz_questions;
+
+CREATE TABLE quiz_answers_new (
+    quiz_question_id INTEGER PRIMARY KEY,
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    near_miss BOOLEAN NOT NULL DEFAULT 0,
+    answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (quiz_question_id) REFERENCES quiz_questions(id) ON DELETE CASCADE
+);
+INSERT INTO quiz_answers_new (quiz_question_id, answer, correct, near_miss, answered_at)
+SELECT quiz_question_id, answer, correct, near_miss, answered_at
+FROM quiz_answers
+WHERE quiz_question_id IN (SELECT id FROM quiz_questions);
+DROP TABLE quiz_answers;
+ALTER TABLE quiz_answers_new RENAME TO quiz_answers;
+
+CREATE TABLE words_groups_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO words_groups_new (id, word_id, group_id)
+SELECT id, word_id, group_id
+FROM words_groups
+WHERE word_id IN (SELECT id FROM words)
+  AND group_id IN (SELECT id FROM groups);
+DELETE FROM sqlite_sequence WHERE name = 'words_groups_new';
+UPDATE sqlite_sequence SET name = 'words_groups_new' WHERE name = 'words_groups';
+DROP TABLE words_groups;
+ALTER TABLE words_groups_new RENAME TO words_groups;
+CREATE INDEX idx_words_groups_group ON words_groups(group_id, word_id);
+CREATE INDEX idx_words_groups_word ON words_groups(word_id);
+
+CREATE TABLE word_review_items_new (
+    word_id INTEGER NOT NULL,
+    study_session_id INTEGER NOT NULL,
+    correct BOOLEAN NOT NULL,
+    near_miss BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(study_session_id, word_id)
+);
+INSERT INTO word_review_items_new (word_id, study_session_id, correct, near_miss, created_at, user_id)
+SELECT word_id, study_session_id, correct, near_miss, created_at, user_id
+FROM word_review_items
+WHERE word_id IN (SELECT id FROM words)
+  AND study_session_id IN (SELECT id FROM study_sessions)
+  AND user_id IN (SELECT id FROM users);
+DROP TABLE word_review_items;
+ALTER TABLE word_review_items_new RENAME TO word_review_items;
+CREATE INDEX idx_word_review_items_user ON word_review_items(user_id, created_at);
+CREATE INDEX idx_word_review_items_user_word ON word_review_items(user_id, word_id, correct);
+CREATE INDEX idx_word_review_items_word ON word_review_items(word_id);
+CREATE INDEX idx_word_review_items_session ON word_review_items(study_session_id);
+CREATE INDEX idx_word_review_items_created_at ON word_review_items(created_at);
+
+CREATE TABLE study_session_words_new (
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    PRIMARY KEY (study_session_id, word_id)
+);
+INSERT INTO study_session_words_new (study_session_id, word_id)
+SELECT study_session_id, word_id
+FROM study_session_words
+WHERE study_session_id IN (SELECT id FROM study_sessions)
+  AND word_id IN (SELECT id FROM words);
+DROP TABLE study_session_words;
+ALTER TABLE study_session_words_new RENAME TO study_session_words;
+
+CREATE TABLE word_audio_new (
+    word_id INTEGER PRIMARY KEY,
+    url TEXT NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+INSERT INTO word_audio_new (word_id, url)
+SELECT word_id, url
+FROM word_audio
+WHERE word_id IN (SELECT id FROM words);
+DROP TABLE word_audio;
+ALTER TABLE word_audio_new RENAME TO word_audio;
+
+CREATE TABLE word_embeddings_new (
+    word_id INTEGER PRIMARY KEY,
+    model TEXT NOT NULL,
+    dimensions INTEGER NOT NULL,
+    vector TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+INSERT INTO word_embeddings_new (word_id, model, dimensions, vector, updated_at)
+SELECT word_id, model, dimensions, vector, updated_at
+FROM word_embeddings
+WHERE word_id IN (SELECT id FROM words);
+DROP TABLE word_embeddings;
+ALTER TABLE word_embeddings_new RENAME TO word_embeddings;
+
+CREATE TABLE flashcards_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    flips INTEGER NOT NULL DEFAULT 0,
+    rating TEXT,
+    rated_at DATETIME,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    UNIQUE(study_session_id, word_id)
+);
+INSERT INTO flashcards_new (id, study_session_id, word_id, flips, rating, rated_at)
+SELECT id, study_session_id, word_id, flips, rating, rated_at
+FROM flashcards
+WHERE study_session_id IN (SELECT id FROM study_sessions)
+  AND word_id IN (SELECT id FROM words);
+DELETE FROM sqlite_sequence WHERE name = 'flashcards_new';
+UPDATE sqlite_sequence SET name = 'flashcards_new' WHERE name = 'flashcards';
+DROP TABLE flashcards;
+ALTER TABLE flashcards_new RENAME TO flashcards;
+
+CREATE TABLE listening_answers_new (
+    study_session_id INTEGER NOT NULL,
+    question_id INTEGER NOT NULL,
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (study_session_id, question_id),
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES listening_questions(id) ON DELETE RESTRICT
+);
+INSERT INTO listening_answers_new (study_session_id, question_id, answer, correct, answered_at)
+SELECT study_session_id, question_id, answer, correct, answered_at
+FROM listening_answers
+WHERE study_session_id IN (SELECT id FROM study_sessions)
+  AND question_id IN (SELECT id FROM listening_questions);
+DROP TABLE listening_answers;
+ALTER TABLE listening_answers_new RENAME TO listening_answers;
+
+CREATE TABLE word_game_rounds_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    game TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    puzzle TEXT NOT NULL DEFAULT '',
+    guesses TEXT NOT NULL DEFAULT '[]',
+    wrong_guesses INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'playing',
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    UNIQUE(study_session_id, word_id)
+);
+INSERT INTO word_game_rounds_new (id, study_session_id, word_id, game, answer, puzzle, guesses, wrong_guesses, status)
+SELECT id, study_session_id, word_id, game, answer, puzzle, guesses, wrong_guesses, status
+FROM word_game_rounds
+WHERE study_session_id IN (SELECT id FROM study_sessions)
+  AND word_id IN (SELECT id FROM words);
+DELETE FROM sqlite_sequence WHERE name = 'word_game_rounds_new';
+UPDATE sqlite_sequence SET name = 'word_game_rounds_new' WHERE name = 'word_game_rounds';
+DROP TABLE word_game_rounds;
+ALTER TABLE word_game_rounds_new RENAME TO word_game_rounds;
+
+CREATE TABLE word_learning_state_new (
+    user_id INTEGER NOT NULL DEFAULT 1,
+    word_id INTEGER NOT NULL,
+    ease_factor REAL NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    lapses INTEGER NOT NULL DEFAULT 0,
+    due_at DATETIME NOT NULL,
+    last_reviewed_at DATETIME,
+    relearning_step INTEGER NOT NULL DEFAULT 0,
+    lapsed_interval_days INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+INSERT INTO word_learning_state_new (user_id, word_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, relearning_step, lapsed_interval_days)
+SELECT user_id, word_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, relearning_step, lapsed_interval_days
+FROM word_learning_state
+WHERE user_id IN (SELECT id FROM users)
+  AND word_id IN (SELECT id FROM words);
+DROP TABLE word_learning_state;
+ALTER TABLE word_learning_state_new RENAME TO word_learning_state;
+CREATE INDEX idx_word_learning_state_due_at ON word_learning_state(user_id, due_at);
+
+CREATE TABLE goals_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL DEFAULT 1,
+    kind TEXT NOT NULL,
+    target REAL NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, kind)
+);
+INSERT INTO goals_new (id, user_id, kind, target, created_at, updated_at)
+SELECT id, user_id, kind, target, created_at, updated_at
+FROM goals
+WHERE user_id IN (SELECT id FROM users);
+DELETE FROM sqlite_sequence WHERE name = 'goals_new';
+UPDATE sqlite_sequence SET name = 'goals_new' WHERE name = 'goals';
+DROP TABLE goals;
+ALTER TABLE goals_new RENAME TO goals;
+
+CREATE TABLE daily_word_stats_new (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    word_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    learned BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, word_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+INSERT INTO daily_word_stats_new (user_id, day, word_id, reviews, correct, learned)
+SELECT user_id, day, word_id, reviews, correct, learned
+FROM daily_word_stats
+WHERE user_id IN (SELECT id FROM users)
+  AND word_id IN (SELECT id FROM words);
+DROP TABLE daily_word_stats;
+ALTER TABLE daily_word_stats_new RENAME TO daily_word_stats;
+CREATE INDEX idx_daily_word_stats_word ON daily_word_stats(user_id, word_id, learned);
+
+CREATE TABLE daily_group_stats_new (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    group_id INTEGER NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, group_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO daily_group_stats_new (user_id, day, group_id, reviews, correct, words_reviewed, new_words_learned)
+SELECT user_id, day, group_id, reviews, correct, words_reviewed, new_words_learned
+FROM daily_group_stats
+WHERE user_id IN (SELECT id FROM users)
+  AND group_id IN (SELECT id FROM groups);
+DROP TABLE daily_group_stats;
+ALTER TABLE daily_group_stats_new RENAME TO daily_group_stats;
+
+CREATE TABLE daily_stats_new (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO daily_stats_new (user_id, day, reviews, correct, words_reviewed, new_words_learned)
+SELECT user_id, day, reviews, correct, words_reviewed, new_words_learned
+FROM daily_stats
+WHERE user_id IN (SELECT id FROM users);
+DROP TABLE daily_stats;
+ALTER TABLE daily_stats_new RENAME TO daily_stats;
+
+CREATE TABLE class_students_new (
+    class_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (class_id, user_id),
+    FOREIGN KEY (class_id) REFERENCES classes(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO class_students_new (class_id, user_id, joined_at)
+SELECT class_id, user_id, joined_at
+FROM class_students
+WHERE class_id IN (SELECT id FROM classes)
+  AND user_id IN (SELECT id FROM users);
+DROP TABLE class_students;
+ALTER TABLE class_students_new RENAME TO class_students;
+
+CREATE TABLE class_assignments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    class_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    due_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (class_id) REFERENCES classes(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+INSERT INTO class_assignments_new (id, class_id, group_id, due_at, created_at)
+SELECT id, class_id, group_id, due_at, created_at
+FROM class_assignments
+WHERE class_id IN (SELECT id FROM classes)
+  AND group_id IN (SELECT id FROM groups);
+DELETE FROM sqlite_sequence WHERE name = 'class_assignments_new';
+UPDATE sqlite_sequence SET name = 'class_assignments_new' WHERE name = 'class_assignments';
+DROP TABLE class_assignments;
+ALTER TABLE class_assignments_new RENAME TO class_assignments;
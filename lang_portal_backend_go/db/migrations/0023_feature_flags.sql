@@ -0,0 +1,17 @@
+-- Feature flags turning experimental features on for the whole deployment,
+-- or for some users only. A user's override wins over the flag's enabled.
+CREATE TABLE IF NOT EXISTS feature_flags (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS feature_flag_users (
+    flag_name TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (flag_name, user_id),
+    FOREIGN KEY (flag_name) REFERENCES feature_flags(name) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,20 @@
+-- Vocabulary groups a language model wrote, kept for review until they are
+-- approved. words and dropped are JSON arrays; group_id is the group created
+-- on approval.
+CREATE TABLE IF NOT EXISTS group_drafts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    topic TEXT NOT NULL,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    provider TEXT NOT NULL,
+    words TEXT NOT NULL,
+    dropped TEXT NOT NULL DEFAULT '[]',
+    group_id INTEGER,
+    created_at DATETIME NOT NULL,
+    approved_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_drafts_user_id ON group_drafts(user_id);
@@ -0,0 +1,12 @@
+-- Word suggestions of translation providers, so a term is only sent to a
+-- provider once. term is the normalized text of the given language field.
+CREATE TABLE IF NOT EXISTS translation_cache (
+    provider TEXT NOT NULL,
+    language TEXT NOT NULL,
+    term TEXT NOT NULL,
+    urdu TEXT NOT NULL,
+    urdlish TEXT NOT NULL,
+    english TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (provider, language, term)
+);
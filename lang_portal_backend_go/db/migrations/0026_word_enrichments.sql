@@ -0,0 +1,22 @@
+-- What a dictionary says about words. A word looked up without a result
+-- still has an enrichment, with no definitions, so it isn't looked up
+-- again. plurals and examples are JSON arrays.
+CREATE TABLE IF NOT EXISTS word_enrichments (
+    word_id INTEGER PRIMARY KEY,
+    provider TEXT NOT NULL,
+    plurals TEXT NOT NULL DEFAULT '[]',
+    enriched_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS word_definitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    part_of_speech TEXT NOT NULL DEFAULT '',
+    definition TEXT NOT NULL,
+    examples TEXT NOT NULL DEFAULT '[]',
+    FOREIGN KEY (word_id) REFERENCES word_enrichments(word_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_definitions_word_id ON word_definitions(word_id, position);
@@ -0,0 +1,19 @@
+-- Groups kept in step with a shared spreadsheet or CSV file. A sync with an
+-- interval runs again at next_sync_at; last_report is the JSON report of
+-- the last run.
+CREATE TABLE IF NOT EXISTS group_syncs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    interval_minutes INTEGER NOT NULL DEFAULT 0,
+    next_sync_at DATETIME,
+    last_synced_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_report TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_syncs_next_sync_at ON group_syncs(next_sync_at);
@@ -0,0 +1,20 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (9, 'Pronunciation', '/apps/pronunciation', '/images/thumbnails/listening.svg', 'Say each word aloud and see how close you came.');
+
+-- Recordings of words said in pronunciation sessions, as transcribed by a
+-- speech-to-text provider. score is how alike the transcript and the word's
+-- Urdu are, from 0 to 1.
+CREATE TABLE IF NOT EXISTS pronunciation_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    provider TEXT NOT NULL,
+    transcript TEXT NOT NULL,
+    score REAL NOT NULL,
+    result TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pronunciation_attempts_session ON pronunciation_attempts(study_session_id, word_id);
@@ -0,0 +1,34 @@
+-- What a user wants to be notified of, and the address to email them at
+-- when it isn't their account's email. Users without a row get nothing.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY,
+    email TEXT,
+    streak_reminders BOOLEAN NOT NULL DEFAULT 0,
+    weekly_digest BOOLEAN NOT NULL DEFAULT 0,
+    homework_reminders BOOLEAN NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Every notification sent or attempted. dedupe_key names what it is about,
+-- e.g. the day of a streak reminder or the assignment of a homework
+-- reminder, so each is sent once per channel. Failed sends are tried again
+-- by later dispatches, up to a few attempts.
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    dedupe_key TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    sent_at DATETIME,
+    UNIQUE (user_id, kind, channel, dedupe_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user ON notification_deliveries(user_id, created_at);
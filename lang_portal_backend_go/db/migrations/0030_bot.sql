@@ -0,0 +1,43 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (10, 'Chat Quiz', '/apps/chat-quiz', '/images/thumbnails/vocabulary.svg', 'Answer a question a day on a word that is due, in Telegram or Discord.');
+
+-- The Telegram or Discord chat a user is sent a daily quiz question in, from
+-- quiz_hour (UTC). A link is used once the user has entered the code the bot
+-- sent to the chat.
+CREATE TABLE IF NOT EXISTS bot_links (
+    user_id INTEGER PRIMARY KEY,
+    platform TEXT NOT NULL,
+    chat_id TEXT NOT NULL,
+    quiz_hour INTEGER NOT NULL DEFAULT 9,
+    code TEXT,
+    code_expires_at DATETIME,
+    code_attempts INTEGER NOT NULL DEFAULT 0,
+    verified_at DATETIME,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- A chat answers for one user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bot_links_chat ON bot_links(platform, chat_id) WHERE verified_at IS NOT NULL;
+
+-- The quiz question sent to a user's chat each day. options holds the
+-- English offered, as a JSON array, and answer the index of the right one.
+-- The answer is recorded as a review in a Chat Quiz session on the group.
+CREATE TABLE IF NOT EXISTS bot_questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    group_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    options TEXT NOT NULL,
+    answer INTEGER NOT NULL,
+    chosen INTEGER,
+    study_session_id INTEGER,
+    created_at DATETIME NOT NULL,
+    answered_at DATETIME,
+    UNIQUE (user_id, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE SET NULL
+);
@@ -0,0 +1,16 @@
+-- The browsers a user gets Web Push notifications in, with the keys they're
+-- encrypted for and the notifications each wants. An endpoint belongs to the
+-- user who subscribed it last.
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL,
+    auth TEXT NOT NULL,
+    reviews_due BOOLEAN NOT NULL DEFAULT 1,
+    streak_at_risk BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions(user_id);
@@ -0,0 +1,13 @@
+-- Pictures of words, kept in the media storage as images/<name> with a JPEG
+-- thumbnail as images/<thumbnail>. Files are named after their content, so
+-- words with the same picture share them.
+CREATE TABLE IF NOT EXISTS word_images (
+    word_id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    thumbnail TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+-- The secret a user's calendar feed is fetched with. Calendar apps can't
+-- send access tokens, so the feed's URL carries this one instead. Only its
+-- SHA-256 hash is kept.
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,107 @@
+-- Change tracking for offline clients. Words, groups, study sessions and
+-- reviews record when they last changed, as UTC text that compares in order,
+-- and deleted words, groups and sessions leave a tombstone. Triggers keep
+-- both up to date whichever code writes the tables. A group changes when
+-- its words do.
+ALTER TABLE words ADD COLUMN updated_at DATETIME;
+ALTER TABLE groups ADD COLUMN updated_at DATETIME;
+ALTER TABLE study_sessions ADD COLUMN updated_at DATETIME;
+ALTER TABLE word_review_items ADD COLUMN updated_at DATETIME;
+
+-- The ID an offline client gave a session it created, so pushing the
+-- session again doesn't create it twice
+ALTER TABLE study_sessions ADD COLUMN client_id TEXT;
+
+UPDATE words SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now');
+UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now');
+UPDATE study_sessions SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now');
+UPDATE word_review_items SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now');
+
+CREATE INDEX IF NOT EXISTS idx_words_updated_at ON words(updated_at);
+CREATE INDEX IF NOT EXISTS idx_groups_updated_at ON groups(updated_at);
+CREATE INDEX IF NOT EXISTS idx_study_sessions_updated_at ON study_sessions(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_updated_at ON word_review_items(user_id, updated_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_study_sessions_client_id ON study_sessions(user_id, client_id);
+
+-- Deleted words, groups and sessions. user_id is set for sessions, which
+-- only their user syncs.
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    user_id INTEGER,
+    deleted_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_deleted_at ON sync_tombstones(deleted_at);
+
+CREATE TRIGGER IF NOT EXISTS words_sync_insert AFTER INSERT ON words
+BEGIN
+    UPDATE words SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_sync_update AFTER UPDATE OF urdu, urdlish, english, parts ON words
+BEGIN
+    UPDATE words SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_sync_delete AFTER DELETE ON words
+BEGIN
+    INSERT INTO sync_tombstones (entity, entity_id, deleted_at)
+    VALUES ('word', OLD.id, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_insert AFTER INSERT ON groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_update AFTER UPDATE OF name, word_count ON groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_delete AFTER DELETE ON groups
+BEGIN
+    INSERT INTO sync_tombstones (entity, entity_id, deleted_at)
+    VALUES ('group', OLD.id, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_groups_sync_insert AFTER INSERT ON words_groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.group_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_groups_sync_delete AFTER DELETE ON words_groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = OLD.group_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS study_sessions_sync_insert AFTER INSERT ON study_sessions
+BEGIN
+    UPDATE study_sessions SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS study_sessions_sync_update
+AFTER UPDATE OF group_id, study_activity_id, created_at, difficulty, parent_session_id, user_id ON study_sessions
+BEGIN
+    UPDATE study_sessions SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS study_sessions_sync_delete AFTER DELETE ON study_sessions
+BEGIN
+    INSERT INTO sync_tombstones (entity, entity_id, user_id, deleted_at)
+    VALUES ('session', OLD.id, OLD.user_id, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS word_review_items_sync_insert AFTER INSERT ON word_review_items
+BEGIN
+    UPDATE word_review_items SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+    WHERE study_session_id = NEW.study_session_id AND word_id = NEW.word_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS word_review_items_sync_update
+AFTER UPDATE OF correct, near_miss, created_at ON word_review_items
+BEGIN
+    UPDATE word_review_items SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+    WHERE study_session_id = NEW.study_session_id AND word_id = NEW.word_id;
+END;
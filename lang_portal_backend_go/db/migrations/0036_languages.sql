@@ -0,0 +1,82 @@
+-- foreign_keys: off
+-- Languages other than Urdu. Each word and group belongs to a language, and
+-- a word's urdu and urdlish columns become its script and transliteration,
+-- whatever the language. Group names are unique within a language, so the
+-- groups table is rebuilt. The sync triggers of migration 0035 on groups go
+-- with the old table and are created again, and those on words_groups are
+-- dropped meanwhile, as SQLite won't rename a table while a trigger refers
+-- to one that is missing.
+
+-- script is an ISO 15924 code, direction "ltr" or "rtl", and transliteration
+-- names the romanization of the language's transliteration
+CREATE TABLE IF NOT EXISTS languages (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    script TEXT NOT NULL,
+    direction TEXT NOT NULL DEFAULT 'ltr',
+    transliteration TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO languages (code, name, script, direction, transliteration) VALUES
+    ('ur', 'Urdu', 'Arab', 'rtl', 'Roman Urdu'),
+    ('ar', 'Arabic', 'Arab', 'rtl', 'Arabic romanization'),
+    ('ja', 'Japanese', 'Jpan', 'ltr', 'Romaji');
+
+ALTER TABLE words RENAME COLUMN urdu TO script;
+ALTER TABLE words RENAME COLUMN urdlish TO transliteration;
+ALTER TABLE words ADD COLUMN language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code);
+CREATE INDEX IF NOT EXISTS idx_words_language ON words(language);
+
+DROP TRIGGER IF EXISTS words_sync_update;
+CREATE TRIGGER IF NOT EXISTS words_sync_update
+AFTER UPDATE OF script, transliteration, english, parts, language ON words
+BEGIN
+    UPDATE words SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS words_groups_sync_insert;
+DROP TRIGGER IF EXISTS words_groups_sync_delete;
+
+CREATE TABLE groups_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    word_count INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME,
+    language TEXT NOT NULL DEFAULT 'ur',
+    FOREIGN KEY (language) REFERENCES languages(code),
+    UNIQUE(language, name)
+);
+INSERT INTO groups_new (id, name, word_count, updated_at)
+SELECT id, name, word_count, updated_at FROM groups;
+DELETE FROM sqlite_sequence WHERE name = 'groups_new';
+UPDATE sqlite_sequence SET name = 'groups_new' WHERE name = 'groups';
+DROP TABLE groups;
+ALTER TABLE groups_new RENAME TO groups;
+CREATE INDEX IF NOT EXISTS idx_groups_updated_at ON groups(updated_at);
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_insert AFTER INSERT ON groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_update AFTER UPDATE OF name, word_count, language ON groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_sync_delete AFTER DELETE ON groups
+BEGIN
+    INSERT INTO sync_tombstones (entity, entity_id, deleted_at)
+    VALUES ('group', OLD.id, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_groups_sync_insert AFTER INSERT ON words_groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.group_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS words_groups_sync_delete AFTER DELETE ON words_groups
+BEGIN
+    UPDATE groups SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = OLD.group_id;
+END;
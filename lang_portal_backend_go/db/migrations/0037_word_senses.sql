@@ -0,0 +1,36 @@
+-- The English meanings of words. A word's english is its first sense, kept
+-- on the word for the lists, searches and games that show one meaning, and
+-- the triggers keep the two in step however words are created or changed.
+-- register is how formal the sense is, e.g. formal or colloquial, and
+-- usage_note says when it is used; both are empty when not given.
+CREATE TABLE IF NOT EXISTS word_senses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    english TEXT NOT NULL,
+    register TEXT NOT NULL DEFAULT '',
+    usage_note TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    UNIQUE(word_id, position)
+);
+
+INSERT INTO word_senses (word_id, position, english)
+SELECT id, 0, english FROM words
+WHERE english != '' AND id NOT IN (SELECT word_id FROM word_senses);
+
+CREATE TRIGGER IF NOT EXISTS word_senses_word_insert
+AFTER INSERT ON words WHEN NEW.english != ''
+BEGIN
+    INSERT INTO word_senses (word_id, position, english) VALUES (NEW.id, 0, NEW.english);
+END;
+
+CREATE TRIGGER IF NOT EXISTS word_senses_word_update
+AFTER UPDATE OF english ON words WHEN NEW.english != ''
+BEGIN
+    UPDATE word_senses SET english = NEW.english
+    WHERE word_id = NEW.id
+      AND position = (SELECT MIN(position) FROM word_senses WHERE word_id = NEW.id);
+    INSERT INTO word_senses (word_id, position, english)
+    SELECT NEW.id, 0, NEW.english
+    WHERE NOT EXISTS (SELECT 1 FROM word_senses WHERE word_id = NEW.id);
+END;
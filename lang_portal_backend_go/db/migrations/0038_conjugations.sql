@@ -0,0 +1,37 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (11, 'Conjugation Drill', '/apps/conjugation-drill', '/images/thumbnails/vocabulary.svg', 'Write the form of a verb for a tense, person and gender.');
+
+-- The forms of verbs. form is written in the verb's script and
+-- transliteration in its romanization. person and gender are empty for
+-- forms that don't vary by them, e.g. infinitives.
+CREATE TABLE IF NOT EXISTS conjugations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    word_id INTEGER NOT NULL,
+    tense TEXT NOT NULL,
+    person TEXT NOT NULL DEFAULT '',
+    gender TEXT NOT NULL DEFAULT '',
+    form TEXT NOT NULL,
+    transliteration TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    UNIQUE(word_id, tense, person, gender)
+);
+
+-- Rounds of conjugation drills, one per verb of a session. The form asked
+-- for is copied, so editing a verb's conjugations doesn't change drills
+-- already played. result is unanswered until the round is answered.
+CREATE TABLE IF NOT EXISTS conjugation_drill_rounds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    tense TEXT NOT NULL,
+    person TEXT NOT NULL,
+    gender TEXT NOT NULL,
+    form TEXT NOT NULL,
+    transliteration TEXT NOT NULL,
+    answer TEXT,
+    result TEXT NOT NULL DEFAULT 'unanswered',
+    answered_at DATETIME,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    UNIQUE(study_session_id, word_id)
+);
@@ -0,0 +1,29 @@
+-- Example sentences. normalized is the sentence's tokens as the corpus
+-- package compares them, padded with a space either side, so a phrase is
+-- searched for with LIKE '% phrase %'. transliteration, english and source
+-- are empty when not given.
+CREATE TABLE IF NOT EXISTS sentences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    language TEXT NOT NULL DEFAULT 'ur',
+    text TEXT NOT NULL,
+    transliteration TEXT NOT NULL DEFAULT '',
+    english TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    normalized TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (language) REFERENCES languages(code),
+    UNIQUE(language, text)
+);
+
+-- Where words occur in sentences. position and length are in runes of the
+-- sentence's text, and a word occurring twice has two rows.
+CREATE TABLE IF NOT EXISTS sentence_words (
+    sentence_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    length INTEGER NOT NULL,
+    PRIMARY KEY (sentence_id, word_id, position),
+    FOREIGN KEY (sentence_id) REFERENCES sentences(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_sentence_words_word ON sentence_words(word_id);
@@ -0,0 +1,26 @@
+INSERT OR REPLACE INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (12, 'Cloze', '/apps/cloze', '/images/thumbnails/sentences.svg', 'Fill in the word missing from a sentence.');
+
+-- Questions of cloze sessions, one per word of a session, each a sentence
+-- of the corpus with the word blanked out. The prompt, the sentence's
+-- English and the text blanked are copied, so changing the corpus doesn't
+-- change questions already asked. options is a JSON array, empty for typed
+-- questions, and result is unanswered until the question is answered.
+CREATE TABLE IF NOT EXISTS cloze_questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    study_session_id INTEGER NOT NULL,
+    word_id INTEGER NOT NULL,
+    sentence_id INTEGER,
+    prompt TEXT NOT NULL,
+    english TEXT NOT NULL DEFAULT '',
+    answer_mode TEXT NOT NULL,
+    options TEXT NOT NULL DEFAULT '[]',
+    correct_answer TEXT NOT NULL,
+    answer TEXT,
+    result TEXT NOT NULL DEFAULT 'unanswered',
+    answered_at DATETIME,
+    FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    FOREIGN KEY (sentence_id) REFERENCES sentences(id) ON DELETE SET NULL,
+    UNIQUE(study_session_id, word_id)
+);
@@ -0,0 +1,16 @@
+-- Graded reading passages. level is a CEFR level, A1 to C2, and word_count
+-- the number of words of the text, counted when it is stored. Passages are
+-- segmented into words as they are read, so words added to the vocabulary
+-- since are found.
+CREATE TABLE IF NOT EXISTS passages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    language TEXT NOT NULL DEFAULT 'ur',
+    title TEXT NOT NULL,
+    level TEXT NOT NULL,
+    text TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    word_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (language) REFERENCES languages(code)
+);
+CREATE INDEX IF NOT EXISTS idx_passages_language ON passages(language, level);
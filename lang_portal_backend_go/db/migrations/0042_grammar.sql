@@ -0,0 +1,63 @@
+-- Grammar topics, each a lesson on a point of grammar of a language. slug
+-- names a topic within its language, so importing topics again updates
+-- them, and position orders the topics of a level as a course would. lesson
+-- is the lesson's text, in Markdown.
+CREATE TABLE IF NOT EXISTS grammar_topics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    language TEXT NOT NULL DEFAULT 'ur',
+    slug TEXT NOT NULL,
+    title TEXT NOT NULL,
+    level TEXT NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    lesson TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (language) REFERENCES languages(code),
+    UNIQUE(language, slug)
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_topics_language ON grammar_topics(language, level, position);
+
+-- Exercises practising grammar topics. options is a JSON array of the
+-- choices of a multiple choice exercise, the answer among them, and empty
+-- for typed exercises. explanation is shown once an exercise is answered.
+CREATE TABLE IF NOT EXISTS grammar_exercises (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    topic_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    answer_mode TEXT NOT NULL,
+    prompt TEXT NOT NULL,
+    english TEXT NOT NULL DEFAULT '',
+    options TEXT NOT NULL DEFAULT '[]',
+    answer TEXT NOT NULL,
+    explanation TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (topic_id) REFERENCES grammar_topics(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_exercises_topic ON grammar_exercises(topic_id, position);
+
+-- The grammar topics each user has started, by reading the lesson or
+-- answering an exercise, and when they first completed them
+CREATE TABLE IF NOT EXISTS grammar_progress (
+    user_id INTEGER NOT NULL,
+    topic_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME,
+    PRIMARY KEY (user_id, topic_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (topic_id) REFERENCES grammar_topics(id) ON DELETE CASCADE
+);
+
+-- Every answer to grammar exercises. Exercises can be answered again, and
+-- one is solved once it has been answered correctly or as a near miss.
+CREATE TABLE IF NOT EXISTS grammar_answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    exercise_id INTEGER NOT NULL,
+    answer TEXT NOT NULL,
+    result TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES grammar_exercises(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_answers_user ON grammar_answers(user_id, exercise_id);
@@ -0,0 +1,14 @@
+-- Hints for rendering words in their script. font_style is the style a word
+-- is best shown in, nastaleeq or naskh, or empty for the usual one of its
+-- language; diacritized is the word with its short vowels and other marks
+-- written out. ligature_spellings is a JSON array of spellings of the word
+-- whose letters must be joined as they are written, e.g. with zero-width
+-- non-joiners, so clients show them as given rather than normalized.
+CREATE TABLE IF NOT EXISTS word_renderings (
+    word_id INTEGER PRIMARY KEY,
+    font_style TEXT NOT NULL DEFAULT '',
+    diacritized TEXT NOT NULL DEFAULT '',
+    ligature_spellings TEXT NOT NULL DEFAULT '[]',
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+-- Keys sorting words by script and groups by name in Urdu alphabetical
+-- order. sort_key is the hex of a collation key, so comparing keys as text
+-- orders them as their texts; script and name are what the key was made
+-- from, so a key whose word or group has since been renamed is made anew.
+CREATE TABLE IF NOT EXISTS word_sort_keys (
+    word_id INTEGER PRIMARY KEY,
+    script TEXT NOT NULL,
+    sort_key TEXT NOT NULL,
+    FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_sort_keys_sort_key ON word_sort_keys(sort_key);
+
+CREATE TABLE IF NOT EXISTS group_sort_keys (
+    group_id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    sort_key TEXT NOT NULL,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_sort_keys_sort_key ON group_sort_keys(sort_key);
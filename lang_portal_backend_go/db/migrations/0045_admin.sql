@@ -0,0 +1,18 @@
+-- A user's role: learner, or admin for the users who can see the admin
+-- dashboard and change roles. The default user, who owns the history of
+-- the deployment, is its first admin.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'learner';
+
+UPDATE users SET role = 'admin' WHERE id = 1;
+
+-- The seed packs and content packs applied, with the version of each
+-- applied last. source is seed_pack for the packs of the seed directory,
+-- whose version is a checksum of their file, or content_pack for the packs
+-- installed on bootstrap, whose version is the one they give.
+CREATE TABLE IF NOT EXISTS seed_versions (
+    source TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, name)
+);
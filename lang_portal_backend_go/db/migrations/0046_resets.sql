@@ -0,0 +1,14 @@
+-- The resets done, each archived first to the backup named by backup.
+-- kind is history for a user's study history or full for the whole
+-- catalog. restored_at is set once the archive is put back. Full resets
+-- keep this table, so the log outlives them.
+CREATE TABLE IF NOT EXISTS resets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    backup TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    restored_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_resets_user ON resets(user_id, created_at);
@@ -0,0 +1,9 @@
+-- When a user's email address was verified. Google logins link only to
+-- users whose address is verified, so an address given at registration,
+-- which nobody checked, can't be used to take over the Google account's
+-- owner. Users created by a Google login, who have no password, got their
+-- address from Google, which verified it.
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP
+WHERE email IS NOT NULL AND password_hash IS NULL;
@@ -0,0 +1,10 @@
+-- Reviews are upserted on a session's word. Keep only the newest review of
+-- a word in a session, as an upsert would have, and make sure the
+-- constraint the upsert relies on is there.
+DELETE FROM word_review_items
+WHERE rowid NOT IN (
+    SELECT MAX(rowid) FROM word_review_items GROUP BY study_session_id, word_id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_word_review_items_session_word
+ON word_review_items(study_session_id, word_id);
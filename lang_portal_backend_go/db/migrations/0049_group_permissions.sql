@@ -0,0 +1,15 @@
+-- The roles users have on groups. Owners change a group and grant roles on
+-- it, editors change it and viewers only study it. A group with an owner
+-- is seen only by the users with a role on it; groups nobody owns, like
+-- the seeded ones, are seen by everyone and changed only by admins.
+CREATE TABLE IF NOT EXISTS group_permissions (
+    group_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, user_id),
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_permissions_user ON group_permissions(user_id);
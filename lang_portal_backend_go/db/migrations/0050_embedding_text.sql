@@ -0,0 +1,4 @@
+-- The text each embedding was computed from, so embeddings whose word has
+-- changed since can be told apart and computed again. Embeddings stored
+-- before this are of unknown text, which no word has.
+ALTER TABLE word_embeddings ADD COLUMN source_text TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations that build the database
+// schema. Files are named <version>_<name>.sql and applied in version order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,276 @@
+-- The schema of SQLite migrations 0001 to 0019. Timestamps are stored in
+-- UTC.
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    password_hash TEXT,
+    email TEXT,
+    guest_expires_at TIMESTAMPTZ,
+    leaderboard_opt_in BOOLEAN NOT NULL DEFAULT FALSE,
+    leaderboard_name TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_guest_expires_at ON users(guest_expires_at);
+
+CREATE TABLE IF NOT EXISTS words (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    urdu TEXT NOT NULL,
+    urdlish TEXT NOT NULL,
+    english TEXT NOT NULL,
+    parts TEXT
+);
+
+CREATE TABLE IF NOT EXISTS study_activities (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    url TEXT,
+    thumbnail_url TEXT,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    word_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS words_groups (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    group_id BIGINT NOT NULL REFERENCES groups(id)
+);
+
+CREATE TABLE IF NOT EXISTS study_sessions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    group_id BIGINT NOT NULL REFERENCES groups(id),
+    created_at TIMESTAMPTZ NOT NULL,
+    study_activity_id BIGINT NOT NULL REFERENCES study_activities(id),
+    difficulty TEXT,
+    parent_session_id BIGINT REFERENCES study_sessions(id),
+    user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_study_sessions_user ON study_sessions(user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS word_review_items (
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    correct BOOLEAN NOT NULL,
+    near_miss BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id),
+    UNIQUE(study_session_id, word_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_review_items_user ON word_review_items(user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS study_session_words (
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    PRIMARY KEY (study_session_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS word_learning_state (
+    user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id),
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    ease_factor DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    lapses INTEGER NOT NULL DEFAULT 0,
+    due_at TIMESTAMPTZ NOT NULL,
+    last_reviewed_at TIMESTAMPTZ,
+    relearning_step INTEGER NOT NULL DEFAULT 0,
+    lapsed_interval_days INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, word_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_learning_state_due_at ON word_learning_state(user_id, due_at);
+
+CREATE TABLE IF NOT EXISTS word_audio (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id),
+    url TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS quiz_questions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    direction TEXT NOT NULL,
+    answer_mode TEXT NOT NULL DEFAULT 'multiple_choice',
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    hints_used INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(study_session_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS word_embeddings (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id),
+    model TEXT NOT NULL,
+    dimensions INTEGER NOT NULL,
+    vector TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    quiz_question_id BIGINT PRIMARY KEY REFERENCES quiz_questions(id),
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    near_miss BOOLEAN NOT NULL DEFAULT FALSE,
+    answered_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS flashcards (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    flips INTEGER NOT NULL DEFAULT 0,
+    rating TEXT,
+    rated_at TIMESTAMPTZ,
+    UNIQUE(study_session_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS listening_clips (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    group_id BIGINT NOT NULL REFERENCES groups(id),
+    title TEXT NOT NULL,
+    audio_url TEXT NOT NULL,
+    transcript TEXT NOT NULL,
+    difficulty TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS listening_questions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    clip_id BIGINT NOT NULL REFERENCES listening_clips(id),
+    word_id BIGINT REFERENCES words(id),
+    prompt TEXT NOT NULL,
+    options TEXT NOT NULL,
+    correct_answer TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS listening_answers (
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    question_id BIGINT NOT NULL REFERENCES listening_questions(id),
+    answer TEXT NOT NULL,
+    correct BOOLEAN NOT NULL,
+    answered_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (study_session_id, question_id)
+);
+
+CREATE TABLE IF NOT EXISTS word_game_rounds (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id),
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    game TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    puzzle TEXT NOT NULL DEFAULT '',
+    guesses TEXT NOT NULL DEFAULT '[]',
+    wrong_guesses INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'playing',
+    UNIQUE(study_session_id, word_id)
+);
+
+CREATE TABLE IF NOT EXISTS goals (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL DEFAULT 1 REFERENCES users(id),
+    kind TEXT NOT NULL,
+    target DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, kind)
+);
+
+CREATE TABLE IF NOT EXISTS daily_word_stats (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    day TEXT NOT NULL,
+    word_id BIGINT NOT NULL REFERENCES words(id),
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    learned BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, day, word_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_word_stats_word ON daily_word_stats(user_id, word_id, learned);
+
+CREATE TABLE IF NOT EXISTS daily_group_stats (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    day TEXT NOT NULL,
+    group_id BIGINT NOT NULL REFERENCES groups(id),
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, group_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    day TEXT NOT NULL,
+    reviews INTEGER NOT NULL,
+    correct INTEGER NOT NULL,
+    words_reviewed INTEGER NOT NULL,
+    new_words_learned INTEGER NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
+
+-- Days before rolled_up_to have been rolled up
+CREATE TABLE IF NOT EXISTS stats_rollup_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    rolled_up_to TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS classes (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    teacher_id BIGINT NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    invite_code TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS class_students (
+    class_id BIGINT NOT NULL REFERENCES classes(id),
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    joined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS class_assignments (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    class_id BIGINT NOT NULL REFERENCES classes(id),
+    group_id BIGINT NOT NULL REFERENCES groups(id),
+    due_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Seed data
+INSERT INTO users (id, username) VALUES (1, 'default')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO groups (name) VALUES
+    ('Beginner Words'),
+    ('Intermediate Words'),
+    ('Advanced Words')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (1, 'Vocabulary Quiz', '/apps/vocabulary-quiz', '/images/thumbnails/vocabulary.svg', 'Test your vocabulary knowledge with interactive flashcards and quizzes.'),
+    (2, 'Word Matching', '/apps/word-matching', '/images/thumbnails/matching.svg', 'Match Urdu words with their English translations in this fun memory game.'),
+    (3, 'Sentence Builder', '/apps/sentence-builder', '/images/thumbnails/sentences.svg', 'Practice building sentences using the words you''ve learned.'),
+    (4, 'Daily Review', '/apps/daily-review', '/images/thumbnails/flashcards.svg', 'Review the words that are due today, mixing overdue, lapsed and new words.'),
+    (5, 'Flashcards', '/apps/flashcards', '/images/thumbnails/flashcards.svg', 'Flip through the words of a group that are due and rate how well you knew them.'),
+    (6, 'Listening Practice', '/apps/listening-practice', '/images/thumbnails/listening.svg', 'Listen to a clip and answer questions about what you heard.'),
+    (7, 'Word Scramble', '/apps/word-scramble', '/images/thumbnails/vocabulary.svg', 'Unscramble the letters of the words you find hardest.'),
+    (8, 'Hangman', '/apps/hangman', '/images/thumbnails/vocabulary.svg', 'Guess the words you find hardest one letter at a time.')
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    url = excluded.url,
+    thumbnail_url = excluded.thumbnail_url,
+    description = excluded.description;
+
+-- Rows inserted with explicit ids don't advance the identity sequences
+SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT MAX(id) FROM users));
+SELECT setval(pg_get_serial_sequence('study_activities', 'id'), (SELECT MAX(id) FROM study_activities));
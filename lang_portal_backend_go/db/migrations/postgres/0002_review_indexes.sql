@@ -0,0 +1,8 @@
+-- The indexes of SQLite migration 0020
+CREATE INDEX IF NOT EXISTS idx_word_review_items_user_word ON word_review_items(user_id, word_id, correct);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_word ON word_review_items(word_id);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_session ON word_review_items(study_session_id);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_created_at ON word_review_items(created_at);
+CREATE INDEX IF NOT EXISTS idx_words_groups_group ON words_groups(group_id, word_id);
+CREATE INDEX IF NOT EXISTS idx_words_groups_word ON words_groups(word_id);
+CREATE INDEX IF NOT EXISTS idx_study_sessions_group ON study_sessions(group_id);
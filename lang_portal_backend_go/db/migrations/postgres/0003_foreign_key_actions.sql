@@ -0,0 +1,120 @@
+-- The ON DELETE rules of SQLite migration 0021. Foreign keys are always
+-- enforced by PostgreSQL, so there are no orphaned rows to clean up.
+
+ALTER TABLE words_groups
+    DROP CONSTRAINT IF EXISTS words_groups_word_id_fkey,
+    ADD CONSTRAINT words_groups_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS words_groups_group_id_fkey,
+    ADD CONSTRAINT words_groups_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE;
+
+ALTER TABLE study_sessions
+    DROP CONSTRAINT IF EXISTS study_sessions_group_id_fkey,
+    ADD CONSTRAINT study_sessions_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS study_sessions_study_activity_id_fkey,
+    ADD CONSTRAINT study_sessions_study_activity_id_fkey FOREIGN KEY (study_activity_id) REFERENCES study_activities(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS study_sessions_parent_session_id_fkey,
+    ADD CONSTRAINT study_sessions_parent_session_id_fkey FOREIGN KEY (parent_session_id) REFERENCES study_sessions(id) ON DELETE SET NULL,
+    DROP CONSTRAINT IF EXISTS study_sessions_user_id_fkey,
+    ADD CONSTRAINT study_sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE word_review_items
+    DROP CONSTRAINT IF EXISTS word_review_items_word_id_fkey,
+    ADD CONSTRAINT word_review_items_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT IF EXISTS word_review_items_study_session_id_fkey,
+    ADD CONSTRAINT word_review_items_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS word_review_items_user_id_fkey,
+    ADD CONSTRAINT word_review_items_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE study_session_words
+    DROP CONSTRAINT IF EXISTS study_session_words_study_session_id_fkey,
+    ADD CONSTRAINT study_session_words_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS study_session_words_word_id_fkey,
+    ADD CONSTRAINT study_session_words_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT;
+
+ALTER TABLE word_audio
+    DROP CONSTRAINT IF EXISTS word_audio_word_id_fkey,
+    ADD CONSTRAINT word_audio_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE;
+
+ALTER TABLE quiz_questions
+    DROP CONSTRAINT IF EXISTS quiz_questions_study_session_id_fkey,
+    ADD CONSTRAINT quiz_questions_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS quiz_questions_word_id_fkey,
+    ADD CONSTRAINT quiz_questions_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT;
+
+ALTER TABLE word_embeddings
+    DROP CONSTRAINT IF EXISTS word_embeddings_word_id_fkey,
+    ADD CONSTRAINT word_embeddings_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE;
+
+ALTER TABLE quiz_answers
+    DROP CONSTRAINT IF EXISTS quiz_answers_quiz_question_id_fkey,
+    ADD CONSTRAINT quiz_answers_quiz_question_id_fkey FOREIGN KEY (quiz_question_id) REFERENCES quiz_questions(id) ON DELETE CASCADE;
+
+ALTER TABLE flashcards
+    DROP CONSTRAINT IF EXISTS flashcards_study_session_id_fkey,
+    ADD CONSTRAINT flashcards_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS flashcards_word_id_fkey,
+    ADD CONSTRAINT flashcards_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT;
+
+ALTER TABLE listening_clips
+    DROP CONSTRAINT IF EXISTS listening_clips_group_id_fkey,
+    ADD CONSTRAINT listening_clips_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE;
+
+ALTER TABLE listening_questions
+    DROP CONSTRAINT IF EXISTS listening_questions_clip_id_fkey,
+    ADD CONSTRAINT listening_questions_clip_id_fkey FOREIGN KEY (clip_id) REFERENCES listening_clips(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS listening_questions_word_id_fkey,
+    ADD CONSTRAINT listening_questions_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE SET NULL;
+
+ALTER TABLE listening_answers
+    DROP CONSTRAINT IF EXISTS listening_answers_study_session_id_fkey,
+    ADD CONSTRAINT listening_answers_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS listening_answers_question_id_fkey,
+    ADD CONSTRAINT listening_answers_question_id_fkey FOREIGN KEY (question_id) REFERENCES listening_questions(id) ON DELETE RESTRICT;
+
+ALTER TABLE word_game_rounds
+    DROP CONSTRAINT IF EXISTS word_game_rounds_study_session_id_fkey,
+    ADD CONSTRAINT word_game_rounds_study_session_id_fkey FOREIGN KEY (study_session_id) REFERENCES study_sessions(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS word_game_rounds_word_id_fkey,
+    ADD CONSTRAINT word_game_rounds_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE RESTRICT;
+
+ALTER TABLE word_learning_state
+    DROP CONSTRAINT IF EXISTS word_learning_state_user_id_fkey,
+    ADD CONSTRAINT word_learning_state_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS word_learning_state_word_id_fkey,
+    ADD CONSTRAINT word_learning_state_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE;
+
+ALTER TABLE goals
+    DROP CONSTRAINT IF EXISTS goals_user_id_fkey,
+    ADD CONSTRAINT goals_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE daily_word_stats
+    DROP CONSTRAINT IF EXISTS daily_word_stats_user_id_fkey,
+    ADD CONSTRAINT daily_word_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS daily_word_stats_word_id_fkey,
+    ADD CONSTRAINT daily_word_stats_word_id_fkey FOREIGN KEY (word_id) REFERENCES words(id) ON DELETE CASCADE;
+
+ALTER TABLE daily_group_stats
+    DROP CONSTRAINT IF EXISTS daily_group_stats_user_id_fkey,
+    ADD CONSTRAINT daily_group_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS daily_group_stats_group_id_fkey,
+    ADD CONSTRAINT daily_group_stats_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE;
+
+ALTER TABLE daily_stats
+    DROP CONSTRAINT IF EXISTS daily_stats_user_id_fkey,
+    ADD CONSTRAINT daily_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE classes
+    DROP CONSTRAINT IF EXISTS classes_teacher_id_fkey,
+    ADD CONSTRAINT classes_teacher_id_fkey FOREIGN KEY (teacher_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE class_students
+    DROP CONSTRAINT IF EXISTS class_students_class_id_fkey,
+    ADD CONSTRAINT class_students_class_id_fkey FOREIGN KEY (class_id) REFERENCES classes(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS class_students_user_id_fkey,
+    ADD CONSTRAINT class_students_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE class_assignments
+    DROP CONSTRAINT IF EXISTS class_assignments_class_id_fkey,
+    ADD CONSTRAINT class_assignments_class_id_fkey FOREIGN KEY (class_id) REFERENCES classes(id) ON DELETE CASCADE,
+    DROP CONSTRAINT IF EXISTS class_assignments_group_id_fkey,
+    ADD CONSTRAINT class_assignments_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE;
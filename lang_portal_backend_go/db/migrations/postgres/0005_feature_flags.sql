@@ -0,0 +1,14 @@
+-- The feature flag tables of SQLite migration 0023
+CREATE TABLE IF NOT EXISTS feature_flags (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS feature_flag_users (
+    flag_name TEXT NOT NULL REFERENCES feature_flags(name) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (flag_name, user_id)
+);
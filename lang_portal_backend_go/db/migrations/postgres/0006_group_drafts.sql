@@ -0,0 +1,16 @@
+-- The group_drafts table of SQLite migration 0024
+CREATE TABLE IF NOT EXISTS group_drafts (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    topic TEXT NOT NULL,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    provider TEXT NOT NULL,
+    words TEXT NOT NULL,
+    dropped TEXT NOT NULL DEFAULT '[]',
+    group_id BIGINT REFERENCES groups(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    approved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_drafts_user_id ON group_drafts(user_id);
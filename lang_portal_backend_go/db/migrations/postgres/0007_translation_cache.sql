@@ -0,0 +1,11 @@
+-- The translation_cache table of SQLite migration 0025
+CREATE TABLE IF NOT EXISTS translation_cache (
+    provider TEXT NOT NULL,
+    language TEXT NOT NULL,
+    term TEXT NOT NULL,
+    urdu TEXT NOT NULL,
+    urdlish TEXT NOT NULL,
+    english TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (provider, language, term)
+);
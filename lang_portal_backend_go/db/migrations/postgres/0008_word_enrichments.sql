@@ -0,0 +1,18 @@
+-- The word_enrichments and word_definitions tables of SQLite migration 0026
+CREATE TABLE IF NOT EXISTS word_enrichments (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    plurals TEXT NOT NULL DEFAULT '[]',
+    enriched_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS word_definitions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    word_id BIGINT NOT NULL REFERENCES word_enrichments(word_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    part_of_speech TEXT NOT NULL DEFAULT '',
+    definition TEXT NOT NULL,
+    examples TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_definitions_word_id ON word_definitions(word_id, position);
@@ -0,0 +1,15 @@
+-- The group_syncs table of SQLite migration 0027
+CREATE TABLE IF NOT EXISTS group_syncs (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    group_id BIGINT NOT NULL UNIQUE REFERENCES groups(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    interval_minutes INTEGER NOT NULL DEFAULT 0,
+    next_sync_at TIMESTAMPTZ,
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_report TEXT,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_syncs_next_sync_at ON group_syncs(next_sync_at);
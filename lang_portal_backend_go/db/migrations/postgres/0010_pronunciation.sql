@@ -0,0 +1,24 @@
+-- The Pronunciation activity and pronunciation_attempts table of SQLite
+-- migration 0028
+INSERT INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (9, 'Pronunciation', '/apps/pronunciation', '/images/thumbnails/listening.svg', 'Say each word aloud and see how close you came.')
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    url = excluded.url,
+    thumbnail_url = excluded.thumbnail_url,
+    description = excluded.description;
+
+SELECT setval(pg_get_serial_sequence('study_activities', 'id'), (SELECT MAX(id) FROM study_activities));
+
+CREATE TABLE IF NOT EXISTS pronunciation_attempts (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id) ON DELETE CASCADE,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    transcript TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    result TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pronunciation_attempts_session ON pronunciation_attempts(study_session_id, word_id);
@@ -0,0 +1,39 @@
+-- The Chat Quiz activity and bot tables of SQLite migration 0030
+INSERT INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (10, 'Chat Quiz', '/apps/chat-quiz', '/images/thumbnails/vocabulary.svg', 'Answer a question a day on a word that is due, in Telegram or Discord.')
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    url = excluded.url,
+    thumbnail_url = excluded.thumbnail_url,
+    description = excluded.description;
+
+SELECT setval(pg_get_serial_sequence('study_activities', 'id'), (SELECT MAX(id) FROM study_activities));
+
+CREATE TABLE IF NOT EXISTS bot_links (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL,
+    chat_id TEXT NOT NULL,
+    quiz_hour INTEGER NOT NULL DEFAULT 9,
+    code TEXT,
+    code_expires_at TIMESTAMPTZ,
+    code_attempts INTEGER NOT NULL DEFAULT 0,
+    verified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bot_links_chat ON bot_links(platform, chat_id) WHERE verified_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS bot_questions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    group_id BIGINT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    day TEXT NOT NULL,
+    options TEXT NOT NULL,
+    answer INTEGER NOT NULL,
+    chosen INTEGER,
+    study_session_id BIGINT REFERENCES study_sessions(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    answered_at TIMESTAMPTZ,
+    UNIQUE (user_id, day)
+);
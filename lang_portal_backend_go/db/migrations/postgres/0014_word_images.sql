@@ -0,0 +1,10 @@
+-- The word pictures of SQLite migration 0032
+CREATE TABLE IF NOT EXISTS word_images (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    thumbnail TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,6 @@
+-- The calendar feed tokens of SQLite migration 0033
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL
+);
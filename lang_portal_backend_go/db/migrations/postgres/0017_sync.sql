@@ -0,0 +1,83 @@
+-- The change tracking of SQLite migration 0035
+ALTER TABLE words ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE study_sessions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE word_review_items ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE study_sessions ADD COLUMN IF NOT EXISTS client_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_words_updated_at ON words(updated_at);
+CREATE INDEX IF NOT EXISTS idx_groups_updated_at ON groups(updated_at);
+CREATE INDEX IF NOT EXISTS idx_study_sessions_updated_at ON study_sessions(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_word_review_items_updated_at ON word_review_items(user_id, updated_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_study_sessions_client_id ON study_sessions(user_id, client_id);
+
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    entity TEXT NOT NULL,
+    entity_id BIGINT NOT NULL,
+    user_id BIGINT,
+    deleted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_deleted_at ON sync_tombstones(deleted_at);
+
+CREATE OR REPLACE FUNCTION sync_touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION sync_touch_group() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE groups SET updated_at = now() WHERE id = OLD.group_id;
+    ELSE
+        UPDATE groups SET updated_at = now() WHERE id = NEW.group_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TG_ARGV[0] is the entity of the table, and TG_ARGV[1] true for tables
+-- whose rows belong to a user
+CREATE OR REPLACE FUNCTION sync_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO sync_tombstones (entity, entity_id, user_id, deleted_at)
+    VALUES (
+        TG_ARGV[0],
+        OLD.id,
+        CASE WHEN TG_ARGV[1] = 'true' THEN (to_jsonb(OLD) ->> 'user_id')::BIGINT END,
+        now()
+    );
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS words_sync_touch ON words;
+CREATE TRIGGER words_sync_touch BEFORE INSERT OR UPDATE ON words
+    FOR EACH ROW EXECUTE FUNCTION sync_touch();
+DROP TRIGGER IF EXISTS words_sync_delete ON words;
+CREATE TRIGGER words_sync_delete AFTER DELETE ON words
+    FOR EACH ROW EXECUTE FUNCTION sync_tombstone('word', 'false');
+
+DROP TRIGGER IF EXISTS groups_sync_touch ON groups;
+CREATE TRIGGER groups_sync_touch BEFORE INSERT OR UPDATE ON groups
+    FOR EACH ROW EXECUTE FUNCTION sync_touch();
+DROP TRIGGER IF EXISTS groups_sync_delete ON groups;
+CREATE TRIGGER groups_sync_delete AFTER DELETE ON groups
+    FOR EACH ROW EXECUTE FUNCTION sync_tombstone('group', 'false');
+
+DROP TRIGGER IF EXISTS words_groups_sync_touch ON words_groups;
+CREATE TRIGGER words_groups_sync_touch AFTER INSERT OR DELETE ON words_groups
+    FOR EACH ROW EXECUTE FUNCTION sync_touch_group();
+
+DROP TRIGGER IF EXISTS study_sessions_sync_touch ON study_sessions;
+CREATE TRIGGER study_sessions_sync_touch BEFORE INSERT OR UPDATE ON study_sessions
+    FOR EACH ROW EXECUTE FUNCTION sync_touch();
+DROP TRIGGER IF EXISTS study_sessions_sync_delete ON study_sessions;
+CREATE TRIGGER study_sessions_sync_delete AFTER DELETE ON study_sessions
+    FOR EACH ROW EXECUTE FUNCTION sync_tombstone('session', 'true');
+
+DROP TRIGGER IF EXISTS word_review_items_sync_touch ON word_review_items;
+CREATE TRIGGER word_review_items_sync_touch BEFORE INSERT OR UPDATE ON word_review_items
+    FOR EACH ROW EXECUTE FUNCTION sync_touch();
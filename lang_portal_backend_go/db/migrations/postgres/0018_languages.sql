@@ -0,0 +1,25 @@
+-- The languages of SQLite migration 0036
+CREATE TABLE IF NOT EXISTS languages (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    script TEXT NOT NULL,
+    direction TEXT NOT NULL DEFAULT 'ltr',
+    transliteration TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO languages (code, name, script, direction, transliteration) VALUES
+    ('ur', 'Urdu', 'Arab', 'rtl', 'Roman Urdu'),
+    ('ar', 'Arabic', 'Arab', 'rtl', 'Arabic romanization'),
+    ('ja', 'Japanese', 'Jpan', 'ltr', 'Romaji')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE words RENAME COLUMN urdu TO script;
+ALTER TABLE words RENAME COLUMN urdlish TO transliteration;
+ALTER TABLE words ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code);
+CREATE INDEX IF NOT EXISTS idx_words_language ON words(language);
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code);
+ALTER TABLE groups
+    DROP CONSTRAINT IF EXISTS groups_name_key,
+    ADD CONSTRAINT groups_language_name_key UNIQUE (language, name);
@@ -0,0 +1,33 @@
+-- The word senses of SQLite migration 0037
+CREATE TABLE IF NOT EXISTS word_senses (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    english TEXT NOT NULL,
+    register TEXT NOT NULL DEFAULT '',
+    usage_note TEXT NOT NULL DEFAULT '',
+    UNIQUE (word_id, position)
+);
+
+INSERT INTO word_senses (word_id, position, english)
+SELECT id, 0, english FROM words
+WHERE english <> '' AND id NOT IN (SELECT word_id FROM word_senses);
+
+CREATE OR REPLACE FUNCTION word_senses_sync() RETURNS trigger AS $$
+BEGIN
+    IF NEW.english = '' THEN
+        RETURN NULL;
+    END IF;
+    UPDATE word_senses SET english = NEW.english
+    WHERE word_id = NEW.id
+      AND position = (SELECT MIN(position) FROM word_senses WHERE word_id = NEW.id);
+    IF NOT FOUND THEN
+        INSERT INTO word_senses (word_id, position, english) VALUES (NEW.id, 0, NEW.english);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS word_senses_word_sync ON words;
+CREATE TRIGGER word_senses_word_sync AFTER INSERT OR UPDATE OF english ON words
+    FOR EACH ROW EXECUTE FUNCTION word_senses_sync();
@@ -0,0 +1,37 @@
+-- The Conjugation Drill activity and the conjugations and
+-- conjugation_drill_rounds tables of SQLite migration 0038
+INSERT INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (11, 'Conjugation Drill', '/apps/conjugation-drill', '/images/thumbnails/vocabulary.svg', 'Write the form of a verb for a tense, person and gender.')
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    url = excluded.url,
+    thumbnail_url = excluded.thumbnail_url,
+    description = excluded.description;
+
+SELECT setval(pg_get_serial_sequence('study_activities', 'id'), (SELECT MAX(id) FROM study_activities));
+
+CREATE TABLE IF NOT EXISTS conjugations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    tense TEXT NOT NULL,
+    person TEXT NOT NULL DEFAULT '',
+    gender TEXT NOT NULL DEFAULT '',
+    form TEXT NOT NULL,
+    transliteration TEXT NOT NULL DEFAULT '',
+    UNIQUE (word_id, tense, person, gender)
+);
+
+CREATE TABLE IF NOT EXISTS conjugation_drill_rounds (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id) ON DELETE CASCADE,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    tense TEXT NOT NULL,
+    person TEXT NOT NULL,
+    gender TEXT NOT NULL,
+    form TEXT NOT NULL,
+    transliteration TEXT NOT NULL,
+    answer TEXT,
+    result TEXT NOT NULL DEFAULT 'unanswered',
+    answered_at TIMESTAMPTZ,
+    UNIQUE (study_session_id, word_id)
+);
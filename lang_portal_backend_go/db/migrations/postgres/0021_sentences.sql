@@ -0,0 +1,21 @@
+-- The sentences and sentence_words tables of SQLite migration 0039
+CREATE TABLE IF NOT EXISTS sentences (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code),
+    text TEXT NOT NULL,
+    transliteration TEXT NOT NULL DEFAULT '',
+    english TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    normalized TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (language, text)
+);
+
+CREATE TABLE IF NOT EXISTS sentence_words (
+    sentence_id BIGINT NOT NULL REFERENCES sentences(id) ON DELETE CASCADE,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    length INTEGER NOT NULL,
+    PRIMARY KEY (sentence_id, word_id, position)
+);
+CREATE INDEX IF NOT EXISTS idx_sentence_words_word ON sentence_words(word_id);
@@ -0,0 +1,26 @@
+-- The Cloze activity and the cloze_questions table of SQLite migration 0040
+INSERT INTO study_activities (id, name, url, thumbnail_url, description) VALUES
+    (12, 'Cloze', '/apps/cloze', '/images/thumbnails/sentences.svg', 'Fill in the word missing from a sentence.')
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    url = excluded.url,
+    thumbnail_url = excluded.thumbnail_url,
+    description = excluded.description;
+
+SELECT setval(pg_get_serial_sequence('study_activities', 'id'), (SELECT MAX(id) FROM study_activities));
+
+CREATE TABLE IF NOT EXISTS cloze_questions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    study_session_id BIGINT NOT NULL REFERENCES study_sessions(id) ON DELETE CASCADE,
+    word_id BIGINT NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    sentence_id BIGINT REFERENCES sentences(id) ON DELETE SET NULL,
+    prompt TEXT NOT NULL,
+    english TEXT NOT NULL DEFAULT '',
+    answer_mode TEXT NOT NULL,
+    options TEXT NOT NULL DEFAULT '[]',
+    correct_answer TEXT NOT NULL,
+    answer TEXT,
+    result TEXT NOT NULL DEFAULT 'unanswered',
+    answered_at TIMESTAMPTZ,
+    UNIQUE (study_session_id, word_id)
+);
@@ -0,0 +1,12 @@
+-- The passages table of SQLite migration 0041
+CREATE TABLE IF NOT EXISTS passages (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code),
+    title TEXT NOT NULL,
+    level TEXT NOT NULL,
+    text TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    word_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_passages_language ON passages(language, level);
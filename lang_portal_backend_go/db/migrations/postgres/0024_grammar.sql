@@ -0,0 +1,47 @@
+-- The grammar tables of SQLite migration 0042
+CREATE TABLE IF NOT EXISTS grammar_topics (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    language TEXT NOT NULL DEFAULT 'ur' REFERENCES languages(code),
+    slug TEXT NOT NULL,
+    title TEXT NOT NULL,
+    level TEXT NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    lesson TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (language, slug)
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_topics_language ON grammar_topics(language, level, position);
+
+CREATE TABLE IF NOT EXISTS grammar_exercises (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    topic_id BIGINT NOT NULL REFERENCES grammar_topics(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    answer_mode TEXT NOT NULL,
+    prompt TEXT NOT NULL,
+    english TEXT NOT NULL DEFAULT '',
+    options TEXT NOT NULL DEFAULT '[]',
+    answer TEXT NOT NULL,
+    explanation TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_exercises_topic ON grammar_exercises(topic_id, position);
+
+CREATE TABLE IF NOT EXISTS grammar_progress (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    topic_id BIGINT NOT NULL REFERENCES grammar_topics(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, topic_id)
+);
+
+CREATE TABLE IF NOT EXISTS grammar_answers (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    exercise_id BIGINT NOT NULL REFERENCES grammar_exercises(id) ON DELETE CASCADE,
+    answer TEXT NOT NULL,
+    result TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_grammar_answers_user ON grammar_answers(user_id, exercise_id);
@@ -0,0 +1,8 @@
+-- The word_renderings table of SQLite migration 0043
+CREATE TABLE IF NOT EXISTS word_renderings (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id) ON DELETE CASCADE,
+    font_style TEXT NOT NULL DEFAULT '',
+    diacritized TEXT NOT NULL DEFAULT '',
+    ligature_spellings TEXT NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,17 @@
+-- The word_sort_keys and group_sort_keys tables of SQLite migration 0044.
+-- Keys compare byte by byte, whatever the database's collation.
+CREATE TABLE IF NOT EXISTS word_sort_keys (
+    word_id BIGINT PRIMARY KEY REFERENCES words(id) ON DELETE CASCADE,
+    script TEXT NOT NULL,
+    sort_key TEXT COLLATE "C" NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_word_sort_keys_sort_key ON word_sort_keys(sort_key);
+
+CREATE TABLE IF NOT EXISTS group_sort_keys (
+    group_id BIGINT PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    sort_key TEXT COLLATE "C" NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_sort_keys_sort_key ON group_sort_keys(sort_key);
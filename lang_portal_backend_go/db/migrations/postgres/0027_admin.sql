@@ -0,0 +1,12 @@
+-- The users.role column and seed_versions table of SQLite migration 0045
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'learner';
+
+UPDATE users SET role = 'admin' WHERE id = 1;
+
+CREATE TABLE IF NOT EXISTS seed_versions (
+    source TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, name)
+);
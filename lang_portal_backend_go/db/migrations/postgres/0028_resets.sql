@@ -0,0 +1,11 @@
+-- The resets table of SQLite migration 0046
+CREATE TABLE IF NOT EXISTS resets (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    kind TEXT NOT NULL,
+    backup TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    restored_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_resets_user ON resets(user_id, created_at);
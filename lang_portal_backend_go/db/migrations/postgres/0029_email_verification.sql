@@ -0,0 +1,5 @@
+-- The users.email_verified_at column of SQLite migration 0047
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP
+WHERE email IS NOT NULL AND password_hash IS NULL;
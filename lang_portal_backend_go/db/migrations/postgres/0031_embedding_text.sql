@@ -0,0 +1,2 @@
+-- The word_embeddings.source_text column of SQLite migration 0050
+ALTER TABLE word_embeddings ADD COLUMN source_text TEXT NOT NULL DEFAULT '';
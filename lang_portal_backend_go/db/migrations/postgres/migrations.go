@@ -0,0 +1,9 @@
+// Package postgres embeds the migrations that build the schema of a
+// PostgreSQL database. They are kept in step with the SQLite migrations in
+// the parent directory but versioned separately.
+package postgres
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
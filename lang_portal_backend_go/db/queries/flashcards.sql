@@ -0,0 +1,18 @@
+-- name: GetFlashcardDeck :one
+SELECT ss.group_id, ss.created_at
+FROM study_sessions ss
+JOIN study_activities sa ON sa.id = ss.study_activity_id
+WHERE ss.id = ? AND sa.name = ? AND ss.user_id = ?;
+
+-- name: ListFlashcards :many
+SELECT w.id, w.script, w.transliteration, w.english,
+       f.flips, f.rating, f.rated_at, wls.due_at, wls.relearning_step
+FROM flashcards f
+JOIN words w ON w.id = f.word_id
+LEFT JOIN word_learning_state wls ON wls.word_id = f.word_id AND wls.user_id = ?
+WHERE f.study_session_id = ?
+ORDER BY f.id;
+
+-- name: GetFlashcardRating :one
+SELECT rating FROM flashcards
+WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?);
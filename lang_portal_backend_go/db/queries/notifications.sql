@@ -0,0 +1,24 @@
+-- name: GetNotificationPreferences :one
+SELECT email, streak_reminders, weekly_digest, homework_reminders
+FROM notification_preferences WHERE user_id = ?;
+
+-- name: UpsertNotificationPreferences :exec
+INSERT INTO notification_preferences (user_id, email, streak_reminders, weekly_digest, homework_reminders, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id) DO UPDATE SET
+    email = excluded.email,
+    streak_reminders = excluded.streak_reminders,
+    weekly_digest = excluded.weekly_digest,
+    homework_reminders = excluded.homework_reminders,
+    updated_at = excluded.updated_at;
+
+-- name: ListNotificationDeliveries :many
+SELECT id, kind, channel, recipient, subject, status, error, created_at, sent_at
+FROM notification_deliveries
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+
+-- name: FinishNotificationDelivery :exec
+UPDATE notification_deliveries SET status = ?, error = ?, sent_at = ?
+WHERE user_id = ? AND kind = ? AND channel = ? AND dedupe_key = ?;
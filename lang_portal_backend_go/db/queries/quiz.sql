@@ -0,0 +1,7 @@
+-- name: ListQuizResults :many
+SELECT qq.id, qq.word_id, qq.direction, qq.answer_mode, qq.correct_answer, qq.hints_used,
+       qa.answer, qa.correct, qa.near_miss, qa.answered_at
+FROM quiz_questions qq
+LEFT JOIN quiz_answers qa ON qa.quiz_question_id = qq.id
+WHERE qq.study_session_id = ? AND qq.study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+ORDER BY qq.id;
@@ -0,0 +1,9 @@
+-- name: GetUser :one
+SELECT id, username, email, guest_expires_at, created_at FROM users
+WHERE id = sqlc.arg(id) AND (guest_expires_at IS NULL OR guest_expires_at > CAST(sqlc.arg(now) AS TEXT));
+
+-- name: GetUserEmail :one
+SELECT email FROM users WHERE id = ?;
+
+-- name: GetUserPasswordHash :one
+SELECT id, password_hash FROM users WHERE username = ?;
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables configuring login with Google. Google login is
+// enabled when all three are set.
+const (
+	GoogleClientIDEnv     = "LANG_PORTAL_GOOGLE_CLIENT_ID"
+	GoogleClientSecretEnv = "LANG_PORTAL_GOOGLE_CLIENT_SECRET"
+	// GoogleRedirectURLEnv is the public URL of /api/v1/auth/google/callback,
+	// as registered with Google
+	GoogleRedirectURLEnv = "LANG_PORTAL_GOOGLE_REDIRECT_URL"
+)
+
+// Google's OAuth2 endpoints
+var (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+var googleClient = &http.Client{Timeout: 10 * time.Second}
+
+// Google logs users in with the OAuth2 authorization code flow
+type Google struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// GoogleProfile is what Google tells about the account a user logged in with
+type GoogleProfile struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// GoogleFromEnv returns the Google login configured by the environment, or
+// nil if it isn't configured
+func GoogleFromEnv() *Google {
+	g := &Google{
+		ClientID:     os.Getenv(GoogleClientIDEnv),
+		ClientSecret: os.Getenv(GoogleClientSecretEnv),
+		RedirectURL:  os.Getenv(GoogleRedirectURLEnv),
+	}
+	if g.ClientID == "" || g.ClientSecret == "" || g.RedirectURL == "" {
+		return nil
+	}
+	return g
+}
+
+// AuthURL returns the Google consent page to send the user to. state comes
+// back to the callback unchanged.
+func (g *Google) AuthURL(state string) string {
+	params := url.Values{
+		"client_id":     {g.ClientID},
+		"redirect_uri":  {g.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+	}
+	return googleAuthURL + "?" + params.Encode()
+}
+
+// Exchange trades the authorization code passed to the callback for an
+// access token and fetches the profile of the account it belongs to
+func (g *Google) Exchange(ctx context.Context, code string) (*GoogleProfile, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {g.ClientID},
+		"client_secret": {g.ClientSecret},
+		"redirect_uri":  {g.RedirectURL},
+		"grant_type":    {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %v", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("failed to exchange code: no access token")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var profile GoogleProfile
+	if err := doJSON(req, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get profile: %v", err)
+	}
+	return &profile, nil
+}
+
+// doJSON sends a request and decodes its JSON response
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := googleClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,138 @@
+// Package auth issues and verifies the JSON Web Tokens that identify users.
+// Tokens are signed with HMAC-SHA256 (HS256).
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SecretEnv holds the key tokens are signed with
+const SecretEnv = "LANG_PORTAL_JWT_SECRET"
+
+// Token types. Access tokens authenticate requests; refresh tokens are only
+// accepted in exchange for a new pair of tokens.
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+const (
+	// AccessTokenTTL is how long an access token is valid for
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a refresh token is valid for
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, wrongly signed,
+// expired or of the wrong type
+var ErrInvalidToken = errors.New("invalid token")
+
+// header is the only JOSE header tokens are issued with and accepted with
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the claims carried by a token
+type Claims struct {
+	// Subject is the user ID
+	Subject   int64  `json:"sub"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Tokens is a pair of tokens issued to a user
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// Issuer signs and verifies tokens with a secret key
+type Issuer struct {
+	secret []byte
+}
+
+// NewIssuer returns an issuer signing with secret
+func NewIssuer(secret []byte) *Issuer {
+	return &Issuer{secret: secret}
+}
+
+// RandomSecret returns a new 32 byte secret key
+func RandomSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// Issue returns a new access and refresh token for a user
+func (i *Issuer) Issue(userID int64, now time.Time) (*Tokens, error) {
+	access, err := i.sign(Claims{Subject: userID, Type: TokenAccess,
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(AccessTokenTTL).Unix()})
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := i.sign(Claims{Subject: userID, Type: TokenRefresh,
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(RefreshTokenTTL).Unix()})
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Verify checks a token's signature, expiry and type and returns its claims
+func (i *Issuer) Verify(token, tokenType string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, i.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != tokenType || claims.Subject == 0 || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// sign encodes and signs claims
+func (i *Issuer) sign(claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(i.signature(unsigned)), nil
+}
+
+// signature returns the HMAC-SHA256 of a token's header and payload
+func (i *Issuer) signature(unsigned string) []byte {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
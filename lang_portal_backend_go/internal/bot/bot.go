@@ -0,0 +1,110 @@
+// Package bot talks to learners in Telegram and Discord chats: it sends them
+// messages with buttons to answer by, and receives the answers through the
+// platforms' webhooks. The bots are set by the environment.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// TelegramTokenEnv is the token of the Telegram bot, from BotFather
+	TelegramTokenEnv = "LANG_PORTAL_TELEGRAM_BOT_TOKEN"
+	// TelegramSecretEnv is the secret Telegram sends with webhook requests,
+	// as set with setWebhook's secret_token
+	TelegramSecretEnv = "LANG_PORTAL_TELEGRAM_WEBHOOK_SECRET"
+	// TelegramURLEnv overrides the Telegram Bot API URL
+	TelegramURLEnv = "LANG_PORTAL_TELEGRAM_URL"
+	// DiscordTokenEnv is the token of the Discord bot
+	DiscordTokenEnv = "LANG_PORTAL_DISCORD_BOT_TOKEN"
+	// DiscordPublicKeyEnv is the Discord application's public key, in hex,
+	// which interactions are signed with
+	DiscordPublicKeyEnv = "LANG_PORTAL_DISCORD_PUBLIC_KEY"
+	// DiscordURLEnv overrides the Discord API URL
+	DiscordURLEnv = "LANG_PORTAL_DISCORD_URL"
+)
+
+// Platforms
+const (
+	Telegram = "telegram"
+	Discord  = "discord"
+)
+
+// ErrChat is returned for messages the platform refused to deliver to a
+// chat, e.g. because it doesn't exist or the bot isn't in it
+var ErrChat = errors.New("chat can't be messaged")
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Option is a button a message can be answered with
+type Option struct {
+	Label string
+	// Data is what the button answers, up to 64 bytes
+	Data string
+}
+
+// Message is a message to a chat, with buttons to answer it by, if any
+type Message struct {
+	Text    string
+	Options []Option
+}
+
+// Answer is a button pressed in a chat
+type Answer struct {
+	ChatID string
+	Data   string
+}
+
+// AnswerFunc handles an answer, returning the reply shown in the chat
+type AnswerFunc func(ctx context.Context, answer Answer) (string, error)
+
+// Bot sends messages to chats of a platform and receives their answers
+type Bot interface {
+	// Platform names the bot's platform, e.g. "telegram"
+	Platform() string
+	// ValidChatID reports whether id could name a chat of the platform
+	ValidChatID(id string) bool
+	// Send sends msg to a chat
+	Send(ctx context.Context, chatID string, msg Message) error
+	// ServeWebhook handles a request the platform sent to the bot's
+	// webhook, passing the answers in it to answer. The reply is shown
+	// under the answered message, whose buttons are taken away.
+	ServeWebhook(w http.ResponseWriter, r *http.Request, answer AnswerFunc)
+}
+
+// FromEnv returns the bot of a platform configured by the environment, or
+// nil if its token isn't set
+func FromEnv(platform string) (Bot, error) {
+	switch platform {
+	case Telegram:
+		token := os.Getenv(TelegramTokenEnv)
+		if token == "" {
+			return nil, nil
+		}
+		secret := os.Getenv(TelegramSecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("%s is required for %s", TelegramSecretEnv, platform)
+		}
+		return newTelegram(os.Getenv(TelegramURLEnv), token, secret), nil
+	case Discord:
+		token := os.Getenv(DiscordTokenEnv)
+		if token == "" {
+			return nil, nil
+		}
+		key := os.Getenv(DiscordPublicKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("%s is required for %s", DiscordPublicKeyEnv, platform)
+		}
+		return newDiscord(os.Getenv(DiscordURLEnv), token, key)
+	default:
+		return nil, fmt.Errorf("unknown platform %q: use %s or %s", platform, Telegram, Discord)
+	}
+}
+
+// failedReply is shown when an answer couldn't be handled
+const failedReply = "Sorry, your answer couldn't be recorded. Please try again."
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const discordURL = "https://discord.com/api/v10"
+
+// Interaction types and responses of Discord
+const (
+	discordPing             = 1
+	discordComponent        = 3
+	discordPong             = 1
+	discordChannelMessage   = 4
+	discordUpdateMessage    = 7
+	discordEphemeral        = 64
+	discordActionRow        = 1
+	discordButton           = 2
+	discordSecondaryStyle   = 2
+	discordMaxButtonsPerRow = 5
+	discordMaxLabelLength   = 80
+)
+
+// discord is a Discord bot. Chats are channels, named by their ids, which
+// may be direct message channels.
+type discord struct {
+	url       string
+	token     string
+	publicKey ed25519.PublicKey
+}
+
+func newDiscord(apiURL, token, publicKey string) (*discord, error) {
+	if apiURL == "" {
+		apiURL = discordURL
+	}
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid %s: must be a hex Ed25519 public key", DiscordPublicKeyEnv)
+	}
+	return &discord{url: strings.TrimSuffix(apiURL, "/"), token: token, publicKey: key}, nil
+}
+
+func (b *discord) Platform() string { return Discord }
+
+// ValidChatID accepts snowflakes, the numeric ids of Discord
+func (b *discord) ValidChatID(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+func (b *discord) Send(ctx context.Context, chatID string, msg Message) error {
+	request := map[string]interface{}{"content": msg.Text}
+	if len(msg.Options) > 0 {
+		var rows, buttons []map[string]interface{}
+		for _, option := range msg.Options {
+			label := option.Label
+			if utf8.RuneCountInString(label) > discordMaxLabelLength {
+				label = string([]rune(label)[:discordMaxLabelLength-1]) + "…"
+			}
+			buttons = append(buttons, map[string]interface{}{
+				"type": discordButton, "style": discordSecondaryStyle, "label": label, "custom_id": option.Data,
+			})
+			if len(buttons) == discordMaxButtonsPerRow {
+				rows = append(rows, map[string]interface{}{"type": discordActionRow, "components": buttons})
+				buttons = nil
+			}
+		}
+		if len(buttons) > 0 {
+			rows = append(rows, map[string]interface{}{"type": discordActionRow, "components": buttons})
+		}
+		request["components"] = rows
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("discord: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+"/channels/"+chatID+"/messages", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("discord: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+b.token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discord: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("discord: %w: %s", ErrChat, strings.TrimSpace(string(message)))
+	}
+	return fmt.Errorf("discord: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// ServeWebhook handles the interactions Discord posts to the application's
+// interactions endpoint, which must be signed with its key. Presses of
+// buttons are answered by updating their message.
+func (b *discord) ServeWebhook(w http.ResponseWriter, r *http.Request, answer AnswerFunc) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	if err != nil || !ed25519.Verify(b.publicKey, append([]byte(timestamp), body...), signature) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var interaction struct {
+		Type      int    `json:"type"`
+		ChannelID string `json:"channel_id"`
+		Data      struct {
+			CustomID string `json:"custom_id"`
+		} `json:"data"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	var response map[string]interface{}
+	switch interaction.Type {
+	case discordPing:
+		response = map[string]interface{}{"type": discordPong}
+	case discordComponent:
+		reply, err := answer(r.Context(), Answer{ChatID: interaction.ChannelID, Data: interaction.Data.CustomID})
+		if err != nil {
+			// Only the user who pressed sees the failure, and the buttons
+			// are kept, so the answer can be tried again
+			log.Printf("Failed to handle Discord answer: %v", err)
+			response = map[string]interface{}{
+				"type": discordChannelMessage,
+				"data": map[string]interface{}{"content": failedReply, "flags": discordEphemeral},
+			}
+			break
+		}
+		response = map[string]interface{}{
+			"type": discordUpdateMessage,
+			"data": map[string]interface{}{
+				"content":    interaction.Message.Content + "\n\n" + reply,
+				"components": []interface{}{},
+			},
+		}
+	default:
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
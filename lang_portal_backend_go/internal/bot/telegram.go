@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const telegramURL = "https://api.telegram.org"
+
+// telegramSecretHeader carries the webhook's secret
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// telegram is a Telegram bot. Chats are named by their numeric ids.
+type telegram struct {
+	url    string
+	token  string
+	secret string
+}
+
+func newTelegram(apiURL, token, secret string) *telegram {
+	if apiURL == "" {
+		apiURL = telegramURL
+	}
+	return &telegram{url: strings.TrimSuffix(apiURL, "/"), token: token, secret: secret}
+}
+
+func (b *telegram) Platform() string { return Telegram }
+
+func (b *telegram) ValidChatID(id string) bool {
+	_, err := strconv.ParseInt(id, 10, 64)
+	return err == nil
+}
+
+func (b *telegram) Send(ctx context.Context, chatID string, msg Message) error {
+	request := map[string]interface{}{"chat_id": chatID, "text": msg.Text}
+	if len(msg.Options) > 0 {
+		// One button a row, so long answers aren't cut short
+		var keyboard [][]map[string]string
+		for _, option := range msg.Options {
+			keyboard = append(keyboard, []map[string]string{{"text": option.Label, "callback_data": option.Data}})
+		}
+		request["reply_markup"] = map[string]interface{}{"inline_keyboard": keyboard}
+	}
+	return b.call(ctx, "sendMessage", request)
+}
+
+// call calls a method of the Bot API
+func (b *telegram) call(ctx context.Context, method string, request interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("telegram: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+"/bot"+b.token+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		// The URL holds the token, which errors mustn't show
+		return fmt.Errorf("telegram: invalid request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram: %s failed", method)
+	}
+	defer resp.Body.Close()
+	var response struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(body, &response)
+	if resp.StatusCode == http.StatusOK && response.OK {
+		return nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("telegram: %w: %s", ErrChat, response.Description)
+	}
+	return fmt.Errorf("telegram: %s failed with status %d: %s", method, resp.StatusCode, response.Description)
+}
+
+// ServeWebhook handles the updates Telegram posts. Only presses of buttons
+// are answered; other updates are acknowledged and ignored, since Telegram
+// sends an update again until it is.
+func (b *telegram) ServeWebhook(w http.ResponseWriter, r *http.Request, answer AnswerFunc) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(telegramSecretHeader)), []byte(b.secret)) != 1 {
+		http.Error(w, "invalid secret", http.StatusUnauthorized)
+		return
+	}
+	var update struct {
+		CallbackQuery *struct {
+			ID      string `json:"id"`
+			Data    string `json:"data"`
+			Message *struct {
+				MessageID int64  `json:"message_id"`
+				Text      string `json:"text"`
+				Chat      struct {
+					ID int64 `json:"id"`
+				} `json:"chat"`
+			} `json:"message"`
+		} `json:"callback_query"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&update); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+	query := update.CallbackQuery
+	if query == nil || query.Message == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ctx := r.Context()
+	chatID := strconv.FormatInt(query.Message.Chat.ID, 10)
+	reply, err := answer(ctx, Answer{ChatID: chatID, Data: query.Data})
+	if err != nil {
+		// The buttons are kept, so the answer can be tried again
+		log.Printf("Failed to handle Telegram answer: %v", err)
+		if err := b.call(ctx, "answerCallbackQuery", map[string]string{
+			"callback_query_id": query.ID, "text": failedReply,
+		}); err != nil {
+			log.Printf("Failed to acknowledge Telegram answer: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := b.call(ctx, "answerCallbackQuery", map[string]string{"callback_query_id": query.ID}); err != nil {
+		log.Printf("Failed to acknowledge Telegram answer: %v", err)
+	}
+	// Replace the buttons with the reply, so the message is answered once
+	if err := b.call(ctx, "editMessageText", map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": query.Message.MessageID,
+		"text":       query.Message.Text + "\n\n" + reply,
+	}); err != nil {
+		log.Printf("Failed to reply to Telegram answer: %v", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
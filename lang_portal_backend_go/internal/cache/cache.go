@@ -0,0 +1,58 @@
+// Package cache keeps short-lived values, such as cached dashboard
+// statistics, idempotency keys and rate limit counters, in memory or in
+// Redis. Redis keeps them across restarts and shares them between replicas
+// of the server. Which one is used is set by the server's settings.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lang_portal/internal/config"
+	"time"
+)
+
+// ErrMiss is returned for keys that hold no value
+var ErrMiss = errors.New("cache miss")
+
+// Cache holds values under keys until they expire
+type Cache interface {
+	// Get returns the value under key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set puts value under key until ttl passes, or for good if ttl is 0
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Add puts value under key like Set, unless key already holds a value.
+	// It reports whether value was put.
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete removes the value under key, if any
+	Delete(ctx context.Context, key string) error
+	// Take takes a token from the bucket under key, which refills at
+	// perSecond tokens a second up to burst. It reports whether there was
+	// a token to take and, if not, how long until there is.
+	Take(ctx context.Context, key string, perSecond float64, burst int) (bool, time.Duration, error)
+	// Close releases the cache's connections
+	Close() error
+}
+
+// New returns the cache configured by cfg. A Redis cache is checked to be
+// reachable.
+func New(cfg config.Cache) (Cache, error) {
+	switch cfg.Backend {
+	case config.CacheMemory:
+		return NewMemory(), nil
+	case config.CacheRedis:
+		redis, err := NewRedis(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := redis.Ping(ctx); err != nil {
+			redis.Close()
+			return nil, err
+		}
+		return redis, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
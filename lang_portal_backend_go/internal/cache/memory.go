@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired values and full buckets are forgotten,
+// so the maps don't grow with every key ever used
+const sweepInterval = time.Minute
+
+// entry is a value and when it expires, zero for never
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// bucket holds the tokens left under a key
+type bucket struct {
+	tokens    float64
+	seen      time.Time
+	perSecond float64
+	burst     float64
+}
+
+// refill adds the tokens earned since the bucket was last seen
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.seen).Seconds()*b.perSecond)
+	b.seen = now
+}
+
+// Memory keeps values in the server's memory. They are lost when it
+// restarts and aren't shared with other replicas.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemory returns an empty in-memory cache
+func NewMemory() *Memory {
+	return &Memory{
+		entries:   map[string]entry{},
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// sweep forgets expired values and full buckets once a sweepInterval. It
+// must be called with mu held.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	for key, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+	for key, b := range m.buckets {
+		if b.tokens+now.Sub(b.seen).Seconds()*b.perSecond >= b.burst {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || e.expired(m.now()) {
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+	m.entries[key] = m.entry(now, value, ttl)
+	return nil
+}
+
+func (m *Memory) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+	if e, ok := m.entries[key]; ok && !e.expired(now) {
+		return false, nil
+	}
+	m.entries[key] = m.entry(now, value, ttl)
+	return true, nil
+}
+
+// entry returns value as an entry expiring ttl after now
+func (m *Memory) entry(now time.Time, value []byte, ttl time.Duration) entry {
+	e := entry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	return e
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *Memory) Take(ctx context.Context, key string, perSecond float64, burst int) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), seen: now}
+		m.buckets[key] = b
+	}
+	b.perSecond, b.burst = perSecond, float64(burst)
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	return false, wait, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,329 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyPrefix namespaces the server's keys, so a Redis can be shared with
+// other applications
+const keyPrefix = "lang_portal:"
+
+// maxIdleConns is how many connections are kept open between commands
+const maxIdleConns = 8
+
+// commandTimeout bounds commands whose context has no deadline
+const commandTimeout = 5 * time.Second
+
+// takeScript takes a token from the bucket hashed under KEYS[1] as
+// Memory.Take does, atomically so replicas share the bucket. ARGV holds the
+// rate a second, the burst and the time in seconds. The wait is returned as
+// a string, since Redis truncates Lua numbers to integers.
+const takeScript = `
+local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'seen')
+local tokens, seen = tonumber(state[1]), tonumber(state[2])
+if tokens == nil then
+	tokens, seen = burst, now
+end
+tokens = math.min(burst, tokens + math.max(0, now - seen) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'seen', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000))
+return {allowed, tostring((1 - tokens) / rate)}
+`
+
+// redisError is an error Redis answered a command with. The connection it
+// came on can still be used.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// Redis keeps values in a Redis server, speaking its protocol over a small
+// pool of connections
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+	idle     chan *redisConn
+	now      func() time.Time
+}
+
+// redisConn is a connection to Redis and its buffered replies
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedis returns a cache in the Redis of rawURL, e.g.
+// redis://:password@localhost:6379/0, or rediss:// for TLS. Connections
+// are opened when commands need them.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %v", err)
+	}
+	r := &Redis{addr: u.Host, idle: make(chan *redisConn, maxIdleConns), now: time.Now}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		r.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, fmt.Errorf("invalid Redis URL: scheme must be redis or rediss")
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("invalid Redis URL: database %q is not a number", db)
+		}
+	}
+	return r, nil
+}
+
+// Ping checks that Redis can be reached
+func (r *Redis) Ping(ctx context.Context) error {
+	if _, err := r.do(ctx, "PING"); err != nil {
+		return fmt.Errorf("failed to reach Redis: %v", err)
+	}
+	return nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := r.do(ctx, "GET", keyPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", keyPrefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+func (r *Redis) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []string{"SET", keyPrefix + key, string(value), "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	reply, err := r.do(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+	// SET NX answers nil when the key already holds a value
+	return reply != nil, nil
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DEL", keyPrefix+key)
+	return err
+}
+
+func (r *Redis) Take(ctx context.Context, key string, perSecond float64, burst int) (bool, time.Duration, error) {
+	now := float64(r.now().UnixNano()) / float64(time.Second)
+	reply, err := r.do(ctx, "EVAL", takeScript, "1", keyPrefix+key,
+		strconv.FormatFloat(perSecond, 'f', -1, 64), strconv.Itoa(burst), strconv.FormatFloat(now, 'f', 3, 64))
+	if err != nil {
+		return false, 0, err
+	}
+	result, ok := reply.([]interface{})
+	if !ok || len(result) != 2 {
+		return false, 0, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	allowed, _ := result[0].(int64)
+	if allowed == 1 {
+		return true, 0, nil
+	}
+	wait, _ := result[1].([]byte)
+	seconds, err := strconv.ParseFloat(string(wait), 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("redis: unexpected wait %q", wait)
+	}
+	return false, time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do runs a command and returns its reply: a string, an int64, a []byte,
+// nil or a []interface{} of them
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.command(ctx, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, fmt.Errorf("redis: %v", err)
+	}
+	r.release(conn)
+	return reply, err
+}
+
+// conn returns an idle connection, or a new one if none is idle
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: commandTimeout}
+	var (
+		netConn net.Conn
+		err     error
+	)
+	if r.tls != nil {
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: r.tls}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to connect: %v", err)
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	var setup [][]string
+	if r.password != "" {
+		if r.username != "" {
+			setup = append(setup, []string{"AUTH", r.username, r.password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.password})
+		}
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := conn.command(ctx, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis: failed to %s: %v", strings.ToLower(args[0]), err)
+		}
+	}
+	return conn, nil
+}
+
+// release keeps conn for the next command, or closes it if enough are kept
+func (r *Redis) release(conn *redisConn) {
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// command sends a command and reads its reply
+func (c *redisConn) command(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(commandTimeout)
+	}
+	c.SetDeadline(deadline)
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, request.String()); err != nil {
+		return nil, err
+	}
+	return c.reply()
+}
+
+// reply reads a reply of the Redis protocol
+func (c *redisConn) reply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply %q", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			// An error inside an array doesn't fail the array
+			item, err := c.reply()
+			var replyErr redisError
+			if errors.As(err, &replyErr) {
+				item, err = replyErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("invalid reply %q", line)
+	}
+}
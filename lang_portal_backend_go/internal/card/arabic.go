@@ -0,0 +1,178 @@
+package card
+
+import "unicode"
+
+// arabicForms are the presentation forms of Arabic script letters: isolated,
+// final, initial and medial. Letters without initial and medial forms join
+// only the letter before them.
+var arabicForms = map[rune][4]rune{
+	'ء': {0xFE80, 0, 0, 0},
+	'آ': {0xFE81, 0xFE82, 0, 0},
+	'أ': {0xFE83, 0xFE84, 0, 0},
+	'ؤ': {0xFE85, 0xFE86, 0, 0},
+	'إ': {0xFE87, 0xFE88, 0, 0},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E, 0, 0},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94, 0, 0},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA, 0, 0},
+	'ذ': {0xFEAB, 0xFEAC, 0, 0},
+	'ر': {0xFEAD, 0xFEAE, 0, 0},
+	'ز': {0xFEAF, 0xFEB0, 0, 0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE, 0, 0},
+	'ى': {0xFEEF, 0xFEF0, 0, 0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	// Urdu letters
+	'ٹ': {0xFB66, 0xFB67, 0xFB68, 0xFB69},
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	'ڈ': {0xFB88, 0xFB89, 0, 0},
+	'ڑ': {0xFB8C, 0xFB8D, 0, 0},
+	'ژ': {0xFB8A, 0xFB8B, 0, 0},
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	'ں': {0xFB9E, 0xFB9F, 0, 0},
+	'ھ': {0xFBAA, 0xFBAB, 0xFBAC, 0xFBAD},
+	'ۀ': {0xFBA4, 0xFBA5, 0, 0},
+	'ہ': {0xFBA6, 0xFBA7, 0xFBA8, 0xFBA9},
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+	'ے': {0xFBAE, 0xFBAF, 0, 0},
+	'ۓ': {0xFBB0, 0xFBB1, 0, 0},
+}
+
+// lamAlef are the isolated and final ligatures of lam and the alefs after it
+var lamAlef = map[rune][2]rune{
+	'آ': {0xFEF5, 0xFEF6},
+	'أ': {0xFEF7, 0xFEF8},
+	'إ': {0xFEF9, 0xFEFA},
+	'ا': {0xFEFB, 0xFEFC},
+}
+
+const (
+	isolated = iota
+	final
+	initial
+	medial
+)
+
+const tatweel = 'ـ'
+
+// joinsAfter reports whether r joins the letter after it
+func joinsAfter(r rune) bool {
+	return r == tatweel || arabicForms[r][initial] != 0
+}
+
+// joinsBefore reports whether r joins the letter before it
+func joinsBefore(r rune) bool {
+	return r == tatweel || arabicForms[r][final] != 0
+}
+
+// shapeArabic replaces the Arabic script letters of text with the
+// presentation forms they take next to the letters around them, as fonts
+// drawn without shaping need. Marks such as diacritics are kept between the
+// letters they sit on.
+func shapeArabic(text string) string {
+	runes := []rune(text)
+	// neighbour returns the letter next to i in direction step, skipping
+	// marks, or 0
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if !unicode.Is(unicode.Mn, runes[j]) {
+				return runes[j]
+			}
+		}
+		return 0
+	}
+
+	shaped := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			shaped = append(shaped, r)
+			continue
+		}
+		joinsPrev := joinsAfter(neighbour(i, -1))
+		if r == 'ل' {
+			if next := neighbour(i, 1); next != 0 {
+				if ligature, ok := lamAlef[next]; ok {
+					form := isolated
+					if joinsPrev {
+						form = final
+					}
+					shaped = append(shaped, ligature[form])
+					// Skip the marks and the alef the ligature includes
+					for i++; runes[i] != next; i++ {
+						shaped = append(shaped, runes[i])
+					}
+					continue
+				}
+			}
+		}
+		joinsNext := joinsAfter(r) && joinsBefore(neighbour(i, 1))
+
+		form := isolated
+		switch {
+		case joinsPrev && joinsNext:
+			form = medial
+		case joinsPrev:
+			form = final
+		case joinsNext:
+			form = initial
+		}
+		if forms[form] == 0 {
+			// Letters that don't join the one before take their isolated
+			// form after a letter that would join them
+			form = isolated
+		}
+		shaped = append(shaped, forms[form])
+	}
+	return string(shaped)
+}
+
+// visualOrder lays out right-to-left text for drawing from left to right:
+// it reverses the text, keeping marks after the letters they sit on and
+// runs of left-to-right letters and digits in their order
+func visualOrder(text string) string {
+	// Split the text into letters with their marks, and left-to-right runs
+	var clusters [][]rune
+	ltr := func(r rune) bool {
+		return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.Is(unicode.Arabic, r)
+	}
+	for _, r := range text {
+		if n := len(clusters); n > 0 {
+			last := clusters[n-1]
+			if unicode.Is(unicode.Mn, r) || ltr(r) && ltr(last[len(last)-1]) {
+				clusters[n-1] = append(last, r)
+				continue
+			}
+		}
+		clusters = append(clusters, []rune{r})
+	}
+
+	runes := make([]rune, 0, len(text))
+	for i := len(clusters) - 1; i >= 0; i-- {
+		runes = append(runes, clusters[i]...)
+	}
+	return string(runes)
+}
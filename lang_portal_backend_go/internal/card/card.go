@@ -0,0 +1,133 @@
+package card
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"lang_portal/internal/models"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	width  = 600
+	height = 315
+)
+
+// RenderSVG renders a shareable summary card for a study session
+func RenderSVG(summary *models.SessionCard) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	buf.WriteString(`<rect width="100%" height="100%" rx="16" fill="#0f766e"/>`)
+	buf.WriteString(`<rect x="16" y="16" width="568" height="283" rx="12" fill="#ffffff"/>`)
+
+	writeText(&buf, 40, 60, 26, "#0f172a", true, summary.GroupName)
+	writeText(&buf, 40, 88, 16, "#475569", false, summary.ActivityName)
+
+	writeStat(&buf, 40, "Score", fmt.Sprintf("%d/%d", summary.CorrectCount, summary.TotalWords))
+	writeStat(&buf, 220, "Streak", streakDays(summary.StudyStreakDays))
+	writeStat(&buf, 400, "Words mastered", fmt.Sprintf("%d", summary.WordsMastered))
+
+	// Accuracy bar
+	buf.WriteString(`<rect x="40" y="215" width="520" height="12" rx="6" fill="#e2e8f0"/>`)
+	barWidth := int(summary.Accuracy * 520)
+	if barWidth > 0 {
+		fmt.Fprintf(&buf, `<rect x="40" y="215" width="%d" height="12" rx="6" fill="#14b8a6"/>`, barWidth)
+	}
+
+	if len(summary.Highlights) > 0 {
+		writeText(&buf, 560, 272, 24, "#0f766e", true, strings.Join(summary.Highlights, "  ·  "))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// streakDays describes a streak of days
+func streakDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func writeStat(buf *bytes.Buffer, x int, label, value string) {
+	writeText(buf, x, 150, 32, "#0f172a", true, value)
+	writeText(buf, x, 178, 14, "#64748b", false, label)
+}
+
+// writeText writes a text element, switching to right-to-left layout when
+// the text contains Arabic script so Urdu renders in the correct order
+func writeText(buf *bytes.Buffer, x, y, size int, fill string, bold bool, text string) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+
+	if isRTL(text) {
+		fmt.Fprintf(buf, `<text x="%d" y="%d" font-family="'Noto Nastaliq Urdu', 'Jameel Noori Nastaleeq', serif" font-size="%d" font-weight="%s" fill="%s" direction="rtl" unicode-bidi="embed" text-anchor="start">`,
+			rtlX(x), y, size, weight, fill)
+	} else {
+		fmt.Fprintf(buf, `<text x="%d" y="%d" font-family="Helvetica, Arial, sans-serif" font-size="%d" font-weight="%s" fill="%s">`,
+			x, y, size, weight, fill)
+	}
+	xml.EscapeText(buf, []byte(text))
+	buf.WriteString(`</text>`)
+}
+
+// rtlX anchors right-to-left text against the right edge of the card
+func rtlX(x int) int {
+	if x < width/2 {
+		return width - x
+	}
+	return x
+}
+
+// isRTL reports whether the text contains any Arabic script characters
+func isRTL(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Arabic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache keeps rendered cards keyed by the state they were rendered from
+type Cache struct {
+	mu       sync.Mutex
+	items    map[string][]byte
+	maxItems int
+}
+
+// NewCache creates a cache holding at most maxItems rendered cards
+func NewCache(maxItems int) *Cache {
+	return &Cache{
+		items:    make(map[string][]byte),
+		maxItems: maxItems,
+	}
+}
+
+// Key builds a cache key that changes whenever the card content would
+func Key(summary *models.SessionCard, format string) string {
+	return fmt.Sprintf("%d:%d:%d:%d:%d:%s", summary.SessionID, summary.CorrectCount,
+		summary.TotalWords, summary.StudyStreakDays, summary.WordsMastered, format)
+}
+
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.items[key]
+	return data, ok
+}
+
+func (c *Cache) Set(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items) >= c.maxItems {
+		c.items = make(map[string][]byte)
+	}
+	c.items[key] = data
+}
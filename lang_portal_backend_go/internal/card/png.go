@@ -0,0 +1,209 @@
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"lang_portal/internal/models"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontEnv names a TrueType or OpenType font with Arabic script glyphs,
+// including the Arabic presentation forms, that PNG cards draw Urdu text
+// with. The Go fonts the rest of the card is drawn with have none, so
+// without it Urdu text is left off PNG cards.
+const FontEnv = "LANG_PORTAL_CARD_FONT"
+
+// fonts are the parsed fonts PNG cards are drawn with
+type fonts struct {
+	regular, bold, arabic *opentype.Font
+	// faces are the faces of the fonts by font and size
+	mu    sync.Mutex
+	faces map[faceKey]font.Face
+}
+
+type faceKey struct {
+	font *opentype.Font
+	size int
+}
+
+var (
+	loadFonts sync.Once
+	cardFonts *fonts
+	fontsErr  error
+)
+
+// getFonts parses the Go fonts and the font FontEnv names, once
+func getFonts() (*fonts, error) {
+	loadFonts.Do(func() {
+		f := &fonts{faces: map[faceKey]font.Face{}}
+		if f.regular, fontsErr = opentype.Parse(goregular.TTF); fontsErr != nil {
+			return
+		}
+		if f.bold, fontsErr = opentype.Parse(gobold.TTF); fontsErr != nil {
+			return
+		}
+		if path := os.Getenv(FontEnv); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fontsErr = fmt.Errorf("failed to read %s: %v", FontEnv, err)
+				return
+			}
+			if f.arabic, err = opentype.Parse(data); err != nil {
+				fontsErr = fmt.Errorf("failed to parse %s: %v", FontEnv, err)
+				return
+			}
+		}
+		cardFonts = f
+	})
+	return cardFonts, fontsErr
+}
+
+func (f *fonts) face(fnt *opentype.Font, size int) (font.Face, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := faceKey{fnt, size}
+	if face, ok := f.faces[key]; ok {
+		return face, nil
+	}
+	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %v", err)
+	}
+	f.faces[key] = face
+	return face, nil
+}
+
+var (
+	teal      = color.RGBA{0x0f, 0x76, 0x6e, 0xff}
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ink       = color.RGBA{0x0f, 0x17, 0x2a, 0xff}
+	slate     = color.RGBA{0x47, 0x55, 0x69, 0xff}
+	muted     = color.RGBA{0x64, 0x74, 0x8b, 0xff}
+	track     = color.RGBA{0xe2, 0xe8, 0xf0, 0xff}
+	highlight = color.RGBA{0x14, 0xb8, 0xa6, 0xff}
+)
+
+// RenderPNG renders the card RenderSVG does as a PNG image
+func RenderPNG(summary *models.SessionCard) ([]byte, error) {
+	f, err := getFonts()
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRoundedRect(img, image.Rect(0, 0, width, height), 16, teal)
+	fillRoundedRect(img, image.Rect(16, 16, 584, 299), 12, white)
+
+	r := pngRenderer{img: img, fonts: f}
+	r.text(40, 60, 26, ink, true, summary.GroupName)
+	r.text(40, 88, 16, slate, false, summary.ActivityName)
+
+	r.stat(40, "Score", fmt.Sprintf("%d/%d", summary.CorrectCount, summary.TotalWords))
+	r.stat(220, "Streak", streakDays(summary.StudyStreakDays))
+	r.stat(400, "Words mastered", fmt.Sprintf("%d", summary.WordsMastered))
+
+	// Accuracy bar
+	fillRoundedRect(img, image.Rect(40, 215, 560, 227), 6, track)
+	if barWidth := int(summary.Accuracy * 520); barWidth > 0 {
+		fillRoundedRect(img, image.Rect(40, 215, 40+barWidth, 227), 6, highlight)
+	}
+
+	if len(summary.Highlights) > 0 {
+		r.text(560, 272, 24, teal, true, strings.Join(summary.Highlights, "  ·  "))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode card: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pngRenderer draws the text of a PNG card, keeping the first error
+type pngRenderer struct {
+	img   *image.RGBA
+	fonts *fonts
+	err   error
+}
+
+func (r *pngRenderer) stat(x int, label, value string) {
+	r.text(x, 150, 32, ink, true, value)
+	r.text(x, 178, 14, muted, false, label)
+}
+
+// text draws text with its baseline at y, as writeText does: left-to-right
+// text starts at x and right-to-left text ends at rtlX(x)
+func (r *pngRenderer) text(x, y, size int, c color.Color, bold bool, text string) {
+	if r.err != nil || text == "" {
+		return
+	}
+	fnt := r.fonts.regular
+	if bold {
+		fnt = r.fonts.bold
+	}
+	rtl := isRTL(text)
+	if rtl {
+		if r.fonts.arabic == nil {
+			return
+		}
+		fnt, text = r.fonts.arabic, visualOrder(shapeArabic(text))
+	}
+	face, err := r.fonts.face(fnt, size)
+	if err != nil {
+		r.err = err
+		return
+	}
+	d := font.Drawer{Dst: r.img, Src: image.NewUniform(c), Face: face}
+	if rtl {
+		x = rtlX(x) - d.MeasureString(text).Ceil()
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(text)
+}
+
+// fillRoundedRect fills rect with c, rounding its corners by radius
+func fillRoundedRect(img *image.RGBA, rect image.Rectangle, radius int, c color.Color) {
+	if radius*2 > rect.Dx() {
+		radius = rect.Dx() / 2
+	}
+	if radius*2 > rect.Dy() {
+		radius = rect.Dy() / 2
+	}
+	src := image.NewUniform(c)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		// inset is how far the row starts in from the rectangle's sides
+		inset := 0
+		dy := 0
+		switch {
+		case y < rect.Min.Y+radius:
+			dy = rect.Min.Y + radius - y
+		case y >= rect.Max.Y-radius:
+			dy = y - (rect.Max.Y - radius - 1)
+		}
+		if dy > 0 {
+			for inset < radius {
+				dx := radius - inset
+				if dx*dx+dy*dy <= radius*radius {
+					break
+				}
+				inset++
+			}
+		}
+		row := image.Rect(rect.Min.X+inset, y, rect.Max.X-inset, y+1)
+		draw.Draw(img, row, src, image.Point{}, draw.Src)
+	}
+}
@@ -0,0 +1,29 @@
+// Package collation orders text in the alphabetical order of a language,
+// by the Unicode Collation Algorithm with the CLDR tailorings ICU also
+// uses, rather than by code point. Sort keys let a database do the
+// ordering: comparing two keys byte by byte orders them as their texts.
+package collation
+
+import (
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// urdu orders text in Urdu alphabetical order, e.g. ٹ after ت and گ after
+// ک. A collator isn't safe for concurrent use, so the mutex guards it.
+var urdu = struct {
+	sync.Mutex
+	*collate.Collator
+	buf collate.Buffer
+}{Collator: collate.New(language.Urdu)}
+
+// UrduKey returns the sort key of text in Urdu alphabetical order
+func UrduKey(text string) []byte {
+	urdu.Lock()
+	defer urdu.Unlock()
+	defer urdu.buf.Reset()
+	key := urdu.KeyFromString(&urdu.buf, text)
+	return append([]byte(nil), key...)
+}
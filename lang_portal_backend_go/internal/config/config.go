@@ -0,0 +1,663 @@
+// Package config loads the server's settings. Each setting has a default,
+// which an optional YAML file, environment variables and command-line flags
+// override in that order.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables overriding the settings of the file
+const (
+	// FileEnv holds the path of the YAML file to read settings from
+	FileEnv        = "LANG_PORTAL_CONFIG"
+	PortEnv        = "LANG_PORTAL_PORT"
+	DatabaseURLEnv = "LANG_PORTAL_DATABASE_URL"
+	SeedsDirEnv    = "LANG_PORTAL_SEEDS_DIR"
+	LogLevelEnv    = "LANG_PORTAL_LOG_LEVEL"
+	// CORSOriginsEnv holds a comma-separated list of origins
+	CORSOriginsEnv = "LANG_PORTAL_CORS_ORIGINS"
+	RateLimitEnv   = "LANG_PORTAL_RATE_LIMIT"
+	RateBurstEnv   = "LANG_PORTAL_RATE_BURST"
+	// ShutdownTimeoutEnv and the other timeouts hold a duration, e.g. "30s"
+	ShutdownTimeoutEnv = "LANG_PORTAL_SHUTDOWN_TIMEOUT"
+	ReadTimeoutEnv     = "LANG_PORTAL_READ_TIMEOUT"
+	WriteTimeoutEnv    = "LANG_PORTAL_WRITE_TIMEOUT"
+	IdleTimeoutEnv     = "LANG_PORTAL_IDLE_TIMEOUT"
+	MaxBodyBytesEnv    = "LANG_PORTAL_MAX_BODY_BYTES"
+	MaxUploadBytesEnv  = "LANG_PORTAL_MAX_UPLOAD_BYTES"
+	TLSCertFileEnv     = "LANG_PORTAL_TLS_CERT"
+	TLSKeyFileEnv      = "LANG_PORTAL_TLS_KEY"
+	// TLSDomainsEnv holds a comma-separated list of domains
+	TLSDomainsEnv   = "LANG_PORTAL_TLS_DOMAINS"
+	TLSCacheDirEnv  = "LANG_PORTAL_TLS_CACHE_DIR"
+	TLSEmailEnv     = "LANG_PORTAL_TLS_EMAIL"
+	RedirectPortEnv = "LANG_PORTAL_REDIRECT_PORT"
+	JobWorkersEnv   = "LANG_PORTAL_JOB_WORKERS"
+	// OTLPEndpointEnv and ServiceNameEnv are the standard OpenTelemetry
+	// variables, so collectors' usual setups work unchanged
+	OTLPEndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	ServiceNameEnv  = "OTEL_SERVICE_NAME"
+	// StorageBackendEnv selects where media is kept: local or s3
+	StorageBackendEnv    = "LANG_PORTAL_STORAGE_BACKEND"
+	StorageDirEnv        = "LANG_PORTAL_STORAGE_DIR"
+	StorageURLExpiryEnv  = "LANG_PORTAL_STORAGE_URL_EXPIRY"
+	StorageSigningKeyEnv = "LANG_PORTAL_STORAGE_SIGNING_KEY"
+	S3EndpointEnv        = "LANG_PORTAL_S3_ENDPOINT"
+	S3RegionEnv          = "LANG_PORTAL_S3_REGION"
+	S3BucketEnv          = "LANG_PORTAL_S3_BUCKET"
+	// S3PathStyleEnv holds true or false
+	S3PathStyleEnv = "LANG_PORTAL_S3_PATH_STYLE"
+	// S3AccessKeyEnv and S3SecretKeyEnv are the standard AWS variables
+	S3AccessKeyEnv = "AWS_ACCESS_KEY_ID"
+	S3SecretKeyEnv = "AWS_SECRET_ACCESS_KEY"
+	// CacheBackendEnv selects where cached values are kept: memory or redis
+	CacheBackendEnv = "LANG_PORTAL_CACHE_BACKEND"
+	RedisURLEnv     = "LANG_PORTAL_REDIS_URL"
+)
+
+// Cache backends
+const (
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// Storage backends
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// maxURLExpiry is the longest S3 lets a presigned URL stay valid
+const maxURLExpiry = 7 * 24 * time.Hour
+
+// Log levels, from the most to the least verbose
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// Config is the server's settings
+type Config struct {
+	// Port is the port the server listens on
+	Port int `yaml:"port"`
+	// DatabaseURL is the path of a SQLite file
+	DatabaseURL string `yaml:"database_url"`
+	// SeedsDir is the directory seed packs are read from
+	SeedsDir string `yaml:"seeds_dir"`
+	// LogLevel is debug, info, warn or error. Requests are logged at info,
+	// client errors at warn and server errors at error.
+	LogLevel string `yaml:"log_level"`
+	// CORSOrigins are the origins browsers may call the API from, or "*"
+	// for any origin
+	CORSOrigins []string  `yaml:"cors_origins"`
+	RateLimit   RateLimit `yaml:"rate_limit"`
+	// ShutdownTimeout is how long the server waits for requests in flight
+	// to finish when it is stopped
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ReadTimeout is how long a client may take to send a request, headers
+	// and body
+	ReadTimeout time.Duration `yaml:"read_timeout"`
+	// WriteTimeout is how long the server may take to answer a request once
+	// its headers are read
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// IdleTimeout is how long a keep-alive connection is held open between
+	// requests
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// MaxBodyBytes is the largest request body the server accepts
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// MaxUploadBytes is the largest multipart request body, such as a photo
+	// to import, the server accepts
+	MaxUploadBytes int64 `yaml:"max_upload_bytes"`
+	TLS            TLS   `yaml:"tls"`
+	// JobWorkers is how many background jobs run at once
+	JobWorkers int     `yaml:"job_workers"`
+	Tracing    Tracing `yaml:"tracing"`
+	Storage    Storage `yaml:"storage"`
+	Cache      Cache   `yaml:"cache"`
+}
+
+// Cache configures where cached dashboard statistics, idempotency keys and
+// rate limit counters are kept
+type Cache struct {
+	// Backend is memory, which forgets everything when the server restarts,
+	// or redis, which replicas of the server can share
+	Backend string `yaml:"backend"`
+	// RedisURL is the Redis to use, e.g. redis://:password@localhost:6379/0
+	RedisURL string `yaml:"redis_url"`
+}
+
+// TLS configures serving HTTPS without a reverse proxy, with either a
+// certificate and key or certificates from Let's Encrypt
+type TLS struct {
+	// CertFile and KeyFile are the PEM files of the certificate and its key
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// Domains are the domains to get certificates from Let's Encrypt for
+	Domains []string `yaml:"domains"`
+	// CacheDir is where certificates from Let's Encrypt are kept between
+	// restarts
+	CacheDir string `yaml:"cache_dir"`
+	// Email is given to Let's Encrypt to warn about expiring certificates
+	Email string `yaml:"email"`
+	// RedirectPort is a port to redirect HTTP to HTTPS from, and to answer
+	// Let's Encrypt's challenges on, or 0 for none
+	RedirectPort int `yaml:"redirect_port"`
+}
+
+// Enabled reports whether the server serves HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || len(t.Domains) > 0
+}
+
+// RateLimit limits how many requests a client may make
+type RateLimit struct {
+	// RequestsPerMinute is how many requests a client may make a minute
+	// on average, or 0 for no limit
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// Burst is how many requests a client may make at once. It defaults to
+	// RequestsPerMinute.
+	Burst int `yaml:"burst"`
+}
+
+// Tracing configures the export of request traces
+type Tracing struct {
+	// OTLPEndpoint is the base URL of an OTLP/HTTP collector, e.g.
+	// http://localhost:4318, or "" not to trace requests
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	// ServiceName names the server in the traces
+	ServiceName string `yaml:"service_name"`
+}
+
+// Storage configures where media, such as synthesized audio, is kept and
+// how long the URLs it is served from stay valid
+type Storage struct {
+	// Backend is local or s3
+	Backend string `yaml:"backend"`
+	// Dir is the directory the local backend keeps media in
+	Dir string `yaml:"dir"`
+	// URLExpiry is how long a signed URL to media stays valid
+	URLExpiry time.Duration `yaml:"url_expiry"`
+	// SigningKey signs the local backend's URLs. A random key is used if
+	// it's empty, so URLs stop working when the server restarts.
+	SigningKey string `yaml:"signing_key"`
+	S3         S3     `yaml:"s3"`
+}
+
+// S3 configures keeping media in a bucket of S3 or a server compatible
+// with it, such as MinIO
+type S3 struct {
+	// Endpoint is the server's base URL, e.g. http://localhost:9000, or ""
+	// for AWS in Region
+	Endpoint string `yaml:"endpoint"`
+	Region   string `yaml:"region"`
+	Bucket   string `yaml:"bucket"`
+	// PathStyle addresses the bucket in the path rather than the host
+	// name, as MinIO expects
+	PathStyle bool   `yaml:"path_style"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// Default returns the settings used when nothing overrides them
+func Default() *Config {
+	return &Config{
+		Port:            8080,
+		DatabaseURL:     "words.db",
+		SeedsDir:        "db/seeds",
+		LogLevel:        LogInfo,
+		CORSOrigins:     []string{"*"},
+		ShutdownTimeout: 30 * time.Second,
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    60 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		MaxBodyBytes:    1 << 20,
+		MaxUploadBytes:  10 << 20,
+		TLS:             TLS{CacheDir: "certs"},
+		JobWorkers:      2,
+		Tracing:         Tracing{ServiceName: "lang-portal"},
+		Storage: Storage{
+			Backend:   StorageLocal,
+			Dir:       "media",
+			URLExpiry: time.Hour,
+			S3:        S3{Region: "us-east-1"},
+		},
+		Cache: Cache{Backend: CacheMemory},
+	}
+}
+
+// Load reads the settings from the YAML file named by the -config flag or
+// FileEnv, if any, then the environment, then the flags in args, and
+// validates them
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(FileEnv), "YAML file to read settings from")
+	port := fs.Int("port", 0, "port to listen on")
+	databaseURL := fs.String("db", "", "SQLite file")
+	seedsDir := fs.String("seeds", "", "directory of the seed packs")
+	logLevel := fs.String("log-level", "", "debug, info, warn or error")
+	corsOrigins := fs.String("cors-origins", "", "comma-separated origins allowed to call the API, or *")
+	rateLimit := fs.Int("rate-limit", 0, "requests a client may make a minute, or 0 for no limit")
+	rateBurst := fs.Int("rate-burst", 0, "requests a client may make at once")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 0, "how long to wait for requests in flight when stopping")
+	readTimeout := fs.Duration("read-timeout", 0, "how long a client may take to send a request")
+	writeTimeout := fs.Duration("write-timeout", 0, "how long the server may take to answer a request")
+	idleTimeout := fs.Duration("idle-timeout", 0, "how long to keep idle connections open")
+	maxBodyBytes := fs.Int64("max-body-bytes", 0, "largest request body accepted, in bytes")
+	maxUploadBytes := fs.Int64("max-upload-bytes", 0, "largest multipart request body accepted, in bytes")
+	tlsCert := fs.String("tls-cert", "", "PEM file of the TLS certificate")
+	tlsKey := fs.String("tls-key", "", "PEM file of the TLS certificate's key")
+	tlsDomains := fs.String("tls-domains", "", "comma-separated domains to get certificates from Let's Encrypt for")
+	redirectPort := fs.Int("redirect-port", 0, "port to redirect HTTP to HTTPS from, or 0 for none")
+	jobWorkers := fs.Int("job-workers", 0, "how many background jobs run at once")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "OTLP/HTTP collector to export traces to")
+	storageBackend := fs.String("storage", "", "where media is kept: local or s3")
+	storageDir := fs.String("storage-dir", "", "directory the local storage keeps media in")
+	cacheBackend := fs.String("cache", "", "where cached values are kept: memory or redis")
+	redisURL := fs.String("redis-url", "", "redis:// URL of the Redis cache")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := cfg.readFile(*file); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.readEnv(); err != nil {
+		return nil, err
+	}
+
+	// Only flags given on the command line override the other settings
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = *port
+		case "db":
+			cfg.DatabaseURL = *databaseURL
+		case "seeds":
+			cfg.SeedsDir = *seedsDir
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		case "cors-origins":
+			cfg.CORSOrigins = splitList(*corsOrigins)
+		case "rate-limit":
+			cfg.RateLimit.RequestsPerMinute = *rateLimit
+		case "rate-burst":
+			cfg.RateLimit.Burst = *rateBurst
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = *shutdownTimeout
+		case "read-timeout":
+			cfg.ReadTimeout = *readTimeout
+		case "write-timeout":
+			cfg.WriteTimeout = *writeTimeout
+		case "idle-timeout":
+			cfg.IdleTimeout = *idleTimeout
+		case "max-body-bytes":
+			cfg.MaxBodyBytes = *maxBodyBytes
+		case "max-upload-bytes":
+			cfg.MaxUploadBytes = *maxUploadBytes
+		case "tls-cert":
+			cfg.TLS.CertFile = *tlsCert
+		case "tls-key":
+			cfg.TLS.KeyFile = *tlsKey
+		case "tls-domains":
+			cfg.TLS.Domains = splitList(*tlsDomains)
+		case "redirect-port":
+			cfg.TLS.RedirectPort = *redirectPort
+		case "job-workers":
+			cfg.JobWorkers = *jobWorkers
+		case "otlp-endpoint":
+			cfg.Tracing.OTLPEndpoint = *otlpEndpoint
+		case "storage":
+			cfg.Storage.Backend = *storageBackend
+		case "storage-dir":
+			cfg.Storage.Dir = *storageDir
+		case "cache":
+			cfg.Cache.Backend = *cacheBackend
+		case "redis-url":
+			cfg.Cache.RedisURL = *redisURL
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimit.Burst == 0 {
+		cfg.RateLimit.Burst = cfg.RateLimit.RequestsPerMinute
+	}
+	return cfg, nil
+}
+
+// readFile overrides the settings with those of a YAML file
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	defer file.Close()
+
+	// Misspelled settings are errors rather than silently ignored
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// readEnv overrides the settings with the environment variables that are set
+func (c *Config) readEnv() error {
+	if value := os.Getenv(PortEnv); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", PortEnv, value)
+		}
+		c.Port = port
+	}
+	if value := os.Getenv(DatabaseURLEnv); value != "" {
+		c.DatabaseURL = value
+	}
+	if value := os.Getenv(SeedsDirEnv); value != "" {
+		c.SeedsDir = value
+	}
+	if value := os.Getenv(LogLevelEnv); value != "" {
+		c.LogLevel = value
+	}
+	if value := os.Getenv(CORSOriginsEnv); value != "" {
+		c.CORSOrigins = splitList(value)
+	}
+	if value := os.Getenv(RateLimitEnv); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", RateLimitEnv, value)
+		}
+		c.RateLimit.RequestsPerMinute = limit
+	}
+	if value := os.Getenv(RateBurstEnv); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", RateBurstEnv, value)
+		}
+		c.RateLimit.Burst = burst
+	}
+	if value := os.Getenv(ShutdownTimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a duration", ShutdownTimeoutEnv, value)
+		}
+		c.ShutdownTimeout = timeout
+	}
+	for _, setting := range []struct {
+		env     string
+		timeout *time.Duration
+	}{
+		{ReadTimeoutEnv, &c.ReadTimeout},
+		{WriteTimeoutEnv, &c.WriteTimeout},
+		{IdleTimeoutEnv, &c.IdleTimeout},
+	} {
+		if value := os.Getenv(setting.env); value != "" {
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %q is not a duration", setting.env, value)
+			}
+			*setting.timeout = timeout
+		}
+	}
+	if value := os.Getenv(MaxBodyBytesEnv); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", MaxBodyBytesEnv, value)
+		}
+		c.MaxBodyBytes = limit
+	}
+	if value := os.Getenv(MaxUploadBytesEnv); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", MaxUploadBytesEnv, value)
+		}
+		c.MaxUploadBytes = limit
+	}
+	if value := os.Getenv(TLSCertFileEnv); value != "" {
+		c.TLS.CertFile = value
+	}
+	if value := os.Getenv(TLSKeyFileEnv); value != "" {
+		c.TLS.KeyFile = value
+	}
+	if value := os.Getenv(TLSDomainsEnv); value != "" {
+		c.TLS.Domains = splitList(value)
+	}
+	if value := os.Getenv(TLSCacheDirEnv); value != "" {
+		c.TLS.CacheDir = value
+	}
+	if value := os.Getenv(TLSEmailEnv); value != "" {
+		c.TLS.Email = value
+	}
+	if value := os.Getenv(RedirectPortEnv); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", RedirectPortEnv, value)
+		}
+		c.TLS.RedirectPort = port
+	}
+	if value := os.Getenv(JobWorkersEnv); value != "" {
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a number", JobWorkersEnv, value)
+		}
+		c.JobWorkers = workers
+	}
+	if value := os.Getenv(OTLPEndpointEnv); value != "" {
+		c.Tracing.OTLPEndpoint = value
+	}
+	if value := os.Getenv(ServiceNameEnv); value != "" {
+		c.Tracing.ServiceName = value
+	}
+	if value := os.Getenv(CacheBackendEnv); value != "" {
+		c.Cache.Backend = value
+	}
+	if value := os.Getenv(RedisURLEnv); value != "" {
+		c.Cache.RedisURL = value
+	}
+	return c.Storage.readEnv()
+}
+
+// readEnv overrides the storage settings with the environment variables
+// that are set
+func (s *Storage) readEnv() error {
+	if value := os.Getenv(StorageBackendEnv); value != "" {
+		s.Backend = value
+	}
+	if value := os.Getenv(StorageDirEnv); value != "" {
+		s.Dir = value
+	}
+	if value := os.Getenv(StorageURLExpiryEnv); value != "" {
+		expiry, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not a duration", StorageURLExpiryEnv, value)
+		}
+		s.URLExpiry = expiry
+	}
+	if value := os.Getenv(StorageSigningKeyEnv); value != "" {
+		s.SigningKey = value
+	}
+	if value := os.Getenv(S3EndpointEnv); value != "" {
+		s.S3.Endpoint = value
+	}
+	if value := os.Getenv(S3RegionEnv); value != "" {
+		s.S3.Region = value
+	}
+	if value := os.Getenv(S3BucketEnv); value != "" {
+		s.S3.Bucket = value
+	}
+	if value := os.Getenv(S3PathStyleEnv); value != "" {
+		pathStyle, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %q is not true or false", S3PathStyleEnv, value)
+		}
+		s.S3.PathStyle = pathStyle
+	}
+	if value := os.Getenv(S3AccessKeyEnv); value != "" {
+		s.S3.AccessKey = value
+	}
+	if value := os.Getenv(S3SecretKeyEnv); value != "" {
+		s.S3.SecretKey = value
+	}
+	return nil
+}
+
+// Validate returns an error describing the first invalid setting
+func (c *Config) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	if c.DatabaseURL == "" {
+		return fmt.Errorf("database URL is required")
+	}
+	if c.SeedsDir == "" {
+		return fmt.Errorf("seeds directory is required")
+	}
+	switch c.LogLevel {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		return fmt.Errorf("invalid log level %q, must be debug, info, warn or error", c.LogLevel)
+	}
+	if len(c.CORSOrigins) == 0 {
+		return fmt.Errorf("at least one CORS origin is required")
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid CORS origin %q, must be * or start with http:// or https://", origin)
+		}
+	}
+	if c.RateLimit.RequestsPerMinute < 0 {
+		return fmt.Errorf("invalid rate limit %d", c.RateLimit.RequestsPerMinute)
+	}
+	if c.RateLimit.Burst < 0 {
+		return fmt.Errorf("invalid rate burst %d", c.RateLimit.Burst)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid shutdown timeout %s", c.ShutdownTimeout)
+	}
+	if c.ReadTimeout <= 0 {
+		return fmt.Errorf("invalid read timeout %s", c.ReadTimeout)
+	}
+	if c.WriteTimeout <= 0 {
+		return fmt.Errorf("invalid write timeout %s", c.WriteTimeout)
+	}
+	if c.IdleTimeout <= 0 {
+		return fmt.Errorf("invalid idle timeout %s", c.IdleTimeout)
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("invalid max body bytes %d", c.MaxBodyBytes)
+	}
+	if c.MaxUploadBytes <= 0 {
+		return fmt.Errorf("invalid max upload bytes %d", c.MaxUploadBytes)
+	}
+	if c.JobWorkers < 1 {
+		return fmt.Errorf("invalid job workers %d", c.JobWorkers)
+	}
+	if err := c.TLS.validate(c.Port); err != nil {
+		return err
+	}
+	if endpoint := c.Tracing.OTLPEndpoint; endpoint != "" && !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return fmt.Errorf("invalid OTLP endpoint %q, must start with http:// or https://", endpoint)
+	}
+	if c.Tracing.ServiceName == "" {
+		return fmt.Errorf("tracing service name is required")
+	}
+	if err := c.Storage.validate(); err != nil {
+		return err
+	}
+	return c.Cache.validate()
+}
+
+// validate returns an error describing the first invalid cache setting
+func (c Cache) validate() error {
+	switch c.Backend {
+	case CacheMemory:
+	case CacheRedis:
+		if !strings.HasPrefix(c.RedisURL, "redis://") && !strings.HasPrefix(c.RedisURL, "rediss://") {
+			// The URL isn't shown, as it may hold a password
+			return fmt.Errorf("invalid Redis URL, must start with redis:// or rediss://")
+		}
+	default:
+		return fmt.Errorf("invalid cache backend %q, must be memory or redis", c.Backend)
+	}
+	return nil
+}
+
+// validate returns an error describing the first invalid storage setting
+func (s Storage) validate() error {
+	if s.URLExpiry <= 0 || s.URLExpiry > maxURLExpiry {
+		return fmt.Errorf("invalid storage URL expiry %s, must be positive and at most %s", s.URLExpiry, maxURLExpiry)
+	}
+	switch s.Backend {
+	case StorageLocal:
+		if s.Dir == "" {
+			return fmt.Errorf("storage directory is required for local storage")
+		}
+	case StorageS3:
+		if s.S3.Bucket == "" {
+			return fmt.Errorf("S3 bucket is required for s3 storage")
+		}
+		if s.S3.Region == "" {
+			return fmt.Errorf("S3 region is required for s3 storage")
+		}
+		if s.S3.AccessKey == "" || s.S3.SecretKey == "" {
+			return fmt.Errorf("S3 access key and secret key are required for s3 storage")
+		}
+		if endpoint := s.S3.Endpoint; endpoint != "" && !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+			return fmt.Errorf("invalid S3 endpoint %q, must start with http:// or https://", endpoint)
+		}
+	default:
+		return fmt.Errorf("invalid storage backend %q, must be local or s3", s.Backend)
+	}
+	return nil
+}
+
+// validate returns an error describing the first invalid TLS setting. port
+// is the port HTTPS is served on.
+func (t TLS) validate(port int) error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS certificate and key files must be set together")
+	}
+	if t.CertFile != "" && len(t.Domains) > 0 {
+		return fmt.Errorf("TLS certificate files and Let's Encrypt domains can't both be set")
+	}
+	if len(t.Domains) > 0 && t.CacheDir == "" {
+		return fmt.Errorf("TLS cache directory is required for Let's Encrypt")
+	}
+	if t.RedirectPort != 0 {
+		if !t.Enabled() {
+			return fmt.Errorf("redirect port %d requires TLS", t.RedirectPort)
+		}
+		if t.RedirectPort < 1 || t.RedirectPort > 65535 || t.RedirectPort == port {
+			return fmt.Errorf("invalid redirect port %d", t.RedirectPort)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
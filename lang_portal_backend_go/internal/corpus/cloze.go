@@ -0,0 +1,48 @@
+package corpus
+
+import "math/rand"
+
+// Blank replaces the word asked for in a cloze question's prompt
+const Blank = "____"
+
+// OptionCount is the number of options of a multiple choice cloze
+// question, including the answer
+const OptionCount = 4
+
+// Cloze blanks the occurrence of a word out of text, returning the prompt
+// and the text blanked, as it is written there. An occurrence outside text
+// leaves it whole and returns no answer.
+func Cloze(text string, occurrence Occurrence) (prompt, answer string) {
+	runes := []rune(text)
+	end := occurrence.Position + occurrence.Length
+	if occurrence.Position < 0 || occurrence.Length <= 0 || end > len(runes) {
+		return text, ""
+	}
+	return string(runes[:occurrence.Position]) + Blank + string(runes[end:]), string(runes[occurrence.Position:end])
+}
+
+// Options returns the options of a multiple choice question about answer:
+// up to OptionCount-1 of the candidates, taken in order, that don't read as
+// answer or as each other, and answer, shuffled. Candidates should come
+// best first, such as the forms of other verbs of the same tense before
+// other words.
+func Options(answer string, candidates []string, rng *rand.Rand) []string {
+	seen := map[string]bool{Key(answer): true}
+	options := []string{}
+	for _, candidate := range candidates {
+		key := Key(candidate)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, candidate)
+		if len(options) == OptionCount-1 {
+			break
+		}
+	}
+	options = append(options, answer)
+	rng.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	return options
+}
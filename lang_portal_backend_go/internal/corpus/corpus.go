@@ -0,0 +1,192 @@
+// Package corpus splits sentences into tokens and finds the words of a
+// vocabulary they contain. Tokens are compared as spelling.Normalize
+// compares answers, so case, diacritics and Arabic and Urdu letter variants
+// don't keep a word from being found.
+package corpus
+
+import (
+	"strings"
+	"unicode"
+
+	"lang_portal/internal/spelling"
+)
+
+// MaxPhraseTokens is the most tokens a word of a vocabulary can span, e.g.
+// a compound verb such as شروع کرنا
+const MaxPhraseTokens = 6
+
+// Token is a word of a sentence as it is written there
+type Token struct {
+	Text string
+	// Normalized is Text as spelling.Normalize writes it
+	Normalized string
+	// Position and Length are the token's offset and length in runes
+	Position int
+	Length   int
+}
+
+// Occurrence is a word of a vocabulary found in a sentence
+type Occurrence struct {
+	WordID int64
+	// Position and Length are the offset and length in runes of the tokens
+	// the word spans
+	Position int
+	Length   int
+}
+
+// joiner reports whether r joins letters into one token without being one:
+// marks, tatweel and the zero-width joiners Urdu writes some words with
+func joiner(r rune) bool {
+	return unicode.Is(unicode.Mn, r) || r == 'ـ' || r == '\u200c' || r == '\u200d'
+}
+
+// Normalize returns text as its tokens are compared: normalized by
+// spelling.Normalize, without the zero-width joiners it would split words at
+func Normalize(text string) string {
+	return spelling.Normalize(strings.NewReplacer("\u200c", "", "\u200d", "").Replace(text))
+}
+
+// Tokenize splits text into its runs of letters and digits, with the marks
+// and joiners within them. Everything else separates tokens.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || joiner(runes[i])) {
+			i++
+		}
+		token := string(runes[start:i])
+		tokens = append(tokens, Token{Text: token, Normalized: Normalize(token), Position: start, Length: i - start})
+	}
+	return tokens
+}
+
+// Key returns the tokens of text joined as an Index looks them up, or "" if
+// text has no tokens. Sentences are searched for phrases by their keys,
+// padded with a space either side.
+func Key(text string) string {
+	tokens := Tokenize(text)
+	normalized := make([]string, len(tokens))
+	for i, token := range tokens {
+		normalized[i] = token.Normalized
+	}
+	return strings.Join(normalized, " ")
+}
+
+// Index finds the words of a vocabulary in sentences. The zero value is
+// empty and ready to use.
+type Index struct {
+	words map[string][]int64
+	// longest is the most tokens a form spans
+	longest int
+}
+
+// Add adds a form of a word, such as how it is written or one of its
+// conjugations. Forms of more than MaxPhraseTokens tokens are ignored.
+func (x *Index) Add(form string, wordID int64) {
+	tokens := Tokenize(form)
+	if len(tokens) == 0 || len(tokens) > MaxPhraseTokens {
+		return
+	}
+	key := Key(form)
+	if x.words == nil {
+		x.words = map[string][]int64{}
+	}
+	for _, id := range x.words[key] {
+		if id == wordID {
+			return
+		}
+	}
+	x.words[key] = append(x.words[key], wordID)
+	x.longest = max(x.longest, len(tokens))
+}
+
+// Len returns the number of forms in the index
+func (x *Index) Len() int {
+	return len(x.words)
+}
+
+// Find returns the words of the index that occur in tokens, in the order
+// they occur. A word spanning several tokens is found as well as any words
+// of its tokens, and a word is found as often as it occurs.
+func (x *Index) Find(tokens []Token) []Occurrence {
+	var occurrences []Occurrence
+	for i := range tokens {
+		key := ""
+		for n := 1; n <= x.longest && i+n <= len(tokens); n++ {
+			if n > 1 {
+				key += " "
+			}
+			key += tokens[i+n-1].Normalized
+			last := tokens[i+n-1]
+			for _, id := range x.words[key] {
+				occurrences = append(occurrences, Occurrence{
+					WordID:   id,
+					Position: tokens[i].Position,
+					Length:   last.Position + last.Length - tokens[i].Position,
+				})
+			}
+		}
+	}
+	return occurrences
+}
+
+// Segment is a piece of a text: a word, a run of tokens read as one word of
+// the index, or the spaces and punctuation between them
+type Segment struct {
+	Text string
+	// Position and Length are the segment's offset and length in runes
+	Position int
+	Length   int
+	// Word is false for the text between words
+	Word bool
+	// WordIDs are the words of the index the segment reads as, none if it
+	// is no word of the index
+	WordIDs []int64
+}
+
+// Segment splits text into segments that together make it up. At each
+// token the longest form of the index starting there is read as one word,
+// so a compound verb is one segment rather than two.
+func (x *Index) Segment(text string) []Segment {
+	runes := []rune(text)
+	tokens := Tokenize(text)
+	var segments []Segment
+	gap := func(from, to int) {
+		if to > from {
+			segments = append(segments, Segment{Text: string(runes[from:to]), Position: from, Length: to - from})
+		}
+	}
+
+	end := 0
+	for i := 0; i < len(tokens); {
+		gap(end, tokens[i].Position)
+		n, key, ids := 1, "", []int64(nil)
+		for m := 1; m <= x.longest && i+m <= len(tokens); m++ {
+			if m > 1 {
+				key += " "
+			}
+			key += tokens[i+m-1].Normalized
+			if found := x.words[key]; len(found) > 0 {
+				n, ids = m, found
+			}
+		}
+		last := tokens[i+n-1]
+		end = last.Position + last.Length
+		segments = append(segments, Segment{
+			Text:     string(runes[tokens[i].Position:end]),
+			Position: tokens[i].Position,
+			Length:   end - tokens[i].Position,
+			Word:     true,
+			WordIDs:  ids,
+		})
+		i += n
+	}
+	gap(end, len(runes))
+	return segments
+}
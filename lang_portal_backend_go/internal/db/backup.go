@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup writes a consistent copy of the database to path, which must not
+// exist yet. Reads and writes carry on while it is written.
+func Backup(ctx context.Context, conn *sql.DB, path string) error {
+	_, err := conn.ExecContext(ctx, `VACUUM INTO ?`, path)
+	return err
+}
+
+// OpenBackup opens the backup at path to be read. It is opened read-only,
+// so it is left as it is.
+func OpenBackup(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return sql.Open("sqlite3", "file:"+path+"?mode=ro")
+}
+
+// Restore replaces the contents of the database with those of the backup
+// at path, using SQLite's online backup API. Other connections see the
+// restored database once it is written.
+func Restore(ctx context.Context, conn *sql.DB, path string) error {
+	src, err := OpenBackup(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	destConn, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(dest interface{}) error {
+		return srcConn.Raw(func(source interface{}) error {
+			destSQLite, ok := dest.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("database is not SQLite")
+			}
+			backup, err := destSQLite.Backup("main", source.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			// Copy every page in one step
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,43 @@
+// Package db opens the SQLite database the portal stores its data in.
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Connection settings of every SQLite database the portal opens
+const (
+	// busyTimeoutMillis is how long a connection waits for another
+	// process's lock before failing with SQLITE_BUSY
+	busyTimeoutMillis = "5000"
+	// maxOpenConns limits the connections of the pool. With WAL, readers
+	// don't block each other or the writer.
+	maxOpenConns = 8
+)
+
+// Open opens the SQLite database at path, creating it if it doesn't exist,
+// in WAL mode with foreign keys enforced and a busy timeout. An in-memory
+// database, ":memory:", is opened on a single connection since each
+// connection to it gets a new, empty database.
+func Open(path string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := path + sep + "_journal=WAL&_fk=true&_busy_timeout=" + busyTimeoutMillis
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(maxOpenConns)
+		db.SetMaxIdleConns(maxOpenConns)
+	}
+	return db, nil
+}
@@ -0,0 +1,59 @@
+// Package dialect covers the differences between the SQL of the databases
+// the portal can store its data in.
+package dialect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is a database the portal can store its data in, named after its
+// database/sql driver
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite3"
+	Postgres Dialect = "postgres"
+)
+
+// FromURL returns the dialect of a database URL: PostgreSQL for
+// postgres:// and postgresql:// URLs and SQLite for anything else, which is
+// taken to be a file path
+func FromURL(url string) Dialect {
+	if strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
+}
+
+// Rebind rewrites the ? and ?NNN placeholders of a query written for SQLite
+// into the $N placeholders PostgreSQL expects. Bare ? are numbered in order.
+// Queries must not contain a literal ?.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+		j := i + 1
+		for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+			j++
+		}
+		b.WriteByte('$')
+		if j > i+1 {
+			b.WriteString(query[i+1 : j])
+		} else {
+			n++
+			b.WriteString(strconv.Itoa(n))
+		}
+		i = j - 1
+	}
+	return b.String()
+}
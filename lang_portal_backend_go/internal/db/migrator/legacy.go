@@ -0,0 +1,461 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// LegacyVersion is the schema version of databases created before
+// migrations were versioned, once upgradeLegacySchema has run
+const LegacyVersion = 19
+
+// upgradeLegacySchema brings a database created before migrations were
+// versioned up to LegacyVersion and records the migrations up to it as
+// applied. Such databases have tables but no schema_migrations table; the
+// schema used to be brought up to date by hand every time the server
+// started. Other databases are left alone.
+func (m *Migrator) upgradeLegacySchema() error {
+	// Begin a transaction
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if versioned, err := tableExists(tx, "schema_migrations"); err != nil || versioned {
+		return err
+	}
+	if legacy, err := tableExists(tx, "words"); err != nil || !legacy {
+		return err
+	}
+
+	// Create the tables added since the first migration
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL UNIQUE,
+			email TEXT,
+			password_hash TEXT,
+			guest_expires_at DATETIME,
+			leaderboard_opt_in BOOLEAN NOT NULL DEFAULT 0,
+			leaderboard_name TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		// History recorded before users existed belongs to the default user
+		`INSERT OR IGNORE INTO users (id, username) VALUES (1, 'default')`,
+		`CREATE TABLE IF NOT EXISTS study_session_words (
+			study_session_id INTEGER NOT NULL,
+			word_id INTEGER NOT NULL,
+			FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+			FOREIGN KEY (word_id) REFERENCES words(id),
+			PRIMARY KEY (study_session_id, word_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS quiz_questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			study_session_id INTEGER NOT NULL,
+			word_id INTEGER NOT NULL,
+			direction TEXT NOT NULL,
+			answer_mode TEXT NOT NULL DEFAULT 'multiple_choice',
+			options TEXT NOT NULL,
+			correct_answer TEXT NOT NULL,
+			hints_used INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+			FOREIGN KEY (word_id) REFERENCES words(id),
+			UNIQUE(study_session_id, word_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS quiz_answers (
+			quiz_question_id INTEGER PRIMARY KEY,
+			answer TEXT NOT NULL,
+			correct BOOLEAN NOT NULL,
+			near_miss BOOLEAN NOT NULL DEFAULT 0,
+			answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (quiz_question_id) REFERENCES quiz_questions(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS word_embeddings (
+			word_id INTEGER PRIMARY KEY,
+			model TEXT NOT NULL,
+			dimensions INTEGER NOT NULL,
+			vector TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (word_id) REFERENCES words(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS flashcards (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			study_session_id INTEGER NOT NULL,
+			word_id INTEGER NOT NULL,
+			flips INTEGER NOT NULL DEFAULT 0,
+			rating TEXT,
+			rated_at DATETIME,
+			FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+			FOREIGN KEY (word_id) REFERENCES words(id),
+			UNIQUE(study_session_id, word_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS listening_clips (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			audio_url TEXT NOT NULL,
+			transcript TEXT NOT NULL,
+			difficulty TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (group_id) REFERENCES groups(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS listening_questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			clip_id INTEGER NOT NULL,
+			word_id INTEGER,
+			prompt TEXT NOT NULL,
+			options TEXT NOT NULL,
+			correct_answer TEXT NOT NULL,
+			FOREIGN KEY (clip_id) REFERENCES listening_clips(id),
+			FOREIGN KEY (word_id) REFERENCES words(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS listening_answers (
+			study_session_id INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			answer TEXT NOT NULL,
+			correct BOOLEAN NOT NULL,
+			answered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (study_session_id, question_id),
+			FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+			FOREIGN KEY (question_id) REFERENCES listening_questions(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS word_game_rounds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			study_session_id INTEGER NOT NULL,
+			word_id INTEGER NOT NULL,
+			game TEXT NOT NULL,
+			answer TEXT NOT NULL,
+			puzzle TEXT NOT NULL DEFAULT '',
+			guesses TEXT NOT NULL DEFAULT '[]',
+			wrong_guesses INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL DEFAULT 'playing',
+			FOREIGN KEY (study_session_id) REFERENCES study_sessions(id),
+			FOREIGN KEY (word_id) REFERENCES words(id),
+			UNIQUE(study_session_id, word_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS word_audio (
+			word_id INTEGER PRIMARY KEY,
+			url TEXT NOT NULL,
+			FOREIGN KEY (word_id) REFERENCES words(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS stats_rollup_state (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			rolled_up_to TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS classes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			teacher_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			invite_code TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (teacher_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS class_students (
+			class_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (class_id, user_id),
+			FOREIGN KEY (class_id) REFERENCES classes(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS class_assignments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			class_id INTEGER NOT NULL,
+			group_id INTEGER NOT NULL,
+			due_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (class_id) REFERENCES classes(id),
+			FOREIGN KEY (group_id) REFERENCES groups(id)
+		)`,
+		// Sessions created before study_session_words existed kept their
+		// word list as pre-filled review items
+		`INSERT OR IGNORE INTO study_session_words (study_session_id, word_id)
+		SELECT study_session_id, word_id FROM word_review_items`,
+	}
+
+	// Execute schema
+	for _, query := range schema {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute schema: %v", err)
+		}
+	}
+
+	// Add columns introduced after a table was first created
+	if err := ensureColumn(tx, "words", "parts", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "study_sessions", "difficulty", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "study_sessions", "parent_session_id", "INTEGER REFERENCES study_sessions(id)"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "word_review_items", "near_miss", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "quiz_questions", "answer_mode", "TEXT NOT NULL DEFAULT 'multiple_choice'"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "quiz_questions", "hints_used", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	// word_learning_state is created below; one created before relearning
+	// steps is brought up to date first so its rows can be copied over
+	if exists, err := tableExists(tx, "word_learning_state"); err != nil {
+		return err
+	} else if exists {
+		if err := ensureColumn(tx, "word_learning_state", "relearning_step", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		if err := ensureColumn(tx, "word_learning_state", "lapsed_interval_days", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+	if err := ensureColumn(tx, "users", "password_hash", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "users", "email", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "users", "guest_expires_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "users", "leaderboard_opt_in", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "users", "leaderboard_name", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "study_sessions", "user_id", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "word_review_items", "user_id", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	// Reviews are upserted on a session's word, which these databases
+	// didn't enforce. Only the newest review of a word in a session is
+	// kept, as an upsert would have, so migration 0021 can add the
+	// constraint.
+	_, err = tx.Exec(`
+		DELETE FROM word_review_items
+		WHERE rowid NOT IN (SELECT MAX(rowid) FROM word_review_items GROUP BY study_session_id, word_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to de-duplicate reviews: %v", err)
+	}
+
+	// Create the tables keyed by user, rebuilding those created before
+	// users existed
+	for _, table := range userScopedTables {
+		if err := createUserScopedTable(tx, table.name, table.definition, table.columns); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM stats_rollup_state WHERE NOT EXISTS (SELECT 1 FROM daily_stats)`); err != nil {
+		return fmt.Errorf("failed to reset stats rollup state: %v", err)
+	}
+
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_word_learning_state_due_at ON word_learning_state(user_id, due_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_daily_word_stats_word ON daily_word_stats(user_id, word_id, learned)`,
+		`CREATE INDEX IF NOT EXISTS idx_study_sessions_user ON study_sessions(user_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_word_review_items_user ON word_review_items(user_id, created_at)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_guest_expires_at ON users(guest_expires_at)`,
+	}
+	for _, query := range indexes {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to create index: %v", err)
+		}
+	}
+
+	// Record the migrations the schema now matches
+	_, err = tx.Exec(`
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > LegacyVersion {
+			break
+		}
+		_, err := tx.Exec(`
+			INSERT INTO schema_migrations (version, name) VALUES (?, ?)
+		`, migration.Version, migration.Name)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %04d_%s: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// userScopedTables are keyed by user. columns lists the columns kept when a
+// table created before users existed is rebuilt; rollups are left empty to
+// be rolled up again.
+var userScopedTables = []struct {
+	name       string
+	definition string
+	columns    string
+}{
+	{"word_learning_state", `CREATE TABLE word_learning_state (
+		user_id INTEGER NOT NULL DEFAULT 1,
+		word_id INTEGER NOT NULL,
+		ease_factor REAL NOT NULL DEFAULT 2.5,
+		interval_days INTEGER NOT NULL DEFAULT 0,
+		repetitions INTEGER NOT NULL DEFAULT 0,
+		lapses INTEGER NOT NULL DEFAULT 0,
+		due_at DATETIME NOT NULL,
+		last_reviewed_at DATETIME,
+		relearning_step INTEGER NOT NULL DEFAULT 0,
+		lapsed_interval_days INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, word_id),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (word_id) REFERENCES words(id)
+	)`, "word_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, relearning_step, lapsed_interval_days"},
+	{"goals", `CREATE TABLE goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL DEFAULT 1,
+		kind TEXT NOT NULL,
+		target REAL NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		UNIQUE(user_id, kind)
+	)`, "id, kind, target, created_at, updated_at"},
+	{"daily_word_stats", `CREATE TABLE daily_word_stats (
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		word_id INTEGER NOT NULL,
+		reviews INTEGER NOT NULL,
+		correct INTEGER NOT NULL,
+		learned BOOLEAN NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, day, word_id),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (word_id) REFERENCES words(id)
+	)`, ""},
+	{"daily_group_stats", `CREATE TABLE daily_group_stats (
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		group_id INTEGER NOT NULL,
+		reviews INTEGER NOT NULL,
+		correct INTEGER NOT NULL,
+		words_reviewed INTEGER NOT NULL,
+		new_words_learned INTEGER NOT NULL,
+		PRIMARY KEY (user_id, group_id, day),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (group_id) REFERENCES groups(id)
+	)`, ""},
+	{"daily_stats", `CREATE TABLE daily_stats (
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		reviews INTEGER NOT NULL,
+		correct INTEGER NOT NULL,
+		words_reviewed INTEGER NOT NULL,
+		new_words_learned INTEGER NOT NULL,
+		PRIMARY KEY (user_id, day),
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	)`, ""},
+}
+
+// createUserScopedTable creates a table keyed by user. A table of the same
+// name without a user_id column is replaced, with the given columns of its
+// rows copied over to the default user.
+func createUserScopedTable(tx *sql.Tx, table, definition, columns string) error {
+	exists, err := tableExists(tx, table)
+	if err != nil {
+		return err
+	}
+	if exists {
+		scoped, err := hasColumn(tx, table, "user_id")
+		if err != nil || scoped {
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s_single_user", table, table)); err != nil {
+			return fmt.Errorf("failed to rename %s: %v", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(definition); err != nil {
+		return fmt.Errorf("failed to create %s: %v", table, err)
+	}
+	if !exists {
+		return nil
+	}
+
+	if columns != "" {
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (user_id, %s) SELECT 1, %s FROM %s_single_user",
+			table, columns, columns, table))
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %v", table, err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DROP TABLE %s_single_user", table)); err != nil {
+		return fmt.Errorf("failed to drop old %s: %v", table, err)
+	}
+	return nil
+}
+
+// tableExists reports whether a table has been created
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)
+	`, table).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %v", table, err)
+	}
+	return exists, nil
+}
+
+// ensureColumn adds a column to an existing table if it isn't there yet
+func ensureColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := hasColumn(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// hasColumn reports whether a table has a column
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan column of %s: %v", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
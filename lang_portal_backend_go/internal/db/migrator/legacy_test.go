@@ -0,0 +1,108 @@
+package migrator
+
+import (
+	"lang_portal/db/migrations"
+	sqlitedb "lang_portal/internal/db"
+	"strings"
+	"testing"
+)
+
+// legacySchema is the schema of databases created before migrations were
+// versioned, which didn't enforce one review of a word per session
+func legacySchema(t *testing.T) string {
+	t.Helper()
+	init, err := migrations.FS.ReadFile("0001_init.sql")
+	if err != nil {
+		t.Fatalf("failed to read first migration: %v", err)
+	}
+	schema := strings.Replace(string(init), ",\n    UNIQUE(study_session_id, word_id)\n);", "\n);", 1)
+	if schema == string(init) {
+		t.Fatal("first migration has no unique review constraint to drop")
+	}
+	return schema
+}
+
+func TestUpgradeLegacyRepeatedReviews(t *testing.T) {
+	tests := []struct {
+		name string
+		// reviews are the correct column of each review of word 1 in
+		// session 1, oldest first
+		reviews []bool
+	}{
+		{name: "reviewed once", reviews: []bool{true}},
+		{name: "reviewed twice", reviews: []bool{false, true}},
+		{name: "reviewed three times", reviews: []bool{true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlitedb.Open(":memory:")
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer db.Close()
+
+			if _, err := db.Exec(legacySchema(t)); err != nil {
+				t.Fatalf("failed to create legacy schema: %v", err)
+			}
+			_, err = db.Exec(`
+				INSERT INTO words (id, urdu, urdlish, english) VALUES (1, 'سلام', 'salaam', 'hello'), (2, 'شکریہ', 'shukriya', 'thank you');
+				INSERT INTO study_sessions (id, group_id, created_at, study_activity_id) VALUES (1, 1, CURRENT_TIMESTAMP, 1);
+				INSERT INTO word_review_items (word_id, study_session_id, correct, created_at) VALUES (2, 1, 1, CURRENT_TIMESTAMP);
+			`)
+			if err != nil {
+				t.Fatalf("failed to insert legacy data: %v", err)
+			}
+			for _, correct := range tt.reviews {
+				_, err := db.Exec(`
+					INSERT INTO word_review_items (word_id, study_session_id, correct, created_at)
+					VALUES (1, 1, ?, CURRENT_TIMESTAMP)
+				`, correct)
+				if err != nil {
+					t.Fatalf("failed to insert review: %v", err)
+				}
+			}
+
+			m, err := NewMigrator(db, migrations.FS)
+			if err != nil {
+				t.Fatalf("NewMigrator() error = %v", err)
+			}
+			if _, err := m.Up(); err != nil {
+				t.Fatalf("Up() error = %v", err)
+			}
+
+			var count int
+			var correct bool
+			err = db.QueryRow(`
+				SELECT COUNT(*), MAX(correct) FROM word_review_items WHERE study_session_id = 1 AND word_id = 1
+			`).Scan(&count, &correct)
+			if err != nil {
+				t.Fatalf("failed to count reviews: %v", err)
+			}
+			if count != 1 {
+				t.Fatalf("kept %d reviews of the word, want 1", count)
+			}
+			if want := tt.reviews[len(tt.reviews)-1]; correct != want {
+				t.Errorf("kept review with correct = %v, want the newest, %v", correct, want)
+			}
+
+			var others int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM word_review_items WHERE word_id = 2`).Scan(&others); err != nil {
+				t.Fatalf("failed to count reviews: %v", err)
+			}
+			if others != 1 {
+				t.Errorf("kept %d reviews of the other word, want 1", others)
+			}
+
+			// The upsert reviews are recorded with can now update the review
+			_, err = db.Exec(`
+				INSERT INTO word_review_items (word_id, study_session_id, correct, near_miss, created_at, user_id)
+				VALUES (1, 1, 1, 0, CURRENT_TIMESTAMP, 1)
+				ON CONFLICT(study_session_id, word_id) DO UPDATE SET correct = excluded.correct
+			`)
+			if err != nil {
+				t.Fatalf("failed to review the word again: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"lang_portal/internal/db/dialect"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// foreignKeysOff marks a SQLite migration that rebuilds tables other tables
+// refer to. It runs with foreign keys off, as SQLite's table rebuild
+// procedure requires, and fails if it leaves any foreign key violated.
+const foreignKeysOff = "-- foreign_keys: off"
+
+// Migration is a versioned SQL script that changes the schema
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	// ForeignKeysOff is set for migrations marked with foreignKeysOff
+	ForeignKeysOff bool
+}
+
+// Migrator applies the migrations that haven't been applied to a database
+// yet, recording each in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect.Dialect
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the SQLite migrations in a directory,
+// named <version>_<name>.sql
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	return NewDialectMigrator(db, dialect.SQLite, fsys)
+}
+
+// NewDialectMigrator creates a migrator for migrations written for a
+// database of the given dialect
+func NewDialectMigrator(db *sql.DB, d dialect.Dialect, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %v", err)
+	}
+
+	versions := map[int]string{}
+	var migrations []Migration
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration name %s", file)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, file)
+		}
+		versions[version] = file
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", file, err)
+		}
+		migrations = append(migrations, Migration{
+			Version:        version,
+			Name:           name,
+			SQL:            string(data),
+			ForeignKeysOff: d == dialect.SQLite && strings.HasPrefix(string(data), foreignKeysOff),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest migration, or 0 if there are none
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the newest migration applied to the
+// database, or 0 if none have been
+func (m *Migrator) Version() (int, error) {
+	if err := m.createVersionTable(); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %v", err)
+	}
+	return version, nil
+}
+
+// Pending returns the migrations that haven't been applied yet, oldest first
+func (m *Migrator) Pending() ([]Migration, error) {
+	// Only SQLite databases predate versioned migrations
+	if m.dialect == dialect.SQLite {
+		if err := m.upgradeLegacySchema(); err != nil {
+			return nil, err
+		}
+	}
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in version order, each in its own
+// transaction, and returns them. It stops at the first that fails, leaving
+// the ones before it applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		if err := m.apply(migration); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	ctx := context.Background()
+	// Foreign keys can only be turned off outside a transaction, so the
+	// migration runs on a connection of its own
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if migration.ForeignKeysOff {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return fmt.Errorf("failed to turn off foreign keys: %v", err)
+		}
+		defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	}
+
+	// Begin a transaction
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return fmt.Errorf("failed to apply migration %04d_%s: %v", migration.Version, migration.Name, err)
+	}
+	if migration.ForeignKeysOff {
+		if err := checkForeignKeys(tx); err != nil {
+			return fmt.Errorf("migration %04d_%s violates foreign keys: %v", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec(m.dialect.Rebind(`
+		INSERT INTO schema_migrations (version, name) VALUES (?, ?)
+	`), migration.Version, migration.Name); err != nil {
+		return fmt.Errorf("failed to record migration %04d_%s: %v", migration.Version, migration.Name, err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// checkForeignKeys returns an error naming the first row that refers to a
+// row that doesn't exist
+func checkForeignKeys(tx *sql.Tx) error {
+	var (
+		table  string
+		rowID  sql.NullInt64
+		parent string
+		fkID   int
+	)
+	err := tx.QueryRow(`PRAGMA foreign_key_check`).Scan(&table, &rowID, &parent, &fkID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %v", err)
+	}
+	return fmt.Errorf("row %d of %s refers to a missing row of %s", rowID.Int64, table, parent)
+}
+
+func (m *Migrator) createVersionTable() error {
+	timestamp := "DATETIME"
+	if m.dialect == dialect.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at ` + timestamp + ` DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
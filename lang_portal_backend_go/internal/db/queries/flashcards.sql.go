@@ -0,0 +1,112 @@
+// Written by hand in the form sqlc generates from the queries in
+// db/queries/flashcards.sql; `mage generate` replaces it with sqlc's output.
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const getFlashcardDeck = `-- name: GetFlashcardDeck :one
+SELECT ss.group_id, ss.created_at
+FROM study_sessions ss
+JOIN study_activities sa ON sa.id = ss.study_activity_id
+WHERE ss.id = ? AND sa.name = ? AND ss.user_id = ?
+`
+
+type GetFlashcardDeckParams struct {
+	ID     int64
+	Name   string
+	UserID int64
+}
+
+type GetFlashcardDeckRow struct {
+	GroupID   int64
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetFlashcardDeck(ctx context.Context, arg GetFlashcardDeckParams) (GetFlashcardDeckRow, error) {
+	row := q.db.QueryRowContext(ctx, getFlashcardDeck, arg.ID, arg.Name, arg.UserID)
+	var i GetFlashcardDeckRow
+	err := row.Scan(&i.GroupID, &i.CreatedAt)
+	return i, err
+}
+
+const getFlashcardRating = `-- name: GetFlashcardRating :one
+SELECT rating FROM flashcards
+WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+`
+
+type GetFlashcardRatingParams struct {
+	StudySessionID int64
+	WordID         int64
+	UserID         int64
+}
+
+func (q *Queries) GetFlashcardRating(ctx context.Context, arg GetFlashcardRatingParams) (*string, error) {
+	row := q.db.QueryRowContext(ctx, getFlashcardRating, arg.StudySessionID, arg.WordID, arg.UserID)
+	var rating *string
+	err := row.Scan(&rating)
+	return rating, err
+}
+
+const listFlashcards = `-- name: ListFlashcards :many
+SELECT w.id, w.script, w.transliteration, w.english,
+       f.flips, f.rating, f.rated_at, wls.due_at, wls.relearning_step
+FROM flashcards f
+JOIN words w ON w.id = f.word_id
+LEFT JOIN word_learning_state wls ON wls.word_id = f.word_id AND wls.user_id = ?
+WHERE f.study_session_id = ?
+ORDER BY f.id
+`
+
+type ListFlashcardsParams struct {
+	UserID         int64
+	StudySessionID int64
+}
+
+type ListFlashcardsRow struct {
+	ID              int64
+	Script          string
+	Transliteration string
+	English         string
+	Flips           int64
+	Rating          *string
+	RatedAt         *time.Time
+	DueAt           *time.Time
+	RelearningStep  *int64
+}
+
+func (q *Queries) ListFlashcards(ctx context.Context, arg ListFlashcardsParams) ([]ListFlashcardsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFlashcards, arg.UserID, arg.StudySessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFlashcardsRow
+	for rows.Next() {
+		var i ListFlashcardsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Script,
+			&i.Transliteration,
+			&i.English,
+			&i.Flips,
+			&i.Rating,
+			&i.RatedAt,
+			&i.DueAt,
+			&i.RelearningStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,151 @@
+// Written by hand in the form sqlc generates from the queries in
+// db/queries/notifications.sql; `mage generate` replaces it with sqlc's output.
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const finishNotificationDelivery = `-- name: FinishNotificationDelivery :exec
+UPDATE notification_deliveries SET status = ?, error = ?, sent_at = ?
+WHERE user_id = ? AND kind = ? AND channel = ? AND dedupe_key = ?
+`
+
+type FinishNotificationDeliveryParams struct {
+	Status    string
+	Error     string
+	SentAt    *time.Time
+	UserID    int64
+	Kind      string
+	Channel   string
+	DedupeKey string
+}
+
+func (q *Queries) FinishNotificationDelivery(ctx context.Context, arg FinishNotificationDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, finishNotificationDelivery,
+		arg.Status,
+		arg.Error,
+		arg.SentAt,
+		arg.UserID,
+		arg.Kind,
+		arg.Channel,
+		arg.DedupeKey,
+	)
+	return err
+}
+
+const getNotificationPreferences = `-- name: GetNotificationPreferences :one
+SELECT email, streak_reminders, weekly_digest, homework_reminders
+FROM notification_preferences WHERE user_id = ?
+`
+
+type GetNotificationPreferencesRow struct {
+	Email             *string
+	StreakReminders   bool
+	WeeklyDigest      bool
+	HomeworkReminders bool
+}
+
+func (q *Queries) GetNotificationPreferences(ctx context.Context, userID int64) (GetNotificationPreferencesRow, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationPreferences, userID)
+	var i GetNotificationPreferencesRow
+	err := row.Scan(
+		&i.Email,
+		&i.StreakReminders,
+		&i.WeeklyDigest,
+		&i.HomeworkReminders,
+	)
+	return i, err
+}
+
+const listNotificationDeliveries = `-- name: ListNotificationDeliveries :many
+SELECT id, kind, channel, recipient, subject, status, error, created_at, sent_at
+FROM notification_deliveries
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC
+LIMIT ?
+`
+
+type ListNotificationDeliveriesParams struct {
+	UserID int64
+	Limit  int64
+}
+
+type ListNotificationDeliveriesRow struct {
+	ID        int64
+	Kind      string
+	Channel   string
+	Recipient string
+	Subject   string
+	Status    string
+	Error     string
+	CreatedAt time.Time
+	SentAt    *time.Time
+}
+
+func (q *Queries) ListNotificationDeliveries(ctx context.Context, arg ListNotificationDeliveriesParams) ([]ListNotificationDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNotificationDeliveries, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotificationDeliveriesRow
+	for rows.Next() {
+		var i ListNotificationDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Channel,
+			&i.Recipient,
+			&i.Subject,
+			&i.Status,
+			&i.Error,
+			&i.CreatedAt,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertNotificationPreferences = `-- name: UpsertNotificationPreferences :exec
+INSERT INTO notification_preferences (user_id, email, streak_reminders, weekly_digest, homework_reminders, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id) DO UPDATE SET
+    email = excluded.email,
+    streak_reminders = excluded.streak_reminders,
+    weekly_digest = excluded.weekly_digest,
+    homework_reminders = excluded.homework_reminders,
+    updated_at = excluded.updated_at
+`
+
+type UpsertNotificationPreferencesParams struct {
+	UserID            int64
+	Email             *string
+	StreakReminders   bool
+	WeeklyDigest      bool
+	HomeworkReminders bool
+	UpdatedAt         time.Time
+}
+
+func (q *Queries) UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNotificationPreferences,
+		arg.UserID,
+		arg.Email,
+		arg.StreakReminders,
+		arg.WeeklyDigest,
+		arg.HomeworkReminders,
+		arg.UpdatedAt,
+	)
+	return err
+}
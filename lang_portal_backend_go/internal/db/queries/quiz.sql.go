@@ -0,0 +1,70 @@
+// Written by hand in the form sqlc generates from the queries in
+// db/queries/quiz.sql; `mage generate` replaces it with sqlc's output.
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const listQuizResults = `-- name: ListQuizResults :many
+SELECT qq.id, qq.word_id, qq.direction, qq.answer_mode, qq.correct_answer, qq.hints_used,
+       qa.answer, qa.correct, qa.near_miss, qa.answered_at
+FROM quiz_questions qq
+LEFT JOIN quiz_answers qa ON qa.quiz_question_id = qq.id
+WHERE qq.study_session_id = ? AND qq.study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+ORDER BY qq.id
+`
+
+type ListQuizResultsParams struct {
+	StudySessionID int64
+	UserID         int64
+}
+
+type ListQuizResultsRow struct {
+	ID            int64
+	WordID        int64
+	Direction     string
+	AnswerMode    string
+	CorrectAnswer string
+	HintsUsed     int64
+	Answer        *string
+	Correct       *bool
+	NearMiss      *bool
+	AnsweredAt    *time.Time
+}
+
+func (q *Queries) ListQuizResults(ctx context.Context, arg ListQuizResultsParams) ([]ListQuizResultsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listQuizResults, arg.StudySessionID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListQuizResultsRow
+	for rows.Next() {
+		var i ListQuizResultsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WordID,
+			&i.Direction,
+			&i.AnswerMode,
+			&i.CorrectAnswer,
+			&i.HintsUsed,
+			&i.Answer,
+			&i.Correct,
+			&i.NearMiss,
+			&i.AnsweredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,67 @@
+// Written by hand in the form sqlc generates from the queries in
+// db/queries/users.sql; `mage generate` replaces it with sqlc's output.
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const getUser = `-- name: GetUser :one
+SELECT id, username, email, guest_expires_at, created_at FROM users
+WHERE id = ? AND (guest_expires_at IS NULL OR guest_expires_at > CAST(? AS TEXT))
+`
+
+type GetUserParams struct {
+	ID  int64
+	Now string
+}
+
+type GetUserRow struct {
+	ID             int64
+	Username       string
+	Email          *string
+	GuestExpiresAt *time.Time
+	CreatedAt      *time.Time
+}
+
+func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (GetUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getUser, arg.ID, arg.Now)
+	var i GetUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.GuestExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserEmail = `-- name: GetUserEmail :one
+SELECT email FROM users WHERE id = ?
+`
+
+func (q *Queries) GetUserEmail(ctx context.Context, id int64) (*string, error) {
+	row := q.db.QueryRowContext(ctx, getUserEmail, id)
+	var email *string
+	err := row.Scan(&email)
+	return email, err
+}
+
+const getUserPasswordHash = `-- name: GetUserPasswordHash :one
+SELECT id, password_hash FROM users WHERE username = ?
+`
+
+type GetUserPasswordHashRow struct {
+	ID           int64
+	PasswordHash *string
+}
+
+func (q *Queries) GetUserPasswordHash(ctx context.Context, username string) (GetUserPasswordHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserPasswordHash, username)
+	var i GetUserPasswordHashRow
+	err := row.Scan(&i.ID, &i.PasswordHash)
+	return i, err
+}
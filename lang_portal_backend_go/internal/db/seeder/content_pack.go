@@ -0,0 +1,240 @@
+package seeder
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/quality"
+)
+
+// ContentPack is a bundle of word groups that can be installed into the
+// database, such as the starter catalog fetched on bootstrap
+type ContentPack struct {
+	Name    string             `json:"name"`
+	Version string             `json:"version"`
+	Groups  []ContentPackGroup `json:"groups"`
+	// Language is the code of the language of the pack's words, Urdu if
+	// empty. Their urdu and urdlish are their script and transliteration.
+	Language string `json:"language,omitempty"`
+	// Audio maps the urdlish spelling of a word to a recording of it
+	Audio map[string]string `json:"audio"`
+}
+
+type ContentPackGroup struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Words       []ContentPackWord `json:"words"`
+}
+
+type ContentPackWord struct {
+	Urdu     string          `json:"urdu"`
+	Urdlish  string          `json:"urdlish"`
+	English  string          `json:"english"`
+	Parts    json.RawMessage `json:"parts,omitempty"`
+	AudioURL string          `json:"audio_url,omitempty"`
+}
+
+// InstallResult reports what a content pack installation changed
+type InstallResult struct {
+	GroupsCreated int           `json:"groups_created"`
+	WordsCreated  int           `json:"words_created"`
+	WordsSkipped  int           `json:"words_skipped"`
+	AudioLinked   int           `json:"audio_linked"`
+	Warnings      []WordWarning `json:"warnings"`
+}
+
+// WordWarning lists the data quality warnings raised for one installed word
+type WordWarning struct {
+	Group    string            `json:"group"`
+	Urdu     string            `json:"urdu"`
+	English  string            `json:"english"`
+	Warnings []quality.Warning `json:"warnings"`
+}
+
+// ErrInvalidPack is matched by the errors of packs that can't be installed
+var ErrInvalidPack = errors.New("invalid pack")
+
+// packError is an error about a pack that can't be installed
+type packError string
+
+func (e packError) Error() string {
+	return string(e)
+}
+
+// Is matches ErrInvalidPack
+func (e packError) Is(target error) bool {
+	return target == ErrInvalidPack
+}
+
+// Validate checks that a content pack has everything needed to install it
+func (p *ContentPack) Validate() error {
+	if len(p.Groups) == 0 {
+		return packError("content pack has no groups")
+	}
+	for _, group := range p.Groups {
+		if group.Name == "" {
+			return packError("content pack has a group without a name")
+		}
+		for _, word := range group.Words {
+			if word.Urdu == "" || word.Urdlish == "" || word.English == "" {
+				return packError(fmt.Sprintf("group %q has a word with missing fields", group.Name))
+			}
+		}
+	}
+	return nil
+}
+
+// InstallContentPack adds the groups and words of a content pack. Groups are
+// matched by name within the pack's language and words already in a group
+// are skipped, so installing the same pack twice is harmless.
+func (s *Seeder) InstallContentPack(ctx context.Context, pack *ContentPack) (*InstallResult, error) {
+	if err := pack.Validate(); err != nil {
+		return nil, err
+	}
+
+	var result *InstallResult
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		if result, err = s.installContentPack(ctx, tx, pack); err != nil {
+			return err
+		}
+		return recordSeedVersion(ctx, tx, SourceContentPack, pack.Name, pack.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// installContentPack adds the groups and words of a content pack within tx
+func (s *Seeder) installContentPack(ctx context.Context, tx *models.Tx, pack *ContentPack) (*InstallResult, error) {
+	language := pack.Language
+	if language == "" {
+		language = models.DefaultLanguage
+	}
+	var known bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM languages WHERE code = ?)
+	`, language).Scan(&known); err != nil {
+		return nil, fmt.Errorf("failed to look up language: %v", err)
+	}
+	if !known {
+		return nil, packError(fmt.Sprintf("content pack is of unknown language %q", language))
+	}
+
+	result := &InstallResult{Warnings: []WordWarning{}}
+	checker := quality.NewDefaultEngine(quality.SQLDuplicateLookup(ctx, tx))
+	for _, group := range pack.Groups {
+		// Get or create group
+		var groupID int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM groups WHERE name = ? AND language = ?
+		`, group.Name, language).Scan(&groupID)
+		if err == sql.ErrNoRows {
+			res, err := tx.ExecContext(ctx, `
+				INSERT INTO groups (name, language)
+				VALUES (?, ?)
+			`, group.Name, language)
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert group: %v", err)
+			}
+			groupID, err = res.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get group ID: %v", err)
+			}
+			result.GroupsCreated++
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to query group: %v", err)
+		}
+
+		for _, word := range group.Words {
+			var wordID int64
+			err := tx.QueryRowContext(ctx, `
+				SELECT w.id
+				FROM words w
+				JOIN words_groups wg ON wg.word_id = w.id
+				WHERE wg.group_id = ? AND w.script = ? AND w.english = ?
+			`, groupID, word.Urdu, word.English).Scan(&wordID)
+			if err == nil {
+				result.WordsSkipped++
+			} else if err == sql.ErrNoRows {
+				candidate := &models.Word{
+					Urdu:     word.Urdu,
+					Urdlish:  word.Urdlish,
+					English:  word.English,
+					Parts:    string(word.Parts),
+					Language: language,
+				}
+				warnings, err := checker.Check(candidate)
+				if err != nil {
+					return nil, err
+				}
+				if len(warnings) > 0 {
+					result.Warnings = append(result.Warnings, WordWarning{
+						Group:    group.Name,
+						Urdu:     word.Urdu,
+						English:  word.English,
+						Warnings: warnings,
+					})
+				}
+
+				var parts interface{}
+				if len(word.Parts) > 0 {
+					parts = string(word.Parts)
+				}
+				res, err := tx.ExecContext(ctx, `
+					INSERT INTO words (script, transliteration, english, parts, language)
+					VALUES (?, ?, ?, ?, ?)
+				`, word.Urdu, word.Urdlish, word.English, parts, language)
+				if err != nil {
+					return nil, fmt.Errorf("failed to insert word: %v", err)
+				}
+				wordID, err = res.LastInsertId()
+				if err != nil {
+					return nil, fmt.Errorf("failed to get word ID: %v", err)
+				}
+
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO words_groups (word_id, group_id)
+					VALUES (?, ?)
+				`, wordID, groupID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to associate word with group: %v", err)
+				}
+				result.WordsCreated++
+			} else {
+				return nil, fmt.Errorf("failed to query word: %v", err)
+			}
+
+			audioURL := word.AudioURL
+			if audioURL == "" {
+				audioURL = pack.Audio[word.Urdlish]
+			}
+			if audioURL != "" {
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO word_audio (word_id, url)
+					VALUES (?, ?)
+					ON CONFLICT(word_id) DO UPDATE SET url = excluded.url
+				`, wordID, audioURL)
+				if err != nil {
+					return nil, fmt.Errorf("failed to link word audio: %v", err)
+				}
+				result.AudioLinked++
+			}
+		}
+
+		// Keep the cached word count in step with the new words
+		_, err = tx.ExecContext(ctx, `
+			UPDATE groups
+			SET word_count = (SELECT COUNT(*) FROM words_groups WHERE group_id = ?)
+			WHERE id = ?
+		`, groupID, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update word count: %v", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package seeder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of seed pack, told apart by the shape of the file
+const (
+	// SeedPackActivities is a list of study activities, which replaces the
+	// activities no session refers to
+	SeedPackActivities = "activities"
+	// SeedPackGroups is a list of groups with their words
+	SeedPackGroups = "groups"
+	// SeedPackWords is a list of words, added to the beginner group
+	SeedPackWords = "words"
+)
+
+// Sources of the packs whose versions are recorded in seed_versions
+const (
+	SourceSeedPack    = "seed_pack"
+	SourceContentPack = "content_pack"
+)
+
+// seedWordsGroup is the group the words of a words pack are added to, as
+// mage seed does
+const seedWordsGroup = "Beginner Words"
+
+// SeedPack describes a seed pack, a JSON file of the seed directory named
+// after the file without its extension
+type SeedPack struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Version is a checksum of the file, so editing it changes the version
+	Version    string `json:"version"`
+	Activities int    `json:"activities"`
+	Groups     int    `json:"groups"`
+	Words      int    `json:"words"`
+}
+
+// SeedResult reports what applying a seed pack changed or, on a dry run,
+// would have changed
+type SeedResult struct {
+	Pack            string `json:"pack"`
+	DryRun          bool   `json:"dry_run"`
+	ActivitiesSaved int    `json:"activities_saved"`
+	InstallResult
+}
+
+// ErrNotFound is returned for seed packs that don't exist
+var ErrNotFound = errors.New("seed pack not found")
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// seedFile is a seed pack read from its file
+type seedFile struct {
+	info       SeedPack
+	activities []models.StudyActivity
+	pack       *ContentPack
+}
+
+// recordSeedVersion records the version of a pack applied within tx
+func recordSeedVersion(ctx context.Context, tx *models.Tx, source, name, version string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO seed_versions (source, name, version, applied_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (source, name) DO UPDATE SET
+			version = excluded.version,
+			applied_at = excluded.applied_at
+	`, source, name, version)
+	if err != nil {
+		return fmt.Errorf("failed to record seed version: %v", err)
+	}
+	return nil
+}
+
+// ListSeedPacks lists the seed packs of dir by name
+func ListSeedPacks(dir string) ([]SeedPack, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seed packs: %v", err)
+	}
+	sort.Strings(paths)
+
+	packs := []SeedPack{}
+	for _, path := range paths {
+		file, err := readSeedFile(dir, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, file.info)
+	}
+	return packs, nil
+}
+
+// readSeedFile reads the seed pack of dir with the given name
+func readSeedFile(dir, name string) (*seedFile, error) {
+	if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed pack %s: %v", name, err)
+	}
+
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse seed pack %s: %v", name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	file := &seedFile{info: SeedPack{Name: name, Version: hex.EncodeToString(sum[:])[:12]}}
+	switch {
+	case len(entries) == 0:
+		file.info.Kind = SeedPackWords
+		file.pack = &ContentPack{Name: name}
+	case entries[0]["words"] != nil:
+		file.info.Kind = SeedPackGroups
+		file.pack = &ContentPack{Name: name}
+		err = json.Unmarshal(data, &file.pack.Groups)
+	case entries[0]["urdu"] != nil:
+		file.info.Kind = SeedPackWords
+		group := ContentPackGroup{Name: seedWordsGroup}
+		err = json.Unmarshal(data, &group.Words)
+		file.pack = &ContentPack{Name: name, Groups: []ContentPackGroup{group}}
+	case entries[0]["name"] != nil:
+		file.info.Kind = SeedPackActivities
+		err = json.Unmarshal(data, &file.activities)
+	default:
+		return nil, packError(fmt.Sprintf("seed pack %s is not a list of activities, groups or words", name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse seed pack %s: %v", name, err)
+	}
+
+	file.info.Activities = len(file.activities)
+	if file.pack != nil {
+		file.info.Groups = len(file.pack.Groups)
+		for _, group := range file.pack.Groups {
+			file.info.Words += len(group.Words)
+		}
+	}
+	return file, nil
+}
+
+// ApplySeedPack applies the seed pack of dir with the given name. Groups are
+// matched by name and words already in a group are skipped, so applying a
+// pack twice is harmless. A dry run reports what would change and leaves the
+// database as it is.
+func (s *Seeder) ApplySeedPack(ctx context.Context, dir, name string, dryRun bool) (*SeedResult, error) {
+	file, err := readSeedFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if file.pack != nil {
+		if err := file.pack.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var result *SeedResult
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		result = &SeedResult{Pack: name, DryRun: dryRun, InstallResult: InstallResult{Warnings: []WordWarning{}}}
+		if file.pack != nil {
+			installed, err := s.installContentPack(ctx, tx, file.pack)
+			if err != nil {
+				return err
+			}
+			result.InstallResult = *installed
+		} else {
+			if err := s.saveStudyActivities(ctx, tx, file.activities); err != nil {
+				return err
+			}
+			result.ActivitiesSaved = len(file.activities)
+		}
+		if err := recordSeedVersion(ctx, tx, SourceSeedPack, name, file.info.Version); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+	return result, nil
+}
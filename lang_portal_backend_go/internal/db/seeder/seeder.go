@@ -0,0 +1,316 @@
+package seeder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/models"
+	"os"
+	"path/filepath"
+)
+
+// Seeder handles database seeding operations
+type Seeder struct {
+	db *models.DB
+}
+
+// NewSeeder creates a new seeder instance
+func NewSeeder(db *models.DB) *Seeder {
+	return &Seeder{db: db}
+}
+
+// saveStudyActivities replaces the study activities within tx
+func (s *Seeder) saveStudyActivities(ctx context.Context, tx *models.Tx, activities []models.StudyActivity) error {
+	// Clear study activities no session refers to. Activities with sessions
+	// are kept, since foreign keys are enforced, and updated below.
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM study_activities
+		WHERE id NOT IN (SELECT study_activity_id FROM study_sessions WHERE study_activity_id IS NOT NULL)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to clear study activities: %v", err)
+	}
+
+	// Insert new study activities
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO study_activities (id, name, url, thumbnail_url, description)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			url = excluded.url,
+			thumbnail_url = excluded.thumbnail_url,
+			description = excluded.description
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, activity := range activities {
+		_, err = stmt.ExecContext(ctx,
+			activity.ID,
+			activity.Name,
+			activity.URL,
+			activity.ThumbnailURL,
+			activity.Description,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert study activity: %v", err)
+		}
+	}
+	return nil
+}
+
+type StudyActivity struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Description  string `json:"description"`
+}
+
+type WordGroup struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Words       []struct {
+		Urdu    string `json:"urdu"`
+		Urdlish string `json:"urdlish"`
+		English string `json:"english"`
+	} `json:"words"`
+}
+
+func (s *Seeder) SeedTestData() error {
+	// Get the current working directory and find the project root
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %v", err)
+	}
+
+	// Find the project root by looking for db/seeds directory
+	var seedsDir string
+	for dir := wd; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "db", "seeds")); err == nil {
+			seedsDir = filepath.Join(dir, "db", "seeds")
+			break
+		}
+	}
+	if seedsDir == "" {
+		return fmt.Errorf("failed to find db/seeds directory")
+	}
+
+	// Read and parse study activities
+	studyActivitiesBytes, err := os.ReadFile(filepath.Join(seedsDir, "study_activities.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read study activities: %v", err)
+	}
+
+	var studyActivities []StudyActivity
+	if err := json.Unmarshal(studyActivitiesBytes, &studyActivities); err != nil {
+		return fmt.Errorf("failed to parse study activities: %v", err)
+	}
+
+	// Read and parse word groups
+	wordGroupsBytes, err := os.ReadFile(filepath.Join(seedsDir, "word_groups.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read word groups: %v", err)
+	}
+
+	var wordGroups []WordGroup
+	if err := json.Unmarshal(wordGroupsBytes, &wordGroups); err != nil {
+		return fmt.Errorf("failed to parse word groups: %v", err)
+	}
+
+	return s.db.WithTx(context.Background(), func(tx *models.Tx) error {
+		// Clear existing data
+		_, err := tx.Exec(`DELETE FROM word_review_items`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_review_items: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_learning_state`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_learning_state: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM study_session_words`)
+		if err != nil {
+			return fmt.Errorf("failed to clear study_session_words: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM quiz_answers`)
+		if err != nil {
+			return fmt.Errorf("failed to clear quiz_answers: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM quiz_questions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear quiz_questions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM flashcards`)
+		if err != nil {
+			return fmt.Errorf("failed to clear flashcards: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM listening_answers`)
+		if err != nil {
+			return fmt.Errorf("failed to clear listening_answers: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_game_rounds`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_game_rounds: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM pronunciation_attempts`)
+		if err != nil {
+			return fmt.Errorf("failed to clear pronunciation_attempts: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM conjugation_drill_rounds`)
+		if err != nil {
+			return fmt.Errorf("failed to clear conjugation_drill_rounds: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM cloze_questions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear cloze_questions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM bot_questions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear bot_questions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM daily_word_stats`)
+		if err != nil {
+			return fmt.Errorf("failed to clear daily_word_stats: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM daily_group_stats`)
+		if err != nil {
+			return fmt.Errorf("failed to clear daily_group_stats: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM daily_stats`)
+		if err != nil {
+			return fmt.Errorf("failed to clear daily_stats: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM stats_rollup_state`)
+		if err != nil {
+			return fmt.Errorf("failed to clear stats_rollup_state: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM listening_questions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear listening_questions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM listening_clips`)
+		if err != nil {
+			return fmt.Errorf("failed to clear listening_clips: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM study_sessions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear study_sessions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM study_activities`)
+		if err != nil {
+			return fmt.Errorf("failed to clear study_activities: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM goals`)
+		if err != nil {
+			return fmt.Errorf("failed to clear goals: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM class_assignments`)
+		if err != nil {
+			return fmt.Errorf("failed to clear class_assignments: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM group_syncs`)
+		if err != nil {
+			return fmt.Errorf("failed to clear group_syncs: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM words_groups`)
+		if err != nil {
+			return fmt.Errorf("failed to clear words_groups: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_embeddings`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_embeddings: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_audio`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_audio: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_images`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_images: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_renderings`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_renderings: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_sort_keys`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_sort_keys: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM group_sort_keys`)
+		if err != nil {
+			return fmt.Errorf("failed to clear group_sort_keys: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_definitions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_definitions: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM word_enrichments`)
+		if err != nil {
+			return fmt.Errorf("failed to clear word_enrichments: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM conjugations`)
+		if err != nil {
+			return fmt.Errorf("failed to clear conjugations: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM sentence_words`)
+		if err != nil {
+			return fmt.Errorf("failed to clear sentence_words: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM words`)
+		if err != nil {
+			return fmt.Errorf("failed to clear words: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM groups`)
+		if err != nil {
+			return fmt.Errorf("failed to clear groups: %v", err)
+		}
+		_, err = tx.Exec(`DELETE FROM seed_versions`)
+		if err != nil {
+			return fmt.Errorf("failed to clear seed_versions: %v", err)
+		}
+
+		// Insert groups first
+		for i, group := range wordGroups {
+			groupID := i + 1
+			_, err := tx.Exec(`INSERT INTO groups (id, name, word_count) VALUES (?, ?, ?)`,
+				groupID, group.Name, len(group.Words))
+			if err != nil {
+				return fmt.Errorf("failed to insert group: %v", err)
+			}
+
+			// Insert words and word_groups
+			for _, word := range group.Words {
+				// Let SQLite auto-increment handle the word IDs
+				result, err := tx.Exec(`INSERT INTO words (script, transliteration, english) VALUES (?, ?, ?)`,
+					word.Urdu, word.Urdlish, word.English)
+				if err != nil {
+					return fmt.Errorf("failed to insert word: %v", err)
+				}
+
+				// Get the auto-generated word ID
+				wordID, err := result.LastInsertId()
+				if err != nil {
+					return fmt.Errorf("failed to get last insert ID: %v", err)
+				}
+
+				_, err = tx.Exec(`INSERT INTO words_groups (word_id, group_id) VALUES (?, ?)`,
+					wordID, groupID)
+				if err != nil {
+					return fmt.Errorf("failed to insert word_group: %v", err)
+				}
+			}
+		}
+
+		// Insert study activities
+		for _, activity := range studyActivities {
+			_, err := tx.Exec(`INSERT INTO study_activities (id, group_id, activity_id) VALUES (?, ?, ?)`,
+				activity.ID, 1, activity.ID) // Using first group for all activities in test data
+			if err != nil {
+				return fmt.Errorf("failed to insert study activity: %v", err)
+			}
+		}
+		return nil
+	})
+}
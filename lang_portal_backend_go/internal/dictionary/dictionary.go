@@ -0,0 +1,105 @@
+// Package dictionary looks words up in an external dictionary for their
+// definitions, plural forms and examples of their use. Wiktionary and the
+// language model of package llm are supported; which one is used is set by
+// the environment.
+package dictionary
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/llm"
+	"lang_portal/internal/models"
+	"os"
+	"strings"
+)
+
+const (
+	// ProviderEnv selects the provider: wiktionary or llm
+	ProviderEnv = "LANG_PORTAL_DICTIONARY_PROVIDER"
+	// URLEnv overrides the Wiktionary REST API URL, e.g. for another
+	// edition's mirror
+	URLEnv = "LANG_PORTAL_DICTIONARY_URL"
+)
+
+// Providers
+const (
+	Wiktionary = "wiktionary"
+	// LLM asks the language model configured by package llm
+	LLM = "llm"
+)
+
+// maxDefinitions is the most definitions kept of a word
+const maxDefinitions = 10
+
+// maxExamples is the most examples kept of a definition
+const maxExamples = 3
+
+// Entry is what a dictionary says about a word
+type Entry struct {
+	Definitions []models.WordDefinition
+	Plurals     []string
+}
+
+// Provider looks words up
+type Provider interface {
+	// Name names the provider, e.g. "wiktionary"
+	Name() string
+	// Lookup returns the entry of the word, or nil if the dictionary has
+	// none
+	Lookup(ctx context.Context, word models.Word) (*Entry, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case Wiktionary:
+		return newWiktionary(os.Getenv(URLEnv)), nil
+	case LLM:
+		model, err := llm.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if model == nil {
+			return nil, fmt.Errorf("%s is required for %s", llm.ProviderEnv, provider)
+		}
+		return &languageModel{model: model}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s or %s", ProviderEnv, provider, Wiktionary, LLM)
+	}
+}
+
+// clean trims an entry's text, drops empty definitions, examples and
+// plurals and caps how many are kept. It returns nil if no definition is
+// left.
+func clean(entry *Entry) *Entry {
+	cleaned := &Entry{Definitions: []models.WordDefinition{}, Plurals: []string{}}
+	for _, definition := range entry.Definitions {
+		definition.PartOfSpeech = strings.TrimSpace(definition.PartOfSpeech)
+		definition.Definition = strings.TrimSpace(definition.Definition)
+		if definition.Definition == "" || len(cleaned.Definitions) == maxDefinitions {
+			continue
+		}
+		examples := []models.UsageExample{}
+		for _, example := range definition.Examples {
+			example.Text = strings.TrimSpace(example.Text)
+			example.Translation = strings.TrimSpace(example.Translation)
+			if example.Text != "" && len(examples) < maxExamples {
+				examples = append(examples, example)
+			}
+		}
+		definition.Examples = examples
+		cleaned.Definitions = append(cleaned.Definitions, definition)
+	}
+	if len(cleaned.Definitions) == 0 {
+		return nil
+	}
+	for _, plural := range entry.Plurals {
+		if plural = strings.TrimSpace(plural); plural != "" {
+			cleaned.Plurals = append(cleaned.Plurals, plural)
+		}
+	}
+	return cleaned
+}
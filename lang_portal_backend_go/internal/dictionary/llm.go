@@ -0,0 +1,49 @@
+package dictionary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/llm"
+	"lang_portal/internal/models"
+	"strings"
+)
+
+// system tells the model what to answer
+const system = `You are an Urdu dictionary for English speakers.
+Given an Urdu word, answer with a JSON object only, of the form
+{"definitions": [{"part_of_speech": "...", "definition": "...",
+"examples": [{"text": "...", "translation": "..."}]}], "plurals": ["..."]}
+where definitions are the word's meanings in English, examples are short
+Urdu sentences in Urdu script using the word with their English
+translation, and plurals are its plural forms in Urdu script, if it has
+any. Answer {"definitions": []} if you don't know the word.`
+
+// languageModel asks a language model, which also gives plural forms
+type languageModel struct {
+	model llm.Provider
+}
+
+func (p *languageModel) Name() string { return LLM + "/" + p.model.Name() }
+
+func (p *languageModel) Lookup(ctx context.Context, word models.Word) (*Entry, error) {
+	prompt := fmt.Sprintf("The Urdu word is %q, romanized %q, meaning %q.", word.Urdu, word.Urdlish, word.English)
+	answer, err := p.model.Complete(ctx, system, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("answer has no JSON object")
+	}
+	var parsed struct {
+		Definitions []models.WordDefinition `json:"definitions"`
+		Plurals     []string                `json:"plurals"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse answer: %v", err)
+	}
+	return clean(&Entry{Definitions: parsed.Definitions, Plurals: parsed.Plurals}), nil
+}
@@ -0,0 +1,101 @@
+package dictionary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"lang_portal/internal/models"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const wiktionaryURL = "https://en.wiktionary.org/api/rest_v1"
+
+// userAgent identifies the portal to Wikimedia, as its API policy asks
+const userAgent = "lang_portal (Urdu vocabulary portal)"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// tags matches the HTML tags of Wiktionary's definitions
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// wiktionary looks words up in the English Wiktionary's Urdu entries. It
+// has no plural forms.
+type wiktionary struct {
+	url string
+}
+
+func newWiktionary(apiURL string) *wiktionary {
+	if apiURL == "" {
+		apiURL = wiktionaryURL
+	}
+	return &wiktionary{url: strings.TrimSuffix(apiURL, "/")}
+}
+
+func (p *wiktionary) Name() string { return Wiktionary }
+
+func (p *wiktionary) Lookup(ctx context.Context, word models.Word) (*Entry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.url+"/page/definition/"+url.PathEscape(strings.TrimSpace(word.Urdu)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("wiktionary: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("wiktionary: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("wiktionary: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	// Entries are keyed by language code
+	var response map[string][]struct {
+		PartOfSpeech string `json:"partOfSpeech"`
+		Definitions  []struct {
+			Definition     string `json:"definition"`
+			ParsedExamples []struct {
+				Example     string `json:"example"`
+				Translation string `json:"translation"`
+			} `json:"parsedExamples"`
+		} `json:"definitions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("wiktionary: failed to decode response: %v", err)
+	}
+
+	entry := &Entry{}
+	for _, usage := range response["ur"] {
+		for _, definition := range usage.Definitions {
+			parsed := models.WordDefinition{
+				PartOfSpeech: strings.ToLower(usage.PartOfSpeech),
+				Definition:   stripHTML(definition.Definition),
+			}
+			for _, example := range definition.ParsedExamples {
+				parsed.Examples = append(parsed.Examples, models.UsageExample{
+					Text:        stripHTML(example.Example),
+					Translation: stripHTML(example.Translation),
+				})
+			}
+			entry.Definitions = append(entry.Definitions, parsed)
+		}
+	}
+	return clean(entry), nil
+}
+
+// stripHTML returns the text of an HTML fragment
+func stripHTML(fragment string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(tags.ReplaceAllString(fragment, ""))), " ")
+}
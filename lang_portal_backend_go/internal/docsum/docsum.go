@@ -0,0 +1,127 @@
+// Package docsum asks the document summarization service of opea-comps for
+// the English and Urdu summaries of web pages, text and documents, and
+// picks the Urdu words out of them. The service's URL is set by the
+// environment.
+package docsum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// URLEnv is the URL of the summarization service, e.g. http://docsum:8002
+const URLEnv = "LANG_PORTAL_DOCSUM_URL"
+
+// Kinds of content the service summarizes
+const (
+	URL  = "url"
+	Text = "text"
+	// Document is an uploaded file, such as a PDF
+	Document = "document"
+)
+
+// Summarizing translates and synthesizes speech too, so it takes a while
+var client = &http.Client{Timeout: 3 * time.Minute}
+
+// Summary is what the service answers for a document
+type Summary struct {
+	Summary           string `json:"summary"`
+	TranslatedSummary string `json:"translated_summary"`
+}
+
+// Client calls the summarization service
+type Client struct {
+	url string
+}
+
+// FromEnv returns the client configured by the environment, or nil if
+// URLEnv isn't set
+func FromEnv() (*Client, error) {
+	base := os.Getenv(URLEnv)
+	if base == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%s must be an http or https URL", URLEnv)
+	}
+	return &Client{url: strings.TrimSuffix(base, "/")}, nil
+}
+
+// Summarize summarizes content, a URL or text of kind URL or Text
+func (c *Client) Summarize(ctx context.Context, kind, content string) (*Summary, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"content_type": kind,
+		"content":      content,
+		"use_cache":    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docsum: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/summarize", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("docsum: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req)
+}
+
+// SummarizeFile summarizes an uploaded document
+func (c *Client) SummarizeFile(ctx context.Context, filename string, data []byte) (*Summary, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("docsum: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("docsum: %v", err)
+	}
+	form.WriteField("content_type", Document)
+	form.WriteField("use_cache", "true")
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("docsum: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/summarize/file", &body)
+	if err != nil {
+		return nil, fmt.Errorf("docsum: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) (*Summary, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docsum: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// FastAPI explains errors in detail
+		var failure struct {
+			Detail interface{} `json:"detail"`
+		}
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(message, &failure) == nil && failure.Detail != nil {
+			return nil, fmt.Errorf("docsum: request failed with status %d: %v", resp.StatusCode, failure.Detail)
+		}
+		return nil, fmt.Errorf("docsum: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	var summary Summary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("docsum: failed to decode response: %v", err)
+	}
+	if strings.TrimSpace(summary.TranslatedSummary) == "" {
+		return nil, fmt.Errorf("docsum: the summary has no Urdu translation")
+	}
+	return &summary, nil
+}
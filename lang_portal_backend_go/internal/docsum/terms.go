@@ -0,0 +1,80 @@
+package docsum
+
+import (
+	"lang_portal/internal/spelling"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// zeroWidthNonJoiner separates the parts of some Urdu words without a space
+const zeroWidthNonJoiner = '‌'
+
+// stopWords are the Urdu particles, postpositions, pronouns and auxiliaries
+// too common to be worth learning from a text, normalized
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range []string{
+		"ہے", "ہیں", "تھا", "تھی", "تھے", "ہو", "ہوا", "ہوئی", "ہوئے", "ہوتا", "ہوتی", "ہوتے", "گا", "گی", "گے",
+		"کا", "کی", "کے", "کو", "نے", "سے", "میں", "پر", "تک", "لیے", "لئے", "ساتھ", "بعد", "پہلے",
+		"اور", "یا", "لیکن", "مگر", "کہ", "اگر", "تو", "بھی", "ہی", "نہیں", "نہ", "جو", "جس", "جن",
+		"یہ", "وہ", "اس", "ان", "اسے", "انہیں", "ایک", "کچھ", "کوئی", "سب", "کر", "کیا", "کیے", "کرنے",
+		"گیا", "گئی", "گئے", "رہا", "رہی", "رہے", "والا", "والی", "والے", "بہت", "اپنے", "اپنی", "اپنا",
+	} {
+		stopWords[spelling.Normalize(word)] = true
+	}
+}
+
+// Term is an Urdu word of a text
+type Term struct {
+	// Urdu is the word as it first appears in the text
+	Urdu string
+	// Key is the word normalized with spelling.Normalize, which its
+	// spellings share
+	Key string
+	// Count is how many times the word appears
+	Count int
+}
+
+// Terms returns the Urdu words of text, leaving out stop words, single
+// letters and words in other scripts, most frequent first and otherwise in
+// the order they first appear
+func Terms(text string) []Term {
+	var (
+		terms []Term
+		index = map[string]int{}
+		word  []rune
+	)
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		urdu := string(word)
+		word = word[:0]
+		key := spelling.Normalize(urdu)
+		if utf8.RuneCountInString(key) < 2 || stopWords[key] {
+			return
+		}
+		if i, ok := index[key]; ok {
+			terms[i].Count++
+			return
+		}
+		index[key] = len(terms)
+		terms = append(terms, Term{Urdu: urdu, Key: key, Count: 1})
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) && unicode.Is(unicode.Arabic, r):
+			word = append(word, r)
+		case len(word) > 0 && (unicode.Is(unicode.Mn, r) || r == zeroWidthNonJoiner):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	sort.SliceStable(terms, func(i, j int) bool { return terms[i].Count > terms[j].Count })
+	return terms
+}
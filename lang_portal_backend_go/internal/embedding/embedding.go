@@ -0,0 +1,78 @@
+// Package embedding compares words by their embedding vectors, which are
+// computed by OpenAI or Ollama, as set by the environment, or given
+// precomputed, and finds the nearest of them with an in-memory index.
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Vector is an embedding of a word
+type Vector []float32
+
+// Validate checks that a vector can be compared with others
+func (v Vector) Validate() error {
+	if len(v) == 0 {
+		return fmt.Errorf("vector is empty")
+	}
+	var norm float64
+	for _, x := range v {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return fmt.Errorf("vector contains non-finite values")
+		}
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return fmt.Errorf("vector has zero length")
+	}
+	return nil
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// dimensions differ
+func Cosine(a, b Vector) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Match is a candidate scored against a query vector
+type Match struct {
+	ID         int64
+	Similarity float64
+}
+
+// Nearest returns up to limit candidate IDs ordered by descending similarity
+// to query. Candidates whose dimensions differ from the query are skipped.
+func Nearest(query Vector, candidates map[int64]Vector, limit int) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for id, v := range candidates {
+		if len(v) != len(query) {
+			continue
+		}
+		matches = append(matches, Match{ID: id, Similarity: Cosine(query, v)})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,209 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	// indexTables is how many hash tables an index has. More tables find
+	// more of the true nearest vectors, at the cost of comparing more.
+	indexTables = 8
+	// indexBits is how many hyperplanes hash a vector in each table, so a
+	// table has up to 2^indexBits buckets
+	indexBits = 10
+	// exactSearchSize is the size up to which an index is searched by
+	// comparing the query with every vector, which is as quick as hashing
+	exactSearchSize = 2000
+)
+
+// Index finds the vectors nearest a query among many without comparing the
+// query with each of them. Vectors are hashed by which side of random
+// hyperplanes they fall on, so that close vectors likely share a bucket,
+// in several tables; a query is compared with the vectors in its buckets and
+// in the buckets one hyperplane away. The answer is approximate: a vector
+// sharing no bucket with the query is missed. Small indexes are searched
+// exactly. An Index is safe for concurrent use.
+type Index struct {
+	mu sync.RWMutex
+	// dimensions of the vectors, set by the first one added
+	dimensions int
+	// planes are the normals of each table's hyperplanes
+	planes  [indexTables][indexBits]Vector
+	buckets [indexTables]map[uint32][]int64
+	// vectors are the indexed vectors, normalized to unit length
+	vectors map[int64]Vector
+	hashes  map[int64][indexTables]uint32
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	x := &Index{vectors: map[int64]Vector{}, hashes: map[int64][indexTables]uint32{}}
+	for t := range x.buckets {
+		x.buckets[t] = map[uint32][]int64{}
+	}
+	return x
+}
+
+// Len returns how many vectors are indexed
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.vectors)
+}
+
+// Add indexes v under id, replacing any vector of that id. All the vectors
+// of an index must have the same dimensions.
+func (x *Index) Add(id int64, v Vector) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.dimensions == 0 {
+		x.dimensions = len(v)
+		x.makePlanes()
+	}
+	if len(v) != x.dimensions {
+		return fmt.Errorf("vector has %d dimensions, not %d", len(v), x.dimensions)
+	}
+
+	x.remove(id)
+	unit := normalize(v)
+	hashes := x.hash(unit)
+	for t, h := range hashes {
+		x.buckets[t][h] = append(x.buckets[t][h], id)
+	}
+	x.vectors[id] = unit
+	x.hashes[id] = hashes
+	return nil
+}
+
+// Remove drops the vector of id, if indexed
+func (x *Index) Remove(id int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *Index) remove(id int64) {
+	hashes, ok := x.hashes[id]
+	if !ok {
+		return
+	}
+	for t, h := range hashes {
+		bucket := x.buckets[t][h]
+		for i, other := range bucket {
+			if other == id {
+				bucket = append(bucket[:i], bucket[i+1:]...)
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(x.buckets[t], h)
+		} else {
+			x.buckets[t][h] = bucket
+		}
+	}
+	delete(x.vectors, id)
+	delete(x.hashes, id)
+}
+
+// Search returns up to limit indexed IDs ordered by descending similarity
+// to query. A query whose dimensions differ from the index's matches
+// nothing.
+func (x *Index) Search(query Vector, limit int) []Match {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(query) != x.dimensions || query.Validate() != nil || limit <= 0 {
+		return nil
+	}
+	unit := normalize(query)
+
+	candidates := x.vectors
+	if len(x.vectors) > exactSearchSize {
+		candidates = map[int64]Vector{}
+		for t, h := range x.hash(unit) {
+			x.collect(candidates, t, h)
+			for bit := 0; bit < indexBits; bit++ {
+				x.collect(candidates, t, h^(1<<bit))
+			}
+		}
+		// Too few neighbours hashed alike, e.g. for a query far from
+		// every word; comparing with all of them still answers
+		if len(candidates) < limit {
+			candidates = x.vectors
+		}
+	}
+
+	matches := make([]Match, 0, len(candidates))
+	for id, v := range candidates {
+		matches = append(matches, Match{ID: id, Similarity: dot(unit, v)})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// collect adds the vectors of a bucket to candidates
+func (x *Index) collect(candidates map[int64]Vector, table int, hash uint32) {
+	for _, id := range x.buckets[table][hash] {
+		candidates[id] = x.vectors[id]
+	}
+}
+
+// makePlanes draws the hyperplanes of each table. They are drawn from a
+// fixed seed, so an index hashes alike every time it's built.
+func (x *Index) makePlanes() {
+	random := rand.New(rand.NewSource(int64(x.dimensions)))
+	for t := range x.planes {
+		for b := range x.planes[t] {
+			plane := make(Vector, x.dimensions)
+			for i := range plane {
+				plane[i] = float32(random.NormFloat64())
+			}
+			x.planes[t][b] = plane
+		}
+	}
+}
+
+// hash returns the bucket of a vector in each table: bit b of a bucket is
+// set if the vector is on the positive side of the table's hyperplane b
+func (x *Index) hash(v Vector) [indexTables]uint32 {
+	var hashes [indexTables]uint32
+	for t := range x.planes {
+		for b, plane := range x.planes[t] {
+			if dot(v, plane) >= 0 {
+				hashes[t] |= 1 << b
+			}
+		}
+	}
+	return hashes
+}
+
+func normalize(v Vector) Vector {
+	norm := math.Sqrt(dot(v, v))
+	unit := make(Vector, len(v))
+	for i, x := range v {
+		unit[i] = float32(float64(x) / norm)
+	}
+	return unit
+}
+
+func dot(a, b Vector) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
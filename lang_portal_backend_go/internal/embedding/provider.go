@@ -0,0 +1,189 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderEnv selects the provider that computes embeddings: openai or
+	// ollama
+	ProviderEnv = "LANG_PORTAL_EMBEDDING_PROVIDER"
+	// ModelEnv is the embedding model
+	ModelEnv = "LANG_PORTAL_EMBEDDING_MODEL"
+	// URLEnv overrides the provider's API URL, e.g. for an OpenAI-compatible
+	// server or an Ollama on another host
+	URLEnv = "LANG_PORTAL_EMBEDDING_URL"
+	// APIKeyEnv is the OpenAI API key
+	APIKeyEnv = "LANG_PORTAL_EMBEDDING_API_KEY"
+)
+
+// Providers
+const (
+	OpenAI = "openai"
+	Ollama = "ollama"
+)
+
+const (
+	openAIURL   = "https://api.openai.com/v1"
+	openAIModel = "text-embedding-3-small"
+	ollamaURL   = "http://localhost:11434"
+	ollamaModel = "nomic-embed-text"
+)
+
+var client = &http.Client{Timeout: time.Minute}
+
+// Provider computes the embeddings of texts
+type Provider interface {
+	// Name names the provider and model, e.g. "openai/text-embedding-3-small".
+	// Only embeddings of the same name can be compared.
+	Name() string
+	// Embed returns the embedding of each text, in order
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	model := os.Getenv(ModelEnv)
+	url := strings.TrimSuffix(os.Getenv(URLEnv), "/")
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case OpenAI:
+		key := os.Getenv(APIKeyEnv)
+		if key == "" && url == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		if url == "" {
+			url = openAIURL
+		}
+		if model == "" {
+			model = openAIModel
+		}
+		return &openAI{url: url, key: key, model: model}, nil
+	case Ollama:
+		if url == "" {
+			url = ollamaURL
+		}
+		if model == "" {
+			model = ollamaModel
+		}
+		return &ollama{url: url, model: model}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s or %s", ProviderEnv, provider, OpenAI, Ollama)
+	}
+}
+
+// openAI computes embeddings with the embeddings API of OpenAI, or of a
+// server compatible with it
+type openAI struct {
+	url   string
+	key   string
+	model string
+}
+
+func (p *openAI) Name() string { return OpenAI + "/" + p.model }
+
+func (p *openAI) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	header := http.Header{}
+	if p.key != "" {
+		header.Set("Authorization", "Bearer "+p.key)
+	}
+	var response struct {
+		Data []struct {
+			Index     int    `json:"index"`
+			Embedding Vector `json:"embedding"`
+		} `json:"data"`
+	}
+	err := postJSON(ctx, p.url+"/embeddings", header, map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	}, &response)
+	if err != nil {
+		return nil, fmt.Errorf("openai: %v", err)
+	}
+	vectors := make([]Vector, len(texts))
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("openai: embedding of unknown input %d", data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	return checkVectors(OpenAI, vectors)
+}
+
+// ollama computes embeddings with the embed API of an Ollama server
+type ollama struct {
+	url   string
+	model string
+}
+
+func (p *ollama) Name() string { return Ollama + "/" + p.model }
+
+func (p *ollama) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	var response struct {
+		Embeddings []Vector `json:"embeddings"`
+	}
+	err := postJSON(ctx, p.url+"/api/embed", nil, map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	}, &response)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %v", err)
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama: %d embeddings returned for %d texts", len(response.Embeddings), len(texts))
+	}
+	return checkVectors(Ollama, response.Embeddings)
+}
+
+// checkVectors checks that a provider returned a usable vector for every
+// text
+func checkVectors(provider string, vectors []Vector) ([]Vector, error) {
+	for i, v := range vectors {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: embedding %d: %v", provider, i, err)
+		}
+	}
+	return vectors, nil
+}
+
+// postJSON posts body as JSON to url with the given headers and decodes the
+// response into v
+func postJSON(ctx context.Context, url string, header http.Header, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package events lets the service announce what happened, such as a word
+// being reviewed, to subscribers that act on it. Side effects subscribe to
+// the events they care about instead of being wired into the code that
+// records what happened.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Names of the events
+const (
+	NameWordReviewed     = "word_reviewed"
+	NameSessionCompleted = "session_completed"
+	NameWordCreated      = "word_created"
+	NameWordUpdated      = "word_updated"
+)
+
+// Event is something that happened
+type Event interface {
+	// Name names the kind of event, for subscribing to it
+	Name() string
+}
+
+// WordReviewed is published when a user reviews a word in any activity
+type WordReviewed struct {
+	UserID    int64
+	SessionID int64
+	WordID    int64
+	Correct   bool
+	At        time.Time
+}
+
+func (WordReviewed) Name() string { return NameWordReviewed }
+
+// SessionCompleted is published when a review leaves none of a study
+// session's words unreviewed
+type SessionCompleted struct {
+	UserID    int64
+	SessionID int64
+	// Words is how many words the session has, and Correct how many of
+	// them were last answered correctly
+	Words   int
+	Correct int
+	At      time.Time
+}
+
+func (SessionCompleted) Name() string { return NameSessionCompleted }
+
+// WordCreated is published when a word is added
+type WordCreated struct {
+	UserID  int64
+	WordID  int64
+	Urdu    string
+	English string
+	At      time.Time
+}
+
+func (WordCreated) Name() string { return NameWordCreated }
+
+// WordUpdated is published when a word's script, transliteration or
+// English changes
+type WordUpdated struct {
+	UserID int64
+	WordID int64
+	At     time.Time
+}
+
+func (WordUpdated) Name() string { return NameWordUpdated }
+
+// Handler acts on an event. Slow work, such as calling another service,
+// should be queued as a job rather than done while the publisher waits.
+type Handler func(ctx context.Context, event Event)
+
+// Bus delivers published events to the handlers subscribed to them
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe calls handler with every event of the named kind published from
+// now on
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish calls the handlers subscribed to the event, in the order they
+// subscribed. A handler that panics is logged and skipped, so one
+// subscriber can't fail the request that published the event.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("Handler of %s event panicked: %v", event.Name(), p)
+				}
+			}()
+			handler(ctx, event)
+		}()
+	}
+}
@@ -0,0 +1,157 @@
+// Package groupgen asks a language model for the words of a vocabulary
+// group on a topic and checks what it answers, so the group can be reviewed
+// before it is created.
+package groupgen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/llm"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxSize is the most words a generated group may have
+const MaxSize = 50
+
+// maxFieldLength is the longest urdu, urdlish or english text kept
+const maxFieldLength = 100
+
+// system tells the model what to answer
+const system = `You write vocabulary lists for English speakers learning Urdu.
+Answer with a JSON object only, of the form
+{"words": [{"urdu": "...", "urdlish": "...", "english": "..."}]}
+where urdu is the word in Urdu script, urdlish its romanized spelling in
+Latin letters, and english its English meaning. Use common, everyday words
+and phrases, each listed once.`
+
+// Generate asks provider for size words on topic and returns those worth
+// reviewing, and those dropped with why. A few more words than needed are
+// asked for, since some are usually dropped.
+func Generate(ctx context.Context, provider llm.Provider, topic string, size int) ([]models.DraftWord, []models.DroppedWord, error) {
+	prompt := fmt.Sprintf("Write %d Urdu words or short phrases for the topic %q.", size+extra(size), topic)
+	answer, err := provider.Complete(ctx, system, prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+	words, err := Parse(answer)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept, dropped := Clean(words, size)
+	return kept, dropped, nil
+}
+
+// extra is how many more words than size are asked for
+func extra(size int) int {
+	if size < 8 {
+		return 2
+	}
+	return size / 4
+}
+
+// Parse reads the words of a model's answer. Text around the JSON object,
+// such as a Markdown code fence, is ignored.
+func Parse(answer string) ([]models.DraftWord, error) {
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("answer has no JSON object")
+	}
+	var parsed struct {
+		Words []models.DraftWord `json:"words"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse answer: %v", err)
+	}
+	if len(parsed.Words) == 0 {
+		return nil, fmt.Errorf("answer has no words")
+	}
+	return parsed.Words, nil
+}
+
+// Clean trims the words' fields and drops words with missing or overlong
+// fields, with fields in the wrong script, and that repeat the urdu or
+// english of an earlier word, keeping at most size words. Loanwords whose
+// urdlish is their english are kept.
+func Clean(words []models.DraftWord, size int) ([]models.DraftWord, []models.DroppedWord) {
+	kept := []models.DraftWord{}
+	dropped := []models.DroppedWord{}
+	seen := map[string]bool{}
+	for _, word := range words {
+		word = trim(word)
+		reason := problem(word, seen)
+		if reason == "" && len(kept) == size {
+			reason = "more words than asked for"
+		}
+		if reason != "" {
+			dropped = append(dropped, models.DroppedWord{DraftWord: word, Reason: reason})
+			continue
+		}
+
+		seen["urdu:"+spelling.Normalize(word.Urdu)] = true
+		seen["english:"+spelling.Normalize(word.English)] = true
+		kept = append(kept, word)
+	}
+	return kept, dropped
+}
+
+// Problems returns why Clean would drop each word, or "" for words it
+// would keep, however many words there are
+func Problems(words []models.DraftWord) []string {
+	problems := make([]string, len(words))
+	seen := map[string]bool{}
+	for i, word := range words {
+		word = trim(word)
+		problems[i] = problem(word, seen)
+		seen["urdu:"+spelling.Normalize(word.Urdu)] = true
+		seen["english:"+spelling.Normalize(word.English)] = true
+	}
+	return problems
+}
+
+func trim(word models.DraftWord) models.DraftWord {
+	word.Urdu = strings.TrimSpace(word.Urdu)
+	word.Urdlish = strings.TrimSpace(word.Urdlish)
+	word.English = strings.TrimSpace(word.English)
+	word.WordID = 0
+	return word
+}
+
+// problem returns why word should be dropped, given the normalized urdu
+// and english of the words before it, or ""
+func problem(word models.DraftWord, seen map[string]bool) string {
+	switch {
+	case word.Urdu == "" || word.Urdlish == "" || word.English == "":
+		return "missing fields"
+	case tooLong(word.Urdu) || tooLong(word.Urdlish) || tooLong(word.English):
+		return "too long"
+	case !hasNonLatin(word.Urdu):
+		return "urdu contains no letters outside Latin script"
+	case hasNonLatin(word.Urdlish) || hasNonLatin(word.English):
+		return "urdlish and english should be written in Latin script"
+	case seen["urdu:"+spelling.Normalize(word.Urdu)] || seen["english:"+spelling.Normalize(word.English)]:
+		return "duplicate"
+	}
+	return ""
+}
+
+func tooLong(text string) bool {
+	return utf8.RuneCountInString(text) > maxFieldLength
+}
+
+// hasNonLatin reports whether text has letters of a script other than
+// Latin. The urdu of the words of a sheet of another language is in that
+// language's script.
+func hasNonLatin(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+	return false
+}
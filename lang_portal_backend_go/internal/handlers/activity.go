@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Activity is a study activity served by the API. Activities register
+// themselves with RegisterActivity; their routes are mounted by
+// RegisterActivityRoutes and the generic study session endpoints hand
+// sessions of the activity over to them.
+type Activity interface {
+	// Name is the activity's name in study_activities
+	Name() string
+	// Routes adds the activity's own endpoints
+	Routes(r *gin.RouterGroup)
+	// SessionCreated prepares a session created through the generic study
+	// session endpoints, e.g. by picking its words
+	SessionCreated(ctx context.Context, session *models.StudySessionResponse) error
+	// Score returns the score of a session of the activity
+	Score(ctx context.Context, sessionID int64) (*models.SessionScore, error)
+}
+
+// activityFactories build the registered activities by name
+var activityFactories = map[string]func(h *Handler) Activity{}
+
+// RegisterActivity makes an activity available under its study activity name.
+// It is meant to be called from init.
+func RegisterActivity(name string, factory func(h *Handler) Activity) {
+	if _, ok := activityFactories[name]; ok {
+		panic("handlers: activity registered twice: " + name)
+	}
+	activityFactories[name] = factory
+}
+
+// activity returns the registered activity with the given name
+func (h *Handler) activity(name string) (Activity, bool) {
+	factory, ok := activityFactories[name]
+	if !ok {
+		return nil, false
+	}
+	return factory(h), true
+}
+
+// RegisterActivityRoutes mounts the routes of every registered activity
+func RegisterActivityRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	names := make([]string, 0, len(activityFactories))
+	for name := range activityFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		activityFactories[name](h).Routes(r)
+	}
+}
+
+// sessionCreated runs the creation hook of the session's activity, if it is
+// a registered one
+func (h *Handler) sessionCreated(ctx context.Context, session *models.StudySessionResponse) error {
+	activity, ok := h.activity(session.ActivityName)
+	if !ok {
+		return nil
+	}
+	return activity.SessionCreated(ctx, session)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetUserRoleRequest represents the request body for changing a user's role
+type SetUserRoleRequest struct {
+	// Role is learner or admin
+	Role string `json:"role" binding:"required"`
+}
+
+// RegisterAdminRoutes registers the admin dashboard and role routes. r
+// must require the admin role, see RequireAdmin.
+func RegisterAdminRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	admin := r.Group("/admin")
+	{
+		admin.GET("/dashboard", h.GetAdminDashboard)
+		admin.PUT("/users/:id/role", h.SetUserRole)
+		admin.GET("/embeddings", h.GetEmbeddingStatus)
+	}
+}
+
+// RequireAdmin answers 403 Forbidden to requests of users who aren't admins
+func RequireAdmin(svc *service.Service) gin.HandlerFunc {
+	h := NewHandler(svc)
+	return func(c *gin.Context) {
+		if err := h.svcFor(c).CheckAdmin(c.Request.Context()); err != nil {
+			serviceError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAdminDashboard returns the state of the database, background jobs and
+// latest server errors
+func (h *Handler) GetAdminDashboard(c *gin.Context) {
+	dashboard, err := h.svc.AdminDashboard(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// SetUserRole changes the role of a user
+func (h *Handler) SetUserRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.svc.SetUserRole(c.Request.Context(), id, req.Role)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// GetEmbeddingStatus counts the words whose embeddings are current, missing
+// or stale
+func (h *Handler) GetEmbeddingStatus(c *gin.Context) {
+	status, err := h.svcFor(c).GetEmbeddingStatus(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
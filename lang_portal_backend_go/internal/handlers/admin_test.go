@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"lang_portal/internal/repository"
+	"lang_portal/internal/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerAdmin mounts the routes behind RequireAdmin as the server does
+func registerAdmin(r *gin.RouterGroup, svc *service.Service) {
+	admin := r.Group("")
+	admin.Use(RequireAdmin(svc))
+	RegisterSystemAdminRoutes(admin, svc)
+	RegisterBackupRoutes(admin, svc)
+	RegisterMaintenanceRoutes(admin, svc)
+	RegisterAdminRoutes(admin, svc)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodPost, path: "/full_reset", body: `{"confirm_token": "token"}`},
+		{method: http.MethodPost, path: "/system/bootstrap"},
+		{method: http.MethodPost, path: "/system/rollup_stats"},
+		{method: http.MethodGet, path: "/system/seeds"},
+		{method: http.MethodPost, path: "/system/seeds/urdu-basics"},
+		{method: http.MethodPost, path: "/admin/backup"},
+		{method: http.MethodGet, path: "/admin/backup"},
+		{method: http.MethodPost, path: "/admin/restore", body: `{"name": "backup.db"}`},
+		{method: http.MethodGet, path: "/admin/consistency"},
+		{method: http.MethodGet, path: "/admin/maintenance"},
+		{method: http.MethodPost, path: "/admin/maintenance/analyze"},
+		{method: http.MethodPost, path: "/admin/maintenance/optimize"},
+		{method: http.MethodPost, path: "/admin/maintenance/vacuum"},
+		{method: http.MethodGet, path: "/admin/maintenance/integrity_check"},
+		{method: http.MethodPut, path: "/admin/users/2/role", body: `{"role": "admin"}`},
+	}
+
+	svc := newTestService(t, repository.Repositories{}, `INSERT INTO users (id, username) VALUES (2, 'learner')`)
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := serve(registerAdmin, svc.ForUser(2), tt.method, tt.path, tt.body)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := serve(registerAdmin, svc, http.MethodGet, "/admin/maintenance", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("status for an admin = %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResetRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int64
+		body       string
+		wantStatus int
+	}{
+		{name: "learner confirms a history reset", userID: 2, body: `{"kind": "history"}`, wantStatus: http.StatusCreated},
+		{name: "learner confirms a full reset", userID: 2, body: `{"kind": "full"}`, wantStatus: http.StatusForbidden},
+		{name: "admin confirms a full reset", userID: 1, body: `{"kind": "full"}`, wantStatus: http.StatusCreated},
+	}
+
+	svc := newTestService(t, repository.Repositories{}, `INSERT INTO users (id, username) VALUES (2, 'learner')`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(RegisterResetRoutes, svc.ForUser(tt.userID), http.MethodPost, "/resets/confirmations", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAudioRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/words/:id/audio/generate", h.GenerateWordAudio)
+	r.POST("/groups/:id/audio/generate", h.GenerateGroupAudio)
+}
+
+// RegisterAudioFileRoutes serves the synthesized audio files under
+// service.AudioURLPrefix. They need no access token, so audio elements can
+// play them.
+func RegisterAudioFileRoutes(r gin.IRoutes, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET(service.AudioURLPrefix+":name", h.ServeAudio)
+}
+
+// GenerateWordAudio gives a word synthesized speech. With force=true a word
+// that already has audio is given new audio too.
+func (h *Handler) GenerateWordAudio(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	audio, err := h.svcFor(c).GenerateWordAudio(c.Request.Context(), id, c.Query("force") == "true")
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, audio)
+}
+
+// GenerateGroupAudio queues giving every word of a group synthesized speech
+func (h *Handler) GenerateGroupAudio(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	job, err := h.svcFor(c).EnqueueGroupAudio(c.Request.Context(), id, c.Query("force") == "true")
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// ServeAudio redirects to a signed URL of a synthesized audio file in the
+// media storage. The URL expires, so the redirect is temporary.
+func (h *Handler) ServeAudio(c *gin.Context) {
+	url, err := h.svc.AudioFileURL(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"lang_portal/internal/auth"
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CredentialsRequest represents the request body for logging in
+type CredentialsRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RegisterRequest represents the request body for registering
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"`
+}
+
+// RefreshRequest represents the request body for refreshing tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ClaimGuestRequest represents the request body for claiming a guest's
+// history. GuestToken is the guest's access or refresh token.
+type ClaimGuestRequest struct {
+	GuestToken string `json:"guest_token" binding:"required"`
+}
+
+// AuthResponse is returned when tokens are issued
+type AuthResponse struct {
+	User *models.User `json:"user"`
+	*auth.Tokens
+}
+
+// googleStateCookie carries the state a Google login was started with, for
+// the callback to check
+const googleStateCookie = "google_oauth_state"
+
+// googleStateCookiePath scopes the state cookie to the API, so a login
+// started under /api/v1 can finish at a callback under /api or the reverse
+const googleStateCookiePath = "/api"
+
+// authHandler serves the endpoints that issue tokens
+type authHandler struct {
+	svc    *service.Service
+	issuer *auth.Issuer
+	// google is nil when Google login isn't configured
+	google *auth.Google
+}
+
+// RegisterAuthRoutes mounts the endpoints that issue tokens. They must not be
+// behind middleware.Auth.
+func RegisterAuthRoutes(r *gin.RouterGroup, svc *service.Service, issuer *auth.Issuer, google *auth.Google) {
+	h := &authHandler{svc: svc, issuer: issuer, google: google}
+	routes := r.Group("/auth")
+	{
+		routes.POST("/register", h.Register)
+		routes.POST("/login", h.Login)
+		routes.POST("/refresh", h.Refresh)
+		routes.POST("/guest", h.Guest)
+		routes.GET("/google", h.GoogleLogin)
+		routes.GET("/google/callback", h.GoogleCallback)
+	}
+}
+
+// RegisterGuestRoutes mounts the endpoints for moving guest history to a
+// user. They must be behind middleware.Auth.
+func RegisterGuestRoutes(r *gin.RouterGroup, svc *service.Service, issuer *auth.Issuer) {
+	h := &authHandler{svc: svc, issuer: issuer}
+	r.POST("/guest/claim", h.ClaimGuest)
+}
+
+// Register creates a user and logs them in
+func (h *authHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.svc.RegisterUser(c.Request.Context(), req.Username, req.Password, req.Email)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	h.issue(c, http.StatusCreated, user)
+}
+
+// Login issues tokens for a username and password
+func (h *authHandler) Login(c *gin.Context) {
+	var req CredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.svc.AuthenticateUser(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	h.issue(c, http.StatusOK, user)
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens
+func (h *authHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := h.issuer.Verify(req.RefreshToken, auth.TokenRefresh, time.Now())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	user, err := h.svc.GetUser(c.Request.Context(), claims.Subject)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		serviceError(c, err)
+		return
+	}
+	h.issue(c, http.StatusOK, user)
+}
+
+// Guest creates a guest user and logs them in
+func (h *authHandler) Guest(c *gin.Context) {
+	user, err := h.svc.CreateGuest(c.Request.Context(), time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	h.issue(c, http.StatusCreated, user)
+}
+
+// ClaimGuest moves the history of the guest a token was issued to over to the
+// user making the request
+func (h *authHandler) ClaimGuest(c *gin.Context) {
+	var req ClaimGuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	claims, err := h.issuer.Verify(req.GuestToken, auth.TokenRefresh, now)
+	if err != nil {
+		claims, err = h.issuer.Verify(req.GuestToken, auth.TokenAccess, now)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid guest token"})
+		return
+	}
+
+	userID, _ := middleware.CurrentUserID(c)
+	if claims.Subject == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "guests cannot claim guest history"})
+		return
+	}
+
+	svc := h.svc.ForUser(userID)
+	if err := svc.ClaimGuest(c.Request.Context(), claims.Subject, now); err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	user, err := svc.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// GoogleLogin sends the user to Google to log in
+func (h *authHandler) GoogleLogin(c *gin.Context) {
+	if h.google == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "google login is not configured"})
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		serviceError(c, err)
+		return
+	}
+	state := hex.EncodeToString(nonce)
+
+	c.SetCookie(googleStateCookie, state, 600, googleStateCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, h.google.AuthURL(state))
+}
+
+// GoogleCallback finishes a Google login and issues tokens for the user with
+// the account's email address, creating one if there is none
+func (h *authHandler) GoogleCallback(c *gin.Context) {
+	if h.google == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "google login is not configured"})
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "google login failed: " + reason})
+		return
+	}
+
+	state, err := c.Cookie(googleStateCookie)
+	if err != nil || state == "" || c.Query("state") != state {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+		return
+	}
+	c.SetCookie(googleStateCookie, "", -1, googleStateCookiePath, "", c.Request.TLS != nil, true)
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing code"})
+		return
+	}
+
+	profile, err := h.google.Exchange(c.Request.Context(), code)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if profile.Email == "" || !profile.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "google account email is not verified"})
+		return
+	}
+
+	user, err := h.svc.LoginWithEmail(c.Request.Context(), profile.Email)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	h.issue(c, http.StatusOK, user)
+}
+
+// issue responds with new tokens for a user
+func (h *authHandler) issue(c *gin.Context, status int, user *models.User) {
+	tokens, err := h.issuer.Issue(user.ID, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(status, AuthResponse{User: user, Tokens: tokens})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterBackupRoutes registers the backup and restore routes. r must
+// require the admin role.
+func RegisterBackupRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/admin/backup", h.CreateBackup)
+	r.GET("/admin/backup", h.ListBackups)
+	r.POST("/admin/restore", h.RestoreBackup)
+	r.GET("/admin/consistency", h.CheckConsistency)
+}
+
+// RestoreRequest names the backup to restore
+type RestoreRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateBackup queues a backup of the database to the backup directory
+func (h *Handler) CreateBackup(c *gin.Context) {
+	job, err := h.svcFor(c).EnqueueBackup(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// ListBackups lists the backups, newest first
+func (h *Handler) ListBackups(c *gin.Context) {
+	backups, err := h.svc.ListBackups()
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": backups})
+}
+
+// RestoreBackup replaces the database with a backup
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	var req RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	previous, err := h.svc.RestoreBackup(c.Request.Context(), req.Name)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"restored": req.Name,
+		"backup":   previous,
+	})
+}
+
+// CheckConsistency reports whether foreign keys are enforced and the rows
+// that refer to missing rows
+func (h *Handler) CheckConsistency(c *gin.Context) {
+	report, err := h.svc.CheckConsistency(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
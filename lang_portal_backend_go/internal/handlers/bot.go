@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"lang_portal/internal/bot"
+	"lang_portal/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BotLinkRequest represents the request body for linking a chat
+type BotLinkRequest struct {
+	Platform string `json:"platform" binding:"required"`
+	ChatID   string `json:"chat_id" binding:"required"`
+	QuizHour *int   `json:"quiz_hour"`
+}
+
+// BotLinkConfirmRequest represents the request body for confirming a chat
+type BotLinkConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func RegisterBotRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	link := r.Group("/bot/link")
+	{
+		link.GET("", h.GetBotLink)
+		link.PUT("", h.LinkBotChat)
+		link.DELETE("", h.UnlinkBotChat)
+		link.POST("/confirm", h.ConfirmBotLink)
+	}
+}
+
+// RegisterBotWebhookRoutes receives the answers sent through the bots. The
+// platforms send no access token; each bot checks its requests instead.
+func RegisterBotWebhookRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/bot/:platform/webhook", h.BotWebhook)
+}
+
+// GetBotLink returns the chat the user is sent quiz questions in
+func (h *Handler) GetBotLink(c *gin.Context) {
+	link, err := h.svcFor(c).GetBotLink(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// LinkBotChat links a chat to the user, sending it a code to confirm with
+func (h *Handler) LinkBotChat(c *gin.Context) {
+	var req BotLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	quizHour := service.DefaultQuizHour
+	if req.QuizHour != nil {
+		quizHour = *req.QuizHour
+	}
+
+	link, err := h.svcFor(c).LinkBotChat(c.Request.Context(), req.Platform, req.ChatID, quizHour, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// ConfirmBotLink starts the quiz questions of the linked chat
+func (h *Handler) ConfirmBotLink(c *gin.Context) {
+	var req BotLinkConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.svcFor(c).ConfirmBotLink(c.Request.Context(), req.Code, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// UnlinkBotChat stops the quiz questions
+func (h *Handler) UnlinkBotChat(c *gin.Context) {
+	if err := h.svcFor(c).UnlinkBotChat(c.Request.Context()); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// BotWebhook hands a platform's webhook request to its bot, which records
+// the answers in it
+func (h *Handler) BotWebhook(c *gin.Context) {
+	platform := c.Param("platform")
+	b, err := h.svc.Bot(platform)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	b.ServeWebhook(c.Writer, c.Request, func(ctx context.Context, answer bot.Answer) (string, error) {
+		reply, err := h.svc.AnswerBotQuestion(ctx, platform, answer.ChatID, answer.Data, time.Now())
+		// Answers that can't be recorded, e.g. a second answer, are told
+		// so in the chat
+		var svcErr *service.Error
+		if errors.As(err, &svcErr) {
+			return svcErr.Message, nil
+		}
+		return reply, err
+	})
+}
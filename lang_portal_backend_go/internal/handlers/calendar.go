@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"lang_portal/internal/ical"
+	"lang_portal/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCalendarRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/me/calendar/token", h.CreateCalendarToken)
+	r.DELETE("/me/calendar/token", h.DeleteCalendarToken)
+}
+
+// RegisterCalendarFeedRoutes serves users' calendar feeds. Calendar apps
+// send no access token; the feed's URL carries a calendar token instead.
+func RegisterCalendarFeedRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/me/calendar.ics", h.GetCalendarFeed)
+}
+
+// CreateCalendarToken gives the user a new calendar feed URL, replacing the
+// one they had
+func (h *Handler) CreateCalendarToken(c *gin.Context) {
+	token, err := h.svcFor(c).CreateCalendarToken(c.Request.Context(), time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	token.URL = calendarFeedURL(c, token.Token)
+	c.JSON(http.StatusCreated, token)
+}
+
+// DeleteCalendarToken stops serving the user's calendar feed
+func (h *Handler) DeleteCalendarToken(c *gin.Context) {
+	if err := h.svcFor(c).DeleteCalendarToken(c.Request.Context()); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetCalendarFeed serves the study calendar of the user a calendar token
+// was given to, as iCalendar data
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	userID, err := h.svc.CalendarUser(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	now := time.Now()
+	calendar, err := h.svc.ForUser(userID).GetCalendar(c.Request.Context(), now)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Content-Disposition", `inline; filename="lang-portal.ics"`)
+	c.Data(http.StatusOK, ical.ContentType, calendar.Encode(now))
+}
+
+// calendarFeedURL returns the URL of the calendar feed of token on the host
+// the request was made to. Calendar apps need the whole URL to subscribe.
+func calendarFeedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	base := "/api"
+	if strings.HasPrefix(c.Request.URL.Path, "/api/v1/") {
+		base = "/api/v1"
+	}
+	return scheme + "://" + c.Request.Host + base + "/me/calendar.ics?token=" + url.QueryEscape(token)
+}
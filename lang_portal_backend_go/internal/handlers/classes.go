@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateClassRequest represents the request body for creating a class
+type CreateClassRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// JoinClassRequest represents the request body for joining a class
+type JoinClassRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+}
+
+// CreateAssignmentRequest represents the request body for setting homework.
+// DueAt is an RFC 3339 time, or a date meaning the end of that day (UTC).
+type CreateAssignmentRequest struct {
+	GroupID int64  `json:"group_id" binding:"required"`
+	DueAt   string `json:"due_at" binding:"required"`
+}
+
+func RegisterClassRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	classes := r.Group("/classes")
+	{
+		classes.GET("", h.ListClasses)
+		classes.POST("", h.CreateClass)
+		classes.POST("/join", h.JoinClass)
+		classes.GET("/:id", h.GetClass)
+		classes.DELETE("/:id", h.DeleteClass)
+		classes.DELETE("/:id/students/:user_id", h.RemoveClassStudent)
+		classes.GET("/:id/assignments", h.ListClassAssignments)
+		classes.POST("/:id/assignments", h.CreateClassAssignment)
+		classes.DELETE("/:id/assignments/:assignment_id", h.DeleteClassAssignment)
+		classes.GET("/:id/progress", h.GetClassProgress)
+	}
+}
+
+// parseDueAt reads an RFC 3339 time, or a date meaning the end of that day
+// (UTC)
+func parseDueAt(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), true
+	}
+	return time.Time{}, false
+}
+
+// ListClasses returns the classes the user teaches or is a student of
+func (h *Handler) ListClasses(c *gin.Context) {
+	classes, err := h.svcFor(c).GetClasses(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, classes)
+}
+
+// CreateClass creates a class taught by the user
+func (h *Handler) CreateClass(c *gin.Context) {
+	var req CreateClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	class, err := h.svcFor(c).CreateClass(c.Request.Context(), req.Name)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, class)
+}
+
+// JoinClass makes the user a student of the class with an invite code
+func (h *Handler) JoinClass(c *gin.Context) {
+	var req JoinClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	class, err := h.svcFor(c).JoinClass(c.Request.Context(), req.InviteCode)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, class)
+}
+
+// GetClass returns a class
+func (h *Handler) GetClass(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	class, err := h.svcFor(c).GetClass(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, class)
+}
+
+// DeleteClass deletes a class
+func (h *Handler) DeleteClass(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteClass(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveClassStudent takes a student out of a class
+func (h *Handler) RemoveClassStudent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.svcFor(c).RemoveClassStudent(c.Request.Context(), id, userID); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListClassAssignments returns the assignments of a class
+func (h *Handler) ListClassAssignments(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	assignments, err := h.svcFor(c).GetClassAssignments(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// CreateClassAssignment sets a group as homework for a class
+func (h *Handler) CreateClassAssignment(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dueAt, ok := parseDueAt(req.DueAt)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid due date"})
+		return
+	}
+
+	assignment, err := h.svcFor(c).CreateClassAssignment(c.Request.Context(), id, req.GroupID, dueAt, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// DeleteClassAssignment removes an assignment from a class
+func (h *Handler) DeleteClassAssignment(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	assignmentID, err := strconv.ParseInt(c.Param("assignment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid assignment id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteClassAssignment(c.Request.Context(), id, assignmentID); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetClassProgress returns each student's progress, for the class teacher
+func (h *Handler) GetClassProgress(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	progress, err := h.svcFor(c).GetClassProgress(c.Request.Context(), id, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
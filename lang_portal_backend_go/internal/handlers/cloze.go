@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultClozeWordCount is used when no word count is given
+const defaultClozeWordCount = 10
+
+// StartClozeRequest represents the request body for starting a cloze
+// session
+type StartClozeRequest struct {
+	GroupID   int64 `json:"group_id" binding:"required"`
+	WordCount int   `json:"word_count" binding:"omitempty,min=1,max=20"`
+	// AnswerMode is multiple_choice, the default, or typed
+	AnswerMode string `json:"answer_mode"`
+}
+
+// ClozeAnswer represents the answer to a cloze question
+type ClozeAnswer struct {
+	Answer string `json:"answer" binding:"required"`
+}
+
+func RegisterClozeRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	cloze := r.Group("/cloze")
+	{
+		cloze.POST("/start", h.StartCloze)
+		cloze.GET("/sessions/:session_id", h.GetCloze)
+		cloze.POST("/sessions/:session_id/words/:word_id/answer", h.AnswerCloze)
+	}
+}
+
+// StartCloze starts a cloze session on the words of a group the learner
+// struggles with most
+func (h *Handler) StartCloze(c *gin.Context) {
+	var req StartClozeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.WordCount == 0 {
+		req.WordCount = defaultClozeWordCount
+	}
+
+	session, err := h.svcFor(c).StartCloze(c.Request.Context(), req.GroupID, req.WordCount, req.AnswerMode)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetCloze returns the questions of a cloze session
+func (h *Handler) GetCloze(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetCloze(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// AnswerCloze checks the answer to a cloze question and returns the
+// answered question
+func (h *Handler) AnswerCloze(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return
+	}
+
+	var req ClozeAnswer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.svcFor(c).AnswerCloze(c.Request.Context(), sessionID, wordID, req.Answer)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, question)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultConjugationDrillWordCount is used when no word count is given
+const defaultConjugationDrillWordCount = 10
+
+// ConjugationsRequest represents the request body for replacing the forms
+// of a verb
+type ConjugationsRequest struct {
+	Conjugations []models.Conjugation `json:"conjugations"`
+}
+
+// StartConjugationDrillRequest represents the request body for starting a
+// conjugation drill
+type StartConjugationDrillRequest struct {
+	GroupID   int64 `json:"group_id" binding:"required"`
+	WordCount int   `json:"word_count" binding:"omitempty,min=1,max=20"`
+	// Tense and Person, if given, only drill forms of them
+	Tense  string `json:"tense"`
+	Person string `json:"person"`
+}
+
+// ConjugationDrillAnswer represents the answer to a conjugation drill round
+type ConjugationDrillAnswer struct {
+	Answer string `json:"answer" binding:"required"`
+}
+
+func RegisterConjugationRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/words/:id/conjugations", h.GetConjugations)
+	r.PUT("/words/:id/conjugations", h.SetConjugations)
+
+	drills := r.Group("/conjugation-drill")
+	{
+		drills.POST("/start", h.StartConjugationDrill)
+		drills.GET("/sessions/:session_id", h.GetConjugationDrill)
+		drills.POST("/sessions/:session_id/words/:word_id/answer", h.AnswerConjugationDrill)
+	}
+}
+
+// GetConjugations returns the forms of a verb
+func (h *Handler) GetConjugations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	conjugations, err := h.svcFor(c).GetConjugations(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conjugations": conjugations})
+}
+
+// SetConjugations replaces the forms of a verb
+func (h *Handler) SetConjugations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req ConjugationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conjugations, err := h.svcFor(c).SetConjugations(c.Request.Context(), id, req.Conjugations)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conjugations": conjugations})
+}
+
+// StartConjugationDrill starts a conjugation drill on the verbs of a group
+// the learner struggles with most
+func (h *Handler) StartConjugationDrill(c *gin.Context) {
+	var req StartConjugationDrillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.WordCount == 0 {
+		req.WordCount = defaultConjugationDrillWordCount
+	}
+
+	session, err := h.svcFor(c).StartConjugationDrill(c.Request.Context(), req.GroupID, req.WordCount, req.Tense, req.Person)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetConjugationDrill returns the rounds of a conjugation drill
+func (h *Handler) GetConjugationDrill(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetConjugationDrill(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// AnswerConjugationDrill checks the answer to a round and returns the
+// answered round
+func (h *Handler) AnswerConjugationDrill(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return
+	}
+
+	var req ConjugationDrillAnswer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	round, err := h.svcFor(c).AnswerConjugationDrill(c.Request.Context(), sessionID, wordID, req.Answer)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, round)
+}
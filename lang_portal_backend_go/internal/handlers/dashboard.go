@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default number of buckets returned by the trends endpoint
+const (
+	defaultDailyTrendPeriods  = 30
+	defaultWeeklyTrendPeriods = 12
+	maxTrendPeriods           = 366
+)
+
+// Defaults of the forecast endpoint
+const (
+	defaultForecastWindowDays = 30
+	maxForecastWindowDays     = 365
+	defaultForecastTargetDays = 90
+)
+
+func RegisterDashboardRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	dashboard := r.Group("/dashboard")
+	dashboard.Use(middleware.ETag())
+	{
+		dashboard.GET("/last_study_session", h.GetLastStudySession)
+		dashboard.GET("/study_progress", h.GetStudyProgress)
+		dashboard.GET("/quick-stats", h.GetQuickStats)
+		dashboard.GET("/heatmap", h.GetActivityHeatmap)
+		dashboard.GET("/trends", h.GetTrends)
+		dashboard.GET("/goals", h.GetGoalStatuses)
+		dashboard.GET("/forecast", h.GetForecast)
+	}
+}
+
+func (h *Handler) GetLastStudySession(c *gin.Context) {
+	session, err := h.svcFor(c).GetLastStudySession(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// dashboardRange reads the date range of a statistics request: from and to
+// dates, or else a range preset. It responds with 400 if the range is invalid.
+func dashboardRange(c *gin.Context, defaultPreset string) (models.DateRange, bool) {
+	var (
+		r   models.DateRange
+		err error
+	)
+	if from, to := c.Query("from"), c.Query("to"); from != "" || to != "" {
+		r, err = service.CustomRange(from, to)
+	} else {
+		r, err = service.PresetRange(c.DefaultQuery("range", defaultPreset), time.Now())
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return r, false
+	}
+	return r, true
+}
+
+func (h *Handler) GetStudyProgress(c *gin.Context) {
+	r, ok := dashboardRange(c, service.RangeAll)
+	if !ok {
+		return
+	}
+	progress, err := h.svcFor(c).GetStudyProgress(c.Request.Context(), r)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+func (h *Handler) GetQuickStats(c *gin.Context) {
+	r, ok := dashboardRange(c, service.RangeLast30Days)
+	if !ok {
+		return
+	}
+	stats, err := h.svcFor(c).GetQuickStats(c.Request.Context(), r)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetActivityHeatmap returns review counts per day of a year, the current
+// one by default
+func (h *Handler) GetActivityHeatmap(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil || year < 1 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+		return
+	}
+
+	heatmap, err := h.svcFor(c).GetActivityHeatmap(c.Request.Context(), year)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// GetTrends returns accuracy, review and new word counts per day or week,
+// optionally for the words of one group
+func (h *Handler) GetTrends(c *gin.Context) {
+	granularity := c.DefaultQuery("granularity", service.TrendDay)
+	defaultPeriods := defaultDailyTrendPeriods
+	switch granularity {
+	case service.TrendDay:
+	case service.TrendWeek:
+		defaultPeriods = defaultWeeklyTrendPeriods
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be day or week"})
+		return
+	}
+
+	periods, err := strconv.Atoi(c.DefaultQuery("periods", strconv.Itoa(defaultPeriods)))
+	if err != nil || periods < 1 || periods > maxTrendPeriods {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid periods"})
+		return
+	}
+
+	var groupID int64
+	if value := c.Query("group_id"); value != "" {
+		groupID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || groupID < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_id"})
+			return
+		}
+	}
+
+	trends, err := h.svcFor(c).GetTrends(c.Request.Context(), granularity, periods, groupID, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, trends)
+}
+
+// GetGoalStatuses returns the progress toward every goal
+func (h *Handler) GetGoalStatuses(c *gin.Context) {
+	statuses, err := h.svcFor(c).GetGoalStatuses(c.Request.Context(), time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+// GetForecast projects when each group and the whole corpus will be learned
+func (h *Handler) GetForecast(c *gin.Context) {
+	window, err := strconv.Atoi(c.DefaultQuery("window_days", strconv.Itoa(defaultForecastWindowDays)))
+	if err != nil || window < 1 || window > maxForecastWindowDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window_days"})
+		return
+	}
+
+	now := time.Now()
+	target := now.AddDate(0, 0, defaultForecastTargetDays)
+	if date := c.Query("target_date"); date != "" {
+		target, err = time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target_date"})
+			return
+		}
+	}
+
+	forecast, err := h.svcFor(c).GetForecast(c.Request.Context(), window, target, now)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, forecast)
+}
@@ -0,0 +1,680 @@
+package handlers
+
+import (
+	"lang_portal/internal/auth"
+	"lang_portal/internal/models"
+	"lang_portal/internal/openapi"
+	"lang_portal/internal/quality"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsPrefix is the prefix of the routes the OpenAPI document describes
+const DocsPrefix = "/api/v1"
+
+// swaggerUIVersion is the version of swagger-ui-dist the docs page loads
+const swaggerUIVersion = "5.17.14"
+
+// pageQuery is the page parameter of paginated lists
+var pageQuery = []openapi.Param{{Name: "page", Description: "Page to return, from 1", Type: "integer"}}
+
+// cursorQuery are the parameters of lists that can also be read by cursor
+var cursorQuery = append([]openapi.Param{
+	{Name: "cursor", Description: "Read by cursor instead of page number: empty for the first page, then the next_cursor of the previous page"},
+}, pageQuery...)
+
+// sorted adds the sort parameter of lists that can be sorted in Urdu
+// alphabetical order to params
+func sorted(params []openapi.Param, by string) []openapi.Param {
+	return append(append([]openapi.Param{}, params...), openapi.Param{
+		Name:        "sort",
+		Description: "id, the default, or urdu to sort by " + by + " in Urdu alphabetical order",
+	})
+}
+
+// wordMatches is the response of word searches
+var wordMatches = struct {
+	Items []models.WordMatch `json:"items"`
+	Count int                `json:"count"`
+}{}
+
+// wordSenses is the response of a word's senses
+var wordSenses = struct {
+	Senses []models.WordSense `json:"senses"`
+}{}
+
+// conjugationList is the response of a verb's forms
+var conjugationList = struct {
+	Conjugations []models.Conjugation `json:"conjugations"`
+}{}
+
+// sentenceList is the response of sentence lists and searches
+var sentenceList = struct {
+	Items []models.Sentence `json:"items"`
+	Count int               `json:"count"`
+}{}
+
+// passageList is the response of the passage list
+var passageList = struct {
+	Items []models.Passage `json:"items"`
+	Count int              `json:"count"`
+}{}
+
+// grammarTopicList is the response of the grammar topic list
+var grammarTopicList = struct {
+	Items []models.GrammarTopic `json:"items"`
+	Count int                   `json:"count"`
+}{}
+
+// languageList is the response of the language list
+var languageList = struct {
+	Languages []models.Language `json:"languages"`
+}{}
+
+// withFields adds the fields parameter of routes whose responses can be
+// trimmed to params
+func withFields(params []openapi.Param) []openapi.Param {
+	return append(append([]openapi.Param{}, params...), openapi.Param{
+		Name:        "fields",
+		Description: "Comma-separated fields to return of each item, or of the object; all by default",
+	})
+}
+
+// rangeQuery are the date range parameters of dashboard statistics
+var rangeQuery = []openapi.Param{
+	{Name: "range", Description: "Preset range: 7d, 30d, 90d or all"},
+	{Name: "from", Description: "Start of a custom range, YYYY-MM-DD"},
+	{Name: "to", Description: "End of a custom range, YYYY-MM-DD, inclusive"},
+}
+
+// languageQuery is the parameter of routes whose words and statistics can
+// be narrowed to a language
+var languageQuery = openapi.Param{Name: "language", Description: "Code of the language to cover, e.g. ja; every language by default"}
+
+// inLanguage adds languageQuery to params
+func inLanguage(params []openapi.Param) []openapi.Param {
+	return append(append([]openapi.Param{}, params...), languageQuery)
+}
+
+// routeDocs describes the request and response bodies of routes, by
+// method and path below DocsPrefix. Routes missing here are documented by
+// their path and handler name.
+var routeDocs = map[string]openapi.Operation{
+	"POST /auth/register": {Summary: "Register a user", Request: RegisterRequest{}, Response: AuthResponse{}, Status: http.StatusCreated, Public: true},
+	"POST /auth/login":    {Summary: "Log in", Request: CredentialsRequest{}, Response: AuthResponse{}, Public: true},
+	"POST /auth/refresh":  {Summary: "Refresh tokens", Request: RefreshRequest{}, Response: auth.Tokens{}, Public: true},
+	"POST /auth/guest":    {Summary: "Log in as a new guest", Response: AuthResponse{}, Status: http.StatusCreated, Public: true},
+	"GET /auth/google":    {Summary: "Start a Google login", Status: http.StatusFound, Public: true},
+	"GET /auth/google/callback": {
+		Summary: "Finish a Google login",
+		Query: []openapi.Param{
+			{Name: "code", Description: "Authorization code from Google"},
+			{Name: "state", Description: "State the login was started with"},
+		},
+		Response: AuthResponse{},
+		Public:   true,
+	},
+	"POST /guest/claim": {Summary: "Claim a guest's history", Request: ClaimGuestRequest{}, Response: models.User{}},
+
+	"GET /openapi.json": {Summary: "OpenAPI document of the API", Public: true},
+	"GET /docs":         {Summary: "Swagger UI of the API", Public: true},
+
+	"GET /dashboard/last_study_session": {Summary: "Last study session", Response: models.StudySessionResponse{}},
+	"GET /dashboard/study_progress":     {Summary: "Words studied", Query: inLanguage(rangeQuery), Response: models.StudyProgress{}},
+	"GET /dashboard/quick-stats":        {Summary: "Study statistics", Query: inLanguage(rangeQuery), Response: models.DashboardStats{}},
+	"GET /dashboard/heatmap": {
+		Summary:  "Reviews per day of a year",
+		Query:    []openapi.Param{{Name: "year", Description: "Year, the current one by default", Type: "integer"}},
+		Response: models.ActivityHeatmap{},
+	},
+	"GET /dashboard/trends": {
+		Summary: "Reviews and accuracy per period",
+		Query: []openapi.Param{
+			{Name: "granularity", Description: "day or week"},
+			{Name: "periods", Description: "Number of periods", Type: "integer"},
+			{Name: "group_id", Description: "Only reviews of the group's words", Type: "integer"},
+		},
+		Response: models.Trends{},
+	},
+	"GET /dashboard/goals": {Summary: "Progress towards goals", Response: []models.GoalStatus{}},
+	"GET /dashboard/forecast": {
+		Summary: "Forecast of learned words",
+		Query: []openapi.Param{
+			{Name: "window_days", Description: "Days of history the pace is measured over", Type: "integer"},
+			{Name: "target_date", Description: "Date to forecast, YYYY-MM-DD"},
+		},
+		Response: models.Forecast{},
+	},
+
+	"GET /study_activities":                    {Summary: "List study activities", Query: pageQuery, Page: models.StudyActivityResponse{}},
+	"GET /study_activities/:id":                {Summary: "Get a study activity", Response: models.StudyActivityResponse{}},
+	"GET /study_activities/:id/study_sessions": {Summary: "List an activity's study sessions", Query: pageQuery, Page: models.StudySessionResponse{}},
+	"POST /study_activities": {
+		Summary: "Start a study session of an activity",
+		Request: struct {
+			GroupID         int64 `json:"group_id"`
+			StudyActivityID int64 `json:"study_activity_id"`
+		}{},
+		Response: models.StudySessionResponse{},
+		Status:   http.StatusCreated,
+	},
+
+	"GET /words":                    {Summary: "List words", Description: "Lists read by cursor are in ID order only.", Query: inLanguage(withFields(sorted(cursorQuery, "script"))), Page: models.WordResponse{}},
+	"GET /words/:id":                {Summary: "Get a word", Query: withFields(nil), Response: models.WordResponse{}},
+	"GET /words/:id/learning_state": {Summary: "Spaced repetition schedule of a word", Response: models.WordLearningState{}},
+	"GET /words/:id/embedding": {
+		Summary:     "A word's embedding status",
+		Description: "The model, dimensions and text of the word's embedding, without its vector, and whether it is stale: missing, of another model than the configured provider's, or computed from text the word no longer has.",
+		Response:    models.WordEmbeddingStatus{},
+	},
+	"PUT /words/:id/embedding": {Summary: "Store a word's embedding", Request: WordEmbeddingRequest{}, Response: map[string]interface{}{}},
+	"GET /words/:id/senses":    {Summary: "English meanings of a word", Response: wordSenses},
+	"PUT /words/:id/senses": {
+		Summary:     "Replace the English meanings of a word",
+		Description: "Senses are given most common first, and the first becomes the word's english. A register is one of formal, informal, colloquial, literary, poetic, religious, slang or archaic, or empty for a neutral sense.",
+		Request:     WordSensesRequest{},
+		Response:    wordSenses,
+	},
+	"GET /words/search": {
+		Summary:     "Search words",
+		Description: "Text searches match words whose Urdu, Urdlish, English or any English sense contains q, exact matches first. Semantic searches return the words whose embeddings are nearest q's, as computed by the configured embedding provider.",
+		Query: withFields([]openapi.Param{
+			{Name: "q", Description: "What to search for", Required: true},
+			{Name: "mode", Description: "text, the default, or semantic"},
+			{Name: "limit", Description: "Most words to return, 20 by default and at most 100", Type: "integer"},
+		}),
+		Response: wordMatches,
+	},
+	"GET /words/:id/similar": {
+		Summary:     "Words similar in meaning to a word",
+		Description: "Returns the words whose embeddings are nearest the word's, among those of the same model. A word without an embedding has one computed first.",
+		Query:       withFields([]openapi.Param{{Name: "limit", Description: "Most words to return, 20 by default and at most 100", Type: "integer"}}),
+		Response:    wordMatches,
+	},
+	"POST /words/embed": {
+		Summary:     "Queue computing word embeddings",
+		Description: "Computes the embeddings of the words whose embeddings are missing or stale from the configured provider, in throttled batches. The job's result counts the words embedded and those already current, and lists those that failed.",
+		Query:       []openapi.Param{{Name: "force", Description: "true to compute every word's"}},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"POST /words": {
+		Summary:     "Add a word",
+		Description: "Data quality problems don't fail the request but are returned as warnings.",
+		Request:     CreateWordRequest{},
+		Response: struct {
+			Word     models.Word       `json:"word"`
+			Warnings []quality.Warning `json:"warnings"`
+		}{},
+		Status: http.StatusCreated,
+	},
+
+	"GET /groups":                    {Summary: "List groups", Query: inLanguage(withFields(sorted(pageQuery, "name"))), Page: models.GroupResponse{}},
+	"GET /groups/:id":                {Summary: "Get a group", Query: withFields(nil), Response: models.GroupResponse{}},
+	"GET /groups/:id/words":          {Summary: "List a group's words", Query: withFields(sorted(pageQuery, "script")), Page: models.WordResponse{}},
+	"GET /groups/:id/study_sessions": {Summary: "List a group's study sessions", Query: withFields(cursorQuery), Page: models.StudySessionResponse{}},
+	"POST /groups/:id/words":         {Summary: "Add words to a group", Request: AddWordsRequest{}},
+
+	"GET /groups/:id/permissions": {Summary: "List the roles users have on a group", Response: []models.GroupPermission{}},
+	"PUT /groups/:id/permissions/:user_id": {
+		Summary:     "Grant a user a role on a group",
+		Description: "A role is owner, editor or viewer. Only the group's owners and admins can grant roles. Giving a group its first owner makes it private to the users with a role on it. The last owner can't step down.",
+		Request:     SetGroupPermissionRequest{},
+		Response:    models.GroupPermission{},
+	},
+	"DELETE /groups/:id/permissions/:user_id": {Summary: "Revoke a user's role on a group", Status: http.StatusNoContent},
+
+	"GET /study_sessions":     {Summary: "List study sessions", Query: withFields(cursorQuery), Page: models.StudySessionResponse{}},
+	"GET /study_sessions/:id": {Summary: "Get a study session", Query: withFields(nil), Response: models.StudySessionResponse{}},
+	"GET /study_sessions/:id/card": {
+		Summary:     "Shareable summary card of a session",
+		Description: "Responds with an SVG or PNG image rather than JSON.",
+		Query:       []openapi.Param{{Name: "format", Description: "svg or png"}},
+	},
+	"GET /study_sessions/:id/score": {Summary: "Score of a session", Response: models.SessionScore{}},
+	"GET /study_sessions/:id/words": {Summary: "List a session's words", Query: withFields(pageQuery), Page: models.WordResponse{}},
+	"POST /study_sessions/:id/words/:word_id/review": {
+		Summary: "Review a word",
+		Request: struct {
+			Correct bool `json:"correct"`
+		}{},
+		Response: models.WordReviewItem{},
+	},
+	"POST /study_sessions": {Summary: "Start a study session", Request: CreateStudySessionRequest{}, Response: models.StudySessionResponse{}, Status: http.StatusCreated},
+
+	"POST /groups/generate": {
+		Summary:     "Queue generating a group on a topic",
+		Description: "A language model writes the words; the job's result is a draft to review and approve.",
+		Request:     GenerateGroupRequest{},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"GET /group_drafts":              {Summary: "List group drafts", Response: []models.GroupDraft{}},
+	"GET /group_drafts/:id":          {Summary: "Get a group draft", Response: models.GroupDraft{}},
+	"POST /group_drafts/:id/approve": {Summary: "Create the group of a draft", Request: ApproveGroupDraftRequest{}, Response: models.GroupResponse{}, Status: http.StatusCreated},
+	"DELETE /group_drafts/:id":       {Summary: "Discard a group draft", Status: http.StatusNoContent},
+
+	"POST /words/:id/audio/generate": {
+		Summary:     "Synthesize a word's audio",
+		Description: "A word that already has audio keeps it unless force=true.",
+		Query:       []openapi.Param{{Name: "force", Description: "true to replace existing audio"}},
+		Response:    models.WordAudio{},
+	},
+	"POST /groups/:id/audio/generate": {
+		Summary:     "Queue synthesizing the audio of a group's words",
+		Description: "The job's result counts the words given audio and lists those that failed.",
+		Query:       []openapi.Param{{Name: "force", Description: "true to replace existing audio"}},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"POST /words/:id/image": {
+		Summary:     "Upload a word's picture",
+		Description: "Replaces the picture the word had. A JPEG thumbnail at most 256 pixels square is made of it.",
+		Form: []openapi.Param{
+			{Name: "file", Type: "file", Description: "PNG, JPEG or GIF picture of at most 10 MB", Required: true},
+		},
+		Response: models.WordImage{},
+	},
+	"DELETE /words/:id/image": {Summary: "Remove a word's picture", Status: http.StatusNoContent},
+	"PUT /words/:id/rendering": {
+		Summary:     "Replace the hints for rendering a word",
+		Description: "font_style is nastaleeq or naskh. The diacritized form and up to 5 ligature spellings must be the word once marks, joiners and letter variants are set aside.",
+		Request:     WordRenderingRequest{},
+		Response:    models.WordRendering{},
+	},
+	"DELETE /words/:id/rendering": {Summary: "Remove the hints for rendering a word", Status: http.StatusNoContent},
+	"POST /words/diacritized": {
+		Summary:     "Add the diacritized forms of words",
+		Description: "Sets the diacritized forms of up to 1000 words of a language, by default the request's, keeping their other rendering hints. A form without a word_id is matched to the word written the same without its marks; forms no word or several words match are returned rather than added.",
+		Request:     ImportDiacritizedRequest{},
+		Response:    models.DiacritizedImport{},
+	},
+	"POST /words/suggest": {
+		Summary:     "Suggest a word's fields from its English or Urdu",
+		Description: "Give exactly one of english or urdu. The other fields are proposed by the configured translation provider; urdlish is empty if the provider can't romanize.",
+		Request:     SuggestWordRequest{},
+		Response:    models.WordSuggestion{},
+	},
+	"POST /words/:id/enrich": {
+		Summary:     "Look a word up in the configured dictionary",
+		Description: "Stores the word's definitions, plural forms and usage examples, which GET /words/:id then returns. A word the dictionary doesn't have is given no definitions.",
+		Query:       []openapi.Param{{Name: "force", Description: "true to look up a word looked up before"}},
+		Response:    models.WordEnrichment{},
+	},
+	"POST /words/enrich": {
+		Summary:     "Queue looking up words in the configured dictionary",
+		Description: "Looks up the words not looked up before. The job's result counts the words enriched and not found and lists those that failed.",
+		Query:       []openapi.Param{{Name: "force", Description: "true to look up every word"}},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"POST /import/image": {
+		Summary:     "Read the words of a photo or scan of a word list",
+		Description: "Runs the configured OCR engine on a PNG, JPEG, GIF or WebP image or a PDF and parses its lines into candidate words, which are not stored. Confirm them with POST /import/words.",
+		Form: []openapi.Param{
+			{Name: "file", Type: "file", Description: "The image or PDF", Required: true},
+			{Name: "columns", Description: "Order of the list's columns, urdu,urdlish,english by default"},
+		},
+		Response: models.ImageImport{},
+	},
+	"POST /import/document": {
+		Summary:     "Draft a group of the unfamiliar words of a document",
+		Description: "Has the docsum service summarize a web page, text or document in English and Urdu, lists the Urdu words of the summary the user doesn't know yet, most frequent first, and drafts a group of the first of them. New words are translated by the configured translation provider. Approve the draft with POST /group_drafts/:id/approve.",
+		Form: []openapi.Param{
+			{Name: "url", Description: "A web page to summarize"},
+			{Name: "text", Description: "Text to summarize"},
+			{Name: "file", Type: "file", Description: "A document to summarize; give one of url, text or file"},
+			{Name: "name", Description: "Name of the group, after the document by default"},
+			{Name: "size", Type: "integer", Description: "Words to draft, 10 by default"},
+		},
+		Response: models.DocumentImport{},
+	},
+	"POST /import/words": {
+		Summary:     "Import confirmed words",
+		Description: "Creates the words in one transaction, optionally adding them to a group. Words that already exist aren't created again.",
+		Request:     ImportWordsRequest{},
+		Response:    models.ImportWordsResult{},
+		Status:      http.StatusCreated,
+	},
+
+	"POST /pronunciation/check": {
+		Summary:     "Check the pronunciation of a word",
+		Description: "Transcribes a recording of the word with the configured speech-to-text provider and scores how close it came to the word's Urdu. The attempt is kept in a Pronunciation session, started on group_id if session_id isn't given; a word's first attempt in a session is recorded as a review.",
+		Form: []openapi.Param{
+			{Name: "audio", Type: "file", Description: "WAV, FLAC, Ogg, WebM, MP3 or M4A recording", Required: true},
+			{Name: "word_id", Type: "integer", Description: "The word said", Required: true},
+			{Name: "session_id", Type: "integer", Description: "Pronunciation session to continue"},
+			{Name: "group_id", Type: "integer", Description: "Group to start a session on, if session_id isn't given"},
+		},
+		Response: models.PronunciationCheck{},
+	},
+
+	"GET /words/:id/conjugations": {Summary: "Forms of a verb", Response: conjugationList},
+	"PUT /words/:id/conjugations": {
+		Summary:     "Replace the forms of a verb",
+		Description: "Each form is of a tense, e.g. simple_past, and optionally a person (first_singular, first_plural, second_intimate, second_familiar, second_formal, third_singular or third_plural) and a gender (masculine or feminine). No forms removes them all.",
+		Request:     ConjugationsRequest{},
+		Response:    conjugationList,
+	},
+	"POST /conjugation-drill/start": {
+		Summary:     "Start a conjugation drill",
+		Description: "Asks for one form of each of up to word_count verbs of the group that have forms, picking the verbs the learner struggles with most.",
+		Request:     StartConjugationDrillRequest{},
+		Response:    models.ConjugationDrillSession{},
+		Status:      http.StatusCreated,
+	},
+	"GET /conjugation-drill/sessions/:session_id": {Summary: "Get a conjugation drill", Response: models.ConjugationDrillSession{}},
+	"POST /conjugation-drill/sessions/:session_id/words/:word_id/answer": {
+		Summary:     "Answer a round of a conjugation drill",
+		Description: "The answer may be written in the verb's script or transliterated, and is recorded as a review of the verb. A round is answered once.",
+		Request:     ConjugationDrillAnswer{},
+		Response:    models.ConjugationDrillRound{},
+	},
+
+	"POST /cloze/start": {
+		Summary:     "Start a cloze session",
+		Description: "Blanks each of up to word_count words of the group that occur in the sentence corpus out of one of their sentences, picking the words the learner struggles with most. Multiple choice questions offer the same form of other verbs when the word blanked is conjugated, and otherwise other words of the group and its language.",
+		Request:     StartClozeRequest{},
+		Response:    models.ClozeSession{},
+		Status:      http.StatusCreated,
+	},
+	"GET /cloze/sessions/:session_id": {Summary: "Get a cloze session", Response: models.ClozeSession{}},
+	"POST /cloze/sessions/:session_id/words/:word_id/answer": {
+		Summary:     "Answer a cloze question",
+		Description: "A multiple choice answer must be one of the options; typed answers are matched as typed quiz answers are. The answer is recorded as a review of the word, and a question is answered once.",
+		Request:     ClozeAnswer{},
+		Response:    models.ClozeQuestion{},
+	},
+
+	"POST /sentences/import": {
+		Summary:     "Import sentences",
+		Description: "Adds up to 1000 sentences of a language, by default the request's, to the corpus, and links each to the words of the language it contains, as they are written or conjugated. Sentences already in the corpus are skipped.",
+		Request:     ImportSentencesRequest{},
+		Response:    models.SentenceImport{},
+		Status:      http.StatusCreated,
+	},
+	"POST /sentences/link": {
+		Summary:     "Queue linking sentences to words again",
+		Description: "Finds the words of a language, by default the request's, in each of its sentences again, so words added since the sentences were imported are linked. The job's result counts the sentences and occurrences.",
+		Request:     LinkSentencesRequest{},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"GET /sentences/search": {
+		Summary:     "Search sentences",
+		Description: "Matches sentences containing q as a whole phrase, ignoring case, diacritics and punctuation, and then sentences whose English contains q. Shorter sentences come first.",
+		Query: inLanguage([]openapi.Param{
+			{Name: "q", Description: "What to search for", Required: true},
+			{Name: "limit", Description: "Most sentences to return, 20 by default and at most 100", Type: "integer"},
+		}),
+		Response: sentenceList,
+	},
+	"GET /sentences/:id": {Summary: "Get a sentence and where words occur in it", Response: models.Sentence{}},
+	"GET /words/:id/sentences": {
+		Summary:  "Sentences a word occurs in",
+		Query:    []openapi.Param{{Name: "limit", Description: "Most sentences to return, 20 by default and at most 100", Type: "integer"}},
+		Response: sentenceList,
+	},
+
+	"POST /passages": {
+		Summary:     "Add a reading passage",
+		Description: "Stores a text of a language, by default the request's, graded by CEFR level, A1 to C2. Passages can be at most 20000 characters long.",
+		Request:     CreatePassageRequest{},
+		Response:    models.Passage{},
+		Status:      http.StatusCreated,
+	},
+	"GET /passages": {
+		Summary:     "List reading passages",
+		Description: "Lists passages easiest first, without their texts.",
+		Query:       inLanguage([]openapi.Param{{Name: "level", Description: "Only passages of this CEFR level"}}),
+		Response:    passageList,
+	},
+	"GET /passages/:id": {
+		Summary:     "Read a passage",
+		Description: "Splits the passage into words and the text between them. Each word is linked to the words of the vocabulary it reads as, as written or conjugated, with words spanning several tokens read as one, and staged by the learner's history: unlisted if it isn't in the vocabulary, then new, learning, young or mature. Unlisted, new and learning words are marked unknown. The words linked are returned with their senses, to gloss the passage with.",
+		Response:    models.PassageReading{},
+	},
+	"DELETE /passages/:id": {Summary: "Delete a passage", Status: http.StatusNoContent},
+	"POST /passages/:id/words": {
+		Summary:     "Add a word of a passage to a group",
+		Description: "Adds the word segment at position to the group, by default the inbox of the passage's language. A word that isn't in the vocabulary is captured into the inbox first, as a draft to fill in.",
+		Request:     AddPassageWordRequest{},
+		Response:    models.AddedWord{},
+	},
+
+	"GET /grammar/topics": {
+		Summary:     "List grammar topics",
+		Description: "Lists topics in course order, by level and then position, with the learner's progress through each. Lessons are left out.",
+		Query: inLanguage([]openapi.Param{
+			{Name: "level", Description: "Only topics of this CEFR level"},
+			{Name: "status", Description: "Only topics the learner's progress through is not_started, in_progress or completed"},
+		}),
+		Response: grammarTopicList,
+	},
+	"GET /grammar/topics/:id": {
+		Summary:     "Get a grammar lesson",
+		Description: "Returns a topic's lesson, its exercises without their answers, each with the result of the learner's last answer, and the learner's progress.",
+		Response:    models.GrammarTopic{},
+	},
+	"POST /grammar/topics/:id/read": {
+		Summary:     "Mark a grammar lesson read",
+		Description: "Starts the topic, or completes it if it has no exercises.",
+		Response:    models.GrammarProgress{},
+	},
+	"POST /grammar/exercises/:id/answer": {
+		Summary:     "Answer a grammar exercise",
+		Description: "A multiple choice answer must be one of the options; typed answers are matched as typed quiz answers are. Exercises can be answered again. Answering starts the exercise's topic, which is completed once each of its exercises has been answered correctly or as a near miss.",
+		Request:     GrammarAnswer{},
+		Response:    models.GrammarAnswerResult{},
+	},
+	"POST /admin/grammar/import": {
+		Summary:     "Import grammar topics",
+		Description: "Adds up to 100 topics of a language, by default the request's, with their exercises. A topic whose slug the language already has is updated and its exercises replaced, with the answers learners gave them. Nothing is imported unless every topic is valid.",
+		Request:     ImportGrammarRequest{},
+		Response:    models.GrammarImport{},
+	},
+	"POST /admin/grammar/topics": {
+		Summary:     "Add a grammar topic",
+		Description: "Adds a topic of a language, by default the request's, with its exercises. Exercises with options are multiple choice unless answer_mode says otherwise.",
+		Request:     CreateGrammarTopicRequest{},
+		Response:    models.GrammarTopic{},
+		Status:      http.StatusCreated,
+	},
+	"GET /admin/grammar/topics/:id":    {Summary: "Get a grammar topic with its answers", Response: models.GrammarTopic{}},
+	"PUT /admin/grammar/topics/:id":    {Summary: "Change a grammar topic", Description: "Its language and exercises are kept.", Request: GrammarTopicRequest{}, Response: models.GrammarTopic{}},
+	"DELETE /admin/grammar/topics/:id": {Summary: "Delete a grammar topic", Description: "Its exercises and the learners' answers and progress go with it.", Status: http.StatusNoContent},
+	"POST /admin/grammar/topics/:id/exercises": {
+		Summary:     "Add a grammar exercise",
+		Description: "Adds the exercise after the topic's others unless it is given a position.",
+		Request:     GrammarExerciseRequest{},
+		Response:    models.GrammarExercise{},
+		Status:      http.StatusCreated,
+	},
+	"PUT /admin/grammar/exercises/:id":    {Summary: "Replace a grammar exercise", Description: "Keeps its position unless given one, and the answers learners gave.", Request: GrammarExerciseRequest{}, Response: models.GrammarExercise{}},
+	"DELETE /admin/grammar/exercises/:id": {Summary: "Delete a grammar exercise", Status: http.StatusNoContent},
+
+	"POST /group_syncs": {
+		Summary:     "Sync a group from a sheet",
+		Description: "Keeps a group in step with a CSV file or shared Google Sheets document whose header names urdu, urdlish and english columns, every interval_minutes (15 to 10080) or, if 0, only on request. The url must be an https URL of Google Sheets or of a host listed in LANG_PORTAL_SHEET_HOSTS.",
+		Request:     GroupSyncRequest{},
+		Response:    models.GroupSync{},
+		Status:      http.StatusCreated,
+	},
+	"GET /group_syncs":        {Summary: "List group syncs", Response: []models.GroupSync{}},
+	"GET /group_syncs/:id":    {Summary: "Get a group sync and the report of its last run", Response: models.GroupSync{}},
+	"PUT /group_syncs/:id":    {Summary: "Change a group sync's URL and interval", Request: GroupSyncRequest{}, Response: models.GroupSync{}},
+	"DELETE /group_syncs/:id": {Summary: "Stop syncing a group", Description: "The group keeps its words.", Status: http.StatusNoContent},
+	"POST /group_syncs/:id/preview": {
+		Summary:     "Preview a group sync",
+		Description: "Reads the sheet and reports the words that syncing would add, update and remove from the group, and the rows left out, without changing anything.",
+		Response:    models.SyncReport{},
+	},
+	"POST /group_syncs/:id/run": {
+		Summary:     "Sync a group now",
+		Description: "Words are removed from the group, not deleted. The job's result is the sync's report, which is also kept as the sync's last.",
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+
+	"GET /languages":       {Summary: "List the languages words can be studied in", Response: languageList},
+	"GET /languages/:code": {Summary: "Get a language", Response: models.Language{}},
+	"PUT /admin/languages/:code": {
+		Summary:     "Add or change a language",
+		Description: "The code is an ISO 639 code, e.g. ja, optionally with a region. Languages can't be deleted, as words and groups refer to them.",
+		Request:     SetLanguageRequest{},
+		Response:    models.Language{},
+	},
+
+	"GET /admin/dashboard": {
+		Summary:     "State of the deployment",
+		Description: "The database's size and schema version, the rows of each of its tables, the versions of the seed and content packs applied, the background jobs queued, running and failed, and the latest server errors since the server started, newest first. Only admins can see it.",
+		Response:    models.AdminDashboard{},
+	},
+	"GET /admin/embeddings": {
+		Summary:     "Count stale word embeddings",
+		Description: "Counts the words whose embeddings are current for the configured provider, missing, of another model, or computed from text the word no longer has, and those waiting to be embedded. Only admins can see it.",
+		Response:    models.EmbeddingStatus{},
+	},
+	"PUT /admin/users/:id/role": {
+		Summary:     "Change a user's role",
+		Description: "A role is learner or admin. The last admin can't be made a learner. Only admins can change roles.",
+		Request:     SetUserRoleRequest{},
+		Response:    models.UserRole{},
+	},
+
+	"GET /feature_flags":                               {Summary: "Whether each feature flag is on for the user", Response: map[string]bool{}},
+	"GET /admin/feature_flags":                         {Summary: "List feature flags", Response: []models.FeatureFlag{}},
+	"GET /admin/feature_flags/:name":                   {Summary: "Get a feature flag", Response: models.FeatureFlag{}},
+	"PUT /admin/feature_flags/:name":                   {Summary: "Create or change a feature flag", Request: SetFeatureFlagRequest{}, Response: models.FeatureFlag{}},
+	"DELETE /admin/feature_flags/:name":                {Summary: "Delete a feature flag", Status: http.StatusNoContent},
+	"PUT /admin/feature_flags/:name/users/:user_id":    {Summary: "Turn a feature flag on or off for a user", Request: SetFeatureFlagUserRequest{}, Response: models.FeatureFlag{}},
+	"DELETE /admin/feature_flags/:name/users/:user_id": {Summary: "Remove a user's override of a feature flag", Response: models.FeatureFlag{}},
+
+	"POST /me/calendar/token": {
+		Summary:     "Create a calendar feed URL",
+		Description: "Replaces the user's calendar token, so the URL given before stops working. The token is only shown here.",
+		Response:    models.CalendarToken{},
+		Status:      http.StatusCreated,
+	},
+	"DELETE /me/calendar/token": {Summary: "Stop serving the calendar feed", Status: http.StatusNoContent},
+	"GET /me/calendar.ics": {
+		Summary:     "Study calendar feed",
+		Description: "iCalendar events of the sessions studied in the last 90 days and of the days in the next 30 with words due for review, for calendar apps to subscribe to.",
+		Query:       []openapi.Param{{Name: "token", Description: "Calendar token", Required: true}},
+		Public:      true,
+	},
+
+	"GET /api_keys":        {Summary: "List the user's API keys"},
+	"POST /api_keys":       {Summary: "Create an API key", Description: "The key is only shown in this response.", Request: CreateAPIKeyRequest{}, Response: models.APIKey{}, Status: http.StatusCreated},
+	"DELETE /api_keys/:id": {Summary: "Revoke an API key", Status: http.StatusNoContent},
+	"POST /inbox/words": {
+		Summary:     "Capture a word into the user's Inbox group",
+		Description: "Authenticated by an API key in the X-API-Key header or as a bearer token, for external automations. Takes JSON or a form with text, urdu or english; missing fields are suggested by the translation provider if one is configured. Answers 201 for a new word and 200 for one that existed.",
+		Request:     InboxWordRequest{},
+		Response:    models.InboxWord{},
+		Status:      http.StatusCreated,
+		Public:      true,
+	},
+
+	"GET /sync": {
+		Summary:     "Pull changes for an offline client",
+		Description: "Words, groups, the user's study sessions and reviews changed since the sync token, and the IDs of those deleted. Without a token, or with one older than 90 days, returns everything with full set, to replace what the client has. Changes from just before the token may be returned again.",
+		Query:       []openapi.Param{{Name: "token", Description: "Sync token of the last pull"}},
+		Response:    models.Changes{},
+	},
+	"POST /sync": {
+		Summary:     "Push an offline client's sessions and reviews",
+		Description: "Sessions are identified by their client_id, so pushing one again is a duplicate. The first review of a word in a session the server gets is kept; a different one is a conflict. Reviews are scheduled in the order they were made. Each session and review is created, a duplicate, a conflict or rejected.",
+		Request:     SyncPushRequest{},
+		Response:    models.PushResult{},
+	},
+
+	"POST /reset_history": {
+		Summary:     "Reset the user's study history",
+		Description: "The database is archived to a backup first, so the reset can be restored. The request must give a confirm_token from POST /resets/confirmations for a history reset. Only SQLite databases can be reset.",
+		Request:     ResetRequest{},
+		Response:    models.Reset{},
+	},
+	"POST /full_reset": {
+		Summary:     "Delete the catalog and everyone's study history",
+		Description: "The database is archived to a backup first, so the reset can be restored. Users, classes and the resets log are kept. The request must give a confirm_token from POST /resets/confirmations for a full reset. Only SQLite databases can be reset. Only admins can reset everything.",
+		Request:     ResetRequest{},
+		Response:    models.Reset{},
+	},
+	"POST /resets/confirmations": {
+		Summary:     "Get a token to confirm a reset with",
+		Description: "The token confirms one reset of the kind, history or full, by the user, within five minutes. Only admins can confirm full resets.",
+		Request:     ResetConfirmationRequest{},
+		Response:    models.ResetConfirmation{},
+		Status:      http.StatusCreated,
+	},
+	"GET /resets": {Summary: "The user's history resets and, to admins, the full resets, newest first", Response: []models.Reset{}},
+	"POST /resets/:id/restore": {
+		Summary:     "Put back what a reset deleted",
+		Description: "A history reset, which only the user who did it can restore, replaces the user's history with the archived one. A full reset replaces the whole database with its archive, keeping the resets log. The database is backed up first. A reset is restored once. Only admins can restore full resets.",
+		Response:    models.Reset{},
+	},
+
+	"GET /jobs/:id":             {Summary: "Status of a background job", Response: models.Job{}},
+	"POST /admin/backup":        {Summary: "Queue a database backup", Response: models.Job{}, Status: http.StatusAccepted},
+	"POST /system/rollup_stats": {Summary: "Queue the stats rollup", Response: models.Job{}, Status: http.StatusAccepted},
+	"POST /system/bootstrap": {
+		Summary:     "Queue installing a starter catalog",
+		Description: "A url given must be an https URL of the configured catalog's host or of one listed in LANG_PORTAL_CATALOG_HOSTS. Only admins can bootstrap.",
+		Request:     BootstrapRequest{},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+	"POST /system/seeds/:name": {
+		Summary:     "Queue applying a seed pack",
+		Description: "With dry_run=true reports at once what the pack would change instead.",
+		Query:       []openapi.Param{{Name: "dry_run", Description: "true to only report the changes"}},
+		Response:    models.Job{},
+		Status:      http.StatusAccepted,
+	},
+}
+
+// RegisterDocsRoutes serves the OpenAPI document of the routes under
+// DocsPrefix at /openapi.json and a Swagger UI reading it at /docs. routes
+// lists the router's routes; it is called on the first request, once every
+// route is registered.
+func RegisterDocsRoutes(r gin.IRoutes, routes func() gin.RoutesInfo) {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+	r.GET("/openapi.json", func(c *gin.Context) {
+		once.Do(func() {
+			var list []openapi.Route
+			for _, route := range routes() {
+				list = append(list, openapi.Route{Method: route.Method, Path: route.Path, Handler: route.Handler})
+			}
+			doc = openapi.Build(openapi.Info{Title: "Lang Portal API", Version: "1"}, DocsPrefix, list, routeDocs)
+		})
+		c.JSON(http.StatusOK, doc)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+	})
+}
+
+// swaggerUI is the docs page. It loads Swagger UI from a CDN and the
+// document from openapi.json next to it.
+var swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Lang Portal API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEnrichmentRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/words/enrich", h.EnrichWords)
+	r.POST("/words/:id/enrich", h.EnrichWord)
+}
+
+// EnrichWord looks a word up in the configured dictionary. With force=true
+// a word looked up before is looked up again.
+func (h *Handler) EnrichWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	enrichment, err := h.svcFor(c).EnrichWord(c.Request.Context(), id, c.Query("force") == "true")
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrichment)
+}
+
+// EnrichWords queues looking up the words not yet looked up in the
+// configured dictionary, or every word with force=true
+func (h *Handler) EnrichWords(c *gin.Context) {
+	job, err := h.svcFor(c).EnqueueWordEnrichment(c.Request.Context(), c.Query("force") == "true")
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetFeatureFlagRequest represents the request body for creating or changing
+// a feature flag
+type SetFeatureFlagRequest struct {
+	Description string `json:"description"`
+	Enabled     *bool  `json:"enabled" binding:"required"`
+}
+
+// SetFeatureFlagUserRequest represents the request body for turning a
+// feature flag on or off for a user
+type SetFeatureFlagUserRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+func RegisterFeatureFlagRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/feature_flags", h.GetFeatureFlags)
+}
+
+// RegisterFeatureFlagAdminRoutes registers the routes managing feature
+// flags. r must require the admin role.
+func RegisterFeatureFlagAdminRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	flags := r.Group("/admin/feature_flags")
+	{
+		flags.GET("", h.ListFeatureFlags)
+		flags.GET("/:name", h.GetFeatureFlag)
+		flags.PUT("/:name", h.SetFeatureFlag)
+		flags.DELETE("/:name", h.DeleteFeatureFlag)
+		flags.PUT("/:name/users/:user_id", h.SetFeatureFlagUser)
+		flags.DELETE("/:name/users/:user_id", h.DeleteFeatureFlagUser)
+	}
+}
+
+// GetFeatureFlags returns whether each feature flag is on for the user, so
+// clients can show the experimental features they have
+func (h *Handler) GetFeatureFlags(c *gin.Context) {
+	flags, err := h.svcFor(c).FeatureFlags(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// ListFeatureFlags returns every feature flag with its user overrides
+func (h *Handler) ListFeatureFlags(c *gin.Context) {
+	flags, err := h.svc.ListFeatureFlags(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// GetFeatureFlag returns a feature flag with its user overrides
+func (h *Handler) GetFeatureFlag(c *gin.Context) {
+	flag, err := h.svc.GetFeatureFlag(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// SetFeatureFlag creates a feature flag or changes whether it is on for the
+// deployment
+func (h *Handler) SetFeatureFlag(c *gin.Context) {
+	var req SetFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flag, err := h.svc.SetFeatureFlag(c.Request.Context(), c.Param("name"), req.Description, *req.Enabled)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFeatureFlag removes a feature flag, turning it off for everyone
+func (h *Handler) DeleteFeatureFlag(c *gin.Context) {
+	if err := h.svc.DeleteFeatureFlag(c.Request.Context(), c.Param("name")); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetFeatureFlagUser turns a feature flag on or off for a user
+func (h *Handler) SetFeatureFlagUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req SetFeatureFlagUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flag, err := h.svc.SetFeatureFlagUser(c.Request.Context(), c.Param("name"), userID, *req.Enabled)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFeatureFlagUser removes a user's override of a feature flag
+func (h *Handler) DeleteFeatureFlagUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	flag, err := h.svc.DeleteFeatureFlagUser(c.Request.Context(), c.Param("name"), userID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFlashcardDeckSize is used when no deck size is given
+const defaultFlashcardDeckSize = 20
+
+// CreateFlashcardDeckRequest represents the request body for building a deck
+type CreateFlashcardDeckRequest struct {
+	GroupID int64 `json:"group_id" binding:"required"`
+	Limit   int   `json:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// RateFlashcardRequest represents a learner's rating of a card
+type RateFlashcardRequest struct {
+	Rating string `json:"rating" binding:"required,oneof=again hard good easy"`
+}
+
+func RegisterFlashcardRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	flashcards := r.Group("/flashcards")
+	{
+		flashcards.POST("/decks", h.CreateFlashcardDeck)
+		flashcards.GET("/decks/:session_id", h.GetFlashcardDeck)
+		flashcards.POST("/decks/:session_id/cards/:word_id/flip", h.FlipFlashcard)
+		flashcards.POST("/decks/:session_id/cards/:word_id/rate", h.RateFlashcard)
+	}
+}
+
+// CreateFlashcardDeck builds a deck from the due and new words of a group
+func (h *Handler) CreateFlashcardDeck(c *gin.Context) {
+	var req CreateFlashcardDeckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultFlashcardDeckSize
+	}
+
+	deck, err := h.svcFor(c).CreateFlashcardDeck(c.Request.Context(), req.GroupID, req.Limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, deck)
+}
+
+// GetFlashcardDeck returns a deck with the flips and rating of each card
+func (h *Handler) GetFlashcardDeck(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	deck, err := h.svcFor(c).GetFlashcardDeck(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deck)
+}
+
+// FlipFlashcard records that a card was turned over
+func (h *Handler) FlipFlashcard(c *gin.Context) {
+	sessionID, wordID, ok := flashcardParams(c)
+	if !ok {
+		return
+	}
+
+	flips, err := h.svcFor(c).FlipFlashcard(c.Request.Context(), sessionID, wordID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"word_id":    wordID,
+		"flips":      flips,
+	})
+}
+
+// RateFlashcard records how well the learner knew a card and reschedules
+// the word
+func (h *Handler) RateFlashcard(c *gin.Context) {
+	sessionID, wordID, ok := flashcardParams(c)
+	if !ok {
+		return
+	}
+
+	var req RateFlashcardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviewItem, err := h.svcFor(c).RateFlashcard(c.Request.Context(), sessionID, wordID, req.Rating)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	state, err := h.svcFor(c).GetWordLearningState(c.Request.Context(), wordID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"word_id":        reviewItem.WordID,
+		"session_id":     reviewItem.StudySessionID,
+		"rating":         req.Rating,
+		"correct":        reviewItem.Correct,
+		"learning_state": state,
+	})
+}
+
+// flashcardParams reads the session and word IDs of a card from the path,
+// answering with 400 if either is invalid
+func flashcardParams(c *gin.Context) (int64, int64, bool) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return 0, 0, false
+	}
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return 0, 0, false
+	}
+	return sessionID, wordID, true
+}
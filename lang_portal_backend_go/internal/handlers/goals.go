@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGoalRequest represents the request body for creating a goal
+type CreateGoalRequest struct {
+	Kind   string  `json:"kind" binding:"required,oneof=daily_reviews weekly_new_words target_accuracy"`
+	Target float64 `json:"target" binding:"required"`
+}
+
+// UpdateGoalRequest represents the request body for changing a goal's target
+type UpdateGoalRequest struct {
+	Target float64 `json:"target" binding:"required"`
+}
+
+func RegisterGoalRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	goals := r.Group("/goals")
+	{
+		goals.GET("", h.ListGoals)
+		goals.POST("", h.CreateGoal)
+		goals.GET("/:id", h.GetGoal)
+		goals.PUT("/:id", h.UpdateGoal)
+		goals.DELETE("/:id", h.DeleteGoal)
+	}
+}
+
+// ListGoals returns every goal
+func (h *Handler) ListGoals(c *gin.Context) {
+	goals, err := h.svcFor(c).GetGoals(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goals)
+}
+
+// CreateGoal adds a goal
+func (h *Handler) CreateGoal(c *gin.Context) {
+	var req CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := h.svcFor(c).CreateGoal(c.Request.Context(), req.Kind, req.Target)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, goal)
+}
+
+// GetGoal returns a goal
+func (h *Handler) GetGoal(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	goal, err := h.svcFor(c).GetGoal(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// UpdateGoal changes a goal's target
+func (h *Handler) UpdateGoal(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := h.svcFor(c).UpdateGoal(c.Request.Context(), id, req.Target)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// DeleteGoal removes a goal
+func (h *Handler) DeleteGoal(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGoal(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
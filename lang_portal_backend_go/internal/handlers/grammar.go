@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GrammarExerciseRequest represents an exercise of a grammar topic
+type GrammarExerciseRequest struct {
+	// AnswerMode is multiple_choice or typed, by default multiple_choice if
+	// there are options
+	AnswerMode string   `json:"answer_mode"`
+	Prompt     string   `json:"prompt" binding:"required"`
+	English    string   `json:"english"`
+	Options    []string `json:"options"`
+	Answer     string   `json:"answer" binding:"required"`
+	// Explanation is shown once the exercise is answered
+	Explanation string `json:"explanation"`
+	// Position orders the exercises of a topic, by default after the others
+	Position int `json:"position" binding:"omitempty,min=0"`
+}
+
+// GrammarTopicRequest represents the request body for changing a grammar
+// topic
+type GrammarTopicRequest struct {
+	Slug  string `json:"slug" binding:"required"`
+	Title string `json:"title" binding:"required"`
+	// Level is the CEFR level of the topic, A1 to C2
+	Level   string `json:"level" binding:"required"`
+	Summary string `json:"summary"`
+	// Lesson is the lesson's text in Markdown
+	Lesson   string `json:"lesson"`
+	Position int    `json:"position" binding:"omitempty,min=0"`
+}
+
+// CreateGrammarTopicRequest represents the request body for adding a
+// grammar topic with its exercises
+type CreateGrammarTopicRequest struct {
+	// Language is the topic's language, by default the request's
+	Language string `json:"language"`
+	GrammarTopicRequest
+	Exercises []GrammarExerciseRequest `json:"exercises" binding:"dive"`
+}
+
+// ImportGrammarRequest represents the request body for importing grammar
+// topics
+type ImportGrammarRequest struct {
+	// Language is the topics' language, by default the request's
+	Language string                      `json:"language"`
+	Topics   []CreateGrammarTopicRequest `json:"topics" binding:"required,dive"`
+}
+
+// GrammarAnswer represents the answer to a grammar exercise
+type GrammarAnswer struct {
+	Answer string `json:"answer" binding:"required"`
+}
+
+func RegisterGrammarRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	grammar := r.Group("/grammar")
+	{
+		grammar.GET("/topics", h.ListGrammarTopics)
+		grammar.GET("/topics/:id", h.GetGrammarLesson)
+		grammar.POST("/topics/:id/read", h.ReadGrammarLesson)
+		grammar.POST("/exercises/:id/answer", h.AnswerGrammarExercise)
+	}
+}
+
+// RegisterGrammarAdminRoutes registers the routes writing grammar topics.
+// r must require the admin role.
+func RegisterGrammarAdminRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	admin := r.Group("/admin/grammar")
+	{
+		admin.POST("/import", h.ImportGrammarTopics)
+		admin.POST("/topics", h.CreateGrammarTopic)
+		admin.GET("/topics/:id", h.GetGrammarTopic)
+		admin.PUT("/topics/:id", h.UpdateGrammarTopic)
+		admin.DELETE("/topics/:id", h.DeleteGrammarTopic)
+		admin.POST("/topics/:id/exercises", h.AddGrammarExercise)
+		admin.PUT("/exercises/:id", h.UpdateGrammarExercise)
+		admin.DELETE("/exercises/:id", h.DeleteGrammarExercise)
+	}
+}
+
+// grammarExercise converts an exercise of a request
+func grammarExercise(req GrammarExerciseRequest) models.GrammarExercise {
+	return models.GrammarExercise{
+		AnswerMode:  req.AnswerMode,
+		Prompt:      req.Prompt,
+		English:     req.English,
+		Options:     req.Options,
+		Answer:      req.Answer,
+		Explanation: req.Explanation,
+		Position:    req.Position,
+	}
+}
+
+// grammarTopic converts a topic of a request, with its exercises
+func grammarTopic(req CreateGrammarTopicRequest) models.GrammarTopic {
+	topic := models.GrammarTopic{
+		Language: req.Language,
+		Slug:     req.Slug,
+		Title:    req.Title,
+		Level:    req.Level,
+		Summary:  req.Summary,
+		Lesson:   req.Lesson,
+		Position: req.Position,
+	}
+	for _, exercise := range req.Exercises {
+		topic.Exercises = append(topic.Exercises, grammarExercise(exercise))
+	}
+	return topic
+}
+
+// ListGrammarTopics returns the grammar topics in course order with the
+// learner's progress, optionally only those of a level or status
+func (h *Handler) ListGrammarTopics(c *gin.Context) {
+	topics, err := h.svcFor(c).ListGrammarTopics(c.Request.Context(), c.Query("level"), c.Query("status"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": topics, "count": len(topics)})
+}
+
+// GetGrammarLesson returns a grammar topic's lesson and exercises, without
+// their answers
+func (h *Handler) GetGrammarLesson(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	topic, err := h.svcFor(c).GetGrammarLesson(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, topic)
+}
+
+// ReadGrammarLesson records that the learner read a grammar topic's lesson
+// and returns their progress
+func (h *Handler) ReadGrammarLesson(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	progress, err := h.svcFor(c).ReadGrammarLesson(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// AnswerGrammarExercise checks the answer to a grammar exercise and returns
+// the correct answer with the learner's progress
+func (h *Handler) AnswerGrammarExercise(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req GrammarAnswer
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svcFor(c).AnswerGrammarExercise(c.Request.Context(), id, req.Answer)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportGrammarTopics adds grammar topics with their exercises, updating
+// the topics already added
+func (h *Handler) ImportGrammarTopics(c *gin.Context) {
+	var req ImportGrammarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topics := make([]models.GrammarTopic, len(req.Topics))
+	for i, topic := range req.Topics {
+		topics[i] = grammarTopic(topic)
+	}
+	result, err := h.svcFor(c).ImportGrammarTopics(c.Request.Context(), req.Language, topics)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateGrammarTopic adds a grammar topic with its exercises
+func (h *Handler) CreateGrammarTopic(c *gin.Context) {
+	var req CreateGrammarTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topic, err := h.svcFor(c).CreateGrammarTopic(c.Request.Context(), grammarTopic(req))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, topic)
+}
+
+// GetGrammarTopic returns a grammar topic with its exercises' answers
+func (h *Handler) GetGrammarTopic(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	topic, err := h.svcFor(c).GetGrammarTopic(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, topic)
+}
+
+// UpdateGrammarTopic changes a grammar topic, keeping its exercises
+func (h *Handler) UpdateGrammarTopic(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req GrammarTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topic, err := h.svcFor(c).UpdateGrammarTopic(c.Request.Context(), id, grammarTopic(CreateGrammarTopicRequest{GrammarTopicRequest: req}))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, topic)
+}
+
+// DeleteGrammarTopic deletes a grammar topic with its exercises
+func (h *Handler) DeleteGrammarTopic(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGrammarTopic(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddGrammarExercise adds an exercise to a grammar topic
+func (h *Handler) AddGrammarExercise(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req GrammarExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exercise, err := h.svcFor(c).AddGrammarExercise(c.Request.Context(), id, grammarExercise(req))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, exercise)
+}
+
+// UpdateGrammarExercise replaces a grammar exercise
+func (h *Handler) UpdateGrammarExercise(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req GrammarExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exercise, err := h.svcFor(c).UpdateGrammarExercise(c.Request.Context(), id, grammarExercise(req))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, exercise)
+}
+
+// DeleteGrammarExercise deletes a grammar exercise
+func (h *Handler) DeleteGrammarExercise(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGrammarExercise(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateGroupRequest represents the request body for generating a group
+type GenerateGroupRequest struct {
+	Topic string `json:"topic" binding:"required"`
+	// Name defaults to the topic
+	Name string `json:"name"`
+	// Size defaults to service.DefaultGroupSize
+	Size int `json:"size"`
+}
+
+// ApproveGroupDraftRequest optionally corrects a draft before its group is
+// created
+type ApproveGroupDraftRequest struct {
+	Name  string             `json:"name"`
+	Words []models.DraftWord `json:"words"`
+}
+
+func RegisterGroupDraftRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/groups/generate", h.GenerateGroup)
+
+	drafts := r.Group("/group_drafts")
+	{
+		drafts.GET("", h.ListGroupDrafts)
+		drafts.GET("/:id", h.GetGroupDraft)
+		drafts.POST("/:id/approve", h.ApproveGroupDraft)
+		drafts.DELETE("/:id", h.DeleteGroupDraft)
+	}
+}
+
+// GenerateGroup queues asking the language model for the words of a group
+// on a topic. The job's result is a draft to review and approve.
+func (h *Handler) GenerateGroup(c *gin.Context) {
+	var req GenerateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.svcFor(c).EnqueueGroupGeneration(c.Request.Context(), req.Topic, req.Name, req.Size)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// ListGroupDrafts returns the user's group drafts
+func (h *Handler) ListGroupDrafts(c *gin.Context) {
+	drafts, err := h.svcFor(c).ListGroupDrafts(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, drafts)
+}
+
+// GetGroupDraft returns a group draft
+func (h *Handler) GetGroupDraft(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	draft, err := h.svcFor(c).GetGroupDraft(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// ApproveGroupDraft creates the group of a draft
+func (h *Handler) ApproveGroupDraft(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req ApproveGroupDraftRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	group, err := h.svcFor(c).ApproveGroupDraft(c.Request.Context(), id, req.Name, req.Words)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, group)
+}
+
+// DeleteGroupDraft discards a group draft
+func (h *Handler) DeleteGroupDraft(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGroupDraft(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetGroupPermissionRequest represents the request body for granting a
+// user a role on a group
+type SetGroupPermissionRequest struct {
+	// Role is owner, editor or viewer
+	Role string `json:"role" binding:"required"`
+}
+
+func RegisterGroupPermissionRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/groups/:id/permissions", h.ListGroupPermissions)
+	r.PUT("/groups/:id/permissions/:user_id", h.SetGroupPermission)
+	r.DELETE("/groups/:id/permissions/:user_id", h.DeleteGroupPermission)
+}
+
+// groupPermissionIDs parses the group and user IDs of a permission's path
+func groupPermissionIDs(c *gin.Context) (groupID, userID int64, ok bool) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, 0, false
+	}
+	userID, err = strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return groupID, userID, true
+}
+
+// ListGroupPermissions returns the roles users have on a group
+func (h *Handler) ListGroupPermissions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	permissions, err := h.svcFor(c).ListGroupPermissions(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+// SetGroupPermission grants a user a role on a group
+func (h *Handler) SetGroupPermission(c *gin.Context) {
+	groupID, userID, ok := groupPermissionIDs(c)
+	if !ok {
+		return
+	}
+
+	var req SetGroupPermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	permission, err := h.svcFor(c).SetGroupPermission(c.Request.Context(), groupID, userID, req.Role)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, permission)
+}
+
+// DeleteGroupPermission revokes a user's role on a group
+func (h *Handler) DeleteGroupPermission(c *gin.Context) {
+	groupID, userID, ok := groupPermissionIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGroupPermission(c.Request.Context(), groupID, userID); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
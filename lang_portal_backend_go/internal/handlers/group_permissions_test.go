@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"net/http"
+	"testing"
+)
+
+// groupRoles makes group 2 private to user 2, who owns it, and user 3, who
+// edits it. User 4 has no role and user 1 is the admin. Word 4 is only in
+// group 2; words 1 and 2 are also in group 1, which nobody owns.
+const groupRoles = `
+	INSERT INTO users (id, username) VALUES (2, 'owner'), (3, 'editor'), (4, 'stranger');
+	INSERT INTO group_permissions (group_id, user_id, role) VALUES (2, 2, 'owner'), (2, 3, 'editor');
+	INSERT INTO words (id, script, transliteration, english) VALUES (4, 'پانی', 'paani', 'water');
+	INSERT INTO words_groups (word_id, group_id) VALUES (4, 2);
+`
+
+func TestGroupPermissions(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int64
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "owner lists roles", userID: 2, method: http.MethodGet, path: "/groups/2/permissions", wantStatus: http.StatusOK},
+		{name: "stranger lists roles of a private group", userID: 4, method: http.MethodGet, path: "/groups/2/permissions", wantStatus: http.StatusNotFound},
+		{name: "owner grants", userID: 2, method: http.MethodPut, path: "/groups/2/permissions/4", body: `{"role": "viewer"}`, wantStatus: http.StatusOK},
+		{name: "editor grants", userID: 3, method: http.MethodPut, path: "/groups/2/permissions/4", body: `{"role": "viewer"}`, wantStatus: http.StatusForbidden},
+		{name: "stranger grants", userID: 4, method: http.MethodPut, path: "/groups/2/permissions/4", body: `{"role": "owner"}`, wantStatus: http.StatusNotFound},
+		{name: "learner grants on a group nobody owns", userID: 2, method: http.MethodPut, path: "/groups/1/permissions/2", body: `{"role": "owner"}`, wantStatus: http.StatusForbidden},
+		{name: "admin grants on a group nobody owns", userID: 1, method: http.MethodPut, path: "/groups/1/permissions/2", body: `{"role": "owner"}`, wantStatus: http.StatusOK},
+		{name: "unknown role", userID: 2, method: http.MethodPut, path: "/groups/2/permissions/4", body: `{"role": "admin"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown user", userID: 2, method: http.MethodPut, path: "/groups/2/permissions/99", body: `{"role": "viewer"}`, wantStatus: http.StatusNotFound},
+		{name: "invalid user id", userID: 2, method: http.MethodPut, path: "/groups/2/permissions/four", body: `{"role": "viewer"}`, wantStatus: http.StatusBadRequest},
+		{name: "last owner steps down", userID: 2, method: http.MethodPut, path: "/groups/2/permissions/2", body: `{"role": "editor"}`, wantStatus: http.StatusConflict},
+		{name: "owner revokes", userID: 2, method: http.MethodDelete, path: "/groups/2/permissions/3", wantStatus: http.StatusNoContent},
+		{name: "last owner leaves", userID: 2, method: http.MethodDelete, path: "/groups/2/permissions/2", wantStatus: http.StatusConflict},
+		{name: "revoking a role nobody has", userID: 2, method: http.MethodDelete, path: "/groups/2/permissions/4", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, repository.Repositories{}, groupRoles)
+
+			w := serve(RegisterGroupPermissionRoutes, svc.ForUser(tt.userID), tt.method, tt.path, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListGroupPermissions(t *testing.T) {
+	svc := newTestService(t, repository.Repositories{}, groupRoles)
+
+	w := serve(RegisterGroupPermissionRoutes, svc.ForUser(3), http.MethodGet, "/groups/2/permissions", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var permissions []models.GroupPermission
+	decode(t, w, &permissions)
+	if len(permissions) != 2 {
+		t.Fatalf("permissions = %+v, want the owner and the editor", permissions)
+	}
+	if p := permissions[0]; p.UserID != 2 || p.Username != "owner" || p.Role != "owner" {
+		t.Errorf("first permission = %+v, want user 2's ownership", p)
+	}
+	if p := permissions[1]; p.UserID != 3 || p.Role != "editor" {
+		t.Errorf("second permission = %+v, want user 3's editing", p)
+	}
+}
+
+func TestAddWordsToGroupRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int64
+		path       string
+		wantStatus int
+	}{
+		{name: "editor", userID: 3, path: "/groups/2/words", wantStatus: http.StatusOK},
+		{name: "owner", userID: 2, path: "/groups/2/words", wantStatus: http.StatusOK},
+		{name: "stranger to a private group", userID: 4, path: "/groups/2/words", wantStatus: http.StatusNotFound},
+		{name: "learner to a group nobody owns", userID: 2, path: "/groups/1/words", wantStatus: http.StatusForbidden},
+		{name: "admin to a group nobody owns", userID: 1, path: "/groups/1/words", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := &mocks.GroupRepositoryMock{
+				AddWordsFunc: func(ctx context.Context, q repository.Querier, groupID int64, wordIDs []int64) error {
+					return nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Groups: groups}, groupRoles)
+
+			w := serve(RegisterGroupsRoutes, svc.ForUser(tt.userID), http.MethodPost, tt.path, `{"word_ids": [3]}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			wantAdded := tt.wantStatus == http.StatusOK
+			if calls := groups.AddWordsCalls(); (len(calls) == 1) != wantAdded {
+				t.Errorf("AddWords called %d times, want added = %v", len(calls), wantAdded)
+			}
+		})
+	}
+}
+
+func TestSetWordSensesRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int64
+		path       string
+		wantStatus int
+	}{
+		{name: "editor of the word's only group", userID: 3, path: "/words/4/senses", wantStatus: http.StatusOK},
+		{name: "owner of the word's only group", userID: 2, path: "/words/4/senses", wantStatus: http.StatusOK},
+		{name: "stranger", userID: 4, path: "/words/4/senses", wantStatus: http.StatusForbidden},
+		{name: "word also in a group nobody owns", userID: 2, path: "/words/1/senses", wantStatus: http.StatusForbidden},
+		{name: "admin", userID: 1, path: "/words/1/senses", wantStatus: http.StatusOK},
+		{name: "missing word", userID: 2, path: "/words/99/senses", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, repository.Repositories{}, groupRoles)
+
+			w := serve(RegisterWordsRoutes, svc.ForUser(tt.userID), http.MethodPut, tt.path, `{"senses": [{"english": "water"}]}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
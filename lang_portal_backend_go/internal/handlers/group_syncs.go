@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupSyncRequest represents the request body for creating or updating a
+// group sync
+type GroupSyncRequest struct {
+	GroupID int64 `json:"group_id"`
+	// URL of a CSV file or a shared Google Sheets document
+	URL string `json:"url" binding:"required"`
+	// IntervalMinutes between scheduled syncs; 0 syncs only on request
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+func RegisterGroupSyncRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	syncs := r.Group("/group_syncs")
+	{
+		syncs.POST("", h.CreateGroupSync)
+		syncs.GET("", h.ListGroupSyncs)
+		syncs.GET("/:id", h.GetGroupSync)
+		syncs.PUT("/:id", h.UpdateGroupSync)
+		syncs.DELETE("/:id", h.DeleteGroupSync)
+		syncs.POST("/:id/preview", h.PreviewGroupSync)
+		syncs.POST("/:id/run", h.RunGroupSync)
+	}
+}
+
+// CreateGroupSync starts keeping a group in step with a sheet
+func (h *Handler) CreateGroupSync(c *gin.Context) {
+	var req GroupSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.GroupID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_id is required"})
+		return
+	}
+
+	sync, err := h.svcFor(c).CreateGroupSync(c.Request.Context(), req.GroupID, req.URL, req.IntervalMinutes)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sync)
+}
+
+// ListGroupSyncs returns the user's group syncs
+func (h *Handler) ListGroupSyncs(c *gin.Context) {
+	syncs, err := h.svcFor(c).ListGroupSyncs(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, syncs)
+}
+
+// GetGroupSync returns a group sync and the report of its last run
+func (h *Handler) GetGroupSync(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	sync, err := h.svcFor(c).GetGroupSync(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sync)
+}
+
+// UpdateGroupSync changes a group sync's URL and interval
+func (h *Handler) UpdateGroupSync(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req GroupSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sync, err := h.svcFor(c).UpdateGroupSync(c.Request.Context(), id, req.URL, req.IntervalMinutes)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sync)
+}
+
+// DeleteGroupSync stops syncing a group
+func (h *Handler) DeleteGroupSync(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteGroupSync(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewGroupSync reports what syncing a group now would change, without
+// changing it
+func (h *Handler) PreviewGroupSync(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	report, err := h.svcFor(c).RunGroupSync(c.Request.Context(), id, true)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RunGroupSync queues syncing a group now. The job's result is the sync's
+// report.
+func (h *Handler) RunGroupSync(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	job, err := h.svcFor(c).EnqueueGroupSync(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
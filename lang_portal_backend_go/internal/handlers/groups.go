@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterGroupsRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	groups := r.Group("/groups")
+	groups.Use(middleware.ETag(), middleware.Fields())
+	{
+		groups.GET("", h.ListGroups)
+		groups.GET("/:id", h.GetGroup)
+		groups.GET("/:id/words", h.GetGroupWords)
+		groups.GET("/:id/study_sessions", h.GetGroupStudySessions)
+		groups.POST("/:id/words", h.AddWordsToGroup)
+	}
+}
+
+func (h *Handler) ListGroups(c *gin.Context) {
+	page := c.DefaultQuery("page", "1")
+	pageNum, _ := strconv.Atoi(page)
+
+	groups, err := h.svcFor(c).ListGroups(c.Request.Context(), pageNum, c.Query("sort"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *Handler) GetGroup(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	group, err := h.svcFor(c).GetGroup(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *Handler) GetGroupWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageNum, _ := strconv.Atoi(page)
+
+	words, err := h.svcFor(c).GetGroupWords(c.Request.Context(), id, pageNum, c.Query("sort"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, words)
+}
+
+func (h *Handler) GetGroupStudySessions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		sessions, err := h.svcFor(c).ListStudySessionsByCursor(c.Request.Context(), id, cursor)
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageNum, _ := strconv.Atoi(page)
+
+	sessions, err := h.svcFor(c).GetGroupStudySessions(c.Request.Context(), id, pageNum)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// AddWordsRequest represents the request body for adding words to a group
+type AddWordsRequest struct {
+	WordIDs []int64 `json:"word_ids" binding:"required"`
+}
+
+func (h *Handler) AddWordsToGroup(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+
+	var req AddWordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err = h.svcFor(c).AddWordsToGroup(c.Request.Context(), id, req.WordIDs)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
\ No newline at end of file
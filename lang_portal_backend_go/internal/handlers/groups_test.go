@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"net/http"
+	"testing"
+)
+
+func TestListGroups(t *testing.T) {
+	groups := &mocks.GroupRepositoryMock{
+		ListFunc: func(ctx context.Context, viewerID int64, language, sort string, limit, offset int) ([]models.GroupResponse, error) {
+			return []models.GroupResponse{{ID: 1, Name: "Greetings", WordCount: 3}}, nil
+		},
+		CountFunc: func(ctx context.Context, viewerID int64, language string) (int, error) {
+			return 1, nil
+		},
+	}
+	svc := newTestService(t, repository.Repositories{Groups: groups})
+
+	w := serve(RegisterGroupsRoutes, svc, http.MethodGet, "/groups", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Items      []models.GroupResponse `json:"items"`
+		Pagination models.Pagination      `json:"pagination"`
+	}
+	decode(t, w, &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "Greetings" || page.Items[0].WordCount != 3 {
+		t.Errorf("items = %+v, want the listed group", page.Items)
+	}
+	if page.Pagination.TotalItems != 1 || page.Pagination.CurrentPage != 1 {
+		t.Errorf("pagination = %+v, want 1 item on page 1", page.Pagination)
+	}
+	// The default user is an admin, who sees every group
+	if calls := groups.ListCalls(); len(calls) != 1 || calls[0].Sort != repository.SortID || calls[0].ViewerID != 0 {
+		t.Errorf("List called %+v, want once sorted by id for every group", calls)
+	}
+}
+
+func TestGetGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		getErr     error
+		wantStatus int
+	}{
+		{name: "found", path: "/groups/2", wantStatus: http.StatusOK},
+		{name: "not found", path: "/groups/2", getErr: sql.ErrNoRows, wantStatus: http.StatusNotFound},
+		{name: "repository error", path: "/groups/2", getErr: errors.New("disk I/O error"), wantStatus: http.StatusInternalServerError},
+		{name: "invalid id", path: "/groups/two", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := &mocks.GroupRepositoryMock{
+				GetFunc: func(ctx context.Context, id int64) (*models.GroupResponse, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					return &models.GroupResponse{ID: id, Name: "Basics"}, nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Groups: groups})
+
+			w := serve(RegisterGroupsRoutes, svc, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var group models.GroupResponse
+			decode(t, w, &group)
+			if group.ID != 2 || group.Name != "Basics" {
+				t.Errorf("group = %+v, want group 2", group)
+			}
+		})
+	}
+}
+
+func TestGetGroupWords(t *testing.T) {
+	groups := &mocks.GroupRepositoryMock{
+		ListWordsFunc: func(ctx context.Context, userID, groupID int64, sort string, limit, offset int) ([]models.WordResponse, error) {
+			return []models.WordResponse{
+				{ID: 1, English: "hello", CorrectCount: 2},
+				{ID: 2, English: "goodbye", WrongCount: 1},
+			}, nil
+		},
+		CountWordsFunc: func(ctx context.Context, groupID int64) (int, error) {
+			return 2, nil
+		},
+	}
+	svc := newTestService(t, repository.Repositories{Groups: groups})
+
+	w := serve(RegisterGroupsRoutes, svc, http.MethodGet, "/groups/1/words?page=3", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Items []models.WordResponse `json:"items"`
+	}
+	decode(t, w, &page)
+	if len(page.Items) != 2 || page.Items[0].CorrectCount != 2 || page.Items[1].WrongCount != 1 {
+		t.Errorf("items = %+v, want the group's words with their counts", page.Items)
+	}
+
+	calls := groups.ListWordsCalls()
+	if len(calls) != 1 {
+		t.Fatalf("ListWords called %d times, want 1", len(calls))
+	}
+	if calls[0].GroupID != 1 || calls[0].UserID != 1 || calls[0].Offset != 200 {
+		t.Errorf("ListWords(userID %d, groupID %d, offset %d), want (1, 1, 200)",
+			calls[0].UserID, calls[0].GroupID, calls[0].Offset)
+	}
+}
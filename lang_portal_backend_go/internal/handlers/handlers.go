@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc *service.Service
+}
+
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// svcFor returns the service scoped to the user making the request and to
+// the language it selects. Requests without an identified user are served
+// as the default user, and those without a language cover every language.
+func (h *Handler) svcFor(c *gin.Context) *service.Service {
+	svc := h.svc
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		svc = svc.ForUser(userID)
+	}
+	if language := middleware.CurrentLanguage(c); language != "" {
+		svc = svc.ForLanguage(language)
+	}
+	return svc
+}
+
+// forRequest returns a handler whose helpers act as the user making the
+// request
+func (h *Handler) forRequest(c *gin.Context) *Handler {
+	return &Handler{svc: h.svcFor(c)}
+}
+
+// serviceError responds with the status matching the kind of a service
+// error, or 500 for unexpected errors
+func serviceError(c *gin.Context, err error) {
+	c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
+
+// errorStatus returns the status code of a service error's kind
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrValidation), errors.Is(err, service.ErrUnsupported):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListWords lists words by page number, or by cursor when the cursor
+// parameter is given, even empty for the first page. The sort parameter
+// sorts pages by script in Urdu alphabetical order when it is urdu.
+func (h *Handler) ListWords(c *gin.Context) {
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		response, err := h.svcFor(c).ListWordsByCursor(c.Request.Context(), cursor, c.Query("sort"))
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageNum, err := strconv.Atoi(page)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page number"})
+		return
+	}
+
+	response, err := h.svcFor(c).ListWords(c.Request.Context(), pageNum, c.Query("sort"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+} 
\ No newline at end of file
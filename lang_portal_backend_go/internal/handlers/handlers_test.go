@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"lang_portal/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestService creates a service reading words, groups, study sessions
+// and reviews through the given mocks, over an in-memory database for
+// everything else, set up by the given statements. Repositories left nil
+// are mocks that panic when called.
+func newTestService(t *testing.T, repos repository.Repositories, setup ...string) *service.Service {
+	t.Helper()
+	db, err := models.NewTestDB()
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	for _, query := range setup {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("failed to set up database: %v", err)
+		}
+	}
+	if repos.Words == nil {
+		repos.Words = &mocks.WordRepositoryMock{}
+	}
+	if repos.Groups == nil {
+		repos.Groups = &mocks.GroupRepositoryMock{}
+	}
+	if repos.Sessions == nil {
+		repos.Sessions = &mocks.SessionRepositoryMock{}
+	}
+	if repos.Reviews == nil {
+		repos.Reviews = &mocks.ReviewRepositoryMock{}
+	}
+	svc := service.NewServiceWithRepositories(db.DB, repos)
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+// serve sends a request to the routes register adds for svc and returns
+// the response
+func serve(register func(*gin.RouterGroup, *service.Service), svc *service.Service, method, path, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	register(r.Group("/api/v1"), svc)
+	req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals a response's JSON body into v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{service.ErrNotFound, http.StatusNotFound},
+		{service.ErrConflict, http.StatusConflict},
+		{service.ErrValidation, http.StatusBadRequest},
+		{service.ErrUnsupported, http.StatusBadRequest},
+		{service.ErrForbidden, http.StatusForbidden},
+		{service.ErrUnauthorized, http.StatusUnauthorized},
+		{http.ErrBodyNotAllowed, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
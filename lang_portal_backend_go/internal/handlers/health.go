@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Health reports whether the server is alive and whether it is ready for
+// traffic. It isn't ready until it has started listening, nor once it has
+// begun shutting down, so load balancers stop sending it requests first.
+type Health struct {
+	svc   *service.Service
+	ready atomic.Bool
+}
+
+// NewHealth creates a health check that isn't ready yet
+func NewHealth(svc *service.Service) *Health {
+	return &Health{svc: svc}
+}
+
+// SetReady sets whether the server is ready for traffic
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// RegisterHealthRoutes registers the health checks, which need no access
+// token
+func RegisterHealthRoutes(r gin.IRoutes, health *Health) {
+	r.GET("/health", health.Live)
+	r.GET("/ready", health.Ready)
+}
+
+// Live responds while the server is running
+func (h *Health) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready responds with 503 Service Unavailable unless the server is ready
+// for traffic and its database can be reached
+func (h *Health) Ready(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
+		return
+	}
+	if err := h.svc.Ping(c.Request.Context()); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
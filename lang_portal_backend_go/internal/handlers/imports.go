@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"io"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportWordsRequest represents the request body for importing confirmed
+// words
+type ImportWordsRequest struct {
+	Words []models.DraftWord `json:"words" binding:"required"`
+	// GroupID is a group to add the words to
+	GroupID int64 `json:"group_id"`
+}
+
+func RegisterImportRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	imports := r.Group("/import")
+	{
+		imports.POST("/image", h.ImportImage)
+		imports.POST("/words", h.ImportWords)
+		imports.POST("/document", h.ImportDocument)
+	}
+}
+
+// ImportImage reads the words of an uploaded photo or scan of a word list,
+// sent as the multipart file field, and returns them for confirmation
+func (h *Handler) ImportImage(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	result, err := h.svcFor(c).ImportImage(c.Request.Context(), data, c.PostForm("columns"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportWords adds confirmed words, optionally to a group
+func (h *Handler) ImportWords(c *gin.Context) {
+	var req ImportWordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svcFor(c).ImportWords(c.Request.Context(), req.Words, req.GroupID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// ImportDocument summarizes a web page, text or uploaded document, sent as
+// the url, text or multipart file field, and drafts a group of its words
+// the user doesn't know
+func (h *Handler) ImportDocument(c *gin.Context) {
+	source := service.DocumentSource{URL: c.PostForm("url"), Text: c.PostForm("text")}
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		defer file.Close()
+		if source.Data, err = io.ReadAll(file); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		source.Filename = header.Filename
+	}
+	size := 0
+	if value := c.PostForm("size"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size"})
+			return
+		}
+	}
+
+	result, err := h.svcFor(c).ImportDocument(c.Request.Context(), source, c.PostForm("name"), size)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
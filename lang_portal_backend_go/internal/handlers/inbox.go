@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the API key of requests from external automations
+const APIKeyHeader = "X-API-Key"
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// InboxWordRequest is a word captured by an external automation, as JSON or
+// a form. Only text, urdu or english is needed.
+type InboxWordRequest struct {
+	// Text is the word's urdu or its english, told apart by its script
+	Text    string `json:"text" form:"text"`
+	Urdu    string `json:"urdu" form:"urdu"`
+	Urdlish string `json:"urdlish" form:"urdlish"`
+	English string `json:"english" form:"english"`
+}
+
+func RegisterAPIKeyRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	keys := r.Group("/api_keys")
+	{
+		keys.GET("", h.ListAPIKeys)
+		keys.POST("", h.CreateAPIKey)
+		keys.DELETE("/:id", h.DeleteAPIKey)
+	}
+}
+
+// RegisterInboxRoutes receives words from external automations, such as a
+// phone shortcut or Zapier. They send an API key rather than an access
+// token.
+func RegisterInboxRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	inbox := r.Group("/inbox")
+	inbox.Use(h.requireAPIKey, middleware.Idempotency(svc.Cache()))
+	{
+		inbox.POST("/words", h.CaptureInboxWord)
+	}
+}
+
+// requireAPIKey rejects requests without a valid API key, sent in the
+// X-API-Key header or as a bearer token, and records the user it was given
+// to under middleware.UserIDKey
+func (h *Handler) requireAPIKey(c *gin.Context) {
+	key := c.GetHeader(APIKeyHeader)
+	if key == "" {
+		key, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	userID, err := h.svc.APIKeyUser(c.Request.Context(), key, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		c.Abort()
+		return
+	}
+	c.Set(middleware.UserIDKey, userID)
+	c.Next()
+}
+
+// ListAPIKeys lists the user's API keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.svcFor(c).ListAPIKeys(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// CreateAPIKey gives the user a new API key, shown only in the response
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.svcFor(c).CreateAPIKey(c.Request.Context(), req.Name, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// DeleteAPIKey revokes one of the user's API keys
+func (h *Handler) DeleteAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteAPIKey(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CaptureInboxWord puts a word in the user's Inbox group
+func (h *Handler) CaptureInboxWord(c *gin.Context) {
+	var req InboxWordRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	word, err := h.svcFor(c).CaptureInboxWord(c.Request.Context(), req.Text, models.Word{
+		Urdu:    req.Urdu,
+		Urdlish: req.Urdlish,
+		English: req.English,
+	})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if word.Created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, word)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterJobRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/jobs/:id", h.GetJob)
+}
+
+// GetJob returns the status of a background job the user queued, and its
+// result once it has succeeded
+func (h *Handler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	job, err := h.svcFor(c).GetJob(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// accepted responds that a job was queued, with where to follow it
+func accepted(c *gin.Context, job *models.Job) {
+	base := "/api"
+	if strings.HasPrefix(c.Request.URL.Path, "/api/v1/") {
+		base = "/api/v1"
+	}
+	c.Header("Location", base+"/jobs/"+strconv.FormatInt(job.ID, 10))
+	c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetLanguageRequest represents the request body for adding or changing a
+// language
+type SetLanguageRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Script is the ISO 15924 code of the language's script, e.g. Jpan
+	Script string `json:"script" binding:"required"`
+	// Direction is ltr, the default, or rtl
+	Direction       string `json:"direction"`
+	Transliteration string `json:"transliteration"`
+}
+
+func RegisterLanguageRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/languages", h.ListLanguages)
+	r.GET("/languages/:code", h.GetLanguage)
+}
+
+// RegisterLanguageAdminRoutes registers the routes changing languages. r
+// must require the admin role.
+func RegisterLanguageAdminRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.PUT("/admin/languages/:code", h.SetLanguage)
+}
+
+// ListLanguages returns the languages words can be studied in
+func (h *Handler) ListLanguages(c *gin.Context) {
+	languages, err := h.svc.ListLanguages(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"languages": languages})
+}
+
+// GetLanguage returns a language by its code
+func (h *Handler) GetLanguage(c *gin.Context) {
+	language, err := h.svc.GetLanguage(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, language)
+}
+
+// SetLanguage adds a language words can be studied in or changes how one is
+// written
+func (h *Handler) SetLanguage(c *gin.Context) {
+	var req SetLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	language, err := h.svc.SetLanguage(c.Request.Context(), models.Language{
+		Code:            c.Param("code"),
+		Name:            req.Name,
+		Script:          req.Script,
+		Direction:       req.Direction,
+		Transliteration: req.Transliteration,
+	})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, language)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Number of entries returned by the leaderboard endpoint
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// LeaderboardSettingsRequest represents the request body for changing
+// leaderboard settings
+type LeaderboardSettingsRequest struct {
+	OptIn       *bool  `json:"opt_in" binding:"required"`
+	DisplayName string `json:"display_name"`
+}
+
+func RegisterLeaderboardRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	leaderboards := r.Group("/leaderboards")
+	{
+		leaderboards.GET("", h.GetLeaderboard)
+		leaderboards.GET("/settings", h.GetLeaderboardSettings)
+		leaderboards.PUT("/settings", h.UpdateLeaderboardSettings)
+	}
+}
+
+// GetLeaderboard ranks the users who opted in over a week
+func (h *Handler) GetLeaderboard(c *gin.Context) {
+	metric := c.DefaultQuery("metric", service.LeaderboardReviews)
+	switch metric {
+	case service.LeaderboardReviews, service.LeaderboardAccuracy, service.LeaderboardStreak:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be reviews, accuracy or streak"})
+		return
+	}
+
+	day := time.Now()
+	if value := c.Query("week"); value != "" {
+		var err error
+		day, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid week"})
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLeaderboardLimit)))
+	if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	board, err := h.svcFor(c).GetLeaderboard(c.Request.Context(), metric, day, limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// GetLeaderboardSettings returns the user's leaderboard settings
+func (h *Handler) GetLeaderboardSettings(c *gin.Context) {
+	settings, err := h.svcFor(c).GetLeaderboardSettings(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateLeaderboardSettings opts the user in to or out of leaderboards
+func (h *Handler) UpdateLeaderboardSettings(c *gin.Context) {
+	var req LeaderboardSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.svcFor(c).UpdateLeaderboardSettings(c.Request.Context(), *req.OptIn, req.DisplayName)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
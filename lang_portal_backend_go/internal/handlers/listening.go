@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"lang_portal/internal/listening"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultListeningQuestionCount is used when no question count is given
+const defaultListeningQuestionCount = 5
+
+// CreateListeningClipRequest represents the request body for adding a clip
+type CreateListeningClipRequest struct {
+	GroupID    int64  `json:"group_id" binding:"required"`
+	Title      string `json:"title" binding:"required"`
+	AudioURL   string `json:"audio_url" binding:"required"`
+	Transcript string `json:"transcript" binding:"required"`
+	// Difficulty decides how close the incorrect options are; defaults to medium
+	Difficulty    string `json:"difficulty"`
+	QuestionCount int    `json:"question_count" binding:"omitempty,min=1,max=20"`
+}
+
+// ListeningAnswer is an answer to one listening question
+type ListeningAnswer struct {
+	QuestionID int64  `json:"question_id" binding:"required"`
+	Answer     string `json:"answer"`
+}
+
+// SubmitListeningAttemptRequest represents the answers to a clip's questions
+type SubmitListeningAttemptRequest struct {
+	Answers []ListeningAnswer `json:"answers" binding:"dive"`
+}
+
+func RegisterListeningRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	clips := r.Group("/listening/clips")
+	{
+		clips.GET("", h.GetListeningClips)
+		clips.POST("", h.CreateListeningClip)
+		clips.GET("/:id", h.GetListeningClip)
+		clips.POST("/:id/attempts", h.SubmitListeningAttempt)
+	}
+}
+
+// GetListeningClips lists the clips, optionally of one difficulty level
+func (h *Handler) GetListeningClips(c *gin.Context) {
+	difficulty := c.Query("difficulty")
+	if difficulty != "" && !listening.ValidDifficulty(difficulty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+
+	clips, err := h.svcFor(c).GetListeningClips(c.Request.Context(), difficulty)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": clips,
+		"count": len(clips),
+	})
+}
+
+// CreateListeningClip stores a clip and generates its questions
+func (h *Handler) CreateListeningClip(c *gin.Context) {
+	var req CreateListeningClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Difficulty == "" {
+		req.Difficulty = listening.Medium
+	}
+	if !listening.ValidDifficulty(req.Difficulty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+	if req.QuestionCount == 0 {
+		req.QuestionCount = defaultListeningQuestionCount
+	}
+
+	clip := models.ListeningClip{
+		GroupID:    req.GroupID,
+		Title:      req.Title,
+		AudioURL:   req.AudioURL,
+		Transcript: req.Transcript,
+		Difficulty: req.Difficulty,
+	}
+	if err := h.svcFor(c).CreateListeningClip(c.Request.Context(), &clip, req.QuestionCount); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, clip)
+}
+
+// GetListeningClip returns a clip with its questions
+func (h *Handler) GetListeningClip(c *gin.Context) {
+	clipID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid clip id"})
+		return
+	}
+
+	clip, err := h.svcFor(c).GetListeningClip(c.Request.Context(), clipID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, clip)
+}
+
+// SubmitListeningAttempt scores answers to a clip's questions and records
+// them as a study session
+func (h *Handler) SubmitListeningAttempt(c *gin.Context) {
+	clipID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid clip id"})
+		return
+	}
+
+	var req SubmitListeningAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	answers := make(map[int64]string, len(req.Answers))
+	for _, answer := range req.Answers {
+		answers[answer.QuestionID] = answer.Answer
+	}
+
+	attempt, err := h.svcFor(c).SubmitListeningAttempt(c.Request.Context(), clipID, answers)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, attempt)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"lang_portal/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMaintenanceRoutes registers the database maintenance routes. r
+// must require the admin role.
+func RegisterMaintenanceRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/admin/maintenance", h.GetDatabaseStats)
+	r.POST("/admin/maintenance/analyze", h.AnalyzeDatabase)
+	r.POST("/admin/maintenance/optimize", h.OptimizeDatabase)
+	r.POST("/admin/maintenance/vacuum", h.VacuumDatabase)
+	r.GET("/admin/maintenance/integrity_check", h.CheckIntegrity)
+}
+
+// GetDatabaseStats returns the size and fragmentation of the database
+func (h *Handler) GetDatabaseStats(c *gin.Context) {
+	stats, err := h.svc.DatabaseStats(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// AnalyzeDatabase gathers query planner statistics for every table
+func (h *Handler) AnalyzeDatabase(c *gin.Context) {
+	h.runMaintenance(c, h.svc.Analyze)
+}
+
+// OptimizeDatabase gathers query planner statistics for the tables that
+// need them
+func (h *Handler) OptimizeDatabase(c *gin.Context) {
+	h.runMaintenance(c, h.svc.Optimize)
+}
+
+// VacuumDatabase reclaims the database's free pages
+func (h *Handler) VacuumDatabase(c *gin.Context) {
+	h.runMaintenance(c, h.svc.Vacuum)
+}
+
+// CheckIntegrity runs SQLite's integrity check
+func (h *Handler) CheckIntegrity(c *gin.Context) {
+	check, err := h.svc.CheckIntegrity(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// runMaintenance runs a maintenance task and responds with the database
+// stats before and after it
+func (h *Handler) runMaintenance(c *gin.Context, task func(ctx context.Context) error) {
+	ctx := c.Request.Context()
+	before, err := h.svc.DatabaseStats(ctx)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	if err := task(ctx); err != nil {
+		serviceError(c, err)
+		return
+	}
+	after, err := h.svc.DatabaseStats(ctx)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"before":  before,
+		"after":   after,
+	})
+}
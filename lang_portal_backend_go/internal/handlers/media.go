@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"lang_portal/internal/storage"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMediaRoutes serves the media of local storage under
+// storage.URLPrefix to whoever has a signed URL of it. Other storage serves
+// its signed URLs itself.
+func RegisterMediaRoutes(r gin.IRoutes, media storage.Storage) {
+	local, ok := media.(*storage.Local)
+	if !ok {
+		return
+	}
+	r.GET(storage.URLPrefix+"*key", ServeMedia(local))
+}
+
+// ServeMedia serves a file of local storage if the URL's signature is valid
+// and hasn't expired. It may be cached until the URL expires.
+func ServeMedia(local *storage.Local) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		expires := c.Query("expires")
+		if !storage.ValidKey(key) || !local.Verify(key, expires, c.Query("signature")) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		path := local.Path(key)
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(local.Expires(expires).Seconds())))
+		c.File(path)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationPreferencesRequest represents the request body for changing
+// notification preferences
+type NotificationPreferencesRequest struct {
+	Email             string `json:"email"`
+	StreakReminders   *bool  `json:"streak_reminders" binding:"required"`
+	WeeklyDigest      *bool  `json:"weekly_digest" binding:"required"`
+	HomeworkReminders *bool  `json:"homework_reminders" binding:"required"`
+}
+
+func RegisterNotificationRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	notifications := r.Group("/notifications")
+	{
+		notifications.GET("/preferences", h.GetNotificationPreferences)
+		notifications.PUT("/preferences", h.UpdateNotificationPreferences)
+		notifications.GET("/deliveries", h.ListNotificationDeliveries)
+	}
+}
+
+// GetNotificationPreferences returns the notifications the user wants
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	prefs, err := h.svcFor(c).GetNotificationPreferences(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateNotificationPreferences sets the notifications the user wants
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.svcFor(c).UpdateNotificationPreferences(c.Request.Context(), models.NotificationPreferences{
+		Email:             req.Email,
+		StreakReminders:   *req.StreakReminders,
+		WeeklyDigest:      *req.WeeklyDigest,
+		HomeworkReminders: *req.HomeworkReminders,
+	}, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// ListNotificationDeliveries returns the notifications last sent to the user
+func (h *Handler) ListNotificationDeliveries(c *gin.Context) {
+	deliveries, err := h.svcFor(c).ListNotificationDeliveries(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePassageRequest represents the request body for creating a reading
+// passage
+type CreatePassageRequest struct {
+	// Language is the passage's language, by default the request's
+	Language string `json:"language"`
+	Title    string `json:"title" binding:"required"`
+	// Level is the CEFR level of the passage, A1 to C2
+	Level string `json:"level" binding:"required"`
+	Text  string `json:"text" binding:"required"`
+	// Source is where the passage is taken from, e.g. a book
+	Source string `json:"source"`
+}
+
+// AddPassageWordRequest represents the request body for adding a word of a
+// passage to a group
+type AddPassageWordRequest struct {
+	// Position is the position of the word in the passage, in characters,
+	// as the passage's segments give it
+	Position *int `json:"position" binding:"required,min=0"`
+	// WordID picks which of the words a segment reads as is added, by
+	// default the first
+	WordID int64 `json:"word_id"`
+	// GroupID is the group the word is added to, by default the inbox
+	GroupID int64 `json:"group_id"`
+}
+
+func RegisterPassageRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	passages := r.Group("/passages")
+	{
+		passages.POST("", h.CreatePassage)
+		passages.GET("", h.ListPassages)
+		passages.GET("/:id", h.ReadPassage)
+		passages.DELETE("/:id", h.DeletePassage)
+		passages.POST("/:id/words", h.AddPassageWord)
+	}
+}
+
+// CreatePassage stores a graded reading passage
+func (h *Handler) CreatePassage(c *gin.Context) {
+	var req CreatePassageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	passage, err := h.svcFor(c).CreatePassage(c.Request.Context(), models.Passage{
+		Language: req.Language,
+		Title:    req.Title,
+		Level:    req.Level,
+		Text:     req.Text,
+		Source:   req.Source,
+	})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, passage)
+}
+
+// ListPassages returns the passages, without their texts, optionally only
+// those of a level
+func (h *Handler) ListPassages(c *gin.Context) {
+	passages, err := h.svcFor(c).ListPassages(c.Request.Context(), c.Query("level"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": passages, "count": len(passages)})
+}
+
+// ReadPassage returns a passage segmented into words linked to the
+// vocabulary, marked with whether the learner knows them
+func (h *Handler) ReadPassage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	reading, err := h.svcFor(c).ReadPassage(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reading)
+}
+
+// DeletePassage deletes a passage
+func (h *Handler) DeletePassage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeletePassage(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddPassageWord adds the word tapped in a passage to a group, capturing it
+// into the inbox first if it isn't in the vocabulary
+func (h *Handler) AddPassageWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req AddPassageWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	added, err := h.svcFor(c).AddPassageWord(c.Request.Context(), id, *req.Position, req.WordID, req.GroupID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, added)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"io"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPronunciationRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/pronunciation/check", h.CheckPronunciation)
+}
+
+// CheckPronunciation scores a recording of a word, sent as the multipart
+// audio field, and records the attempt in a pronunciation session
+func (h *Handler) CheckPronunciation(c *gin.Context) {
+	wordID, err := strconv.ParseInt(c.PostForm("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word_id"})
+		return
+	}
+	var sessionID, groupID int64
+	if value := c.PostForm("session_id"); value != "" {
+		if sessionID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session_id"})
+			return
+		}
+	}
+	if value := c.PostForm("group_id"); value != "" {
+		if groupID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_id"})
+			return
+		}
+	}
+
+	header, err := c.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read audio"})
+		return
+	}
+	defer file.Close()
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read audio"})
+		return
+	}
+
+	check, err := h.svcFor(c).CheckPronunciation(c.Request.Context(), wordID, sessionID, groupID, audio)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
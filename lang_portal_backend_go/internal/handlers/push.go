@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"lang_portal/internal/push"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PushSubscriptionRequest represents the request body for subscribing a
+// browser, as PushSubscription.toJSON() gives it, with the notifications
+// it wants. Both are wanted unless turned off.
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+	ReviewsDue   *bool `json:"reviews_due"`
+	StreakAtRisk *bool `json:"streak_at_risk"`
+}
+
+func RegisterPushRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	pushes := r.Group("/push")
+	{
+		pushes.GET("/public_key", h.GetPushPublicKey)
+		pushes.GET("/subscriptions", h.ListPushSubscriptions)
+		pushes.POST("/subscriptions", h.SubscribePush)
+		pushes.DELETE("/subscriptions/:id", h.DeletePushSubscription)
+	}
+}
+
+// GetPushPublicKey returns the key browsers subscribe with
+func (h *Handler) GetPushPublicKey(c *gin.Context) {
+	key, err := h.svcFor(c).PushPublicKey()
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"public_key": key})
+}
+
+// ListPushSubscriptions returns the browsers the user subscribed
+func (h *Handler) ListPushSubscriptions(c *gin.Context) {
+	subscriptions, err := h.svcFor(c).ListPushSubscriptions(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// SubscribePush subscribes a browser to the user's notifications
+func (h *Handler) SubscribePush(c *gin.Context) {
+	var req PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reviewsDue, streakAtRisk := true, true
+	if req.ReviewsDue != nil {
+		reviewsDue = *req.ReviewsDue
+	}
+	if req.StreakAtRisk != nil {
+		streakAtRisk = *req.StreakAtRisk
+	}
+
+	subscription, err := h.svcFor(c).SubscribePush(c.Request.Context(), push.Subscription{
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}, reviewsDue, streakAtRisk, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeletePushSubscription unsubscribes a browser
+func (h *Handler) DeletePushSubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeletePushSubscription(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewActivityVocabularyQuiz is the only activity questions can be previewed for
+const PreviewActivityVocabularyQuiz = "vocabulary_quiz"
+
+// QuestionPreviewRequest represents the request body for previewing a question
+type QuestionPreviewRequest struct {
+	WordID   int64  `json:"word_id" binding:"required"`
+	GroupID  int64  `json:"group_id"`
+	Activity string `json:"activity"`
+	// Difficulty shapes the incorrect options; defaults to medium
+	Difficulty QuizDifficulty `json:"difficulty"`
+	// Direction is what is shown and what is asked; defaults to urdu_to_english
+	Direction string `json:"direction"`
+	// AnswerMode is multiple_choice (default) or typed
+	AnswerMode string `json:"answer_mode"`
+}
+
+func RegisterQuestionRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	questions := r.Group("/questions")
+	{
+		questions.POST("/preview", h.PreviewQuestion)
+	}
+}
+
+// PreviewQuestion returns the question a learner would be shown for a word
+// without creating a study session
+func (h *Handler) PreviewQuestion(c *gin.Context) {
+	var req QuestionPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Activity == "" {
+		req.Activity = PreviewActivityVocabularyQuiz
+	}
+	if req.Activity != PreviewActivityVocabularyQuiz {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported activity"})
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = Medium
+	}
+	if _, ok := difficultyPoints[req.Difficulty]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+
+	if req.Direction == "" {
+		req.Direction = QuizDirectionUrduToEnglish
+	}
+	if !validQuizDirection(req.Direction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported quiz direction"})
+		return
+	}
+
+	if req.AnswerMode == "" {
+		req.AnswerMode = service.AnswerModeMultipleChoice
+	}
+	if !validAnswerMode(req.AnswerMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "answer mode must be multiple_choice or typed"})
+		return
+	}
+
+	word, pool, err := h.svcFor(c).GetQuestionPool(c.Request.Context(), req.WordID, req.GroupID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	question, err := h.forRequest(c).buildQuizWord(c.Request.Context(), *word, pool, req.GroupID, quizSettings{
+		Difficulty: req.Difficulty,
+		Direction:  req.Direction,
+		AnswerMode: req.AnswerMode,
+	})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, question)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"lang_portal/internal/models"
+)
+
+// Quiz hint types, from least to most revealing
+const (
+	QuizHintFirstLetter = "first_letter"
+	QuizHintUrdlish     = "urdlish"
+	QuizHintExample     = "example"
+)
+
+// QuizHint is one hint for a quiz question
+type QuizHint struct {
+	Level int    `json:"level"`
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+}
+
+// QuizHintResponse lists the hints revealed so far for a quiz question
+type QuizHintResponse struct {
+	SessionID      int64      `json:"session_id"`
+	WordID         int64      `json:"word_id"`
+	HintsUsed      int        `json:"hints_used"`
+	HintsAvailable int        `json:"hints_available"`
+	Hints          []QuizHint `json:"hints"`
+}
+
+// quizPoints is what a quiz is worth: each correct answer earns the points of
+// its difficulty and direction, and each hint taken on one costs a point
+func quizPoints(correctCount, hintsUsed int, difficulty QuizDifficulty, direction string) int {
+	points := correctCount*(difficultyPoints[difficulty]+directionBonus[direction]) - hintsUsed
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// quizHints returns the hints available for a question, least revealing
+// first. The transliteration is skipped when it is the prompt, and the
+// example when the word has none.
+func quizHints(question *models.QuizQuestion, word *models.WordResponse, example string) []QuizHint {
+	var hints []QuizHint
+	add := func(hintType, text string) {
+		hints = append(hints, QuizHint{Level: len(hints) + 1, Type: hintType, Text: text})
+	}
+
+	if answer := []rune(question.CorrectAnswer); len(answer) > 0 {
+		add(QuizHintFirstLetter, string(answer[0]))
+	}
+	if word.Urdlish != "" && question.Direction != QuizDirectionUrdlishToUrdu {
+		add(QuizHintUrdlish, word.Urdlish)
+	}
+	if example != "" {
+		add(QuizHintExample, example)
+	}
+	return hints
+}
+
+// GetQuizHint reveals the next hint for a word in a quiz session and returns
+// all hints revealed so far. Hints taken are recorded and cost points.
+func (h *Handler) GetQuizHint(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return
+	}
+
+	question, err := h.svcFor(c).GetQuizQuestion(c.Request.Context(), sessionID, wordID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	word, err := h.svcFor(c).GetWord(c.Request.Context(), wordID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	example, err := h.svcFor(c).GetWordExample(c.Request.Context(), wordID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	hints := quizHints(question, word, example)
+
+	used, err := h.svcFor(c).UseQuizHint(c.Request.Context(), question.ID, len(hints))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, QuizHintResponse{
+		SessionID:      sessionID,
+		WordID:         wordID,
+		HintsUsed:      used,
+		HintsAvailable: len(hints),
+		Hints:          hints[:used],
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReviewQueueLimit is used when no limit is given
+const defaultReviewQueueLimit = 20
+
+func RegisterReviewQueueRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	queue := r.Group("/review-queue")
+	{
+		queue.GET("", h.GetReviewQueue)
+		queue.POST("/session", h.CreateDailyReviewSession)
+	}
+}
+
+// GetReviewQueue returns the words due for review today
+func (h *Handler) GetReviewQueue(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReviewQueueLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	queue, err := h.svcFor(c).GetReviewQueue(c.Request.Context(), limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": queue,
+		"count": len(queue),
+	})
+}
+
+// CreateDailyReviewSession starts a Daily Review session from the review queue
+func (h *Handler) CreateDailyReviewSession(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReviewQueueLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	session, err := h.svcFor(c).CreateDailyReviewSession(c.Request.Context(), limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSearchRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	words := r.Group("/words")
+	words.Use(middleware.ETag(), middleware.Fields())
+	{
+		words.GET("/search", h.SearchWords)
+		words.GET("/:id/similar", h.SimilarWords)
+	}
+	r.POST("/words/embed", h.EmbedWords)
+}
+
+// SearchWords returns the words matching q, by their text or, with
+// mode=semantic, by meaning
+func (h *Handler) SearchWords(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSearchLimit)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	words, err := h.svcFor(c).SearchWords(c.Request.Context(), c.Query("q"), c.Query("mode"), limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": words,
+		"count": len(words),
+	})
+}
+
+// SimilarWords returns the words nearest a word in meaning
+func (h *Handler) SimilarWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSearchLimit)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	words, err := h.svcFor(c).SimilarWords(c.Request.Context(), id, limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": words,
+		"count": len(words),
+	})
+}
+
+// EmbedWords queues computing the embeddings of the words without one from
+// the configured provider, or of every word with force=true
+func (h *Handler) EmbedWords(c *gin.Context) {
+	job, err := h.svcFor(c).EnqueueWordEmbeddings(c.Request.Context(), c.Query("force") == "true")
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportSentence is a sentence to add to the corpus
+type ImportSentence struct {
+	Text            string `json:"text" binding:"required"`
+	Transliteration string `json:"transliteration"`
+	English         string `json:"english"`
+	// Source is where the sentence is taken from, e.g. a book
+	Source string `json:"source"`
+}
+
+// ImportSentencesRequest represents the request body for importing
+// sentences
+type ImportSentencesRequest struct {
+	// Language is the sentences' language, by default the request's
+	Language  string           `json:"language"`
+	Sentences []ImportSentence `json:"sentences" binding:"required,dive"`
+}
+
+// LinkSentencesRequest represents the request body for linking sentences to
+// words again
+type LinkSentencesRequest struct {
+	Language string `json:"language"`
+}
+
+func RegisterSentenceRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/words/:id/sentences", h.GetWordSentences)
+
+	sentences := r.Group("/sentences")
+	{
+		sentences.POST("/import", h.ImportSentences)
+		sentences.POST("/link", h.LinkSentences)
+		sentences.GET("/search", h.SearchSentences)
+		sentences.GET("/:id", h.GetSentence)
+	}
+}
+
+// ImportSentences adds sentences to the corpus, linked to the words they
+// contain
+func (h *Handler) ImportSentences(c *gin.Context) {
+	var req ImportSentencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sentences := make([]models.Sentence, len(req.Sentences))
+	for i, sentence := range req.Sentences {
+		sentences[i] = models.Sentence{
+			Text:            sentence.Text,
+			Transliteration: sentence.Transliteration,
+			English:         sentence.English,
+			Source:          sentence.Source,
+		}
+	}
+	result, err := h.svcFor(c).ImportSentences(c.Request.Context(), req.Language, sentences)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// LinkSentences queues linking the sentences of a language to its words
+// again, so words added since they were imported are found
+func (h *Handler) LinkSentences(c *gin.Context) {
+	var req LinkSentencesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	job, err := h.svcFor(c).EnqueueSentenceLinking(c.Request.Context(), req.Language)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetSentence returns a sentence of the corpus
+func (h *Handler) GetSentence(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	sentence, err := h.svcFor(c).GetSentence(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sentence)
+}
+
+// GetWordSentences returns sentences a word occurs in, shortest first
+func (h *Handler) GetWordSentences(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSearchLimit)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	sentences, err := h.svcFor(c).GetWordSentences(c.Request.Context(), id, limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": sentences,
+		"count": len(sentences),
+	})
+}
+
+// SearchSentences returns the sentences containing the phrase q, or whose
+// English contains it
+func (h *Handler) SearchSentences(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(service.DefaultSearchLimit)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	sentences, err := h.svcFor(c).SearchSentences(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": sentences,
+		"count": len(sentences),
+	})
+}
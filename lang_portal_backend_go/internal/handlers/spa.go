@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSPARoutes serves the frontend's files under /. Other paths outside
+// the API get its index.html, so the app's own routes can be opened
+// directly or reloaded.
+func RegisterSPARoutes(r *gin.Engine, files fs.FS) error {
+	index, err := fs.ReadFile(files, "index.html")
+	if err != nil {
+		return err
+	}
+	fileServer := http.FileServer(http.FS(files))
+
+	r.NoRoute(func(c *gin.Context) {
+		urlPath := c.Request.URL.Path
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead ||
+			urlPath == "/api" || strings.HasPrefix(urlPath, "/api/") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(urlPath), "/")
+		if name != "" && !strings.HasPrefix(path.Base(name), ".") {
+			if info, err := fs.Stat(files, name); err == nil && !info.IsDir() {
+				// Vite names assets after their content, so they never change
+				if strings.HasPrefix(name, "assets/") {
+					c.Header("Cache-Control", "public, max-age=31536000, immutable")
+				}
+				fileServer.ServeHTTP(c.Writer, c.Request)
+				return
+			}
+		}
+		// Missing files are 404s rather than the app
+		if path.Ext(name) != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+
+		// The app is fetched again on every visit so deploys take effect
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	return nil
+}
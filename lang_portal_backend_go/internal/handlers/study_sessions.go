@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"lang_portal/internal/card"
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStudySessionsRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	sessions := r.Group("/study_sessions")
+	sessions.Use(middleware.Fields())
+	{
+		sessions.GET("", h.ListStudySessions)
+		sessions.GET("/:id", h.GetStudySession)
+		sessions.GET("/:id/card", h.GetStudySessionCard)
+		sessions.GET("/:id/score", h.GetStudySessionScore)
+		sessions.GET("/:id/words", h.GetStudySessionWords)
+		sessions.POST("/:id/words/:word_id/review", h.ReviewWord)
+		sessions.POST("", h.CreateStudySession)
+	}
+}
+
+func (h *Handler) ListStudySessions(c *gin.Context) {
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		sessions, err := h.svcFor(c).ListStudySessionsByCursor(c.Request.Context(), 0, cursor)
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageNum, _ := strconv.Atoi(page)
+
+	sessions, err := h.svcFor(c).ListStudySessions(c.Request.Context(), pageNum)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) GetStudySession(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetStudySession(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetStudySessionScore returns the score of a study session, computed by the
+// session's activity
+func (h *Handler) GetStudySessionScore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetStudySession(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	activity, ok := h.forRequest(c).activity(session.ActivityName)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "activity does not score sessions"})
+		return
+	}
+	score, err := activity.Score(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, score)
+}
+
+// sessionCards caches rendered session cards between requests
+var sessionCards = card.NewCache(500)
+
+// cardContentTypes are the content types of the formats cards are rendered in
+var cardContentTypes = map[string]string{
+	"svg": "image/svg+xml",
+	"png": "image/png",
+}
+
+// GetStudySessionCard renders a shareable summary card for a study session
+func (h *Handler) GetStudySessionCard(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "svg")
+	contentType, ok := cardContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported card format, use svg or png"})
+		return
+	}
+
+	summary, err := h.svcFor(c).GetStudySessionCard(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	key := card.Key(summary, format)
+	image, ok := sessionCards.Get(key)
+	if !ok {
+		if format == "png" {
+			if image, err = card.RenderPNG(summary); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			image = card.RenderSVG(summary)
+		}
+		sessionCards.Set(key, image)
+	}
+
+	// Cards show the user's own progress, so shared caches mustn't keep them
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, contentType, image)
+}
+
+func (h *Handler) GetStudySessionWords(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageNum, _ := strconv.Atoi(page)
+
+	words, err := h.svcFor(c).GetStudySessionWords(c.Request.Context(), id, pageNum, true)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, words)
+}
+
+func (h *Handler) ReviewWord(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return
+	}
+
+	var req struct {
+		Correct bool `json:"correct" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.svcFor(c).ReviewWord(c.Request.Context(), sessionID, wordID, req.Correct)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// CreateStudySessionRequest represents the request body for creating a study session
+type CreateStudySessionRequest struct {
+	GroupID      int64  `json:"group_id" binding:"required"`
+	ActivityName string `json:"activity_name" binding:"required"`
+}
+
+func (h *Handler) CreateStudySession(c *gin.Context) {
+	var req CreateStudySessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	session, err := h.svcFor(c).CreateStudySessionWithActivity(c.Request.Context(), req.GroupID, req.ActivityName)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	// Let the activity prepare the session, e.g. pick its words
+	if err := h.forRequest(c).sessionCreated(c.Request.Context(), session); err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"image/png"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetStudySession(t *testing.T) {
+	sessions := &mocks.SessionRepositoryMock{
+		GetFunc: func(ctx context.Context, userID, id int64) (*models.StudySessionResponse, error) {
+			if id != 7 {
+				return nil, sql.ErrNoRows
+			}
+			return &models.StudySessionResponse{ID: 7, GroupID: 1, ActivityName: "Flashcards", ReviewItemsCount: 4}, nil
+		},
+	}
+	svc := newTestService(t, repository.Repositories{Sessions: sessions})
+
+	w := serve(RegisterStudySessionsRoutes, svc, http.MethodGet, "/study_sessions/7", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var session models.StudySessionResponse
+	decode(t, w, &session)
+	if session.ID != 7 || session.ActivityName != "Flashcards" || session.ReviewItemsCount != 4 {
+		t.Errorf("session = %+v, want session 7", session)
+	}
+
+	if w := serve(RegisterStudySessionsRoutes, svc, http.MethodGet, "/study_sessions/8", ""); w.Code != http.StatusNotFound {
+		t.Errorf("status of a missing session = %d, want 404", w.Code)
+	}
+}
+
+func TestReviewWord(t *testing.T) {
+	// Session 1 is of group 2, which holds words 1 and 2
+	setup := `
+		INSERT INTO study_sessions (id, group_id, created_at, study_activity_id, user_id)
+		VALUES (1, 2, CURRENT_TIMESTAMP, 1, 1)
+	`
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantRecord bool
+	}{
+		{name: "word of the session", path: "/study_sessions/1/words/1/review", body: `{"correct": true}`, wantStatus: http.StatusOK, wantRecord: true},
+		{name: "word outside the session", path: "/study_sessions/1/words/3/review", body: `{"correct": true}`, wantStatus: http.StatusNotFound},
+		{name: "someone else's session", path: "/study_sessions/2/words/1/review", body: `{"correct": true}`, wantStatus: http.StatusNotFound},
+		{name: "invalid word id", path: "/study_sessions/1/words/one/review", body: `{"correct": true}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", path: "/study_sessions/1/words/1/review", body: `{"correct": "yes"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &mocks.SessionRepositoryMock{
+				OwnsFunc: func(ctx context.Context, q repository.Querier, userID, sessionID int64) (bool, error) {
+					return sessionID == 1, nil
+				},
+			}
+			reviews := &mocks.ReviewRepositoryMock{
+				RecordFunc: func(ctx context.Context, q repository.Querier, userID int64, item models.WordReviewItem) error {
+					return nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Sessions: sessions, Reviews: reviews}, setup)
+
+			w := serve(RegisterStudySessionsRoutes, svc, http.MethodPost, tt.path, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			calls := reviews.RecordCalls()
+			if recorded := len(calls) == 1; recorded != tt.wantRecord {
+				t.Fatalf("Record called %d times, want recorded = %v", len(calls), tt.wantRecord)
+			}
+			if !tt.wantRecord {
+				return
+			}
+			if item := calls[0].Item; item.StudySessionID != 1 || item.WordID != 1 || !item.Correct || calls[0].UserID != 1 {
+				t.Errorf("recorded %+v for user %d, want a correct review of word 1 in session 1 for user 1", item, calls[0].UserID)
+			}
+		})
+	}
+}
+
+func TestGetStudySessionCard(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "svg by default", path: "/study_sessions/7/card", wantStatus: http.StatusOK, wantContentType: "image/svg+xml"},
+		{name: "png", path: "/study_sessions/7/card?format=png", wantStatus: http.StatusOK, wantContentType: "image/png"},
+		{name: "unknown format", path: "/study_sessions/7/card?format=gif", wantStatus: http.StatusBadRequest},
+		{name: "not found", path: "/study_sessions/8/card", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &mocks.SessionRepositoryMock{
+				GetFunc: func(ctx context.Context, userID, id int64) (*models.StudySessionResponse, error) {
+					if id != 7 {
+						return nil, sql.ErrNoRows
+					}
+					return &models.StudySessionResponse{ID: 7, GroupID: 1, GroupName: "Basics", ActivityName: "Flashcards"}, nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Sessions: sessions})
+
+			w := serve(RegisterStudySessionsRoutes, svc, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private") {
+				t.Errorf("Cache-Control = %q, want a private response", got)
+			}
+			if tt.wantContentType == "image/png" {
+				if _, err := png.Decode(w.Body); err != nil {
+					t.Errorf("card isn't a PNG: %v", err)
+				}
+			}
+		})
+	}
+}
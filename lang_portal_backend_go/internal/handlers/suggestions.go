@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuggestWordRequest gives one term of a word, its english or its urdu
+type SuggestWordRequest struct {
+	English string `json:"english"`
+	Urdu    string `json:"urdu"`
+}
+
+func RegisterSuggestionRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/words/suggest", h.SuggestWord)
+}
+
+// SuggestWord proposes the missing fields of a word from its english or its
+// urdu, for the editor to confirm before creating the word
+func (h *Handler) SuggestWord(c *gin.Context) {
+	var req SuggestWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	suggestion, err := h.svcFor(c).SuggestWord(c.Request.Context(), req.English, req.Urdu)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncPushRequest represents the request body for pushing the study
+// sessions and reviews an offline client made
+type SyncPushRequest struct {
+	Sessions []models.OfflineSession `json:"study_sessions"`
+	Reviews  []models.OfflineReview  `json:"word_review_items"`
+}
+
+// RegisterSyncRoutes lets mobile clients study offline: they pull what
+// changed since their last sync, and push the sessions and reviews they
+// made meanwhile
+func RegisterSyncRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET("/sync", h.PullChanges)
+	r.POST("/sync", h.PushChanges)
+}
+
+// PullChanges returns what changed since the sync token in the token query
+// parameter, or everything without one
+func (h *Handler) PullChanges(c *gin.Context) {
+	changes, err := h.svcFor(c).GetChanges(c.Request.Context(), c.Query("token"), time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// PushChanges records the study sessions and reviews an offline client made
+// and returns what became of each
+func (h *Handler) PushChanges(c *gin.Context) {
+	var req SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svcFor(c).PushChanges(c.Request.Context(), req.Sessions, req.Reviews, time.Now())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
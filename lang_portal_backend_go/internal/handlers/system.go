@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterResetRoutes registers the routes resetting and restoring the
+// user's study history. Confirming and restoring full resets also goes
+// through them, which the service allows admins only.
+func RegisterResetRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/reset_history", h.ResetHistory)
+	r.POST("/resets/confirmations", h.CreateResetConfirmation)
+	r.GET("/resets", h.ListResets)
+	r.POST("/resets/:id/restore", h.RestoreReset)
+}
+
+// RegisterSystemAdminRoutes registers the routes resetting the database,
+// installing content and running stats rollups. r must require the admin
+// role.
+func RegisterSystemAdminRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/full_reset", h.FullReset)
+	r.POST("/system/bootstrap", h.Bootstrap)
+	r.POST("/system/rollup_stats", h.RollupStats)
+	r.GET("/system/seeds", h.ListSeedPacks)
+	r.POST("/system/seeds/:name", h.ApplySeedPack)
+}
+
+// ResetRequest confirms a reset with a token from POST /resets/confirmations
+type ResetRequest struct {
+	ConfirmToken string `json:"confirm_token"`
+}
+
+// ResetConfirmationRequest names the kind of reset to confirm
+type ResetConfirmationRequest struct {
+	// Kind is history or full
+	Kind string `json:"kind" binding:"required"`
+}
+
+// BootstrapRequest optionally overrides the configured starter catalog
+type BootstrapRequest struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+func (h *Handler) Bootstrap(c *gin.Context) {
+	var req BootstrapRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	job, err := h.svcFor(c).EnqueueBootstrap(c.Request.Context(), req.URL, req.SHA256)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// ListSeedPacks lists the seed packs that can be applied
+func (h *Handler) ListSeedPacks(c *gin.Context) {
+	packs, err := h.svc.ListSeedPacks()
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": packs})
+}
+
+// ApplySeedPack queues applying a seed pack, or with ?dry_run=true reports
+// at once what it would change
+func (h *Handler) ApplySeedPack(c *gin.Context) {
+	if c.Query("dry_run") == "true" {
+		result, err := h.svc.ApplySeedPack(c.Request.Context(), c.Param("name"), true)
+		if err != nil {
+			seedPackError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"result":  result,
+		})
+		return
+	}
+
+	job, err := h.svcFor(c).EnqueueSeedPack(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		seedPackError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// seedPackError responds with the error of a seed pack that can't be
+// applied
+func seedPackError(c *gin.Context, err error) {
+	// Packs that can't be installed are well-formed requests
+	if errors.Is(err, service.ErrValidation) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	serviceError(c, err)
+}
+
+// RollupStats queues the nightly stats rollup to run now
+func (h *Handler) RollupStats(c *gin.Context) {
+	job, err := h.svcFor(c).EnqueueStatsRollup(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	accepted(c, job)
+}
+
+// ResetHistory archives the database and deletes the user's study history
+func (h *Handler) ResetHistory(c *gin.Context) {
+	token, ok := bindResetRequest(c)
+	if !ok {
+		return
+	}
+	reset, err := h.svcFor(c).ResetHistory(c.Request.Context(), token)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Study history has been reset",
+		"reset":   reset,
+	})
+}
+
+// FullReset archives the database and deletes the catalog and everyone's
+// study history
+func (h *Handler) FullReset(c *gin.Context) {
+	token, ok := bindResetRequest(c)
+	if !ok {
+		return
+	}
+	reset, err := h.svcFor(c).FullReset(c.Request.Context(), token)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "System has been fully reset",
+		"reset":   reset,
+	})
+}
+
+// bindResetRequest returns the confirmation token of a reset's request,
+// or responds 400 Bad Request to malformed bodies. A missing token is left
+// for the service to reject.
+func bindResetRequest(c *gin.Context) (string, bool) {
+	var req ResetRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return "", false
+		}
+	}
+	return req.ConfirmToken, true
+}
+
+// CreateResetConfirmation gives the user a token to confirm a reset with
+func (h *Handler) CreateResetConfirmation(c *gin.Context) {
+	var req ResetConfirmationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmation, err := h.svcFor(c).CreateResetConfirmation(c.Request.Context(), req.Kind)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, confirmation)
+}
+
+// ListResets lists the user's history resets and, to admins, the full
+// resets, newest first
+func (h *Handler) ListResets(c *gin.Context) {
+	resets, err := h.svcFor(c).ListResets(c.Request.Context())
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": resets})
+}
+
+// RestoreReset puts back what a reset deleted from its archive
+func (h *Handler) RestoreReset(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	reset, err := h.svcFor(c).RestoreReset(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"reset":   reset,
+	})
+}
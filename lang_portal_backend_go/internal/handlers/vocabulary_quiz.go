@@ -0,0 +1,1213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lang_portal/internal/models"
+	"lang_portal/internal/selection"
+	"lang_portal/internal/service"
+)
+
+// QuizDifficulty represents the difficulty level of the quiz
+type QuizDifficulty string
+
+const (
+	Easy   QuizDifficulty = "easy"
+	Medium QuizDifficulty = "medium"
+	Hard   QuizDifficulty = "hard"
+)
+
+// difficultyPoints is what a correct answer is worth at each difficulty
+var difficultyPoints = map[QuizDifficulty]int{
+	Easy:   1,
+	Medium: 2,
+	Hard:   3,
+}
+
+// directionBonus is added to the points of a correct answer in directions
+// where the learner recalls the word without seeing it written in Urdu
+var directionBonus = map[string]int{
+	QuizDirectionEnglishToUrdu:  1,
+	QuizDirectionAudioToEnglish: 1,
+	QuizDirectionImageToUrdu:    1,
+}
+
+// distractorPoolSize caps how many candidate words are fetched for options
+const distractorPoolSize = 20
+
+// QuizConfig represents the configuration for a quiz
+type QuizConfig struct {
+	GroupID    int64          `json:"group_id" binding:"required"`
+	Difficulty QuizDifficulty `json:"difficulty" binding:"required"`
+	WordCount  int           `json:"word_count" binding:"required,min=5,max=20"`
+}
+
+// StartQuizRequest represents the request body for starting a quiz
+type StartQuizRequest struct {
+	GroupID  int64 `json:"group_id" binding:"required"`
+	WordCount int  `json:"word_count" binding:"required,min=5,max=20"`
+	// Difficulty shapes how the incorrect options are picked; defaults to medium
+	Difficulty QuizDifficulty `json:"difficulty"`
+	// Direction is what is shown and what is asked; defaults to urdu_to_english
+	Direction string `json:"direction"`
+	// AnswerMode is multiple_choice (default) or typed
+	AnswerMode string `json:"answer_mode"`
+	// Selection is how words are favoured: random, weak, stale, new or
+	// balanced (default)
+	Selection string `json:"selection"`
+	// Cooldown is how many previous quizzes of the group have their words
+	// avoided; defaults to 1, 0 allows repeats
+	Cooldown *int `json:"cooldown" binding:"omitempty,min=0,max=20"`
+}
+
+// defaultQuizCooldown avoids repeating the words of the previous quiz
+const defaultQuizCooldown = 1
+
+// quizSettings are the choices a quiz session's questions are built with
+type quizSettings struct {
+	Difficulty QuizDifficulty
+	Direction  string
+	AnswerMode string
+}
+
+// validAnswerMode reports whether mode is a known answer mode
+func validAnswerMode(mode string) bool {
+	return mode == service.AnswerModeMultipleChoice || mode == service.AnswerModeTyped
+}
+
+// Quiz directions
+const (
+	// QuizDirectionUrduToEnglish shows the Urdu word and asks for its English meaning
+	QuizDirectionUrduToEnglish = "urdu_to_english"
+	// QuizDirectionEnglishToUrdu shows the English meaning and asks for the Urdu word
+	QuizDirectionEnglishToUrdu = "english_to_urdu"
+	// QuizDirectionUrdlishToUrdu shows the transliteration and asks for the Urdu script
+	QuizDirectionUrdlishToUrdu = "urdlish_to_urdu"
+	// QuizDirectionAudioToEnglish plays the word's audio and asks for its English meaning
+	QuizDirectionAudioToEnglish = "audio_to_english"
+	// QuizDirectionImageToUrdu shows the word's picture and asks for the Urdu word
+	QuizDirectionImageToUrdu = "image_to_urdu"
+)
+
+// validQuizDirection reports whether direction is a known quiz direction
+func validQuizDirection(direction string) bool {
+	switch direction {
+	case QuizDirectionUrduToEnglish, QuizDirectionEnglishToUrdu,
+		QuizDirectionUrdlishToUrdu, QuizDirectionAudioToEnglish,
+		QuizDirectionImageToUrdu:
+		return true
+	}
+	return false
+}
+
+// quizPrompt returns the text shown for a word; audio and picture questions
+// have none
+func quizPrompt(word *models.WordResponse, direction string) string {
+	switch direction {
+	case QuizDirectionEnglishToUrdu:
+		return word.English
+	case QuizDirectionUrdlishToUrdu:
+		return word.Urdlish
+	case QuizDirectionAudioToEnglish, QuizDirectionImageToUrdu:
+		return ""
+	default:
+		return word.Urdu
+	}
+}
+
+// quizAnswer returns the option that answers a question about word
+func quizAnswer(word *models.WordResponse, direction string) string {
+	switch direction {
+	case QuizDirectionEnglishToUrdu, QuizDirectionUrdlishToUrdu, QuizDirectionImageToUrdu:
+		return word.Urdu
+	default:
+		return word.English
+	}
+}
+
+// QuizWord represents a word in the quiz with multiple choice options. Only
+// what the question's direction shows is sent, so the response doesn't give
+// the answer away: the prompt, or the audio or picture it is asked from.
+type QuizWord struct {
+	WordID     int64    `json:"word_id"`
+	Prompt     string   `json:"prompt,omitempty"`
+	Options    []string `json:"options"`
+	Direction  string   `json:"direction"`
+	AnswerMode string   `json:"answer_mode"`
+	AudioURL   string   `json:"audio_url,omitempty"`
+	ImageURL   string   `json:"image_url,omitempty"`
+}
+
+// newQuizWord returns the question asked about a word in a direction, with
+// the word's audio or picture only when the direction plays or shows it
+func newQuizWord(word *models.WordResponse, direction, answerMode string, options []string, audioURL, imageURL string) *QuizWord {
+	quizWord := &QuizWord{
+		WordID:     word.ID,
+		Prompt:     quizPrompt(word, direction),
+		Options:    options,
+		Direction:  direction,
+		AnswerMode: answerMode,
+	}
+	switch direction {
+	case QuizDirectionAudioToEnglish:
+		quizWord.AudioURL = audioURL
+	case QuizDirectionImageToUrdu:
+		quizWord.ImageURL = imageURL
+	}
+	return quizWord
+}
+
+// QuizScore represents the score for a quiz session
+type QuizScore struct {
+	SessionID    int64   `json:"session_id"`
+	TotalWords   int     `json:"total_words"`
+	CorrectCount int     `json:"correct_count"`
+	Accuracy     float64 `json:"accuracy"`
+	Difficulty   string  `json:"difficulty"`
+	Direction    string  `json:"direction"`
+	// NearMissCount counts typed answers accepted despite a small typo
+	NearMissCount int    `json:"near_miss_count"`
+	// HintsUsed counts the hints taken on correctly answered questions
+	HintsUsed    int     `json:"hints_used"`
+	Points       int     `json:"points"`
+	// Questions breaks the score down per question
+	Questions []models.QuizQuestionResult `json:"questions,omitempty"`
+}
+
+// QuizAnswer represents a submitted answer for the vocabulary quiz. The
+// server decides whether it is correct.
+type QuizAnswer struct {
+	WordID    int64  `json:"word_id" binding:"required"`
+	SessionID int64  `json:"session_id" binding:"required"`
+	Answer    string `json:"answer" binding:"required"`
+}
+
+func init() {
+	RegisterActivity(service.VocabularyQuizActivity, func(h *Handler) Activity {
+		return &vocabularyQuiz{Handler: h}
+	})
+}
+
+// vocabularyQuiz is the vocabulary quiz study activity
+type vocabularyQuiz struct {
+	*Handler
+}
+
+// defaultQuizWordCount is how many words a quiz created through the generic
+// study session endpoints asks
+const defaultQuizWordCount = 10
+
+// Name returns the vocabulary quiz's study activity name
+func (q *vocabularyQuiz) Name() string {
+	return service.VocabularyQuizActivity
+}
+
+// Routes registers all routes for vocabulary quiz
+func (q *vocabularyQuiz) Routes(r *gin.RouterGroup) {
+	quiz := r.Group("/vocabulary-quiz")
+	{
+		quiz.POST("/start", q.StartQuiz)
+		quiz.GET("/words/:session_id", q.GetQuizWords)
+		quiz.POST("/answer", q.SubmitQuizAnswer)
+		quiz.GET("/score/:session_id", q.GetQuizScore)
+		quiz.GET("/history", q.GetQuizHistory)
+		quiz.POST("/:session_id/retry", q.RetryQuiz)
+		quiz.GET("/:session_id/hint/:word_id", q.GetQuizHint)
+	}
+}
+
+// SessionCreated narrows a session created with all of its group's words
+// down to a quiz's worth, picked as StartQuiz does with its defaults. The
+// questions are generated when the words are first fetched.
+func (q *vocabularyQuiz) SessionCreated(ctx context.Context, session *models.StudySessionResponse) error {
+	groupWords, err := q.allGroupWords(ctx, session.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group words: %v", err)
+	}
+	words, err := q.pickQuizWords(ctx, session.GroupID, groupWords,
+		defaultQuizWordCount, selection.Balanced, defaultQuizCooldown, session.ID)
+	if err != nil {
+		return err
+	}
+
+	wordIDs := make([]int64, len(words))
+	for i, word := range words {
+		wordIDs[i] = word.ID
+	}
+	if err := q.svc.AddWordsToStudySession(ctx, session.ID, wordIDs); err != nil {
+		return err
+	}
+	return q.svc.SetStudySessionDifficulty(ctx, session.ID, string(Medium))
+}
+
+// Score returns the score of a quiz session
+func (q *vocabularyQuiz) Score(ctx context.Context, sessionID int64) (*models.SessionScore, error) {
+	score, err := q.quizScore(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SessionScore{
+		SessionID:    sessionID,
+		Activity:     service.VocabularyQuizActivity,
+		TotalCount:   score.TotalWords,
+		CorrectCount: score.CorrectCount,
+		Accuracy:     score.Accuracy,
+		Points:       score.Points,
+		Details:      score,
+	}, nil
+}
+
+// StartQuiz starts a new vocabulary quiz session
+func (h *Handler) StartQuiz(c *gin.Context) {
+	var req StartQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = Medium
+	}
+	if _, ok := difficultyPoints[req.Difficulty]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+
+	if req.Direction == "" {
+		req.Direction = QuizDirectionUrduToEnglish
+	}
+	if !validQuizDirection(req.Direction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported quiz direction"})
+		return
+	}
+
+	if req.AnswerMode == "" {
+		req.AnswerMode = service.AnswerModeMultipleChoice
+	}
+	if !validAnswerMode(req.AnswerMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "answer mode must be multiple_choice or typed"})
+		return
+	}
+
+	strategy, err := selection.ParseStrategy(req.Selection)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get words from the group
+	allWords, err := h.forRequest(c).allGroupWords(c.Request.Context(), req.GroupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get group words: %v", err)})
+		return
+	}
+
+	if len(allWords) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No words found in the group"})
+		return
+	}
+
+	// Audio questions can only be asked about words with a recording
+	if req.Direction == QuizDirectionAudioToEnglish {
+		withAudio := make([]models.WordResponse, 0, len(allWords))
+		for _, word := range allWords {
+			audioURL, err := h.svcFor(c).GetWordAudioURL(c.Request.Context(), word.ID)
+			if err != nil {
+				serviceError(c, err)
+				return
+			}
+			if audioURL != "" {
+				withAudio = append(withAudio, word)
+			}
+		}
+		if len(withAudio) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No words with audio found in the group"})
+			return
+		}
+		allWords = withAudio
+	}
+
+	// Picture questions can only be asked about words with a picture
+	if req.Direction == QuizDirectionImageToUrdu {
+		withImage := make([]models.WordResponse, 0, len(allWords))
+		for _, word := range allWords {
+			image, err := h.svcFor(c).GetWordImage(c.Request.Context(), word.ID)
+			if err != nil {
+				serviceError(c, err)
+				return
+			}
+			if image != nil {
+				withImage = append(withImage, word)
+			}
+		}
+		if len(withImage) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No words with pictures found in the group"})
+			return
+		}
+		allWords = withImage
+	}
+
+	// Select the requested number of words
+	wordCount := req.WordCount
+	if wordCount <= 0 {
+		wordCount = 10 // Default to 10 words
+	}
+
+	// Pick words at random, favouring those the learner needs to practise
+	cooldown := defaultQuizCooldown
+	if req.Cooldown != nil {
+		cooldown = *req.Cooldown
+	}
+	selectedWords, err := h.forRequest(c).pickQuizWords(c.Request.Context(), req.GroupID, allWords, wordCount, strategy, cooldown, 0)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	// Create a new study session
+	session, err := h.svcFor(c).CreateStudySessionWithActivity(c.Request.Context(), req.GroupID, service.VocabularyQuizActivity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create study session: %v", err)})
+		return
+	}
+
+	// Add words to study session
+	wordIDs := make([]int64, len(selectedWords))
+	for i, word := range selectedWords {
+		wordIDs[i] = word.ID
+	}
+
+	err = h.svcFor(c).AddWordsToStudySession(c.Request.Context(), session.ID, wordIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to add words to session: %v", err)})
+		return
+	}
+
+	// Remember the difficulty so options and scoring stay consistent
+	if err := h.svcFor(c).SetStudySessionDifficulty(c.Request.Context(), session.ID, string(req.Difficulty)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to set difficulty: %v", err)})
+		return
+	}
+
+	// Generate the questions once so every fetch serves the same options
+	if _, err := h.forRequest(c).createQuizQuestions(c.Request.Context(), session.ID, selectedWords, selectedWords, req.GroupID, quizSettings{
+		Difficulty: req.Difficulty,
+		Direction:  req.Direction,
+		AnswerMode: req.AnswerMode,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create questions: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": session.ID,
+		"word_count": len(selectedWords),
+		"difficulty": req.Difficulty,
+		"direction":  req.Direction,
+		"answer_mode": req.AnswerMode,
+	})
+}
+
+// GetQuizWords returns a list of words for a quiz
+func (h *Handler) GetQuizWords(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetStudySession(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	difficulty, err := h.forRequest(c).sessionDifficulty(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	// Get all words for this session
+	reviewItems, err := h.svcFor(c).GetStudySessionWords(c.Request.Context(), sessionID, 1, true) // true to include word data
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	wordResponses := reviewItems.Items.([]models.WordResponse)
+
+	questions, err := h.svcFor(c).GetQuizQuestions(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	// Sessions started before questions were stored get theirs generated now
+	if len(questions) == 0 {
+		quizWords, err := h.forRequest(c).createQuizQuestions(c.Request.Context(), sessionID, wordResponses, wordResponses, session.GroupID, quizSettings{
+			Difficulty: difficulty,
+			Direction:  QuizDirectionUrduToEnglish,
+			AnswerMode: service.AnswerModeMultipleChoice,
+		})
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, quizWords)
+		return
+	}
+
+	wordsByID := make(map[int64]models.WordResponse, len(wordResponses))
+	for _, word := range wordResponses {
+		wordsByID[word.ID] = word
+	}
+
+	quizWords := make([]QuizWord, 0, len(questions))
+	for _, question := range questions {
+		word, ok := wordsByID[question.WordID]
+		if !ok {
+			continue
+		}
+		quizWord, err := h.forRequest(c).quizWordFromQuestion(c.Request.Context(), word, &question)
+		if err != nil {
+			serviceError(c, err)
+			return
+		}
+		quizWords = append(quizWords, *quizWord)
+	}
+
+	c.JSON(http.StatusOK, quizWords)
+}
+
+// pickQuizWords picks count of words at random, favouring those the learner
+// needs to practise. Words from the group's last cooldown quizzes, other than
+// the session being prepared, are only used once the rest run out.
+func (h *Handler) pickQuizWords(ctx context.Context, groupID int64, words []models.WordResponse, count int, strategy selection.Strategy, cooldown int, sessionID int64) ([]models.WordResponse, error) {
+	lastReviewed, err := h.svc.GetGroupLastReviewed(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := h.svc.GetRecentlyQuizzedWords(ctx, groupID, service.VocabularyQuizActivity, cooldown, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	wordsByID := make(map[int64]models.WordResponse, len(words))
+	var fresh, cooling []selection.Candidate
+	for _, word := range words {
+		candidate := selection.Candidate{
+			ID:           word.ID,
+			CorrectCount: word.CorrectCount,
+			WrongCount:   word.WrongCount,
+		}
+		if reviewedAt, ok := lastReviewed[word.ID]; ok {
+			candidate.LastReviewedAt = &reviewedAt
+		}
+		if recent[word.ID] {
+			cooling = append(cooling, candidate)
+		} else {
+			fresh = append(fresh, candidate)
+		}
+		wordsByID[word.ID] = word
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	now := time.Now()
+	selectedIDs := selection.Pick(fresh, count, strategy, now, rng)
+	selectedIDs = append(selectedIDs, selection.Pick(cooling, count-len(selectedIDs), strategy, now, rng)...)
+	selected := make([]models.WordResponse, 0, len(selectedIDs))
+	for _, id := range selectedIDs {
+		selected = append(selected, wordsByID[id])
+	}
+	// Ask them in random order rather than most-needed first
+	rng.Shuffle(len(selected), func(i, j int) {
+		selected[i], selected[j] = selected[j], selected[i]
+	})
+	return selected, nil
+}
+
+// allGroupWords returns every word of a group, reading all of its pages
+func (h *Handler) allGroupWords(ctx context.Context, groupID int64) ([]models.WordResponse, error) {
+	var words []models.WordResponse
+	for page := 1; ; page++ {
+		groupWords, err := h.svc.GetGroupWords(ctx, groupID, page, service.SortID)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, groupWords.Items.([]models.WordResponse)...)
+		if page >= groupWords.Pagination.TotalPages {
+			return words, nil
+		}
+	}
+}
+
+// createQuizQuestions generates and stores a question for every word of a
+// quiz session, drawing options from pool. If a question was already stored
+// for a word, e.g. by a concurrent request, the stored one is returned instead.
+func (h *Handler) createQuizQuestions(ctx context.Context, sessionID int64, words []models.WordResponse, pool []models.WordResponse, groupID int64, settings quizSettings) ([]QuizWord, error) {
+	quizWords := make([]QuizWord, len(words))
+	for i, word := range words {
+		quizWord, err := h.buildQuizWord(ctx, word, pool, groupID, settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build question for word %d: %v", word.ID, err)
+		}
+
+		// Keep the question so the answer can be checked on submit
+		question := &models.QuizQuestion{
+			StudySessionID: sessionID,
+			WordID:         word.ID,
+			Direction:      quizWord.Direction,
+			AnswerMode:     quizWord.AnswerMode,
+			Options:        quizWord.Options,
+			CorrectAnswer:  quizAnswer(&word, settings.Direction),
+		}
+		if err := h.svc.SaveQuizQuestion(ctx, question); err != nil {
+			return nil, err
+		}
+		quizWords[i] = *newQuizWord(&word, question.Direction, question.AnswerMode, question.Options, quizWord.AudioURL, quizWord.ImageURL)
+	}
+	return quizWords, nil
+}
+
+// quizWordFromQuestion rebuilds a stored question as it is served
+func (h *Handler) quizWordFromQuestion(ctx context.Context, word models.WordResponse, question *models.QuizQuestion) (*QuizWord, error) {
+	audioURL, err := h.svc.GetWordAudioURL(ctx, word.ID)
+	if err != nil {
+		return nil, err
+	}
+	imageURL, err := h.wordImageURL(ctx, word.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuizWord(&word, question.Direction, question.AnswerMode, question.Options, audioURL, imageURL), nil
+}
+
+// RetryQuiz starts a follow-up round over the words answered incorrectly in a
+// quiz session, asked the same way as the original
+func (h *Handler) RetryQuiz(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	settings, err := h.forRequest(c).sessionSettings(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	// Options come from the original round so a short retry still has enough
+	originalWords, err := h.svcFor(c).GetStudySessionWords(c.Request.Context(), sessionID, 1, true)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	session, words, err := h.svcFor(c).CreateRetrySession(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	pool := originalWords.Items.([]models.WordResponse)
+	if _, err := h.forRequest(c).createQuizQuestions(c.Request.Context(), session.ID, words, pool, session.GroupID, settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create questions: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":        session.ID,
+		"parent_session_id": sessionID,
+		"word_count":        len(words),
+		"difficulty":        settings.Difficulty,
+		"direction":         settings.Direction,
+		"answer_mode":       settings.AnswerMode,
+	})
+}
+
+// sessionSettings returns the settings a quiz session's questions were
+// built with
+func (h *Handler) sessionSettings(ctx context.Context, sessionID int64) (quizSettings, error) {
+	difficulty, err := h.sessionDifficulty(ctx, sessionID)
+	if err != nil {
+		return quizSettings{}, err
+	}
+
+	settings := quizSettings{
+		Difficulty: difficulty,
+		Direction:  QuizDirectionUrduToEnglish,
+		AnswerMode: service.AnswerModeMultipleChoice,
+	}
+	questions, err := h.svc.GetQuizQuestions(ctx, sessionID)
+	if err != nil {
+		return quizSettings{}, err
+	}
+	if len(questions) > 0 {
+		settings.Direction = questions[0].Direction
+		settings.AnswerMode = questions[0].AnswerMode
+	}
+	return settings, nil
+}
+
+// sessionDifficulty returns the difficulty a quiz session was started with.
+// Sessions from before difficulties were recorded play as medium.
+func (h *Handler) sessionDifficulty(ctx context.Context, sessionID int64) (QuizDifficulty, error) {
+	difficulty, err := h.svc.GetStudySessionDifficulty(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := difficultyPoints[QuizDifficulty(difficulty)]; !ok {
+		return Medium, nil
+	}
+	return QuizDifficulty(difficulty), nil
+}
+
+// buildQuizWord builds the question a learner sees for a word. Incorrect
+// options come from the group's pool or elsewhere depending on difficulty;
+// typed questions have no options.
+func (h *Handler) buildQuizWord(ctx context.Context, word models.WordResponse, pool []models.WordResponse, groupID int64, settings quizSettings) (*QuizWord, error) {
+	direction := settings.Direction
+	selectedOptions := []string{}
+	if settings.AnswerMode != service.AnswerModeTyped {
+		// Get incorrect options for this word
+		incorrectOptions, err := h.getOptionsForDifficulty(ctx, &word, pool, groupID, settings.Difficulty, direction)
+		if err != nil {
+			return nil, err
+		}
+
+		// Create final list of options including the correct answer
+		selectedOptions = append([]string{quizAnswer(&word, direction)}, incorrectOptions...)
+
+		// Final shuffle of all options
+		rand.Shuffle(len(selectedOptions), func(i, j int) {
+			selectedOptions[i], selectedOptions[j] = selectedOptions[j], selectedOptions[i]
+		})
+	}
+
+	audioURL, err := h.svc.GetWordAudioURL(ctx, word.ID)
+	if err != nil {
+		return nil, err
+	}
+	if direction == QuizDirectionAudioToEnglish && audioURL == "" {
+		return nil, fmt.Errorf("word %d has no audio", word.ID)
+	}
+	imageURL, err := h.wordImageURL(ctx, word.ID)
+	if err != nil {
+		return nil, err
+	}
+	if direction == QuizDirectionImageToUrdu && imageURL == "" {
+		return nil, fmt.Errorf("word %d has no picture", word.ID)
+	}
+
+	return newQuizWord(&word, direction, settings.AnswerMode, selectedOptions, audioURL, imageURL), nil
+}
+
+// wordImageURL returns where the picture of a word can be seen, or an empty
+// string when it has none. Questions show the thumbnail, which loads faster.
+func (h *Handler) wordImageURL(ctx context.Context, wordID int64) (string, error) {
+	image, err := h.svc.GetWordImage(ctx, wordID)
+	if err != nil || image == nil {
+		return "", err
+	}
+	return image.ThumbnailURL, nil
+}
+
+// GetQuizScore returns the score for a quiz session
+func (h *Handler) GetQuizScore(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	score, err := h.forRequest(c).quizScore(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, score)
+}
+
+// quizScore scores a quiz session from the answers submitted to its questions
+func (h *Handler) quizScore(ctx context.Context, sessionID int64) (*QuizScore, error) {
+	difficulty, err := h.sessionDifficulty(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Score the answers recorded for the session's questions
+	results, err := h.svc.GetQuizResults(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every question of a session is asked in the same direction
+	direction := QuizDirectionUrduToEnglish
+	totalWords, correctCount, nearMissCount, hintsUsed := 0, 0, 0, 0
+	if len(results) > 0 {
+		direction = results[0].Direction
+		totalWords = len(results)
+		for _, result := range results {
+			switch result.Result {
+			case service.QuizResultCorrect:
+				correctCount++
+				hintsUsed += result.HintsUsed
+			case service.QuizResultNearMiss:
+				correctCount++
+				nearMissCount++
+				hintsUsed += result.HintsUsed
+			}
+		}
+	} else {
+		// Sessions from before questions were stored only have review items
+		totalWords, correctCount, err = h.scoreFromReviewItems(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Unanswered questions count against accuracy
+	var accuracy float64
+	if totalWords > 0 {
+		accuracy = float64(correctCount) / float64(totalWords)
+	}
+
+	return &QuizScore{
+		SessionID:    sessionID,
+		TotalWords:   totalWords,
+		CorrectCount: correctCount,
+		Accuracy:     accuracy,
+		Difficulty:   string(difficulty),
+		Direction:    direction,
+		NearMissCount: nearMissCount,
+		HintsUsed:    hintsUsed,
+		Points:       quizPoints(correctCount, hintsUsed, difficulty, direction),
+		Questions:    results,
+	}, nil
+}
+
+// scoreFromReviewItems counts a session's words and correct review items
+func (h *Handler) scoreFromReviewItems(ctx context.Context, sessionID int64) (int, int, error) {
+	// Get the words asked in this session
+	sessionWords, err := h.svc.GetStudySessionWords(ctx, sessionID, 1, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Get all review items for this session
+	reviewItems, err := h.svc.GetStudySessionWords(ctx, sessionID, 1, false) // false since we don't need word data
+	if err != nil {
+		return 0, 0, err
+	}
+
+	correctCount := 0
+	for _, item := range reviewItems.Items.([]models.WordReviewItem) {
+		if item.Correct {
+			correctCount++
+		}
+	}
+	return sessionWords.Pagination.TotalItems, correctCount, nil
+}
+
+// quizTrendWindow is how many recent quizzes are compared with the ones
+// before them to compute the accuracy trend
+const quizTrendWindow = 5
+
+// defaultQuizHistoryLimit is how many quizzes are listed when no limit is given
+const defaultQuizHistoryLimit = 20
+
+// QuizHistory lists a group's past quizzes with the learner's best result
+type QuizHistory struct {
+	GroupID      int64                     `json:"group_id"`
+	TotalQuizzes int                       `json:"total_quizzes"`
+	Quizzes      []models.QuizHistoryEntry `json:"quizzes"`
+	PersonalBest *models.QuizHistoryEntry  `json:"personal_best,omitempty"`
+	// AccuracyTrend is the average accuracy of the latest quizzes minus that
+	// of the quizzes before them; positive means improving
+	AccuracyTrend *float64 `json:"accuracy_trend,omitempty"`
+}
+
+// GetQuizHistory returns past quiz scores for a group, the personal best and
+// the accuracy trend. Retry rounds are listed but left out of the best and
+// the trend, since they only repeat missed words.
+func (h *Handler) GetQuizHistory(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Query("group_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group id"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultQuizHistoryLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	entries, err := h.svcFor(c).GetQuizHistory(c.Request.Context(), groupID, service.VocabularyQuizActivity)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+
+	history := QuizHistory{
+		GroupID:      groupID,
+		TotalQuizzes: len(entries),
+		Quizzes:      []models.QuizHistoryEntry{},
+	}
+
+	// Entries are most recent first
+	var full []float64
+	for i := range entries {
+		entry := &entries[i]
+		difficulty := QuizDifficulty(entry.Difficulty)
+		if _, ok := difficultyPoints[difficulty]; !ok {
+			difficulty = Medium
+		}
+		if entry.Direction == "" {
+			entry.Direction = QuizDirectionUrduToEnglish
+		}
+		entry.Difficulty = string(difficulty)
+		entry.Points = quizPoints(entry.CorrectCount, entry.HintsUsed, difficulty, entry.Direction)
+
+		if entry.ParentSessionID != nil || entry.TotalWords == 0 {
+			continue
+		}
+		full = append(full, entry.Accuracy)
+		best := history.PersonalBest
+		if best == nil || entry.Accuracy > best.Accuracy ||
+			(entry.Accuracy == best.Accuracy && entry.Points > best.Points) {
+			history.PersonalBest = entry
+		}
+	}
+
+	if len(full) > quizTrendWindow {
+		recent := full[:quizTrendWindow]
+		earlier := full[quizTrendWindow:]
+		if len(earlier) > quizTrendWindow {
+			earlier = earlier[:quizTrendWindow]
+		}
+		trend := average(recent) - average(earlier)
+		history.AccuracyTrend = &trend
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	history.Quizzes = append(history.Quizzes, entries...)
+
+	c.JSON(http.StatusOK, history)
+}
+
+// average returns the mean of values
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// getOptionsForDifficulty returns incorrect options for a quiz word. Easy
+// quizzes draw them from other groups, medium quizzes from the word's own
+// group, and hard quizzes from the words whose embeddings are closest to the
+// word's. Hard questions about words without an embedding use words of the
+// same part of speech and the keyword heuristic instead.
+func (h *Handler) getOptionsForDifficulty(ctx context.Context, word *models.WordResponse, groupWords []models.WordResponse, groupID int64, difficulty QuizDifficulty, direction string) ([]string, error) {
+	switch difficulty {
+	case Easy:
+		otherWords, err := h.svc.GetWordsOutsideGroup(ctx, groupID, distractorPoolSize)
+		if err != nil {
+			return nil, err
+		}
+		// Small catalogs may not have enough words outside the group
+		return pickOptions(word, direction, otherWords, shuffle(groupWords)), nil
+	case Hard:
+		sameType, err := h.svc.GetWordsOfSameType(ctx, word.ID, distractorPoolSize)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := append(append([]models.WordResponse{}, groupWords...), sameType...)
+		similar, err := h.svc.GetSimilarWords(ctx, word.ID, candidates, distractorPoolSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(similar) > 0 {
+			return pickOptions(word, direction, similar, sameType, shuffle(groupWords)), nil
+		}
+
+		related, err := h.getIncorrectOptions(word, groupWords)
+		if err != nil {
+			return nil, err
+		}
+		// Related options are English meanings; map them back to words so
+		// the option can be shown in the direction's answer language
+		relatedWords := make([]models.WordResponse, 0, len(related))
+		for _, english := range related {
+			for _, w := range groupWords {
+				if w.English == english {
+					relatedWords = append(relatedWords, w)
+					break
+				}
+			}
+		}
+		return pickOptions(word, direction, sameType, relatedWords), nil
+	default:
+		return pickOptions(word, direction, shuffle(groupWords)), nil
+	}
+}
+
+// pickOptions takes up to three distinct incorrect options from the
+// candidate lists, in order
+func pickOptions(word *models.WordResponse, direction string, candidates ...[]models.WordResponse) []string {
+	used := map[string]bool{quizAnswer(word, direction): true}
+	options := make([]string, 0, 3)
+	for _, list := range candidates {
+		for i := range list {
+			if len(options) >= 3 {
+				return options
+			}
+			option := quizAnswer(&list[i], direction)
+			if list[i].ID == word.ID || used[option] {
+				continue
+			}
+			options = append(options, option)
+			used[option] = true
+		}
+	}
+	return options
+}
+
+// getIncorrectOptions returns a list of incorrect options for a quiz word,
+// preferring words that look related by English keywords. It is the fallback
+// for words without an embedding.
+func (h *Handler) getIncorrectOptions(word *models.WordResponse, allWords []models.WordResponse) ([]string, error) {
+    // Create a map to track used English translations
+    usedTranslations := make(map[string]bool)
+    usedTranslations[word.English] = true // Mark correct answer as used
+
+    // Get semantically related words based on word type and common terms
+    var relatedWords []models.WordResponse
+    wordLower := strings.ToLower(word.English)
+    
+    // Check for family relationships
+    if strings.Contains(wordLower, "brother") || strings.Contains(wordLower, "sister") ||
+        strings.Contains(wordLower, "mother") || strings.Contains(wordLower, "father") ||
+        strings.Contains(wordLower, "aunt") || strings.Contains(wordLower, "uncle") ||
+        strings.Contains(wordLower, "cousin") || strings.Contains(wordLower, "son") ||
+        strings.Contains(wordLower, "daughter") || strings.Contains(wordLower, "husband") ||
+        strings.Contains(wordLower, "wife") || strings.Contains(wordLower, "parent") ||
+        strings.Contains(wordLower, "child") || strings.Contains(wordLower, "family") {
+        // Find other family-related words
+        for _, w := range allWords {
+            wLower := strings.ToLower(w.English)
+            if (strings.Contains(wLower, "brother") || strings.Contains(wLower, "sister") ||
+                strings.Contains(wLower, "mother") || strings.Contains(wLower, "father") ||
+                strings.Contains(wLower, "aunt") || strings.Contains(wLower, "uncle") ||
+                strings.Contains(wLower, "cousin") || strings.Contains(wLower, "son") ||
+                strings.Contains(wLower, "daughter") || strings.Contains(wLower, "husband") ||
+                strings.Contains(wLower, "wife") || strings.Contains(wLower, "parent") ||
+                strings.Contains(wLower, "child") || strings.Contains(wLower, "family")) &&
+                w.ID != word.ID {
+                relatedWords = append(relatedWords, w)
+            }
+        }
+    } else if strings.HasPrefix(wordLower, "to ") {
+        // For verbs, find other verbs
+        for _, w := range allWords {
+            if strings.HasPrefix(strings.ToLower(w.English), "to ") && w.ID != word.ID {
+                relatedWords = append(relatedWords, w)
+            }
+        }
+    } else if strings.Contains(wordLower, "room") || strings.Contains(wordLower, "house") ||
+        strings.Contains(wordLower, "building") || strings.Contains(wordLower, "door") ||
+        strings.Contains(wordLower, "window") || strings.Contains(wordLower, "wall") ||
+        strings.Contains(wordLower, "floor") || strings.Contains(wordLower, "ceiling") {
+        // Find other house/building related words
+        for _, w := range allWords {
+            wLower := strings.ToLower(w.English)
+            if (strings.Contains(wLower, "room") || strings.Contains(wLower, "house") ||
+                strings.Contains(wLower, "building") || strings.Contains(wLower, "door") ||
+                strings.Contains(wLower, "window") || strings.Contains(wLower, "wall") ||
+                strings.Contains(wLower, "floor") || strings.Contains(wLower, "ceiling")) &&
+                w.ID != word.ID {
+                relatedWords = append(relatedWords, w)
+            }
+        }
+    }
+
+    // Create a list of incorrect options
+    incorrectOptions := make([]string, 0, 3)
+
+    // Add related options first
+    relatedWords = shuffle(relatedWords)
+    for _, w := range relatedWords {
+        if len(incorrectOptions) >= 3 {
+            break
+        }
+        if !usedTranslations[w.English] {
+            incorrectOptions = append(incorrectOptions, w.English)
+            usedTranslations[w.English] = true
+        }
+    }
+
+    // If we still need more options, add some random ones
+    if len(incorrectOptions) < 3 {
+        shuffledWords := shuffle(allWords)
+        for _, w := range shuffledWords {
+            if len(incorrectOptions) >= 3 {
+                break
+            }
+            if !usedTranslations[w.English] {
+                incorrectOptions = append(incorrectOptions, w.English)
+                usedTranslations[w.English] = true
+            }
+        }
+    }
+
+    return incorrectOptions, nil
+}
+
+// isNoun checks if a word is likely a noun based on common patterns
+func isNoun(word string) bool {
+	// Skip common prefixes that indicate non-nouns
+	commonPrefixes := []string{
+		"to ", "is ", "are ", "was ", "were ",
+		"this ", "that ", "these ", "those ",
+		"my ", "your ", "his ", "her ", "its ", "our ", "their ",
+		"a ", "an ", "the ",
+		"in ", "on ", "at ", "by ", "for ", "with ", "from ",
+		"and ", "or ", "but ", "if ", "when ", "where ", "how ",
+		"what ", "who ", "whom ", "whose ", "which ",
+		"yes ", "no ", "okay ", "please ", "thank ",
+	}
+
+	word = strings.ToLower(word)
+	for _, prefix := range commonPrefixes {
+		if strings.HasPrefix(word, prefix) {
+			return false
+		}
+	}
+
+	// Check for pronouns and common non-nouns
+	pronouns := []string{
+		"i", "you", "he", "she", "it", "we", "they",
+		"me", "him", "her", "us", "them",
+		"this", "that", "these", "those",
+		"who", "what", "where", "when", "why", "how",
+	}
+
+	for _, pronoun := range pronouns {
+		if word == pronoun {
+			return false
+		}
+	}
+
+	// Check for family relation terms
+	familyTerms := []string{
+		"mother", "father", "sister", "brother",
+		"aunt", "uncle", "grandfather", "grandmother",
+		"son", "daughter", "cousin", "wife", "husband",
+		"parent", "child", "sibling",
+	}
+
+	for _, term := range familyTerms {
+		if strings.Contains(word, term) {
+			return true
+		}
+	}
+
+	// Common object words are likely nouns
+	objectWords := []string{
+		"table", "chair", "bed", "door", "window",
+		"phone", "book", "pen", "pencil", "paper",
+		"plate", "cup", "glass", "spoon", "fork", "knife",
+		"room", "house", "car", "bike", "computer",
+		"television", "radio", "clock", "watch", "camera",
+		"key", "lock", "bowl", "utensil", "fan",
+		"ceiling", "floor", "wall", "roof", "door",
+		"cupboard", "drawer", "shelf", "mirror", "picture",
+		"mobile", "phone", "laptop", "tablet", "screen",
+	}
+
+	for _, obj := range objectWords {
+		if strings.Contains(word, obj) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPronoun checks if a word is a pronoun
+func isPronoun(word string) bool {
+	pronouns := []string{
+		"i", "you", "he", "she", "it", "we", "they",
+		"me", "him", "her", "us", "them",
+		"this", "that", "these", "those",
+		"who", "what", "where", "when", "why", "how",
+		"my", "your", "his", "her", "its", "our", "their",
+		"mine", "yours", "hers", "ours", "theirs",
+	}
+
+	word = strings.ToLower(word)
+	for _, pronoun := range pronouns {
+		if word == pronoun {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shuffle returns a shuffled copy of the input slice
+func shuffle(words []models.WordResponse) []models.WordResponse {
+	result := make([]models.WordResponse, len(words))
+	copy(result, words)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+// SubmitQuizAnswer handles the submission of a quiz answer
+func (h *Handler) SubmitQuizAnswer(c *gin.Context) {
+	var answer QuizAnswer
+	if err := c.ShouldBindJSON(&answer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check the answer against the served question and add the review item
+	reviewItem, correctAnswer, err := h.svcFor(c).AnswerQuizQuestion(c.Request.Context(), answer.SessionID, answer.WordID, answer.Answer)
+	if err != nil {
+		if status := errorStatus(err); status != http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to submit answer: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"word_id":     reviewItem.WordID,
+		"session_id":  reviewItem.StudySessionID,
+		"correct":     reviewItem.Correct,
+		"near_miss":   reviewItem.NearMiss,
+		"correct_answer": correctAnswer,
+		"created_at":  reviewItem.CreatedAt,
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"testing"
+)
+
+func TestAllGroupWords(t *testing.T) {
+	const total = 250
+	groups := &mocks.GroupRepositoryMock{
+		ListWordsFunc: func(ctx context.Context, userID, groupID int64, sort string, limit, offset int) ([]models.WordResponse, error) {
+			var words []models.WordResponse
+			for id := offset + 1; id <= total && id <= offset+limit; id++ {
+				words = append(words, models.WordResponse{ID: int64(id)})
+			}
+			return words, nil
+		},
+		CountWordsFunc: func(ctx context.Context, groupID int64) (int, error) {
+			return total, nil
+		},
+	}
+	svc := newTestService(t, repository.Repositories{Groups: groups})
+
+	words, err := NewHandler(svc).allGroupWords(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("allGroupWords() error = %v", err)
+	}
+	if len(words) != total || words[total-1].ID != total {
+		t.Errorf("allGroupWords() = %d words, want all %d", len(words), total)
+	}
+	if calls := groups.ListWordsCalls(); len(calls) != 3 {
+		t.Errorf("ListWords called %d times, want once per page", len(calls))
+	}
+}
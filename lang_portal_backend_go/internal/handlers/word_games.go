@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultWordGameWordCount is used when no word count is given
+const defaultWordGameWordCount = 5
+
+// StartWordGameRequest represents the request body for starting a word game
+type StartWordGameRequest struct {
+	Game      string `json:"game" binding:"required,oneof=scramble hangman"`
+	GroupID   int64  `json:"group_id" binding:"required"`
+	WordCount int    `json:"word_count" binding:"omitempty,min=1,max=20"`
+	// Script is urdlish (default) or urdu
+	Script string `json:"script"`
+}
+
+// WordGameGuess represents a guess in a word game round
+type WordGameGuess struct {
+	Guess string `json:"guess" binding:"required"`
+}
+
+func RegisterWordGameRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	games := r.Group("/word-games")
+	{
+		games.POST("/start", h.StartWordGame)
+		games.GET("/sessions/:session_id", h.GetWordGameSession)
+		games.POST("/sessions/:session_id/words/:word_id/guess", h.GuessWordGame)
+	}
+}
+
+// StartWordGame starts a word scramble or hangman session with the words of
+// a group the learner struggles with most
+func (h *Handler) StartWordGame(c *gin.Context) {
+	var req StartWordGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.WordCount == 0 {
+		req.WordCount = defaultWordGameWordCount
+	}
+	if req.Script == "" {
+		req.Script = service.WordGameScriptUrdlish
+	}
+
+	session, err := h.svcFor(c).StartWordGame(c.Request.Context(), req.Game, req.GroupID, req.WordCount, req.Script)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetWordGameSession returns the rounds of a word game session
+func (h *Handler) GetWordGameSession(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	session, err := h.svcFor(c).GetWordGameSession(c.Request.Context(), sessionID)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// GuessWordGame checks a guess in a round and returns the updated round
+func (h *Handler) GuessWordGame(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	wordID, err := strconv.ParseInt(c.Param("word_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid word id"})
+		return
+	}
+
+	var req WordGameGuess
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	round, err := h.svcFor(c).GuessWordGame(c.Request.Context(), sessionID, wordID, req.Guess)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, round)
+}
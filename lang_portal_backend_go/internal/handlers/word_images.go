@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"io"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWordImageRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/words/:id/image", h.SetWordImage)
+	r.DELETE("/words/:id/image", h.DeleteWordImage)
+}
+
+// RegisterWordImageFileRoutes serves pictures of words and their thumbnails
+// under service.ImageURLPrefix. Like audio, they need no access token, so
+// img elements can show them.
+func RegisterWordImageFileRoutes(r gin.IRoutes, svc *service.Service) {
+	h := NewHandler(svc)
+	r.GET(service.ImageURLPrefix+":name", h.ServeWordImage)
+}
+
+// SetWordImage uploads the picture of a word as the multipart field "file",
+// replacing the picture it had
+func (h *Handler) SetWordImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	image, err := h.svcFor(c).SetWordImage(c.Request.Context(), id, data)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, image)
+}
+
+// DeleteWordImage removes the picture of a word
+func (h *Handler) DeleteWordImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteWordImage(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ServeWordImage redirects to a signed URL of a picture in the media
+// storage. The URL expires, so the redirect is temporary.
+func (h *Handler) ServeWordImage(c *gin.Context) {
+	url, err := h.svc.WordImageFileURL(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WordRenderingRequest represents the request body for setting the hints
+// for rendering a word
+type WordRenderingRequest struct {
+	// FontStyle is nastaleeq or naskh, or empty for the usual one of the
+	// word's language
+	FontStyle   string `json:"font_style"`
+	Diacritized string `json:"diacritized"`
+	// LigatureSpellings are spellings of the word whose letters must be
+	// joined as written
+	LigatureSpellings []string `json:"ligature_spellings"`
+}
+
+// ImportDiacritizedRequest represents the request body for adding the
+// diacritized forms of words
+type ImportDiacritizedRequest struct {
+	// Language is the words' language, by default the request's
+	Language string                   `json:"language"`
+	Forms    []models.DiacritizedForm `json:"forms" binding:"required"`
+}
+
+func RegisterWordRenderingRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	r.POST("/words/diacritized", h.ImportDiacritizedForms)
+	r.PUT("/words/:id/rendering", h.SetWordRendering)
+	r.DELETE("/words/:id/rendering", h.DeleteWordRendering)
+}
+
+// SetWordRendering replaces the hints for rendering a word
+func (h *Handler) SetWordRendering(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req WordRenderingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendering, err := h.svcFor(c).SetWordRendering(c.Request.Context(), id, models.WordRendering{
+		FontStyle:         req.FontStyle,
+		Diacritized:       req.Diacritized,
+		LigatureSpellings: req.LigatureSpellings,
+	})
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rendering)
+}
+
+// DeleteWordRendering removes the hints for rendering a word
+func (h *Handler) DeleteWordRendering(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.svcFor(c).DeleteWordRendering(c.Request.Context(), id); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ImportDiacritizedForms sets the diacritized forms of words in bulk,
+// matching forms without a word ID to the words written the same without
+// their marks
+func (h *Handler) ImportDiacritizedForms(c *gin.Context) {
+	var req ImportDiacritizedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svcFor(c).ImportDiacritizedForms(c.Request.Context(), req.Language, req.Forms)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"lang_portal/internal/embedding"
+	"lang_portal/internal/middleware"
+	"lang_portal/internal/models"
+	"lang_portal/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWordsRoutes(r *gin.RouterGroup, svc *service.Service) {
+	h := NewHandler(svc)
+	words := r.Group("/words")
+	words.Use(middleware.ETag(), middleware.Fields())
+	{
+		words.GET("", h.ListWords)
+		words.GET("/:id", h.GetWord)
+		words.POST("", h.CreateWord)
+		words.GET("/:id/learning_state", h.GetWordLearningState)
+		words.GET("/:id/embedding", h.GetWordEmbeddingStatus)
+		words.PUT("/:id/embedding", h.SetWordEmbedding)
+		words.GET("/:id/senses", h.GetWordSenses)
+		words.PUT("/:id/senses", h.SetWordSenses)
+	}
+}
+
+func (h *Handler) GetWord(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	word, err := h.svcFor(c).GetWord(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, word)
+} 
+
+// CreateWordRequest represents the request body for creating a word
+type CreateWordRequest struct {
+	Urdu    string `json:"urdu" binding:"required"`
+	Urdlish string `json:"urdlish" binding:"required"`
+	// English is required unless Senses are given, whose first then is the
+	// word's english
+	English string          `json:"english" binding:"required_without=Senses"`
+	Parts   json.RawMessage `json:"parts"`
+	// Language is the code of the word's language, by default the one the
+	// request selects or else Urdu
+	Language string `json:"language"`
+	// Senses are the word's English meanings, most common first
+	Senses []models.WordSense `json:"senses"`
+}
+
+// CreateWord adds a word. Data quality problems don't fail the request but
+// are returned in the warnings array.
+func (h *Handler) CreateWord(c *gin.Context) {
+	var req CreateWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	word := &models.Word{
+		Urdu:     req.Urdu,
+		Urdlish:  req.Urdlish,
+		English:  req.English,
+		Parts:    string(req.Parts),
+		Language: req.Language,
+		Senses:   req.Senses,
+	}
+	warnings, err := h.svcFor(c).CreateWord(c.Request.Context(), word)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"word":     word,
+		"warnings": warnings,
+	})
+}
+
+// GetWordLearningState returns the spaced repetition schedule of a word
+func (h *Handler) GetWordLearningState(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	state, err := h.svcFor(c).GetWordLearningState(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// GetWordEmbeddingStatus describes a word's embedding and whether it is stale
+func (h *Handler) GetWordEmbeddingStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	status, err := h.svcFor(c).GetWordEmbeddingStatus(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// WordEmbeddingRequest represents the request body for storing a word's
+// precomputed embedding
+type WordEmbeddingRequest struct {
+	Model  string           `json:"model" binding:"required"`
+	Vector embedding.Vector `json:"vector" binding:"required"`
+}
+
+// SetWordEmbedding stores a precomputed embedding used to find similar words
+func (h *Handler) SetWordEmbedding(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req WordEmbeddingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svcFor(c).SetWordEmbedding(c.Request.Context(), id, req.Model, req.Vector); err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"word_id":    id,
+		"model":      req.Model,
+		"dimensions": len(req.Vector),
+	})
+}
+
+// WordSensesRequest represents the request body for replacing the senses of
+// a word
+type WordSensesRequest struct {
+	Senses []models.WordSense `json:"senses" binding:"required"`
+}
+
+// GetWordSenses returns the English meanings of a word, most common first
+func (h *Handler) GetWordSenses(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	senses, err := h.svcFor(c).GetWordSenses(c.Request.Context(), id)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"senses": senses})
+}
+
+// SetWordSenses replaces the English meanings of a word. The first becomes
+// its english.
+func (h *Handler) SetWordSenses(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req WordSensesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	senses, err := h.svcFor(c).SetWordSenses(c.Request.Context(), id, req.Senses)
+	if err != nil {
+		serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"senses": senses})
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/repository/mocks"
+	"net/http"
+	"testing"
+)
+
+func TestListWords(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		listErr    error
+		wantStatus int
+		// wantOffset is the offset the page is listed from
+		wantOffset int
+	}{
+		{name: "first page", path: "/words", wantStatus: http.StatusOK},
+		{name: "second page", path: "/words?page=2", wantStatus: http.StatusOK, wantOffset: 100},
+		{name: "page not a number", path: "/words?page=x", wantStatus: http.StatusBadRequest},
+		{name: "page before the first", path: "/words?page=0", wantStatus: http.StatusBadRequest},
+		{name: "unknown sort", path: "/words?sort=english", wantStatus: http.StatusBadRequest},
+		{name: "repository error", path: "/words", listErr: errors.New("disk I/O error"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := &mocks.WordRepositoryMock{
+				ListFunc: func(ctx context.Context, userID, viewerID int64, language, sort string, limit, offset int) ([]models.WordResponse, error) {
+					return []models.WordResponse{{ID: 1, Urdu: "سلام", Urdlish: "salaam", English: "hello"}}, tt.listErr
+				},
+				CountFunc: func(ctx context.Context, viewerID int64, language string) (int, error) {
+					return 101, nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Words: words})
+
+			w := serve(RegisterWordsRoutes, svc, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			calls := words.ListCalls()
+			if len(calls) != 1 {
+				t.Fatalf("List called %d times, want 1", len(calls))
+			}
+			if calls[0].UserID != 1 || calls[0].Limit != 100 || calls[0].Offset != tt.wantOffset {
+				t.Errorf("List(userID %d, limit %d, offset %d), want (1, 100, %d)",
+					calls[0].UserID, calls[0].Limit, calls[0].Offset, tt.wantOffset)
+			}
+			var page struct {
+				Items      []models.WordResponse `json:"items"`
+				Pagination models.Pagination     `json:"pagination"`
+			}
+			decode(t, w, &page)
+			if len(page.Items) != 1 || page.Items[0].English != "hello" {
+				t.Errorf("items = %+v, want the listed word", page.Items)
+			}
+			if page.Pagination.TotalItems != 101 || page.Pagination.TotalPages != 2 {
+				t.Errorf("pagination = %+v, want 101 items on 2 pages", page.Pagination)
+			}
+		})
+	}
+}
+
+func TestListWordsByCursor(t *testing.T) {
+	words := &mocks.WordRepositoryMock{
+		ListAfterFunc: func(ctx context.Context, userID, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error) {
+			list := make([]models.WordResponse, limit)
+			for i := range list {
+				list[i].ID = afterID + int64(i) + 1
+			}
+			return list, nil
+		},
+		CountFunc: func(ctx context.Context, viewerID int64, language string) (int, error) {
+			return 500, nil
+		},
+	}
+	svc := newTestService(t, repository.Repositories{Words: words})
+
+	w := serve(RegisterWordsRoutes, svc, http.MethodGet, "/words?cursor=", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Items      []models.WordResponse `json:"items"`
+		Pagination models.Pagination     `json:"pagination"`
+	}
+	decode(t, w, &page)
+	if len(page.Items) != 100 {
+		t.Fatalf("page has %d words, want 100", len(page.Items))
+	}
+	if page.Pagination.NextCursor != models.EncodeCursor(100) {
+		t.Errorf("next cursor = %q, want the cursor of word 100", page.Pagination.NextCursor)
+	}
+
+	w = serve(RegisterWordsRoutes, svc, http.MethodGet, "/words?cursor="+page.Pagination.NextCursor, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	calls := words.ListAfterCalls()
+	if got := calls[len(calls)-1].AfterID; got != 100 {
+		t.Errorf("next page listed after word %d, want 100", got)
+	}
+
+	for _, path := range []string{"/words?cursor=not-a-cursor", "/words?cursor=&sort=urdu"} {
+		if w := serve(RegisterWordsRoutes, svc, http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestGetWord(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		getErr     error
+		wantStatus int
+	}{
+		{name: "found", path: "/words/1", wantStatus: http.StatusOK},
+		{name: "not found", path: "/words/1", getErr: sql.ErrNoRows, wantStatus: http.StatusNotFound},
+		{name: "invalid id", path: "/words/one", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := &mocks.WordRepositoryMock{
+				GetFunc: func(ctx context.Context, userID, viewerID, id int64) (*models.WordResponse, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					return &models.WordResponse{ID: id, Urdu: "سلام", Urdlish: "salaam", English: "hello"}, nil
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Words: words})
+
+			w := serve(RegisterWordsRoutes, svc, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var word models.WordResponse
+			decode(t, w, &word)
+			if word.ID != 1 || word.Urdlish != "salaam" {
+				t.Errorf("word = %+v, want word 1", word)
+			}
+		})
+	}
+}
+
+func TestCreateWord(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCreate bool
+	}{
+		{
+			name:       "created",
+			body:       `{"urdu": "پانی", "urdlish": "paani", "english": "water"}`,
+			wantStatus: http.StatusCreated,
+			wantCreate: true,
+		},
+		{
+			name:       "english from the first sense",
+			body:       `{"urdu": "پانی", "urdlish": "paani", "senses": [{"english": "water"}]}`,
+			wantStatus: http.StatusCreated,
+			wantCreate: true,
+		},
+		{
+			name:       "missing english",
+			body:       `{"urdu": "پانی", "urdlish": "paani"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown language",
+			body:       `{"urdu": "پانی", "urdlish": "paani", "english": "water", "language": "xx"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := &mocks.WordRepositoryMock{
+				CreateFunc: func(ctx context.Context, q repository.Querier, word *models.Word) error {
+					return q.QueryRowContext(ctx, `
+						INSERT INTO words (script, transliteration, english, language) VALUES (?, ?, ?, ?)
+						RETURNING id
+					`, word.Urdu, word.Urdlish, word.English, word.Language).Scan(&word.ID)
+				},
+			}
+			svc := newTestService(t, repository.Repositories{Words: words})
+
+			w := serve(RegisterWordsRoutes, svc, http.MethodPost, "/words", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			calls := words.CreateCalls()
+			if created := len(calls) == 1; created != tt.wantCreate {
+				t.Fatalf("Create called %d times, want created = %v", len(calls), tt.wantCreate)
+			}
+			if !tt.wantCreate {
+				return
+			}
+			if word := calls[0].Word; word.English != "water" || word.Language != "ur" {
+				t.Errorf("created %+v, want water in Urdu", word)
+			}
+		})
+	}
+}
+
+func TestGetWordEmbeddingStatus(t *testing.T) {
+	const embeddings = `
+		INSERT INTO words (id, script, transliteration, english) VALUES
+			(101, 'پانی', 'paani', 'water'), (102, 'آگ', 'aag', 'fire'),
+			(103, 'ہوا', 'hawa', 'wind'), (104, 'زمین', 'zameen', 'earth');
+		INSERT INTO word_embeddings (word_id, model, dimensions, vector, source_text) VALUES
+			(101, 'test', 2, '[0.6, 0.8]', 'water (پانی, paani)'),
+			(102, 'test', 2, '[0.6, 0.8]', 'flame (آگ, aag)'),
+			(103, 'test', 2, '[0.6, 0.8]', '');
+	`
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "current", path: "/words/101/embedding", wantStatus: http.StatusOK},
+		{name: "text changed", path: "/words/102/embedding", wantStatus: http.StatusOK, wantReason: models.EmbeddingTextChanged},
+		{name: "text unknown", path: "/words/103/embedding", wantStatus: http.StatusOK, wantReason: models.EmbeddingTextChanged},
+		{name: "missing", path: "/words/104/embedding", wantStatus: http.StatusOK, wantReason: models.EmbeddingMissing},
+		{name: "not found", path: "/words/999/embedding", wantStatus: http.StatusNotFound},
+		{name: "invalid id", path: "/words/one/embedding", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, repository.Repositories{}, embeddings)
+
+			w := serve(RegisterWordsRoutes, svc, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var status models.WordEmbeddingStatus
+			decode(t, w, &status)
+			if status.StaleReason != tt.wantReason || status.Stale != (tt.wantReason != "") {
+				t.Errorf("status = %+v, want stale reason %q", status, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package https serves the API over TLS without a reverse proxy, with a
+// certificate from files or from Let's Encrypt, and redirects plain HTTP
+// to it
+package https
+
+import (
+	"crypto/tls"
+	"fmt"
+	"lang_portal/internal/config"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// New returns the TLS settings of a server serving HTTPS on port, and the
+// handler of the redirect port. The handler answers Let's Encrypt's HTTP
+// challenges and redirects everything else to HTTPS. HTTP/2 is negotiated
+// with clients that support it.
+func New(cfg config.TLS, port int) (*tls.Config, http.Handler, error) {
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		}, Redirect(port), nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	// The manager's settings also answer the TLS-ALPN challenge, so
+	// certificates can be issued without a redirect port
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig, manager.HTTPHandler(Redirect(port)), nil
+}
+
+// Redirect redirects requests to the same URL over HTTPS on port. Requests
+// other than GET and HEAD get 308 Permanent Redirect so clients resend
+// their body.
+func Redirect(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		host = strings.Trim(host, "[]")
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
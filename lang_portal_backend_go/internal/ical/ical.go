@@ -0,0 +1,120 @@
+// Package ical writes calendars in the iCalendar format of RFC 5545, which
+// calendar apps such as Google Calendar subscribe to by URL.
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the content type of iCalendar data
+const ContentType = "text/calendar; charset=utf-8"
+
+// lineLength is the most octets a content line may have before it is
+// folded onto the next
+const lineLength = 75
+
+// Calendar is a named list of events
+type Calendar struct {
+	// ProductID names the program that wrote the calendar
+	ProductID string
+	Name      string
+	// Refresh is how often subscribers should fetch the calendar again
+	Refresh time.Duration
+	Events  []Event
+}
+
+// Event is a timed or all-day event
+type Event struct {
+	// UID identifies the event across fetches, so apps update it in place
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	// End is when a timed event ends. All-day events end after their day.
+	End    time.Time
+	AllDay bool
+}
+
+// Encode writes the calendar as iCalendar data, stamped with now
+func (c *Calendar) Encode(now time.Time) []byte {
+	var b bytes.Buffer
+	line := func(name, value string) {
+		fold(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		refresh := duration(c.Refresh)
+		line("REFRESH-INTERVAL;VALUE=DURATION", refresh)
+		line("X-PUBLISHED-TTL", refresh)
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp)
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE", event.Start.AddDate(0, 0, 1).Format("20060102"))
+			line("TRANSP", "TRANSPARENT")
+		} else {
+			line("DTSTART", event.Start.UTC().Format("20060102T150405Z"))
+			line("DTEND", event.End.UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+// escape escapes the characters text values can't hold as they are
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// fold writes a content line ending in CRLF, breaking it after lineLength
+// octets onto lines starting with a space, without splitting characters
+func fold(b *bytes.Buffer, line string) {
+	limit := lineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The space starting a continuation line counts towards its length
+		limit = lineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// duration formats a duration of whole minutes as an iCalendar duration,
+// e.g. PT1H
+func duration(d time.Duration) string {
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours := int(d / time.Hour); hours > 0 {
+		b.WriteString(strconv.Itoa(hours) + "H")
+	}
+	if minutes := int(d % time.Hour / time.Minute); minutes > 0 || d < time.Hour {
+		b.WriteString(strconv.Itoa(minutes) + "M")
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+// Package imaging checks uploaded pictures and scales them down to
+// thumbnails. PNG, JPEG and GIF pictures are read; thumbnails are JPEG.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Registered for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Content types of pictures
+const (
+	PNG  = "image/png"
+	JPEG = "image/jpeg"
+	GIF  = "image/gif"
+)
+
+// MaxPixels is the most pixels a picture may have, so a small file can't
+// decode into gigabytes
+const MaxPixels = 40 * 1000 * 1000
+
+// thumbnailQuality is the JPEG quality of thumbnails
+const thumbnailQuality = 85
+
+// ErrUnsupported is returned for data that isn't a PNG, JPEG or GIF picture
+var ErrUnsupported = errors.New("unsupported image type: use a PNG, JPEG or GIF picture")
+
+// formats maps the formats image.Decode names to their content types and
+// file extensions
+var formats = map[string]struct{ contentType, extension string }{
+	"png":  {PNG, "png"},
+	"jpeg": {JPEG, "jpg"},
+	"gif":  {GIF, "gif"},
+}
+
+// Info describes a picture
+type Info struct {
+	ContentType string
+	// Extension is the file extension of the format, without a dot
+	Extension string
+	Width     int
+	Height    int
+}
+
+// Inspect returns what picture data is without decoding it
+func Inspect(data []byte) (*Info, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	f, ok := formats[format]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("picture has no pixels")
+	}
+	if config.Width > MaxPixels/config.Height {
+		return nil, fmt.Errorf("picture is %dx%d, larger than %d megapixels", config.Width, config.Height, MaxPixels/1000000)
+	}
+	return &Info{ContentType: f.contentType, Extension: f.extension, Width: config.Width, Height: config.Height}, nil
+}
+
+// Thumbnail scales picture data down to fit within size pixels square,
+// averaging the pixels each thumbnail pixel covers, and encodes it as JPEG.
+// Smaller pictures keep their size. Transparent pixels are made white.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	if _, err := Inspect(data); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode picture: %v", err)
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			width, height = size, max(1, height*size/width)
+		} else {
+			width, height = max(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// Colors are premultiplied, so adding what is
+					// transparent of white lays them on white
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					b += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %v", err)
+	}
+	return out.Bytes(), nil
+}
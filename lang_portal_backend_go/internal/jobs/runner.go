@@ -0,0 +1,310 @@
+// Package jobs runs work in the background on a pool of workers, so
+// requests that ask for slow work can answer at once. Jobs are kept in the
+// jobs table, so they survive restarts, and failed attempts are retried
+// with exponential backoff.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWorkers is how many jobs run at once unless Workers is set.
+	// SQLite has one writer, so more workers mostly wait on each other.
+	DefaultWorkers = 2
+	// DefaultMaxAttempts is how many times a job is tried before it fails
+	DefaultMaxAttempts = 3
+	// pollInterval is how often idle workers look for jobs whose retry is
+	// due
+	pollInterval = time.Second
+	// retryDelay is how long after its first failed attempt a job is
+	// retried. The delay doubles with each attempt up to maxRetryDelay.
+	retryDelay    = 10 * time.Second
+	maxRetryDelay = 10 * time.Minute
+)
+
+// Handler runs a job and returns its result, which is stored as JSON
+type Handler func(ctx context.Context, job *models.Job) (interface{}, error)
+
+// permanentError is an error retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error retrying won't fix, so the job fails at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Runner runs the jobs of the jobs table with the handlers registered for
+// their kind
+type Runner struct {
+	db       *models.DB
+	dialect  dialect.Dialect
+	handlers map[string]Handler
+	// Workers is how many jobs run at once. It must be set before Start.
+	Workers int
+
+	// wake tells an idle worker a job was queued
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+	done sync.WaitGroup
+}
+
+// NewRunner creates a runner for the jobs table of db, whose SQL dialect is d
+func NewRunner(db *models.DB, d dialect.Dialect) *Runner {
+	return &Runner{
+		db:       db,
+		dialect:  d,
+		handlers: map[string]Handler{},
+		Workers:  DefaultWorkers,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler of a kind of job. Handlers must be registered
+// before Start.
+func (r *Runner) Register(kind string, handler Handler) {
+	r.handlers[kind] = handler
+}
+
+// Start queues again the jobs that were running when the server last
+// stopped, then starts the workers
+func (r *Runner) Start(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE jobs SET status = ?, run_at = ? WHERE status = ?
+	`), models.JobQueued, time.Now().UTC(), models.JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to requeue interrupted jobs: %v", err)
+	}
+
+	for i := 0; i < r.Workers; i++ {
+		r.done.Add(1)
+		go r.work()
+	}
+	return nil
+}
+
+// Stop stops the workers, waiting for the jobs they are running to finish
+func (r *Runner) Stop() {
+	r.once.Do(func() { close(r.stop) })
+	r.done.Wait()
+}
+
+// Enqueue queues a job of a kind with a payload, which is stored as JSON,
+// for a user, or 0 for the server's own jobs
+func (r *Runner) Enqueue(ctx context.Context, userID int64, kind string, payload interface{}) (*models.Job, error) {
+	if _, ok := r.handlers[kind]; !ok {
+		return nil, fmt.Errorf("unknown job kind %q", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %v", err)
+	}
+
+	now := time.Now().UTC()
+	var id int64
+	err = r.db.WithTx(ctx, func(tx *models.Tx) error {
+		// RETURNING works on both SQLite and PostgreSQL, whose driver has no
+		// LastInsertId
+		return tx.QueryRowContext(ctx, r.dialect.Rebind(`
+			INSERT INTO jobs (user_id, kind, payload, status, max_attempts, run_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`), sql.NullInt64{Int64: userID, Valid: userID != 0}, kind, string(data), models.JobQueued, DefaultMaxAttempts, now, now).Scan(&id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue job: %v", err)
+	}
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return r.Get(ctx, id)
+}
+
+// Get returns a job, or sql.ErrNoRows if there is none
+func (r *Runner) Get(ctx context.Context, id int64) (*models.Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT `+jobColumns+` FROM jobs WHERE id = ?
+	`), id))
+}
+
+// jobColumns are the columns scanJob reads
+const jobColumns = `id, user_id, kind, status, payload, attempts, max_attempts, result, error,
+	run_at, created_at, started_at, finished_at`
+
+func scanJob(row *models.Row) (*models.Job, error) {
+	var (
+		job        models.Job
+		userID     sql.NullInt64
+		payload    string
+		result     sql.NullString
+		lastError  sql.NullString
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+	)
+	err := row.Scan(&job.ID, &userID, &job.Kind, &job.Status, &payload, &job.Attempts, &job.MaxAttempts,
+		&result, &lastError, &job.RunAt, &job.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.UserID = userID.Int64
+	job.Payload = json.RawMessage(payload)
+	if result.Valid {
+		job.Result = json.RawMessage(result.String)
+	}
+	job.Error = lastError.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
+
+// work runs jobs until the runner is stopped, waiting for more when there
+// are none due
+func (r *Runner) work() {
+	defer r.done.Done()
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		job, err := r.claim(context.Background())
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job != nil {
+			r.run(job)
+			continue
+		}
+
+		select {
+		case <-r.wake:
+		case <-time.After(pollInterval):
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// claim marks the queued job that has been due longest as running and
+// returns it, or nil if no job is due. Idle workers only read, so polling
+// doesn't take the writer's turn from requests.
+func (r *Runner) claim(ctx context.Context) (*models.Job, error) {
+	now := time.Now().UTC()
+	var id int64
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT id FROM jobs WHERE status = ? AND run_at <= ? ORDER BY run_at, id LIMIT 1
+	`), models.JobQueued, now).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find due job: %v", err)
+	}
+
+	// Checking the status again keeps another worker, or another server
+	// sharing a PostgreSQL database, from running the job too
+	result, err := r.db.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, started_at = ?, finished_at = NULL
+		WHERE id = ? AND status = ?
+	`), models.JobRunning, now, id, models.JobQueued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start job: %v", err)
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return nil, err
+	}
+	return r.Get(ctx, id)
+}
+
+// run runs a claimed job and records how it went
+func (r *Runner) run(job *models.Job) {
+	ctx := context.Background()
+	result, err := r.handle(ctx, job)
+
+	now := time.Now().UTC()
+	if err == nil {
+		var data []byte
+		data, err = json.Marshal(result)
+		if err == nil {
+			_, err = r.db.ExecContext(ctx, r.dialect.Rebind(`
+				UPDATE jobs SET status = ?, result = ?, error = NULL, finished_at = ? WHERE id = ?
+			`), models.JobSucceeded, string(data), now, job.ID)
+			if err != nil {
+				log.Printf("Failed to record job %d: %v", job.ID, err)
+			}
+			return
+		}
+		err = Permanent(fmt.Errorf("failed to encode job result: %v", err))
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %d (%s) failed: %v", job.ID, job.Kind, err)
+		_, err = r.db.ExecContext(ctx, r.dialect.Rebind(`
+			UPDATE jobs SET status = ?, error = ?, finished_at = ? WHERE id = ?
+		`), models.JobFailed, err.Error(), now, job.ID)
+	} else {
+		delay := backoff(job.Attempts)
+		log.Printf("Job %d (%s) failed, retrying in %s: %v", job.ID, job.Kind, delay, err)
+		_, err = r.db.ExecContext(ctx, r.dialect.Rebind(`
+			UPDATE jobs SET status = ?, error = ?, run_at = ? WHERE id = ?
+		`), models.JobQueued, err.Error(), now.Add(delay), job.ID)
+	}
+	if err != nil {
+		log.Printf("Failed to record job %d: %v", job.ID, err)
+	}
+}
+
+// handle runs a job's handler, turning a panic into a permanent error so a
+// bad job can't take the worker down
+func (r *Runner) handle(ctx context.Context, job *models.Job) (result interface{}, err error) {
+	handler, ok := r.handlers[job.Kind]
+	if !ok {
+		return nil, Permanent(fmt.Errorf("unknown job kind %q", job.Kind))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = Permanent(fmt.Errorf("job panicked: %v", p))
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns how long to wait before retrying a job that has failed
+// attempts times
+func backoff(attempts int) time.Duration {
+	delay := retryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,201 @@
+// Package listening builds comprehension questions from the transcripts of
+// listening clips. Each question is a sentence of the transcript with one
+// vocabulary word blanked out.
+package listening
+
+import (
+	"math/rand"
+	"strings"
+
+	"lang_portal/internal/spelling"
+)
+
+// Difficulty levels, which decide where the incorrect options come from
+const (
+	// Easy draws incorrect options from words not heard in the clip
+	Easy = "easy"
+	// Medium draws incorrect options from any vocabulary word
+	Medium = "medium"
+	// Hard draws incorrect options from words heard elsewhere in the clip
+	Hard = "hard"
+)
+
+// Blank replaces the answer in a question's prompt
+const Blank = "____"
+
+// optionCount is the number of options of a question, including the answer
+const optionCount = 4
+
+// Word is a vocabulary word that questions can ask about
+type Word struct {
+	ID   int64
+	Text string
+}
+
+// Question is a transcript sentence with a vocabulary word blanked out
+type Question struct {
+	Prompt  string
+	Options []string
+	Answer  string
+	WordID  int64
+}
+
+// ValidDifficulty reports whether difficulty is a known difficulty level
+func ValidDifficulty(difficulty string) bool {
+	return difficulty == Easy || difficulty == Medium || difficulty == Hard
+}
+
+// Sentences splits a transcript on sentence punctuation, including the Urdu
+// full stop and question mark, and on line breaks
+func Sentences(transcript string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(transcript)
+	for i, r := range runes {
+		switch r {
+		case '.', '?', '!', '۔', '؟', '\n':
+			if s := strings.TrimSpace(string(runes[start : i+1])); len([]rune(s)) > 1 {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+// Generate returns up to count questions about the vocabulary words heard in
+// the transcript, in transcript order. A sentence is asked about at most
+// once, and so is a word.
+func Generate(transcript string, vocabulary []Word, count int, difficulty string, rng *rand.Rand) []Question {
+	sentences := Sentences(transcript)
+
+	type candidate struct {
+		sentence int
+		word     Word
+		prompt   string
+	}
+	var candidates []candidate
+	heard := make(map[string]bool)
+	used := make(map[string]bool)
+	for i, sentence := range sentences {
+		asked := false
+		for _, word := range vocabulary {
+			prompt, ok := blank(sentence, word.Text)
+			if !ok {
+				continue
+			}
+			key := spelling.Normalize(word.Text)
+			heard[key] = true
+			if asked || used[key] {
+				continue
+			}
+			candidates = append(candidates, candidate{sentence: i, word: word, prompt: prompt})
+			used[key] = true
+			asked = true
+		}
+	}
+
+	// Keep a random subset, still in transcript order
+	if count > 0 && len(candidates) > count {
+		keep := rng.Perm(len(candidates))[:count]
+		selected := make([]bool, len(candidates))
+		for _, i := range keep {
+			selected[i] = true
+		}
+		var kept []candidate
+		for i, c := range candidates {
+			if selected[i] {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+
+	var questions []Question
+	for _, c := range candidates {
+		options := distractors(c.word, vocabulary, heard, difficulty, rng)
+		if len(options) == 0 {
+			continue
+		}
+		options = append(options, c.word.Text)
+		rng.Shuffle(len(options), func(i, j int) {
+			options[i], options[j] = options[j], options[i]
+		})
+		questions = append(questions, Question{
+			Prompt:  c.prompt,
+			Options: options,
+			Answer:  c.word.Text,
+			WordID:  c.word.ID,
+		})
+	}
+	return questions
+}
+
+// blank replaces the first whole-word occurrence of word in sentence with
+// Blank, ignoring case, punctuation and diacritics
+func blank(sentence, word string) (string, bool) {
+	target := strings.Fields(spelling.Normalize(word))
+	if len(target) == 0 {
+		return "", false
+	}
+	tokens := strings.Fields(sentence)
+	for i := 0; i+len(target) <= len(tokens); i++ {
+		match := true
+		for j, t := range target {
+			if spelling.Normalize(tokens[i+j]) != t {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		prompt := append([]string{}, tokens[:i]...)
+		prompt = append(prompt, Blank)
+		prompt = append(prompt, tokens[i+len(target):]...)
+		return strings.Join(prompt, " "), true
+	}
+	return "", false
+}
+
+// distractors picks the incorrect options for a question about word. The
+// difficulty decides whether words heard in the clip are avoided or
+// preferred; the other words fill any remaining places.
+func distractors(word Word, vocabulary []Word, heard map[string]bool, difficulty string, rng *rand.Rand) []string {
+	answer := spelling.Normalize(word.Text)
+	seen := map[string]bool{answer: true}
+	var inClip, outOfClip []string
+	for _, i := range rng.Perm(len(vocabulary)) {
+		w := vocabulary[i]
+		key := spelling.Normalize(w.Text)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if heard[key] {
+			inClip = append(inClip, w.Text)
+		} else {
+			outOfClip = append(outOfClip, w.Text)
+		}
+	}
+
+	var pool []string
+	switch difficulty {
+	case Easy:
+		pool = append(outOfClip, inClip...)
+	case Hard:
+		pool = append(inClip, outOfClip...)
+	default:
+		pool = append(inClip, outOfClip...)
+		rng.Shuffle(len(pool), func(i, j int) {
+			pool[i], pool[j] = pool[j], pool[i]
+		})
+	}
+	if len(pool) > optionCount-1 {
+		pool = pool[:optionCount-1]
+	}
+	return pool
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bedrock completes prompts with the Converse API of Amazon Bedrock. The
+// credentials and region are read from the standard AWS variables.
+type bedrock struct {
+	url          string
+	region       string
+	model        string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func newBedrock(endpoint, model string) (*bedrock, error) {
+	p := &bedrock{
+		url:          endpoint,
+		region:       os.Getenv("AWS_REGION"),
+		model:        model,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if p.region == "" {
+		p.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	switch {
+	case p.model == "":
+		return nil, fmt.Errorf("%s is required for %s", ModelEnv, Bedrock)
+	case p.region == "":
+		return nil, fmt.Errorf("AWS_REGION is required for %s", Bedrock)
+	case p.accessKey == "" || p.secretKey == "":
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for %s", Bedrock)
+	}
+	if p.url == "" {
+		p.url = "https://bedrock-runtime." + p.region + ".amazonaws.com"
+	}
+	return p, nil
+}
+
+func (p *bedrock) Name() string { return Bedrock + "/" + p.model }
+
+func (p *bedrock) Complete(ctx context.Context, system, prompt string) (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"system": []map[string]string{{"text": system}},
+		"messages": []map[string]interface{}{{
+			"role":    "user",
+			"content": []map[string]string{{"text": prompt}},
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("bedrock: failed to encode request: %v", err)
+	}
+
+	// Model IDs contain colons, which must be escaped in the path
+	path := "/model/" + awsEscape(p.model) + "/converse"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+path, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("bedrock: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.sign(req, data, time.Now().UTC())
+
+	var response struct {
+		Output struct {
+			Message struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"message"`
+		} `json:"output"`
+	}
+	if err := do(req, &response); err != nil {
+		return "", fmt.Errorf("bedrock: %v", err)
+	}
+	var text strings.Builder
+	for _, content := range response.Output.Message.Content {
+		text.WriteString(content.Text)
+	}
+	return text.String(), nil
+}
+
+// sign signs req, whose body is data, with AWS Signature Version 4
+func (p *bedrock) sign(req *http.Request, data []byte, now time.Time) {
+	const service = "bedrock"
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(data)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if p.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Services other than S3 escape each segment of the path again
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		strings.Join(segments, "/"),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + p.region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), day)
+	key = hmacSHA256(key, p.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKey, scope, signedHeaders, signature))
+}
+
+// awsEscape escapes every byte of s but the unreserved characters of RFC
+// 3986, as AWS signatures expect
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,107 @@
+// Package llm asks a large language model to complete a prompt. OpenAI,
+// Ollama and Amazon Bedrock are supported; which one is used, and its model,
+// is set by the environment.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderEnv selects the provider: openai, ollama or bedrock
+	ProviderEnv = "LANG_PORTAL_LLM_PROVIDER"
+	// ModelEnv is the model to ask, required for bedrock
+	ModelEnv = "LANG_PORTAL_LLM_MODEL"
+	// URLEnv overrides the provider's API URL, e.g. for an OpenAI-compatible
+	// server or an Ollama on another host
+	URLEnv = "LANG_PORTAL_LLM_URL"
+	// APIKeyEnv is the OpenAI API key
+	APIKeyEnv = "LANG_PORTAL_LLM_API_KEY"
+)
+
+// Providers
+const (
+	OpenAI  = "openai"
+	Ollama  = "ollama"
+	Bedrock = "bedrock"
+)
+
+// client calls the providers. Completions of a few dozen words can take a
+// while on a local model.
+var client = &http.Client{Timeout: 2 * time.Minute}
+
+// Provider completes prompts with a model
+type Provider interface {
+	// Name names the provider and model, e.g. "openai/gpt-4o-mini"
+	Name() string
+	// Complete returns the model's answer to prompt, following the
+	// instructions of system. The answer is asked to be a JSON object.
+	Complete(ctx context.Context, system, prompt string) (string, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	model := os.Getenv(ModelEnv)
+	url := strings.TrimSuffix(os.Getenv(URLEnv), "/")
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case OpenAI:
+		key := os.Getenv(APIKeyEnv)
+		if key == "" && url == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newOpenAI(url, key, model), nil
+	case Ollama:
+		return newOllama(url, model), nil
+	case Bedrock:
+		return newBedrock(url, model)
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s, %s or %s", ProviderEnv, provider, OpenAI, Ollama, Bedrock)
+	}
+}
+
+// postJSON posts body as JSON to url with the given headers and decodes the
+// response into v
+func postJSON(ctx context.Context, url string, header http.Header, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(req, v)
+}
+
+// do sends req and decodes the JSON response into v
+func do(req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ollamaURL   = "http://localhost:11434"
+	ollamaModel = "llama3.1"
+)
+
+// ollama completes prompts with the chat API of an Ollama server
+type ollama struct {
+	url   string
+	model string
+}
+
+func newOllama(url, model string) *ollama {
+	if url == "" {
+		url = ollamaURL
+	}
+	if model == "" {
+		model = ollamaModel
+	}
+	return &ollama{url: url, model: model}
+}
+
+func (p *ollama) Name() string { return Ollama + "/" + p.model }
+
+func (p *ollama) Complete(ctx context.Context, system, prompt string) (string, error) {
+	var response struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	err := postJSON(ctx, p.url+"/api/chat", nil, map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		},
+		"format": "json",
+		"stream": false,
+	}, &response)
+	if err != nil {
+		return "", fmt.Errorf("ollama: %v", err)
+	}
+	return response.Message.Content, nil
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const (
+	openAIURL   = "https://api.openai.com/v1"
+	openAIModel = "gpt-4o-mini"
+)
+
+// openAI completes prompts with the chat completions API of OpenAI, or of a
+// server compatible with it
+type openAI struct {
+	url   string
+	key   string
+	model string
+}
+
+func newOpenAI(url, key, model string) *openAI {
+	if url == "" {
+		url = openAIURL
+	}
+	if model == "" {
+		model = openAIModel
+	}
+	return &openAI{url: url, key: key, model: model}
+}
+
+func (p *openAI) Name() string { return OpenAI + "/" + p.model }
+
+func (p *openAI) Complete(ctx context.Context, system, prompt string) (string, error) {
+	header := http.Header{}
+	if p.key != "" {
+		header.Set("Authorization", "Bearer "+p.key)
+	}
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err := postJSON(ctx, p.url+"/chat/completions", header, map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		},
+		"response_format": map[string]string{"type": "json_object"},
+	}, &response)
+	if err != nil {
+		return "", fmt.Errorf("openai: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("openai: no completion returned")
+	}
+	return response.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"lang_portal/internal/auth"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth rejects requests without a valid access token in the Authorization
+// header and records the user they are made by under UserIDKey
+func Auth(issuer *auth.Issuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing access token"})
+			return
+		}
+
+		claims, err := issuer.Verify(token, auth.TokenAccess, time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid access token"})
+			return
+		}
+
+		c.Set(UserIDKey, claims.Subject)
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit answers requests whose body is over limit bytes, or uploadLimit
+// bytes for multipart uploads, with 413 Request Entity Too Large, before any
+// handler reads it. Bodies without a length are read up to the limit, so a
+// client can't stream an endless one.
+func BodyLimit(limit, uploadLimit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		limit := limit
+		if c.ContentType() == "multipart/form-data" {
+			limit = uploadLimit
+		}
+		if c.Request.ContentLength > limit {
+			tooLarge(c, limit)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var maxBytes *http.MaxBytesError
+			if errors.As(err, &maxBytes) {
+				tooLarge(c, limit)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func tooLarge(c *gin.Context, limit int64) {
+	// The rest of the body isn't read, so the connection can't be reused
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "request body too large",
+		"max_bytes": limit,
+	})
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS lets browsers call the API from the given origins, or from any
+// origin if they include "*"
+func CORS(origins []string) gin.HandlerFunc {
+	anyOrigin := slices.Contains(origins, "*")
+	return func(c *gin.Context) {
+		if anyOrigin {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// The allowed origin depends on the request's
+			c.Writer.Header().Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); slices.Contains(origins, origin) {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match, "+RequestIDHeader+", "+APIVersionHeader)
+		// Let browser clients read the ID of their request, the version
+		// and deprecation of the API serving it and the ETag of the response
+		c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", "+APIVersionHeader+", Deprecation, Link, ETag")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagsKey is the gin context key holding the feature flags of the
+// user a request is made by
+const FeatureFlagsKey = "feature_flags"
+
+// featureFlags are a request's feature flags, looked up the first time a
+// handler asks for one
+type featureFlags struct {
+	once   sync.Once
+	lookup func() (map[string]bool, error)
+	flags  map[string]bool
+}
+
+func (f *featureFlags) enabled(name string) bool {
+	f.once.Do(func() {
+		flags, err := f.lookup()
+		if err != nil {
+			log.Printf("Failed to look up feature flags: %v", err)
+		}
+		f.flags = flags
+	})
+	return f.flags[name]
+}
+
+// FeatureFlags lets the handlers of a request ask which feature flags are on
+// for the user it is made by, with FeatureEnabled. lookup returns the flags
+// and is only called by requests that ask; if it fails every flag is off.
+func FeatureFlags(lookup func(c *gin.Context) (map[string]bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(FeatureFlagsKey, &featureFlags{lookup: func() (map[string]bool, error) {
+			return lookup(c)
+		}})
+		c.Next()
+	}
+}
+
+// FeatureEnabled reports whether a feature flag is on for the user a request
+// is made by. Flags are off for requests FeatureFlags didn't see.
+func FeatureEnabled(c *gin.Context, name string) bool {
+	flags, ok := c.Get(FeatureFlagsKey)
+	if !ok {
+		return false
+	}
+	f, ok := flags.(*featureFlags)
+	return ok && f.enabled(name)
+}
+
+// RequireFeature answers 404 Not Found to requests whose user doesn't have a
+// feature flag on, hiding the routes of an experimental feature from them
+func RequireFeature(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !FeatureEnabled(c, name) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.Next()
+	}
+}
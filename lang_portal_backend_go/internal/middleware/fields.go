@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldsParam selects the fields of the objects a response returns, e.g.
+// ?fields=id,urdu,english
+const FieldsParam = "fields"
+
+// Fields trims successful JSON responses to the fields the client selects
+// with the fields parameter, so clients on slow connections can skip what
+// they don't show. Lists keep their pagination and have the fields of each
+// item selected; other responses have their own fields selected. Unknown
+// fields are ignored.
+func Fields() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		selected := selectedFields(c.Query(FieldsParam))
+		if selected == nil {
+			c.Next()
+			return
+		}
+
+		w := &fieldsWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.body.Bytes()
+		if w.Status() == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			if trimmed, err := selectFields(body, selected); err == nil {
+				body = trimmed
+			}
+		}
+		if len(body) == 0 {
+			w.ResponseWriter.WriteHeaderNow()
+			return
+		}
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// selectedFields parses the fields parameter, returning nil if it selects
+// nothing
+func selectedFields(param string) map[string]bool {
+	var selected map[string]bool
+	for _, field := range strings.Split(param, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			if selected == nil {
+				selected = make(map[string]bool)
+			}
+			selected[field] = true
+		}
+	}
+	return selected
+}
+
+// selectFields trims a JSON body to the selected fields
+func selectFields(body []byte, selected map[string]bool) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Numbers are copied as they are, so IDs don't lose precision
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			selectEach(items, selected)
+		} else {
+			selectObject(v, selected)
+		}
+	case []interface{}:
+		selectEach(v, selected)
+	}
+	return json.Marshal(value)
+}
+
+func selectEach(items []interface{}, selected map[string]bool) {
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			selectObject(object, selected)
+		}
+	}
+}
+
+func selectObject(object map[string]interface{}, selected map[string]bool) {
+	for field := range object {
+		if !selected[field] {
+			delete(object, field)
+		}
+	}
+}
+
+// fieldsWriter holds a response's body back until its fields are selected
+type fieldsWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *fieldsWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *fieldsWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/cache"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader holds a key the client picks for a request it
+	// may retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a retry
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	// maxIdempotencyKeyLength is the longest key accepted
+	maxIdempotencyKeyLength = 255
+	// idempotencyTTL is how long a response is replayed for retries
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyPendingTTL is how long a request in progress holds its
+	// key, so a request the server never finished can be retried
+	idempotencyPendingTTL = 5 * time.Minute
+)
+
+// storedResponse is a response kept for retries of its request. A request
+// still in progress is stored without a status.
+type storedResponse struct {
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Location is kept for 201 and 202 responses pointing at what they
+	// created or queued
+	Location string `json:"location,omitempty"`
+	Body     []byte `json:"body,omitempty"`
+}
+
+// Idempotency replays the response of a POST, PUT or PATCH request whose
+// Idempotency-Key header the same user has sent before with the same
+// method and path, rather than running it again, so clients can retry
+// writes safely. A retry while the first request is still in progress gets
+// 409 Conflict. Server errors aren't kept, so they can be retried. Keys are
+// kept in store for 24 hours.
+func Idempotency(store cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			key = ""
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "idempotency key is too long"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		userID, _ := CurrentUserID(c)
+		cacheKey := fmt.Sprintf("idempotency:%d:%s %s:%s", userID, c.Request.Method, c.Request.URL.Path, key)
+		pending, _ := json.Marshal(storedResponse{})
+		added, err := store.Add(ctx, cacheKey, pending, idempotencyPendingTTL)
+		if err != nil {
+			// Requests aren't refused because the store can't be reached
+			log.Printf("Failed to check idempotency key: %v", err)
+			c.Next()
+			return
+		}
+		if !added {
+			replay(c, store, cacheKey)
+			return
+		}
+
+		w := &teeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() >= http.StatusInternalServerError {
+			if err := store.Delete(ctx, cacheKey); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		data, _ := json.Marshal(storedResponse{Status: w.Status(), ContentType: w.Header().Get("Content-Type"),
+			Location: w.Header().Get("Location"), Body: w.body.Bytes()})
+		if err := store.Set(ctx, cacheKey, data, idempotencyTTL); err != nil {
+			log.Printf("Failed to keep idempotent response: %v", err)
+		}
+	}
+}
+
+// replay answers a retry with the response stored under cacheKey, or 409
+// if the first request is still in progress
+func replay(c *gin.Context, store cache.Cache, cacheKey string) {
+	data, err := store.Get(c.Request.Context(), cacheKey)
+	var stored storedResponse
+	if err == nil {
+		err = json.Unmarshal(data, &stored)
+	}
+	if err == cache.ErrMiss || (err == nil && stored.Status == 0) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this idempotency key is in progress"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to replay response: %v", err)})
+		return
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	if stored.Location != "" {
+		c.Header("Location", stored.Location)
+	}
+	c.Data(stored.Status, stored.ContentType, stored.Body)
+	c.Abort()
+}
+
+// teeWriter keeps a copy of the body it writes
+type teeWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *teeWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LanguageParam is the query parameter a request selects the language of
+// the words, groups and statistics it is served with, e.g. ?language=ja
+const LanguageParam = "language"
+
+// LanguageKey is the gin context key holding the code of the language a
+// request selects
+const LanguageKey = "language"
+
+// Language records the language a request selects with the language query
+// parameter, for handlers to ask with CurrentLanguage. known reports
+// whether a language exists; requests selecting one that doesn't get 400.
+func Language(known func(ctx context.Context, code string) (bool, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.Query(LanguageParam)
+		if code == "" {
+			c.Next()
+			return
+		}
+		ok, err := known(c.Request.Context(), code)
+		if err != nil {
+			log.Printf("Failed to look up language %q: %v", code, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to look up language"})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unknown language " + strconv.Quote(code)})
+			return
+		}
+		c.Set(LanguageKey, code)
+		c.Next()
+	}
+}
+
+// CurrentLanguage returns the code of the language a request selects, or ""
+// if it selects none
+func CurrentLanguage(c *gin.Context) string {
+	return c.GetString(LanguageKey)
+}
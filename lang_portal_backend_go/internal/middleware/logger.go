@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"lang_portal/internal/config"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger logs requests with their IDs at the given log level: every request
+// at debug and info, client and server errors at warn, and server errors at
+// error
+func Logger(level string) gin.HandlerFunc {
+	minStatus := 0
+	switch level {
+	case config.LogWarn:
+		minStatus = http.StatusBadRequest
+	case config.LogError:
+		minStatus = http.StatusInternalServerError
+	}
+
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		if param.StatusCode < minStatus {
+			return ""
+		}
+		return fmt.Sprintf("%s - [%s] %s \"%s %s %s %d %s \"%s\" %s\"\n",
+			param.ClientIP,
+			param.TimeStamp.Format(time.RFC1123),
+			param.Keys[RequestIDKey],
+			param.Method,
+			param.Path,
+			param.Request.Proto,
+			param.StatusCode,
+			param.Latency,
+			param.Request.UserAgent(),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"lang_portal/internal/cache"
+	"lang_portal/internal/config"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits the requests of each client IP address, answering those
+// over the limit with 429 Too Many Requests. Each address has a bucket in
+// store that refills at the rate limit up to the burst, so replicas sharing
+// a Redis store share the limit. It does nothing if the limit is 0.
+func RateLimit(limit config.RateLimit, store cache.Cache) gin.HandlerFunc {
+	if limit.RequestsPerMinute == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	perSecond := float64(limit.RequestsPerMinute) / 60
+	return func(c *gin.Context) {
+		allowed, wait, err := store.Take(c.Request.Context(), "ratelimit:"+c.ClientIP(), perSecond, limit.Burst)
+		if err != nil {
+			// Requests aren't refused because the store can't be reached
+			log.Printf("Failed to check rate limit: %v", err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
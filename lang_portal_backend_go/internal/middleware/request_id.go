@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request to and from clients
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the context key of the request's ID
+const RequestIDKey = "request_id"
+
+// validRequestID matches the IDs a client may choose for its requests.
+// Anything else is replaced, so IDs are safe to log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, the client's X-Request-ID if it
+// sent a valid one, and returns it in the X-Request-ID header and the body
+// of JSON error responses, so a client's error can be matched to the
+// server's logs
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, id: id}
+		c.Next()
+	}
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDWriter adds the request's ID to JSON error responses
+type requestIDWriter struct {
+	gin.ResponseWriter
+	id string
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(data)
+	}
+
+	// Error responses are written as a whole, so the body is a complete
+	// object unless it isn't an object at all
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	body[RequestIDKey] = w.id
+	tagged, err := json.Marshal(body)
+	if err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(tagged); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *requestIDWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
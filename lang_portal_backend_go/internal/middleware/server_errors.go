@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"lang_portal/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxErrorLength is how much of a server error's message is recorded
+const maxErrorLength = 500
+
+// ServerErrors passes the requests answered with a 5xx status to record,
+// with the error of their JSON body, or the status text for other bodies,
+// e.g. those of recovered panics
+func ServerErrors(record func(models.ServerError)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &serverErrorWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError {
+			return
+		}
+		message := writer.message
+		if message == "" {
+			message = http.StatusText(status)
+		}
+		if len(message) > maxErrorLength {
+			message = strings.ToValidUTF8(message[:maxErrorLength], "")
+		}
+		record(models.ServerError{
+			At:        time.Now().UTC(),
+			RequestID: c.GetString(RequestIDKey),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    status,
+			Error:     message,
+		})
+	}
+}
+
+// serverErrorWriter keeps the error of a server error's JSON body
+type serverErrorWriter struct {
+	gin.ResponseWriter
+	message string
+}
+
+func (w *serverErrorWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusInternalServerError && w.message == "" &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		// Error responses are written as a whole
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			w.message = body.Error
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *serverErrorWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
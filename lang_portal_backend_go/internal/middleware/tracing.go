@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"lang_portal/internal/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing starts a span for every request, continuing the caller's trace if
+// it sent a traceparent header, so the service calls and SQL queries the
+// request makes are traced under it. It does nothing if tracer is nil.
+func Tracing(tracer *tracing.Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tracer == nil {
+			c.Next()
+			return
+		}
+
+		ctx, span := tracer.StartTrace(c.Request.Context(), c.Request.Method, tracing.KindServer, c.GetHeader(tracing.TraceParentHeader))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// Spans are named by route rather than path, so requests for
+		// different IDs are grouped together
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.response.status_code", c.Writer.Status())
+		if id, ok := c.Get(RequestIDKey); ok {
+			span.SetAttribute("request_id", id)
+		}
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := c.Errors.Last(); err != nil {
+				span.SetError(err)
+			} else {
+				span.SetError(errors.New(http.StatusText(c.Writer.Status())))
+			}
+		}
+	}
+}
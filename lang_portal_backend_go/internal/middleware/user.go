@@ -0,0 +1,18 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// UserIDKey is the gin context key holding the ID of the user a request is
+// made by
+const UserIDKey = "user_id"
+
+// CurrentUserID returns the ID of the user a request is made by, if it has
+// been identified
+func CurrentUserID(c *gin.Context) (int64, bool) {
+	id, ok := c.Get(UserIDKey)
+	if !ok {
+		return 0, false
+	}
+	userID, ok := id.(int64)
+	return userID, ok
+}
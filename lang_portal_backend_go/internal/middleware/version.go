@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionHeader carries the version of the API a request is served by,
+// and on unversioned paths the version a client asks for
+const APIVersionHeader = "API-Version"
+
+// APIVersionKey is the context key of the version serving the request
+const APIVersionKey = "api_version"
+
+// Versions of the API. A new version is added when a change would break
+// existing clients; handlers branch on APIVersion where versions differ.
+const (
+	APIv1 = 1
+	// LatestAPIVersion is the newest version
+	LatestAPIVersion = APIv1
+)
+
+// APIVersion serves the requests of a versioned path, e.g. /api/v1, with
+// the given version
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setAPIVersion(c, version)
+		c.Next()
+	}
+}
+
+// NegotiateAPIVersion serves the requests of the unversioned /api paths
+// with the version a client asks for in the API-Version header, or with
+// fallback if it asks for none, so clients written before versioning keep
+// working. Clients asking for a version that doesn't exist get 400.
+func NegotiateAPIVersion(fallback int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := fallback
+		if value := strings.TrimPrefix(strings.TrimSpace(c.GetHeader(APIVersionHeader)), "v"); value != "" {
+			requested, err := strconv.Atoi(value)
+			if err != nil || requested < APIv1 || requested > LatestAPIVersion {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "unsupported API version, the latest is " + strconv.Itoa(LatestAPIVersion),
+				})
+				return
+			}
+			version = requested
+		}
+		setAPIVersion(c, version)
+		c.Next()
+	}
+}
+
+// Deprecated marks the responses of paths under prefix as deprecated with
+// the Deprecation header, and links to the same path under successor,
+// e.g. /api/words to /api/v1/words
+func Deprecated(prefix, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		path := successor + strings.TrimPrefix(c.Request.URL.Path, prefix)
+		c.Header("Link", "<"+path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// CurrentAPIVersion returns the version serving the request, the latest if
+// no version middleware ran
+func CurrentAPIVersion(c *gin.Context) int {
+	if version, ok := c.Get(APIVersionKey); ok {
+		return version.(int)
+	}
+	return LatestAPIVersion
+}
+
+func setAPIVersion(c *gin.Context, version int) {
+	c.Set(APIVersionKey, version)
+	c.Header(APIVersionHeader, strconv.Itoa(version))
+}
@@ -0,0 +1,430 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"lang_portal/db/migrations"
+	sqlitedb "lang_portal/internal/db"
+	"lang_portal/internal/db/migrator"
+	"lang_portal/internal/tracing"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DefaultQueryTimeout is how long a query may run before it is cancelled
+const DefaultQueryTimeout = 10 * time.Second
+
+// Writes that find the database locked by another process are retried
+// busyRetries times, waiting busyBackoff longer before each retry
+const (
+	busyRetries = 5
+	busyBackoff = 50 * time.Millisecond
+)
+
+// DB is the database. Queries run through it or a transaction begun on it
+// are cancelled once they run longer than QueryTimeout, so a slow query
+// can't hang a request, and traced as spans of the trace in their context.
+//
+// SQLite allows one writer at a time and fails other writers with
+// SQLITE_BUSY, so unless ConcurrentWrites is set, transactions and writes
+// outside them take turns: each waits until the one before it is committed
+// or rolled back.
+type DB struct {
+	*sql.DB
+	// QueryTimeout bounds each query, 0 for no bound
+	QueryTimeout time.Duration
+	// ConcurrentWrites lets transactions run side by side, for databases
+	// such as PostgreSQL that handle concurrent writers themselves
+	ConcurrentWrites bool
+	// writer holds a token while a transaction or write has the turn
+	writer chan struct{}
+}
+
+func NewDB(db *sql.DB) *DB {
+	return &DB{DB: db, QueryTimeout: DefaultQueryTimeout, writer: make(chan struct{}, 1)}
+}
+
+// Tx is a transaction begun on a DB. Its queries are bounded like those of
+// the DB.
+type Tx struct {
+	*sql.Tx
+	db *DB
+	// done gives the writer's turn back once, on commit or rollback
+	done sync.Once
+	// afterCommit are run once the transaction is committed
+	afterCommit []func()
+}
+
+// BeginTx begins a transaction, which is rolled back if ctx is cancelled
+// before it is committed. It waits for the writer's turn, which the
+// transaction holds until it is committed or rolled back.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if err := db.lock(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		db.unlock()
+		return nil, err
+	}
+	return &Tx{Tx: tx, db: db}, nil
+}
+
+// ExecContext runs a write once it has the writer's turn, retrying it while
+// another process has the database locked
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	// The span includes the wait for the writer's turn
+	ctx, span := startQuery(ctx, query)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if err := db.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer db.unlock()
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	for retry := 0; ; retry++ {
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		if !IsBusy(err) || retry == busyRetries {
+			return result, err
+		}
+		span.SetAttribute("db.retries", retry+1)
+		if err := backoff(ctx, retry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WithTx runs fn in a transaction, which is committed if fn returns nil and
+// rolled back if it returns an error or panics. While another process has
+// the database locked, the transaction is rolled back and fn run again in a
+// new one, so fn must not change anything outside the transaction.
+func (db *DB) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	for retry := 0; ; retry++ {
+		err := db.runTx(ctx, fn)
+		if !IsBusy(err) || retry == busyRetries {
+			return err
+		}
+		if err := backoff(ctx, retry); err != nil {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in a transaction once
+func (db *DB) runTx(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// Restore replaces the contents of the database with those of the SQLite
+// backup at path once it has the writer's turn
+func (db *DB) Restore(ctx context.Context, path string) error {
+	if err := db.lock(ctx); err != nil {
+		return err
+	}
+	defer db.unlock()
+	return sqlitedb.Restore(ctx, db.DB, path)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	ctx, cancel := db.withTimeout(ctx)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return newRows(rows, err, cancel)
+}
+
+// QueryRowContext runs a query returning at most one row. Writes returning
+// rows must run in a transaction to take the writer's turn.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	ctx, cancel := db.withTimeout(ctx)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	span.SetError(row.Err())
+	return &Row{Row: row, cancel: cancel}
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	ctx, cancel := tx.db.withTimeout(ctx)
+	defer cancel()
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	span.SetError(err)
+	return result, err
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	ctx, cancel := tx.db.withTimeout(ctx)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return newRows(rows, err, cancel)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	ctx, cancel := tx.db.withTimeout(ctx)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	span.SetError(row.Err())
+	return &Row{Row: row, cancel: cancel}
+}
+
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.done.Do(tx.db.unlock)
+	if err == nil {
+		for _, fn := range tx.afterCommit {
+			fn()
+		}
+	}
+	return err
+}
+
+// AfterCommit runs fn once the transaction is committed and has given back
+// the writer's turn, so fn may write. fn isn't run if the transaction is
+// rolled back, including when WithTx runs it again.
+func (tx *Tx) AfterCommit(fn func()) {
+	tx.afterCommit = append(tx.afterCommit, fn)
+}
+
+func (tx *Tx) Rollback() error {
+	defer tx.done.Do(tx.db.unlock)
+	return tx.Tx.Rollback()
+}
+
+// startQuery starts a span for a SQL statement in the trace of ctx. A
+// query's span ends when it returns, before its rows are read.
+func startQuery(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	if tracing.FromContext(ctx) == nil {
+		return ctx, nil
+	}
+	ctx, span := tracing.Start(ctx, queryName(query), tracing.KindClient)
+	span.SetAttribute("db.statement", query)
+	return ctx, span
+}
+
+// queryName names a SQL statement by its sqlc query name, e.g.
+// GetFlashcardDeck, or else by its first keyword, e.g. SELECT
+func queryName(query string) string {
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-- name:") {
+			if fields := strings.Fields(strings.TrimPrefix(line, "-- name:")); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		return strings.ToUpper(strings.Fields(line)[0])
+	}
+	return "SQL"
+}
+
+// IsBusy reports whether err is, or was formatted from, SQLite's error for
+// a database locked by another writer
+func IsBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	// Errors wrapped with %v only keep the message
+	return strings.Contains(err.Error(), sqlite3.ErrBusy.Error()) ||
+		strings.Contains(err.Error(), sqlite3.ErrLocked.Error())
+}
+
+// backoff waits before the given retry of a write that found the database
+// busy, giving up when ctx is done
+func backoff(ctx context.Context, retry int) error {
+	select {
+	case <-time.After(time.Duration(retry+1) * busyBackoff):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lock waits for the writer's turn, giving up when ctx is done
+func (db *DB) lock(ctx context.Context) error {
+	if db.ConcurrentWrites {
+		return nil
+	}
+	select {
+	case db.writer <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unlock gives the writer's turn to the next transaction or write
+func (db *DB) unlock() {
+	if !db.ConcurrentWrites {
+		<-db.writer
+	}
+}
+
+// withTimeout bounds ctx by the query timeout
+func (db *DB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, db.QueryTimeout)
+}
+
+// Rows are the rows of a query, which is bounded by the query timeout
+// until they are closed, as reading them is part of the query
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+// newRows wraps the rows of a query run with a context released by cancel
+func newRows(rows *sql.Rows, err error, cancel context.CancelFunc) (*Rows, error) {
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+// Close closes the rows and releases the query's context
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// Row is the result of a query for at most one row, which is bounded by
+// the query timeout until the row is scanned
+type Row struct {
+	*sql.Row
+	cancel context.CancelFunc
+}
+
+// Scan copies the row's columns into dest and releases the query's context
+func (r *Row) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return r.Row.Scan(dest...)
+}
+
+// SQLQuerier runs the queries of a DB or transaction for code written
+// against database/sql, like sqlc's, which reads *sql.Rows. Writes are run
+// as the DB's are; reads are bounded by their context alone, as the query
+// timeout of rows read after the query returns can't be released when they
+// are closed.
+type SQLQuerier struct {
+	exec func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	raw  interface {
+		PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+}
+
+// SQL returns the DB's queries for code written against database/sql
+func (db *DB) SQL() *SQLQuerier {
+	return &SQLQuerier{exec: db.ExecContext, raw: db.DB}
+}
+
+// SQL returns the transaction's queries for code written against
+// database/sql
+func (tx *Tx) SQL() *SQLQuerier {
+	return &SQLQuerier{exec: tx.ExecContext, raw: tx.Tx}
+}
+
+func (q *SQLQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.exec(ctx, query, args...)
+}
+
+func (q *SQLQuerier) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return q.raw.PrepareContext(ctx, query)
+}
+
+func (q *SQLQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	rows, err := q.raw.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return rows, err
+}
+
+func (q *SQLQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	row := q.raw.QueryRowContext(ctx, query, args...)
+	span.SetError(row.Err())
+	return row
+}
+
+// NewTestDB creates an in-memory database with the schema of the
+// migrations and a few words in two groups
+func NewTestDB() (*DB, error) {
+	conn, err := sqlitedb.Open(":memory:")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrator.NewMigrator(conn, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Up(); err != nil {
+		return nil, err
+	}
+
+	// Insert test data. The first migration creates the groups.
+	_, err = conn.Exec(`
+		INSERT INTO words (id, script, transliteration, english) VALUES
+		(1, 'سلام', 'salaam', 'hello'),
+		(2, 'خدا حافظ', 'khuda hafiz', 'goodbye'),
+		(3, 'شکریہ', 'shukriya', 'thank you');
+
+		INSERT INTO words_groups (word_id, group_id) VALUES
+		(1, 1),
+		(2, 1),
+		(3, 1),
+		(1, 2),
+		(2, 2);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDB(conn), nil
+}
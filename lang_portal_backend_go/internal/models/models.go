@@ -0,0 +1,1253 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Core domain models
+// Word is a word of a language. Urdu and Urdlish hold the word in the
+// language's script and its transliteration, whatever the language; they
+// keep the names they had when every word was Urdu.
+type Word struct {
+	ID      int64  `json:"id"`
+	Urdu    string `json:"urdu"`
+	Urdlish string `json:"urdlish"`
+	// English is the word's first sense
+	English string `json:"english"`
+	Parts   string `json:"parts"` // JSON string
+	// Language is the code of the word's language, e.g. ur
+	Language string `json:"language"`
+	// Senses are the word's English meanings, most common first. Words are
+	// created with the one sense of English when none are given.
+	Senses []WordSense `json:"senses,omitempty"`
+}
+
+// WordSense is one of a word's English meanings
+type WordSense struct {
+	ID      int64  `json:"id"`
+	English string `json:"english"`
+	// Register is how formal the sense is, e.g. formal or colloquial, or
+	// empty if it is neutral
+	Register string `json:"register,omitempty"`
+	// UsageNote says when the sense is used
+	UsageNote string `json:"usage_note,omitempty"`
+}
+
+type Group struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+}
+
+// DefaultLanguage is the code of the language of words and groups created
+// without one, and of everything created before there were other languages
+const DefaultLanguage = "ur"
+
+// Language is a language words can be studied in
+type Language struct {
+	// Code is the language's ISO 639 code, e.g. ur
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Script is the ISO 15924 code of the script words are written in,
+	// e.g. Arab
+	Script string `json:"script"`
+	// Direction is the direction the script is written in, ltr or rtl
+	Direction string `json:"direction"`
+	// Transliteration names the romanization words are transliterated with
+	Transliteration string    `json:"transliteration"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Sentence is an example sentence of the corpus, linked to the words it
+// contains
+type Sentence struct {
+	ID       int64  `json:"id"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+	// Transliteration, English and Source, e.g. the book a sentence is
+	// taken from, are empty when not known
+	Transliteration string    `json:"transliteration"`
+	English         string    `json:"english"`
+	Source          string    `json:"source"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Occurrences are where words occur in the sentence, in order
+	Occurrences []WordOccurrence `json:"occurrences"`
+}
+
+// WordOccurrence is where a word occurs in a sentence. Position and Length
+// are in characters (Unicode code points) of the sentence's text.
+type WordOccurrence struct {
+	WordID   int64 `json:"word_id"`
+	Position int   `json:"position"`
+	Length   int   `json:"length"`
+}
+
+// SentenceImport is the outcome of importing sentences
+type SentenceImport struct {
+	Language string `json:"language"`
+	Created  int    `json:"created"`
+	// Existing is the number of sentences already in the corpus, which
+	// aren't imported again
+	Existing int `json:"existing"`
+	// Occurrences is the number of word occurrences found in the sentences
+	// created
+	Occurrences int `json:"occurrences"`
+}
+
+// SentenceLinks is the outcome of linking the corpus to the words again
+type SentenceLinks struct {
+	Language    string `json:"language"`
+	Sentences   int    `json:"sentences"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// Passage is a graded reading passage
+type Passage struct {
+	ID       int64  `json:"id"`
+	Language string `json:"language"`
+	Title    string `json:"title"`
+	// Level is the passage's CEFR level, A1 to C2
+	Level string `json:"level"`
+	// Text is left out of passage lists
+	Text      string    `json:"text,omitempty"`
+	Source    string    `json:"source"`
+	WordCount int       `json:"word_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PassageReading is a passage split into segments for reading, with the
+// words they read as and how well the learner knows them
+type PassageReading struct {
+	Passage
+	Segments []PassageSegment `json:"segments"`
+	// Words are the words the segments read as, to gloss them with
+	Words []PassageWord `json:"words"`
+	// UnknownCount is the number of word segments the learner doesn't know
+	// yet
+	UnknownCount int `json:"unknown_count"`
+}
+
+// PassageSegment is a word of a passage, or the text between words.
+// Concatenated, the segments' texts are the passage's.
+type PassageSegment struct {
+	Text     string `json:"text"`
+	Position int    `json:"position"` // in characters of the passage's text
+	// Type is word or text
+	Type string `json:"type"`
+	// WordIDs are the words a word segment reads as, empty for one not in
+	// the vocabulary
+	WordIDs []int64 `json:"word_ids,omitempty"`
+	// Stage is the learning stage of the best known of the words: unlisted,
+	// new, learning, young or mature
+	Stage string `json:"stage,omitempty"`
+	// Unknown marks word segments the learner doesn't know yet: not in the
+	// vocabulary, new or still being learned
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// PassageWord is a word of a passage with the learner's stage of it
+type PassageWord struct {
+	Word
+	Stage string `json:"stage"`
+}
+
+// AddedWord is a word of a passage added to a group
+type AddedWord struct {
+	WordID  int64 `json:"word_id"`
+	GroupID int64 `json:"group_id"`
+	// Created is true for a word that wasn't in the vocabulary and was
+	// captured into the inbox
+	Created bool `json:"created"`
+}
+
+// GrammarTopic is a lesson on a point of grammar of a language, with
+// exercises practising it
+type GrammarTopic struct {
+	ID       int64  `json:"id"`
+	Language string `json:"language"`
+	// Slug names the topic within its language, e.g. postpositions
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+	// Level is the topic's CEFR level, A1 to C2
+	Level   string `json:"level"`
+	Summary string `json:"summary"`
+	// Lesson is the lesson's text in Markdown, left out of topic lists
+	Lesson string `json:"lesson,omitempty"`
+	// Position orders the topics of a level
+	Position      int       `json:"position"`
+	ExerciseCount int       `json:"exercise_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// Progress is the learner's, left out of topics being edited
+	Progress *GrammarProgress `json:"progress,omitempty"`
+	// Exercises are left out of topic lists
+	Exercises []GrammarExercise `json:"exercises,omitempty"`
+}
+
+// GrammarExercise is an exercise of a grammar topic
+type GrammarExercise struct {
+	ID       int64 `json:"id"`
+	TopicID  int64 `json:"topic_id"`
+	Position int   `json:"position"`
+	// AnswerMode is multiple_choice or typed
+	AnswerMode string `json:"answer_mode"`
+	Prompt     string `json:"prompt"`
+	English    string `json:"english,omitempty"`
+	// Options are the choices of a multiple choice exercise
+	Options []string `json:"options,omitempty"`
+	// Answer and Explanation are left out of exercises shown to learners
+	Answer      string `json:"answer,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	// Result is the learner's last answer's: unanswered, correct, near_miss
+	// or wrong
+	Result string `json:"result,omitempty"`
+}
+
+// GrammarProgress is a learner's progress through a grammar topic
+type GrammarProgress struct {
+	TopicID int64 `json:"topic_id"`
+	// Status is not_started, in_progress or completed
+	Status string `json:"status"`
+	// Solved counts the exercises answered correctly, or as near misses
+	Solved    int        `json:"solved"`
+	Exercises int        `json:"exercises"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// CompletedAt is when the learner first solved every exercise, or read
+	// a lesson without exercises
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// GrammarAnswerResult is the outcome of answering a grammar exercise
+type GrammarAnswerResult struct {
+	ExerciseID int64 `json:"exercise_id"`
+	// Result is correct, near_miss or wrong
+	Result        string          `json:"result"`
+	Answer        string          `json:"answer"`
+	CorrectAnswer string          `json:"correct_answer"`
+	Explanation   string          `json:"explanation,omitempty"`
+	Progress      GrammarProgress `json:"progress"`
+}
+
+// GrammarImport is the outcome of importing grammar topics
+type GrammarImport struct {
+	Language  string `json:"language"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Exercises int    `json:"exercises"`
+}
+
+type QuizConfig struct {
+	GroupID    int64  `json:"group_id" binding:"required"`
+	WordCount  int    `json:"word_count" binding:"required,min=1"`
+	Difficulty string `json:"difficulty" binding:"required,oneof=beginner intermediate advanced"`
+}
+
+type StudySession struct {
+	ID              int64     `json:"id"`
+	GroupID         int64     `json:"group_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	StudyActivityID int64     `json:"study_activity_id"`
+}
+
+type StudyActivity struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	URL          *string   `json:"url,omitempty"`
+	ThumbnailURL *string   `json:"thumbnail_url,omitempty"`
+	Description  *string   `json:"description,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WordReviewItem represents a review of a word in a study session
+type WordReviewItem struct {
+	WordID         int64     `json:"word_id"`
+	StudySessionID int64     `json:"study_session_id"`
+	Correct        bool      `json:"correct"`
+	NearMiss       bool      `json:"near_miss,omitempty"` // typed answer accepted despite a small typo
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// QuizQuestion is a question served in a quiz session, kept so answers can
+// be checked on the server
+type QuizQuestion struct {
+	ID             int64    `json:"id"`
+	StudySessionID int64    `json:"study_session_id"`
+	WordID         int64    `json:"word_id"`
+	Direction      string   `json:"direction"`
+	AnswerMode     string   `json:"answer_mode"`
+	Options        []string `json:"options"`
+	HintsUsed      int      `json:"hints_used"`
+	CorrectAnswer  string   `json:"-"`
+}
+
+// QuizQuestionResult is how a quiz question was answered
+type QuizQuestionResult struct {
+	QuestionID    int64      `json:"question_id"`
+	WordID        int64      `json:"word_id"`
+	Direction     string     `json:"direction"`
+	AnswerMode    string     `json:"answer_mode"`
+	CorrectAnswer string     `json:"correct_answer"`
+	Answer        *string    `json:"answer"`
+	Result        string     `json:"result"`
+	HintsUsed     int        `json:"hints_used"`
+	AnsweredAt    *time.Time `json:"answered_at,omitempty"`
+}
+
+// ListeningClip is an audio clip for listening practice. The transcript is
+// only shown once an attempt is scored.
+type ListeningClip struct {
+	ID            int64               `json:"id"`
+	GroupID       int64               `json:"group_id"`
+	Title         string              `json:"title"`
+	AudioURL      string              `json:"audio_url"`
+	Transcript    string              `json:"-"`
+	Difficulty    string              `json:"difficulty"`
+	QuestionCount int                 `json:"question_count"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Questions     []ListeningQuestion `json:"questions,omitempty"`
+}
+
+// ListeningQuestion is a comprehension question about a listening clip
+type ListeningQuestion struct {
+	ID            int64    `json:"id"`
+	ClipID        int64    `json:"clip_id"`
+	WordID        *int64   `json:"word_id,omitempty"`
+	Prompt        string   `json:"prompt"`
+	Options       []string `json:"options"`
+	CorrectAnswer string   `json:"-"`
+}
+
+type Pagination struct {
+	TotalItems int `json:"total_items"`
+	// CurrentPage is 0 for pages of a list read by cursor
+	CurrentPage  int `json:"current_page"`
+	TotalPages   int `json:"total_pages"`
+	ItemsPerPage int `json:"items_per_page"`
+	// NextCursor continues a list read by cursor after this page. It is
+	// empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Study Activities database methods
+func (db *DB) GetStudyActivities(ctx context.Context, limit, offset int) ([]*StudyActivity, error) {
+	query := `
+		SELECT id, name, url, thumbnail_url, description, created_at
+		FROM study_activities
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []*StudyActivity
+	for rows.Next() {
+		activity := &StudyActivity{}
+		var (
+			url          sql.NullString
+			thumbnailURL sql.NullString
+			description  sql.NullString
+			createdAt    sql.NullTime
+		)
+		err := rows.Scan(
+			&activity.ID,
+			&activity.Name,
+			&url,
+			&thumbnailURL,
+			&description,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if url.Valid {
+			activity.URL = &url.String
+		}
+		if thumbnailURL.Valid {
+			activity.ThumbnailURL = &thumbnailURL.String
+		}
+		if description.Valid {
+			activity.Description = &description.String
+		}
+		if createdAt.Valid {
+			activity.CreatedAt = createdAt.Time
+		}
+		activities = append(activities, activity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+func (db *DB) CountStudyActivities(ctx context.Context) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM study_activities").Scan(&count)
+	return count, err
+}
+
+func (db *DB) GetStudyActivity(ctx context.Context, id int64) (*StudyActivity, error) {
+	var (
+		activity     StudyActivity
+		url          sql.NullString
+		thumbnailURL sql.NullString
+		description  sql.NullString
+		createdAt    sql.NullTime
+	)
+	err := db.QueryRowContext(ctx, `
+		SELECT id, name, url, thumbnail_url, description, created_at
+		FROM study_activities WHERE id = ?
+	`, id).Scan(
+		&activity.ID,
+		&activity.Name,
+		&url,
+		&thumbnailURL,
+		&description,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if url.Valid {
+		activity.URL = &url.String
+	}
+	if thumbnailURL.Valid {
+		activity.ThumbnailURL = &thumbnailURL.String
+	}
+	if description.Valid {
+		activity.Description = &description.String
+	}
+	if createdAt.Valid {
+		activity.CreatedAt = createdAt.Time
+	}
+
+	return &activity, nil
+}
+
+func (db *DB) GetStudyActivitySessions(ctx context.Context, activityID int64, limit, offset int) ([]*StudySession, error) {
+	query := `
+		SELECT s.id, s.group_id, s.study_activity_id, s.created_at
+		FROM study_sessions s
+		WHERE s.study_activity_id = ?
+		ORDER BY s.created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := db.QueryContext(ctx, query, activityID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*StudySession
+	for rows.Next() {
+		session := &StudySession{}
+		err := rows.Scan(
+			&session.ID,
+			&session.GroupID,
+			&session.StudyActivityID,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (db *DB) CountStudyActivitySessions(ctx context.Context, activityID int64) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM study_sessions WHERE study_activity_id = ?",
+		activityID,
+	).Scan(&count)
+	return count, err
+}
+
+func (db *DB) CreateStudySession(ctx context.Context, session *StudySession) error {
+	result, err := db.ExecContext(ctx,
+		"INSERT INTO study_sessions (group_id, study_activity_id, created_at) VALUES (?, ?, ?)",
+		session.GroupID,
+		session.StudyActivityID,
+		session.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	session.ID = id
+	return nil
+}
+
+// WordLearningState is the spaced repetition schedule of a single word
+type WordLearningState struct {
+	WordID         int64      `json:"word_id"`
+	EaseFactor     float64    `json:"ease_factor"`
+	IntervalDays   int        `json:"interval_days"`
+	Repetitions    int        `json:"repetitions"`
+	Lapses         int        `json:"lapses"`
+	DueAt          time.Time  `json:"due_at"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty"`
+	// RelearningStep is the 1-based relearning step of a forgotten word, or 0
+	RelearningStep int `json:"relearning_step"`
+	// LapsedIntervalDays is the interval restored once relearning is done
+	LapsedIntervalDays int `json:"lapsed_interval_days,omitempty"`
+}
+
+// Goal is a learning target: reviews per day, new words learned per week or
+// accuracy over the week
+type Goal struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Target    float64   `json:"target"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// User is a learner whose study history is kept apart from other users'.
+// Guests are purged with their history once they expire.
+type User struct {
+	ID             int64      `json:"id"`
+	Username       string     `json:"username"`
+	Email          *string    `json:"email,omitempty"`
+	Guest          bool       `json:"guest"`
+	GuestExpiresAt *time.Time `json:"guest_expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// LeaderboardSettings controls whether a user appears on leaderboards and the
+// name they appear under
+type LeaderboardSettings struct {
+	OptIn       bool   `json:"opt_in"`
+	DisplayName string `json:"display_name"`
+}
+
+// Class is a teacher's class of students. InviteCode is only shown to the
+// teacher.
+type Class struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	TeacherID  int64     `json:"teacher_id"`
+	Role       string    `json:"role"` // the caller's role: teacher or student
+	InviteCode string    `json:"invite_code,omitempty"`
+	Students   int       `json:"students"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ClassAssignment is a group set as homework for a class
+type ClassAssignment struct {
+	ID        int64     `json:"id"`
+	ClassID   int64     `json:"class_id"`
+	GroupID   int64     `json:"group_id"`
+	GroupName string    `json:"group_name"`
+	DueAt     time.Time `json:"due_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NotificationPreferences are the notifications a user wants and where to
+// email them. Email is empty to use the account's email address.
+type NotificationPreferences struct {
+	Email             string `json:"email"`
+	StreakReminders   bool   `json:"streak_reminders"`
+	WeeklyDigest      bool   `json:"weekly_digest"`
+	HomeworkReminders bool   `json:"homework_reminders"`
+}
+
+// NotificationDelivery is a notification sent or attempted
+type NotificationDelivery struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Channel   string     `json:"channel"`
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	Status    string     `json:"status"` // pending, sent or failed
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+// NotificationDispatch counts the notifications a dispatch sent and failed
+// to send
+type NotificationDispatch struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
+
+// BotLink is the Telegram or Discord chat a user is sent a daily quiz
+// question in, from QuizHour (UTC). Until Verified, the code the bot sent to
+// the chat must be entered, before CodeExpiresAt.
+type BotLink struct {
+	Platform      string     `json:"platform"`
+	ChatID        string     `json:"chat_id"`
+	QuizHour      int        `json:"quiz_hour"`
+	Verified      bool       `json:"verified"`
+	CodeExpiresAt *time.Time `json:"code_expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// PushSubscription is a browser the user gets Web Push notifications in,
+// and which of them it wants
+type PushSubscription struct {
+	ID           int64     `json:"id"`
+	Endpoint     string    `json:"endpoint"`
+	ReviewsDue   bool      `json:"reviews_due"`
+	StreakAtRisk bool      `json:"streak_at_risk"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CalendarToken is the secret a user's calendar feed is fetched with. The
+// token is only shown when it is created.
+type CalendarToken struct {
+	Token string `json:"token"`
+	// URL is the feed to subscribe to, with the token
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKey is a key an external automation sends words to the user's inbox
+// with. The key itself is only shown when it is created.
+type APIKey struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the key, to tell keys apart by
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// InboxWord is a word captured into the inbox group
+type InboxWord struct {
+	WordID  int64  `json:"word_id"`
+	GroupID int64  `json:"group_id"`
+	Urdu    string `json:"urdu"`
+	Urdlish string `json:"urdlish"`
+	English string `json:"english"`
+	// Created is false for a word that already existed
+	Created bool `json:"created"`
+	// Suggested lists the fields filled in by the translation provider
+	Suggested []string `json:"suggested"`
+}
+
+// Changes are what changed since an offline client's sync token. Full
+// changes are a copy of everything the client syncs, to replace what it
+// has, for a first sync or a token too old to have kept the deletions
+// since.
+type Changes struct {
+	// Token is the sync token to pull the next changes with
+	Token    string           `json:"token"`
+	Full     bool             `json:"full"`
+	Words    []ChangedWord    `json:"words"`
+	Groups   []ChangedGroup   `json:"groups"`
+	Sessions []ChangedSession `json:"study_sessions"`
+	Reviews  []ChangedReview  `json:"word_review_items"`
+	Deleted  DeletedIDs       `json:"deleted"`
+}
+
+// ChangedWord is a word added or changed since a sync token
+type ChangedWord struct {
+	Word
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChangedGroup is a group added or changed since a sync token, including
+// its words being added or removed
+type ChangedGroup struct {
+	Group
+	WordIDs   []int64   `json:"word_ids"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChangedSession is a study session added since a sync token. ClientID is
+// set for sessions pushed by an offline client.
+type ChangedSession struct {
+	ID              int64     `json:"id"`
+	ClientID        string    `json:"client_id,omitempty"`
+	GroupID         int64     `json:"group_id"`
+	StudyActivityID int64     `json:"study_activity_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ChangedReview is a review recorded or changed since a sync token
+type ChangedReview struct {
+	WordReviewItem
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DeletedIDs are the IDs of what was deleted since a sync token. The
+// reviews of deleted sessions are deleted with them.
+type DeletedIDs struct {
+	Words    []int64 `json:"words"`
+	Groups   []int64 `json:"groups"`
+	Sessions []int64 `json:"study_sessions"`
+}
+
+// OfflineSession is a study session an offline client created, identified
+// by the ID the client gave it
+type OfflineSession struct {
+	ClientID        string    `json:"client_id"`
+	GroupID         int64     `json:"group_id"`
+	StudyActivityID int64     `json:"study_activity_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// OfflineReview is a review an offline client recorded, in a session
+// synced before or pushed with it by its client ID
+type OfflineReview struct {
+	StudySessionID  int64     `json:"study_session_id,omitempty"`
+	SessionClientID string    `json:"session_client_id,omitempty"`
+	WordID          int64     `json:"word_id"`
+	Correct         bool      `json:"correct"`
+	NearMiss        bool      `json:"near_miss,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Push results
+const (
+	PushCreated   = "created"
+	PushDuplicate = "duplicate"
+	PushConflict  = "conflict"
+	PushRejected  = "rejected"
+)
+
+// PushResult is what became of each session and review an offline client
+// pushed, in the order they were pushed
+type PushResult struct {
+	Sessions []PushedSession `json:"study_sessions"`
+	Reviews  []PushedReview  `json:"word_review_items"`
+}
+
+// PushedSession is what became of a pushed session: created, a duplicate of
+// one pushed before, or rejected with an error
+type PushedSession struct {
+	ClientID string `json:"client_id"`
+	ID       int64  `json:"id,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PushedReview is what became of a pushed review: created, a duplicate of
+// the review the server has, a conflict with a different one the server
+// kept, or rejected with an error
+type PushedReview struct {
+	StudySessionID int64  `json:"study_session_id,omitempty"`
+	WordID         int64  `json:"word_id"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+}
+
+// Backup is a copy of the database kept in the backup directory
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Job statuses. A failed attempt that will be retried leaves the job
+// queued.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is work run in the background rather than while a request waits
+type Job struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"-"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	// Payload is the job's input, as JSON
+	Payload     json.RawMessage `json:"-"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	// Result is what a succeeded job returned, as JSON
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is why the last attempt failed
+	Error string `json:"error,omitempty"`
+	// RunAt is when the job is next run, or was last
+	RunAt      time.Time  `json:"run_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Sources of word audio
+const (
+	// AudioExisting is audio the word already had, e.g. from a content pack
+	AudioExisting = "existing"
+	// AudioCached is speech synthesized before for the same text and voice
+	AudioCached = "cached"
+	// AudioSynthesized is speech synthesized for the request
+	AudioSynthesized = "synthesized"
+)
+
+// WordAudio is where a word can be heard, and where the audio came from
+type WordAudio struct {
+	WordID   int64  `json:"word_id"`
+	AudioURL string `json:"audio_url"`
+	Source   string `json:"source"`
+}
+
+// WordImage is where a word's picture, and a thumbnail of it, can be seen
+type WordImage struct {
+	WordID       int64     `json:"word_id"`
+	ImageURL     string    `json:"image_url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WordRendering are hints for rendering a word in its script, e.g. Urdu in
+// Nastaleeq
+type WordRendering struct {
+	// FontStyle is the style the word is best shown in, nastaleeq or naskh,
+	// or empty for the usual one of its language
+	FontStyle string `json:"font_style,omitempty"`
+	// Diacritized is the word with its short vowels and other marks written
+	// out, e.g. کِتاب
+	Diacritized string `json:"diacritized,omitempty"`
+	// LigatureSpellings are spellings of the word whose letters must be
+	// joined as written, e.g. with zero-width non-joiners, and shown as
+	// given rather than normalized
+	LigatureSpellings []string `json:"ligature_spellings,omitempty"`
+}
+
+// DiacritizedForm is the diacritized form of a word. Without a WordID, the
+// word is the one of the language that is written the same without its
+// marks.
+type DiacritizedForm struct {
+	WordID      int64  `json:"word_id,omitempty"`
+	Diacritized string `json:"diacritized"`
+}
+
+// DiacritizedImport is the outcome of adding diacritized forms of words
+type DiacritizedImport struct {
+	Language string `json:"language"`
+	Updated  int    `json:"updated"`
+	// Unmatched are the forms no word of the language is written as, and
+	// Ambiguous those several are, which need a word_id
+	Unmatched []string `json:"unmatched"`
+	Ambiguous []string `json:"ambiguous"`
+}
+
+// GroupAudioResult counts the words of a group given audio, by where it
+// came from, and lists those that failed
+type GroupAudioResult struct {
+	Words       int           `json:"words"`
+	Existing    int           `json:"existing"`
+	Cached      int           `json:"cached"`
+	Synthesized int           `json:"synthesized"`
+	Failed      []WordFailure `json:"failed"`
+}
+
+// WordFailure is why a word of a group or batch couldn't be processed
+type WordFailure struct {
+	WordID int64  `json:"word_id"`
+	Error  string `json:"error"`
+}
+
+// Group draft statuses
+const (
+	DraftPending  = "pending"
+	DraftApproved = "approved"
+)
+
+// GroupDraft is a vocabulary group a language model wrote on a topic, kept
+// for review until it is approved and the group created
+type GroupDraft struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"-"`
+	Topic  string `json:"topic"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Provider names the provider and model that wrote the words
+	Provider string      `json:"provider"`
+	Words    []DraftWord `json:"words"`
+	// Dropped are the words the model answered that failed validation
+	Dropped []DroppedWord `json:"dropped"`
+	// GroupID is the group created when the draft was approved
+	GroupID    *int64     `json:"group_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+}
+
+// DraftWord is a word of a group draft
+type DraftWord struct {
+	Urdu    string `json:"urdu"`
+	Urdlish string `json:"urdlish"`
+	English string `json:"english"`
+	// WordID is the existing word with the same urdu and english, which is
+	// added to the group instead of a new one
+	WordID int64 `json:"word_id,omitempty"`
+}
+
+// DroppedWord is a word left out of a group draft, and why
+type DroppedWord struct {
+	DraftWord
+	Reason string `json:"reason"`
+}
+
+// FeatureFlag turns an experimental feature on or off for the deployment.
+// Users lists the users it is turned on or off for instead.
+type FeatureFlag struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Enabled     bool              `json:"enabled"`
+	Users       []FeatureFlagUser `json:"users"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// FeatureFlagUser is a user's override of a feature flag
+type FeatureFlagUser struct {
+	UserID  int64 `json:"user_id"`
+	Enabled bool  `json:"enabled"`
+}
+
+// ConsistencyReport is whether foreign keys are enforced and the rows that
+// refer to rows that don't exist
+type ConsistencyReport struct {
+	ForeignKeys bool           `json:"foreign_keys"`
+	Consistent  bool           `json:"consistent"`
+	Orphans     []OrphanedRows `json:"orphans"`
+}
+
+// OrphanedRows counts the rows of a table whose column refers to a missing
+// row of the parent table
+type OrphanedRows struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Parent string `json:"parent"`
+	Rows   int    `json:"rows"`
+}
+
+// DatabaseStats is the size of the database and how much of it is free
+// pages that a vacuum would reclaim
+type DatabaseStats struct {
+	SizeBytes     int64   `json:"size_bytes"`
+	WALBytes      int64   `json:"wal_bytes"`
+	PageSize      int64   `json:"page_size"`
+	PageCount     int64   `json:"page_count"`
+	FreePages     int64   `json:"free_pages"`
+	Fragmentation float64 `json:"fragmentation"` // share of the pages that are free
+}
+
+// IntegrityCheck is the result of SQLite's integrity check. Problems is
+// empty when the database is intact.
+type IntegrityCheck struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+// AdminDashboard is the state of the deployment, for its admins
+type AdminDashboard struct {
+	Database DatabaseSummary `json:"database"`
+	// Tables are the tables of the database by name
+	Tables       []TableRows   `json:"tables"`
+	SeedVersions []SeedVersion `json:"seed_versions"`
+	Jobs         JobQueue      `json:"jobs"`
+	RecentErrors []ServerError `json:"recent_errors"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+}
+
+// DatabaseSummary is the kind, size and schema version of the database
+type DatabaseSummary struct {
+	Dialect       string `json:"dialect"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SchemaVersion int    `json:"schema_version"`
+}
+
+// TableRows is how many rows a table has
+type TableRows struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// SeedVersion is the version of a seed or content pack applied last
+type SeedVersion struct {
+	Source    string    `json:"source"` // seed_pack or content_pack
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// JobQueue is how many background jobs are waiting, running and failed
+type JobQueue struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+	Failed  int `json:"failed"`
+	// QueuedByKind counts the queued jobs of each kind
+	QueuedByKind map[string]int `json:"queued_by_kind"`
+	// OldestQueuedAt is when the job queued longest was queued
+	OldestQueuedAt *time.Time `json:"oldest_queued_at,omitempty"`
+}
+
+// ServerError is a request the server answered with a 5xx status
+type ServerError struct {
+	At        time.Time `json:"at"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Error     string    `json:"error"`
+}
+
+// UserRole is the role of a user
+type UserRole struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// GroupPermission is the role a user has on a group
+type GroupPermission struct {
+	GroupID   int64     `json:"group_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reset kinds
+const (
+	ResetHistory = "history"
+	ResetFull    = "full"
+)
+
+// Reset is a reset done, and the backup archived before it
+type Reset struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Kind       string     `json:"kind"`
+	Backup     string     `json:"backup"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RestoredAt *time.Time `json:"restored_at"`
+}
+
+// ResetConfirmation is a token a reset's request must give to confirm it
+type ResetConfirmation struct {
+	Token     string    `json:"confirm_token"`
+	Kind      string    `json:"kind"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WordSuggestion is a word's fields as a translation provider proposes them
+// from one term, for an editor to confirm
+type WordSuggestion struct {
+	Urdu     string `json:"urdu"`
+	Urdlish  string `json:"urdlish"`
+	English  string `json:"english"`
+	Provider string `json:"provider"`
+	// Cached is whether the suggestion was made before for the same term
+	Cached bool `json:"cached"`
+	// Duplicates are the IDs of words with the same urdu or english
+	Duplicates []int64 `json:"duplicates"`
+}
+
+// WordEnrichment is what a dictionary says about a word
+type WordEnrichment struct {
+	Provider    string           `json:"provider"`
+	Definitions []WordDefinition `json:"definitions"`
+	Plurals     []string         `json:"plurals"`
+	EnrichedAt  time.Time        `json:"enriched_at"`
+}
+
+// WordDefinition is one meaning of a word, with examples of its use
+type WordDefinition struct {
+	PartOfSpeech string         `json:"part_of_speech,omitempty"`
+	Definition   string         `json:"definition"`
+	Examples     []UsageExample `json:"examples"`
+}
+
+// UsageExample is a sentence using a word, and its English if known
+type UsageExample struct {
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+// EnrichWordsResult counts the words looked up in a dictionary and lists
+// those that failed
+type EnrichWordsResult struct {
+	Words    int           `json:"words"`
+	Enriched int           `json:"enriched"`
+	NotFound int           `json:"not_found"`
+	Failed   []WordFailure `json:"failed"`
+}
+
+// EmbedWordsResult counts the words whose embeddings were computed, and
+// those left alone as their embeddings were current, and lists those that
+// failed
+type EmbedWordsResult struct {
+	Model    string        `json:"model"`
+	Words    int           `json:"words"`
+	Embedded int           `json:"embedded"`
+	Current  int           `json:"current"`
+	Failed   []WordFailure `json:"failed"`
+}
+
+// Reasons an embedding is stale
+const (
+	EmbeddingMissing     = "missing"
+	EmbeddingOtherModel  = "other_model"
+	EmbeddingTextChanged = "text_changed"
+)
+
+// WordEmbeddingStatus describes a word's embedding, without its vector, for
+// debugging what searches and hard quizzes find
+type WordEmbeddingStatus struct {
+	WordID int64 `json:"word_id"`
+	// Text is what the embedding was computed from, empty if unknown, and
+	// CurrentText what it would be computed from now
+	Text        string     `json:"text"`
+	CurrentText string     `json:"current_text"`
+	Model       string     `json:"model,omitempty"`
+	Dimensions  int        `json:"dimensions,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	// StaleReason is why the embedding needs computing again, if it does
+	Stale       bool   `json:"stale"`
+	StaleReason string `json:"stale_reason,omitempty"`
+	// Queued is whether the word waits to be embedded by this server
+	Queued bool `json:"queued"`
+}
+
+// EmbeddingStatus counts the words by whether their embeddings are current
+// for the configured provider's model
+type EmbeddingStatus struct {
+	Model       string `json:"model"`
+	Words       int    `json:"words"`
+	Current     int    `json:"current"`
+	Missing     int    `json:"missing"`
+	OtherModel  int    `json:"other_model"`
+	TextChanged int    `json:"text_changed"`
+	// Queued is how many words wait to be embedded by this server
+	Queued int `json:"queued"`
+}
+
+// WordMatch is a word found by a search or as similar to another word
+type WordMatch struct {
+	WordResponse
+	// Similarity is the cosine similarity of the word's embedding to the
+	// query's, for semantic matches
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// ImageImport is the words read from a photo or scan of a printed word
+// list, for the user to confirm before they are imported
+type ImageImport struct {
+	Provider string `json:"provider"`
+	// Text is all the text read, for rows that were parsed wrongly
+	Text       string            `json:"text"`
+	Candidates []ImportCandidate `json:"candidates"`
+}
+
+// ImportCandidate is a word read from a line of a word list. WordID is set
+// if the word already exists.
+type ImportCandidate struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	DraftWord
+	// Problem is what must be fixed before the word can be imported, e.g.
+	// "missing fields", or empty
+	Problem string `json:"problem,omitempty"`
+}
+
+// DocumentImport is the vocabulary of a document summarized by the docsum
+// service: the Urdu words of its summary the learner doesn't know yet, most
+// frequent first, and a draft of a group of the first of them to review and
+// approve. Draft is nil if the learner knows every word.
+type DocumentImport struct {
+	Summary     string `json:"summary"`
+	UrduSummary string `json:"urdu_summary"`
+	// KnownWords is how many distinct words of the summary the learner
+	// knows
+	KnownWords int            `json:"known_words"`
+	Terms      []DocumentTerm `json:"terms"`
+	Draft      *GroupDraft    `json:"draft"`
+}
+
+// DocumentTerm is an Urdu word of a document's summary. WordID is set if
+// the word exists but the learner hasn't learned it.
+type DocumentTerm struct {
+	Urdu        string `json:"urdu"`
+	Occurrences int    `json:"occurrences"`
+	WordID      int64  `json:"word_id,omitempty"`
+}
+
+// ImportWordsResult is the words imported, in the order they were given
+type ImportWordsResult struct {
+	Created  int     `json:"created"`
+	Existing int     `json:"existing"`
+	WordIDs  []int64 `json:"word_ids"`
+	GroupID  int64   `json:"group_id,omitempty"`
+}
+
+// GroupSync keeps a group in step with a shared spreadsheet or CSV file
+type GroupSync struct {
+	ID      int64  `json:"id"`
+	UserID  int64  `json:"user_id"`
+	GroupID int64  `json:"group_id"`
+	URL     string `json:"url"`
+	// IntervalMinutes is how often the sync runs, or 0 if only on request
+	IntervalMinutes int         `json:"interval_minutes"`
+	NextSyncAt      *time.Time  `json:"next_sync_at,omitempty"`
+	LastSyncedAt    *time.Time  `json:"last_synced_at,omitempty"`
+	LastError       string      `json:"last_error,omitempty"`
+	LastReport      *SyncReport `json:"last_report,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+}
+
+// SyncReport is what a sync changed in a group, or would change in a dry
+// run. Rows with problems are left out of the sync and reported as
+// invalid.
+type SyncReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Rows      int               `json:"rows"`
+	Added     []DraftWord       `json:"added"`
+	Updated   []SyncUpdate      `json:"updated"`
+	Removed   []DraftWord       `json:"removed"`
+	Unchanged int               `json:"unchanged"`
+	Invalid   []ImportCandidate `json:"invalid"`
+}
+
+// SyncUpdate is a word of a group whose urdlish or english a sync changed
+type SyncUpdate struct {
+	DraftWord
+	PreviousUrdlish string `json:"previous_urdlish"`
+	PreviousEnglish string `json:"previous_english"`
+}
+
+// PronunciationCheck is how close a learner's recording of a word came to
+// the word
+type PronunciationCheck struct {
+	SessionID int64 `json:"session_id"`
+	WordID    int64 `json:"word_id"`
+	// Expected is the word's Urdu
+	Expected   string `json:"expected"`
+	Transcript string `json:"transcript"`
+	Provider   string `json:"provider"`
+	// Score is how alike the transcript and the word are, from 0 to 1
+	Score float64 `json:"score"`
+	// Result is correct, near_miss or wrong
+	Result string `json:"result"`
+	// Review is the review recorded for the word's first attempt in the
+	// session; later attempts are practice
+	Review *WordReviewItem `json:"review,omitempty"`
+}
@@ -0,0 +1,436 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+type PaginatedResponse struct {
+	Items      interface{} `json:"items"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// EncodeCursor returns the opaque cursor of a keyset-paginated list that
+// continues after the item with the given ID
+func EncodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// DecodeCursor returns the ID of the item a cursor continues after, or 0
+// for an empty cursor, which starts at the beginning of the list
+func DecodeCursor(cursor string) (int64, bool) {
+	if cursor == "" {
+		return 0, true
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+	return id, true
+}
+
+// EncodeSyncToken returns the opaque sync token of an offline client that
+// pulled the changes made up to t
+func EncodeSyncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixMilli(), 10)))
+}
+
+// DecodeSyncToken returns the time a sync token was made at, or the zero
+// time for an empty token, which pulls everything
+func DecodeSyncToken(token string) (time.Time, bool) {
+	if token == "" {
+		return time.Time{}, true
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, false
+	}
+	millis, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || millis < 1 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(millis).UTC(), true
+}
+
+type DashboardStats struct {
+	TotalWordsStudied   int       `json:"total_words_studied"`
+	CorrectCount        int       `json:"correct_count"`
+	WrongCount          int       `json:"wrong_count"`
+	UnansweredCount     int       `json:"unanswered_count"`
+	CorrectPercentage   int       `json:"correct_percentage"`
+	TotalAvailableWords int       `json:"total_available_words"`
+	TotalStudySessions  int       `json:"total_study_sessions"`
+	TotalActiveGroups   int       `json:"total_active_groups"`
+	StudyStreakDays     int       `json:"study_streak_days"`
+	SRS                 SRSStats  `json:"srs"`
+	Range               DateRange `json:"range"`
+}
+
+// DateRange is the period dashboard statistics cover: from <= t < to, with
+// a missing bound left open
+type DateRange struct {
+	Preset string     `json:"preset,omitempty"`
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+}
+
+// SRSStats summarises the spaced repetition state of the vocabulary
+type SRSStats struct {
+	RetentionRate       float64 `json:"retention_rate"`
+	RetentionReviews    int     `json:"retention_reviews"`
+	NewWords            int     `json:"new_words"`
+	LearningWords       int     `json:"learning_words"`
+	YoungWords          int     `json:"young_words"`
+	MatureWords         int     `json:"mature_words"`
+	AverageIntervalDays float64 `json:"average_interval_days"`
+}
+
+type StudyProgress struct {
+	TotalWordsStudied   int       `json:"total_words_studied"`
+	TotalAvailableWords int       `json:"total_available_words"`
+	Range               DateRange `json:"range"`
+}
+
+type StudyActivityResponse struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	URL          *string    `json:"url,omitempty"`
+	ThumbnailURL *string    `json:"thumbnail_url,omitempty"`
+	Description  *string    `json:"description,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type StudySessionResponse struct {
+	ID               int64  `json:"id"`
+	GroupID          int64  `json:"group_id"`
+	ActivityName     string `json:"activity_name,omitempty"`
+	GroupName        string `json:"group_name,omitempty"`
+	StartTime        string `json:"start_time,omitempty"`
+	EndTime          string `json:"end_time,omitempty"`
+	ReviewItemsCount int    `json:"review_items_count"`
+	// ParentSessionID links a retry round to the session it retries
+	ParentSessionID *int64 `json:"parent_session_id,omitempty"`
+}
+
+// WordResponse is a word with the user's review counts. As in Word, Urdu
+// and Urdlish hold the word in its language's script and its
+// transliteration.
+type WordResponse struct {
+	ID           int64  `json:"id"`
+	Urdu         string `json:"urdu"`
+	Urdlish      string `json:"urdlish"`
+	English      string `json:"english"`
+	CorrectCount int    `json:"correct_count"`
+	WrongCount   int    `json:"wrong_count"`
+	// Language is the code of the word's language. Only lists and single
+	// words of the words and groups endpoints are returned with it.
+	Language string `json:"language,omitempty"`
+	// AudioURL is where the word can be heard, if it has audio. Only single
+	// words are returned with it.
+	AudioURL string `json:"audio_url,omitempty"`
+	// ImageURL and ThumbnailURL are where the word's picture and its
+	// thumbnail can be seen, if it has one. Only single words are returned
+	// with them.
+	ImageURL     string `json:"image_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	// Enrichment is what a dictionary says about the word, if it has been
+	// looked up. Only single words are returned with it.
+	Enrichment *WordEnrichment `json:"enrichment,omitempty"`
+	// Senses are the word's English meanings, most common first, of which
+	// English is the first. Only single words are returned with them.
+	Senses []WordSense `json:"senses,omitempty"`
+	// Rendering are hints for showing the word in its script, if it has
+	// any. Only lists and single words of the words and groups endpoints
+	// are returned with them.
+	Rendering *WordRendering `json:"rendering,omitempty"`
+}
+
+type GroupResponse struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	WordCount int    `json:"word_count"`
+	// Language is the code of the language of the group's words
+	Language string `json:"language,omitempty"`
+}
+// SessionCard holds the numbers shown on a shareable study session summary
+type SessionCard struct {
+	SessionID       int64    `json:"session_id"`
+	GroupName       string   `json:"group_name"`
+	ActivityName    string   `json:"activity_name"`
+	CorrectCount    int      `json:"correct_count"`
+	TotalWords      int      `json:"total_words"`
+	Accuracy        float64  `json:"accuracy"`
+	StudyStreakDays int      `json:"study_streak_days"`
+	WordsMastered   int      `json:"words_mastered"`
+	Highlights      []string `json:"highlights"`
+}
+
+// ReviewQueueItem is a word waiting in the daily review queue
+type ReviewQueueItem struct {
+	Word        WordResponse `json:"word"`
+	Status      string       `json:"status"` // new, review or lapsed
+	DueAt       *time.Time   `json:"due_at,omitempty"`
+	OverdueDays int          `json:"overdue_days"`
+}
+
+// QuizHistoryEntry is the score of a past quiz session
+type QuizHistoryEntry struct {
+	SessionID       int64     `json:"session_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	TotalWords      int       `json:"total_words"`
+	CorrectCount    int       `json:"correct_count"`
+	Accuracy        float64   `json:"accuracy"`
+	Difficulty      string    `json:"difficulty"`
+	Direction       string    `json:"direction"`
+	Points          int       `json:"points"`
+	HintsUsed       int       `json:"hints_used"` // hints used on correctly answered questions
+	ParentSessionID *int64    `json:"parent_session_id,omitempty"`
+}
+
+// Flashcard is a card of a flashcard deck with the word's current schedule
+type Flashcard struct {
+	Word    WordResponse `json:"word"`
+	Status  string       `json:"status"` // new, review or lapsed
+	DueAt   *time.Time   `json:"due_at,omitempty"`
+	Flips   int          `json:"flips"`
+	Rating  *string      `json:"rating"`
+	RatedAt *time.Time   `json:"rated_at,omitempty"`
+}
+
+// FlashcardDeck is a flashcard study session and its cards
+type FlashcardDeck struct {
+	SessionID int64       `json:"session_id"`
+	GroupID   int64       `json:"group_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Cards     []Flashcard `json:"cards"`
+}
+
+// ListeningAttempt is the score of a listening practice session
+type ListeningAttempt struct {
+	SessionID    int64             `json:"session_id"`
+	ClipID       int64             `json:"clip_id"`
+	TotalCount   int               `json:"total_count"`
+	CorrectCount int               `json:"correct_count"`
+	Accuracy     float64           `json:"accuracy"`
+	Transcript   string            `json:"transcript"`
+	Results      []ListeningResult `json:"results"`
+}
+
+// ListeningResult is how a listening question was answered
+type ListeningResult struct {
+	QuestionID    int64  `json:"question_id"`
+	Answer        string `json:"answer"`
+	Correct       bool   `json:"correct"`
+	CorrectAnswer string `json:"correct_answer"`
+}
+
+// WordGameRound is one word of a word scramble or hangman session. The
+// answer is only shown once the round is over.
+type WordGameRound struct {
+	WordID          int64    `json:"word_id"`
+	Game            string   `json:"game"`
+	Clue            string   `json:"clue"`   // the word's English meaning
+	Puzzle          string   `json:"puzzle"` // scrambled letters, or the word with unguessed letters hidden
+	Guesses         []string `json:"guesses"`
+	WrongGuesses    int      `json:"wrong_guesses"`
+	MaxWrongGuesses int      `json:"max_wrong_guesses"`
+	Status          string   `json:"status"` // playing, won or lost
+	Answer          *string  `json:"answer,omitempty"`
+}
+
+// WordGameSession is a word scramble or hangman study session
+type WordGameSession struct {
+	SessionID int64           `json:"session_id"`
+	GroupID   int64           `json:"group_id"`
+	Game      string          `json:"game"`
+	Rounds    []WordGameRound `json:"rounds"`
+}
+
+// Conjugation is a form of a verb. Person and Gender are empty for forms
+// that don't vary by them.
+type Conjugation struct {
+	ID     int64  `json:"id"`
+	Tense  string `json:"tense"`
+	Person string `json:"person,omitempty"`
+	Gender string `json:"gender,omitempty"`
+	// Form is written in the verb's script, and Transliteration in its
+	// romanization
+	Form            string `json:"form"`
+	Transliteration string `json:"transliteration"`
+}
+
+// ConjugationDrillRound is one verb of a conjugation drill, asking for its
+// form for a tense, person and gender. The form is only shown once the
+// round is answered.
+type ConjugationDrillRound struct {
+	WordID  int64  `json:"word_id"`
+	Verb    string `json:"verb"`    // the verb as the word is written
+	English string `json:"english"` // the verb's English meaning
+	Tense   string `json:"tense"`
+	Person  string `json:"person,omitempty"`
+	Gender  string `json:"gender,omitempty"`
+	// Result is unanswered, correct, near_miss or wrong
+	Result          string  `json:"result"`
+	Answer          *string `json:"answer,omitempty"`
+	Form            *string `json:"form,omitempty"`
+	Transliteration *string `json:"transliteration,omitempty"`
+}
+
+// ConjugationDrillSession is a conjugation drill study session
+type ConjugationDrillSession struct {
+	SessionID int64                   `json:"session_id"`
+	GroupID   int64                   `json:"group_id"`
+	Rounds    []ConjugationDrillRound `json:"rounds"`
+}
+
+// ClozeQuestion is a sentence with a word blanked out. The text blanked is
+// only shown once the question is answered.
+type ClozeQuestion struct {
+	WordID int64 `json:"word_id"`
+	// SentenceID is the corpus sentence the question was made from, or nil
+	// if it has since been deleted
+	SentenceID *int64 `json:"sentence_id"`
+	Prompt     string `json:"prompt"`  // the sentence, with ____ for the word
+	English    string `json:"english"` // the sentence's English, if known
+	// AnswerMode is multiple_choice or typed
+	AnswerMode string   `json:"answer_mode"`
+	Options    []string `json:"options,omitempty"`
+	// Result is unanswered, correct, near_miss or wrong
+	Result        string  `json:"result"`
+	Answer        *string `json:"answer,omitempty"`
+	CorrectAnswer *string `json:"correct_answer,omitempty"`
+}
+
+// ClozeSession is a cloze study session
+type ClozeSession struct {
+	SessionID int64           `json:"session_id"`
+	GroupID   int64           `json:"group_id"`
+	Questions []ClozeQuestion `json:"questions"`
+}
+
+// SessionScore is the score of a study session, whatever its activity
+type SessionScore struct {
+	SessionID    int64       `json:"session_id"`
+	Activity     string      `json:"activity"`
+	TotalCount   int         `json:"total_count"`
+	CorrectCount int         `json:"correct_count"`
+	Accuracy     float64     `json:"accuracy"`
+	Points       int         `json:"points"`
+	Details      interface{} `json:"details,omitempty"` // the activity's own breakdown
+}
+
+// HeatmapDay is the number of reviews made on a day
+type HeatmapDay struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	Count        int    `json:"count"`
+	CorrectCount int    `json:"correct_count"`
+}
+
+// ActivityHeatmap counts reviews per day of a year for a contribution graph
+type ActivityHeatmap struct {
+	Year         int          `json:"year"`
+	TotalReviews int          `json:"total_reviews"`
+	MaxCount     int          `json:"max_count"` // the busiest day's count, for scaling colours
+	Days         []HeatmapDay `json:"days"`
+}
+
+// TrendPoint summarises the reviews of a day or week
+type TrendPoint struct {
+	Date            string  `json:"date"` // first day of the bucket, YYYY-MM-DD
+	Reviews         int     `json:"reviews"`
+	CorrectCount    int     `json:"correct_count"`
+	Accuracy        float64 `json:"accuracy"`
+	NewWordsLearned int     `json:"new_words_learned"`
+}
+
+// Trends are review series bucketed by day or week
+type Trends struct {
+	Granularity string       `json:"granularity"`
+	Points      []TrendPoint `json:"points"`
+}
+
+// GoalStatus is the progress toward a goal in its current period
+type GoalStatus struct {
+	Goal        Goal    `json:"goal"`
+	Period      string  `json:"period"` // day or week
+	PeriodStart string  `json:"period_start"`
+	Current     float64 `json:"current"`
+	Progress    float64 `json:"progress"` // current / target, at most 1
+	Met         bool    `json:"met"`
+}
+
+// ForecastEntry projects when the words of a group, or of the whole corpus,
+// will all be learned
+type ForecastEntry struct {
+	GroupID           *int64  `json:"group_id,omitempty"`
+	GroupName         string  `json:"group_name,omitempty"`
+	TotalWords        int     `json:"total_words"`
+	LearnedWords      int     `json:"learned_words"`
+	RemainingWords    int     `json:"remaining_words"`
+	LearnedInWindow   int     `json:"learned_in_window"`
+	DailyRate         float64 `json:"daily_rate"`
+	ProjectedDate     *string `json:"projected_date"` // null without recent progress
+	RequiredDailyPace float64 `json:"required_daily_pace"`
+}
+
+// Forecast projects learning completion from the recent learning rate
+type Forecast struct {
+	WindowDays int             `json:"window_days"`
+	TargetDate string          `json:"target_date"`
+	Corpus     ForecastEntry   `json:"corpus"`
+	Groups     []ForecastEntry `json:"groups"`
+}
+
+// AssignmentProgress is how far a student got with an assignment: the words
+// of its group they reviewed between it being set and its due date
+type AssignmentProgress struct {
+	AssignmentID  int64   `json:"assignment_id"`
+	WordsReviewed int     `json:"words_reviewed"`
+	TotalWords    int     `json:"total_words"`
+	Progress      float64 `json:"progress"`
+	Completed     bool    `json:"completed"`
+	Overdue       bool    `json:"overdue"`
+}
+
+// StudentProgress sums up a student's study history
+type StudentProgress struct {
+	User          User                 `json:"user"`
+	StudySessions int                  `json:"study_sessions"`
+	Reviews       int                  `json:"reviews"`
+	CorrectCount  int                  `json:"correct_count"`
+	Accuracy      float64              `json:"accuracy"`
+	LastStudiedAt *time.Time           `json:"last_studied_at"`
+	Assignments   []AssignmentProgress `json:"assignments"`
+}
+
+// ClassProgress is a class's progress for its teacher
+type ClassProgress struct {
+	Class       Class             `json:"class"`
+	Assignments []ClassAssignment `json:"assignments"`
+	Students    []StudentProgress `json:"students"`
+}
+
+// LeaderboardEntry is a user's place on a leaderboard. Value is a count of
+// reviews or days, or an accuracy between 0 and 1.
+type LeaderboardEntry struct {
+	Rank        int     `json:"rank"`
+	DisplayName string  `json:"display_name"`
+	Value       float64 `json:"value"`
+	You         bool    `json:"you"`
+}
+
+// Leaderboard ranks the users who opted in by a metric over a week. You is
+// the requesting user's entry, even when it is past the listed entries.
+type Leaderboard struct {
+	Metric     string             `json:"metric"`
+	WeekStart  string             `json:"week_start"`
+	WeekEnd    string             `json:"week_end"`
+	RolledUpTo *string            `json:"rolled_up_to"` // null before the first rollup
+	Entries    []LeaderboardEntry `json:"entries"`
+	You        *LeaderboardEntry  `json:"you"`
+}
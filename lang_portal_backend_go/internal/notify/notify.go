@@ -0,0 +1,189 @@
+// Package notify sends learners notifications, such as a reminder that their
+// study streak is about to break, by email over SMTP. The mail server is set
+// by the environment.
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SMTPHostEnv is the mail server to send through
+	SMTPHostEnv = "LANG_PORTAL_SMTP_HOST"
+	// SMTPPortEnv is its port, 587 by default. Port 465 is spoken over TLS
+	// from the start; other ports upgrade with STARTTLS when offered.
+	SMTPPortEnv = "LANG_PORTAL_SMTP_PORT"
+	// SMTPUsernameEnv and SMTPPasswordEnv log in to the server, if set
+	SMTPUsernameEnv = "LANG_PORTAL_SMTP_USERNAME"
+	SMTPPasswordEnv = "LANG_PORTAL_SMTP_PASSWORD"
+	// SMTPFromEnv is the address notifications are sent from
+	SMTPFromEnv = "LANG_PORTAL_SMTP_FROM"
+)
+
+const defaultSMTPPort = 587
+
+// sendTimeout bounds sending a message whose context has no deadline
+const sendTimeout = 30 * time.Second
+
+// Message is an email to a learner
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv returns the sender configured by the environment, or nil if
+// SMTPHostEnv isn't set
+func FromEnv() (Sender, error) {
+	host := os.Getenv(SMTPHostEnv)
+	if host == "" {
+		return nil, nil
+	}
+	port := defaultSMTPPort
+	if value := os.Getenv(SMTPPortEnv); value != "" {
+		var err error
+		if port, err = strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid %s %q", SMTPPortEnv, value)
+		}
+	}
+	from := os.Getenv(SMTPFromEnv)
+	if from == "" {
+		return nil, fmt.Errorf("%s is required to send email", SMTPFromEnv)
+	}
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", SMTPFromEnv, err)
+	}
+	return &SMTP{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv(SMTPUsernameEnv),
+		Password: os.Getenv(SMTPPasswordEnv),
+		From:     address,
+	}, nil
+}
+
+// SMTP sends messages through a mail server
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     *mail.Address
+}
+
+// Send delivers msg to the mail server
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %v", err)
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid subject")
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("failed to log in to mail server: %v", err)
+		}
+	}
+	if err := client.Mail(s.From.Address); err != nil {
+		return fmt.Errorf("mail server refused sender: %v", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("mail server refused recipient: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+	if _, err := w.Write(s.format(to, msg)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail server refused message: %v", err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the mail server, over TLS on port 465 or upgrading with
+// STARTTLS where the server offers it. The connection gives up at ctx's
+// deadline.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	config := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mail server: %v", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to mail server: %v", err)
+	}
+	if s.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(config); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("failed to start TLS with mail server: %v", err)
+			}
+		}
+	}
+	return client, nil
+}
+
+// format returns msg as a plain text UTF-8 email
+func (s *SMTP) format(to *mail.Address, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	w.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	w.Close()
+	return []byte(b.String())
+}
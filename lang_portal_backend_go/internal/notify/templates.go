@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Kinds of notifications
+const (
+	// StreakAtRisk reminds a learner who studied yesterday but not yet today
+	// that their streak breaks at midnight
+	StreakAtRisk = "streak_at_risk"
+	// WeeklyDigest sums up a learner's last week
+	WeeklyDigest = "weekly_digest"
+	// HomeworkDue reminds a student of an assignment due soon that they
+	// haven't finished
+	HomeworkDue = "homework_due"
+)
+
+// StreakData fills the StreakAtRisk template
+type StreakData struct {
+	Username   string
+	StreakDays int
+}
+
+// DigestData fills the WeeklyDigest template
+type DigestData struct {
+	Username      string
+	WeekStart     string
+	WeekEnd       string
+	StudySessions int
+	Reviews       int
+	CorrectCount  int
+	// Accuracy is the percentage of reviews answered correctly
+	Accuracy      int
+	WordsReviewed int
+	StreakDays    int
+}
+
+// HomeworkData fills the HomeworkDue template
+type HomeworkData struct {
+	Username   string
+	ClassName  string
+	GroupName  string
+	DueAt      string
+	TotalWords int
+	// WordsLeft is how many of the group's words are still to review
+	WordsLeft int
+}
+
+// notificationTemplate is the subject and body of a kind of notification
+type notificationTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[string]notificationTemplate{
+	StreakAtRisk: parse(StreakAtRisk,
+		`Your {{.StreakDays}}-day streak ends at midnight`,
+		`Hi {{.Username}},
+
+You have studied {{.StreakDays}} {{if eq .StreakDays 1}}day{{else}}days{{end}} in a row, but not yet today. A short session before midnight (UTC) keeps your streak going.
+`),
+	WeeklyDigest: parse(WeeklyDigest,
+		`Your week of Urdu: {{.Reviews}} {{if eq .Reviews 1}}review{{else}}reviews{{end}}`,
+		`Hi {{.Username}},
+
+Here is your week from {{.WeekStart}} to {{.WeekEnd}}:
+
+  Study sessions: {{.StudySessions}}
+  Reviews:        {{.Reviews}}{{if .Reviews}} ({{.Accuracy}}% correct){{end}}
+  Words reviewed: {{.WordsReviewed}}
+  Current streak: {{.StreakDays}} {{if eq .StreakDays 1}}day{{else}}days{{end}}
+{{if not .Reviews}}
+A few minutes a day is all it takes to get going again.
+{{end}}`),
+	HomeworkDue: parse(HomeworkDue,
+		`{{.GroupName}} is due for {{.ClassName}}`,
+		`Hi {{.Username}},
+
+Your assignment for {{.ClassName}} is due {{.DueAt}}. {{.WordsLeft}} of the {{.TotalWords}} words of {{.GroupName}} are still to review.
+`),
+}
+
+func parse(kind, subject, body string) notificationTemplate {
+	return notificationTemplate{
+		subject: template.Must(template.New(kind + " subject").Parse(subject)),
+		body:    template.Must(template.New(kind + " body").Parse(body)),
+	}
+}
+
+// Render returns the message of a kind of notification to an address,
+// filled from data
+func Render(kind, to string, data interface{}) (Message, error) {
+	t, ok := templates[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown notification %q", kind)
+	}
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s: %v", kind, err)
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s: %v", kind, err)
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    body.String(),
+	}, nil
+}
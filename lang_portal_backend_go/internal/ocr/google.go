@@ -0,0 +1,136 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const googleURL = "https://vision.googleapis.com/v1"
+
+// googlePDFPages is how many pages of a PDF Google Cloud Vision reads at
+// once, the most it allows
+const googlePDFPages = 5
+
+var client = &http.Client{Timeout: time.Minute}
+
+// google reads images and PDFs with Google Cloud Vision's document text
+// detection. Only the first pages of a PDF are read.
+type google struct {
+	url string
+	key string
+}
+
+func newGoogle(apiURL, key string) *google {
+	if apiURL == "" {
+		apiURL = googleURL
+	}
+	return &google{url: strings.TrimSuffix(apiURL, "/"), key: key}
+}
+
+func (p *google) Name() string { return Google }
+
+// page is the text Google Cloud Vision found on an image or page
+type page struct {
+	FullTextAnnotation struct {
+		Text string `json:"text"`
+	} `json:"fullTextAnnotation"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *google) Recognize(ctx context.Context, data []byte, contentType string) (string, error) {
+	feature := []map[string]string{{"type": "DOCUMENT_TEXT_DETECTION"}}
+	imageContext := map[string][]string{"languageHints": {"ur", "en"}}
+
+	if contentType == PDF {
+		pages := make([]int, googlePDFPages)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		var response struct {
+			Responses []struct {
+				Responses []page `json:"responses"`
+			} `json:"responses"`
+		}
+		err := p.post(ctx, "/files:annotate", map[string]interface{}{
+			"requests": []map[string]interface{}{{
+				"inputConfig":  map[string]interface{}{"content": data, "mimeType": PDF},
+				"features":     feature,
+				"imageContext": imageContext,
+				"pages":        pages,
+			}},
+		}, &response)
+		if err != nil {
+			return "", err
+		}
+		if len(response.Responses) == 0 {
+			return "", fmt.Errorf("google: no text returned")
+		}
+		return join(response.Responses[0].Responses)
+	}
+
+	var response struct {
+		Responses []page `json:"responses"`
+	}
+	err := p.post(ctx, "/images:annotate", map[string]interface{}{
+		"requests": []map[string]interface{}{{
+			"image":        map[string]interface{}{"content": data},
+			"features":     feature,
+			"imageContext": imageContext,
+		}},
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+	return join(response.Responses)
+}
+
+// join returns the text of pages, one after another
+func join(pages []page) (string, error) {
+	var text strings.Builder
+	for _, page := range pages {
+		if page.Error != nil {
+			return "", fmt.Errorf("google: %s", page.Error.Message)
+		}
+		text.WriteString(page.FullTextAnnotation.Text)
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// post posts body as JSON to the API path and decodes the response into v.
+// Byte slices in body are sent base64 encoded, as the API expects content.
+func (p *google) post(ctx context.Context, path string, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("google: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.url+path+"?key="+url.QueryEscape(p.key), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("google: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("google: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("google: failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package ocr reads the text of photos and scans of printed word lists, and
+// parses their rows into words. Tesseract and Google Cloud Vision are
+// supported; which one is used is set by the environment.
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const (
+	// ProviderEnv selects the engine: tesseract or google
+	ProviderEnv = "LANG_PORTAL_OCR_PROVIDER"
+	// LanguagesEnv is the languages Tesseract reads, urd+eng by default
+	LanguagesEnv = "LANG_PORTAL_OCR_LANGUAGES"
+	// APIKeyEnv is the Google Cloud API key
+	APIKeyEnv = "LANG_PORTAL_OCR_API_KEY"
+	// URLEnv overrides the Google Cloud Vision API URL
+	URLEnv = "LANG_PORTAL_OCR_URL"
+)
+
+// Providers
+const (
+	Tesseract = "tesseract"
+	Google    = "google"
+)
+
+// Content types that can be read
+const (
+	PNG  = "image/png"
+	JPEG = "image/jpeg"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+	PDF  = "application/pdf"
+)
+
+// ErrUnsupportedType is returned for content a provider can't read, such as
+// a PDF given to Tesseract
+var ErrUnsupportedType = errors.New("unsupported content type")
+
+// Provider reads text
+type Provider interface {
+	// Name names the provider, e.g. "tesseract"
+	Name() string
+	// Recognize returns the text of data, an image or PDF of contentType
+	Recognize(ctx context.Context, data []byte, contentType string) (string, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case Tesseract:
+		path, err := exec.LookPath("tesseract")
+		if err != nil {
+			return nil, fmt.Errorf("%s needs the tesseract command: %v", provider, err)
+		}
+		languages := os.Getenv(LanguagesEnv)
+		if languages == "" {
+			languages = "urd+eng"
+		}
+		return &tesseract{path: path, languages: languages}, nil
+	case Google:
+		key := os.Getenv(APIKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newGoogle(os.Getenv(URLEnv), key), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s or %s", ProviderEnv, provider, Tesseract, Google)
+	}
+}
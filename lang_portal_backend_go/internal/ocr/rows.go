@@ -0,0 +1,100 @@
+package ocr
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Row is a word read from a line of a list
+type Row struct {
+	// Line is the number of the line, from 1
+	Line    int
+	Text    string
+	Urdu    string
+	Urdlish string
+	English string
+}
+
+// separators split a line into cells: tabs, runs of spaces, and dashes,
+// colons, equals signs and bars
+var separators = regexp.MustCompile(`\t|\s{2,}|\s+[-–—=:]\s+|\|`)
+
+// numbering matches the numbers and bullets lists start their lines with
+var numbering = regexp.MustCompile(`^\s*(?:(?:\d+|[٠-٩۰-۹]+|[a-zA-Z])[.)]|[•·*▪-])\s*`)
+
+// cellTrim is what is trimmed off cells
+const cellTrim = " \t.,;:-–—=|"
+
+// ParseRows parses the lines of a word list's text into rows. Urdu is told
+// apart by its script. Of a line's Latin cells the first is urdlish and the
+// second english, or the other way round if englishFirst; a line with one
+// Latin cell has only english, and further cells are added to english.
+// Lines without letters, such as page numbers, are skipped.
+func ParseRows(text string, englishFirst bool) []Row {
+	rows := []Row{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.ContainsFunc(line, unicode.IsLetter) {
+			continue
+		}
+
+		var urdu, latin []string
+		for _, cell := range separators.Split(numbering.ReplaceAllString(line, ""), -1) {
+			for _, run := range scriptRuns(cell) {
+				run = strings.Trim(run, cellTrim)
+				switch {
+				case !strings.ContainsFunc(run, unicode.IsLetter):
+				case isArabic(run):
+					urdu = append(urdu, run)
+				default:
+					latin = append(latin, run)
+				}
+			}
+		}
+
+		row := Row{Line: i + 1, Text: line, Urdu: strings.Join(urdu, " ")}
+		switch {
+		case len(latin) == 1:
+			row.English = latin[0]
+		case len(latin) > 1 && englishFirst:
+			row.English = strings.Join(append(latin[:1:1], latin[2:]...), ", ")
+			row.Urdlish = latin[1]
+		case len(latin) > 1:
+			row.Urdlish = latin[0]
+			row.English = strings.Join(latin[1:], ", ")
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// scriptRuns splits a cell where its words change between Arabic and Latin
+// script
+func scriptRuns(cell string) []string {
+	var runs []string
+	var run []string
+	arabic := false
+	for _, word := range strings.Fields(cell) {
+		if len(run) > 0 && isArabic(word) != arabic {
+			runs = append(runs, strings.Join(run, " "))
+			run = nil
+		}
+		arabic = isArabic(word)
+		run = append(run, word)
+	}
+	if len(run) > 0 {
+		runs = append(runs, strings.Join(run, " "))
+	}
+	return runs
+}
+
+// isArabic reports whether text has Arabic script letters
+func isArabic(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Arabic, r) && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// tesseract reads images with the tesseract command. It can't read PDFs.
+type tesseract struct {
+	path      string
+	languages string
+}
+
+func (p *tesseract) Name() string { return Tesseract }
+
+func (p *tesseract) Recognize(ctx context.Context, data []byte, contentType string) (string, error) {
+	if contentType == PDF {
+		return "", fmt.Errorf("tesseract: %w %s, convert the PDF to images", ErrUnsupportedType, contentType)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path, "stdin", "stdout", "-l", p.languages)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
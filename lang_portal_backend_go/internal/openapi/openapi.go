@@ -0,0 +1,331 @@
+// Package openapi describes the API as an OpenAPI 3 document, built from
+// the router's routes and the Go types of their request and response
+// bodies.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Version of the OpenAPI specification the documents follow
+const Version = "3.0.3"
+
+// bearerAuth names the security scheme of access tokens
+const bearerAuth = "bearerAuth"
+
+// Route is a route of the router
+type Route struct {
+	Method string
+	// Path is the route's gin path, e.g. /api/v1/words/:id
+	Path string
+	// Handler is the name of the handler function
+	Handler string
+}
+
+// Operation describes a route beyond what its path tells
+type Operation struct {
+	Summary     string
+	Description string
+	Query       []Param
+	// Request is a value of the JSON request body's type, or nil for none
+	Request interface{}
+	// Form is the fields of a multipart form request body, for uploads.
+	// Fields of type file are files.
+	Form []Param
+	// Response is a value of the JSON response body's type, or nil if
+	// undocumented
+	Response interface{}
+	// Page is a value of the type of the items of a paginated response
+	Page interface{}
+	// Status is the status of a successful response, 200 if 0
+	Status int
+	// Public operations need no access token
+	Public bool
+}
+
+// Param is a query parameter or form field
+type Param struct {
+	Name        string
+	Description string
+	// Type is integer, number, boolean or string, or file for form fields
+	Type     string
+	Required bool
+}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Servers    []Server                             `json:"servers"`
+	Paths      map[string]map[string]*OperationJSON `json:"paths"`
+	Components Components                           `json:"components"`
+	Security   []map[string][]string                `json:"security"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// OperationJSON is an operation of a document
+type OperationJSON struct {
+	OperationID string                   `json:"operationId"`
+	Summary     string                   `json:"summary"`
+	Description string                   `json:"description,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
+	Parameters  []ParameterJSON          `json:"parameters,omitempty"`
+	RequestBody *RequestBody             `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseJSON `json:"responses"`
+	// Security is empty for public operations and nil for the default
+	Security []map[string][]string `json:"security,omitempty"`
+}
+
+type ParameterJSON struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type ResponseJSON struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Build describes the routes under prefix, e.g. /api/v1, which becomes the
+// document's server. ops describes routes by method and path below the
+// prefix, e.g. "GET /words/:id"; the others are described by their path
+// and handler name only.
+func Build(info Info, prefix string, routes []Route, ops map[string]Operation) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: []Server{{URL: prefix}},
+		Paths:   make(map[string]map[string]*OperationJSON),
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{bearerAuth: {}}},
+	}
+	schemas := newSchemas()
+	errorSchema := schemas.of(errorResponse{})
+
+	// Sorted so operation IDs are stable
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	ids := make(map[string]int)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix+"/") {
+			continue
+		}
+		path := strings.TrimPrefix(route.Path, prefix)
+		op := ops[route.Method+" "+path]
+
+		name := handlerName(route.Handler)
+		if name == "" {
+			name = pathName(route.Method, path)
+		}
+		id := name
+		if ids[name]++; ids[name] > 1 {
+			id += strconv.Itoa(ids[name])
+		}
+		summary := op.Summary
+		if summary == "" {
+			summary = sentence(name)
+		}
+		operation := &OperationJSON{
+			OperationID: id,
+			Summary:     summary,
+			Description: op.Description,
+			Tags:        []string{tag(path)},
+			Responses: map[string]*ResponseJSON{
+				"default": {Description: "Error", Content: jsonContent(errorSchema)},
+			},
+		}
+		if op.Public {
+			operation.Security = []map[string][]string{}
+		}
+
+		openAPIPath, params := pathParams(path)
+		operation.Parameters = params
+		for _, param := range op.Query {
+			paramType := param.Type
+			if paramType == "" {
+				paramType = "string"
+			}
+			operation.Parameters = append(operation.Parameters, ParameterJSON{
+				Name:        param.Name,
+				In:          "query",
+				Description: param.Description,
+				Required:    param.Required,
+				Schema:      &Schema{Type: paramType},
+			})
+		}
+
+		if op.Request != nil {
+			operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(schemas.of(op.Request))}
+		}
+		if op.Form != nil {
+			operation.RequestBody = &RequestBody{Required: true, Content: formContent(op.Form)}
+		}
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := &ResponseJSON{Description: http.StatusText(status)}
+		switch {
+		case op.Page != nil:
+			response.Content = jsonContent(schemas.page(op.Page))
+		case op.Response != nil:
+			response.Content = jsonContent(schemas.of(op.Response))
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+
+		if doc.Paths[openAPIPath] == nil {
+			doc.Paths[openAPIPath] = make(map[string]*OperationJSON)
+		}
+		doc.Paths[openAPIPath][strings.ToLower(route.Method)] = operation
+	}
+	doc.Components.Schemas = schemas.components
+	return doc
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+	// RequestID is the ID of the request, as in the X-Request-ID header
+	RequestID string `json:"request_id"`
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// formContent describes a multipart form of fields
+func formContent(fields []Param) map[string]*MediaType {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range fields {
+		property := &Schema{Type: field.Type}
+		switch field.Type {
+		case "":
+			property.Type = "string"
+		case "file":
+			property = &Schema{Type: "string", Format: "binary"}
+		}
+		schema.Properties[field.Name] = property
+		if field.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	return map[string]*MediaType{"multipart/form-data": {Schema: schema}}
+}
+
+// pathParams turns a gin path into an OpenAPI path, e.g. /words/:id into
+// /words/{id}, and returns its parameters. IDs are integers.
+func pathParams(path string) (string, []ParameterJSON) {
+	var params []ParameterJSON
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		params = append(params, ParameterJSON{Name: name, In: "path", Required: true, Schema: schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// tag groups a path's operations by its first segment, e.g. words
+func tag(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "" {
+		return "api"
+	}
+	return segment
+}
+
+// handlerName returns the function name of a handler, e.g. ListWords for
+// lang_portal/internal/handlers.(*Handler).ListWords-fm, or "" for a
+// function literal
+func handlerName(handler string) string {
+	name := strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if strings.HasPrefix(name, "func") && strings.Trim(name[len("func"):], "0123456789") == "" {
+		return ""
+	}
+	return name
+}
+
+// pathName names an operation by its method and the fixed segments of its
+// path, e.g. GetOpenapiJson for GET /openapi.json
+func pathName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(sentence(strings.ToLower(method)))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':'
+	}) {
+		if strings.HasPrefix(word, ":") {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// sentence turns a function name into a summary, e.g. ListWords into
+// "List words"
+func sentence(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteRune(' ')
+			r = unicode.ToLower(r)
+		} else if i == 0 {
+			r = unicode.ToUpper(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
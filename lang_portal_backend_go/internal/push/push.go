@@ -0,0 +1,308 @@
+// Package push sends Web Push notifications to browsers, identifying the
+// server to push services with VAPID and encrypting messages for each
+// subscription as RFC 8291 requires. The VAPID keys are set by the
+// environment.
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// PublicKeyEnv is the VAPID public key, an uncompressed P-256 point in
+	// unpadded base64url, as browsers take for applicationServerKey
+	PublicKeyEnv = "LANG_PORTAL_VAPID_PUBLIC_KEY"
+	// PrivateKeyEnv is the VAPID private key, a P-256 scalar in unpadded
+	// base64url
+	PrivateKeyEnv = "LANG_PORTAL_VAPID_PRIVATE_KEY"
+	// SubjectEnv is how push services can reach the server's operator, a
+	// mailto: or https: URL
+	SubjectEnv = "LANG_PORTAL_VAPID_SUBJECT"
+)
+
+// ErrGone is returned for subscriptions the push service no longer has,
+// which should be forgotten
+var ErrGone = errors.New("subscription is gone")
+
+// recordSize is the record size of encrypted messages. Messages are sent in
+// one record, so it only needs to exceed them.
+const recordSize = 4096
+
+// MaxPayload is the largest message that fits in a record
+const MaxPayload = recordSize - 16 - 1 - 86
+
+// tokenTTL is how long the VAPID tokens signed for requests are valid
+const tokenTTL = 12 * time.Hour
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+var encoding = base64.RawURLEncoding
+
+// Subscription is where a browser receives pushes, with the keys messages
+// are encrypted for, as PushSubscription.toJSON() gives them
+type Subscription struct {
+	Endpoint string
+	// P256dh is the browser's P-256 public key, in base64url
+	P256dh string
+	// Auth is the browser's authentication secret, in base64url
+	Auth string
+}
+
+// Sender sends pushes signed with the server's VAPID key
+type Sender struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+}
+
+// FromEnv returns the sender configured by the environment, or nil if
+// PrivateKeyEnv isn't set
+func FromEnv() (*Sender, error) {
+	private := os.Getenv(PrivateKeyEnv)
+	if private == "" {
+		return nil, nil
+	}
+	public := os.Getenv(PublicKeyEnv)
+	if public == "" {
+		return nil, fmt.Errorf("%s is required with %s", PublicKeyEnv, PrivateKeyEnv)
+	}
+	subject := os.Getenv(SubjectEnv)
+	if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https://") {
+		return nil, fmt.Errorf("%s must be a mailto: or https: URL", SubjectEnv)
+	}
+	key, err := parsePrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", PrivateKeyEnv, err)
+	}
+	if encodePublicKey(&key.PublicKey) != strings.TrimRight(public, "=") {
+		return nil, fmt.Errorf("%s isn't the public key of %s", PublicKeyEnv, PrivateKeyEnv)
+	}
+	return &Sender{publicKey: encodePublicKey(&key.PublicKey), privateKey: key, subject: subject}, nil
+}
+
+// GenerateKeys returns a new VAPID key pair, in the forms of PublicKeyEnv
+// and PrivateKeyEnv
+func GenerateKeys() (public, private string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encodePublicKey(&key.PublicKey), encoding.EncodeToString(key.D.FillBytes(make([]byte, 32))), nil
+}
+
+// parsePrivateKey reads a base64url P-256 scalar
+func parsePrivateKey(value string) (*ecdsa.PrivateKey, error) {
+	d, err := encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(d) != 32 {
+		return nil, fmt.Errorf("must be 32 bytes of base64url")
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, err
+	}
+	point := key.PublicKey().Bytes()
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// encodePublicKey returns a public key as an uncompressed point in base64url
+func encodePublicKey(key *ecdsa.PublicKey) string {
+	point := make([]byte, 65)
+	point[0] = 4
+	key.X.FillBytes(point[1:33])
+	key.Y.FillBytes(point[33:])
+	return encoding.EncodeToString(point)
+}
+
+// PublicKey returns the VAPID public key, which browsers subscribe with
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// ValidEndpoint checks that a subscription's endpoint is an HTTPS URL of a
+// named host, so pushes can't be aimed at the server's own network
+func ValidEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("endpoint must be an https URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil || host == "localhost" || strings.HasSuffix(host, ".localhost") || !strings.Contains(host, ".") {
+		return fmt.Errorf("endpoint must name a push service")
+	}
+	return nil
+}
+
+// Send pushes payload to a subscription, to be delivered within ttl if the
+// browser is offline. It returns ErrGone if the subscription has expired or
+// been unsubscribed.
+func (s *Sender) Send(ctx context.Context, sub Subscription, payload []byte, ttl time.Duration) error {
+	if err := ValidEndpoint(sub.Endpoint); err != nil {
+		return err
+	}
+	if len(payload) > MaxPayload {
+		return fmt.Errorf("payload is too large")
+	}
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	token, err := s.token(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("push: %v", err)
+	}
+	req.Header.Set("Authorization", "vapid t="+token+", k="+s.publicKey)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	default:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+}
+
+// token signs a VAPID token for the push service of endpoint
+func (s *Sender) token(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("push: invalid endpoint")
+	}
+	header := encoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(tokenTTL).Unix(),
+		"sub": s.subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("push: failed to encode token: %v", err)
+	}
+	signed := header + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("push: failed to sign token: %v", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signed + "." + encoding.EncodeToString(signature), nil
+}
+
+// encrypt encrypts payload for a subscription with the aes128gcm content
+// coding of RFC 8188, keyed as RFC 8291 describes, in a single record
+func encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	uaPublic, err := encoding.DecodeString(strings.TrimRight(sub.P256dh, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid p256dh key")
+	}
+	authSecret, err := encoding.DecodeString(strings.TrimRight(sub.Auth, "="))
+	if err != nil || len(authSecret) != 16 {
+		return nil, fmt.Errorf("push: invalid auth secret")
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("push: invalid p256dh key")
+	}
+
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+	asPublic := asKey.PublicKey().Bytes()
+	secret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := derive(secret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := derive(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := derive(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+	// The last record ends with a 2 delimiter and no padding
+	plaintext := append(append([]byte{}, payload...), 2)
+
+	header := make([]byte, 21, 21+len(asPublic))
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:20], recordSize)
+	header[20] = byte(len(asPublic))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// derive returns size bytes of HKDF-SHA256 of secret with salt and info
+func derive(secret, salt, info []byte, size int) ([]byte, error) {
+	out := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, fmt.Errorf("push: %v", err)
+	}
+	return out, nil
+}
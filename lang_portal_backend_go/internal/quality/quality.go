@@ -0,0 +1,186 @@
+// Package quality checks word content for problems that shouldn't block a
+// write but that a content author will want to fix.
+package quality
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/models"
+	"strings"
+	"unicode"
+)
+
+// Warning codes
+const (
+	CodeSuspiciousTransliteration = "suspicious_transliteration"
+	CodePossibleDuplicate         = "possible_duplicate"
+	CodeMissingParts              = "missing_parts"
+)
+
+// Warning is a non-fatal problem found in submitted content
+type Warning struct {
+	Code    string `json:"code"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Rule inspects a word and reports any warnings about it
+type Rule interface {
+	Check(word *models.Word) ([]Warning, error)
+}
+
+// RuleFunc adapts a function to the Rule interface
+type RuleFunc func(word *models.Word) ([]Warning, error)
+
+func (f RuleFunc) Check(word *models.Word) ([]Warning, error) {
+	return f(word)
+}
+
+// DuplicateLookup returns the IDs of existing words of the same language
+// with the same script or English text, excluding the word itself
+type DuplicateLookup func(word *models.Word) ([]int64, error)
+
+// Engine runs a set of rules against words
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine creates an engine running the given rules in order
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// NewDefaultEngine creates an engine with the standard word rules. Duplicate
+// detection is skipped when lookup is nil.
+func NewDefaultEngine(lookup DuplicateLookup) *Engine {
+	rules := []Rule{
+		RuleFunc(checkTransliteration),
+		RuleFunc(checkParts),
+	}
+	if lookup != nil {
+		rules = append(rules, duplicateRule(lookup))
+	}
+	return NewEngine(rules...)
+}
+
+// Check runs every rule and collects their warnings
+func (e *Engine) Check(word *models.Word) ([]Warning, error) {
+	warnings := []Warning{}
+	for _, rule := range e.rules {
+		found, err := rule.Check(word)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, found...)
+	}
+	return warnings, nil
+}
+
+// checkTransliteration flags a word's script written in Latin script and
+// its transliteration written in another script, which usually means the
+// fields were swapped. Whatever the language, the urdu field holds the word
+// in its script and urdlish its transliteration.
+func checkTransliteration(word *models.Word) ([]Warning, error) {
+	var warnings []Warning
+
+	if word.Urdu != "" && !hasNonLatin(word.Urdu) {
+		warnings = append(warnings, Warning{
+			Code:    CodeSuspiciousTransliteration,
+			Field:   "urdu",
+			Message: "urdu contains no letters outside Latin script",
+		})
+	}
+	if hasNonLatin(word.Urdlish) {
+		warnings = append(warnings, Warning{
+			Code:    CodeSuspiciousTransliteration,
+			Field:   "urdlish",
+			Message: "urdlish should be written in Latin script",
+		})
+	}
+	if hasNonLatin(word.English) {
+		warnings = append(warnings, Warning{
+			Code:    CodeSuspiciousTransliteration,
+			Field:   "english",
+			Message: "english contains letters outside Latin script",
+		})
+	}
+	if word.Urdlish != "" && strings.EqualFold(strings.TrimSpace(word.Urdlish), strings.TrimSpace(word.English)) {
+		warnings = append(warnings, Warning{
+			Code:    CodeSuspiciousTransliteration,
+			Field:   "urdlish",
+			Message: "urdlish is the same as the english translation",
+		})
+	}
+
+	return warnings, nil
+}
+
+// checkParts flags words without grammatical parts
+func checkParts(word *models.Word) ([]Warning, error) {
+	parts := strings.TrimSpace(word.Parts)
+	if parts == "" || parts == "null" || parts == "{}" {
+		return []Warning{{
+			Code:    CodeMissingParts,
+			Field:   "parts",
+			Message: "word has no parts of speech or grammatical details",
+		}}, nil
+	}
+	return nil, nil
+}
+
+func duplicateRule(lookup DuplicateLookup) Rule {
+	return RuleFunc(func(word *models.Word) ([]Warning, error) {
+		ids, err := lookup(word)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up duplicates: %v", err)
+		}
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		return []Warning{{
+			Code:    CodePossibleDuplicate,
+			Message: fmt.Sprintf("possible duplicate of word %v", ids),
+		}}, nil
+	})
+}
+
+// hasNonLatin reports whether text has letters of a script other than Latin
+func hasNonLatin(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Querier is satisfied by both *models.DB and *models.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*models.Rows, error)
+}
+
+// SQLDuplicateLookup finds duplicates in the words table, querying with ctx
+func SQLDuplicateLookup(ctx context.Context, q Querier) DuplicateLookup {
+	return func(word *models.Word) ([]int64, error) {
+		rows, err := q.QueryContext(ctx, `
+			SELECT id FROM words
+			WHERE (script = ? OR lower(english) = lower(?)) AND id != ? AND language = ?
+			ORDER BY id
+			LIMIT 5
+		`, word.Urdu, word.English, word.ID, word.Language)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, rows.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+)
+
+type sqlGroups struct {
+	db      Querier
+	dialect dialect.Dialect
+}
+
+// visibleGroup returns the condition of the groups g the user bound to the
+// placeholder viewer can see: those nobody owns and those the user has a
+// role on, or every group if the user is 0
+func visibleGroup(viewer string) string {
+	return `(` + viewer + ` = 0
+	OR NOT EXISTS (SELECT 1 FROM group_permissions gp WHERE gp.group_id = g.id AND gp.role = 'owner')
+	OR EXISTS (SELECT 1 FROM group_permissions gp WHERE gp.group_id = g.id AND gp.user_id = ` + viewer + `))`
+}
+
+func (r *sqlGroups) List(ctx context.Context, viewerID int64, language, sort string, limit, offset int) ([]models.GroupResponse, error) {
+	join, orderBy := ``, `g.id`
+	if sort == SortUrdu {
+		join, orderBy = `LEFT JOIN group_sort_keys sk ON sk.group_id = g.id`, `sk.sort_key, g.id`
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT g.id, g.name, g.language,
+			   (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
+		FROM groups g `+join+`
+		WHERE (?1 = '' OR g.language = ?1) AND `+visibleGroup("?2")+`
+		ORDER BY `+orderBy+`
+		LIMIT ?3 OFFSET ?4
+	`), language, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.GroupResponse
+	for rows.Next() {
+		var group models.GroupResponse
+		if err := rows.Scan(&group.ID, &group.Name, &group.Language, &group.WordCount); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (r *sqlGroups) Count(ctx context.Context, viewerID int64, language string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT COUNT(*) FROM groups g WHERE (?1 = '' OR g.language = ?1) AND `+visibleGroup("?2")+`
+	`), language, viewerID).Scan(&total)
+	return total, err
+}
+
+func (r *sqlGroups) Get(ctx context.Context, id int64) (*models.GroupResponse, error) {
+	var group models.GroupResponse
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT g.id, g.name, g.language,
+			   (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = g.id) as word_count
+		FROM groups g
+		WHERE g.id = ?
+	`), id).Scan(&group.ID, &group.Name, &group.Language, &group.WordCount)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *sqlGroups) ListWords(ctx context.Context, userID, groupID int64, sort string, limit, offset int) ([]models.WordResponse, error) {
+	join, orderBy := wordOrder(sort)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT w.id, w.script, w.transliteration, w.english, w.language,
+			   `+reviewCounts+`, `+renderingColumns+`
+		FROM words w `+renderingJoin+` `+join+`
+		WHERE w.id IN (SELECT wg.word_id FROM words_groups wg WHERE wg.group_id = ?2)
+		ORDER BY `+orderBy+`
+		LIMIT ?3 OFFSET ?4
+	`), userID, groupID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanWords(rows)
+}
+
+func (r *sqlGroups) CountWords(ctx context.Context, groupID int64) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT COUNT(DISTINCT w.id)
+		FROM words w
+		JOIN words_groups wg ON w.id = wg.word_id
+		WHERE wg.group_id = ?
+	`), groupID).Scan(&total)
+	return total, err
+}
+
+func (r *sqlGroups) AddWords(ctx context.Context, q Querier, groupID int64, wordIDs []int64) error {
+	// Add each word to the group
+	for _, wordID := range wordIDs {
+		_, err := q.ExecContext(ctx, r.dialect.Rebind(`
+			INSERT INTO words_groups (word_id, group_id)
+			VALUES (?, ?)
+		`), wordID, groupID)
+		if err != nil {
+			return fmt.Errorf("failed to add word to group: %v", err)
+		}
+	}
+
+	// Update word count
+	_, err := q.ExecContext(ctx, r.dialect.Rebind(`
+		UPDATE groups
+		SET word_count = (
+			SELECT COUNT(*)
+			FROM words_groups
+			WHERE group_id = ?
+		)
+		WHERE id = ?
+	`), groupID, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to update word count: %v", err)
+	}
+	return nil
+}
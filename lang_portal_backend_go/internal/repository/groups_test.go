@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestListVisibleGroups(t *testing.T) {
+	ctx := context.Background()
+	db, err := models.NewTestDB()
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	// Group 2 is owned by user 2 and seen by user 4; the others are nobody's
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO users (id, username) VALUES (2, 'owner'), (3, 'stranger'), (4, 'viewer');
+		INSERT INTO group_permissions (group_id, user_id, role) VALUES (2, 2, 'owner'), (2, 4, 'viewer');
+	`)
+	if err != nil {
+		t.Fatalf("failed to set up permissions: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		viewerID int64
+		want     []int64
+	}{
+		{name: "admin", viewerID: 0, want: []int64{1, 2, 3}},
+		{name: "owner", viewerID: 2, want: []int64{1, 2, 3}},
+		{name: "viewer", viewerID: 4, want: []int64{1, 2, 3}},
+		{name: "user without a role", viewerID: 3, want: []int64{1, 3}},
+	}
+
+	groups := NewSQLite(db).Groups
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := groups.List(ctx, tt.viewerID, "", SortID, 100, 0)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var got []int64
+			for _, group := range list {
+				got = append(got, group.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = groups %v, want %v", got, tt.want)
+			}
+
+			total, err := groups.Count(ctx, tt.viewerID, "")
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if total != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
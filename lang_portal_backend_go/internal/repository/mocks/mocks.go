@@ -0,0 +1,1327 @@
+// Package mocks holds mocks of the repository interfaces for tests. They are
+// written by hand, in the shape github.com/matryer/moq gives mocks, so add
+// a method to a mock when its interface gains one.
+package mocks
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"sync"
+)
+
+// Ensure, that WordRepositoryMock does implement repository.WordRepository.
+// If this is not the case, add the missing methods to it.
+var _ repository.WordRepository = &WordRepositoryMock{}
+
+// WordRepositoryMock is a mock implementation of repository.WordRepository.
+//
+//	func TestSomethingThatUsesWordRepository(t *testing.T) {
+//
+//		// make and configure a mocked repository.WordRepository
+//		mockedWordRepository := &WordRepositoryMock{
+//			CountFunc: func(ctx context.Context, viewerID int64, language string) (int, error) {
+//				panic("mock out the Count method")
+//			},
+//			CreateFunc: func(ctx context.Context, q repository.Querier, word *models.Word) error {
+//				panic("mock out the Create method")
+//			},
+//			GetFunc: func(ctx context.Context, userID int64, viewerID int64, id int64) (*models.WordResponse, error) {
+//				panic("mock out the Get method")
+//			},
+//			ListFunc: func(ctx context.Context, userID int64, viewerID int64, language string, sort string, limit int, offset int) ([]models.WordResponse, error) {
+//				panic("mock out the List method")
+//			},
+//			ListAfterFunc: func(ctx context.Context, userID int64, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error) {
+//				panic("mock out the ListAfter method")
+//			},
+//		}
+//
+//		// use mockedWordRepository in code that requires repository.WordRepository
+//		// and then make assertions.
+//
+//	}
+type WordRepositoryMock struct {
+	// CountFunc mocks the Count method.
+	CountFunc func(ctx context.Context, viewerID int64, language string) (int, error)
+
+	// CreateFunc mocks the Create method.
+	CreateFunc func(ctx context.Context, q repository.Querier, word *models.Word) error
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, userID int64, viewerID int64, id int64) (*models.WordResponse, error)
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, userID int64, viewerID int64, language string, sort string, limit int, offset int) ([]models.WordResponse, error)
+
+	// ListAfterFunc mocks the ListAfter method.
+	ListAfterFunc func(ctx context.Context, userID int64, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Count holds details about calls to the Count method.
+		Count []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// Language is the language argument value.
+			Language string
+		}
+		// Create holds details about calls to the Create method.
+		Create []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q repository.Querier
+			// Word is the word argument value.
+			Word *models.Word
+		}
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// ID is the id argument value.
+			ID int64
+		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// Language is the language argument value.
+			Language string
+			// Sort is the sort argument value.
+			Sort string
+			// Limit is the limit argument value.
+			Limit int
+			// Offset is the offset argument value.
+			Offset int
+		}
+		// ListAfter holds details about calls to the ListAfter method.
+		ListAfter []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// Language is the language argument value.
+			Language string
+			// AfterID is the afterID argument value.
+			AfterID int64
+			// Limit is the limit argument value.
+			Limit int
+		}
+	}
+	lockCount     sync.RWMutex
+	lockCreate    sync.RWMutex
+	lockGet       sync.RWMutex
+	lockList      sync.RWMutex
+	lockListAfter sync.RWMutex
+}
+
+// Count calls CountFunc.
+func (mock *WordRepositoryMock) Count(ctx context.Context, viewerID int64, language string) (int, error) {
+	if mock.CountFunc == nil {
+		panic("WordRepositoryMock.CountFunc: method is nil but WordRepository.Count was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+	}{
+		Ctx:      ctx,
+		ViewerID: viewerID,
+		Language: language,
+	}
+	mock.lockCount.Lock()
+	mock.calls.Count = append(mock.calls.Count, callInfo)
+	mock.lockCount.Unlock()
+	return mock.CountFunc(ctx, viewerID, language)
+}
+
+// CountCalls gets all the calls that were made to Count.
+// Check the length with:
+//
+//	len(mockedWordRepository.CountCalls())
+func (mock *WordRepositoryMock) CountCalls() []struct {
+	Ctx      context.Context
+	ViewerID int64
+	Language string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+	}
+	mock.lockCount.RLock()
+	calls = mock.calls.Count
+	mock.lockCount.RUnlock()
+	return calls
+}
+
+// Create calls CreateFunc.
+func (mock *WordRepositoryMock) Create(ctx context.Context, q repository.Querier, word *models.Word) error {
+	if mock.CreateFunc == nil {
+		panic("WordRepositoryMock.CreateFunc: method is nil but WordRepository.Create was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Q    repository.Querier
+		Word *models.Word
+	}{
+		Ctx:  ctx,
+		Q:    q,
+		Word: word,
+	}
+	mock.lockCreate.Lock()
+	mock.calls.Create = append(mock.calls.Create, callInfo)
+	mock.lockCreate.Unlock()
+	return mock.CreateFunc(ctx, q, word)
+}
+
+// CreateCalls gets all the calls that were made to Create.
+// Check the length with:
+//
+//	len(mockedWordRepository.CreateCalls())
+func (mock *WordRepositoryMock) CreateCalls() []struct {
+	Ctx  context.Context
+	Q    repository.Querier
+	Word *models.Word
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Q    repository.Querier
+		Word *models.Word
+	}
+	mock.lockCreate.RLock()
+	calls = mock.calls.Create
+	mock.lockCreate.RUnlock()
+	return calls
+}
+
+// Get calls GetFunc.
+func (mock *WordRepositoryMock) Get(ctx context.Context, userID int64, viewerID int64, id int64) (*models.WordResponse, error) {
+	if mock.GetFunc == nil {
+		panic("WordRepositoryMock.GetFunc: method is nil but WordRepository.Get was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		ID       int64
+	}{
+		Ctx:      ctx,
+		UserID:   userID,
+		ViewerID: viewerID,
+		ID:       id,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(ctx, userID, viewerID, id)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedWordRepository.GetCalls())
+func (mock *WordRepositoryMock) GetCalls() []struct {
+	Ctx      context.Context
+	UserID   int64
+	ViewerID int64
+	ID       int64
+} {
+	var calls []struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		ID       int64
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// List calls ListFunc.
+func (mock *WordRepositoryMock) List(ctx context.Context, userID int64, viewerID int64, language string, sort string, limit int, offset int) ([]models.WordResponse, error) {
+	if mock.ListFunc == nil {
+		panic("WordRepositoryMock.ListFunc: method is nil but WordRepository.List was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		Language string
+		Sort     string
+		Limit    int
+		Offset   int
+	}{
+		Ctx:      ctx,
+		UserID:   userID,
+		ViewerID: viewerID,
+		Language: language,
+		Sort:     sort,
+		Limit:    limit,
+		Offset:   offset,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, userID, viewerID, language, sort, limit, offset)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedWordRepository.ListCalls())
+func (mock *WordRepositoryMock) ListCalls() []struct {
+	Ctx      context.Context
+	UserID   int64
+	ViewerID int64
+	Language string
+	Sort     string
+	Limit    int
+	Offset   int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		Language string
+		Sort     string
+		Limit    int
+		Offset   int
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
+// ListAfter calls ListAfterFunc.
+func (mock *WordRepositoryMock) ListAfter(ctx context.Context, userID int64, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error) {
+	if mock.ListAfterFunc == nil {
+		panic("WordRepositoryMock.ListAfterFunc: method is nil but WordRepository.ListAfter was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		Language string
+		AfterID  int64
+		Limit    int
+	}{
+		Ctx:      ctx,
+		UserID:   userID,
+		ViewerID: viewerID,
+		Language: language,
+		AfterID:  afterID,
+		Limit:    limit,
+	}
+	mock.lockListAfter.Lock()
+	mock.calls.ListAfter = append(mock.calls.ListAfter, callInfo)
+	mock.lockListAfter.Unlock()
+	return mock.ListAfterFunc(ctx, userID, viewerID, language, afterID, limit)
+}
+
+// ListAfterCalls gets all the calls that were made to ListAfter.
+// Check the length with:
+//
+//	len(mockedWordRepository.ListAfterCalls())
+func (mock *WordRepositoryMock) ListAfterCalls() []struct {
+	Ctx      context.Context
+	UserID   int64
+	ViewerID int64
+	Language string
+	AfterID  int64
+	Limit    int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		UserID   int64
+		ViewerID int64
+		Language string
+		AfterID  int64
+		Limit    int
+	}
+	mock.lockListAfter.RLock()
+	calls = mock.calls.ListAfter
+	mock.lockListAfter.RUnlock()
+	return calls
+}
+
+// Ensure, that GroupRepositoryMock does implement repository.GroupRepository.
+// If this is not the case, add the missing methods to it.
+var _ repository.GroupRepository = &GroupRepositoryMock{}
+
+// GroupRepositoryMock is a mock implementation of repository.GroupRepository.
+//
+//	func TestSomethingThatUsesGroupRepository(t *testing.T) {
+//
+//		// make and configure a mocked repository.GroupRepository
+//		mockedGroupRepository := &GroupRepositoryMock{
+//			AddWordsFunc: func(ctx context.Context, q repository.Querier, groupID int64, wordIDs []int64) error {
+//				panic("mock out the AddWords method")
+//			},
+//			CountFunc: func(ctx context.Context, viewerID int64, language string) (int, error) {
+//				panic("mock out the Count method")
+//			},
+//			CountWordsFunc: func(ctx context.Context, groupID int64) (int, error) {
+//				panic("mock out the CountWords method")
+//			},
+//			GetFunc: func(ctx context.Context, id int64) (*models.GroupResponse, error) {
+//				panic("mock out the Get method")
+//			},
+//			ListFunc: func(ctx context.Context, viewerID int64, language string, sort string, limit int, offset int) ([]models.GroupResponse, error) {
+//				panic("mock out the List method")
+//			},
+//			ListWordsFunc: func(ctx context.Context, userID int64, groupID int64, sort string, limit int, offset int) ([]models.WordResponse, error) {
+//				panic("mock out the ListWords method")
+//			},
+//		}
+//
+//		// use mockedGroupRepository in code that requires repository.GroupRepository
+//		// and then make assertions.
+//
+//	}
+type GroupRepositoryMock struct {
+	// AddWordsFunc mocks the AddWords method.
+	AddWordsFunc func(ctx context.Context, q repository.Querier, groupID int64, wordIDs []int64) error
+
+	// CountFunc mocks the Count method.
+	CountFunc func(ctx context.Context, viewerID int64, language string) (int, error)
+
+	// CountWordsFunc mocks the CountWords method.
+	CountWordsFunc func(ctx context.Context, groupID int64) (int, error)
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, id int64) (*models.GroupResponse, error)
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, viewerID int64, language string, sort string, limit int, offset int) ([]models.GroupResponse, error)
+
+	// ListWordsFunc mocks the ListWords method.
+	ListWordsFunc func(ctx context.Context, userID int64, groupID int64, sort string, limit int, offset int) ([]models.WordResponse, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// AddWords holds details about calls to the AddWords method.
+		AddWords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q repository.Querier
+			// GroupID is the groupID argument value.
+			GroupID int64
+			// WordIDs is the wordIDs argument value.
+			WordIDs []int64
+		}
+		// Count holds details about calls to the Count method.
+		Count []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// Language is the language argument value.
+			Language string
+		}
+		// CountWords holds details about calls to the CountWords method.
+		CountWords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// GroupID is the groupID argument value.
+			GroupID int64
+		}
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID int64
+		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ViewerID is the viewerID argument value.
+			ViewerID int64
+			// Language is the language argument value.
+			Language string
+			// Sort is the sort argument value.
+			Sort string
+			// Limit is the limit argument value.
+			Limit int
+			// Offset is the offset argument value.
+			Offset int
+		}
+		// ListWords holds details about calls to the ListWords method.
+		ListWords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// GroupID is the groupID argument value.
+			GroupID int64
+			// Sort is the sort argument value.
+			Sort string
+			// Limit is the limit argument value.
+			Limit int
+			// Offset is the offset argument value.
+			Offset int
+		}
+	}
+	lockAddWords   sync.RWMutex
+	lockCount      sync.RWMutex
+	lockCountWords sync.RWMutex
+	lockGet        sync.RWMutex
+	lockList       sync.RWMutex
+	lockListWords  sync.RWMutex
+}
+
+// AddWords calls AddWordsFunc.
+func (mock *GroupRepositoryMock) AddWords(ctx context.Context, q repository.Querier, groupID int64, wordIDs []int64) error {
+	if mock.AddWordsFunc == nil {
+		panic("GroupRepositoryMock.AddWordsFunc: method is nil but GroupRepository.AddWords was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Q       repository.Querier
+		GroupID int64
+		WordIDs []int64
+	}{
+		Ctx:     ctx,
+		Q:       q,
+		GroupID: groupID,
+		WordIDs: wordIDs,
+	}
+	mock.lockAddWords.Lock()
+	mock.calls.AddWords = append(mock.calls.AddWords, callInfo)
+	mock.lockAddWords.Unlock()
+	return mock.AddWordsFunc(ctx, q, groupID, wordIDs)
+}
+
+// AddWordsCalls gets all the calls that were made to AddWords.
+// Check the length with:
+//
+//	len(mockedGroupRepository.AddWordsCalls())
+func (mock *GroupRepositoryMock) AddWordsCalls() []struct {
+	Ctx     context.Context
+	Q       repository.Querier
+	GroupID int64
+	WordIDs []int64
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Q       repository.Querier
+		GroupID int64
+		WordIDs []int64
+	}
+	mock.lockAddWords.RLock()
+	calls = mock.calls.AddWords
+	mock.lockAddWords.RUnlock()
+	return calls
+}
+
+// Count calls CountFunc.
+func (mock *GroupRepositoryMock) Count(ctx context.Context, viewerID int64, language string) (int, error) {
+	if mock.CountFunc == nil {
+		panic("GroupRepositoryMock.CountFunc: method is nil but GroupRepository.Count was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+	}{
+		Ctx:      ctx,
+		ViewerID: viewerID,
+		Language: language,
+	}
+	mock.lockCount.Lock()
+	mock.calls.Count = append(mock.calls.Count, callInfo)
+	mock.lockCount.Unlock()
+	return mock.CountFunc(ctx, viewerID, language)
+}
+
+// CountCalls gets all the calls that were made to Count.
+// Check the length with:
+//
+//	len(mockedGroupRepository.CountCalls())
+func (mock *GroupRepositoryMock) CountCalls() []struct {
+	Ctx      context.Context
+	ViewerID int64
+	Language string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+	}
+	mock.lockCount.RLock()
+	calls = mock.calls.Count
+	mock.lockCount.RUnlock()
+	return calls
+}
+
+// CountWords calls CountWordsFunc.
+func (mock *GroupRepositoryMock) CountWords(ctx context.Context, groupID int64) (int, error) {
+	if mock.CountWordsFunc == nil {
+		panic("GroupRepositoryMock.CountWordsFunc: method is nil but GroupRepository.CountWords was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		GroupID int64
+	}{
+		Ctx:     ctx,
+		GroupID: groupID,
+	}
+	mock.lockCountWords.Lock()
+	mock.calls.CountWords = append(mock.calls.CountWords, callInfo)
+	mock.lockCountWords.Unlock()
+	return mock.CountWordsFunc(ctx, groupID)
+}
+
+// CountWordsCalls gets all the calls that were made to CountWords.
+// Check the length with:
+//
+//	len(mockedGroupRepository.CountWordsCalls())
+func (mock *GroupRepositoryMock) CountWordsCalls() []struct {
+	Ctx     context.Context
+	GroupID int64
+} {
+	var calls []struct {
+		Ctx     context.Context
+		GroupID int64
+	}
+	mock.lockCountWords.RLock()
+	calls = mock.calls.CountWords
+	mock.lockCountWords.RUnlock()
+	return calls
+}
+
+// Get calls GetFunc.
+func (mock *GroupRepositoryMock) Get(ctx context.Context, id int64) (*models.GroupResponse, error) {
+	if mock.GetFunc == nil {
+		panic("GroupRepositoryMock.GetFunc: method is nil but GroupRepository.Get was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  int64
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(ctx, id)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedGroupRepository.GetCalls())
+func (mock *GroupRepositoryMock) GetCalls() []struct {
+	Ctx context.Context
+	ID  int64
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  int64
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// List calls ListFunc.
+func (mock *GroupRepositoryMock) List(ctx context.Context, viewerID int64, language string, sort string, limit int, offset int) ([]models.GroupResponse, error) {
+	if mock.ListFunc == nil {
+		panic("GroupRepositoryMock.ListFunc: method is nil but GroupRepository.List was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+		Sort     string
+		Limit    int
+		Offset   int
+	}{
+		Ctx:      ctx,
+		ViewerID: viewerID,
+		Language: language,
+		Sort:     sort,
+		Limit:    limit,
+		Offset:   offset,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, viewerID, language, sort, limit, offset)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedGroupRepository.ListCalls())
+func (mock *GroupRepositoryMock) ListCalls() []struct {
+	Ctx      context.Context
+	ViewerID int64
+	Language string
+	Sort     string
+	Limit    int
+	Offset   int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		ViewerID int64
+		Language string
+		Sort     string
+		Limit    int
+		Offset   int
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
+// ListWords calls ListWordsFunc.
+func (mock *GroupRepositoryMock) ListWords(ctx context.Context, userID int64, groupID int64, sort string, limit int, offset int) ([]models.WordResponse, error) {
+	if mock.ListWordsFunc == nil {
+		panic("GroupRepositoryMock.ListWordsFunc: method is nil but GroupRepository.ListWords was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+		Sort    string
+		Limit   int
+		Offset  int
+	}{
+		Ctx:     ctx,
+		UserID:  userID,
+		GroupID: groupID,
+		Sort:    sort,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	mock.lockListWords.Lock()
+	mock.calls.ListWords = append(mock.calls.ListWords, callInfo)
+	mock.lockListWords.Unlock()
+	return mock.ListWordsFunc(ctx, userID, groupID, sort, limit, offset)
+}
+
+// ListWordsCalls gets all the calls that were made to ListWords.
+// Check the length with:
+//
+//	len(mockedGroupRepository.ListWordsCalls())
+func (mock *GroupRepositoryMock) ListWordsCalls() []struct {
+	Ctx     context.Context
+	UserID  int64
+	GroupID int64
+	Sort    string
+	Limit   int
+	Offset  int
+} {
+	var calls []struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+		Sort    string
+		Limit   int
+		Offset  int
+	}
+	mock.lockListWords.RLock()
+	calls = mock.calls.ListWords
+	mock.lockListWords.RUnlock()
+	return calls
+}
+
+// Ensure, that SessionRepositoryMock does implement repository.SessionRepository.
+// If this is not the case, add the missing methods to it.
+var _ repository.SessionRepository = &SessionRepositoryMock{}
+
+// SessionRepositoryMock is a mock implementation of repository.SessionRepository.
+//
+//	func TestSomethingThatUsesSessionRepository(t *testing.T) {
+//
+//		// make and configure a mocked repository.SessionRepository
+//		mockedSessionRepository := &SessionRepositoryMock{
+//			CountFunc: func(ctx context.Context, userID int64, groupID int64) (int, error) {
+//				panic("mock out the Count method")
+//			},
+//			GetFunc: func(ctx context.Context, userID int64, id int64) (*models.StudySessionResponse, error) {
+//				panic("mock out the Get method")
+//			},
+//			ListFunc: func(ctx context.Context, userID int64, groupID int64, limit int, offset int) ([]models.StudySessionResponse, error) {
+//				panic("mock out the List method")
+//			},
+//			ListBeforeFunc: func(ctx context.Context, userID int64, groupID int64, beforeID int64, limit int) ([]models.StudySessionResponse, error) {
+//				panic("mock out the ListBefore method")
+//			},
+//			ListWordsFunc: func(ctx context.Context, sessionID int64) ([]models.WordResponse, error) {
+//				panic("mock out the ListWords method")
+//			},
+//			OwnsFunc: func(ctx context.Context, q repository.Querier, userID int64, sessionID int64) (bool, error) {
+//				panic("mock out the Owns method")
+//			},
+//			SetWordsFunc: func(ctx context.Context, q repository.Querier, sessionID int64, wordIDs []int64) error {
+//				panic("mock out the SetWords method")
+//			},
+//		}
+//
+//		// use mockedSessionRepository in code that requires repository.SessionRepository
+//		// and then make assertions.
+//
+//	}
+type SessionRepositoryMock struct {
+	// CountFunc mocks the Count method.
+	CountFunc func(ctx context.Context, userID int64, groupID int64) (int, error)
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, userID int64, id int64) (*models.StudySessionResponse, error)
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, userID int64, groupID int64, limit int, offset int) ([]models.StudySessionResponse, error)
+
+	// ListBeforeFunc mocks the ListBefore method.
+	ListBeforeFunc func(ctx context.Context, userID int64, groupID int64, beforeID int64, limit int) ([]models.StudySessionResponse, error)
+
+	// ListWordsFunc mocks the ListWords method.
+	ListWordsFunc func(ctx context.Context, sessionID int64) ([]models.WordResponse, error)
+
+	// OwnsFunc mocks the Owns method.
+	OwnsFunc func(ctx context.Context, q repository.Querier, userID int64, sessionID int64) (bool, error)
+
+	// SetWordsFunc mocks the SetWords method.
+	SetWordsFunc func(ctx context.Context, q repository.Querier, sessionID int64, wordIDs []int64) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Count holds details about calls to the Count method.
+		Count []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// GroupID is the groupID argument value.
+			GroupID int64
+		}
+		// Get holds details about calls to the Get method.
+		Get []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// ID is the id argument value.
+			ID int64
+		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// GroupID is the groupID argument value.
+			GroupID int64
+			// Limit is the limit argument value.
+			Limit int
+			// Offset is the offset argument value.
+			Offset int
+		}
+		// ListBefore holds details about calls to the ListBefore method.
+		ListBefore []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID int64
+			// GroupID is the groupID argument value.
+			GroupID int64
+			// BeforeID is the beforeID argument value.
+			BeforeID int64
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// ListWords holds details about calls to the ListWords method.
+		ListWords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SessionID is the sessionID argument value.
+			SessionID int64
+		}
+		// Owns holds details about calls to the Owns method.
+		Owns []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q repository.Querier
+			// UserID is the userID argument value.
+			UserID int64
+			// SessionID is the sessionID argument value.
+			SessionID int64
+		}
+		// SetWords holds details about calls to the SetWords method.
+		SetWords []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q repository.Querier
+			// SessionID is the sessionID argument value.
+			SessionID int64
+			// WordIDs is the wordIDs argument value.
+			WordIDs []int64
+		}
+	}
+	lockCount      sync.RWMutex
+	lockGet        sync.RWMutex
+	lockList       sync.RWMutex
+	lockListBefore sync.RWMutex
+	lockListWords  sync.RWMutex
+	lockOwns       sync.RWMutex
+	lockSetWords   sync.RWMutex
+}
+
+// Count calls CountFunc.
+func (mock *SessionRepositoryMock) Count(ctx context.Context, userID int64, groupID int64) (int, error) {
+	if mock.CountFunc == nil {
+		panic("SessionRepositoryMock.CountFunc: method is nil but SessionRepository.Count was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+	}{
+		Ctx:     ctx,
+		UserID:  userID,
+		GroupID: groupID,
+	}
+	mock.lockCount.Lock()
+	mock.calls.Count = append(mock.calls.Count, callInfo)
+	mock.lockCount.Unlock()
+	return mock.CountFunc(ctx, userID, groupID)
+}
+
+// CountCalls gets all the calls that were made to Count.
+// Check the length with:
+//
+//	len(mockedSessionRepository.CountCalls())
+func (mock *SessionRepositoryMock) CountCalls() []struct {
+	Ctx     context.Context
+	UserID  int64
+	GroupID int64
+} {
+	var calls []struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+	}
+	mock.lockCount.RLock()
+	calls = mock.calls.Count
+	mock.lockCount.RUnlock()
+	return calls
+}
+
+// Get calls GetFunc.
+func (mock *SessionRepositoryMock) Get(ctx context.Context, userID int64, id int64) (*models.StudySessionResponse, error) {
+	if mock.GetFunc == nil {
+		panic("SessionRepositoryMock.GetFunc: method is nil but SessionRepository.Get was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		UserID int64
+		ID     int64
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		ID:     id,
+	}
+	mock.lockGet.Lock()
+	mock.calls.Get = append(mock.calls.Get, callInfo)
+	mock.lockGet.Unlock()
+	return mock.GetFunc(ctx, userID, id)
+}
+
+// GetCalls gets all the calls that were made to Get.
+// Check the length with:
+//
+//	len(mockedSessionRepository.GetCalls())
+func (mock *SessionRepositoryMock) GetCalls() []struct {
+	Ctx    context.Context
+	UserID int64
+	ID     int64
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID int64
+		ID     int64
+	}
+	mock.lockGet.RLock()
+	calls = mock.calls.Get
+	mock.lockGet.RUnlock()
+	return calls
+}
+
+// List calls ListFunc.
+func (mock *SessionRepositoryMock) List(ctx context.Context, userID int64, groupID int64, limit int, offset int) ([]models.StudySessionResponse, error) {
+	if mock.ListFunc == nil {
+		panic("SessionRepositoryMock.ListFunc: method is nil but SessionRepository.List was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+		Limit   int
+		Offset  int
+	}{
+		Ctx:     ctx,
+		UserID:  userID,
+		GroupID: groupID,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, userID, groupID, limit, offset)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedSessionRepository.ListCalls())
+func (mock *SessionRepositoryMock) ListCalls() []struct {
+	Ctx     context.Context
+	UserID  int64
+	GroupID int64
+	Limit   int
+	Offset  int
+} {
+	var calls []struct {
+		Ctx     context.Context
+		UserID  int64
+		GroupID int64
+		Limit   int
+		Offset  int
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
+// ListBefore calls ListBeforeFunc.
+func (mock *SessionRepositoryMock) ListBefore(ctx context.Context, userID int64, groupID int64, beforeID int64, limit int) ([]models.StudySessionResponse, error) {
+	if mock.ListBeforeFunc == nil {
+		panic("SessionRepositoryMock.ListBeforeFunc: method is nil but SessionRepository.ListBefore was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		UserID   int64
+		GroupID  int64
+		BeforeID int64
+		Limit    int
+	}{
+		Ctx:      ctx,
+		UserID:   userID,
+		GroupID:  groupID,
+		BeforeID: beforeID,
+		Limit:    limit,
+	}
+	mock.lockListBefore.Lock()
+	mock.calls.ListBefore = append(mock.calls.ListBefore, callInfo)
+	mock.lockListBefore.Unlock()
+	return mock.ListBeforeFunc(ctx, userID, groupID, beforeID, limit)
+}
+
+// ListBeforeCalls gets all the calls that were made to ListBefore.
+// Check the length with:
+//
+//	len(mockedSessionRepository.ListBeforeCalls())
+func (mock *SessionRepositoryMock) ListBeforeCalls() []struct {
+	Ctx      context.Context
+	UserID   int64
+	GroupID  int64
+	BeforeID int64
+	Limit    int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		UserID   int64
+		GroupID  int64
+		BeforeID int64
+		Limit    int
+	}
+	mock.lockListBefore.RLock()
+	calls = mock.calls.ListBefore
+	mock.lockListBefore.RUnlock()
+	return calls
+}
+
+// ListWords calls ListWordsFunc.
+func (mock *SessionRepositoryMock) ListWords(ctx context.Context, sessionID int64) ([]models.WordResponse, error) {
+	if mock.ListWordsFunc == nil {
+		panic("SessionRepositoryMock.ListWordsFunc: method is nil but SessionRepository.ListWords was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		SessionID int64
+	}{
+		Ctx:       ctx,
+		SessionID: sessionID,
+	}
+	mock.lockListWords.Lock()
+	mock.calls.ListWords = append(mock.calls.ListWords, callInfo)
+	mock.lockListWords.Unlock()
+	return mock.ListWordsFunc(ctx, sessionID)
+}
+
+// ListWordsCalls gets all the calls that were made to ListWords.
+// Check the length with:
+//
+//	len(mockedSessionRepository.ListWordsCalls())
+func (mock *SessionRepositoryMock) ListWordsCalls() []struct {
+	Ctx       context.Context
+	SessionID int64
+} {
+	var calls []struct {
+		Ctx       context.Context
+		SessionID int64
+	}
+	mock.lockListWords.RLock()
+	calls = mock.calls.ListWords
+	mock.lockListWords.RUnlock()
+	return calls
+}
+
+// Owns calls OwnsFunc.
+func (mock *SessionRepositoryMock) Owns(ctx context.Context, q repository.Querier, userID int64, sessionID int64) (bool, error) {
+	if mock.OwnsFunc == nil {
+		panic("SessionRepositoryMock.OwnsFunc: method is nil but SessionRepository.Owns was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Q         repository.Querier
+		UserID    int64
+		SessionID int64
+	}{
+		Ctx:       ctx,
+		Q:         q,
+		UserID:    userID,
+		SessionID: sessionID,
+	}
+	mock.lockOwns.Lock()
+	mock.calls.Owns = append(mock.calls.Owns, callInfo)
+	mock.lockOwns.Unlock()
+	return mock.OwnsFunc(ctx, q, userID, sessionID)
+}
+
+// OwnsCalls gets all the calls that were made to Owns.
+// Check the length with:
+//
+//	len(mockedSessionRepository.OwnsCalls())
+func (mock *SessionRepositoryMock) OwnsCalls() []struct {
+	Ctx       context.Context
+	Q         repository.Querier
+	UserID    int64
+	SessionID int64
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Q         repository.Querier
+		UserID    int64
+		SessionID int64
+	}
+	mock.lockOwns.RLock()
+	calls = mock.calls.Owns
+	mock.lockOwns.RUnlock()
+	return calls
+}
+
+// SetWords calls SetWordsFunc.
+func (mock *SessionRepositoryMock) SetWords(ctx context.Context, q repository.Querier, sessionID int64, wordIDs []int64) error {
+	if mock.SetWordsFunc == nil {
+		panic("SessionRepositoryMock.SetWordsFunc: method is nil but SessionRepository.SetWords was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Q         repository.Querier
+		SessionID int64
+		WordIDs   []int64
+	}{
+		Ctx:       ctx,
+		Q:         q,
+		SessionID: sessionID,
+		WordIDs:   wordIDs,
+	}
+	mock.lockSetWords.Lock()
+	mock.calls.SetWords = append(mock.calls.SetWords, callInfo)
+	mock.lockSetWords.Unlock()
+	return mock.SetWordsFunc(ctx, q, sessionID, wordIDs)
+}
+
+// SetWordsCalls gets all the calls that were made to SetWords.
+// Check the length with:
+//
+//	len(mockedSessionRepository.SetWordsCalls())
+func (mock *SessionRepositoryMock) SetWordsCalls() []struct {
+	Ctx       context.Context
+	Q         repository.Querier
+	SessionID int64
+	WordIDs   []int64
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Q         repository.Querier
+		SessionID int64
+		WordIDs   []int64
+	}
+	mock.lockSetWords.RLock()
+	calls = mock.calls.SetWords
+	mock.lockSetWords.RUnlock()
+	return calls
+}
+
+// Ensure, that ReviewRepositoryMock does implement repository.ReviewRepository.
+// If this is not the case, add the missing methods to it.
+var _ repository.ReviewRepository = &ReviewRepositoryMock{}
+
+// ReviewRepositoryMock is a mock implementation of repository.ReviewRepository.
+//
+//	func TestSomethingThatUsesReviewRepository(t *testing.T) {
+//
+//		// make and configure a mocked repository.ReviewRepository
+//		mockedReviewRepository := &ReviewRepositoryMock{
+//			ListBySessionFunc: func(ctx context.Context, sessionID int64) ([]models.WordReviewItem, error) {
+//				panic("mock out the ListBySession method")
+//			},
+//			RecordFunc: func(ctx context.Context, q repository.Querier, userID int64, item models.WordReviewItem) error {
+//				panic("mock out the Record method")
+//			},
+//		}
+//
+//		// use mockedReviewRepository in code that requires repository.ReviewRepository
+//		// and then make assertions.
+//
+//	}
+type ReviewRepositoryMock struct {
+	// ListBySessionFunc mocks the ListBySession method.
+	ListBySessionFunc func(ctx context.Context, sessionID int64) ([]models.WordReviewItem, error)
+
+	// RecordFunc mocks the Record method.
+	RecordFunc func(ctx context.Context, q repository.Querier, userID int64, item models.WordReviewItem) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// ListBySession holds details about calls to the ListBySession method.
+		ListBySession []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SessionID is the sessionID argument value.
+			SessionID int64
+		}
+		// Record holds details about calls to the Record method.
+		Record []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q repository.Querier
+			// UserID is the userID argument value.
+			UserID int64
+			// Item is the item argument value.
+			Item models.WordReviewItem
+		}
+	}
+	lockListBySession sync.RWMutex
+	lockRecord        sync.RWMutex
+}
+
+// ListBySession calls ListBySessionFunc.
+func (mock *ReviewRepositoryMock) ListBySession(ctx context.Context, sessionID int64) ([]models.WordReviewItem, error) {
+	if mock.ListBySessionFunc == nil {
+		panic("ReviewRepositoryMock.ListBySessionFunc: method is nil but ReviewRepository.ListBySession was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		SessionID int64
+	}{
+		Ctx:       ctx,
+		SessionID: sessionID,
+	}
+	mock.lockListBySession.Lock()
+	mock.calls.ListBySession = append(mock.calls.ListBySession, callInfo)
+	mock.lockListBySession.Unlock()
+	return mock.ListBySessionFunc(ctx, sessionID)
+}
+
+// ListBySessionCalls gets all the calls that were made to ListBySession.
+// Check the length with:
+//
+//	len(mockedReviewRepository.ListBySessionCalls())
+func (mock *ReviewRepositoryMock) ListBySessionCalls() []struct {
+	Ctx       context.Context
+	SessionID int64
+} {
+	var calls []struct {
+		Ctx       context.Context
+		SessionID int64
+	}
+	mock.lockListBySession.RLock()
+	calls = mock.calls.ListBySession
+	mock.lockListBySession.RUnlock()
+	return calls
+}
+
+// Record calls RecordFunc.
+func (mock *ReviewRepositoryMock) Record(ctx context.Context, q repository.Querier, userID int64, item models.WordReviewItem) error {
+	if mock.RecordFunc == nil {
+		panic("ReviewRepositoryMock.RecordFunc: method is nil but ReviewRepository.Record was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Q      repository.Querier
+		UserID int64
+		Item   models.WordReviewItem
+	}{
+		Ctx:    ctx,
+		Q:      q,
+		UserID: userID,
+		Item:   item,
+	}
+	mock.lockRecord.Lock()
+	mock.calls.Record = append(mock.calls.Record, callInfo)
+	mock.lockRecord.Unlock()
+	return mock.RecordFunc(ctx, q, userID, item)
+}
+
+// RecordCalls gets all the calls that were made to Record.
+// Check the length with:
+//
+//	len(mockedReviewRepository.RecordCalls())
+func (mock *ReviewRepositoryMock) RecordCalls() []struct {
+	Ctx    context.Context
+	Q      repository.Querier
+	UserID int64
+	Item   models.WordReviewItem
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Q      repository.Querier
+		UserID int64
+		Item   models.WordReviewItem
+	}
+	mock.lockRecord.RLock()
+	calls = mock.calls.Record
+	mock.lockRecord.RUnlock()
+	return calls
+}
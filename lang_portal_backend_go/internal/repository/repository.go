@@ -0,0 +1,116 @@
+// Package repository holds the SQL behind words, groups, study sessions and
+// reviews, so the service can be tested against mocks of it and store them
+// in SQLite or PostgreSQL. Lookups of rows that don't exist return
+// sql.ErrNoRows.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+)
+
+// Querier runs queries. It is satisfied by *models.DB and *models.Tx, so
+// methods that take one can be part of a caller's transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*models.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *models.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Orders words and groups can be listed in: by ID, or by script or name in
+// Urdu alphabetical order. Sorting in Urdu order uses the keys of the
+// word_sort_keys and group_sort_keys tables, which callers keep up to date;
+// rows without a key are ordered by ID among themselves.
+const (
+	SortID   = "id"
+	SortUrdu = "urdu"
+)
+
+// WordRepository stores words. Review counts are those of the given user.
+// Lists and counts are of the words of one language, or of every language if
+// language is "". Reads take the words the user viewerID can see: those in
+// no group and those in a group the user can see, as GroupRepository.List
+// takes them. A viewerID of 0, for admins, takes every word.
+type WordRepository interface {
+	List(ctx context.Context, userID, viewerID int64, language, sort string, limit, offset int) ([]models.WordResponse, error)
+	// ListAfter returns the words after the given ID in ID order, from the
+	// first word if afterID is 0
+	ListAfter(ctx context.Context, userID, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error)
+	Count(ctx context.Context, viewerID int64, language string) (int, error)
+	Get(ctx context.Context, userID, viewerID, id int64) (*models.WordResponse, error)
+	// Create adds a word and sets its ID
+	Create(ctx context.Context, q Querier, word *models.Word) error
+}
+
+// GroupRepository stores groups and the words in them. Lists and counts
+// are of the groups of one language, or of every language if language is "".
+type GroupRepository interface {
+	// List and Count take the groups the user viewerID can see: those
+	// nobody owns and those the user has a role on. A viewerID of 0, for
+	// admins, takes every group.
+	List(ctx context.Context, viewerID int64, language, sort string, limit, offset int) ([]models.GroupResponse, error)
+	Count(ctx context.Context, viewerID int64, language string) (int, error)
+	Get(ctx context.Context, id int64) (*models.GroupResponse, error)
+	// ListWords returns the words of a group with the given user's review
+	// counts
+	ListWords(ctx context.Context, userID, groupID int64, sort string, limit, offset int) ([]models.WordResponse, error)
+	CountWords(ctx context.Context, groupID int64) (int, error)
+	// AddWords adds words to a group and updates its word count
+	AddWords(ctx context.Context, q Querier, groupID int64, wordIDs []int64) error
+}
+
+// SessionRepository stores users' study sessions and the words in them
+type SessionRepository interface {
+	// List returns a user's sessions, newest first, of one group or of all
+	// groups if groupID is 0
+	List(ctx context.Context, userID, groupID int64, limit, offset int) ([]models.StudySessionResponse, error)
+	// ListBefore returns the sessions List orders after the given session,
+	// from the newest if beforeID is 0
+	ListBefore(ctx context.Context, userID, groupID, beforeID int64, limit int) ([]models.StudySessionResponse, error)
+	Count(ctx context.Context, userID, groupID int64) (int, error)
+	Get(ctx context.Context, userID, id int64) (*models.StudySessionResponse, error)
+	// Owns reports whether a session exists and belongs to a user
+	Owns(ctx context.Context, q Querier, userID, sessionID int64) (bool, error)
+	ListWords(ctx context.Context, sessionID int64) ([]models.WordResponse, error)
+	// SetWords replaces the words of a session
+	SetWords(ctx context.Context, q Querier, sessionID int64, wordIDs []int64) error
+}
+
+// ReviewRepository stores the answers given in study sessions
+type ReviewRepository interface {
+	ListBySession(ctx context.Context, sessionID int64) ([]models.WordReviewItem, error)
+	// Record saves a user's answer for a word of a session, replacing an
+	// earlier answer for the same word
+	Record(ctx context.Context, q Querier, userID int64, item models.WordReviewItem) error
+}
+
+// Repositories bundles the repositories the service uses
+type Repositories struct {
+	Words    WordRepository
+	Groups   GroupRepository
+	Sessions SessionRepository
+	Reviews  ReviewRepository
+}
+
+// NewSQLite returns the SQLite implementations of the repositories
+func NewSQLite(db Querier) Repositories {
+	return New(db, dialect.SQLite)
+}
+
+// NewPostgres returns the PostgreSQL implementations of the repositories
+func NewPostgres(db Querier) Repositories {
+	return New(db, dialect.Postgres)
+}
+
+// New returns the repositories for a database of the given dialect
+func New(db Querier, d dialect.Dialect) Repositories {
+	return Repositories{
+		Words:    &sqlWords{db: db, dialect: d},
+		Groups:   &sqlGroups{db: db, dialect: d},
+		Sessions: &sqlSessions{db: db, dialect: d},
+		Reviews:  &sqlReviews{db: db, dialect: d},
+	}
+}
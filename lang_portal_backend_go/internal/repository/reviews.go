@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+)
+
+type sqlReviews struct {
+	db      Querier
+	dialect dialect.Dialect
+}
+
+func (r *sqlReviews) ListBySession(ctx context.Context, sessionID int64) ([]models.WordReviewItem, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT wri.word_id, wri.correct, wri.near_miss, wri.created_at
+		FROM word_review_items wri
+		WHERE wri.study_session_id = ?
+	`), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.WordReviewItem
+	for rows.Next() {
+		item := models.WordReviewItem{StudySessionID: sessionID}
+		if err := rows.Scan(&item.WordID, &item.Correct, &item.NearMiss, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan word review item: %v", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *sqlReviews) Record(ctx context.Context, q Querier, userID int64, item models.WordReviewItem) error {
+	_, err := q.ExecContext(ctx, r.dialect.Rebind(`
+		INSERT INTO word_review_items (word_id, study_session_id, correct, near_miss, created_at, user_id)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+		ON CONFLICT(study_session_id, word_id) DO UPDATE SET
+		correct = excluded.correct,
+		near_miss = excluded.near_miss,
+		created_at = excluded.created_at
+	`), item.WordID, item.StudySessionID, item.Correct, item.NearMiss, userID)
+	if err != nil {
+		return fmt.Errorf("failed to review word: %v", err)
+	}
+	return nil
+}
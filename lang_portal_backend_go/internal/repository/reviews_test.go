@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"testing"
+)
+
+func TestRecordRepeatedReviews(t *testing.T) {
+	tests := []struct {
+		name    string
+		reviews []models.WordReviewItem
+		// want is the review kept of each word
+		want map[int64]models.WordReviewItem
+	}{
+		{
+			name:    "one review",
+			reviews: []models.WordReviewItem{{WordID: 1, Correct: true}},
+			want:    map[int64]models.WordReviewItem{1: {WordID: 1, Correct: true}},
+		},
+		{
+			name: "word reviewed again",
+			reviews: []models.WordReviewItem{
+				{WordID: 1, Correct: false},
+				{WordID: 1, Correct: true, NearMiss: true},
+			},
+			want: map[int64]models.WordReviewItem{1: {WordID: 1, Correct: true, NearMiss: true}},
+		},
+		{
+			name: "word reviewed three times",
+			reviews: []models.WordReviewItem{
+				{WordID: 1, Correct: true},
+				{WordID: 1, Correct: true},
+				{WordID: 1, Correct: false},
+			},
+			want: map[int64]models.WordReviewItem{1: {WordID: 1, Correct: false}},
+		},
+		{
+			name: "other words kept apart",
+			reviews: []models.WordReviewItem{
+				{WordID: 1, Correct: true},
+				{WordID: 2, Correct: false},
+				{WordID: 1, Correct: false},
+				{WordID: 2, Correct: true},
+			},
+			want: map[int64]models.WordReviewItem{
+				1: {WordID: 1, Correct: false},
+				2: {WordID: 2, Correct: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := models.NewTestDB()
+			if err != nil {
+				t.Fatalf("failed to create database: %v", err)
+			}
+			defer db.Close()
+			_, err = db.ExecContext(ctx, `
+				INSERT INTO study_sessions (id, group_id, created_at, study_activity_id, user_id)
+				VALUES (1, 1, CURRENT_TIMESTAMP, 1, 1)
+			`)
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+
+			reviews := NewSQLite(db).Reviews
+			for _, review := range tt.reviews {
+				review.StudySessionID = 1
+				if err := reviews.Record(ctx, db, 1, review); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			items, err := reviews.ListBySession(ctx, 1)
+			if err != nil {
+				t.Fatalf("ListBySession() error = %v", err)
+			}
+			if len(items) != len(tt.want) {
+				t.Fatalf("ListBySession() returned %d reviews, want %d", len(items), len(tt.want))
+			}
+			for _, item := range items {
+				want, ok := tt.want[item.WordID]
+				if !ok {
+					t.Errorf("unexpected review of word %d", item.WordID)
+					continue
+				}
+				if item.Correct != want.Correct || item.NearMiss != want.NearMiss {
+					t.Errorf("review of word %d: correct = %v, near_miss = %v; want %v, %v",
+						item.WordID, item.Correct, item.NearMiss, want.Correct, want.NearMiss)
+				}
+			}
+		})
+	}
+}
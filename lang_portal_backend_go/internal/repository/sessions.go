@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"strings"
+	"time"
+)
+
+type sqlSessions struct {
+	db      Querier
+	dialect dialect.Dialect
+}
+
+// endTime returns the expression for when a session ended, ten minutes
+// after it started, as RFC 3339
+func (r *sqlSessions) endTime() string {
+	if r.dialect == dialect.Postgres {
+		return `to_char(ss.created_at AT TIME ZONE 'UTC' + interval '10 minutes', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+	}
+	return `strftime('%Y-%m-%dT%H:%M:%SZ', datetime(ss.created_at, '+10 minutes'))`
+}
+
+// listQuery selects the sessions of a list matching where, newest first.
+// The ID breaks ties so keyset pages don't skip or repeat sessions started
+// at the same time.
+func (r *sqlSessions) listQuery(where string) string {
+	return `
+		SELECT ss.id, sa.name as activity_name, g.name as group_name,
+			   ss.created_at as start_time,
+			   ` + r.endTime() + ` as end_time,
+			   (SELECT COUNT(*) FROM word_review_items wri
+				WHERE wri.study_session_id = ss.id) as review_items_count
+		FROM study_sessions ss
+		LEFT JOIN study_activities sa ON ss.study_activity_id = sa.id
+		LEFT JOIN groups g ON ss.group_id = g.id
+		WHERE ` + where + `
+		ORDER BY ss.created_at DESC, ss.id DESC
+	`
+}
+
+func (r *sqlSessions) List(ctx context.Context, userID, groupID int64, limit, offset int) ([]models.StudySessionResponse, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(r.listQuery(`ss.user_id = ?1 AND (?2 = 0 OR ss.group_id = ?2)`)+`
+		LIMIT ?3 OFFSET ?4`), userID, groupID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+func (r *sqlSessions) ListBefore(ctx context.Context, userID, groupID, beforeID int64, limit int) ([]models.StudySessionResponse, error) {
+	// Row values compare the stored times, whatever format the driver
+	// wrote them in
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(r.listQuery(`ss.user_id = ?1 AND (?2 = 0 OR ss.group_id = ?2)
+			AND (?4 = 0 OR (ss.created_at, ss.id) < (SELECT created_at, id FROM study_sessions WHERE id = ?4))`)+`
+		LIMIT ?3`), userID, groupID, limit, beforeID)
+	if err != nil {
+		return nil, err
+	}
+	return scanSessions(rows)
+}
+
+// scanSessions reads the rows of a session list
+func scanSessions(rows *models.Rows) ([]models.StudySessionResponse, error) {
+	defer rows.Close()
+
+	var sessions []models.StudySessionResponse
+	for rows.Next() {
+		var session models.StudySessionResponse
+		var (
+			activityName sql.NullString
+			groupName    sql.NullString
+			startTime    sql.NullTime
+			endTimeStr   sql.NullString
+			reviewCount  sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&session.ID,
+			&activityName,
+			&groupName,
+			&startTime,
+			&endTimeStr,
+			&reviewCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if activityName.Valid {
+			session.ActivityName = activityName.String
+		}
+		if groupName.Valid {
+			session.GroupName = groupName.String
+		}
+		if startTime.Valid {
+			session.StartTime = startTime.Time.Format(time.RFC3339)
+		}
+		if endTimeStr.Valid {
+			session.EndTime = endTimeStr.String
+		}
+		if reviewCount.Valid {
+			session.ReviewItemsCount = int(reviewCount.Int64)
+		}
+
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *sqlSessions) Count(ctx context.Context, userID, groupID int64) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT COUNT(*)
+		FROM study_sessions ss
+		WHERE ss.user_id = ?1 AND (?2 = 0 OR ss.group_id = ?2)
+	`), userID, groupID).Scan(&total)
+	return total, err
+}
+
+func (r *sqlSessions) Get(ctx context.Context, userID, id int64) (*models.StudySessionResponse, error) {
+	var session models.StudySessionResponse
+	var (
+		activityName sql.NullString
+		groupName    sql.NullString
+		startTime    sql.NullTime
+		endTimeStr   sql.NullString
+		reviewCount  sql.NullInt64
+		groupID      sql.NullInt64
+		parentID     sql.NullInt64
+	)
+
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT ss.id, ss.group_id, sa.name, g.name,
+			   ss.created_at,
+			   `+r.endTime()+`,
+			   (SELECT COUNT(*) FROM word_review_items wri WHERE wri.study_session_id = ss.id),
+			   ss.parent_session_id
+		FROM study_sessions ss
+		LEFT JOIN study_activities sa ON ss.study_activity_id = sa.id
+		LEFT JOIN groups g ON ss.group_id = g.id
+		WHERE ss.id = ? AND ss.user_id = ?
+	`), id, userID).Scan(
+		&session.ID,
+		&groupID,
+		&activityName,
+		&groupName,
+		&startTime,
+		&endTimeStr,
+		&reviewCount,
+		&parentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if groupID.Valid {
+		session.GroupID = groupID.Int64
+	}
+	if activityName.Valid {
+		session.ActivityName = activityName.String
+	}
+	if groupName.Valid {
+		session.GroupName = groupName.String
+	}
+	if startTime.Valid {
+		session.StartTime = startTime.Time.Format(time.RFC3339)
+	}
+	if endTimeStr.Valid {
+		session.EndTime = endTimeStr.String
+	}
+	if reviewCount.Valid {
+		session.ReviewItemsCount = int(reviewCount.Int64)
+	}
+	if parentID.Valid {
+		session.ParentSessionID = &parentID.Int64
+	}
+
+	return &session, nil
+}
+
+func (r *sqlSessions) Owns(ctx context.Context, q Querier, userID, sessionID int64) (bool, error) {
+	var owned bool
+	err := q.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT EXISTS (SELECT 1 FROM study_sessions WHERE id = ? AND user_id = ?)
+	`), sessionID, userID).Scan(&owned)
+	return owned, err
+}
+
+func (r *sqlSessions) ListWords(ctx context.Context, sessionID int64) ([]models.WordResponse, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM words w
+		INNER JOIN study_session_words ssw ON w.id = ssw.word_id
+		WHERE ssw.study_session_id = ?
+	`), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []models.WordResponse
+	for rows.Next() {
+		var word models.WordResponse
+		if err := rows.Scan(&word.ID, &word.Urdu, &word.Urdlish, &word.English); err != nil {
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// sessionWordsChunk is how many words SetWords inserts per statement. Each
+// word takes two parameters, keeping statements under SQLite's default limit
+// of 999.
+const sessionWordsChunk = 400
+
+func (r *sqlSessions) SetWords(ctx context.Context, q Querier, sessionID int64, wordIDs []int64) error {
+	// First replace any words already attached to this session
+	_, err := q.ExecContext(ctx, r.dialect.Rebind(`DELETE FROM study_session_words WHERE study_session_id = ?`), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to clean up existing study session words: %v", err)
+	}
+
+	// Add the words in chunks, one multi-row insert each. Full chunks share
+	// a prepared statement.
+	var stmt *sql.Stmt
+	defer func() {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}()
+	for start := 0; start < len(wordIDs); start += sessionWordsChunk {
+		chunk := wordIDs[start:min(start+sessionWordsChunk, len(wordIDs))]
+		args := make([]interface{}, 0, 2*len(chunk))
+		for _, wordID := range chunk {
+			args = append(args, sessionID, wordID)
+		}
+
+		if len(chunk) < sessionWordsChunk {
+			_, err = q.ExecContext(ctx, r.insertWords(len(chunk)), args...)
+		} else {
+			if stmt == nil {
+				stmt, err = q.PrepareContext(ctx, r.insertWords(sessionWordsChunk))
+				if err != nil {
+					return fmt.Errorf("failed to prepare study session words: %v", err)
+				}
+			}
+			_, err = stmt.ExecContext(ctx, args...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add words to study session: %v", err)
+		}
+	}
+	return nil
+}
+
+// insertWords returns the statement adding n words to a session
+func (r *sqlSessions) insertWords(n int) string {
+	return r.dialect.Rebind(`INSERT INTO study_session_words (study_session_id, word_id) VALUES ` +
+		strings.TrimSuffix(strings.Repeat("(?, ?), ", n), ", "))
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+)
+
+// reviewCounts selects how often the user bound to ?1 answered the word w
+// correctly and wrongly
+const reviewCounts = `(SELECT COUNT(*) FROM word_review_items wri
+				WHERE wri.user_id = ?1 AND wri.word_id = w.id AND wri.correct) as correct_count,
+			   (SELECT COUNT(*) FROM word_review_items wri
+				WHERE wri.user_id = ?1 AND wri.word_id = w.id AND NOT wri.correct) as wrong_count`
+
+// renderingColumns selects the rendering hints of the word w, NULL when it
+// has none, from the table renderingJoin joins
+const (
+	renderingColumns = `wr.font_style, wr.diacritized, wr.ligature_spellings`
+	renderingJoin    = `LEFT JOIN word_renderings wr ON wr.word_id = w.id`
+)
+
+// wordRendering holds the rendering hints of a word as scanned
+type wordRendering struct {
+	fontStyle, diacritized, spellings sql.NullString
+}
+
+// scanned adds where to scan renderingColumns into to dest
+func (r *wordRendering) scanned(dest ...interface{}) []interface{} {
+	return append(dest, &r.fontStyle, &r.diacritized, &r.spellings)
+}
+
+// rendering returns the hints scanned, nil if the word has none
+func (r *wordRendering) rendering() (*models.WordRendering, error) {
+	if !r.fontStyle.Valid {
+		return nil, nil
+	}
+	rendering := &models.WordRendering{FontStyle: r.fontStyle.String, Diacritized: r.diacritized.String}
+	if err := json.Unmarshal([]byte(r.spellings.String), &rendering.LigatureSpellings); err != nil {
+		return nil, fmt.Errorf("failed to decode ligature spellings: %v", err)
+	}
+	return rendering, nil
+}
+
+// wordOrder returns the join and ORDER BY terms listing the words w in the
+// given order
+func wordOrder(sort string) (join, orderBy string) {
+	if sort == SortUrdu {
+		return `LEFT JOIN word_sort_keys sk ON sk.word_id = w.id`, `sk.sort_key, w.id`
+	}
+	return ``, `w.id`
+}
+
+// VisibleWord returns the condition of the words w the user bound to the
+// placeholder viewer can see: those in no group and those in a group the
+// user can see, or every word if the user is 0
+func VisibleWord(viewer string) string {
+	// The owned groups the user has no role on don't depend on the word, so
+	// SQLite builds their list once instead of joining groups for every word
+	hiddenGroups := `SELECT gp.group_id FROM group_permissions gp
+		WHERE gp.role = 'owner' AND NOT EXISTS (
+			SELECT 1 FROM group_permissions mine WHERE mine.group_id = gp.group_id AND mine.user_id = ` + viewer + `)`
+	return `(` + viewer + ` = 0
+	OR NOT EXISTS (SELECT 1 FROM words_groups wg WHERE wg.word_id = w.id AND wg.group_id IN (` + hiddenGroups + `))
+	OR EXISTS (SELECT 1 FROM words_groups wg WHERE wg.word_id = w.id AND wg.group_id NOT IN (` + hiddenGroups + `)))`
+}
+
+type sqlWords struct {
+	db      Querier
+	dialect dialect.Dialect
+}
+
+func (r *sqlWords) List(ctx context.Context, userID, viewerID int64, language, sort string, limit, offset int) ([]models.WordResponse, error) {
+	// Counting per word of the page uses idx_word_review_items_user_word
+	// instead of aggregating every review of the user
+	join, orderBy := wordOrder(sort)
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT w.id, w.script, w.transliteration, w.english, w.language,
+			   `+reviewCounts+`, `+renderingColumns+`
+		FROM words w `+renderingJoin+` `+join+`
+		WHERE (?2 = '' OR w.language = ?2) AND `+VisibleWord("?5")+`
+		ORDER BY `+orderBy+`
+		LIMIT ?3 OFFSET ?4
+	`), userID, language, limit, offset, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanWords(rows)
+}
+
+func (r *sqlWords) ListAfter(ctx context.Context, userID, viewerID int64, language string, afterID int64, limit int) ([]models.WordResponse, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.Rebind(`
+		SELECT w.id, w.script, w.transliteration, w.english, w.language,
+			   `+reviewCounts+`, `+renderingColumns+`
+		FROM words w `+renderingJoin+`
+		WHERE w.id > ?3 AND (?2 = '' OR w.language = ?2) AND `+VisibleWord("?5")+`
+		ORDER BY w.id
+		LIMIT ?4
+	`), userID, language, afterID, limit, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanWords(rows)
+}
+
+// scanWords reads the rows of a word list with review counts
+func scanWords(rows *models.Rows) ([]models.WordResponse, error) {
+	defer rows.Close()
+
+	var words []models.WordResponse
+	for rows.Next() {
+		var (
+			word      models.WordResponse
+			rendering wordRendering
+		)
+		if err := rows.Scan(rendering.scanned(&word.ID, &word.Urdu, &word.Urdlish, &word.English, &word.Language,
+			&word.CorrectCount, &word.WrongCount)...); err != nil {
+			return nil, err
+		}
+		var err error
+		if word.Rendering, err = rendering.rendering(); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+func (r *sqlWords) Count(ctx context.Context, viewerID int64, language string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT COUNT(*) FROM words w WHERE (?1 = '' OR w.language = ?1) AND `+VisibleWord("?2")+`
+	`), language, viewerID).Scan(&total)
+	return total, err
+}
+
+func (r *sqlWords) Get(ctx context.Context, userID, viewerID, id int64) (*models.WordResponse, error) {
+	var (
+		word      models.WordResponse
+		rendering wordRendering
+	)
+	err := r.db.QueryRowContext(ctx, r.dialect.Rebind(`
+		SELECT w.id, w.script, w.transliteration, w.english, w.language,
+			   `+reviewCounts+`, `+renderingColumns+`
+		FROM words w `+renderingJoin+`
+		WHERE w.id = ?2 AND `+VisibleWord("?3")+`
+	`), userID, id, viewerID).Scan(rendering.scanned(&word.ID, &word.Urdu, &word.Urdlish, &word.English, &word.Language,
+		&word.CorrectCount, &word.WrongCount)...)
+	if err != nil {
+		return nil, err
+	}
+	if word.Rendering, err = rendering.rendering(); err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+func (r *sqlWords) Create(ctx context.Context, q Querier, word *models.Word) error {
+	var parts interface{}
+	if word.Parts != "" {
+		parts = word.Parts
+	}
+
+	// RETURNING works on both SQLite and PostgreSQL, whose driver has no
+	// LastInsertId
+	err := q.QueryRowContext(ctx, r.dialect.Rebind(`
+		INSERT INTO words (script, transliteration, english, parts, language)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`), word.Urdu, word.Urdlish, word.English, parts, word.Language).Scan(&word.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create word: %v", err)
+	}
+	return nil
+}
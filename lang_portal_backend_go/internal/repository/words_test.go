@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"lang_portal/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestListVisibleWords(t *testing.T) {
+	ctx := context.Background()
+	db, err := models.NewTestDB()
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	// Group 2 is owned by user 2 and seen by user 4. Word 4 is only in
+	// group 2, word 5 is in no group and word 2 is in groups 1 and 2.
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO users (id, username) VALUES (2, 'owner'), (3, 'stranger'), (4, 'viewer');
+		INSERT INTO group_permissions (group_id, user_id, role) VALUES (2, 2, 'owner'), (2, 4, 'viewer');
+		INSERT INTO words (id, script, transliteration, english) VALUES
+		(4, 'پانی', 'paani', 'water'),
+		(5, 'کتاب', 'kitaab', 'book');
+		INSERT INTO words_groups (word_id, group_id) VALUES (4, 2);
+	`)
+	if err != nil {
+		t.Fatalf("failed to set up permissions: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		viewerID int64
+		want     []int64
+		// wantWord4 is whether Get finds the private word 4
+		wantWord4 bool
+	}{
+		{name: "admin", viewerID: 0, want: []int64{1, 2, 3, 4, 5}, wantWord4: true},
+		{name: "owner", viewerID: 2, want: []int64{1, 2, 3, 4, 5}, wantWord4: true},
+		{name: "viewer", viewerID: 4, want: []int64{1, 2, 3, 4, 5}, wantWord4: true},
+		{name: "user without a role", viewerID: 3, want: []int64{1, 2, 3, 5}},
+	}
+
+	words := NewSQLite(db).Words
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := words.List(ctx, tt.viewerID, tt.viewerID, "", SortID, 100, 0)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var got []int64
+			for _, word := range list {
+				got = append(got, word.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = words %v, want %v", got, tt.want)
+			}
+
+			list, err = words.ListAfter(ctx, tt.viewerID, tt.viewerID, "", 0, 100)
+			if err != nil {
+				t.Fatalf("ListAfter() error = %v", err)
+			}
+			got = nil
+			for _, word := range list {
+				got = append(got, word.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListAfter() = words %v, want %v", got, tt.want)
+			}
+
+			total, err := words.Count(ctx, tt.viewerID, "")
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if total != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", total, len(tt.want))
+			}
+
+			_, err = words.Get(ctx, tt.viewerID, tt.viewerID, 4)
+			switch {
+			case tt.wantWord4 && err != nil:
+				t.Errorf("Get(4) error = %v, want the word", err)
+			case !tt.wantWord4 && err != sql.ErrNoRows:
+				t.Errorf("Get(4) error = %v, want %v", err, sql.ErrNoRows)
+			}
+		})
+	}
+}
+
+// Size of the database the benchmarks generate
+const (
+	benchWords             = 100000
+	benchSessions          = 10000
+	benchReviewsPerSession = 20
+)
+
+// newBenchDB returns an in-memory database with benchWords words spread
+// over groups 1 to 3, group 2 owned by user 2, and benchSessions sessions
+// of user 1 with benchReviewsPerSession reviews each
+func newBenchDB(b *testing.B) *models.DB {
+	b.Helper()
+	db, err := models.NewTestDB()
+	if err != nil {
+		b.Fatalf("failed to create database: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	for _, query := range []string{
+		`WITH RECURSIVE n(i) AS (SELECT 4 UNION ALL SELECT i + 1 FROM n WHERE i < ?1)
+		INSERT INTO words (id, script, transliteration, english) SELECT i, 'script ' || i, 'translit ' || i, 'english ' || i FROM n`,
+		`INSERT INTO words_groups (word_id, group_id) SELECT id, 1 + id % 3 FROM words WHERE id > 3`,
+		`INSERT INTO users (id, username) VALUES (2, 'owner')`,
+		`INSERT INTO group_permissions (group_id, user_id, role) VALUES (2, 2, 'owner')`,
+		`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?2)
+		INSERT INTO study_sessions (group_id, created_at, study_activity_id, user_id)
+		SELECT 1 + i % 3, datetime('now', '-' || i || ' minutes'), 1, 1 FROM n`,
+		`WITH RECURSIVE k(j) AS (SELECT 1 UNION ALL SELECT j + 1 FROM k WHERE j < ?3)
+		INSERT OR IGNORE INTO word_review_items (word_id, study_session_id, correct, near_miss, created_at, user_id)
+		SELECT 1 + abs(random()) % ?1, ss.id, abs(random()) % 10 < 7, 0, ss.created_at, ss.user_id
+		FROM study_sessions ss, k`,
+		`ANALYZE`,
+	} {
+		if _, err := db.Exec(query, benchWords, benchSessions, benchReviewsPerSession); err != nil {
+			b.Fatalf("failed to generate data: %v", err)
+		}
+	}
+	return db
+}
+
+func BenchmarkListWords(b *testing.B) {
+	ctx := context.Background()
+	words := NewSQLite(newBenchDB(b)).Words
+	benchmarks := []struct {
+		name string
+		run  func(viewerID int64) error
+	}{
+		{name: "first page", run: func(viewerID int64) error {
+			_, err := words.List(ctx, 1, viewerID, "", SortID, 100, 0)
+			return err
+		}},
+		{name: "middle page", run: func(viewerID int64) error {
+			_, err := words.List(ctx, 1, viewerID, "", SortID, 100, benchWords/2)
+			return err
+		}},
+		{name: "after cursor", run: func(viewerID int64) error {
+			_, err := words.ListAfter(ctx, 1, viewerID, "", benchWords/2, 100)
+			return err
+		}},
+		{name: "count", run: func(viewerID int64) error {
+			_, err := words.Count(ctx, viewerID, "")
+			return err
+		}},
+	}
+
+	// Admins see every word; learners only those of groups they can see
+	for _, viewer := range []struct {
+		name string
+		id   int64
+	}{{"admin", 0}, {"learner", 3}} {
+		for _, bm := range benchmarks {
+			b.Run(viewer.name+"/"+bm.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if err := bm.run(viewer.id); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package selection picks which words a quiz asks, favouring the words a
+// learner most needs to practise.
+package selection
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Strategy decides how strongly each word is favoured
+type Strategy string
+
+const (
+	// Random gives every word the same chance
+	Random Strategy = "random"
+	// Weak favours words with low accuracy
+	Weak Strategy = "weak"
+	// Stale favours words that haven't been reviewed for a long time
+	Stale Strategy = "stale"
+	// New favours words that have never been studied
+	New Strategy = "new"
+	// Balanced combines weak, stale and new
+	Balanced Strategy = "balanced"
+)
+
+// maxStaleDays caps how much time since the last review can add to a weight
+const maxStaleDays = 30
+
+// Candidate is a word that can be picked, with its study record
+type Candidate struct {
+	ID             int64
+	CorrectCount   int
+	WrongCount     int
+	LastReviewedAt *time.Time
+}
+
+// ParseStrategy returns the strategy with the given name; an empty name is
+// Balanced
+func ParseStrategy(name string) (Strategy, error) {
+	switch s := Strategy(name); s {
+	case "":
+		return Balanced, nil
+	case Random, Weak, Stale, New, Balanced:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown selection strategy %q", name)
+}
+
+// Weight returns how strongly a candidate is favoured. Every weight is at
+// least 1, so no word is ever excluded.
+func Weight(strategy Strategy, c Candidate, now time.Time) float64 {
+	studied := c.CorrectCount+c.WrongCount > 0
+
+	weak := 1.0
+	if studied {
+		accuracy := float64(c.CorrectCount) / float64(c.CorrectCount+c.WrongCount)
+		weak = 1 + 4*(1-accuracy)
+	}
+
+	stale := 1 + maxStaleDays/3.0
+	if c.LastReviewedAt != nil {
+		days := math.Min(now.Sub(*c.LastReviewedAt).Hours()/24, maxStaleDays)
+		stale = 1 + math.Max(days, 0)/3
+	}
+
+	fresh := 1.0
+	if !studied {
+		fresh = 10
+	}
+
+	switch strategy {
+	case Weak:
+		return weak
+	case Stale:
+		return stale
+	case New:
+		return fresh
+	case Balanced:
+		return (weak + stale + fresh) / 3
+	default:
+		return 1
+	}
+}
+
+// Pick draws up to n candidate IDs without replacement, each with a chance
+// proportional to its weight
+func Pick(candidates []Candidate, n int, strategy Strategy, now time.Time, rng *rand.Rand) []int64 {
+	// Weighted sampling: the n largest keys u^(1/w) form the sample
+	type keyed struct {
+		id  int64
+		key float64
+	}
+	keys := make([]keyed, len(candidates))
+	for i, c := range candidates {
+		u := rng.Float64()
+		keys[i] = keyed{id: c.ID, key: math.Pow(u, 1/Weight(strategy, c, now))}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	if n > len(keys) {
+		n = len(keys)
+	}
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = keys[i].id
+	}
+	return ids
+}
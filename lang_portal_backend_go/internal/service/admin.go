@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// User roles. Admins can see the admin dashboard and change roles.
+const (
+	RoleLearner = "learner"
+	RoleAdmin   = "admin"
+)
+
+// maxServerErrors is how many of the latest server errors are kept for the
+// admin dashboard
+const maxServerErrors = 50
+
+// serverErrors keeps the latest requests answered with a server error, in
+// memory, so they are lost when the server restarts
+type serverErrors struct {
+	mu      sync.Mutex
+	entries []models.ServerError
+}
+
+func (e *serverErrors) add(entry models.ServerError) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.entries = append(e.entries, entry)
+	if len(e.entries) > maxServerErrors {
+		e.entries = e.entries[len(e.entries)-maxServerErrors:]
+	}
+}
+
+// recent returns the errors kept, newest first
+func (e *serverErrors) recent() []models.ServerError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	recent := make([]models.ServerError, len(e.entries))
+	for i, entry := range e.entries {
+		recent[len(recent)-1-i] = entry
+	}
+	return recent
+}
+
+// RecordServerError keeps a request answered with a server error for the
+// admin dashboard
+func (s *Service) RecordServerError(entry models.ServerError) {
+	s.serverErrors.add(entry)
+}
+
+// CheckAdmin returns a forbidden error unless the service's user is an admin
+func (s *Service) CheckAdmin(ctx context.Context) error {
+	admin, err := s.isAdmin(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return forbidden("admin role required")
+	}
+	return nil
+}
+
+// SetUserRole changes the role of a user. The last admin can't be made a
+// learner, so there is always one who can change roles.
+func (s *Service) SetUserRole(ctx context.Context, userID int64, role string) (*models.UserRole, error) {
+	if role != RoleLearner && role != RoleAdmin {
+		return nil, invalid("invalid role %q: use %s or %s", role, RoleLearner, RoleAdmin)
+	}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var current string
+		err := tx.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, userID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return notFound("user not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user role: %v", err)
+		}
+		if current == RoleAdmin && role != RoleAdmin {
+			var admins int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = ?`, RoleAdmin).Scan(&admins); err != nil {
+				return fmt.Errorf("failed to count admins: %v", err)
+			}
+			if admins == 1 {
+				return conflict("can't remove the last admin")
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET role = ? WHERE id = ?`, role, userID); err != nil {
+			return fmt.Errorf("failed to set user role: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserRole{UserID: userID, Role: role}, nil
+}
+
+// AdminDashboard returns the size of the database and the rows of its
+// tables, the seed packs applied, the background jobs waiting and the
+// latest server errors. Every table is counted, so it reads the whole
+// database.
+func (s *Service) AdminDashboard(ctx context.Context) (*models.AdminDashboard, error) {
+	dashboard := &models.AdminDashboard{GeneratedAt: time.Now().UTC()}
+	var err error
+	if dashboard.Database, err = s.databaseSummary(ctx); err != nil {
+		return nil, err
+	}
+	if dashboard.Tables, err = s.tableRows(ctx); err != nil {
+		return nil, err
+	}
+	if dashboard.SeedVersions, err = s.seedVersions(ctx); err != nil {
+		return nil, err
+	}
+	if dashboard.Jobs, err = s.jobQueue(ctx); err != nil {
+		return nil, err
+	}
+	dashboard.RecentErrors = s.serverErrors.recent()
+	return dashboard, nil
+}
+
+// databaseSummary returns the dialect, size and schema version of the
+// database
+func (s *Service) databaseSummary(ctx context.Context) (models.DatabaseSummary, error) {
+	summary := models.DatabaseSummary{Dialect: string(s.dialect)}
+	query := `SELECT p.page_size * c.page_count FROM pragma_page_size AS p, pragma_page_count AS c`
+	if s.dialect == dialect.Postgres {
+		query = `SELECT pg_database_size(current_database())`
+	}
+	if err := s.db.QueryRowContext(ctx, query).Scan(&summary.SizeBytes); err != nil {
+		return summary, fmt.Errorf("failed to get database size: %v", err)
+	}
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&summary.SchemaVersion)
+	if err != nil {
+		return summary, fmt.Errorf("failed to get schema version: %v", err)
+	}
+	return summary, nil
+}
+
+// tableRows counts the rows of each table of the database
+func (s *Service) tableRows(ctx context.Context) ([]models.TableRows, error) {
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	if s.dialect == dialect.Postgres {
+		query = `
+			SELECT table_name FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+			ORDER BY table_name`
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %v", err)
+	}
+	defer rows.Close()
+	tables := []models.TableRows{}
+	for rows.Next() {
+		var table models.TableRows
+		if err := rows.Scan(&table.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %v", err)
+	}
+	rows.Close()
+
+	for i, table := range tables {
+		name := `"` + strings.ReplaceAll(table.Name, `"`, `""`) + `"`
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+name).Scan(&tables[i].Rows); err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %v", table.Name, err)
+		}
+	}
+	return tables, nil
+}
+
+// seedVersions returns the versions of the packs applied, newest first
+func (s *Service) seedVersions(ctx context.Context) ([]models.SeedVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT source, name, version, applied_at FROM seed_versions ORDER BY applied_at DESC, source, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seed versions: %v", err)
+	}
+	defer rows.Close()
+	versions := []models.SeedVersion{}
+	for rows.Next() {
+		var version models.SeedVersion
+		if err := rows.Scan(&version.Source, &version.Name, &version.Version, &version.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan seed version: %v", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating seed versions: %v", err)
+	}
+	return versions, nil
+}
+
+// jobQueue counts the background jobs queued, running and failed
+func (s *Service) jobQueue(ctx context.Context) (models.JobQueue, error) {
+	queue := models.JobQueue{QueuedByKind: map[string]int{}}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT status, kind, COUNT(*) FROM jobs WHERE status IN (?, ?, ?) GROUP BY status, kind
+	`, models.JobQueued, models.JobRunning, models.JobFailed)
+	if err != nil {
+		return queue, fmt.Errorf("failed to count jobs: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			status, kind string
+			count        int
+		)
+		if err := rows.Scan(&status, &kind, &count); err != nil {
+			return queue, fmt.Errorf("failed to scan job count: %v", err)
+		}
+		switch status {
+		case models.JobQueued:
+			queue.Queued += count
+			queue.QueuedByKind[kind] = count
+		case models.JobRunning:
+			queue.Running += count
+		case models.JobFailed:
+			queue.Failed += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return queue, fmt.Errorf("error iterating job counts: %v", err)
+	}
+	rows.Close()
+
+	var oldest time.Time
+	err = s.db.QueryRowContext(ctx, `
+		SELECT created_at FROM jobs WHERE status = ? ORDER BY created_at LIMIT 1
+	`, models.JobQueued).Scan(&oldest)
+	if err != nil && err != sql.ErrNoRows {
+		return queue, fmt.Errorf("failed to get oldest queued job: %v", err)
+	}
+	if err == nil {
+		queue.OldestQueuedAt = &oldest
+	}
+	return queue, nil
+}
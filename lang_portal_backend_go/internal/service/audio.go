@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/tts"
+	"regexp"
+)
+
+// AudioURLPrefix is the path synthesized audio files are served under. It
+// redirects to a signed URL of the file in the media storage, so words'
+// audio URLs stay the same whichever storage is used.
+const AudioURLPrefix = "/audio/"
+
+// audioKeyPrefix is where synthesized audio is kept in the media storage
+const audioKeyPrefix = "audio/"
+
+// audioContentType is the content type of synthesized audio
+const audioContentType = "audio/mpeg"
+
+// audioNamePattern is what the names of synthesized audio files look like
+var audioNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.mp3$`)
+
+// ValidAudioName reports whether name could be a synthesized audio file
+func ValidAudioName(name string) bool {
+	return audioNamePattern.MatchString(name)
+}
+
+// AudioFileURL returns a signed URL of the synthesized audio file name in
+// the media storage
+func (s *Service) AudioFileURL(ctx context.Context, name string) (string, error) {
+	if !ValidAudioName(name) {
+		return "", notFound("audio not found")
+	}
+	key := audioKeyPrefix + name
+	exists, err := s.media.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check audio: %v", err)
+	}
+	if !exists {
+		return "", notFound("audio not found")
+	}
+	url, err := s.media.SignedURL(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign audio URL: %v", err)
+	}
+	return url, nil
+}
+
+// ttsProvider returns the speech synthesizer configured by the environment
+func ttsProvider() (tts.Provider, error) {
+	provider, err := tts.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid text-to-speech settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no text-to-speech provider is configured, set %s", tts.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// audioName names the file of text spoken by provider. Files are named
+// after what they contain, so speech is synthesized once per text and voice.
+func audioName(provider tts.Provider, text string) string {
+	sum := sha256.Sum256([]byte(provider.Name() + "\x00" + text))
+	return hex.EncodeToString(sum[:16]) + ".mp3"
+}
+
+// GenerateWordAudio gives a word synthesized speech of its Urdu and links it
+// as the word's audio. A word that already has audio keeps it unless force
+// is set.
+func (s *Service) GenerateWordAudio(ctx context.Context, wordID int64, force bool) (*models.WordAudio, error) {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return nil, err
+	}
+	provider, err := ttsProvider()
+	if err != nil {
+		return nil, err
+	}
+	return s.generateWordAudio(ctx, provider, wordID, force)
+}
+
+func (s *Service) generateWordAudio(ctx context.Context, provider tts.Provider, wordID int64, force bool) (*models.WordAudio, error) {
+	var urdu string
+	err := s.db.QueryRowContext(ctx, `SELECT script FROM words WHERE id = ?`, wordID).Scan(&urdu)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word: %v", err)
+	}
+
+	if !force {
+		url, err := s.GetWordAudioURL(ctx, wordID)
+		if err != nil {
+			return nil, err
+		}
+		if url != "" {
+			return &models.WordAudio{WordID: wordID, AudioURL: url, Source: models.AudioExisting}, nil
+		}
+	}
+
+	name := audioName(provider, urdu)
+	source := models.AudioCached
+	exists, err := s.media.Exists(ctx, audioKeyPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check audio cache: %v", err)
+	}
+	if !exists {
+		audio, err := provider.Synthesize(ctx, urdu)
+		if err != nil {
+			return nil, fmt.Errorf("failed to synthesize audio: %v", err)
+		}
+		if err := s.media.Put(ctx, audioKeyPrefix+name, audio, audioContentType); err != nil {
+			return nil, fmt.Errorf("failed to store audio: %v", err)
+		}
+		source = models.AudioSynthesized
+	}
+
+	url := AudioURLPrefix + name
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO word_audio (word_id, url) VALUES (?, ?)
+		ON CONFLICT(word_id) DO UPDATE SET url = excluded.url
+	`, wordID, url); err != nil {
+		return nil, fmt.Errorf("failed to link word audio: %v", err)
+	}
+	return &models.WordAudio{WordID: wordID, AudioURL: url, Source: source}, nil
+}
+
+// EnqueueGroupAudio queues giving every word of a group synthesized speech
+func (s *Service) EnqueueGroupAudio(ctx context.Context, groupID int64, force bool) (*models.Job, error) {
+	if err := s.checkGroupEditor(ctx, s.db, groupID); err != nil {
+		return nil, err
+	}
+	if _, err := ttsProvider(); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobGroupAudio, groupAudioJob{GroupID: groupID, Force: force})
+}
+
+// GenerateGroupAudio gives every word of a group synthesized speech, as
+// GenerateWordAudio does. Words that fail are reported rather than failing
+// the rest, unless every word fails.
+func (s *Service) GenerateGroupAudio(ctx context.Context, groupID int64, force bool) (*models.GroupAudioResult, error) {
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	provider, err := ttsProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT word_id FROM words_groups WHERE group_id = ? ORDER BY word_id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group words: %v", err)
+	}
+	var wordIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan group word: %v", err)
+		}
+		wordIDs = append(wordIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group words: %v", err)
+	}
+
+	result := &models.GroupAudioResult{Words: len(wordIDs), Failed: []models.WordFailure{}}
+	var lastErr error
+	for _, id := range wordIDs {
+		audio, err := s.generateWordAudio(ctx, provider, id, force)
+		if err != nil {
+			lastErr = err
+			result.Failed = append(result.Failed, models.WordFailure{WordID: id, Error: err.Error()})
+			continue
+		}
+		switch audio.Source {
+		case models.AudioExisting:
+			result.Existing++
+		case models.AudioCached:
+			result.Cached++
+		default:
+			result.Synthesized++
+		}
+	}
+	if len(wordIDs) > 0 && len(result.Failed) == len(wordIDs) {
+		return nil, lastErr
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/db"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupDirEnv overrides the directory database backups are written to
+const BackupDirEnv = "LANG_PORTAL_BACKUP_DIR"
+
+// defaultBackupDir is where backups are written unless BackupDirEnv is set
+const defaultBackupDir = "backups"
+
+// backupTimeLayout names backups after when they were taken, so they sort
+// by name
+const backupTimeLayout = "20060102T150405.000Z"
+
+// backupDir returns the directory backups are written to
+func backupDir() string {
+	if dir := os.Getenv(BackupDirEnv); dir != "" {
+		return dir
+	}
+	return defaultBackupDir
+}
+
+// Backup writes a consistent copy of the database to the backup directory
+// while it stays in use. The reason, if any, is added to the backup's name.
+func (s *Service) Backup(ctx context.Context, reason string) (*models.Backup, error) {
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("backups are only supported on SQLite")
+	}
+	dir := backupDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	name := "backup-" + time.Now().UTC().Format(backupTimeLayout)
+	if reason != "" {
+		name += "-" + reason
+	}
+	name += ".db"
+	path := filepath.Join(dir, name)
+	if err := db.Backup(ctx, s.db.DB, path); err != nil {
+		return nil, fmt.Errorf("failed to back up database: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup: %v", err)
+	}
+	log.Printf("Backed up database to %s", path)
+	return &models.Backup{Name: name, Size: info.Size(), CreatedAt: info.ModTime().UTC()}, nil
+}
+
+// ListBackups returns the backups in the backup directory, newest first
+func (s *Service) ListBackups() ([]models.Backup, error) {
+	entries, err := os.ReadDir(backupDir())
+	if os.IsNotExist(err) {
+		return []models.Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+
+	backups := []models.Backup{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".db") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backup: %v", err)
+		}
+		backups = append(backups, models.Backup{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime().UTC()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name > backups[j].Name
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the database with a backup, after backing up the
+// database as it is. The restored database is migrated to the current
+// schema.
+func (s *Service) RestoreBackup(ctx context.Context, name string) (*models.Backup, error) {
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("backups are only supported on SQLite")
+	}
+	if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".db") {
+		return nil, notFound("backup not found")
+	}
+	path := filepath.Join(backupDir(), name)
+	if _, err := os.Stat(path); err != nil {
+		return nil, notFound("backup not found")
+	}
+
+	previous, err := s.Backup(ctx, "pre-restore")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Restore(ctx, path); err != nil {
+		return nil, fmt.Errorf("failed to restore backup: %v", err)
+	}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate restored database: %v", err)
+	}
+	log.Printf("Restored database from %s", path)
+	return previous, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lang_portal/internal/db/seeder"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxCatalogSize caps how much of a remote catalog is read
+const maxCatalogSize = 20 << 20
+
+// Environment variables holding the default starter catalog location, and
+// the other hosts catalogs can be downloaded from, separated by commas
+const (
+	CatalogURLEnv    = "LANG_PORTAL_CATALOG_URL"
+	CatalogSHA256Env = "LANG_PORTAL_CATALOG_SHA256"
+	CatalogHostsEnv  = "LANG_PORTAL_CATALOG_HOSTS"
+)
+
+var catalogClient = &http.Client{
+	Timeout: 30 * time.Second,
+	// Redirects are held to the same hosts as the catalog's URL
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return checkCatalogURL(req.URL.String())
+	},
+}
+
+// Bootstrap downloads a starter catalog, verifies its SHA-256 checksum and
+// installs it as a content pack. Empty arguments fall back to the
+// LANG_PORTAL_CATALOG_URL and LANG_PORTAL_CATALOG_SHA256 environment variables.
+func (s *Service) Bootstrap(ctx context.Context, url, checksum string) (*seeder.InstallResult, error) {
+	url, checksum, err := catalogSource(url, checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch catalog: %v", err)
+	}
+	resp, err := catalogClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch catalog: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch catalog: unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %v", err)
+	}
+	if len(data) > maxCatalogSize {
+		return nil, fmt.Errorf("catalog is larger than %d bytes", maxCatalogSize)
+	}
+
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(checksum)) {
+		return nil, ErrChecksumMismatch
+	}
+
+	var pack seeder.ContentPack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %v", err)
+	}
+
+	return s.seeder.InstallContentPack(ctx, &pack)
+}
+
+// catalogSource returns the URL and checksum of the catalog to bootstrap,
+// falling back to the environment for those not given
+func catalogSource(url, checksum string) (string, string, error) {
+	if url == "" {
+		url = os.Getenv(CatalogURLEnv)
+	}
+	if checksum == "" {
+		checksum = os.Getenv(CatalogSHA256Env)
+	}
+	if url == "" {
+		return "", "", invalid("no catalog url configured")
+	}
+	if checksum == "" {
+		return "", "", invalid("no catalog checksum configured")
+	}
+	if err := checkCatalogURL(url); err != nil {
+		return "", "", err
+	}
+	return url, checksum, nil
+}
+
+// checkCatalogURL returns a validation error unless catalogs can be
+// downloaded from the URL: the configured catalog URL, or an HTTPS URL of
+// its host or one of LANG_PORTAL_CATALOG_HOSTS. Requests can't make the
+// server fetch from other hosts, such as those of its internal network.
+func checkCatalogURL(raw string) error {
+	configured := os.Getenv(CatalogURLEnv)
+	if configured != "" && raw == configured {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return invalid("invalid catalog url")
+	}
+	if u.Scheme != "https" || u.User != nil {
+		return invalid("catalog url must be an https url without credentials")
+	}
+	hosts := strings.Split(os.Getenv(CatalogHostsEnv), ",")
+	if c, err := url.Parse(configured); err == nil && c.Hostname() != "" {
+		hosts = append(hosts, c.Hostname())
+	}
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host != "" && strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return invalid("catalog host %s is not allowed: add it to %s", u.Hostname(), CatalogHostsEnv)
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/bot"
+	"lang_portal/internal/models"
+	"log"
+	"math/big"
+	mathrand "math/rand"
+	"strings"
+	"time"
+)
+
+// chatQuizActivity is the study activity answers to quiz questions sent by
+// the bots are filed under
+const chatQuizActivity = "Chat Quiz"
+
+// NotificationDailyQuiz is the kind of notification of the daily quiz
+// question a bot sends
+const NotificationDailyQuiz = "daily_quiz"
+
+const (
+	// DefaultQuizHour is the hour (UTC) quiz questions are sent from unless
+	// the user picks another
+	DefaultQuizHour = 9
+	// botCodeTTL is how long the code sent to a chat being linked is valid
+	botCodeTTL = 15 * time.Minute
+	// maxBotCodeAttempts is how many wrong codes are entered before a chat
+	// must be linked again
+	maxBotCodeAttempts = 5
+	// chatQuizOptions is how many answers a quiz question offers
+	chatQuizOptions = 4
+)
+
+// Bot returns the configured bot of a platform, telegram or discord
+func (s *Service) Bot(platform string) (bot.Bot, error) {
+	if platform != bot.Telegram && platform != bot.Discord {
+		return nil, notFound("unknown platform %q", platform)
+	}
+	b, err := bot.FromEnv(platform)
+	if err != nil {
+		return nil, unsupported("invalid %s bot settings: %v", platform, err)
+	}
+	if b == nil {
+		return nil, unsupported("no %s bot is configured", platform)
+	}
+	return b, nil
+}
+
+// GetBotLink returns the chat the user is sent quiz questions in
+func (s *Service) GetBotLink(ctx context.Context) (*models.BotLink, error) {
+	var (
+		link       models.BotLink
+		expires    sql.NullTime
+		verifiedAt sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT platform, chat_id, quiz_hour, code_expires_at, verified_at, created_at
+		FROM bot_links WHERE user_id = ?
+	`, s.userID).Scan(&link.Platform, &link.ChatID, &link.QuizHour, &expires, &verifiedAt, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("no chat is linked")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat link: %v", err)
+	}
+	link.Verified = verifiedAt.Valid
+	if !link.Verified && expires.Valid {
+		link.CodeExpiresAt = &expires.Time
+	}
+	return &link, nil
+}
+
+// LinkBotChat links a chat of a platform to the user, to be sent a quiz
+// question each day from quizHour (UTC). The bot sends the chat a code,
+// which ConfirmBotLink takes to start the questions. Relinking the linked
+// chat only changes the hour.
+func (s *Service) LinkBotChat(ctx context.Context, platform, chatID string, quizHour int, now time.Time) (*models.BotLink, error) {
+	if quizHour < 0 || quizHour > 23 {
+		return nil, invalid("quiz_hour must be from 0 to 23")
+	}
+	b, err := s.Bot(platform)
+	if errors.Is(err, ErrNotFound) {
+		return nil, invalid("platform must be %s or %s", bot.Telegram, bot.Discord)
+	}
+	if err != nil {
+		return nil, err
+	}
+	chatID = strings.TrimSpace(chatID)
+	if !b.ValidChatID(chatID) {
+		return nil, invalid("invalid chat_id")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE bot_links SET quiz_hour = ?
+		WHERE user_id = ? AND platform = ? AND chat_id = ? AND verified_at IS NOT NULL
+	`, quizHour, s.userID, platform, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update chat link: %v", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %v", err)
+	} else if updated > 0 {
+		return s.GetBotLink(ctx)
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code: %v", err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	err = b.Send(ctx, chatID, bot.Message{
+		Text: fmt.Sprintf("Your Lang Portal code is %s. Enter it to get a quiz question on a word that is due here each day.", code),
+	})
+	if errors.Is(err, bot.ErrChat) {
+		return nil, invalid("the %s bot can't message chat %s; check the chat id and that the bot was started in the chat", platform, chatID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to message chat: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO bot_links (user_id, platform, chat_id, quiz_hour, code, code_expires_at, code_attempts, verified_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, NULL, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			platform = excluded.platform,
+			chat_id = excluded.chat_id,
+			quiz_hour = excluded.quiz_hour,
+			code = excluded.code,
+			code_expires_at = excluded.code_expires_at,
+			code_attempts = 0,
+			verified_at = NULL,
+			created_at = excluded.created_at
+	`, s.userID, platform, chatID, quizHour, code, now.UTC().Add(botCodeTTL), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to link chat: %v", err)
+	}
+	return s.GetBotLink(ctx)
+}
+
+// ConfirmBotLink starts the quiz questions of the linked chat, given the
+// code the bot sent it
+func (s *Service) ConfirmBotLink(ctx context.Context, code string, now time.Time) (*models.BotLink, error) {
+	var (
+		stored   sql.NullString
+		expires  sql.NullTime
+		attempts int
+		verified sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT code, code_expires_at, code_attempts, verified_at FROM bot_links WHERE user_id = ?
+	`, s.userID).Scan(&stored, &expires, &attempts, &verified)
+	if err == sql.ErrNoRows {
+		return nil, notFound("no chat is linked")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat link: %v", err)
+	}
+	if verified.Valid {
+		return s.GetBotLink(ctx)
+	}
+	if !stored.Valid || !expires.Valid || !now.Before(expires.Time) || attempts >= maxBotCodeAttempts {
+		return nil, invalid("the code has expired, link the chat again")
+	}
+	if strings.TrimSpace(code) != stored.String {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE bot_links SET code_attempts = code_attempts + 1 WHERE user_id = ?
+		`, s.userID); err != nil {
+			return nil, fmt.Errorf("failed to update chat link: %v", err)
+		}
+		return nil, invalid("wrong code")
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE bot_links SET verified_at = ?, code = NULL, code_expires_at = NULL WHERE user_id = ?
+	`, now.UTC(), s.userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, conflict("the chat is linked to another user")
+		}
+		return nil, fmt.Errorf("failed to confirm chat link: %v", err)
+	}
+	return s.GetBotLink(ctx)
+}
+
+// UnlinkBotChat stops the quiz questions, forgetting the chat
+func (s *Service) UnlinkBotChat(ctx context.Context) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM bot_links WHERE user_id = ?`, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink chat: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if deleted == 0 {
+		return notFound("no chat is linked")
+	}
+	return nil
+}
+
+// StartBotQuizzes queues sending the daily quiz questions now and then at
+// the start of every hour until the service is closed. It does nothing if
+// no bot is configured.
+func (s *Service) StartBotQuizzes() {
+	configured := false
+	for _, platform := range []string{bot.Telegram, bot.Discord} {
+		b, err := bot.FromEnv(platform)
+		if err != nil {
+			log.Printf("The %s bot is off: %v", platform, err)
+		}
+		configured = configured || b != nil
+	}
+	if !configured {
+		return
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if _, err := s.runner.Enqueue(ctx, 0, JobSendBotQuizzes, nil); err != nil {
+				log.Printf("Failed to queue quiz questions: %v", err)
+			}
+
+			next := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+			select {
+			case <-time.After(time.Until(next)):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// chatQuestion is a quiz question on a word sent to a chat
+type chatQuestion struct {
+	id      int64
+	word    models.WordResponse
+	groupID int64
+	options []string
+	answer  int
+}
+
+// text asks the question
+func (q *chatQuestion) text() string {
+	return fmt.Sprintf("Daily review: what does %s (%s) mean?", q.word.Urdu, q.word.Urdlish)
+}
+
+// SendBotQuizzes sends each user with a confirmed chat whose quiz hour has
+// come today's quiz question, on the word due for review longest, once a
+// day. Users with no words due are sent nothing. Sends are logged as
+// notification deliveries, so failed sends are tried again by later runs.
+func (s *Service) SendBotQuizzes(ctx context.Context, now time.Time) (*models.NotificationDispatch, error) {
+	now = now.UTC()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, platform, chat_id FROM bot_links
+		WHERE verified_at IS NOT NULL AND quiz_hour <= ?
+		ORDER BY user_id
+	`, now.Hour())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find linked chats: %v", err)
+	}
+	type link struct {
+		userID           int64
+		platform, chatID string
+	}
+	var links []link
+	for rows.Next() {
+		var l link
+		if err := rows.Scan(&l.userID, &l.platform, &l.chatID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan linked chat: %v", err)
+		}
+		links = append(links, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating linked chats: %v", err)
+	}
+
+	var dispatch models.NotificationDispatch
+	bots := map[string]bot.Bot{}
+	for _, l := range links {
+		b, ok := bots[l.platform]
+		if !ok {
+			if b, err = s.Bot(l.platform); err != nil {
+				log.Printf("Skipping %s chats: %v", l.platform, err)
+			}
+			bots[l.platform] = b
+		}
+		if b == nil {
+			continue
+		}
+		if err := s.ForUser(l.userID).sendChatQuestion(ctx, b, l.chatID, now, &dispatch); err != nil {
+			return nil, err
+		}
+	}
+	return &dispatch, nil
+}
+
+// sendChatQuestion sends the user's quiz question of the day to their chat
+func (s *Service) sendChatQuestion(ctx context.Context, b bot.Bot, chatID string, now time.Time, dispatch *models.NotificationDispatch) error {
+	day := now.Format("2006-01-02")
+	question, err := s.dailyChatQuestion(ctx, day, now)
+	if err != nil || question == nil {
+		return err
+	}
+	msg := bot.Message{Text: question.text()}
+	for i, option := range question.options {
+		msg.Options = append(msg.Options, bot.Option{Label: option, Data: fmt.Sprintf("quiz:%d:%d", question.id, i)})
+	}
+	return s.deliver(ctx, NotificationDailyQuiz, b.Platform(), day, chatID, msg.Text, now, dispatch, func() error {
+		return b.Send(ctx, chatID, msg)
+	})
+}
+
+// dailyChatQuestion returns the user's quiz question of a day, asking one on
+// the word due longest if there is none yet. It returns nil if no word in
+// a group is due.
+func (s *Service) dailyChatQuestion(ctx context.Context, day string, now time.Time) (*chatQuestion, error) {
+	question, err := s.getChatQuestion(ctx, `q.user_id = ? AND q.day = ?`, s.userID, day)
+	if err != sql.ErrNoRows {
+		return question, err
+	}
+
+	question = &chatQuestion{}
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english,
+			   (SELECT MIN(wg.group_id) FROM words_groups wg WHERE wg.word_id = w.id)
+		FROM word_learning_state wls
+		JOIN words w ON w.id = wls.word_id
+		WHERE wls.user_id = ? AND wls.due_at <= ?
+		AND EXISTS (SELECT 1 FROM words_groups wg WHERE wg.word_id = w.id)
+		ORDER BY wls.due_at ASC
+		LIMIT 1
+	`, s.userID, endOfDay).Scan(&question.word.ID, &question.word.Urdu, &question.word.Urdlish,
+		&question.word.English, &question.groupID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due word: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT english FROM words WHERE id != ? AND english != ?
+		GROUP BY english
+		ORDER BY RANDOM()
+		LIMIT ?
+	`, question.word.ID, question.word.English, chatQuizOptions-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz options: %v", err)
+	}
+	defer rows.Close()
+	question.options = []string{question.word.English}
+	for rows.Next() {
+		var option string
+		if err := rows.Scan(&option); err != nil {
+			return nil, fmt.Errorf("failed to scan quiz option: %v", err)
+		}
+		question.options = append(question.options, option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quiz options: %v", err)
+	}
+	mathrand.Shuffle(len(question.options), func(i, j int) {
+		question.options[i], question.options[j] = question.options[j], question.options[i]
+	})
+	for i, option := range question.options {
+		if option == question.word.English {
+			question.answer = i
+		}
+	}
+
+	options, err := json.Marshal(question.options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quiz options: %v", err)
+	}
+	// A question asked meanwhile by another run is kept
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO bot_questions (user_id, word_id, group_id, day, options, answer, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, day) DO NOTHING
+	`, s.userID, question.word.ID, question.groupID, day, string(options), question.answer, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save quiz question: %v", err)
+	}
+	return s.getChatQuestion(ctx, `q.user_id = ? AND q.day = ?`, s.userID, day)
+}
+
+// getChatQuestion returns the quiz question matching where, with its word.
+// It returns sql.ErrNoRows if there is none.
+func (s *Service) getChatQuestion(ctx context.Context, where string, args ...interface{}) (*chatQuestion, error) {
+	var (
+		question chatQuestion
+		options  string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT q.id, q.group_id, q.options, q.answer, w.id, w.script, w.transliteration, w.english
+		FROM bot_questions q
+		JOIN words w ON w.id = q.word_id
+		WHERE `+where, args...).Scan(&question.id, &question.groupID, &options, &question.answer,
+		&question.word.ID, &question.word.Urdu, &question.word.Urdlish, &question.word.English)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz question: %v", err)
+	}
+	if err := json.Unmarshal([]byte(options), &question.options); err != nil {
+		return nil, fmt.Errorf("failed to decode quiz options: %v", err)
+	}
+	return &question, nil
+}
+
+// AnswerBotQuestion records an answer pressed in a chat of a platform,
+// whose data names a quiz question sent to the chat and the option chosen,
+// as a review of the question's word in a Chat Quiz session. It returns the
+// reply to show in the chat. Questions are answered once.
+func (s *Service) AnswerBotQuestion(ctx context.Context, platform, chatID, data string, now time.Time) (string, error) {
+	var questionID int64
+	var chosen int
+	if _, err := fmt.Sscanf(data, "quiz:%d:%d", &questionID, &chosen); err != nil {
+		return "", invalid("unknown answer")
+	}
+
+	var userID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT q.user_id FROM bot_questions q
+		JOIN bot_links bl ON bl.user_id = q.user_id
+		WHERE q.id = ? AND bl.platform = ? AND bl.chat_id = ? AND bl.verified_at IS NOT NULL
+	`, questionID, platform, chatID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", notFound("This question isn't for this chat any more.")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get quiz question: %v", err)
+	}
+	user := s.ForUser(userID)
+	question, err := user.getChatQuestion(ctx, `q.id = ?`, questionID)
+	if err != nil {
+		return "", err
+	}
+	if chosen < 0 || chosen >= len(question.options) {
+		return "", invalid("unknown answer")
+	}
+
+	correct := chosen == question.answer
+	err = user.db.WithTx(ctx, func(tx *models.Tx) error {
+		sessionID, err := user.startActivitySession(ctx, tx, chatQuizActivity, question.groupID, "")
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE bot_questions SET chosen = ?, study_session_id = ?, answered_at = ?
+			WHERE id = ? AND chosen IS NULL
+		`, chosen, sessionID, now.UTC(), questionID)
+		if err != nil {
+			return fmt.Errorf("failed to save answer: %v", err)
+		}
+		if answered, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get affected rows: %v", err)
+		} else if answered == 0 {
+			return conflict("You already answered this question.")
+		}
+		_, err = user.reviewWord(ctx, tx, sessionID, question.word.ID, correct, false)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if correct {
+		return fmt.Sprintf("Correct! %s (%s) means \"%s\".", question.word.Urdu, question.word.Urdlish, question.word.English), nil
+	}
+	return fmt.Sprintf("Not quite: %s (%s) means \"%s\".", question.word.Urdu, question.word.Urdlish, question.word.English), nil
+}
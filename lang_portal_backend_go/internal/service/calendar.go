@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/ical"
+	"lang_portal/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// calendarPastDays is how many days of finished sessions the calendar
+	// feed lists
+	calendarPastDays = 90
+	// calendarFutureDays is how many days of scheduled reviews the calendar
+	// feed lists, today included
+	calendarFutureDays = 30
+	// calendarRefresh is how often calendar apps are asked to fetch the
+	// feed again
+	calendarRefresh = time.Hour
+	// minSessionLength is how long a session with a single review is shown
+	minSessionLength = 5 * time.Minute
+)
+
+// hashCalendarToken returns the hash a calendar token is kept as
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// countWords returns "1 word" or "n words"
+func countWords(n int) string {
+	if n == 1 {
+		return "1 word"
+	}
+	return strconv.Itoa(n) + " words"
+}
+
+// CreateCalendarToken gives the user a new secret to fetch their calendar
+// feed with, replacing the one they had
+func (s *Service) CreateCalendarToken(ctx context.Context, now time.Time) (*models.CalendarToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate calendar token: %v", err)
+	}
+	token := hex.EncodeToString(secret)
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO calendar_tokens (user_id, token_hash, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			created_at = excluded.created_at
+	`, s.userID, hashCalendarToken(token), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save calendar token: %v", err)
+	}
+	return &models.CalendarToken{Token: token, CreatedAt: now.UTC()}, nil
+}
+
+// DeleteCalendarToken stops the user's calendar feed being served
+func (s *Service) DeleteCalendarToken(ctx context.Context) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM calendar_tokens WHERE user_id = ?`, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar token: %v", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	} else if deleted == 0 {
+		return notFound("no calendar token")
+	}
+	return nil
+}
+
+// CalendarUser returns the user a calendar token was given to
+func (s *Service) CalendarUser(ctx context.Context, token string) (int64, error) {
+	if token == "" {
+		return 0, unauthorized("missing calendar token")
+	}
+	var userID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id FROM calendar_tokens WHERE token_hash = ?
+	`, hashCalendarToken(token)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, unauthorized("invalid calendar token")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to check calendar token: %v", err)
+	}
+	return userID, nil
+}
+
+// GetCalendar returns the user's study calendar: the sessions they studied
+// in over the last calendarPastDays days, from their start to their last
+// review, and an all-day event on each of the next calendarFutureDays days
+// with words due for review. Words already overdue are due today.
+func (s *Service) GetCalendar(ctx context.Context, now time.Time) (*ical.Calendar, error) {
+	calendar := &ical.Calendar{
+		ProductID: "-//Lang Portal//Study Calendar//EN",
+		Name:      "Lang Portal",
+		Refresh:   calendarRefresh,
+		Events:    []ical.Event{},
+	}
+	today := startOfDay(now)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, a.name, g.name, s.created_at,
+			   CAST(strftime('%s', MAX(r.created_at)) AS INTEGER),
+			   COUNT(r.word_id), SUM(CASE WHEN r.correct THEN 1 ELSE 0 END)
+		FROM study_sessions s
+		JOIN study_activities a ON a.id = s.study_activity_id
+		JOIN groups g ON g.id = s.group_id
+		JOIN word_review_items r ON r.study_session_id = s.id
+		WHERE s.user_id = ? AND s.created_at >= ?
+		GROUP BY s.id, a.name, g.name, s.created_at
+		ORDER BY s.created_at
+	`, s.userID, today.AddDate(0, 0, -calendarPastDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study sessions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id                int64
+			activity, group   string
+			start             time.Time
+			lastReview        int64
+			reviewed, correct int
+		)
+		if err := rows.Scan(&id, &activity, &group, &start, &lastReview, &reviewed, &correct); err != nil {
+			return nil, fmt.Errorf("failed to scan study session: %v", err)
+		}
+		end := time.Unix(lastReview, 0)
+		if end.Before(start.Add(minSessionLength)) {
+			end = start.Add(minSessionLength)
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         "session-" + strconv.FormatInt(id, 10) + "@lang-portal",
+			Summary:     activity + ": " + group,
+			Description: fmt.Sprintf("%s reviewed, %d correct", countWords(reviewed), correct),
+			Start:       start,
+			End:         end,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating study sessions: %v", err)
+	}
+	rows.Close()
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT max(date(due_at), ?) AS day, COUNT(*)
+		FROM word_learning_state
+		WHERE user_id = ? AND due_at < ?
+		GROUP BY day
+		ORDER BY day
+	`, today.Format("2006-01-02"), s.userID, today.AddDate(0, 0, calendarFutureDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled reviews: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			day   string
+			count int
+		)
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled reviews: %v", err)
+		}
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse review day %q: %v", day, err)
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:     "reviews-" + strings.ReplaceAll(day, "-", "") + "@lang-portal",
+			Summary: "Review " + countWords(count),
+			Start:   date,
+			AllDay:  true,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled reviews: %v", err)
+	}
+	return calendar, nil
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/models"
+	"strings"
+	"time"
+)
+
+// Class roles
+const (
+	ClassRoleTeacher = "teacher"
+	ClassRoleStudent = "student"
+)
+
+// classDueAtLayout is how assignment due dates are stored, so they compare
+// with review times
+const classDueAtLayout = "2006-01-02 15:04:05"
+
+// classSQL selects a class the user teaches or is a student of. It takes the
+// user ID as ?1.
+const classSQL = `
+	SELECT c.id, c.name, c.teacher_id, c.invite_code, c.created_at,
+		   (SELECT COUNT(*) FROM class_students cs WHERE cs.class_id = c.id),
+		   CASE WHEN c.teacher_id = ?1 THEN 'teacher' ELSE 'student' END
+	FROM classes c
+	WHERE (c.teacher_id = ?1 OR EXISTS (
+		SELECT 1 FROM class_students cs WHERE cs.class_id = c.id AND cs.user_id = ?1
+	))
+`
+
+// scanClass reads a class selected by classSQL, hiding the invite code from
+// students
+func scanClass(row interface{ Scan(...interface{}) error }) (*models.Class, error) {
+	var class models.Class
+	if err := row.Scan(&class.ID, &class.Name, &class.TeacherID, &class.InviteCode,
+		&class.CreatedAt, &class.Students, &class.Role); err != nil {
+		return nil, err
+	}
+	if class.Role != ClassRoleTeacher {
+		class.InviteCode = ""
+	}
+	return &class, nil
+}
+
+// GetClasses returns the classes the user teaches or is a student of
+func (s *Service) GetClasses(ctx context.Context) ([]models.Class, error) {
+	rows, err := s.db.QueryContext(ctx, classSQL+` ORDER BY c.id`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get classes: %v", err)
+	}
+	defer rows.Close()
+
+	classes := []models.Class{}
+	for rows.Next() {
+		class, err := scanClass(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan class: %v", err)
+		}
+		classes = append(classes, *class)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating classes: %v", err)
+	}
+	return classes, nil
+}
+
+// GetClass returns a class the user teaches or is a student of
+func (s *Service) GetClass(ctx context.Context, id int64) (*models.Class, error) {
+	class, err := scanClass(s.db.QueryRowContext(ctx, classSQL+` AND c.id = ?2`, s.userID, id))
+	if err == sql.ErrNoRows {
+		return nil, notFound("class not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class: %v", err)
+	}
+	return class, nil
+}
+
+// getTaughtClass returns a class for changes only its teacher may make
+func (s *Service) getTaughtClass(ctx context.Context, id int64) (*models.Class, error) {
+	class, err := s.GetClass(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if class.Role != ClassRoleTeacher {
+		return nil, forbidden("not the class teacher")
+	}
+	return class, nil
+}
+
+// CreateClass creates a class taught by the user
+func (s *Service) CreateClass(ctx context.Context, name string) (*models.Class, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, invalid("invalid class name")
+	}
+
+	code := make([]byte, 5)
+	if _, err := rand.Read(code); err != nil {
+		return nil, fmt.Errorf("failed to generate invite code: %v", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO classes (teacher_id, name, invite_code) VALUES (?, ?, ?)
+	`, s.userID, name, strings.ToUpper(hex.EncodeToString(code)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create class: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class id: %v", err)
+	}
+	return s.GetClass(ctx, id)
+}
+
+// DeleteClass deletes a class the user teaches along with its assignments
+func (s *Service) DeleteClass(ctx context.Context, id int64) error {
+	if _, err := s.getTaughtClass(ctx, id); err != nil {
+		return err
+	}
+
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM class_assignments WHERE class_id = ?`,
+			`DELETE FROM class_students WHERE class_id = ?`,
+			`DELETE FROM classes WHERE id = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return fmt.Errorf("failed to delete class: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// JoinClass makes the user a student of the class with the invite code
+func (s *Service) JoinClass(ctx context.Context, inviteCode string) (*models.Class, error) {
+	var (
+		classID   int64
+		teacherID int64
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, teacher_id FROM classes WHERE invite_code = ?
+	`, strings.ToUpper(strings.TrimSpace(inviteCode))).Scan(&classID, &teacherID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("invalid invite code")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class: %v", err)
+	}
+	if teacherID == s.userID {
+		return nil, conflict("already in class")
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO class_students (class_id, user_id) VALUES (?, ?)
+	`, classID, s.userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, conflict("already in class")
+		}
+		return nil, fmt.Errorf("failed to join class: %v", err)
+	}
+	return s.GetClass(ctx, classID)
+}
+
+// RemoveClassStudent takes a student out of a class. Teachers can remove any
+// of their students; students can only leave.
+func (s *Service) RemoveClassStudent(ctx context.Context, classID, userID int64) error {
+	class, err := s.GetClass(ctx, classID)
+	if err != nil {
+		return err
+	}
+	if class.Role != ClassRoleTeacher && userID != s.userID {
+		return forbidden("not the class teacher")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM class_students WHERE class_id = ? AND user_id = ?
+	`, classID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove student: %v", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove student: %v", err)
+	}
+	if removed == 0 {
+		return notFound("student not found")
+	}
+	return nil
+}
+
+// GetClassAssignments returns the assignments of a class the user teaches or
+// is a student of, soonest due first
+func (s *Service) GetClassAssignments(ctx context.Context, classID int64) ([]models.ClassAssignment, error) {
+	if _, err := s.GetClass(ctx, classID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ca.id, ca.class_id, ca.group_id, g.name, ca.due_at, ca.created_at
+		FROM class_assignments ca
+		JOIN groups g ON g.id = ca.group_id
+		WHERE ca.class_id = ?
+		ORDER BY ca.due_at, ca.id
+	`, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignments: %v", err)
+	}
+	defer rows.Close()
+
+	assignments := []models.ClassAssignment{}
+	for rows.Next() {
+		var assignment models.ClassAssignment
+		if err := rows.Scan(&assignment.ID, &assignment.ClassID, &assignment.GroupID,
+			&assignment.GroupName, &assignment.DueAt, &assignment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %v", err)
+		}
+		assignments = append(assignments, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assignments: %v", err)
+	}
+	return assignments, nil
+}
+
+// CreateClassAssignment sets a group as homework for a class the user
+// teaches
+func (s *Service) CreateClassAssignment(ctx context.Context, classID, groupID int64, dueAt, now time.Time) (*models.ClassAssignment, error) {
+	if _, err := s.getTaughtClass(ctx, classID); err != nil {
+		return nil, err
+	}
+	if !dueAt.After(now) {
+		return nil, invalid("invalid due date")
+	}
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, notFound("group not found")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO class_assignments (class_id, group_id, due_at, created_at) VALUES (?, ?, ?, ?)
+	`, classID, groupID, dueAt.UTC().Format(classDueAtLayout), now.UTC().Format(classDueAtLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create assignment: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment id: %v", err)
+	}
+
+	assignments, err := s.GetClassAssignments(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		if assignment.ID == id {
+			return &assignment, nil
+		}
+	}
+	return nil, notFound("assignment not found")
+}
+
+// DeleteClassAssignment removes an assignment from a class the user teaches
+func (s *Service) DeleteClassAssignment(ctx context.Context, classID, assignmentID int64) error {
+	if _, err := s.getTaughtClass(ctx, classID); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM class_assignments WHERE id = ? AND class_id = ?
+	`, assignmentID, classID)
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment: %v", err)
+	}
+	if deleted == 0 {
+		return notFound("assignment not found")
+	}
+	return nil
+}
+
+// GetClassProgress sums up the study history of each student of a class the
+// user teaches, and how far they got with each assignment. An assignment is
+// completed once the student has reviewed every word of its group between it
+// being set and its due date.
+func (s *Service) GetClassProgress(ctx context.Context, classID int64, now time.Time) (*models.ClassProgress, error) {
+	class, err := s.getTaughtClass(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.GetClassAssignments(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	progress := models.ClassProgress{
+		Class:       *class,
+		Assignments: assignments,
+		Students:    []models.StudentProgress{},
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.username, u.created_at,
+			   (SELECT COUNT(*) FROM study_sessions ss WHERE ss.user_id = u.id),
+			   COUNT(wri.word_id),
+			   COALESCE(SUM(CASE WHEN wri.correct THEN 1 ELSE 0 END), 0),
+			   CAST(strftime('%s', MAX(wri.created_at)) AS INTEGER)
+		FROM class_students cs
+		JOIN users u ON u.id = cs.user_id
+		LEFT JOIN word_review_items wri ON wri.user_id = u.id
+		WHERE cs.class_id = ?
+		GROUP BY u.id
+		ORDER BY u.username
+	`, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student progress: %v", err)
+	}
+	defer rows.Close()
+
+	students := map[int64]int{}
+	for rows.Next() {
+		var (
+			student     models.StudentProgress
+			lastStudied sql.NullInt64
+		)
+		if err := rows.Scan(&student.User.ID, &student.User.Username, &student.User.CreatedAt,
+			&student.StudySessions, &student.Reviews, &student.CorrectCount, &lastStudied); err != nil {
+			return nil, fmt.Errorf("failed to scan student progress: %v", err)
+		}
+		if student.Reviews > 0 {
+			student.Accuracy = float64(student.CorrectCount) / float64(student.Reviews)
+		}
+		if lastStudied.Valid {
+			t := time.Unix(lastStudied.Int64, 0).UTC()
+			student.LastStudiedAt = &t
+		}
+		student.Assignments = []models.AssignmentProgress{}
+		students[student.User.ID] = len(progress.Students)
+		progress.Students = append(progress.Students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating student progress: %v", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT ca.id, cs.user_id, ca.due_at <= ?2,
+			   (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = ca.group_id),
+			   (SELECT COUNT(DISTINCT wri.word_id)
+				FROM word_review_items wri
+				JOIN words_groups wg ON wg.word_id = wri.word_id AND wg.group_id = ca.group_id
+				WHERE wri.user_id = cs.user_id
+				AND datetime(wri.created_at) >= ca.created_at
+				AND datetime(wri.created_at) <= ca.due_at)
+		FROM class_assignments ca
+		JOIN class_students cs ON cs.class_id = ca.class_id
+		WHERE ca.class_id = ?1
+		ORDER BY ca.due_at, ca.id
+	`, classID, now.UTC().Format(classDueAtLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment progress: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry  models.AssignmentProgress
+			userID int64
+			past   bool
+		)
+		if err := rows.Scan(&entry.AssignmentID, &userID, &past, &entry.TotalWords, &entry.WordsReviewed); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment progress: %v", err)
+		}
+		if entry.TotalWords > 0 {
+			entry.Progress = float64(entry.WordsReviewed) / float64(entry.TotalWords)
+		}
+		entry.Completed = entry.TotalWords > 0 && entry.WordsReviewed == entry.TotalWords
+		entry.Overdue = past && !entry.Completed
+
+		i, ok := students[userID]
+		if !ok {
+			continue
+		}
+		progress.Students[i].Assignments = append(progress.Students[i].Assignments, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assignment progress: %v", err)
+	}
+	return &progress, nil
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/corpus"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// clozeActivity is the study activity cloze sessions are filed under
+const clozeActivity = "Cloze"
+
+// clozeCandidatePool caps how many other words are fetched as candidate
+// options of a cloze question
+const clozeCandidatePool = 20
+
+// clozeSentence is the occurrence of a word in a sentence a cloze question
+// is made from
+type clozeSentence struct {
+	id      int64
+	text    string
+	english string
+	corpus.Occurrence
+}
+
+// StartCloze starts a cloze session with up to wordCount words of a group
+// that occur in the corpus, each blanked out of one of its sentences picked
+// at random. Like word games, the words the learner struggles with most are
+// picked first. Multiple choice questions offer the forms of other verbs of
+// the same tense, person and gender when the word blanked is a conjugated
+// verb, and otherwise other words of the group and then of its language.
+func (s *Service) StartCloze(ctx context.Context, groupID int64, wordCount int, answerMode string) (*models.ClozeSession, error) {
+	switch answerMode {
+	case "":
+		answerMode = AnswerModeMultipleChoice
+	case AnswerModeMultipleChoice, AnswerModeTyped:
+	default:
+		return nil, invalid("invalid answer mode %q: use %s or %s", answerMode, AnswerModeMultipleChoice, AnswerModeTyped)
+	}
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id
+		FROM words_groups wg
+		JOIN words w ON w.id = wg.word_id
+		LEFT JOIN word_learning_state wls ON wls.word_id = w.id AND wls.user_id = ?1
+		WHERE wg.group_id = ?2
+		  AND EXISTS (SELECT 1 FROM sentence_words sw WHERE sw.word_id = w.id)
+		ORDER BY COALESCE(wls.relearning_step, 0) > 0 DESC,
+			COALESCE(wls.ease_factor, 2.5),
+			COALESCE(wls.lapses, 0) DESC,
+			RANDOM()
+		LIMIT ?3
+	`, s.userID, groupID, wordCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cloze words: %v", err)
+	}
+	var wordIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan cloze word: %v", err)
+		}
+		wordIDs = append(wordIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cloze words: %v", err)
+	}
+	if len(wordIDs) == 0 {
+		return nil, notFound("no words of the group occur in the sentence corpus")
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	questions := make([]models.ClozeQuestion, 0, len(wordIDs))
+	answers := make([]string, 0, len(wordIDs))
+	for _, wordID := range wordIDs {
+		var sentence clozeSentence
+		err := s.db.QueryRowContext(ctx, `
+			SELECT s.id, s.text, s.english, sw.position, sw.length
+			FROM sentence_words sw
+			JOIN sentences s ON s.id = sw.sentence_id
+			WHERE sw.word_id = ?
+			ORDER BY RANDOM()
+			LIMIT 1
+		`, wordID).Scan(&sentence.id, &sentence.text, &sentence.english, &sentence.Position, &sentence.Length)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cloze sentence: %v", err)
+		}
+		prompt, answer := corpus.Cloze(sentence.text, sentence.Occurrence)
+		if answer == "" {
+			continue
+		}
+
+		question := models.ClozeQuestion{
+			WordID:     wordID,
+			SentenceID: &sentence.id,
+			Prompt:     prompt,
+			English:    sentence.english,
+			AnswerMode: answerMode,
+		}
+		if answerMode == AnswerModeMultipleChoice {
+			candidates, err := s.clozeCandidates(ctx, wordID, groupID, answer)
+			if err != nil {
+				return nil, err
+			}
+			question.Options = corpus.Options(answer, candidates, rng)
+			// With nothing to choose between, the word is typed instead
+			if len(question.Options) < 2 {
+				question.AnswerMode, question.Options = AnswerModeTyped, nil
+			}
+		}
+		questions = append(questions, question)
+		answers = append(answers, answer)
+	}
+	if len(questions) == 0 {
+		return nil, notFound("no words of the group occur in the sentence corpus")
+	}
+
+	var sessionID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		sessionID, err = s.startActivitySession(ctx, tx, clozeActivity, groupID, "")
+		if err != nil {
+			return err
+		}
+
+		for i, question := range questions {
+			options, err := json.Marshal(question.Options)
+			if err != nil {
+				return fmt.Errorf("failed to encode options: %v", err)
+			}
+			if question.Options == nil {
+				options = []byte("[]")
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO study_session_words (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, question.WordID); err != nil {
+				return fmt.Errorf("failed to add word to study session: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO cloze_questions (study_session_id, word_id, sentence_id, prompt, english, answer_mode, options, correct_answer)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			`, sessionID, question.WordID, question.SentenceID, question.Prompt, question.English, question.AnswerMode,
+				string(options), answers[i]); err != nil {
+				return fmt.Errorf("failed to add cloze question: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCloze(ctx, sessionID)
+}
+
+// clozeCandidates returns the candidate wrong options of a cloze question
+// blanking answer, a form of a word, best first: the same form of other
+// verbs if answer is a conjugated form, then other words of the word's
+// language, those of the group first
+func (s *Service) clozeCandidates(ctx context.Context, wordID, groupID int64, answer string) ([]string, error) {
+	var candidates []string
+	key := corpus.Key(answer)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.form, o.form
+		FROM conjugations c
+		JOIN conjugations o ON o.tense = c.tense AND o.person = c.person AND o.gender = c.gender AND o.word_id != c.word_id
+		JOIN words v ON v.id = c.word_id
+		JOIN words w ON w.id = o.word_id
+		WHERE c.word_id = ? AND w.language = v.language
+		ORDER BY RANDOM()
+	`, wordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get other verb forms: %v", err)
+	}
+	for rows.Next() {
+		var form, other string
+		if err := rows.Scan(&form, &other); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan verb form: %v", err)
+		}
+		if corpus.Key(form) == key {
+			candidates = append(candidates, other)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating verb forms: %v", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT w.script
+		FROM words w
+		JOIN words v ON v.id = ?1
+		WHERE w.id != v.id AND w.language = v.language
+		ORDER BY w.id IN (SELECT word_id FROM words_groups WHERE group_id = ?2) DESC, RANDOM()
+		LIMIT ?3
+	`, wordID, groupID, clozeCandidatePool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get other words: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var script string
+		if err := rows.Scan(&script); err != nil {
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		candidates = append(candidates, script)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %v", err)
+	}
+	return candidates, nil
+}
+
+// clozeQuestionColumns are the columns scanClozeQuestion reads, of
+// cloze_questions q
+const clozeQuestionColumns = `q.word_id, q.sentence_id, q.prompt, q.english, q.answer_mode, q.options,
+	q.correct_answer, q.answer, q.result`
+
+// GetCloze returns a cloze session and its questions
+func (s *Service) GetCloze(ctx context.Context, sessionID int64) (*models.ClozeSession, error) {
+	session := models.ClozeSession{SessionID: sessionID, Questions: []models.ClozeQuestion{}}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ss.group_id FROM study_sessions ss
+		JOIN study_activities sa ON sa.id = ss.study_activity_id
+		WHERE ss.id = ? AND ss.user_id = ? AND sa.name = ?
+	`, sessionID, s.userID, clozeActivity).Scan(&session.GroupID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("cloze session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study session: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+clozeQuestionColumns+`
+		FROM cloze_questions q
+		WHERE q.study_session_id = ?
+		ORDER BY q.id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cloze questions: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		question, err := scanClozeQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		session.Questions = append(session.Questions, *question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cloze questions: %v", err)
+	}
+	return &session, nil
+}
+
+// scanClozeQuestion reads a question selected by clozeQuestionColumns,
+// hiding the text blanked until the question is answered
+func scanClozeQuestion(row interface{ Scan(...interface{}) error }) (*models.ClozeQuestion, error) {
+	var (
+		question         models.ClozeQuestion
+		sentenceID       sql.NullInt64
+		options, correct string
+		answer           sql.NullString
+	)
+	if err := row.Scan(&question.WordID, &sentenceID, &question.Prompt, &question.English, &question.AnswerMode,
+		&options, &correct, &answer, &question.Result); err != nil {
+		return nil, fmt.Errorf("failed to scan cloze question: %v", err)
+	}
+	if sentenceID.Valid {
+		question.SentenceID = &sentenceID.Int64
+	}
+	if err := json.Unmarshal([]byte(options), &question.Options); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %v", err)
+	}
+	if question.Result != QuizResultUnanswered {
+		question.Answer = &answer.String
+		question.CorrectAnswer = &correct
+	}
+	return &question, nil
+}
+
+// AnswerCloze checks the answer to a cloze question and records it as a
+// review of the word. A multiple choice answer must be one of the options.
+// Typed answers forgive diacritics and small typos as typed quiz answers do;
+// those with typos are near misses. A question is answered once.
+func (s *Service) AnswerCloze(ctx context.Context, sessionID, wordID int64, answer string) (*models.ClozeQuestion, error) {
+	if strings.TrimSpace(answer) == "" {
+		return nil, invalid("answer is required")
+	}
+
+	var question *models.ClozeQuestion
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var answerMode, options, correct, result string
+		err := tx.QueryRowContext(ctx, `
+			SELECT answer_mode, options, correct_answer, result
+			FROM cloze_questions
+			WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+		`, sessionID, wordID, s.userID).Scan(&answerMode, &options, &correct, &result)
+		if err == sql.ErrNoRows {
+			return notFound("cloze question not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get cloze question: %v", err)
+		}
+		if result != QuizResultUnanswered {
+			return conflict("cloze question already answered")
+		}
+
+		var choices []string
+		if err := json.Unmarshal([]byte(options), &choices); err != nil {
+			return fmt.Errorf("failed to decode options: %v", err)
+		}
+		match, err := matchAnswer(answerMode, choices, answer, correct)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE cloze_questions SET answer = ?, result = ?, answered_at = ?
+			WHERE study_session_id = ? AND word_id = ?
+		`, answer, string(match), time.Now().UTC(), sessionID, wordID); err != nil {
+			return fmt.Errorf("failed to update cloze question: %v", err)
+		}
+		if _, err := s.reviewWord(ctx, tx, sessionID, wordID, match != spelling.Wrong, match == spelling.NearMiss); err != nil {
+			return err
+		}
+
+		question, err = scanClozeQuestion(tx.QueryRowContext(ctx, `
+			SELECT `+clozeQuestionColumns+`
+			FROM cloze_questions q
+			WHERE q.study_session_id = ? AND q.word_id = ?
+		`, sessionID, wordID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// matchAnswer checks an answer to a question of an answer mode. A multiple
+// choice answer must be one of the options and is either right or wrong;
+// typed answers forgive diacritics and small typos as typed quiz answers do.
+func matchAnswer(answerMode string, options []string, answer, correct string) (spelling.Result, error) {
+	if answerMode != AnswerModeMultipleChoice {
+		return spelling.Match(answer, correct), nil
+	}
+	chosen := false
+	for _, option := range options {
+		chosen = chosen || strings.TrimSpace(option) == strings.TrimSpace(answer)
+	}
+	if !chosen {
+		return "", invalid("answer must be one of the options")
+	}
+	if strings.TrimSpace(answer) == strings.TrimSpace(correct) {
+		return spelling.Exact, nil
+	}
+	return spelling.Wrong, nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// conjugationDrillActivity is the study activity conjugation drills are
+// filed under
+const conjugationDrillActivity = "Conjugation Drill"
+
+// MaxConjugations is the most forms a verb can have
+const MaxConjugations = 200
+
+// tensePattern is what tenses look like, e.g. present_habitual or
+// simple_past. Languages have tenses of their own, so any is taken.
+var tensePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// conjugationPersons are the persons a form can be of. Urdu tells three
+// second persons apart by how familiar the speaker is with the listener:
+// tu, tum and aap.
+var conjugationPersons = map[string]bool{
+	"first_singular":  true,
+	"first_plural":    true,
+	"second_intimate": true,
+	"second_familiar": true,
+	"second_formal":   true,
+	"third_singular":  true,
+	"third_plural":    true,
+}
+
+// conjugationGenders are the genders a form can be of
+var conjugationGenders = map[string]bool{
+	"masculine": true,
+	"feminine":  true,
+}
+
+// GetConjugations returns the forms of a verb in the order they were given
+func (s *Service) GetConjugations(ctx context.Context, wordID int64) ([]models.Conjugation, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words WHERE id = ?)
+	`, wordID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up word: %v", err)
+	}
+	if !exists {
+		return nil, notFound("word not found")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, tense, person, gender, form, transliteration
+		FROM conjugations
+		WHERE word_id = ?
+		ORDER BY id
+	`, wordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conjugations: %v", err)
+	}
+	defer rows.Close()
+
+	conjugations := []models.Conjugation{}
+	for rows.Next() {
+		var conjugation models.Conjugation
+		if err := rows.Scan(&conjugation.ID, &conjugation.Tense, &conjugation.Person, &conjugation.Gender,
+			&conjugation.Form, &conjugation.Transliteration); err != nil {
+			return nil, fmt.Errorf("failed to scan conjugation: %v", err)
+		}
+		conjugations = append(conjugations, conjugation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conjugations: %v", err)
+	}
+	return conjugations, nil
+}
+
+// SetConjugations replaces the forms of a verb. No forms removes them all.
+// Drills already played keep the forms they asked for. Like other changes
+// to a word, it needs the editor role on every group of the verb.
+func (s *Service) SetConjugations(ctx context.Context, wordID int64, conjugations []models.Conjugation) ([]models.Conjugation, error) {
+	if len(conjugations) > MaxConjugations {
+		return nil, invalid("a verb can have at most %d conjugations", MaxConjugations)
+	}
+	seen := map[string]bool{}
+	for i := range conjugations {
+		conjugation := &conjugations[i]
+		conjugation.Tense = strings.ToLower(strings.TrimSpace(conjugation.Tense))
+		conjugation.Person = strings.ToLower(strings.TrimSpace(conjugation.Person))
+		conjugation.Gender = strings.ToLower(strings.TrimSpace(conjugation.Gender))
+		conjugation.Form = strings.TrimSpace(conjugation.Form)
+		conjugation.Transliteration = strings.TrimSpace(conjugation.Transliteration)
+		key := conjugation.Tense + "/" + conjugation.Person + "/" + conjugation.Gender
+		switch {
+		case !tensePattern.MatchString(conjugation.Tense):
+			return nil, invalid("conjugation %d has invalid tense %q: use lowercase letters and underscores, e.g. simple_past", i+1, conjugation.Tense)
+		case conjugation.Person != "" && !conjugationPersons[conjugation.Person]:
+			return nil, invalid("conjugation %d has unknown person %q", i+1, conjugation.Person)
+		case conjugation.Gender != "" && !conjugationGenders[conjugation.Gender]:
+			return nil, invalid("conjugation %d has unknown gender %q", i+1, conjugation.Gender)
+		case conjugation.Form == "":
+			return nil, invalid("conjugation %d has no form", i+1)
+		case seen[key]:
+			return nil, invalid("conjugation %d repeats the %s form", i+1, strings.Trim(key, "/"))
+		}
+		seen[key] = true
+	}
+
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkWordEditor(ctx, tx, wordID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM conjugations WHERE word_id = ?`, wordID); err != nil {
+			return fmt.Errorf("failed to delete conjugations: %v", err)
+		}
+		for _, conjugation := range conjugations {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO conjugations (word_id, tense, person, gender, form, transliteration)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, wordID, conjugation.Tense, conjugation.Person, conjugation.Gender,
+				conjugation.Form, conjugation.Transliteration); err != nil {
+				return fmt.Errorf("failed to add conjugation: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetConjugations(ctx, wordID)
+}
+
+// StartConjugationDrill starts a conjugation drill with up to wordCount
+// verbs of a group, asking for one form of each picked at random. tense and
+// person, if not empty, only ask for forms of them. Like word games, the
+// verbs the learner struggles with most are picked first.
+func (s *Service) StartConjugationDrill(ctx context.Context, groupID int64, wordCount int, tense, person string) (*models.ConjugationDrillSession, error) {
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id
+		FROM words_groups wg
+		JOIN words w ON w.id = wg.word_id
+		LEFT JOIN word_learning_state wls ON wls.word_id = w.id AND wls.user_id = ?1
+		WHERE wg.group_id = ?2
+		  AND EXISTS (
+			SELECT 1 FROM conjugations c
+			WHERE c.word_id = w.id AND (?3 = '' OR c.tense = ?3) AND (?4 = '' OR c.person = ?4)
+		  )
+		ORDER BY COALESCE(wls.relearning_step, 0) > 0 DESC,
+			COALESCE(wls.ease_factor, 2.5),
+			COALESCE(wls.lapses, 0) DESC,
+			RANDOM()
+		LIMIT ?5
+	`, s.userID, groupID, tense, person, wordCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drill verbs: %v", err)
+	}
+	var wordIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan drill verb: %v", err)
+		}
+		wordIDs = append(wordIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating drill verbs: %v", err)
+	}
+	if len(wordIDs) == 0 {
+		return nil, notFound("no verbs with conjugations found in the group")
+	}
+
+	var sessionID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		sessionID, err = s.startActivitySession(ctx, tx, conjugationDrillActivity, groupID, "")
+		if err != nil {
+			return err
+		}
+
+		for _, wordID := range wordIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO study_session_words (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, wordID); err != nil {
+				return fmt.Errorf("failed to add word to study session: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO conjugation_drill_rounds (study_session_id, word_id, tense, person, gender, form, transliteration)
+				SELECT ?1, word_id, tense, person, gender, form, transliteration
+				FROM conjugations
+				WHERE word_id = ?2 AND (?3 = '' OR tense = ?3) AND (?4 = '' OR person = ?4)
+				ORDER BY RANDOM()
+				LIMIT 1
+			`, sessionID, wordID, tense, person); err != nil {
+				return fmt.Errorf("failed to add conjugation drill round: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetConjugationDrill(ctx, sessionID)
+}
+
+// conjugationRoundColumns are the columns scanConjugationRound reads, of
+// conjugation_drill_rounds r joined with words w
+const conjugationRoundColumns = `r.word_id, w.script, w.english, r.tense, r.person, r.gender,
+	r.form, r.transliteration, r.answer, r.result`
+
+// GetConjugationDrill returns a conjugation drill and its rounds
+func (s *Service) GetConjugationDrill(ctx context.Context, sessionID int64) (*models.ConjugationDrillSession, error) {
+	session := models.ConjugationDrillSession{SessionID: sessionID, Rounds: []models.ConjugationDrillRound{}}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ss.group_id FROM study_sessions ss
+		JOIN study_activities sa ON sa.id = ss.study_activity_id
+		WHERE ss.id = ? AND ss.user_id = ? AND sa.name = ?
+	`, sessionID, s.userID, conjugationDrillActivity).Scan(&session.GroupID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("conjugation drill not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study session: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+conjugationRoundColumns+`
+		FROM conjugation_drill_rounds r
+		JOIN words w ON w.id = r.word_id
+		WHERE r.study_session_id = ?
+		ORDER BY r.id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conjugation drill rounds: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		round, err := scanConjugationRound(rows)
+		if err != nil {
+			return nil, err
+		}
+		session.Rounds = append(session.Rounds, *round)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conjugation drill rounds: %v", err)
+	}
+	return &session, nil
+}
+
+// scanConjugationRound reads a round selected by conjugationRoundColumns,
+// hiding the form until the round is answered
+func scanConjugationRound(row interface{ Scan(...interface{}) error }) (*models.ConjugationDrillRound, error) {
+	var (
+		round                 models.ConjugationDrillRound
+		form, transliteration string
+		answer                sql.NullString
+	)
+	if err := row.Scan(&round.WordID, &round.Verb, &round.English, &round.Tense, &round.Person, &round.Gender,
+		&form, &transliteration, &answer, &round.Result); err != nil {
+		return nil, fmt.Errorf("failed to scan conjugation drill round: %v", err)
+	}
+	if round.Result != QuizResultUnanswered {
+		round.Answer = &answer.String
+		round.Form = &form
+		round.Transliteration = &transliteration
+	}
+	return &round, nil
+}
+
+// AnswerConjugationDrill checks the answer to a round of a conjugation
+// drill and records it as a review of the verb. Answers written in the
+// verb's script or its transliteration are taken, forgiving diacritics and
+// small typos as typed quiz answers do; those with typos are near misses. A
+// round is answered once.
+func (s *Service) AnswerConjugationDrill(ctx context.Context, sessionID, wordID int64, answer string) (*models.ConjugationDrillRound, error) {
+	if strings.TrimSpace(answer) == "" {
+		return nil, invalid("answer is required")
+	}
+
+	var round *models.ConjugationDrillRound
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var form, transliteration, result string
+		err := tx.QueryRowContext(ctx, `
+			SELECT form, transliteration, result
+			FROM conjugation_drill_rounds
+			WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+		`, sessionID, wordID, s.userID).Scan(&form, &transliteration, &result)
+		if err == sql.ErrNoRows {
+			return notFound("conjugation drill round not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get conjugation drill round: %v", err)
+		}
+		if result != QuizResultUnanswered {
+			return conflict("conjugation drill round already answered")
+		}
+
+		match := spelling.Match(answer, form)
+		if match != spelling.Exact && transliteration != "" {
+			if other := spelling.Match(answer, transliteration); other != spelling.Wrong {
+				match = other
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE conjugation_drill_rounds SET answer = ?, result = ?, answered_at = ?
+			WHERE study_session_id = ? AND word_id = ?
+		`, answer, string(match), time.Now().UTC(), sessionID, wordID); err != nil {
+			return fmt.Errorf("failed to update conjugation drill round: %v", err)
+		}
+		if _, err := s.reviewWord(ctx, tx, sessionID, wordID, match != spelling.Wrong, match == spelling.NearMiss); err != nil {
+			return err
+		}
+
+		round, err = scanConjugationRound(tx.QueryRowContext(ctx, `
+			SELECT `+conjugationRoundColumns+`
+			FROM conjugation_drill_rounds r
+			JOIN words w ON w.id = r.word_id
+			WHERE r.study_session_id = ? AND r.word_id = ?
+		`, sessionID, wordID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return round, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"lang_portal/internal/models"
+	"testing"
+)
+
+func TestSetConjugationsPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int64
+		wordID  int64
+		wantErr error
+	}{
+		{name: "editor", userID: testEditor, wordID: 4},
+		{name: "owner", userID: testOwner, wordID: 4},
+		{name: "admin changes a word in no group", userID: testAdmin, wordID: 5},
+		{name: "viewer", userID: testViewer, wordID: 4, wantErr: ErrForbidden},
+		{name: "user without a role", userID: testStranger, wordID: 4, wantErr: ErrForbidden},
+		{name: "learner changes a word of a group nobody owns", userID: testEditor, wordID: 3, wantErr: ErrForbidden},
+		{name: "missing word", userID: testAdmin, wordID: 99, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := newTestService(t, testGroups)
+			conjugations := []models.Conjugation{{Tense: "simple_past", Gender: "feminine", Form: "گئی"}}
+			_, err := svc.ForUser(tt.userID).SetConjugations(ctx, tt.wordID, conjugations)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetConjugations() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == ErrNotFound {
+				return
+			}
+			// Refused changes leave the verb without forms
+			got, err := svc.GetConjugations(ctx, tt.wordID)
+			if err != nil {
+				t.Fatalf("GetConjugations() error = %v", err)
+			}
+			want := len(conjugations)
+			if tt.wantErr != nil {
+				want = 0
+			}
+			if len(got) != want {
+				t.Errorf("GetConjugations() = %d forms, want %d", len(got), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+)
+
+// CheckConsistency reports whether foreign keys are enforced and counts the
+// rows that refer to missing rows, by table and column. PostgreSQL always
+// enforces foreign keys, so it never has orphaned rows.
+func (s *Service) CheckConsistency(ctx context.Context) (*models.ConsistencyReport, error) {
+	report := &models.ConsistencyReport{Orphans: []models.OrphanedRows{}}
+	if s.dialect != dialect.SQLite {
+		report.ForeignKeys = true
+		report.Consistent = true
+		return report, nil
+	}
+
+	if err := s.db.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&report.ForeignKeys); err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c."table", l."from", c.parent, COUNT(*)
+		FROM pragma_foreign_key_check AS c
+		JOIN pragma_foreign_key_list(c."table") AS l ON l.id = c.fkid
+		GROUP BY c."table", l."from", c.parent
+		ORDER BY c."table", l."from"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for orphaned rows: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orphans models.OrphanedRows
+		if err := rows.Scan(&orphans.Table, &orphans.Column, &orphans.Parent, &orphans.Rows); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned rows: %v", err)
+		}
+		report.Orphans = append(report.Orphans, orphans)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check for orphaned rows: %v", err)
+	}
+
+	report.Consistent = report.ForeignKeys && len(report.Orphans) == 0
+	return report, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/cache"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"log"
+	"strconv"
+	"time"
+)
+
+// statsCacheTTL is how long dashboard statistics are cached. Reviews and
+// new words invalidate them sooner; other changes, such as deleting a word,
+// show once they expire.
+const statsCacheTTL = time.Minute
+
+// allUsers stands for every user when invalidating statistics
+const allUsers int64 = 0
+
+// Cache returns the cache the service keeps dashboard statistics in, which
+// the server also keeps idempotency keys and rate limits in
+func (s *Service) Cache() cache.Cache {
+	return s.cache
+}
+
+// statsVersionKey is the key of the version of a user's statistics, or of
+// everyone's for allUsers. Cached statistics are keyed by both versions,
+// so changing either invalidates them.
+func statsVersionKey(userID int64) string {
+	return fmt.Sprintf("stats_version:%d", userID)
+}
+
+// statsVersion returns the version of a user's statistics, "0" if it was
+// never invalidated
+func (s *Service) statsVersion(ctx context.Context, userID int64) (string, error) {
+	version, err := s.cache.Get(ctx, statsVersionKey(userID))
+	if err == cache.ErrMiss {
+		return "0", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(version), nil
+}
+
+// invalidateStats invalidates the cached statistics of a user, or of
+// everyone for allUsers
+func (s *Service) invalidateStats(ctx context.Context, userID int64) {
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := s.cache.Set(ctx, statsVersionKey(userID), []byte(version), 0); err != nil {
+		log.Printf("Failed to invalidate cached statistics: %v", err)
+	}
+}
+
+// cachedStats fills out, a pointer to a statistic, from the cache under
+// name, or else by compute, which fills it and is then cached. Statistics
+// are cached apart for each language the service is scoped to. A cache that
+// can't be reached only costs the computation.
+func (s *Service) cachedStats(ctx context.Context, name string, out interface{}, compute func() error) error {
+	everyone, err := s.statsVersion(ctx, allUsers)
+	var user string
+	if err == nil {
+		user, err = s.statsVersion(ctx, s.userID)
+	}
+	if err != nil {
+		log.Printf("Failed to read cached statistics: %v", err)
+		return compute()
+	}
+
+	key := fmt.Sprintf("stats:%d:%s:%s:%s:%s", s.userID, everyone, user, s.language, name)
+	if data, err := s.cache.Get(ctx, key); err == nil && json.Unmarshal(data, out) == nil {
+		return nil
+	}
+	if err := compute(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil
+	}
+	if err := s.cache.Set(ctx, key, data, statsCacheTTL); err != nil {
+		log.Printf("Failed to cache statistics: %v", err)
+	}
+	return nil
+}
+
+// rangeKey names a date range in cache keys. Presets are named by their
+// preset rather than their moving bounds.
+func rangeKey(r models.DateRange) string {
+	if r.Preset != "" {
+		return r.Preset
+	}
+	from, to := rangeBounds(r)
+	return from + "/" + to
+}
+
+// subscribeDashboardCache invalidates cached statistics when a word is
+// reviewed or added
+func (s *Service) subscribeDashboardCache() {
+	s.events.Subscribe(events.NameWordReviewed, func(ctx context.Context, event events.Event) {
+		s.invalidateStats(ctx, event.(events.WordReviewed).UserID)
+	})
+	s.events.Subscribe(events.NameSessionCompleted, func(ctx context.Context, event events.Event) {
+		s.invalidateStats(ctx, event.(events.SessionCompleted).UserID)
+	})
+	// Every user's dashboard counts the words available
+	s.events.Subscribe(events.NameWordCreated, func(ctx context.Context, event events.Event) {
+		s.invalidateStats(ctx, allUsers)
+	})
+}
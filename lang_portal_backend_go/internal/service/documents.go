@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/docsum"
+	"lang_portal/internal/groupgen"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"lang_portal/internal/translate"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxDocumentText is the longest text, in characters, that is
+	// summarized for its vocabulary
+	maxDocumentText = 100000
+	// maxDocumentTerms is how many unfamiliar words of a document are listed
+	maxDocumentTerms = 100
+)
+
+// DocumentSource is what ImportDocument summarizes: a web page at URL, Text,
+// or the file Data named Filename. Exactly one is given.
+type DocumentSource struct {
+	URL      string
+	Text     string
+	Filename string
+	Data     []byte
+}
+
+// docsumClient returns the summarization service configured by the
+// environment
+func docsumClient() (*docsum.Client, error) {
+	client, err := docsum.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid summarization settings: %v", err)
+	}
+	if client == nil {
+		return nil, unsupported("no summarization service is configured, set %s", docsum.URLEnv)
+	}
+	return client, nil
+}
+
+// ImportDocument has the docsum service summarize a document in English and
+// Urdu, ranks the Urdu words of the summary the user doesn't know yet by how
+// often they appear, and drafts a group of the first size of them, 0 for
+// DefaultGroupSize, named name or after the document. Words that exist are
+// drafted as they are; the others are translated by the configured
+// translation provider, and dropped from the draft if that fails. A word is
+// known once the user has recalled it in a review since they last forgot it.
+func (s *Service) ImportDocument(ctx context.Context, source DocumentSource, name string, size int) (*models.DocumentImport, error) {
+	source.URL = strings.TrimSpace(source.URL)
+	source.Text = strings.TrimSpace(source.Text)
+	given := 0
+	for _, ok := range []bool{source.URL != "", source.Text != "", len(source.Data) > 0} {
+		if ok {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, invalid("give one of url, text or file")
+	}
+	topic := "Pasted text"
+	switch {
+	case source.URL != "":
+		u, err := url.Parse(source.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, invalid("url must be an http or https URL")
+		}
+		topic = u.Host + strings.TrimSuffix(u.Path, "/")
+	case source.Text != "":
+		if utf8.RuneCountInString(source.Text) > maxDocumentText {
+			return nil, invalid("text must be at most %d characters", maxDocumentText)
+		}
+	default:
+		if source.Filename = strings.TrimSpace(source.Filename); source.Filename == "" {
+			source.Filename = "document"
+		}
+		topic = source.Filename
+	}
+	if utf8.RuneCountInString(topic) > maxTopicLength {
+		topic = string([]rune(topic)[:maxTopicLength])
+	}
+	if size == 0 {
+		size = DefaultGroupSize
+	}
+	if size < 1 || size > groupgen.MaxSize {
+		return nil, invalid("size must be between 1 and %d", groupgen.MaxSize)
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		if err := s.checkGroupName(ctx, s.db, DefaultLanguage, name); err != nil {
+			return nil, err
+		}
+	} else {
+		name = "Words from " + topic
+	}
+
+	client, err := docsumClient()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := translateProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	var summary *docsum.Summary
+	switch {
+	case source.URL != "":
+		summary, err = client.Summarize(ctx, docsum.URL, source.URL)
+	case source.Text != "":
+		summary, err = client.Summarize(ctx, docsum.Text, source.Text)
+	default:
+		summary, err = client.SummarizeFile(ctx, source.Filename, source.Data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize document: %v", err)
+	}
+
+	known, err := s.knownWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.DocumentImport{
+		Summary:     summary.Summary,
+		UrduSummary: summary.TranslatedSummary,
+		Terms:       []models.DocumentTerm{},
+	}
+	var (
+		words   []models.DraftWord
+		dropped []models.DroppedWord
+	)
+	for _, term := range docsum.Terms(summary.TranslatedSummary) {
+		if known[term.Key] {
+			result.KnownWords++
+			continue
+		}
+		if len(result.Terms) == maxDocumentTerms {
+			continue
+		}
+		word := models.DraftWord{Urdu: term.Urdu}
+		err := s.db.QueryRowContext(ctx, `
+			SELECT id, transliteration, english FROM words WHERE script = ? AND language = ? ORDER BY id LIMIT 1
+		`, term.Urdu, DefaultLanguage).Scan(&word.WordID, &word.Urdlish, &word.English)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to look up word: %v", err)
+		}
+		result.Terms = append(result.Terms, models.DocumentTerm{Urdu: term.Urdu, Occurrences: term.Count, WordID: word.WordID})
+		if len(words)+len(dropped) == size {
+			continue
+		}
+		if word.WordID == 0 {
+			suggestion, _, err := s.suggest(ctx, provider, translate.Urdu, term.Urdu)
+			if err != nil {
+				dropped = append(dropped, models.DroppedWord{DraftWord: word, Reason: err.Error()})
+				continue
+			}
+			word.Urdlish, word.English = suggestion.Urdlish, suggestion.English
+		}
+		words = append(words, word)
+	}
+	if len(words)+len(dropped) == 0 {
+		return result, nil
+	}
+
+	// Words translated alike, or missing a field, are dropped like those of
+	// generated groups
+	kept, invalidWords := groupgen.Clean(words, size)
+	dropped = append(dropped, invalidWords...)
+	if err := linkExistingWords(ctx, s.db, DefaultLanguage, kept); err != nil {
+		return nil, err
+	}
+	wordsJSON, err := json.Marshal(kept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode draft words: %v", err)
+	}
+	droppedJSON, err := json.Marshal(dropped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dropped words: %v", err)
+	}
+	var id int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO group_drafts (user_id, topic, name, status, provider, words, dropped, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, s.userID, topic, name, models.DraftPending, "docsum+"+provider.Name(),
+		string(wordsJSON), string(droppedJSON), time.Now().UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save group draft: %v", err)
+	}
+	if result.Draft, err = s.GetGroupDraft(ctx, id); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// knownWords returns the normalized urdu of the Urdu words the user knows
+func (s *Service) knownWords(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.script
+		FROM word_learning_state wls
+		JOIN words w ON w.id = wls.word_id
+		WHERE wls.user_id = ? AND wls.repetitions > 0 AND w.language = ?
+	`, s.userID, DefaultLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get known words: %v", err)
+	}
+	defer rows.Close()
+
+	known := map[string]bool{}
+	for rows.Next() {
+		var urdu string
+		if err := rows.Scan(&urdu); err != nil {
+			return nil, fmt.Errorf("failed to scan known word: %v", err)
+		}
+		known[spelling.Normalize(urdu)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating known words: %v", err)
+	}
+	return known, nil
+}
@@ -0,0 +1,725 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/embedding"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Search modes
+const (
+	// SearchText matches words whose fields contain the query
+	SearchText = "text"
+	// SearchSemantic matches words whose embeddings are nearest the
+	// query's, so "vehicle" finds "car"
+	SearchSemantic = "semantic"
+)
+
+// Limits of searches and similar words
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// maxSearchQueryLength is the longest query words can be searched for
+const maxSearchQueryLength = 200
+
+// embeddingBatchSize is how many words' embeddings are asked for at once
+const embeddingBatchSize = 100
+
+// embeddingQueueDelay is how long words created or changed wait before
+// their embeddings are queued, so words changed together, like those of an
+// import, are embedded by one job in batches
+const embeddingQueueDelay = 5 * time.Second
+
+// embeddingBatchInterval is the least time between batches of embeddings
+// asked of the provider, so embedding many words keeps within its rate
+// limits
+const embeddingBatchInterval = time.Second
+
+// indexMaxAge is how long an index of embeddings is searched before it's
+// loaded again, picking up embeddings stored by other servers sharing the
+// database
+const indexMaxAge = 10 * time.Minute
+
+// embeddingProvider returns the embedding provider configured by the
+// environment
+func embeddingProvider() (embedding.Provider, error) {
+	provider, err := embedding.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid embedding settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no embedding provider is configured, set %s", embedding.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// embeddingText is the text of a word whose embedding is computed
+func embeddingText(word *models.WordResponse) string {
+	return fmt.Sprintf("%s (%s, %s)", word.English, word.Urdu, word.Urdlish)
+}
+
+// embeddingTextSQL is embeddingText of the words w, in SQL
+const embeddingTextSQL = `w.english || ' (' || w.script || ', ' || w.transliteration || ')'`
+
+// embeddingStaleReason returns why a word's embedding needs computing
+// again, or "" if it is current. embedded is whether the word has an
+// embedding, of model and text. An embedding of any model is current when
+// no provider is configured, currentModel being "".
+func embeddingStaleReason(embedded bool, model, text, currentModel, currentText string) string {
+	switch {
+	case !embedded:
+		return models.EmbeddingMissing
+	case currentModel != "" && model != currentModel:
+		return models.EmbeddingOtherModel
+	case text != currentText:
+		return models.EmbeddingTextChanged
+	}
+	return ""
+}
+
+// configuredEmbeddingModel returns the model of the configured embedding
+// provider, or "" if there is none
+func configuredEmbeddingModel() string {
+	provider, err := embeddingProvider()
+	if err != nil {
+		return ""
+	}
+	return provider.Name()
+}
+
+// embeddingQueue holds the words created or changed since their embeddings
+// were last queued
+type embeddingQueue struct {
+	mu      sync.Mutex
+	wordIDs map[int64]bool
+}
+
+// add puts a word in the queue and reports whether the queue was empty
+func (q *embeddingQueue) add(wordID int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.wordIDs == nil {
+		q.wordIDs = map[int64]bool{}
+	}
+	q.wordIDs[wordID] = true
+	return len(q.wordIDs) == 1
+}
+
+// take empties the queue and returns the words it held, in ID order
+func (q *embeddingQueue) take() []int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	wordIDs := make([]int64, 0, len(q.wordIDs))
+	for id := range q.wordIDs {
+		wordIDs = append(wordIDs, id)
+	}
+	q.wordIDs = nil
+	sort.Slice(wordIDs, func(i, j int) bool { return wordIDs[i] < wordIDs[j] })
+	return wordIDs
+}
+
+func (q *embeddingQueue) has(wordID int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.wordIDs[wordID]
+}
+
+func (q *embeddingQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.wordIDs)
+}
+
+// wordIndex holds the embeddings of one model in memory, for searching
+// them. It's loaded when first searched and kept up to date as this server
+// stores embeddings.
+type wordIndex struct {
+	mu       sync.Mutex
+	model    string
+	index    *embedding.Index
+	loadedAt time.Time
+}
+
+// add indexes a word's new embedding, if the index is loaded
+func (x *wordIndex) add(model string, wordID int64, vector embedding.Vector) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.index == nil {
+		return
+	}
+	if x.model != model {
+		x.index.Remove(wordID)
+		return
+	}
+	if err := x.index.Add(wordID, vector); err != nil {
+		x.index.Remove(wordID)
+	}
+}
+
+// reset drops the index, to be loaded again when next searched
+func (x *wordIndex) reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.index = nil
+}
+
+// searchIndex returns the index of the embeddings of model, loading it if
+// it isn't loaded or is too old. Embeddings whose dimensions differ from
+// the model's first are left out.
+func (s *Service) searchIndex(ctx context.Context, model string) (*embedding.Index, error) {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if s.index.index != nil && s.index.model == model && time.Since(s.index.loadedAt) < indexMaxAge {
+		return s.index.index, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT word_id, vector FROM word_embeddings WHERE model = ? ORDER BY word_id
+	`, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %v", err)
+	}
+	defer rows.Close()
+
+	index := embedding.NewIndex()
+	for rows.Next() {
+		var (
+			id     int64
+			data   string
+			vector embedding.Vector
+		)
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %v", err)
+		}
+		if err := json.Unmarshal([]byte(data), &vector); err != nil {
+			return nil, fmt.Errorf("failed to decode embedding: %v", err)
+		}
+		if err := index.Add(id, vector); err != nil {
+			log.Printf("Skipping embedding of word %d: %v", id, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating embeddings: %v", err)
+	}
+
+	s.index.model, s.index.index, s.index.loadedAt = model, index, time.Now()
+	return index, nil
+}
+
+// subscribeEmbeddings queues computing the embeddings of words as they are
+// created or changed, if an embedding provider is configured. Words wait
+// embeddingQueueDelay in the queue, so those created or changed together
+// are embedded by one job.
+func (s *Service) subscribeEmbeddings() {
+	queue := func(wordID int64) {
+		if _, err := embeddingProvider(); err != nil {
+			return
+		}
+		if s.embedQueue.add(wordID) {
+			time.AfterFunc(embeddingQueueDelay, s.flushEmbeddings)
+		}
+	}
+	s.events.Subscribe(events.NameWordCreated, func(ctx context.Context, event events.Event) {
+		queue(event.(events.WordCreated).WordID)
+	})
+	s.events.Subscribe(events.NameWordUpdated, func(ctx context.Context, event events.Event) {
+		queue(event.(events.WordUpdated).WordID)
+	})
+}
+
+// flushEmbeddings queues a job computing the embeddings of the words in
+// the queue. Words left in it when the service closes keep their stale
+// embeddings until they are next embedded.
+func (s *Service) flushEmbeddings() {
+	select {
+	case <-s.stop:
+		return
+	default:
+	}
+	wordIDs := s.embedQueue.take()
+	if len(wordIDs) == 0 {
+		return
+	}
+	if _, err := s.runner.Enqueue(context.Background(), 0, JobEmbedWords, embedWordsJob{WordIDs: wordIDs}); err != nil {
+		log.Printf("Failed to queue embedding of %d words: %v", len(wordIDs), err)
+	}
+}
+
+// SetWordEmbedding stores a precomputed embedding for a word, replacing any
+// previous one
+func (s *Service) SetWordEmbedding(ctx context.Context, wordID int64, model string, vector embedding.Vector) error {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(model) == "" {
+		return invalid("invalid embedding: model is required")
+	}
+	if err := vector.Validate(); err != nil {
+		return invalid("invalid embedding: %v", err)
+	}
+
+	// The embedding is taken to be of the word as it is now
+	var text string
+	err := s.db.QueryRowContext(ctx, `SELECT `+embeddingTextSQL+` FROM words w WHERE w.id = ?`, wordID).Scan(&text)
+	if err != nil {
+		return fmt.Errorf("failed to get word: %v", err)
+	}
+	return s.saveWordEmbedding(ctx, wordID, model, text, vector)
+}
+
+// saveWordEmbedding stores a word's embedding, computed from text, and
+// indexes it
+func (s *Service) saveWordEmbedding(ctx context.Context, wordID int64, model, text string, vector embedding.Vector) error {
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode embedding: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO word_embeddings (word_id, model, dimensions, vector, source_text, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(word_id) DO UPDATE SET
+			model = excluded.model,
+			dimensions = excluded.dimensions,
+			vector = excluded.vector,
+			source_text = excluded.source_text,
+			updated_at = excluded.updated_at
+	`, wordID, model, len(vector), string(data), text)
+	if err != nil {
+		return fmt.Errorf("failed to save embedding: %v", err)
+	}
+	s.index.add(model, wordID, vector)
+	return nil
+}
+
+// GetSimilarWords ranks candidates by how close their embeddings are to the
+// word's and returns up to limit of them. Only embeddings from the same model
+// are compared. It returns no words if the word has no embedding, so callers
+// can fall back to another strategy.
+func (s *Service) GetSimilarWords(ctx context.Context, wordID int64, candidates []models.WordResponse, limit int) ([]models.WordResponse, error) {
+	var (
+		model string
+		data  string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT model, vector FROM word_embeddings WHERE word_id = ?
+	`, wordID).Scan(&model, &data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding: %v", err)
+	}
+
+	var query embedding.Vector
+	if err := json.Unmarshal([]byte(data), &query); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %v", err)
+	}
+
+	byID := make(map[int64]models.WordResponse, len(candidates))
+	for _, w := range candidates {
+		if w.ID != wordID {
+			byID[w.ID] = w
+		}
+	}
+	if len(byID) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT word_id, vector FROM word_embeddings
+		WHERE model = ? AND dimensions = ? AND word_id != ?
+	`, model, len(query), wordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %v", err)
+	}
+	defer rows.Close()
+
+	vectors := make(map[int64]embedding.Vector)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %v", err)
+		}
+		if _, ok := byID[id]; !ok {
+			continue
+		}
+		var v embedding.Vector
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			return nil, fmt.Errorf("failed to decode embedding: %v", err)
+		}
+		vectors[id] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating embeddings: %v", err)
+	}
+
+	matches := embedding.Nearest(query, vectors, limit)
+	similar := make([]models.WordResponse, len(matches))
+	for i, m := range matches {
+		similar[i] = byID[m.ID]
+	}
+	return similar, nil
+}
+
+// EnqueueWordEmbeddings queues computing the embeddings of every word
+// without one from the configured provider, or of every word if force is
+// set. Only admins can embed every word.
+func (s *Service) EnqueueWordEmbeddings(ctx context.Context, force bool) (*models.Job, error) {
+	if err := s.CheckAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := embeddingProvider(); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobEmbedWords, embedWordsJob{Force: force})
+}
+
+// EmbedWords computes and stores the embeddings of the given words, or, if
+// none are given, of every word, with the configured provider. Words whose
+// embeddings are current, of the provider's model and the word's text, are
+// left alone unless force is set. Words are sent to the provider in
+// batches, embeddingBatchInterval apart. Words that fail are reported
+// rather than failing the rest, unless every word fails.
+func (s *Service) EmbedWords(ctx context.Context, force bool, wordIDs []int64) (*models.EmbedWordsResult, error) {
+	provider, err := embeddingProvider()
+	if err != nil {
+		return nil, err
+	}
+	model := provider.Name()
+
+	result := &models.EmbedWordsResult{Model: model, Failed: []models.WordFailure{}}
+	var words []*models.WordResponse
+	if len(wordIDs) > 0 {
+		for _, id := range wordIDs {
+			// Embeddings are shared, so every word is embedded whoever sees it
+			word, err := s.words.Get(ctx, s.userID, 0, id)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get word: %v", err)
+			}
+			result.Words++
+			if !force {
+				var embeddedModel, text string
+				err := s.db.QueryRowContext(ctx, `
+					SELECT model, source_text FROM word_embeddings WHERE word_id = ?
+				`, id).Scan(&embeddedModel, &text)
+				if err != nil && err != sql.ErrNoRows {
+					return nil, fmt.Errorf("failed to get embedding: %v", err)
+				}
+				if embeddingStaleReason(err == nil, embeddedModel, text, model, embeddingText(word)) == "" {
+					result.Current++
+					continue
+				}
+			}
+			words = append(words, word)
+		}
+	} else {
+		query := `SELECT w.id, w.script, w.transliteration, w.english FROM words w
+			LEFT JOIN word_embeddings e ON e.word_id = w.id
+			WHERE e.word_id IS NULL OR e.model != ? OR e.source_text != ` + embeddingTextSQL + `
+			ORDER BY w.id`
+		args := []interface{}{model}
+		if force {
+			query, args = `SELECT id, script, transliteration, english FROM words ORDER BY id`, nil
+		}
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get words: %v", err)
+		}
+		for rows.Next() {
+			var word models.WordResponse
+			if err := rows.Scan(&word.ID, &word.Urdu, &word.Urdlish, &word.English); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan word: %v", err)
+			}
+			words = append(words, &word)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating words: %v", err)
+		}
+		if !force {
+			if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM words`).Scan(&result.Words); err != nil {
+				return nil, fmt.Errorf("failed to count words: %v", err)
+			}
+			result.Current = result.Words - len(words)
+		} else {
+			result.Words = len(words)
+		}
+	}
+
+	var lastErr error
+	for start := 0; start < len(words); start += embeddingBatchSize {
+		if start > 0 {
+			select {
+			case <-time.After(embeddingBatchInterval):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		batch := words[start:min(start+embeddingBatchSize, len(words))]
+		texts := make([]string, len(batch))
+		for i, word := range batch {
+			texts[i] = embeddingText(word)
+		}
+		vectors, err := provider.Embed(ctx, texts)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to compute embeddings: %v", err)
+			for _, word := range batch {
+				result.Failed = append(result.Failed, models.WordFailure{WordID: word.ID, Error: lastErr.Error()})
+			}
+			continue
+		}
+		for i, word := range batch {
+			if err := s.saveWordEmbedding(ctx, word.ID, model, texts[i], vectors[i]); err != nil {
+				lastErr = err
+				result.Failed = append(result.Failed, models.WordFailure{WordID: word.ID, Error: err.Error()})
+				continue
+			}
+			result.Embedded++
+		}
+	}
+	if len(words) > 0 && len(result.Failed) == len(words) {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+// GetWordEmbeddingStatus describes a word's embedding and whether it is
+// stale for the configured provider
+func (s *Service) GetWordEmbeddingStatus(ctx context.Context, wordID int64) (*models.WordEmbeddingStatus, error) {
+	status := &models.WordEmbeddingStatus{WordID: wordID}
+	var (
+		model, text sql.NullString
+		dimensions  sql.NullInt64
+		updatedAt   sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+embeddingTextSQL+`, e.model, e.dimensions, e.source_text, e.updated_at
+		FROM words w
+		LEFT JOIN word_embeddings e ON e.word_id = w.id
+		WHERE w.id = ?
+	`, wordID).Scan(&status.CurrentText, &model, &dimensions, &text, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding: %v", err)
+	}
+	status.Model, status.Dimensions, status.Text = model.String, int(dimensions.Int64), text.String
+	if updatedAt.Valid {
+		status.UpdatedAt = &updatedAt.Time
+	}
+	status.StaleReason = embeddingStaleReason(model.Valid, model.String, text.String, configuredEmbeddingModel(), status.CurrentText)
+	status.Stale = status.StaleReason != ""
+	status.Queued = s.embedQueue.has(wordID)
+	return status, nil
+}
+
+// GetEmbeddingStatus counts the words by whether their embeddings are
+// current for the configured provider
+func (s *Service) GetEmbeddingStatus(ctx context.Context) (*models.EmbeddingStatus, error) {
+	status := &models.EmbeddingStatus{Model: configuredEmbeddingModel(), Queued: s.embedQueue.len()}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN e.word_id IS NULL THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN e.word_id IS NOT NULL AND ?1 != '' AND e.model != ?1 THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN e.word_id IS NOT NULL AND (?1 = '' OR e.model = ?1)
+		                         AND e.source_text != `+embeddingTextSQL+` THEN 1 ELSE 0 END), 0)
+		FROM words w
+		LEFT JOIN word_embeddings e ON e.word_id = w.id
+	`, status.Model).Scan(&status.Words, &status.Missing, &status.OtherModel, &status.TextChanged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embeddings: %v", err)
+	}
+	status.Current = status.Words - status.Missing - status.OtherModel - status.TextChanged
+	return status, nil
+}
+
+// checkSearchLimit checks the number of words asked for, 0 meaning the
+// default
+func checkSearchLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultSearchLimit, nil
+	}
+	if limit < 1 || limit > MaxSearchLimit {
+		return 0, invalid("limit must be between 1 and %d", MaxSearchLimit)
+	}
+	return limit, nil
+}
+
+// SimilarWords returns up to limit words whose embeddings are nearest the
+// word's, among the words embedded by the same model. A word without an
+// embedding has one computed by the configured provider first.
+func (s *Service) SimilarWords(ctx context.Context, wordID int64, limit int) ([]models.WordMatch, error) {
+	limit, err := checkSearchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	word, err := s.getWord(ctx, wordID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		model string
+		data  string
+		query embedding.Vector
+	)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT model, vector FROM word_embeddings WHERE word_id = ?
+	`, wordID).Scan(&model, &data)
+	switch {
+	case err == sql.ErrNoRows:
+		provider, err := embeddingProvider()
+		if err != nil {
+			return nil, err
+		}
+		vectors, err := provider.Embed(ctx, []string{embeddingText(word)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute embedding: %v", err)
+		}
+		model, query = provider.Name(), vectors[0]
+		if err := s.saveWordEmbedding(ctx, wordID, model, embeddingText(word), query); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get embedding: %v", err)
+	default:
+		if err := json.Unmarshal([]byte(data), &query); err != nil {
+			return nil, fmt.Errorf("failed to decode embedding: %v", err)
+		}
+	}
+
+	index, err := s.searchIndex(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	return s.wordMatches(ctx, index.Search(query, limit+1), wordID, limit)
+}
+
+// SearchWords returns up to limit words matching a query. Text searches
+// match the words whose Urdu, Urdlish or English contains the query, exact
+// matches first, then those starting with it. Semantic searches compute the
+// query's embedding with the configured provider and return the words
+// nearest it.
+func (s *Service) SearchWords(ctx context.Context, query, mode string, limit int) ([]models.WordMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, invalid("q is required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, invalid("q must be at most %d characters", maxSearchQueryLength)
+	}
+	limit, err := checkSearchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case "", SearchText:
+		return s.searchWordsText(ctx, query, limit)
+	case SearchSemantic:
+		provider, err := embeddingProvider()
+		if err != nil {
+			return nil, err
+		}
+		vectors, err := provider.Embed(ctx, []string{query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute embedding: %v", err)
+		}
+		index, err := s.searchIndex(ctx, provider.Name())
+		if err != nil {
+			return nil, err
+		}
+		return s.wordMatches(ctx, index.Search(vectors[0], limit), 0, limit)
+	default:
+		return nil, invalid("mode must be %s or %s", SearchText, SearchSemantic)
+	}
+}
+
+// searchWordsText returns the words whose fields or senses contain query
+func (s *Service) searchWordsText(ctx context.Context, query string, limit int) ([]models.WordMatch, error) {
+	exact := strings.ToLower(query)
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(exact)
+	prefix, contains := escaped+"%", "%"+escaped+"%"
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id FROM words w
+		WHERE (lower(w.english) LIKE ?1 ESCAPE '\' OR lower(w.transliteration) LIKE ?1 ESCAPE '\' OR w.script LIKE ?1 ESCAPE '\'
+			OR w.id IN (SELECT word_id FROM word_senses WHERE lower(english) LIKE ?1 ESCAPE '\'))
+			AND `+repository.VisibleWord("?6")+`
+		ORDER BY
+			CASE
+				WHEN lower(w.english) = ?2 OR lower(w.transliteration) = ?2 OR w.script = ?3 THEN 0
+				WHEN lower(w.english) LIKE ?4 ESCAPE '\' OR lower(w.transliteration) LIKE ?4 ESCAPE '\' OR w.script LIKE ?4 ESCAPE '\' THEN 1
+				ELSE 2
+			END,
+			w.id
+		LIMIT ?5
+	`, contains, exact, query, prefix, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search words: %v", err)
+	}
+	var matches []embedding.Match
+	for rows.Next() {
+		var match embedding.Match
+		if err := rows.Scan(&match.ID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		matches = append(matches, match)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %v", err)
+	}
+	return s.wordMatches(ctx, matches, 0, limit)
+}
+
+// wordMatches returns the words of up to limit matches, leaving out the
+// word of excludeID, words deleted since they were indexed and words the
+// user can't see
+func (s *Service) wordMatches(ctx context.Context, matches []embedding.Match, excludeID int64, limit int) ([]models.WordMatch, error) {
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	words := []models.WordMatch{}
+	for _, match := range matches {
+		if match.ID == excludeID || len(words) == limit {
+			continue
+		}
+		word, err := s.words.Get(ctx, s.userID, viewerID, match.ID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, models.WordMatch{WordResponse: *word, Similarity: match.Similarity})
+	}
+	return words, nil
+}
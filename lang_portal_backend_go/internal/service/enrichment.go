@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/dictionary"
+	"lang_portal/internal/models"
+	"time"
+)
+
+// dictionaryProvider returns the dictionary configured by the environment
+func dictionaryProvider() (dictionary.Provider, error) {
+	provider, err := dictionary.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid dictionary settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no dictionary is configured, set %s", dictionary.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// GetWordEnrichment returns what a dictionary says about a word, or nil if
+// it hasn't been looked up
+func (s *Service) GetWordEnrichment(ctx context.Context, wordID int64) (*models.WordEnrichment, error) {
+	var (
+		enrichment models.WordEnrichment
+		plurals    string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT provider, plurals, enriched_at FROM word_enrichments WHERE word_id = ?
+	`, wordID).Scan(&enrichment.Provider, &plurals, &enrichment.EnrichedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word enrichment: %v", err)
+	}
+	if err := json.Unmarshal([]byte(plurals), &enrichment.Plurals); err != nil {
+		return nil, fmt.Errorf("failed to decode plurals: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT part_of_speech, definition, examples FROM word_definitions
+		WHERE word_id = ?
+		ORDER BY position
+	`, wordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word definitions: %v", err)
+	}
+	defer rows.Close()
+
+	enrichment.Definitions = []models.WordDefinition{}
+	for rows.Next() {
+		var (
+			definition models.WordDefinition
+			examples   string
+		)
+		if err := rows.Scan(&definition.PartOfSpeech, &definition.Definition, &examples); err != nil {
+			return nil, fmt.Errorf("failed to scan word definition: %v", err)
+		}
+		if err := json.Unmarshal([]byte(examples), &definition.Examples); err != nil {
+			return nil, fmt.Errorf("failed to decode examples: %v", err)
+		}
+		enrichment.Definitions = append(enrichment.Definitions, definition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word definitions: %v", err)
+	}
+	return &enrichment, nil
+}
+
+// EnrichWord looks a word up in the configured dictionary and stores its
+// definitions, plural forms and examples. A word looked up before isn't
+// looked up again unless force is set. A word the dictionary doesn't have
+// is enriched with no definitions.
+func (s *Service) EnrichWord(ctx context.Context, wordID int64, force bool) (*models.WordEnrichment, error) {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return nil, err
+	}
+	provider, err := dictionaryProvider()
+	if err != nil {
+		return nil, err
+	}
+	return s.enrichWord(ctx, provider, wordID, force)
+}
+
+func (s *Service) enrichWord(ctx context.Context, provider dictionary.Provider, wordID int64, force bool) (*models.WordEnrichment, error) {
+	word := models.Word{ID: wordID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT script, transliteration, english FROM words WHERE id = ?
+	`, wordID).Scan(&word.Urdu, &word.Urdlish, &word.English)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word: %v", err)
+	}
+
+	if !force {
+		enrichment, err := s.GetWordEnrichment(ctx, wordID)
+		if err != nil || enrichment != nil {
+			return enrichment, err
+		}
+	}
+
+	entry, err := provider.Lookup(ctx, word)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up word: %v", err)
+	}
+	if entry == nil {
+		entry = &dictionary.Entry{Definitions: []models.WordDefinition{}, Plurals: []string{}}
+	}
+	plurals, err := json.Marshal(entry.Plurals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plurals: %v", err)
+	}
+
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM word_definitions WHERE word_id = ?`, wordID); err != nil {
+			return fmt.Errorf("failed to clear word definitions: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO word_enrichments (word_id, provider, plurals, enriched_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(word_id) DO UPDATE SET
+				provider = excluded.provider,
+				plurals = excluded.plurals,
+				enriched_at = excluded.enriched_at
+		`, wordID, provider.Name(), string(plurals), time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to save word enrichment: %v", err)
+		}
+		for i, definition := range entry.Definitions {
+			examples, err := json.Marshal(definition.Examples)
+			if err != nil {
+				return fmt.Errorf("failed to encode examples: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO word_definitions (word_id, position, part_of_speech, definition, examples)
+				VALUES (?, ?, ?, ?, ?)
+			`, wordID, i, definition.PartOfSpeech, definition.Definition, string(examples)); err != nil {
+				return fmt.Errorf("failed to save word definition: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWordEnrichment(ctx, wordID)
+}
+
+// EnqueueWordEnrichment queues looking up every word not yet looked up in
+// the configured dictionary, or every word if force is set. Only admins can
+// enrich every word.
+func (s *Service) EnqueueWordEnrichment(ctx context.Context, force bool) (*models.Job, error) {
+	if err := s.CheckAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := dictionaryProvider(); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobEnrichWords, enrichWordsJob{Force: force})
+}
+
+// EnrichWords looks up every word not yet looked up, or every word if force
+// is set, as EnrichWord does. Words that fail are reported rather than
+// failing the rest, unless every word fails.
+func (s *Service) EnrichWords(ctx context.Context, force bool) (*models.EnrichWordsResult, error) {
+	provider, err := dictionaryProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id FROM words WHERE id NOT IN (SELECT word_id FROM word_enrichments) ORDER BY id`
+	if force {
+		query = `SELECT id FROM words ORDER BY id`
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words: %v", err)
+	}
+	var wordIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		wordIDs = append(wordIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %v", err)
+	}
+
+	result := &models.EnrichWordsResult{Words: len(wordIDs), Failed: []models.WordFailure{}}
+	var lastErr error
+	for _, id := range wordIDs {
+		enrichment, err := s.enrichWord(ctx, provider, id, true)
+		if err != nil {
+			lastErr = err
+			result.Failed = append(result.Failed, models.WordFailure{WordID: id, Error: err.Error()})
+			continue
+		}
+		if len(enrichment.Definitions) == 0 {
+			result.NotFound++
+		} else {
+			result.Enriched++
+		}
+	}
+	if len(wordIDs) > 0 && len(result.Failed) == len(wordIDs) {
+		return nil, lastErr
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of service errors, matched with errors.Is. Handlers map each kind
+// to a status code.
+var (
+	// ErrNotFound means a resource doesn't exist or isn't the user's
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means a request conflicts with the resource's state
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means a request is invalid
+	ErrValidation = errors.New("invalid request")
+	// ErrForbidden means the user may not do what was requested
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized means the user's credentials are wrong
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnsupported means the database doesn't support the request
+	ErrUnsupported = errors.New("unsupported")
+)
+
+// ErrChecksumMismatch means a fetched catalog doesn't match the configured
+// checksum
+var ErrChecksumMismatch = invalid("catalog checksum mismatch")
+
+// Error is a service error of a kind. Its message is shown to clients.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind of the error, so errors.Is matches it
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return newError(ErrNotFound, format, args...)
+}
+
+func conflict(format string, args ...interface{}) error {
+	return newError(ErrConflict, format, args...)
+}
+
+func invalid(format string, args ...interface{}) error {
+	return newError(ErrValidation, format, args...)
+}
+
+func forbidden(format string, args ...interface{}) error {
+	return newError(ErrForbidden, format, args...)
+}
+
+func unauthorized(format string, args ...interface{}) error {
+	return newError(ErrUnauthorized, format, args...)
+}
+
+func unsupported(format string, args ...interface{}) error {
+	return newError(ErrUnsupported, format, args...)
+}
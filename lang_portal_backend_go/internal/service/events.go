@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"time"
+)
+
+// Events returns the bus the service publishes its events on, for side
+// effects to subscribe to
+func (s *Service) Events() *events.Bus {
+	return s.events
+}
+
+// publishAfterCommit publishes an event once tx is committed, so
+// subscribers never see what was rolled back
+func (s *Service) publishAfterCommit(ctx context.Context, tx *models.Tx, event events.Event) {
+	tx.AfterCommit(func() {
+		s.events.Publish(ctx, event)
+	})
+}
+
+// completesSession reports whether reviewing a word leaves none of a
+// session's words unreviewed, i.e. the word is the last of them not yet
+// reviewed. It must be called before the review is recorded.
+func completesSession(ctx context.Context, tx *models.Tx, sessionID, wordID int64) (bool, error) {
+	var unreviewed, pending int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN ssw.word_id = ?2 THEN 1 ELSE 0 END), 0)
+		FROM study_session_words ssw
+		WHERE ssw.study_session_id = ?1
+		AND NOT EXISTS (
+			SELECT 1 FROM word_review_items r
+			WHERE r.study_session_id = ?1 AND r.word_id = ssw.word_id
+		)
+	`, sessionID, wordID).Scan(&unreviewed, &pending)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session progress: %v", err)
+	}
+	return unreviewed == 1 && pending == 1, nil
+}
+
+// sessionCompleted returns the event of a session whose words have all been
+// reviewed
+func (s *Service) sessionCompleted(ctx context.Context, tx *models.Tx, sessionID int64, at time.Time) (events.SessionCompleted, error) {
+	event := events.SessionCompleted{UserID: s.userID, SessionID: sessionID, At: at}
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN r.correct THEN 1 ELSE 0 END), 0)
+		FROM study_session_words ssw
+		LEFT JOIN word_review_items r ON r.study_session_id = ssw.study_session_id AND r.word_id = ssw.word_id
+		WHERE ssw.study_session_id = ?
+	`, sessionID).Scan(&event.Words, &event.Correct)
+	if err != nil {
+		return event, fmt.Errorf("failed to score session: %v", err)
+	}
+	return event, nil
+}
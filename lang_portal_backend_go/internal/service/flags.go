@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"regexp"
+	"time"
+)
+
+// flagNamePattern is what feature flag names look like, e.g. fsrs_scheduler
+var flagNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// validateFlagName checks a feature flag's name
+func validateFlagName(name string) error {
+	if !flagNamePattern.MatchString(name) {
+		return invalid("invalid feature flag name %q: use up to 64 lowercase letters, digits and underscores", name)
+	}
+	return nil
+}
+
+// FeatureFlags returns whether each feature flag is on for the user: their
+// override if they have one, or else the deployment's setting. Flags that
+// don't exist are off.
+func (s *Service) FeatureFlags(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.name, COALESCE(u.enabled, f.enabled)
+		FROM feature_flags f
+		LEFT JOIN feature_flag_users u ON u.flag_name = f.name AND u.user_id = ?
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flags: %v", err)
+	}
+	defer rows.Close()
+
+	flags := map[string]bool{}
+	for rows.Next() {
+		var (
+			name    string
+			enabled bool
+		)
+		if err := rows.Scan(&name, &enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %v", err)
+		}
+		flags[name] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flags: %v", err)
+	}
+	return flags, nil
+}
+
+// ListFeatureFlags returns every feature flag with its user overrides
+func (s *Service) ListFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, description, enabled, updated_at FROM feature_flags ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %v", err)
+	}
+	defer rows.Close()
+
+	flags := []models.FeatureFlag{}
+	byName := map[string]int{}
+	for rows.Next() {
+		flag := models.FeatureFlag{Users: []models.FeatureFlagUser{}}
+		if err := rows.Scan(&flag.Name, &flag.Description, &flag.Enabled, &flag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %v", err)
+		}
+		byName[flag.Name] = len(flags)
+		flags = append(flags, flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flags: %v", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT flag_name, user_id, enabled FROM feature_flag_users ORDER BY flag_name, user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flag users: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name string
+			user models.FeatureFlagUser
+		)
+		if err := rows.Scan(&name, &user.UserID, &user.Enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag user: %v", err)
+		}
+		if i, ok := byName[name]; ok {
+			flags[i].Users = append(flags[i].Users, user)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flag users: %v", err)
+	}
+	return flags, nil
+}
+
+// GetFeatureFlag returns a feature flag with its user overrides
+func (s *Service) GetFeatureFlag(ctx context.Context, name string) (*models.FeatureFlag, error) {
+	flag := models.FeatureFlag{Users: []models.FeatureFlagUser{}}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, description, enabled, updated_at FROM feature_flags WHERE name = ?
+	`, name).Scan(&flag.Name, &flag.Description, &flag.Enabled, &flag.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("feature flag %q not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, enabled FROM feature_flag_users WHERE flag_name = ? ORDER BY user_id
+	`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag users: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user models.FeatureFlagUser
+		if err := rows.Scan(&user.UserID, &user.Enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag user: %v", err)
+		}
+		flag.Users = append(flag.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flag users: %v", err)
+	}
+	return &flag, nil
+}
+
+// SetFeatureFlag creates a feature flag or changes its description and
+// whether it is on for the deployment. Its user overrides are kept.
+func (s *Service) SetFeatureFlag(ctx context.Context, name, description string, enabled bool) (*models.FeatureFlag, error) {
+	if err := validateFlagName(name); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO feature_flags (name, description, enabled, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			description = excluded.description,
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at
+	`, name, description, enabled, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to set feature flag: %v", err)
+	}
+	return s.GetFeatureFlag(ctx, name)
+}
+
+// DeleteFeatureFlag removes a feature flag and its user overrides, turning
+// it off for everyone
+func (s *Service) DeleteFeatureFlag(ctx context.Context, name string) error {
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM feature_flag_users WHERE flag_name = ?`, name); err != nil {
+			return fmt.Errorf("failed to delete feature flag users: %v", err)
+		}
+		result, err := tx.ExecContext(ctx, `DELETE FROM feature_flags WHERE name = ?`, name)
+		if err != nil {
+			return fmt.Errorf("failed to delete feature flag: %v", err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete feature flag: %v", err)
+		}
+		if deleted == 0 {
+			return notFound("feature flag %q not found", name)
+		}
+		return nil
+	})
+}
+
+// SetFeatureFlagUser turns a feature flag on or off for a user, whatever it
+// is for the deployment
+func (s *Service) SetFeatureFlagUser(ctx context.Context, name string, userID int64, enabled bool) (*models.FeatureFlag, error) {
+	if _, err := s.GetFeatureFlag(ctx, name); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO feature_flag_users (flag_name, user_id, enabled) VALUES (?, ?, ?)
+		ON CONFLICT(flag_name, user_id) DO UPDATE SET enabled = excluded.enabled
+	`, name, userID, enabled); err != nil {
+		return nil, fmt.Errorf("failed to set feature flag user: %v", err)
+	}
+	return s.GetFeatureFlag(ctx, name)
+}
+
+// DeleteFeatureFlagUser removes a user's override of a feature flag, so the
+// deployment's setting applies to them again
+func (s *Service) DeleteFeatureFlagUser(ctx context.Context, name string, userID int64) (*models.FeatureFlag, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM feature_flag_users WHERE flag_name = ? AND user_id = ?
+	`, name, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete feature flag user: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete feature flag user: %v", err)
+	}
+	if deleted == 0 {
+		return nil, notFound("user %d has no override of feature flag %q", userID, name)
+	}
+	return s.GetFeatureFlag(ctx, name)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/db/queries"
+	"lang_portal/internal/models"
+	"lang_portal/internal/srs"
+	"time"
+)
+
+// FlashcardsActivity is the study activity flashcard decks are filed under
+const FlashcardsActivity = "Flashcards"
+
+// CreateFlashcardDeck starts a flashcard session with up to limit words of a
+// group: the words due by the end of today, most overdue first, then words
+// never studied
+func (s *Service) CreateFlashcardDeck(ctx context.Context, groupID int64, limit int) (*models.FlashcardDeck, error) {
+	if limit < 1 {
+		return nil, invalid("invalid limit: %d", limit)
+	}
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, notFound("group not found")
+	}
+
+	now := time.Now().UTC()
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id
+		FROM words_groups wg
+		JOIN words w ON w.id = wg.word_id
+		LEFT JOIN word_learning_state wls ON wls.word_id = w.id AND wls.user_id = ?
+		WHERE wg.group_id = ? AND (wls.word_id IS NULL OR wls.due_at <= ?)
+		ORDER BY wls.word_id IS NULL, wls.due_at, w.id
+		LIMIT ?
+	`, s.userID, groupID, endOfDay, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flashcard words: %v", err)
+	}
+	defer rows.Close()
+
+	var wordIDs []int64
+	for rows.Next() {
+		var wordID int64
+		if err := rows.Scan(&wordID); err != nil {
+			return nil, fmt.Errorf("failed to scan flashcard word: %v", err)
+		}
+		wordIDs = append(wordIDs, wordID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating flashcard words: %v", err)
+	}
+	if len(wordIDs) == 0 {
+		return nil, notFound("no flashcards are due in this group")
+	}
+
+	var sessionID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		sessionID, err = s.startActivitySession(ctx, tx, FlashcardsActivity, groupID, "")
+		if err != nil {
+			return err
+		}
+
+		// Cards are dealt in the order they are inserted
+		for _, wordID := range wordIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO study_session_words (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, wordID); err != nil {
+				return fmt.Errorf("failed to add word to study session: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO flashcards (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, wordID); err != nil {
+				return fmt.Errorf("failed to add flashcard: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetFlashcardDeck(ctx, sessionID)
+}
+
+// GetFlashcardDeck returns a flashcard session and its cards in the order
+// they are dealt
+func (s *Service) GetFlashcardDeck(ctx context.Context, sessionID int64) (*models.FlashcardDeck, error) {
+	row, err := s.queries.GetFlashcardDeck(ctx, queries.GetFlashcardDeckParams{
+		ID:     sessionID,
+		Name:   FlashcardsActivity,
+		UserID: s.userID,
+	})
+	if err == sql.ErrNoRows {
+		return nil, notFound("flashcard deck not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flashcard deck: %v", err)
+	}
+	deck := models.FlashcardDeck{
+		SessionID: sessionID,
+		GroupID:   row.GroupID,
+		CreatedAt: row.CreatedAt,
+		Cards:     []models.Flashcard{},
+	}
+
+	cards, err := s.queries.ListFlashcards(ctx, queries.ListFlashcardsParams{UserID: s.userID, StudySessionID: sessionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flashcards: %v", err)
+	}
+	for _, card := range cards {
+		flashcard := models.Flashcard{
+			Word: models.WordResponse{
+				ID:      card.ID,
+				Urdu:    card.Script,
+				Urdlish: card.Transliteration,
+				English: card.English,
+			},
+			Flips:   int(card.Flips),
+			DueAt:   card.DueAt,
+			Rating:  card.Rating,
+			RatedAt: card.RatedAt,
+		}
+		switch {
+		case card.DueAt == nil:
+			flashcard.Status = ReviewStatusNew
+		case card.RelearningStep != nil && *card.RelearningStep > 0:
+			flashcard.Status = ReviewStatusLapsed
+		default:
+			flashcard.Status = ReviewStatusReview
+		}
+		deck.Cards = append(deck.Cards, flashcard)
+	}
+	return &deck, nil
+}
+
+// FlipFlashcard records that a card was flipped and returns how many times it
+// has been
+func (s *Service) FlipFlashcard(ctx context.Context, sessionID, wordID int64) (int, error) {
+	// The update returns the count, so it runs in a transaction to take
+	// the writer's turn
+	var flips int
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE flashcards
+			SET flips = flips + 1
+			WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+			RETURNING flips
+		`, sessionID, wordID, s.userID).Scan(&flips)
+		if err == sql.ErrNoRows {
+			return notFound("flashcard not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to flip flashcard: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return flips, nil
+}
+
+// RateFlashcard records the learner's own rating of a card and feeds it to
+// the scheduler. Anything but "again" counts as a correct review. A card can
+// only be rated once.
+func (s *Service) RateFlashcard(ctx context.Context, sessionID, wordID int64, rating string) (*models.WordReviewItem, error) {
+	grade, ok := srs.GradeFromRating(rating)
+	if !ok {
+		return nil, invalid("invalid rating")
+	}
+
+	var reviewItem *models.WordReviewItem
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		rated, err := queries.New(tx.SQL()).GetFlashcardRating(ctx, queries.GetFlashcardRatingParams{
+			StudySessionID: sessionID,
+			WordID:         wordID,
+			UserID:         s.userID,
+		})
+		if err == sql.ErrNoRows {
+			return notFound("flashcard not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get flashcard: %v", err)
+		}
+		if rated != nil {
+			return conflict("flashcard already rated")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE flashcards
+			SET rating = ?, rated_at = CURRENT_TIMESTAMP
+			WHERE study_session_id = ? AND word_id = ?
+		`, rating, sessionID, wordID)
+		if err != nil {
+			return fmt.Errorf("failed to rate flashcard: %v", err)
+		}
+
+		reviewItem, err = s.recordReviewItem(ctx, tx, sessionID, wordID, rating != srs.RatingAgain, false, grade)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewItem, nil
+}
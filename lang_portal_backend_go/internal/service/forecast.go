@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/tracing"
+	"math"
+	"time"
+)
+
+// learnedWordsSQL lists each word a user has learned with the time of their
+// first correct review. It takes the user ID as its one parameter.
+const learnedWordsSQL = `
+	SELECT word_id, MIN(created_at) AS learned_at
+	FROM word_review_items
+	WHERE user_id = ? AND correct
+	GROUP BY word_id
+`
+
+// GetForecast projects when each group and the whole corpus will be learned
+// at the rate words were learned over the last windowDays days, and the daily
+// pace needed to learn them by target. A word counts as learned on its first
+// correct review.
+func (s *Service) GetForecast(ctx context.Context, windowDays int, target, now time.Time) (*models.Forecast, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetForecast", tracing.KindInternal)
+	defer span.End()
+	today := startOfDay(now)
+	from := today.AddDate(0, 0, -(windowDays - 1)).Format("2006-01-02")
+	forecast := models.Forecast{
+		WindowDays: windowDays,
+		TargetDate: startOfDay(target).Format("2006-01-02"),
+		Groups:     []models.ForecastEntry{},
+	}
+	daysLeft := int(startOfDay(target).Sub(today).Hours()/24) + 1
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(l.word_id),
+			   COUNT(CASE WHEN date(l.learned_at) >= ? THEN 1 END)
+		FROM words w
+		LEFT JOIN (`+learnedWordsSQL+`) l ON l.word_id = w.id
+	`, from, s.userID).Scan(&forecast.Corpus.TotalWords, &forecast.Corpus.LearnedWords, &forecast.Corpus.LearnedInWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count learned words: %v", err)
+	}
+	project(&forecast.Corpus, windowDays, daysLeft, today)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT g.id, g.name, COUNT(wg.word_id), COUNT(l.word_id),
+			   COUNT(CASE WHEN date(l.learned_at) >= ? THEN 1 END)
+		FROM groups g
+		LEFT JOIN words_groups wg ON wg.group_id = g.id
+		LEFT JOIN (`+learnedWordsSQL+`) l ON l.word_id = wg.word_id
+		GROUP BY g.id
+		ORDER BY g.id
+	`, from, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count learned group words: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry models.ForecastEntry
+			group models.Group
+		)
+		if err := rows.Scan(&group.ID, &group.Name, &entry.TotalWords, &entry.LearnedWords,
+			&entry.LearnedInWindow); err != nil {
+			return nil, fmt.Errorf("failed to scan group forecast: %v", err)
+		}
+		entry.GroupID = &group.ID
+		entry.GroupName = group.Name
+		project(&entry, windowDays, daysLeft, today)
+		forecast.Groups = append(forecast.Groups, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group forecasts: %v", err)
+	}
+	return &forecast, nil
+}
+
+// project fills in the rate, projected completion date and required pace of
+// an entry whose word counts are known. Without recent progress there is no
+// projected date. If the target date has passed the required pace is
+// everything that remains.
+func project(entry *models.ForecastEntry, windowDays, daysLeft int, today time.Time) {
+	entry.RemainingWords = entry.TotalWords - entry.LearnedWords
+	entry.DailyRate = float64(entry.LearnedInWindow) / float64(windowDays)
+
+	switch {
+	case entry.RemainingWords == 0:
+		date := today.Format("2006-01-02")
+		entry.ProjectedDate = &date
+	case entry.DailyRate > 0:
+		days := int(math.Ceil(float64(entry.RemainingWords) / entry.DailyRate))
+		date := today.AddDate(0, 0, days).Format("2006-01-02")
+		entry.ProjectedDate = &date
+	}
+
+	if daysLeft < 1 {
+		daysLeft = 1
+	}
+	entry.RequiredDailyPace = float64(entry.RemainingWords) / float64(daysLeft)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/tracing"
+	"math"
+	"strings"
+	"time"
+)
+
+// Goal kinds
+const (
+	// GoalDailyReviews is a number of reviews to make each day
+	GoalDailyReviews = "daily_reviews"
+	// GoalWeeklyNewWords is a number of words to learn each week
+	GoalWeeklyNewWords = "weekly_new_words"
+	// GoalTargetAccuracy is the share of the week's reviews to get right
+	GoalTargetAccuracy = "target_accuracy"
+)
+
+// validateGoal checks a goal's kind and that its target makes sense for it
+func validateGoal(kind string, target float64) error {
+	switch kind {
+	case GoalDailyReviews, GoalWeeklyNewWords:
+		if target < 1 || target != math.Trunc(target) {
+			return invalid("invalid goal target")
+		}
+	case GoalTargetAccuracy:
+		if target <= 0 || target > 1 {
+			return invalid("invalid goal target")
+		}
+	default:
+		return invalid("invalid goal kind")
+	}
+	return nil
+}
+
+// GetGoals returns every goal of the user
+func (s *Service) GetGoals(ctx context.Context) ([]models.Goal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, kind, target, created_at, updated_at
+		FROM goals
+		WHERE user_id = ?
+		ORDER BY id
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %v", err)
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		var goal models.Goal
+		if err := rows.Scan(&goal.ID, &goal.Kind, &goal.Target, &goal.CreatedAt, &goal.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %v", err)
+		}
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating goals: %v", err)
+	}
+	return goals, nil
+}
+
+// GetGoal returns a goal by ID
+func (s *Service) GetGoal(ctx context.Context, id int64) (*models.Goal, error) {
+	var goal models.Goal
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, kind, target, created_at, updated_at
+		FROM goals
+		WHERE id = ? AND user_id = ?
+	`, id, s.userID).Scan(&goal.ID, &goal.Kind, &goal.Target, &goal.CreatedAt, &goal.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("goal not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %v", err)
+	}
+	return &goal, nil
+}
+
+// CreateGoal adds a goal. A user has at most one goal of each kind.
+func (s *Service) CreateGoal(ctx context.Context, kind string, target float64) (*models.Goal, error) {
+	if err := validateGoal(kind, target); err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO goals (user_id, kind, target) VALUES (?, ?, ?)
+	`, s.userID, kind, target)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, conflict("goal already exists")
+		}
+		return nil, fmt.Errorf("failed to create goal: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal id: %v", err)
+	}
+	return s.GetGoal(ctx, id)
+}
+
+// UpdateGoal changes the target of a goal
+func (s *Service) UpdateGoal(ctx context.Context, id int64, target float64) (*models.Goal, error) {
+	goal, err := s.GetGoal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGoal(goal.Kind, target); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE goals SET target = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, target, id); err != nil {
+		return nil, fmt.Errorf("failed to update goal: %v", err)
+	}
+	return s.GetGoal(ctx, id)
+}
+
+// DeleteGoal removes a goal
+func (s *Service) DeleteGoal(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM goals WHERE id = ? AND user_id = ?`, id, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %v", err)
+	}
+	if deleted == 0 {
+		return notFound("goal not found")
+	}
+	return nil
+}
+
+// GetGoalStatuses evaluates every goal of the user as of now. Daily goals count from
+// midnight UTC and weekly goals from Monday. A word counts as learned in the
+// week of its first correct review.
+func (s *Service) GetGoalStatuses(ctx context.Context, now time.Time) ([]models.GoalStatus, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetGoalStatuses", tracing.KindInternal)
+	defer span.End()
+	goals, err := s.GetGoals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []models.GoalStatus{}
+	for _, goal := range goals {
+		status := models.GoalStatus{Goal: goal, Period: TrendWeek}
+		start := startOfWeek(now)
+		if goal.Kind == GoalDailyReviews {
+			status.Period = TrendDay
+			start = startOfDay(now)
+		}
+		status.PeriodStart = start.Format("2006-01-02")
+
+		switch goal.Kind {
+		case GoalDailyReviews:
+			err = s.db.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM word_review_items WHERE user_id = ? AND date(created_at) >= ?
+			`, s.userID, status.PeriodStart).Scan(&status.Current)
+		case GoalWeeklyNewWords:
+			err = s.db.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM (`+learnedWordsSQL+`) WHERE date(learned_at) >= ?
+			`, s.userID, status.PeriodStart).Scan(&status.Current)
+		case GoalTargetAccuracy:
+			err = s.db.QueryRowContext(ctx, `
+				SELECT COALESCE(AVG(CASE WHEN correct THEN 1.0 ELSE 0.0 END), 0)
+				FROM word_review_items
+				WHERE user_id = ? AND date(created_at) >= ?
+			`, s.userID, status.PeriodStart).Scan(&status.Current)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate %s goal: %v", goal.Kind, err)
+		}
+
+		status.Met = status.Current >= goal.Target
+		status.Progress = math.Min(status.Current/goal.Target, 1)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
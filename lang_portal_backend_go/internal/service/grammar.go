@@ -0,0 +1,696 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/models"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Statuses of a learner's progress through a grammar topic
+const (
+	GrammarNotStarted = "not_started"
+	GrammarInProgress = "in_progress"
+	GrammarCompleted  = "completed"
+)
+
+// MaxImportGrammarTopics is the most grammar topics imported at once
+const MaxImportGrammarTopics = 100
+
+// maxGrammarTitleLength is the longest title of a grammar topic
+const maxGrammarTitleLength = 200
+
+// maxGrammarOptions is the most options of a multiple choice exercise
+const maxGrammarOptions = 6
+
+// grammarSlugPattern is what grammar topic slugs look like, e.g.
+// present-habitual
+var grammarSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// grammarTopicColumns are the columns scanGrammarTopic reads, without the
+// lesson, from grammarTopicTables. They count the exercises the user ?1 has
+// solved, and read when they started and completed the topic.
+const grammarTopicColumns = `t.id, t.language, t.slug, t.title, t.level, t.summary, t.position, t.created_at, t.updated_at,
+	(SELECT COUNT(*) FROM grammar_exercises e WHERE e.topic_id = t.id),
+	(SELECT COUNT(DISTINCT a.exercise_id) FROM grammar_answers a
+		JOIN grammar_exercises e ON e.id = a.exercise_id
+		WHERE e.topic_id = t.id AND a.user_id = ?1 AND a.result != 'wrong'),
+	p.started_at, p.completed_at`
+
+// grammarTopicTables are the tables grammarTopicColumns are selected from
+const grammarTopicTables = `grammar_topics t LEFT JOIN grammar_progress p ON p.topic_id = t.id AND p.user_id = ?1`
+
+// validateGrammarTopic checks a grammar topic and its exercises, trimming
+// their fields
+func validateGrammarTopic(topic *models.GrammarTopic) error {
+	topic.Slug = strings.ToLower(strings.TrimSpace(topic.Slug))
+	topic.Title = strings.TrimSpace(topic.Title)
+	topic.Level = strings.ToUpper(strings.TrimSpace(topic.Level))
+	topic.Summary = strings.TrimSpace(topic.Summary)
+	topic.Lesson = strings.TrimSpace(topic.Lesson)
+	switch {
+	case !grammarSlugPattern.MatchString(topic.Slug) || len(topic.Slug) > 64:
+		return invalid("invalid slug %q: use up to 64 lowercase letters, digits and hyphens", topic.Slug)
+	case topic.Title == "" || utf8.RuneCountInString(topic.Title) > maxGrammarTitleLength:
+		return invalid("title must be 1 to %d characters", maxGrammarTitleLength)
+	case topic.Position < 0:
+		return invalid("position can't be negative")
+	}
+	if err := checkLevel(topic.Level); err != nil {
+		return err
+	}
+	for i := range topic.Exercises {
+		if err := validateGrammarExercise(&topic.Exercises[i]); err != nil {
+			return invalid("exercise %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validateGrammarExercise checks a grammar exercise, trimming its fields.
+// Exercises with options are multiple choice unless said otherwise, and
+// others typed.
+func validateGrammarExercise(exercise *models.GrammarExercise) error {
+	exercise.Prompt = strings.TrimSpace(exercise.Prompt)
+	exercise.English = strings.TrimSpace(exercise.English)
+	exercise.Answer = strings.TrimSpace(exercise.Answer)
+	exercise.Explanation = strings.TrimSpace(exercise.Explanation)
+	for i, option := range exercise.Options {
+		exercise.Options[i] = strings.TrimSpace(option)
+	}
+	if exercise.AnswerMode == "" {
+		exercise.AnswerMode = AnswerModeTyped
+		if len(exercise.Options) > 0 {
+			exercise.AnswerMode = AnswerModeMultipleChoice
+		}
+	}
+
+	switch {
+	case exercise.Prompt == "":
+		return invalid("prompt is required")
+	case exercise.Answer == "":
+		return invalid("answer is required")
+	case exercise.Position < 0:
+		return invalid("position can't be negative")
+	}
+	switch exercise.AnswerMode {
+	case AnswerModeTyped:
+		if len(exercise.Options) > 0 {
+			return invalid("typed exercises have no options")
+		}
+	case AnswerModeMultipleChoice:
+		if len(exercise.Options) < 2 || len(exercise.Options) > maxGrammarOptions {
+			return invalid("multiple choice exercises need 2 to %d options", maxGrammarOptions)
+		}
+		seen := map[string]bool{}
+		for _, option := range exercise.Options {
+			if option == "" || seen[option] {
+				return invalid("options must be different and not empty")
+			}
+			seen[option] = true
+		}
+		if !seen[exercise.Answer] {
+			return invalid("answer must be one of the options")
+		}
+	default:
+		return invalid("invalid answer mode %q: use %s or %s", exercise.AnswerMode, AnswerModeMultipleChoice, AnswerModeTyped)
+	}
+	return nil
+}
+
+// scanGrammarTopic reads a topic selected by grammarTopicColumns, with the
+// progress of the user they were selected for, and any further columns
+// into extra
+func scanGrammarTopic(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.GrammarTopic, error) {
+	var (
+		topic              models.GrammarTopic
+		progress           models.GrammarProgress
+		started, completed sql.NullTime
+	)
+	err := row.Scan(append([]interface{}{&topic.ID, &topic.Language, &topic.Slug, &topic.Title, &topic.Level,
+		&topic.Summary, &topic.Position, &topic.CreatedAt, &topic.UpdatedAt, &topic.ExerciseCount,
+		&progress.Solved, &started, &completed}, extra...)...)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan grammar topic: %v", err)
+	}
+
+	progress.TopicID = topic.ID
+	progress.Exercises = topic.ExerciseCount
+	progress.Status = GrammarNotStarted
+	if started.Valid {
+		progress.Status = GrammarInProgress
+		progress.StartedAt = &started.Time
+	}
+	if completed.Valid {
+		progress.Status = GrammarCompleted
+		progress.CompletedAt = &completed.Time
+	}
+	topic.Progress = &progress
+	return &topic, nil
+}
+
+// grammarTopic returns a grammar topic with its lesson and the progress of
+// the service's user
+func (s *Service) grammarTopic(ctx context.Context, q queryRower, id int64) (*models.GrammarTopic, error) {
+	var lesson string
+	topic, err := scanGrammarTopic(q.QueryRowContext(ctx, `
+		SELECT `+grammarTopicColumns+`, t.lesson
+		FROM `+grammarTopicTables+`
+		WHERE t.id = ?2
+	`, s.userID, id), &lesson)
+	if err == sql.ErrNoRows {
+		return nil, notFound("grammar topic not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	topic.Lesson = lesson
+	return topic, nil
+}
+
+// grammarExercises returns the exercises of a topic in order, with the
+// result of the service's user's last answer to each. Answers and
+// explanations are left out unless withAnswers, when the results are.
+func (s *Service) grammarExercises(ctx context.Context, topicID int64, withAnswers bool) ([]models.GrammarExercise, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.topic_id, e.position, e.answer_mode, e.prompt, e.english, e.options, e.answer, e.explanation,
+			COALESCE((
+				SELECT a.result FROM grammar_answers a
+				WHERE a.exercise_id = e.id AND a.user_id = ?1
+				ORDER BY a.id DESC LIMIT 1
+			), 'unanswered')
+		FROM grammar_exercises e
+		WHERE e.topic_id = ?2
+		ORDER BY e.position, e.id
+	`, s.userID, topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grammar exercises: %v", err)
+	}
+	defer rows.Close()
+
+	exercises := []models.GrammarExercise{}
+	for rows.Next() {
+		exercise, err := scanGrammarExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		if withAnswers {
+			exercise.Result = ""
+		} else {
+			exercise.Answer, exercise.Explanation = "", ""
+		}
+		exercises = append(exercises, *exercise)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating grammar exercises: %v", err)
+	}
+	return exercises, nil
+}
+
+// scanGrammarExercise reads an exercise selected as grammarExercises does.
+// sql.ErrNoRows is returned as is.
+func scanGrammarExercise(row interface{ Scan(...interface{}) error }) (*models.GrammarExercise, error) {
+	var (
+		exercise models.GrammarExercise
+		options  string
+	)
+	err := row.Scan(&exercise.ID, &exercise.TopicID, &exercise.Position, &exercise.AnswerMode, &exercise.Prompt,
+		&exercise.English, &options, &exercise.Answer, &exercise.Explanation, &exercise.Result)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan grammar exercise: %v", err)
+	}
+	if err := json.Unmarshal([]byte(options), &exercise.Options); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %v", err)
+	}
+	return &exercise, nil
+}
+
+// ListGrammarTopics returns the grammar topics of the service's language, or
+// of every language, in course order with the user's progress through each.
+// Topics can be limited to a level and to a status of the user's progress.
+// Their lessons are left out.
+func (s *Service) ListGrammarTopics(ctx context.Context, level, status string) ([]models.GrammarTopic, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level != "" {
+		if err := checkLevel(level); err != nil {
+			return nil, err
+		}
+	}
+	switch status {
+	case "", GrammarNotStarted, GrammarInProgress, GrammarCompleted:
+	default:
+		return nil, invalid("invalid status %q: use %s, %s or %s", status, GrammarNotStarted, GrammarInProgress, GrammarCompleted)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+grammarTopicColumns+`
+		FROM `+grammarTopicTables+`
+		WHERE (?2 = '' OR t.language = ?2) AND (?3 = '' OR t.level = ?3)
+		ORDER BY t.level, t.position, t.title, t.id
+	`, s.userID, s.language, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grammar topics: %v", err)
+	}
+	defer rows.Close()
+
+	topics := []models.GrammarTopic{}
+	for rows.Next() {
+		topic, err := scanGrammarTopic(rows)
+		if err != nil {
+			return nil, err
+		}
+		if status == "" || topic.Progress.Status == status {
+			topics = append(topics, *topic)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating grammar topics: %v", err)
+	}
+	return topics, nil
+}
+
+// GetGrammarLesson returns a grammar topic as learners see it: its lesson,
+// its exercises without their answers, and the user's progress
+func (s *Service) GetGrammarLesson(ctx context.Context, id int64) (*models.GrammarTopic, error) {
+	topic, err := s.grammarTopic(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if topic.Exercises, err = s.grammarExercises(ctx, id, false); err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+// GetGrammarTopic returns a grammar topic as it is edited, with the
+// answers and explanations of its exercises
+func (s *Service) GetGrammarTopic(ctx context.Context, id int64) (*models.GrammarTopic, error) {
+	topic, err := s.grammarTopic(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	topic.Progress = nil
+	if topic.Exercises, err = s.grammarExercises(ctx, id, true); err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+// CreateGrammarTopic adds a grammar topic, with its exercises, to the
+// given language, or else the service's
+func (s *Service) CreateGrammarTopic(ctx context.Context, topic models.GrammarTopic) (*models.GrammarTopic, error) {
+	topic.Language = s.newWordLanguage(topic.Language)
+	if err := validateGrammarTopic(&topic); err != nil {
+		return nil, err
+	}
+	if err := s.checkLanguage(ctx, s.db, topic.Language); err != nil {
+		return nil, err
+	}
+
+	var id int64
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM grammar_topics WHERE language = ? AND slug = ?)
+		`, topic.Language, topic.Slug).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up grammar topic: %v", err)
+		}
+		if exists {
+			return conflict("grammar topic %q already exists", topic.Slug)
+		}
+		var err error
+		id, _, err = saveGrammarTopic(ctx, tx, topic)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGrammarTopic(ctx, id)
+}
+
+// saveGrammarTopic adds a validated grammar topic, or updates the topic of
+// its language with its slug and replaces its exercises, and returns its ID
+// and whether it was added
+func saveGrammarTopic(ctx context.Context, tx *models.Tx, topic models.GrammarTopic) (int64, bool, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM grammar_topics WHERE language = ? AND slug = ?
+	`, topic.Language, topic.Slug).Scan(&id)
+	created := err == sql.ErrNoRows
+	switch {
+	case created:
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO grammar_topics (language, slug, title, level, summary, lesson, position)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`, topic.Language, topic.Slug, topic.Title, topic.Level, topic.Summary, topic.Lesson, topic.Position).Scan(&id); err != nil {
+			return 0, false, fmt.Errorf("failed to create grammar topic: %v", err)
+		}
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to look up grammar topic: %v", err)
+	default:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE grammar_topics
+			SET title = ?, level = ?, summary = ?, lesson = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, topic.Title, topic.Level, topic.Summary, topic.Lesson, topic.Position, id); err != nil {
+			return 0, false, fmt.Errorf("failed to update grammar topic: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM grammar_exercises WHERE topic_id = ?`, id); err != nil {
+			return 0, false, fmt.Errorf("failed to replace grammar exercises: %v", err)
+		}
+	}
+
+	for i, exercise := range topic.Exercises {
+		if exercise.Position == 0 {
+			exercise.Position = i + 1
+		}
+		if _, err := insertGrammarExercise(ctx, tx, id, exercise); err != nil {
+			return 0, false, err
+		}
+	}
+	return id, created, nil
+}
+
+// insertGrammarExercise adds a validated exercise to a topic
+func insertGrammarExercise(ctx context.Context, tx *models.Tx, topicID int64, exercise models.GrammarExercise) (int64, error) {
+	options, err := json.Marshal(exercise.Options)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode options: %v", err)
+	}
+	if exercise.Options == nil {
+		options = []byte("[]")
+	}
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO grammar_exercises (topic_id, position, answer_mode, prompt, english, options, answer, explanation)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, topicID, exercise.Position, exercise.AnswerMode, exercise.Prompt, exercise.English, string(options),
+		exercise.Answer, exercise.Explanation).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to create grammar exercise: %v", err)
+	}
+	return id, nil
+}
+
+// UpdateGrammarTopic changes a grammar topic's slug, title, level, summary,
+// lesson and position. Its language and exercises are kept.
+func (s *Service) UpdateGrammarTopic(ctx context.Context, id int64, topic models.GrammarTopic) (*models.GrammarTopic, error) {
+	topic.Exercises = nil
+	if err := validateGrammarTopic(&topic); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		current, err := s.grammarTopic(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM grammar_topics WHERE language = ? AND slug = ? AND id != ?)
+		`, current.Language, topic.Slug, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up grammar topic: %v", err)
+		}
+		if exists {
+			return conflict("grammar topic %q already exists", topic.Slug)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE grammar_topics
+			SET slug = ?, title = ?, level = ?, summary = ?, lesson = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, topic.Slug, topic.Title, topic.Level, topic.Summary, topic.Lesson, topic.Position, id); err != nil {
+			return fmt.Errorf("failed to update grammar topic: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGrammarTopic(ctx, id)
+}
+
+// DeleteGrammarTopic deletes a grammar topic with its exercises and the
+// learners' answers and progress
+func (s *Service) DeleteGrammarTopic(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM grammar_topics WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete grammar topic: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return notFound("grammar topic not found")
+	}
+	return nil
+}
+
+// grammarExercise returns an exercise with its answer
+func (s *Service) grammarExercise(ctx context.Context, q queryRower, id int64) (*models.GrammarExercise, error) {
+	exercise, err := scanGrammarExercise(q.QueryRowContext(ctx, `
+		SELECT id, topic_id, position, answer_mode, prompt, english, options, answer, explanation, ''
+		FROM grammar_exercises
+		WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, notFound("grammar exercise not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return exercise, nil
+}
+
+// AddGrammarExercise adds an exercise to a grammar topic, after its others
+// unless it is given a position
+func (s *Service) AddGrammarExercise(ctx context.Context, topicID int64, exercise models.GrammarExercise) (*models.GrammarExercise, error) {
+	if err := validateGrammarExercise(&exercise); err != nil {
+		return nil, err
+	}
+
+	var id int64
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if _, err := s.grammarTopic(ctx, tx, topicID); err != nil {
+			return err
+		}
+		if exercise.Position == 0 {
+			if err := tx.QueryRowContext(ctx, `
+				SELECT COALESCE(MAX(position), 0) + 1 FROM grammar_exercises WHERE topic_id = ?
+			`, topicID).Scan(&exercise.Position); err != nil {
+				return fmt.Errorf("failed to get exercise position: %v", err)
+			}
+		}
+		var err error
+		id, err = insertGrammarExercise(ctx, tx, topicID, exercise)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.grammarExercise(ctx, s.db, id)
+}
+
+// UpdateGrammarExercise replaces an exercise, keeping its position unless
+// it is given one. The answers learners gave are kept.
+func (s *Service) UpdateGrammarExercise(ctx context.Context, id int64, exercise models.GrammarExercise) (*models.GrammarExercise, error) {
+	if err := validateGrammarExercise(&exercise); err != nil {
+		return nil, err
+	}
+	current, err := s.grammarExercise(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if exercise.Position == 0 {
+		exercise.Position = current.Position
+	}
+	options, err := json.Marshal(exercise.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode options: %v", err)
+	}
+	if exercise.Options == nil {
+		options = []byte("[]")
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE grammar_exercises
+		SET position = ?, answer_mode = ?, prompt = ?, english = ?, options = ?, answer = ?, explanation = ?
+		WHERE id = ?
+	`, exercise.Position, exercise.AnswerMode, exercise.Prompt, exercise.English, string(options),
+		exercise.Answer, exercise.Explanation, id); err != nil {
+		return nil, fmt.Errorf("failed to update grammar exercise: %v", err)
+	}
+	return s.grammarExercise(ctx, s.db, id)
+}
+
+// DeleteGrammarExercise deletes an exercise with the answers learners gave
+func (s *Service) DeleteGrammarExercise(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM grammar_exercises WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete grammar exercise: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return notFound("grammar exercise not found")
+	}
+	return nil
+}
+
+// ImportGrammarTopics adds up to MaxImportGrammarTopics grammar topics with
+// their exercises to a language, by default the service's. Topics whose
+// slug the language already has are updated instead, and their exercises
+// replaced along with the answers learners gave them; the learners'
+// progress is kept. Nothing is imported unless every topic is valid.
+func (s *Service) ImportGrammarTopics(ctx context.Context, language string, topics []models.GrammarTopic) (*models.GrammarImport, error) {
+	if len(topics) == 0 || len(topics) > MaxImportGrammarTopics {
+		return nil, invalid("import 1 to %d topics at a time", MaxImportGrammarTopics)
+	}
+	language = s.newWordLanguage(language)
+	if err := s.checkLanguage(ctx, s.db, language); err != nil {
+		return nil, err
+	}
+	slugs := map[string]bool{}
+	for i := range topics {
+		topics[i].Language = language
+		if err := validateGrammarTopic(&topics[i]); err != nil {
+			return nil, invalid("topic %d: %v", i+1, err)
+		}
+		if slugs[topics[i].Slug] {
+			return nil, invalid("topic %d: slug %q is imported twice", i+1, topics[i].Slug)
+		}
+		slugs[topics[i].Slug] = true
+	}
+
+	result := &models.GrammarImport{Language: language}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for _, topic := range topics {
+			_, created, err := saveGrammarTopic(ctx, tx, topic)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+			result.Exercises += len(topic.Exercises)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// startGrammarTopic records that the service's user started a topic, if
+// they hadn't already, and that they completed it if they have now read its
+// lesson or solved its exercises. It returns their progress.
+func (s *Service) startGrammarTopic(ctx context.Context, tx *models.Tx, topicID int64) (*models.GrammarProgress, error) {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO grammar_progress (user_id, topic_id) VALUES (?, ?)
+		ON CONFLICT (user_id, topic_id) DO NOTHING
+	`, s.userID, topicID); err != nil {
+		return nil, fmt.Errorf("failed to start grammar topic: %v", err)
+	}
+	topic, err := s.grammarTopic(ctx, tx, topicID)
+	if err != nil {
+		return nil, err
+	}
+	progress := topic.Progress
+	if progress.Status == GrammarCompleted || progress.Solved < progress.Exercises {
+		return progress, nil
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE grammar_progress SET completed_at = ? WHERE user_id = ? AND topic_id = ?
+	`, now, s.userID, topicID); err != nil {
+		return nil, fmt.Errorf("failed to complete grammar topic: %v", err)
+	}
+	progress.Status = GrammarCompleted
+	progress.CompletedAt = &now
+	return progress, nil
+}
+
+// ReadGrammarLesson records that the service's user read a topic's lesson,
+// starting the topic, or completing it if it has no exercises
+func (s *Service) ReadGrammarLesson(ctx context.Context, id int64) (*models.GrammarProgress, error) {
+	var progress *models.GrammarProgress
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if _, err := s.grammarTopic(ctx, tx, id); err != nil {
+			return err
+		}
+		var err error
+		progress, err = s.startGrammarTopic(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// AnswerGrammarExercise checks an answer to a grammar exercise and records
+// it, starting the exercise's topic, or completing it once every exercise
+// is solved. A multiple choice answer must be one of the options; typed
+// answers forgive diacritics and small typos as typed quiz answers do, and
+// those with typos are near misses. Exercises can be answered again.
+func (s *Service) AnswerGrammarExercise(ctx context.Context, id int64, answer string) (*models.GrammarAnswerResult, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, invalid("answer is required")
+	}
+
+	var result *models.GrammarAnswerResult
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		exercise, err := s.grammarExercise(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		match, err := matchAnswer(exercise.AnswerMode, exercise.Options, answer, exercise.Answer)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO grammar_answers (user_id, exercise_id, answer, result) VALUES (?, ?, ?, ?)
+		`, s.userID, id, answer, string(match)); err != nil {
+			return fmt.Errorf("failed to record grammar answer: %v", err)
+		}
+		progress, err := s.startGrammarTopic(ctx, tx, exercise.TopicID)
+		if err != nil {
+			return err
+		}
+		result = &models.GrammarAnswerResult{
+			ExerciseID:    id,
+			Result:        string(match),
+			Answer:        answer,
+			CorrectAnswer: exercise.Answer,
+			Explanation:   exercise.Explanation,
+			Progress:      *progress,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/events"
+	"lang_portal/internal/groupgen"
+	"lang_portal/internal/llm"
+	"lang_portal/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultGroupSize is how many words a group is generated with unless asked
+// for another number
+const DefaultGroupSize = 10
+
+// maxTopicLength is the longest topic a group can be generated on
+const maxTopicLength = 200
+
+// llmProvider returns the language model configured by the environment
+func llmProvider() (llm.Provider, error) {
+	provider, err := llm.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid language model settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no language model is configured, set %s", llm.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// checkGroupGeneration checks what a group is asked to be generated with,
+// filling in the defaults
+func (s *Service) checkGroupGeneration(ctx context.Context, job *generateGroupJob) error {
+	job.Topic = strings.TrimSpace(job.Topic)
+	job.Name = strings.TrimSpace(job.Name)
+	if job.Topic == "" || utf8.RuneCountInString(job.Topic) > maxTopicLength {
+		return invalid("topic must be between 1 and %d characters", maxTopicLength)
+	}
+	if job.Name == "" {
+		job.Name = job.Topic
+	}
+	if job.Size == 0 {
+		job.Size = DefaultGroupSize
+	}
+	if job.Size < 1 || job.Size > groupgen.MaxSize {
+		return invalid("size must be between 1 and %d", groupgen.MaxSize)
+	}
+	return s.checkGroupName(ctx, s.db, DefaultLanguage, job.Name)
+}
+
+// checkGroupName reports a conflict if a group of a language already has
+// name
+func (s *Service) checkGroupName(ctx context.Context, q queryRower, language, name string) error {
+	var id int64
+	err := q.QueryRowContext(ctx, `
+		SELECT id FROM groups WHERE name = ? AND language = ?
+	`, name, language).Scan(&id)
+	if err == nil {
+		return conflict("group %q already exists", name)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to look up group: %v", err)
+	}
+	return nil
+}
+
+// EnqueueGroupGeneration queues asking the language model for size words on
+// topic, 0 for DefaultGroupSize. The job's result is a draft of the group
+// named name, or after the topic, to review and approve.
+func (s *Service) EnqueueGroupGeneration(ctx context.Context, topic, name string, size int) (*models.Job, error) {
+	job := generateGroupJob{Topic: topic, Name: name, Size: size}
+	if err := s.checkGroupGeneration(ctx, &job); err != nil {
+		return nil, err
+	}
+	if _, err := llmProvider(); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobGenerateGroup, job)
+}
+
+// GenerateGroupDraft asks the language model for the words of a group and
+// keeps them as a draft, with the existing words among them linked. The
+// model is asked for Urdu words, so drafts are of the default language.
+func (s *Service) GenerateGroupDraft(ctx context.Context, topic, name string, size int) (*models.GroupDraft, error) {
+	job := generateGroupJob{Topic: topic, Name: name, Size: size}
+	if err := s.checkGroupGeneration(ctx, &job); err != nil {
+		return nil, err
+	}
+	provider, err := llmProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	words, dropped, err := groupgen.Generate(ctx, provider, job.Topic, job.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate words: %v", err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("failed to generate words: every word the model answered was dropped")
+	}
+
+	var id int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := linkExistingWords(ctx, tx, DefaultLanguage, words); err != nil {
+			return err
+		}
+		wordsJSON, err := json.Marshal(words)
+		if err != nil {
+			return fmt.Errorf("failed to encode draft words: %v", err)
+		}
+		droppedJSON, err := json.Marshal(dropped)
+		if err != nil {
+			return fmt.Errorf("failed to encode dropped words: %v", err)
+		}
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO group_drafts (user_id, topic, name, status, provider, words, dropped, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`, s.userID, job.Topic, job.Name, models.DraftPending, provider.Name(),
+			string(wordsJSON), string(droppedJSON), time.Now().UTC()).Scan(&id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save group draft: %v", err)
+	}
+	return s.GetGroupDraft(ctx, id)
+}
+
+// createDraftWords creates the words of a language that don't exist yet,
+// setting their WordID, and returns the IDs of all the words and how many
+// were created
+func (s *Service) createDraftWords(ctx context.Context, tx *models.Tx, language string, words []models.DraftWord) ([]int64, int, error) {
+	if err := linkExistingWords(ctx, tx, language, words); err != nil {
+		return nil, 0, err
+	}
+	wordIDs := make([]int64, 0, len(words))
+	created := 0
+	for i, draftWord := range words {
+		if draftWord.WordID == 0 {
+			word := &models.Word{Urdu: draftWord.Urdu, Urdlish: draftWord.Urdlish, English: draftWord.English, Language: language}
+			if err := s.words.Create(ctx, tx, word); err != nil {
+				return nil, 0, err
+			}
+			words[i].WordID = word.ID
+			created++
+			s.publishAfterCommit(ctx, tx, events.WordCreated{
+				UserID:  s.userID,
+				WordID:  word.ID,
+				Urdu:    word.Urdu,
+				English: word.English,
+				At:      time.Now().UTC(),
+			})
+		}
+		wordIDs = append(wordIDs, words[i].WordID)
+	}
+	return wordIDs, created, nil
+}
+
+// linkExistingWords sets the WordID of the words whose urdu and english are
+// already those of a word of the language
+func linkExistingWords(ctx context.Context, q queryRower, language string, words []models.DraftWord) error {
+	for i := range words {
+		err := q.QueryRowContext(ctx, `
+			SELECT id FROM words
+			WHERE script = ? AND lower(english) = lower(?) AND language = ?
+			ORDER BY id LIMIT 1
+		`, words[i].Urdu, words[i].English, language).Scan(&words[i].WordID)
+		if err == sql.ErrNoRows {
+			words[i].WordID = 0
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up word: %v", err)
+		}
+	}
+	return nil
+}
+
+// groupDraftColumns are the columns scanGroupDraft reads
+const groupDraftColumns = `id, user_id, topic, name, status, provider, words, dropped, group_id, created_at, approved_at`
+
+func scanGroupDraft(row interface{ Scan(...interface{}) error }) (*models.GroupDraft, error) {
+	var (
+		draft      models.GroupDraft
+		words      string
+		dropped    string
+		groupID    sql.NullInt64
+		approvedAt sql.NullTime
+	)
+	err := row.Scan(&draft.ID, &draft.UserID, &draft.Topic, &draft.Name, &draft.Status, &draft.Provider,
+		&words, &dropped, &groupID, &draft.CreatedAt, &approvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(words), &draft.Words); err != nil {
+		return nil, fmt.Errorf("failed to decode draft words: %v", err)
+	}
+	if err := json.Unmarshal([]byte(dropped), &draft.Dropped); err != nil {
+		return nil, fmt.Errorf("failed to decode dropped words: %v", err)
+	}
+	if groupID.Valid {
+		draft.GroupID = &groupID.Int64
+	}
+	if approvedAt.Valid {
+		draft.ApprovedAt = &approvedAt.Time
+	}
+	return &draft, nil
+}
+
+// ListGroupDrafts returns the user's group drafts, newest first
+func (s *Service) ListGroupDrafts(ctx context.Context) ([]models.GroupDraft, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+groupDraftColumns+` FROM group_drafts WHERE user_id = ? ORDER BY id DESC
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group drafts: %v", err)
+	}
+	defer rows.Close()
+
+	drafts := []models.GroupDraft{}
+	for rows.Next() {
+		draft, err := scanGroupDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group draft: %v", err)
+		}
+		drafts = append(drafts, *draft)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group drafts: %v", err)
+	}
+	return drafts, nil
+}
+
+// GetGroupDraft returns one of the user's group drafts
+func (s *Service) GetGroupDraft(ctx context.Context, id int64) (*models.GroupDraft, error) {
+	return s.getGroupDraft(ctx, s.db, id)
+}
+
+func (s *Service) getGroupDraft(ctx context.Context, q queryRower, id int64) (*models.GroupDraft, error) {
+	draft, err := scanGroupDraft(q.QueryRowContext(ctx, `
+		SELECT `+groupDraftColumns+` FROM group_drafts WHERE id = ? AND user_id = ?
+	`, id, s.userID))
+	if err == sql.ErrNoRows {
+		return nil, notFound("group draft %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group draft: %v", err)
+	}
+	return draft, nil
+}
+
+// ApproveGroupDraft creates the group of a pending draft, with its words
+// and named after it, in one transaction. A name or words given replace the
+// draft's, so a reviewer can correct them; words are validated again. Words
+// that already exist are added to the group rather than created again.
+func (s *Service) ApproveGroupDraft(ctx context.Context, id int64, name string, words []models.DraftWord) (*models.GroupResponse, error) {
+	if words != nil {
+		kept, dropped := groupgen.Clean(words, groupgen.MaxSize)
+		if len(dropped) > 0 {
+			return nil, invalid("word %q: %s", dropped[0].English, dropped[0].Reason)
+		}
+		if len(kept) == 0 {
+			return nil, invalid("a group needs at least one word")
+		}
+		words = kept
+	}
+
+	var groupID int64
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		draft, err := s.getGroupDraft(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if draft.Status != models.DraftPending {
+			return conflict("group draft %d is already %s", id, draft.Status)
+		}
+		if name = strings.TrimSpace(name); name == "" {
+			name = draft.Name
+		}
+		if words == nil {
+			words = draft.Words
+		}
+		if err := s.checkGroupName(ctx, tx, DefaultLanguage, name); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO groups (name, language) VALUES (?, ?) RETURNING id
+		`, name, DefaultLanguage).Scan(&groupID); err != nil {
+			return fmt.Errorf("failed to create group: %v", err)
+		}
+		if err := s.ownNewGroup(ctx, tx, groupID); err != nil {
+			return err
+		}
+
+		// Words may have been added since the draft was written
+		wordIDs, _, err := s.createDraftWords(ctx, tx, DefaultLanguage, words)
+		if err != nil {
+			return err
+		}
+		if err := s.groups.AddWords(ctx, tx, groupID, wordIDs); err != nil {
+			return err
+		}
+
+		wordsJSON, err := json.Marshal(words)
+		if err != nil {
+			return fmt.Errorf("failed to encode draft words: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE group_drafts SET status = ?, name = ?, words = ?, group_id = ?, approved_at = ? WHERE id = ?
+		`, models.DraftApproved, name, string(wordsJSON), groupID, time.Now().UTC(), id); err != nil {
+			return fmt.Errorf("failed to approve group draft: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, groupID)
+}
+
+// DeleteGroupDraft discards one of the user's group drafts. The group of an
+// approved draft is kept.
+func (s *Service) DeleteGroupDraft(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM group_drafts WHERE id = ? AND user_id = ?`, id, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete group draft: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete group draft: %v", err)
+	}
+	if deleted == 0 {
+		return notFound("group draft %d not found", id)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"time"
+)
+
+// Group roles. Owners change a group and grant roles on it, editors change
+// it and viewers only study it. A group with an owner is seen only by the
+// users with a role on it; groups nobody owns, like the seeded ones, are
+// seen by everyone and changed only by admins, who can do anything with
+// any group.
+const (
+	GroupOwner  = "owner"
+	GroupEditor = "editor"
+	GroupViewer = "viewer"
+)
+
+// isAdmin reports whether the service's user is an admin
+func (s *Service) isAdmin(ctx context.Context, q queryRower) (bool, error) {
+	var role string
+	err := q.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, s.userID).Scan(&role)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to get user role: %v", err)
+	}
+	return role == RoleAdmin, nil
+}
+
+// viewerID returns the user whose groups and words the service lists: the
+// service's user, or 0 for admins, who see every group
+func (s *Service) viewerID(ctx context.Context) (int64, error) {
+	admin, err := s.isAdmin(ctx, s.db)
+	if err != nil || admin {
+		return 0, err
+	}
+	return s.userID, nil
+}
+
+// groupAccess is what the service's user may do with a group
+type groupAccess struct {
+	exists bool
+	// owned is whether anyone owns the group
+	owned bool
+	// role is the user's role on the group, if any
+	role  string
+	admin bool
+}
+
+func (a groupAccess) canView() bool {
+	return a.exists && (a.admin || !a.owned || a.role != "")
+}
+
+func (a groupAccess) canEdit() bool {
+	return a.exists && (a.admin || a.role == GroupOwner || a.role == GroupEditor)
+}
+
+func (s *Service) groupAccess(ctx context.Context, q queryRower, groupID int64) (groupAccess, error) {
+	var access groupAccess
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM groups WHERE id = ?1),
+		       EXISTS (SELECT 1 FROM group_permissions WHERE group_id = ?1 AND role = 'owner'),
+		       COALESCE((SELECT role FROM group_permissions WHERE group_id = ?1 AND user_id = ?2), '')
+	`, groupID, s.userID).Scan(&access.exists, &access.owned, &access.role)
+	if err != nil {
+		return access, fmt.Errorf("failed to get group permissions: %v", err)
+	}
+	if access.admin, err = s.isAdmin(ctx, q); err != nil {
+		return access, err
+	}
+	return access, nil
+}
+
+// checkGroupViewer returns a not found error unless the group exists and
+// the user can see it, so private groups aren't revealed to other users
+func (s *Service) checkGroupViewer(ctx context.Context, q queryRower, groupID int64) error {
+	access, err := s.groupAccess(ctx, q, groupID)
+	if err != nil {
+		return err
+	}
+	if !access.canView() {
+		return notFound("group not found")
+	}
+	return nil
+}
+
+// checkGroupEditor returns an error unless the user can change the group:
+// its words, their order and what syncs it
+func (s *Service) checkGroupEditor(ctx context.Context, q queryRower, groupID int64) error {
+	access, err := s.groupAccess(ctx, q, groupID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !access.canView():
+		return notFound("group not found")
+	case access.canEdit():
+		return nil
+	case !access.owned:
+		return forbidden("only admins can change groups nobody owns")
+	default:
+		return forbidden("editor role required to change the group")
+	}
+}
+
+// checkWordEditor returns an error unless the user can change a word. A
+// word is shared by the groups it is in, so the user must be able to
+// change every one of them; words in no group are changed only by admins.
+func (s *Service) checkWordEditor(ctx context.Context, q queryRower, wordID int64) error {
+	var (
+		exists           bool
+		groups, editable int
+	)
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words WHERE id = ?1),
+		       (SELECT COUNT(DISTINCT group_id) FROM words_groups WHERE word_id = ?1),
+		       (SELECT COUNT(DISTINCT wg.group_id)
+		        FROM words_groups wg
+		        JOIN group_permissions gp ON gp.group_id = wg.group_id
+		        WHERE wg.word_id = ?1 AND gp.user_id = ?2 AND gp.role IN ('owner', 'editor'))
+	`, wordID, s.userID).Scan(&exists, &groups, &editable)
+	if err != nil {
+		return fmt.Errorf("failed to get word permissions: %v", err)
+	}
+	if !exists {
+		return notFound("word not found")
+	}
+	admin, err := s.isAdmin(ctx, q)
+	if err != nil {
+		return err
+	}
+	switch {
+	case admin:
+		return nil
+	case groups == 0:
+		return forbidden("only admins can change words outside a group")
+	case editable < groups:
+		return forbidden("editor role required on every group of the word")
+	}
+	return nil
+}
+
+// ownNewGroup makes the user the owner of a group they just created, so
+// it is private to them, unless they are an admin, whose groups are
+// everyone's
+func (s *Service) ownNewGroup(ctx context.Context, tx *models.Tx, groupID int64) error {
+	admin, err := s.isAdmin(ctx, tx)
+	if err != nil || admin {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO group_permissions (group_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+	`, groupID, s.userID, GroupOwner, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to own group: %v", err)
+	}
+	return nil
+}
+
+const groupPermissionColumns = `gp.group_id, gp.user_id, u.username, gp.role, gp.created_at`
+
+// ListGroupPermissions returns the roles users have on a group, owners
+// first
+func (s *Service) ListGroupPermissions(ctx context.Context, groupID int64) ([]models.GroupPermission, error) {
+	if err := s.checkGroupViewer(ctx, s.db, groupID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+groupPermissionColumns+`
+		FROM group_permissions gp
+		JOIN users u ON u.id = gp.user_id
+		WHERE gp.group_id = ?
+		ORDER BY CASE gp.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, gp.user_id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group permissions: %v", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.GroupPermission{}
+	for rows.Next() {
+		var permission models.GroupPermission
+		if err := rows.Scan(&permission.GroupID, &permission.UserID, &permission.Username,
+			&permission.Role, &permission.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group permission: %v", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group permissions: %v", err)
+	}
+	return permissions, nil
+}
+
+// checkGroupOwner returns an error unless the user can grant roles on the
+// group: its owners and admins can
+func (s *Service) checkGroupOwner(ctx context.Context, tx *models.Tx, groupID int64) error {
+	access, err := s.groupAccess(ctx, tx, groupID)
+	if err != nil {
+		return err
+	}
+	if !access.canView() {
+		return notFound("group not found")
+	}
+	if !access.admin && access.role != GroupOwner {
+		return forbidden("owner role required to grant roles on the group")
+	}
+	return nil
+}
+
+// checkLastOwner returns a conflict if the user is the group's only owner,
+// so a private group always has someone to grant roles on it
+func checkLastOwner(ctx context.Context, tx *models.Tx, groupID, userID int64) error {
+	var last bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM group_permissions WHERE group_id = ?1 AND user_id = ?2 AND role = 'owner')
+		   AND (SELECT COUNT(*) FROM group_permissions WHERE group_id = ?1 AND role = 'owner') = 1
+	`, groupID, userID).Scan(&last); err != nil {
+		return fmt.Errorf("failed to count group owners: %v", err)
+	}
+	if last {
+		return conflict("can't remove the group's last owner")
+	}
+	return nil
+}
+
+// SetGroupPermission gives a user a role on a group, replacing any role
+// they had. Giving a group nobody owns an owner makes it private.
+func (s *Service) SetGroupPermission(ctx context.Context, groupID, userID int64, role string) (*models.GroupPermission, error) {
+	if role != GroupOwner && role != GroupEditor && role != GroupViewer {
+		return nil, invalid("invalid role %q: use %s, %s or %s", role, GroupOwner, GroupEditor, GroupViewer)
+	}
+	var permission models.GroupPermission
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkGroupOwner(ctx, tx, groupID); err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)`, userID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get user: %v", err)
+		}
+		if !exists {
+			return notFound("user not found")
+		}
+		if role != GroupOwner {
+			if err := checkLastOwner(ctx, tx, groupID, userID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO group_permissions (group_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (group_id, user_id) DO UPDATE SET role = excluded.role
+		`, groupID, userID, role, time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to set group permission: %v", err)
+		}
+		return tx.QueryRowContext(ctx, `
+			SELECT `+groupPermissionColumns+`
+			FROM group_permissions gp
+			JOIN users u ON u.id = gp.user_id
+			WHERE gp.group_id = ? AND gp.user_id = ?
+		`, groupID, userID).Scan(&permission.GroupID, &permission.UserID, &permission.Username,
+			&permission.Role, &permission.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// DeleteGroupPermission revokes a user's role on a group
+func (s *Service) DeleteGroupPermission(ctx context.Context, groupID, userID int64) error {
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkGroupOwner(ctx, tx, groupID); err != nil {
+			return err
+		}
+		if err := checkLastOwner(ctx, tx, groupID, userID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `
+			DELETE FROM group_permissions WHERE group_id = ? AND user_id = ?
+		`, groupID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete group permission: %v", err)
+		}
+		if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+			return notFound("group permission not found")
+		}
+		return nil
+	})
+}
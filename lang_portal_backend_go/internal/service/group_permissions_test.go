@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckGroupViewer(t *testing.T) {
+	svc := newTestService(t, testGroups)
+	tests := []struct {
+		name    string
+		userID  int64
+		groupID int64
+		wantErr error
+	}{
+		{name: "admin sees a private group", userID: testAdmin, groupID: 2},
+		{name: "owner", userID: testOwner, groupID: 2},
+		{name: "editor", userID: testEditor, groupID: 2},
+		{name: "viewer", userID: testViewer, groupID: 2},
+		{name: "user without a role", userID: testStranger, groupID: 2, wantErr: ErrNotFound},
+		{name: "group nobody owns", userID: testStranger, groupID: 1},
+		{name: "missing group", userID: testAdmin, groupID: 99, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := svc.ForUser(tt.userID)
+			err := s.checkGroupViewer(context.Background(), s.db, tt.groupID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkGroupViewer() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckGroupEditor(t *testing.T) {
+	svc := newTestService(t, testGroups)
+	tests := []struct {
+		name    string
+		userID  int64
+		groupID int64
+		wantErr error
+	}{
+		{name: "admin", userID: testAdmin, groupID: 2},
+		{name: "owner", userID: testOwner, groupID: 2},
+		{name: "editor", userID: testEditor, groupID: 2},
+		{name: "viewer", userID: testViewer, groupID: 2, wantErr: ErrForbidden},
+		{name: "user without a role", userID: testStranger, groupID: 2, wantErr: ErrNotFound},
+		{name: "admin changes a group nobody owns", userID: testAdmin, groupID: 1},
+		{name: "learner changes a group nobody owns", userID: testOwner, groupID: 1, wantErr: ErrForbidden},
+		{name: "missing group", userID: testAdmin, groupID: 99, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := svc.ForUser(tt.userID)
+			err := s.checkGroupEditor(context.Background(), s.db, tt.groupID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkGroupEditor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckWordEditor(t *testing.T) {
+	svc := newTestService(t, testGroups)
+	tests := []struct {
+		name    string
+		userID  int64
+		wordID  int64
+		wantErr error
+	}{
+		{name: "admin", userID: testAdmin, wordID: 4},
+		{name: "owner", userID: testOwner, wordID: 4},
+		{name: "editor", userID: testEditor, wordID: 4},
+		{name: "viewer", userID: testViewer, wordID: 4, wantErr: ErrForbidden},
+		{name: "user without a role", userID: testStranger, wordID: 4, wantErr: ErrForbidden},
+		{name: "word also in a group nobody owns", userID: testOwner, wordID: 1, wantErr: ErrForbidden},
+		{name: "admin changes a word in no group", userID: testAdmin, wordID: 5},
+		{name: "learner changes a word in no group", userID: testOwner, wordID: 5, wantErr: ErrForbidden},
+		{name: "missing word", userID: testAdmin, wordID: 99, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := svc.ForUser(tt.userID)
+			err := s.checkWordEditor(context.Background(), s.db, tt.wordID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkWordEditor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrivateGroupWords(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, testGroups)
+	tests := []struct {
+		name      string
+		userID    int64
+		wantTotal int
+		wantErr   error
+	}{
+		{name: "admin", userID: testAdmin, wantTotal: 5},
+		{name: "viewer", userID: testViewer, wantTotal: 5},
+		{name: "user without a role", userID: testStranger, wantTotal: 4, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := svc.ForUser(tt.userID)
+			page, err := s.ListWords(ctx, 1, SortID)
+			if err != nil {
+				t.Fatalf("ListWords() error = %v", err)
+			}
+			if page.Pagination.TotalItems != tt.wantTotal {
+				t.Errorf("ListWords() total = %d, want %d", page.Pagination.TotalItems, tt.wantTotal)
+			}
+			if _, err := s.GetWord(ctx, 4); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetWord() of the private word error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := s.GetGroupWords(ctx, 2, 1, SortID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetGroupWords() of the private group error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetGroupPermission(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		userID  int64
+		grantTo int64
+		role    string
+		wantErr error
+	}{
+		{name: "owner grants a role", userID: testOwner, grantTo: testStranger, role: GroupViewer},
+		{name: "admin grants a role", userID: testAdmin, grantTo: testStranger, role: GroupEditor},
+		{name: "editor can't grant roles", userID: testEditor, grantTo: testStranger, role: GroupViewer, wantErr: ErrForbidden},
+		{name: "user without a role can't see the group", userID: testStranger, grantTo: testStranger, role: GroupOwner, wantErr: ErrNotFound},
+		{name: "last owner can't step down", userID: testOwner, grantTo: testOwner, role: GroupEditor, wantErr: ErrConflict},
+		{name: "invalid role", userID: testOwner, grantTo: testStranger, role: "admin", wantErr: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, testGroups)
+			_, err := svc.ForUser(tt.userID).SetGroupPermission(ctx, 2, tt.grantTo, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetGroupPermission() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,633 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lang_portal/internal/events"
+	"lang_portal/internal/groupgen"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Bounds of how often a group sync runs, in minutes
+const (
+	minSyncInterval = 15
+	maxSyncInterval = 7 * 24 * 60
+)
+
+// maxSheetSize caps how much of a synced sheet is read
+const maxSheetSize = 5 << 20
+
+// maxSheetRows is the most rows a synced sheet may have
+const maxSheetRows = 2000
+
+// syncPollInterval is how often due group syncs are looked for
+const syncPollInterval = time.Minute
+
+// SheetHostsEnv lists the hosts besides Google Sheets' that groups can be
+// synced from, separated by commas. A host starting with a dot allows its
+// subdomains.
+const SheetHostsEnv = "LANG_PORTAL_SHEET_HOSTS"
+
+// googleSheetHosts are the hosts Google Sheets' CSV exports are served
+// from: docs.google.com redirects them to a googleusercontent.com subdomain
+var googleSheetHosts = []string{"docs.google.com", ".googleusercontent.com"}
+
+var sheetClient = &http.Client{
+	Timeout: 30 * time.Second,
+	// Sheets are fetched without a proxy, so the addresses checked are
+	// those of the sheets' hosts
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: checkSheetAddress}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	// Redirects are held to the hosts sheets can be synced from
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return checkSheetURL(req.URL)
+	},
+}
+
+// checkSheetURL returns a validation error unless sheets can be synced from
+// the URL: an HTTPS URL of Google Sheets or of one of
+// LANG_PORTAL_SHEET_HOSTS. Syncs, which anyone can set up and the server
+// runs on a schedule, can't make it fetch from other hosts, such as those
+// of its internal network.
+func checkSheetURL(u *url.URL) error {
+	if u.Scheme != "https" || u.User != nil || u.Hostname() == "" {
+		return invalid("url must be an https URL without credentials")
+	}
+	host := strings.ToLower(u.Hostname())
+	hosts := append(strings.Split(os.Getenv(SheetHostsEnv), ","), googleSheetHosts...)
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		switch {
+		case allowed == "":
+		case strings.HasPrefix(allowed, "."):
+			if strings.HasSuffix(host, allowed) {
+				return nil
+			}
+		case host == allowed:
+			return nil
+		}
+	}
+	return invalid("sheet host %s is not allowed: add it to %s", u.Hostname(), SheetHostsEnv)
+}
+
+// checkSheetAddress refuses connections to loopback, private, link-local
+// and unspecified addresses, so an allowed host whose name resolves into
+// the server's own network can't be synced from either
+func checkSheetAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("sheet address %s is not allowed", host)
+	}
+	return nil
+}
+
+// googleSheetPath matches the path of a Google Sheets document's URL and
+// captures its ID
+var googleSheetPath = regexp.MustCompile(`^/spreadsheets/d/([A-Za-z0-9_-]+)`)
+
+// googleSheetGID finds the sheet of a Google Sheets URL, e.g. in #gid=0
+var googleSheetGID = regexp.MustCompile(`(?:^|[?&#])gid=([0-9]+)`)
+
+// sheetURL returns the URL a sheet's CSV is read from. A Google Sheets URL,
+// as shared, is turned into the URL of its CSV export; others are read as
+// they are.
+func sheetURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", invalid("url must be an https URL")
+	}
+	if err := checkSheetURL(parsed); err != nil {
+		return "", err
+	}
+	match := googleSheetPath.FindStringSubmatch(parsed.Path)
+	if parsed.Host != "docs.google.com" || match == nil {
+		return parsed.String(), nil
+	}
+	export := "https://docs.google.com/spreadsheets/d/" + match[1] + "/export?format=csv"
+	if gid := googleSheetGID.FindStringSubmatch(parsed.RawQuery + "#" + parsed.Fragment); gid != nil {
+		export += "&gid=" + gid[1]
+	}
+	return export, nil
+}
+
+// sheetRow is a word read from a row of a sheet
+type sheetRow struct {
+	Line int
+	Text string
+	models.DraftWord
+}
+
+// fetchSheet reads the rows of a sheet's CSV. Its first row names the
+// columns, which must include urdu, urdlish and english in any order;
+// other columns are ignored.
+func fetchSheet(ctx context.Context, rawURL string) ([]sheetRow, error) {
+	source, err := sheetURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sheet: %v", err)
+	}
+	resp, err := sheetClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sheet: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch sheet: unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSheetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet: %v", err)
+	}
+	if len(data) > maxSheetSize {
+		return nil, invalid("sheet is larger than %d bytes", maxSheetSize)
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, invalid("failed to read sheet's header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"urdu", "urdlish", "english"} {
+		if _, ok := columns[name]; !ok {
+			return nil, invalid("sheet has no %s column", name)
+		}
+	}
+	cell := func(record []string, name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []sheetRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalid("failed to read sheet: %v", err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxSheetRows {
+			return nil, invalid("sheet has more than %d rows", maxSheetRows)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, sheetRow{
+			Line: line,
+			Text: strings.Join(record, ","),
+			DraftWord: models.DraftWord{
+				Urdu:    cell(record, "urdu"),
+				Urdlish: cell(record, "urdlish"),
+				English: cell(record, "english"),
+			},
+		})
+	}
+	return rows, nil
+}
+
+// groupSyncColumns are the columns scanGroupSync reads
+const groupSyncColumns = `id, user_id, group_id, url, interval_minutes, next_sync_at, last_synced_at,
+	last_error, last_report, created_at`
+
+func scanGroupSync(row interface{ Scan(...interface{}) error }) (*models.GroupSync, error) {
+	var (
+		sync         models.GroupSync
+		nextSyncAt   sql.NullTime
+		lastSyncedAt sql.NullTime
+		lastReport   sql.NullString
+	)
+	err := row.Scan(&sync.ID, &sync.UserID, &sync.GroupID, &sync.URL, &sync.IntervalMinutes,
+		&nextSyncAt, &lastSyncedAt, &sync.LastError, &lastReport, &sync.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if nextSyncAt.Valid {
+		sync.NextSyncAt = &nextSyncAt.Time
+	}
+	if lastSyncedAt.Valid {
+		sync.LastSyncedAt = &lastSyncedAt.Time
+	}
+	if lastReport.Valid {
+		if err := json.Unmarshal([]byte(lastReport.String), &sync.LastReport); err != nil {
+			return nil, fmt.Errorf("failed to decode sync report: %v", err)
+		}
+	}
+	return &sync, nil
+}
+
+// checkSyncSettings checks a sync's URL and interval and returns when it
+// next runs, if it runs on a schedule
+func checkSyncSettings(rawURL string, intervalMinutes int) (sql.NullTime, error) {
+	if _, err := sheetURL(rawURL); err != nil {
+		return sql.NullTime{}, err
+	}
+	if intervalMinutes == 0 {
+		return sql.NullTime{}, nil
+	}
+	if intervalMinutes < minSyncInterval || intervalMinutes > maxSyncInterval {
+		return sql.NullTime{}, invalid("interval_minutes must be 0, to sync only on request, or between %d and %d", minSyncInterval, maxSyncInterval)
+	}
+	return sql.NullTime{Time: time.Now().UTC().Add(time.Duration(intervalMinutes) * time.Minute), Valid: true}, nil
+}
+
+// CreateGroupSync keeps a group in step with the sheet or CSV file at
+// rawURL, every intervalMinutes or, if 0, only when asked to. A group has
+// at most one sync.
+func (s *Service) CreateGroupSync(ctx context.Context, groupID int64, rawURL string, intervalMinutes int) (*models.GroupSync, error) {
+	if err := s.checkGroupEditor(ctx, s.db, groupID); err != nil {
+		return nil, err
+	}
+	nextSyncAt, err := checkSyncSettings(rawURL, intervalMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var existing int64
+		err := tx.QueryRowContext(ctx, `SELECT id FROM group_syncs WHERE group_id = ?`, groupID).Scan(&existing)
+		if err == nil {
+			return conflict("group %d is already synced by sync %d", groupID, existing)
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("failed to look up group sync: %v", err)
+		}
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO group_syncs (user_id, group_id, url, interval_minutes, next_sync_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`, s.userID, groupID, strings.TrimSpace(rawURL), intervalMinutes, nextSyncAt, time.Now().UTC()).Scan(&id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroupSync(ctx, id)
+}
+
+// ListGroupSyncs returns the user's group syncs
+func (s *Service) ListGroupSyncs(ctx context.Context) ([]models.GroupSync, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+groupSyncColumns+` FROM group_syncs WHERE user_id = ? ORDER BY id
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group syncs: %v", err)
+	}
+	defer rows.Close()
+
+	syncs := []models.GroupSync{}
+	for rows.Next() {
+		sync, err := scanGroupSync(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group sync: %v", err)
+		}
+		syncs = append(syncs, *sync)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group syncs: %v", err)
+	}
+	return syncs, nil
+}
+
+// GetGroupSync returns one of the user's group syncs
+func (s *Service) GetGroupSync(ctx context.Context, id int64) (*models.GroupSync, error) {
+	sync, err := scanGroupSync(s.db.QueryRowContext(ctx, `
+		SELECT `+groupSyncColumns+` FROM group_syncs WHERE id = ? AND user_id = ?
+	`, id, s.userID))
+	if err == sql.ErrNoRows {
+		return nil, notFound("group sync not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group sync: %v", err)
+	}
+	return sync, nil
+}
+
+// UpdateGroupSync changes where a sync reads from and how often. Its next
+// scheduled run is an interval from now.
+func (s *Service) UpdateGroupSync(ctx context.Context, id int64, rawURL string, intervalMinutes int) (*models.GroupSync, error) {
+	nextSyncAt, err := checkSyncSettings(rawURL, intervalMinutes)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE group_syncs SET url = ?, interval_minutes = ?, next_sync_at = ? WHERE id = ? AND user_id = ?
+	`, strings.TrimSpace(rawURL), intervalMinutes, nextSyncAt, id, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update group sync: %v", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return nil, notFound("group sync not found")
+	}
+	return s.GetGroupSync(ctx, id)
+}
+
+// DeleteGroupSync stops syncing a group. The group keeps its words.
+func (s *Service) DeleteGroupSync(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM group_syncs WHERE id = ? AND user_id = ?`, id, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete group sync: %v", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+		return notFound("group sync not found")
+	}
+	return nil
+}
+
+// EnqueueGroupSync queues running a sync now
+func (s *Service) EnqueueGroupSync(ctx context.Context, id int64) (*models.Job, error) {
+	sync, err := s.GetGroupSync(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGroupEditor(ctx, s.db, sync.GroupID); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobGroupSync, groupSyncJob{SyncID: id})
+}
+
+// RunGroupSync reads a sync's sheet and makes its group's words those of
+// the sheet: rows whose urdu isn't in the group are added, words whose
+// urdlish or english differ from their row are updated, and words whose
+// urdu isn't in the sheet are removed from the group, though not deleted.
+// Rows with problems are reported and left out. A dry run reports what
+// would change without changing anything; other runs are recorded as the
+// sync's last. The sync's user must still be able to change the group and
+// the words it updates.
+func (s *Service) RunGroupSync(ctx context.Context, id int64, dryRun bool) (*models.SyncReport, error) {
+	sync, err := s.GetGroupSync(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGroupEditor(ctx, s.db, sync.GroupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := fetchSheet(ctx, sync.URL)
+	var report *models.SyncReport
+	if err == nil && dryRun {
+		report, err = s.diffGroupSync(ctx, s.db, sync.GroupID, rows)
+	} else if err == nil {
+		err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+			var err error
+			report, err = s.diffGroupSync(ctx, tx, sync.GroupID, rows)
+			if err != nil {
+				return err
+			}
+			return s.applyGroupSync(ctx, tx, sync.GroupID, report)
+		})
+	}
+	if dryRun {
+		if err != nil {
+			return nil, err
+		}
+		report.DryRun = true
+		return report, nil
+	}
+
+	reportJSON := sql.NullString{}
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	} else if data, err := json.Marshal(report); err == nil {
+		reportJSON = sql.NullString{String: string(data), Valid: true}
+	}
+	if _, recordErr := s.db.ExecContext(ctx, `
+		UPDATE group_syncs SET last_synced_at = ?, last_error = ?, last_report = COALESCE(?, last_report) WHERE id = ?
+	`, time.Now().UTC(), lastError, reportJSON, id); recordErr != nil {
+		log.Printf("Failed to record group sync %d: %v", id, recordErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// syncQuerier is the database or a transaction a sync is diffed in
+type syncQuerier interface {
+	queryRower
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*models.Rows, error)
+}
+
+// diffGroupSync compares the words of a group with a sheet's rows, which
+// are words of the group's language
+func (s *Service) diffGroupSync(ctx context.Context, q syncQuerier, groupID int64, rows []sheetRow) (*models.SyncReport, error) {
+	var language string
+	if err := q.QueryRowContext(ctx, `SELECT language FROM groups WHERE id = ?`, groupID).Scan(&language); err != nil {
+		return nil, fmt.Errorf("failed to get group language: %v", err)
+	}
+	words, err := q.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM words w
+		JOIN words_groups wg ON wg.word_id = w.id
+		WHERE wg.group_id = ?
+		ORDER BY w.id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group words: %v", err)
+	}
+	var current []models.DraftWord
+	byUrdu := map[string]models.DraftWord{}
+	for words.Next() {
+		var word models.DraftWord
+		if err := words.Scan(&word.WordID, &word.Urdu, &word.Urdlish, &word.English); err != nil {
+			words.Close()
+			return nil, fmt.Errorf("failed to scan group word: %v", err)
+		}
+		current = append(current, word)
+		if _, ok := byUrdu[spelling.Normalize(word.Urdu)]; !ok {
+			byUrdu[spelling.Normalize(word.Urdu)] = word
+		}
+	}
+	words.Close()
+	if err := words.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group words: %v", err)
+	}
+
+	report := &models.SyncReport{
+		Rows:    len(rows),
+		Added:   []models.DraftWord{},
+		Updated: []models.SyncUpdate{},
+		Removed: []models.DraftWord{},
+		Invalid: []models.ImportCandidate{},
+	}
+	drafts := make([]models.DraftWord, len(rows))
+	for i, row := range rows {
+		drafts[i] = row.DraftWord
+	}
+	problems := groupgen.Problems(drafts)
+
+	inSheet := map[string]bool{}
+	for i, row := range rows {
+		urdu := spelling.Normalize(row.Urdu)
+		if urdu != "" {
+			inSheet[urdu] = true
+		}
+		if problems[i] != "" {
+			report.Invalid = append(report.Invalid, models.ImportCandidate{
+				Line: row.Line, Text: row.Text, DraftWord: row.DraftWord, Problem: problems[i],
+			})
+			continue
+		}
+		existing, ok := byUrdu[urdu]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, row.DraftWord)
+		case existing.Urdlish != row.Urdlish || existing.English != row.English:
+			report.Updated = append(report.Updated, models.SyncUpdate{
+				DraftWord:       models.DraftWord{Urdu: existing.Urdu, Urdlish: row.Urdlish, English: row.English, WordID: existing.WordID},
+				PreviousUrdlish: existing.Urdlish,
+				PreviousEnglish: existing.English,
+			})
+		default:
+			report.Unchanged++
+		}
+	}
+	for _, word := range current {
+		if !inSheet[spelling.Normalize(word.Urdu)] {
+			report.Removed = append(report.Removed, word)
+		}
+	}
+
+	// Added words that already exist elsewhere are linked rather than
+	// created again
+	if err := linkExistingWords(ctx, q, language, report.Added); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// applyGroupSync makes the changes of a sync's report
+func (s *Service) applyGroupSync(ctx context.Context, tx *models.Tx, groupID int64, report *models.SyncReport) error {
+	var language string
+	if err := tx.QueryRowContext(ctx, `SELECT language FROM groups WHERE id = ?`, groupID).Scan(&language); err != nil {
+		return fmt.Errorf("failed to get group language: %v", err)
+	}
+	for _, update := range report.Updated {
+		if err := s.checkWordEditor(ctx, tx, update.WordID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE words SET transliteration = ?, english = ? WHERE id = ?
+		`, update.Urdlish, update.English, update.WordID); err != nil {
+			return fmt.Errorf("failed to update word: %v", err)
+		}
+		s.publishAfterCommit(ctx, tx, events.WordUpdated{UserID: s.userID, WordID: update.WordID, At: time.Now().UTC()})
+	}
+	for _, word := range report.Removed {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM words_groups WHERE group_id = ? AND word_id = ?
+		`, groupID, word.WordID); err != nil {
+			return fmt.Errorf("failed to remove word from group: %v", err)
+		}
+	}
+	wordIDs, _, err := s.createDraftWords(ctx, tx, language, report.Added)
+	if err != nil {
+		return err
+	}
+	// AddWords also recounts the group's words after the removals
+	return s.groups.AddWords(ctx, tx, groupID, wordIDs)
+}
+
+// StartGroupSyncs queues the group syncs that are due, now and then every
+// minute until the service is closed
+func (s *Service) StartGroupSyncs() {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		for {
+			if err := s.queueDueGroupSyncs(context.Background()); err != nil {
+				log.Printf("Failed to queue group syncs: %v", err)
+			}
+			select {
+			case <-time.After(syncPollInterval):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// queueDueGroupSyncs queues a job for each sync due to run and schedules
+// its next run
+func (s *Service) queueDueGroupSyncs(ctx context.Context) error {
+	now := time.Now().UTC()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, interval_minutes, next_sync_at FROM group_syncs
+		WHERE interval_minutes > 0 AND next_sync_at <= ?
+		ORDER BY next_sync_at
+	`, now)
+	if err != nil {
+		return fmt.Errorf("failed to find due group syncs: %v", err)
+	}
+	type due struct {
+		id, userID int64
+		interval   int
+		at         time.Time
+	}
+	var syncs []due
+	for rows.Next() {
+		var sync due
+		if err := rows.Scan(&sync.id, &sync.userID, &sync.interval, &sync.at); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan group sync: %v", err)
+		}
+		syncs = append(syncs, sync)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating group syncs: %v", err)
+	}
+
+	for _, sync := range syncs {
+		// Checking the time again keeps another server sharing a
+		// PostgreSQL database from queueing the sync too
+		result, err := s.db.ExecContext(ctx, `
+			UPDATE group_syncs SET next_sync_at = ? WHERE id = ? AND next_sync_at = ?
+		`, now.Add(time.Duration(sync.interval)*time.Minute), sync.id, sync.at)
+		if err != nil {
+			return fmt.Errorf("failed to schedule group sync: %v", err)
+		}
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+			continue
+		}
+		if _, err := s.runner.Enqueue(ctx, sync.userID, JobGroupSync, groupSyncJob{SyncID: sync.id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/models"
+	"log"
+	"time"
+)
+
+// GuestTTL is how long a guest identity, and the history recorded under it,
+// is kept before it is purged
+const GuestTTL = 24 * time.Hour
+
+// guestPurgeInterval is how often expired guests are purged
+const guestPurgeInterval = time.Hour
+
+// guestExpiresAtLayout is how guest expiry times are stored, so they compare
+// as text
+const guestExpiresAtLayout = "2006-01-02 15:04:05"
+
+// CreateGuest adds a guest user without a password that expires after
+// GuestTTL. Its username is "guest-" and a random suffix.
+func (s *Service) CreateGuest(ctx context.Context, now time.Time) (*models.User, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate guest name: %v", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (username, guest_expires_at) VALUES (?, ?)
+	`, "guest-"+hex.EncodeToString(suffix), now.Add(GuestTTL).UTC().Format(guestExpiresAtLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create guest: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user id: %v", err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+// claimGuestStatements move everything of the guest ?1 to the user ?2 and
+// then delete the guest. Every table with a user's rows is here, since the
+// rows left behind are deleted with the guest. Where both have learning
+// state for a word the more recently reviewed one is kept, and where both
+// have a role on a group the higher one; otherwise the user's own goals,
+// settings, links and progress win over the guest's. Daily stats are rolled
+// up again.
+var claimGuestStatements = []string{
+	`UPDATE study_sessions SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE word_review_items SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM word_learning_state WHERE user_id = ?2 AND word_id IN (
+		SELECT g.word_id FROM word_learning_state g
+		WHERE g.user_id = ?1
+		AND COALESCE(g.last_reviewed_at, '') > COALESCE(word_learning_state.last_reviewed_at, '')
+	)`,
+	`DELETE FROM word_learning_state WHERE user_id = ?1 AND word_id IN (
+		SELECT word_id FROM word_learning_state WHERE user_id = ?2
+	)`,
+	`UPDATE word_learning_state SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE OR IGNORE goals SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM goals WHERE user_id = ?1`,
+	`UPDATE OR IGNORE class_students SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM class_students WHERE user_id = ?1`,
+	`UPDATE classes SET teacher_id = ?2 WHERE teacher_id = ?1`,
+	`UPDATE OR IGNORE grammar_progress SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM grammar_progress WHERE user_id = ?1`,
+	`UPDATE grammar_answers SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE OR IGNORE bot_questions SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM bot_questions WHERE user_id = ?1`,
+	`DELETE FROM group_permissions WHERE user_id = ?2 AND group_id IN (
+		SELECT g.group_id FROM group_permissions g
+		WHERE g.user_id = ?1
+		AND CASE g.role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END >
+			CASE group_permissions.role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END
+	)`,
+	`UPDATE OR IGNORE group_permissions SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM group_permissions WHERE user_id = ?1`,
+	`UPDATE group_drafts SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE group_syncs SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE jobs SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE OR IGNORE feature_flag_users SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM feature_flag_users WHERE user_id = ?1`,
+	`UPDATE OR IGNORE notification_preferences SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM notification_preferences WHERE user_id = ?1`,
+	`UPDATE OR IGNORE notification_deliveries SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM notification_deliveries WHERE user_id = ?1`,
+	`UPDATE OR IGNORE bot_links SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM bot_links WHERE user_id = ?1`,
+	`UPDATE OR IGNORE calendar_tokens SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM calendar_tokens WHERE user_id = ?1`,
+	`UPDATE push_subscriptions SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE api_keys SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE resets SET user_id = ?2 WHERE user_id = ?1`,
+	`UPDATE sync_tombstones SET user_id = ?2 WHERE user_id = ?1`,
+	`DELETE FROM daily_word_stats WHERE user_id = ?1`,
+	`DELETE FROM daily_group_stats WHERE user_id = ?1`,
+	`DELETE FROM daily_stats WHERE user_id = ?1`,
+	`DELETE FROM users WHERE id = ?1`,
+}
+
+// ClaimGuest moves the study history, groups, settings and everything else
+// of an unexpired guest to the service's user and deletes the guest, as
+// claimGuestStatements do.
+func (s *Service) ClaimGuest(ctx context.Context, guestID int64, now time.Time) error {
+	user, err := s.GetUser(ctx, s.userID)
+	if err != nil {
+		return err
+	}
+	if user.Guest {
+		return forbidden("guests cannot claim guest history")
+	}
+	guest, err := s.GetUser(ctx, guestID)
+	if err != nil || !guest.Guest {
+		return notFound("guest not found")
+	}
+
+	var firstDay sql.NullString
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		// Roll the guest's days up again under the user
+		err := tx.QueryRowContext(ctx, `
+			SELECT MIN(date(created_at)) FROM word_review_items WHERE user_id = ?
+		`, guestID).Scan(&firstDay)
+		if err != nil {
+			return fmt.Errorf("failed to get guest history: %v", err)
+		}
+		if firstDay.Valid {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE stats_rollup_state SET rolled_up_to = ? WHERE id = 1 AND rolled_up_to > ?
+			`, firstDay.String, firstDay.String)
+			if err != nil {
+				return fmt.Errorf("failed to rewind stats rollup: %v", err)
+			}
+		}
+
+		for _, query := range claimGuestStatements {
+			if _, err := tx.ExecContext(ctx, query, guestID, s.userID); err != nil {
+				return fmt.Errorf("failed to claim guest history: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if firstDay.Valid {
+		if _, err := s.RollupStats(ctx, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredGuests deletes the guests that expired before now, with their
+// study history and the classes they teach, and returns how many there were
+func (s *Service) PurgeExpiredGuests(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM users WHERE guest_expires_at IS NOT NULL AND guest_expires_at <= ?
+	`, now.UTC().Format(guestExpiresAtLayout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired guests: %v", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan guest: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		guest := s.ForUser(id)
+		err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+			return guest.deleteHistory(ctx, tx)
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, query := range []string{
+			`DELETE FROM class_assignments WHERE class_id IN (SELECT id FROM classes WHERE teacher_id = ?1)`,
+			`DELETE FROM class_students WHERE class_id IN (SELECT id FROM classes WHERE teacher_id = ?1)`,
+			`DELETE FROM classes WHERE teacher_id = ?1`,
+			`DELETE FROM class_students WHERE user_id = ?1`,
+			`DELETE FROM goals WHERE user_id = ?1`,
+			`DELETE FROM users WHERE id = ?1`,
+		} {
+			if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+				return 0, fmt.Errorf("failed to purge guest: %v", err)
+			}
+		}
+	}
+	return len(ids), nil
+}
+
+// StartGuestPurge purges expired guests now and then every hour until the
+// service is closed
+func (s *Service) StartGuestPurge() {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if purged, err := s.PurgeExpiredGuests(ctx, time.Now()); err != nil {
+				log.Printf("Guest purge failed: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired guests", purged)
+			}
+
+			select {
+			case <-time.After(guestPurgeInterval):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
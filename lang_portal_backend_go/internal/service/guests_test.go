@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaimGuest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc := newTestService(t, testGroups, `
+		INSERT INTO users (id, username, guest_expires_at) VALUES (6, 'guest-a1b2c3', '2999-01-01 00:00:00');
+		INSERT INTO grammar_topics (id, slug, title, level) VALUES
+		(1, 'postpositions', 'Postpositions', 'A1'),
+		(2, 'future-tense', 'Future tense', 'A2');
+		INSERT INTO grammar_progress (user_id, topic_id, completed_at) VALUES
+		(6, 1, '2024-03-10 10:00:00'),
+		(6, 2, NULL),
+		(2, 2, '2024-03-09 10:00:00');
+		INSERT INTO groups (id, name) VALUES (4, 'Guest words');
+		INSERT INTO group_permissions (group_id, user_id, role) VALUES (4, 6, 'owner'), (2, 6, 'viewer');
+	`)
+
+	if err := svc.ForUser(testOwner).ClaimGuest(ctx, 6, now); err != nil {
+		t.Fatalf("ClaimGuest() error = %v", err)
+	}
+
+	var progress int
+	if err := svc.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM grammar_progress WHERE user_id = ? AND completed_at IS NOT NULL
+	`, testOwner).Scan(&progress); err != nil {
+		t.Fatalf("failed to count grammar progress: %v", err)
+	}
+	if progress != 2 {
+		t.Errorf("completed topics = %d, want the guest's and the user's own", progress)
+	}
+
+	owner := svc.ForUser(testOwner)
+	for _, groupID := range []int64{4, 2} {
+		access, err := owner.groupAccess(ctx, owner.db, groupID)
+		if err != nil {
+			t.Fatalf("groupAccess() error = %v", err)
+		}
+		if access.role != GroupOwner {
+			t.Errorf("user's role on group %d = %q, want %q", groupID, access.role, GroupOwner)
+		}
+	}
+	if _, err := svc.GetUser(ctx, 6); err == nil {
+		t.Error("guest still exists after being claimed")
+	}
+}
+
+// TestClaimGuestStatementsCoverUserTables guards against tables added later
+// losing a guest's rows when the guest is claimed and deleted
+func TestClaimGuestStatementsCoverUserTables(t *testing.T) {
+	svc := newTestService(t)
+	rows, err := svc.db.QueryContext(context.Background(), `
+		SELECT DISTINCT m.name FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND f."table" = 'users'
+	`)
+	if err != nil {
+		t.Fatalf("failed to list tables: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatalf("failed to scan table: %v", err)
+		}
+		found := false
+		for _, query := range claimGuestStatements {
+			found = found || strings.Contains(query, " "+table+" ")
+		}
+		if !found {
+			t.Errorf("claimGuestStatements don't move the guest's %s", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
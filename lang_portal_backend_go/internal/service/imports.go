@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lang_portal/internal/groupgen"
+	"lang_portal/internal/models"
+	"lang_portal/internal/ocr"
+	"net/http"
+	"strings"
+)
+
+// MaxImportWords is the most words imported at once
+const MaxImportWords = 500
+
+// ocrProvider returns the OCR engine configured by the environment
+func ocrProvider() (ocr.Provider, error) {
+	provider, err := ocr.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid OCR settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no OCR engine is configured, set %s", ocr.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// importColumns reads the order of a word list's columns, e.g.
+// "urdu,urdlish,english", and reports whether english comes before
+// urdlish. Urdu is told apart by its script, so only their order matters.
+func importColumns(columns string) (bool, error) {
+	if strings.TrimSpace(columns) == "" {
+		return false, nil
+	}
+	position := map[string]int{}
+	for i, column := range strings.Split(columns, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "urdu", "urdlish", "english":
+		default:
+			return false, invalid("unknown column %q: use urdu, urdlish and english", column)
+		}
+		if _, ok := position[column]; ok {
+			return false, invalid("column %q is given twice", column)
+		}
+		position[column] = i
+	}
+	urdlish, ok := position["urdlish"]
+	return ok && position["english"] < urdlish, nil
+}
+
+// ImportImage reads the words of a photo or scan of a printed word list,
+// with the configured OCR engine, and returns them as candidates to confirm
+// with ImportWords. columns is the order of the list's columns, by default
+// urdu,urdlish,english. Nothing is stored.
+func (s *Service) ImportImage(ctx context.Context, data []byte, columns string) (*models.ImageImport, error) {
+	englishFirst, err := importColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case ocr.PNG, ocr.JPEG, ocr.GIF, ocr.WebP, ocr.PDF:
+	default:
+		return nil, invalid("unsupported file type %s: use a PNG, JPEG, GIF or WebP image or a PDF", contentType)
+	}
+	provider, err := ocrProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := provider.Recognize(ctx, data, contentType)
+	if errors.Is(err, ocr.ErrUnsupportedType) {
+		return nil, unsupported("%v", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text: %v", err)
+	}
+
+	rows := ocr.ParseRows(text, englishFirst)
+	if len(rows) > MaxImportWords {
+		rows = rows[:MaxImportWords]
+	}
+	words := make([]models.DraftWord, len(rows))
+	for i, row := range rows {
+		words[i] = models.DraftWord{Urdu: row.Urdu, Urdlish: row.Urdlish, English: row.English}
+	}
+	if err := linkExistingWords(ctx, s.db, s.newWordLanguage(""), words); err != nil {
+		return nil, err
+	}
+	problems := groupgen.Problems(words)
+
+	result := &models.ImageImport{Provider: provider.Name(), Text: text, Candidates: []models.ImportCandidate{}}
+	for i, row := range rows {
+		result.Candidates = append(result.Candidates, models.ImportCandidate{
+			Line:      row.Line,
+			Text:      row.Text,
+			DraftWord: words[i],
+			Problem:   problems[i],
+		})
+	}
+	return result, nil
+}
+
+// ImportWords adds confirmed words, such as the candidates of ImportImage,
+// in one transaction, and adds them to the group groupID unless it is 0.
+// Words are checked as generated groups' words are. Words that already
+// exist aren't created again. They are words of the group's language, or
+// without a group of the service's.
+func (s *Service) ImportWords(ctx context.Context, words []models.DraftWord, groupID int64) (*models.ImportWordsResult, error) {
+	if len(words) == 0 || len(words) > MaxImportWords {
+		return nil, invalid("give between 1 and %d words", MaxImportWords)
+	}
+	words, dropped := groupgen.Clean(words, len(words))
+	if len(dropped) > 0 {
+		return nil, invalid("word %q: %s", dropped[0].English, dropped[0].Reason)
+	}
+	language := s.newWordLanguage("")
+	if groupID != 0 {
+		group, err := s.GetGroup(ctx, groupID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkGroupEditor(ctx, s.db, groupID); err != nil {
+			return nil, err
+		}
+		language = group.Language
+	}
+
+	result := &models.ImportWordsResult{GroupID: groupID}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		wordIDs, created, err := s.createDraftWords(ctx, tx, language, words)
+		if err != nil {
+			return err
+		}
+		result.WordIDs = wordIDs
+		result.Created = created
+		result.Existing = len(wordIDs) - created
+		if groupID == 0 {
+			return nil
+		}
+
+		// Existing words may be in the group already
+		var added []int64
+		for _, id := range wordIDs {
+			var member bool
+			if err := tx.QueryRowContext(ctx, `
+				SELECT EXISTS (SELECT 1 FROM words_groups WHERE group_id = ? AND word_id = ?)
+			`, groupID, id).Scan(&member); err != nil {
+				return fmt.Errorf("failed to look up group word: %v", err)
+			}
+			if !member {
+				added = append(added, id)
+			}
+		}
+		return s.groups.AddWords(ctx, tx, groupID, added)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
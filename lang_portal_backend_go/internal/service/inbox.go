@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"lang_portal/internal/translate"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InboxGroupName is the group captured words are put in, to be curated
+// into other groups later. A learner's inbox is named after them, as in
+// "Inbox (alice)".
+const InboxGroupName = "Inbox"
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to find
+	apiKeyPrefix = "lpk_"
+	// apiKeyShownPrefix is how much of a key is kept to tell keys apart by
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+	// maxAPIKeys is how many API keys a user can have
+	maxAPIKeys = 20
+	// maxAPIKeyNameLength is the longest name of an API key
+	maxAPIKeyNameLength = 100
+	// maxInboxFieldLength is the longest field of a captured word
+	maxInboxFieldLength = 100
+)
+
+// hashAPIKey returns the hash an API key is kept as
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey gives the user a new API key named name. The key is only
+// returned here.
+func (s *Service) CreateAPIKey(ctx context.Context, name string, now time.Time) (*models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, invalid("name must be 1 to %d characters", maxAPIKeyNameLength)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %v", err)
+	}
+	key := &models.APIKey{
+		Name:      name,
+		Key:       apiKeyPrefix + hex.EncodeToString(secret),
+		CreatedAt: now.UTC(),
+	}
+	key.Prefix = key.Key[:apiKeyShownPrefix]
+
+	// The insert returns a row, so it runs in a transaction to take the
+	// writer's turn, which also keeps the count from changing under it
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var count int
+		if err := tx.QueryRowContext(ctx, s.dialect.Rebind(`SELECT COUNT(*) FROM api_keys WHERE user_id = ?`), s.userID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count API keys: %v", err)
+		}
+		if count >= maxAPIKeys {
+			return conflict("a user can have at most %d API keys", maxAPIKeys)
+		}
+		if err := tx.QueryRowContext(ctx, s.dialect.Rebind(`
+			INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at)
+			VALUES (?, ?, ?, ?, ?)
+			RETURNING id
+		`), s.userID, key.Name, key.Prefix, hashAPIKey(key.Key), key.CreatedAt).Scan(&key.ID); err != nil {
+			return fmt.Errorf("failed to save API key: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the user's API keys, without the keys themselves
+func (s *Service) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, prefix, created_at, last_used_at
+		FROM api_keys WHERE user_id = ?
+		ORDER BY id
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var (
+			key      models.APIKey
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &key.CreatedAt, &lastUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %v", err)
+		}
+		if lastUsed.Valid {
+			key.LastUsedAt = &lastUsed.Time
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %v", err)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey revokes one of the user's API keys
+func (s *Service) DeleteAPIKey(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %v", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	} else if deleted == 0 {
+		return notFound("API key not found")
+	}
+	return nil
+}
+
+// APIKeyUser returns the user an API key was given to, and records that the
+// key was used
+func (s *Service) APIKeyUser(ctx context.Context, key string, now time.Time) (int64, error) {
+	if key == "" {
+		return 0, unauthorized("missing API key")
+	}
+	var id, userID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id FROM api_keys WHERE key_hash = ?
+	`, hashAPIKey(key)).Scan(&id, &userID)
+	if err == sql.ErrNoRows {
+		return 0, unauthorized("invalid API key")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to check API key: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now.UTC(), id); err != nil {
+		return 0, fmt.Errorf("failed to record API key use: %v", err)
+	}
+	return userID, nil
+}
+
+// CaptureInboxWord puts a word sent by an external automation in the user's
+// Inbox group of its language, creating the group the first time. Only the
+// word's urdu or its english is needed; text is either, told apart by its
+// script.
+// Missing fields of Urdu words are suggested by the configured translation
+// provider, if there is one, and are otherwise left empty for the word to
+// be curated later. A word that already exists is put in the group rather
+// than created again.
+func (s *Service) CaptureInboxWord(ctx context.Context, text string, word models.Word) (*models.InboxWord, error) {
+	language := s.newWordLanguage(word.Language)
+	if err := s.checkLanguage(ctx, s.db, language); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	captured := &models.InboxWord{
+		Urdu:      strings.TrimSpace(word.Urdu),
+		Urdlish:   strings.TrimSpace(word.Urdlish),
+		English:   strings.TrimSpace(word.English),
+		Suggested: []string{},
+	}
+	if text != "" {
+		field, name := &captured.English, "english"
+		if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) }) >= 0 {
+			field, name = &captured.Urdu, "urdu"
+		}
+		if *field != "" {
+			return nil, invalid("give text or %s, not both", name)
+		}
+		*field = text
+	}
+	if captured.Urdu == "" && captured.English == "" {
+		return nil, invalid("give text, urdu or english")
+	}
+	for _, field := range []string{captured.Urdu, captured.Urdlish, captured.English} {
+		if utf8.RuneCountInString(field) > maxInboxFieldLength {
+			return nil, invalid("fields must be at most %d characters", maxInboxFieldLength)
+		}
+	}
+	// The translation providers only translate Urdu
+	if language == DefaultLanguage && (captured.Urdu == "" || captured.Urdlish == "" || captured.English == "") {
+		s.suggestInboxWord(ctx, captured)
+	}
+
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		groupID, err := s.inboxGroupID(ctx, tx, language)
+		if err != nil {
+			return err
+		}
+		captured.GroupID = groupID
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM words
+			WHERE (script = ? OR ? = '') AND (lower(english) = lower(?) OR ? = '') AND language = ?
+			ORDER BY id LIMIT 1
+		`, captured.Urdu, captured.Urdu, captured.English, captured.English, language).Scan(&captured.WordID)
+		if err == sql.ErrNoRows {
+			word := &models.Word{Urdu: captured.Urdu, Urdlish: captured.Urdlish, English: captured.English, Language: language}
+			if err := s.words.Create(ctx, tx, word); err != nil {
+				return err
+			}
+			captured.WordID = word.ID
+			captured.Created = true
+			s.publishAfterCommit(ctx, tx, events.WordCreated{
+				UserID:  s.userID,
+				WordID:  word.ID,
+				Urdu:    word.Urdu,
+				English: word.English,
+				At:      time.Now().UTC(),
+			})
+		} else if err != nil {
+			return fmt.Errorf("failed to look up word: %v", err)
+		}
+
+		return s.addGroupWord(ctx, tx, captured.GroupID, captured.WordID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return captured, nil
+}
+
+// inboxGroupID returns the user's inbox group of a language, creating it if
+// need be. Each learner owns their inbox, named after them since group names
+// are unique, so words they capture stay private to them; admins share the
+// Inbox nobody owns, as they do every group they create.
+func (s *Service) inboxGroupID(ctx context.Context, tx *models.Tx, language string) (int64, error) {
+	admin, err := s.isAdmin(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	name := InboxGroupName
+	if !admin {
+		var username string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT username FROM users WHERE id = ?
+		`, s.userID).Scan(&username); err != nil {
+			return 0, fmt.Errorf("failed to look up user: %v", err)
+		}
+		name = fmt.Sprintf("%s (%s)", InboxGroupName, username)
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT g.id FROM groups g
+		WHERE g.name = ? AND g.language = ? AND CASE WHEN ?
+			THEN NOT EXISTS (SELECT 1 FROM group_permissions gp WHERE gp.group_id = g.id)
+			ELSE EXISTS (SELECT 1 FROM group_permissions gp WHERE gp.group_id = g.id AND gp.user_id = ? AND gp.role = ?)
+		END
+	`, name, language, admin, s.userID, GroupOwner).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get inbox group: %v", err)
+	}
+	if err := s.checkGroupName(ctx, tx, language, name); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO groups (name, language) VALUES (?, ?) RETURNING id
+	`, name, language).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to create inbox group: %v", err)
+	}
+	if err := s.ownNewGroup(ctx, tx, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// addGroupWord adds a word to a group unless it is already in it
+func (s *Service) addGroupWord(ctx context.Context, tx *models.Tx, groupID, wordID int64) error {
+	var member bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words_groups WHERE group_id = ? AND word_id = ?)
+	`, groupID, wordID).Scan(&member); err != nil {
+		return fmt.Errorf("failed to look up group word: %v", err)
+	}
+	if member {
+		return nil
+	}
+	return s.groups.AddWords(ctx, tx, groupID, []int64{wordID})
+}
+
+// suggestInboxWord fills in the empty fields of a captured word with the
+// translation provider's suggestion, from its urdu if it has one. Words are
+// captured without suggestions when there is no provider or it fails.
+func (s *Service) suggestInboxWord(ctx context.Context, captured *models.InboxWord) {
+	provider, err := translate.FromEnv()
+	if err != nil || provider == nil {
+		return
+	}
+	language, text := translate.English, captured.English
+	if captured.Urdu != "" {
+		language, text = translate.Urdu, captured.Urdu
+	}
+	suggestion, _, err := s.suggest(ctx, provider, language, text)
+	if err != nil {
+		log.Printf("Failed to suggest inbox word %q: %v", text, err)
+		return
+	}
+	for _, field := range []struct {
+		name      string
+		value     *string
+		suggested string
+	}{
+		{"urdu", &captured.Urdu, suggestion.Urdu},
+		{"urdlish", &captured.Urdlish, suggestion.Urdlish},
+		{"english", &captured.English, suggestion.English},
+	} {
+		if *field.value == "" && strings.TrimSpace(field.suggested) != "" {
+			*field.value = strings.TrimSpace(field.suggested)
+			captured.Suggested = append(captured.Suggested, field.name)
+		}
+	}
+}
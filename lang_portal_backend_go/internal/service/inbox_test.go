@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"testing"
+)
+
+func TestCaptureInboxWordPerUser(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, testGroups)
+	capture := func(userID int64, english string) int64 {
+		t.Helper()
+		captured, err := svc.ForUser(userID).CaptureInboxWord(ctx, english, models.Word{})
+		if err != nil {
+			t.Fatalf("CaptureInboxWord() by user %d error = %v", userID, err)
+		}
+		return captured.GroupID
+	}
+
+	owner := capture(testOwner, "river")
+	var name string
+	if err := svc.db.QueryRowContext(ctx, `SELECT name FROM groups WHERE id = ?`, owner).Scan(&name); err != nil {
+		t.Fatalf("failed to look up inbox group: %v", err)
+	}
+	if want := "Inbox (owner)"; name != want {
+		t.Errorf("inbox group name = %q, want %q", name, want)
+	}
+	if again := capture(testOwner, "mountain"); again != owner {
+		t.Errorf("second capture went to group %d, want the user's inbox %d", again, owner)
+	}
+	viewer := capture(testViewer, "river")
+	if viewer == owner {
+		t.Errorf("users share inbox group %d, want one each", owner)
+	}
+	admin := capture(testAdmin, "river")
+	if admin == owner || admin == viewer {
+		t.Errorf("admin captured into learner's inbox group %d", admin)
+	}
+
+	for _, tt := range []struct {
+		userID  int64
+		groupID int64
+	}{{testOwner, owner}, {testViewer, viewer}} {
+		permissions, err := svc.ListGroupPermissions(ctx, tt.groupID)
+		if err != nil {
+			t.Fatalf("ListGroupPermissions() error = %v", err)
+		}
+		if len(permissions) != 1 || permissions[0].UserID != tt.userID || permissions[0].Role != GroupOwner {
+			t.Errorf("roles on user %d's inbox = %+v, want them its only owner", tt.userID, permissions)
+		}
+		s := svc.ForUser(testStranger)
+		if err := s.checkGroupViewer(ctx, s.db, tt.groupID); err == nil {
+			t.Errorf("another user sees user %d's inbox", tt.userID)
+		}
+	}
+}
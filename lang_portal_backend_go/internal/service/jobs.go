@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/jobs"
+	"lang_portal/internal/models"
+	"time"
+)
+
+// Kinds of background jobs
+const (
+	JobBackup        = "backup"
+	JobRollupStats   = "rollup_stats"
+	JobSeedPack      = "seed_pack"
+	JobBootstrap     = "bootstrap"
+	JobGenerateGroup = "generate_group"
+	JobGroupAudio    = "group_audio"
+	JobEnrichWords   = "enrich_words"
+	JobGroupSync     = "group_sync"
+	JobEmbedWords    = "embed_words"
+	JobLinkSentences = "link_sentences"
+
+	JobDispatchNotifications = "dispatch_notifications"
+	JobSendBotQuizzes        = "send_bot_quizzes"
+	JobDispatchPush          = "dispatch_push"
+)
+
+// seedPackJob is the payload of a seed pack job
+type seedPackJob struct {
+	Name string `json:"name"`
+}
+
+// bootstrapJob is the payload of a bootstrap job
+type bootstrapJob struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// generateGroupJob is the payload of a group generation job
+type generateGroupJob struct {
+	Topic string `json:"topic"`
+	Name  string `json:"name"`
+	Size  int    `json:"size"`
+}
+
+// groupAudioJob is the payload of a group audio job
+type groupAudioJob struct {
+	GroupID int64 `json:"group_id"`
+	Force   bool  `json:"force"`
+}
+
+// enrichWordsJob is the payload of a word enrichment job
+type enrichWordsJob struct {
+	Force bool `json:"force"`
+}
+
+// embedWordsJob is the payload of a word embedding job
+type embedWordsJob struct {
+	Force bool `json:"force"`
+	// WordIDs limits the job to the given words
+	WordIDs []int64 `json:"word_ids,omitempty"`
+}
+
+// linkSentencesJob is the payload of a sentence linking job
+type linkSentencesJob struct {
+	Language string `json:"language"`
+}
+
+// groupSyncJob is the payload of a group sync job
+type groupSyncJob struct {
+	SyncID int64 `json:"sync_id"`
+}
+
+// registerJobs sets the handlers of the kinds of jobs the service runs
+func (s *Service) registerJobs() {
+	s.runner.Register(JobBackup, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		return s.Backup(ctx, "")
+	})
+	s.runner.Register(JobRollupStats, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		rolledUpTo, err := s.RollupStats(ctx, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"rolled_up_to": rolledUpTo}, nil
+	})
+	s.runner.Register(JobSeedPack, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload seedPackJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid seed pack job: %v", err))
+		}
+		result, err := s.ApplySeedPack(ctx, payload.Name, false)
+		return result, jobError(err)
+	})
+	s.runner.Register(JobBootstrap, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload bootstrapJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid bootstrap job: %v", err))
+		}
+		result, err := s.Bootstrap(ctx, payload.URL, payload.SHA256)
+		return result, jobError(err)
+	})
+	s.runner.Register(JobGenerateGroup, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload generateGroupJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid group generation job: %v", err))
+		}
+		// The job runs as the user who queued it, who owns the draft
+		draft, err := s.ForUser(job.UserID).GenerateGroupDraft(ctx, payload.Topic, payload.Name, payload.Size)
+		return draft, jobError(err)
+	})
+	s.runner.Register(JobGroupAudio, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload groupAudioJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid group audio job: %v", err))
+		}
+		result, err := s.GenerateGroupAudio(ctx, payload.GroupID, payload.Force)
+		return result, jobError(err)
+	})
+	s.runner.Register(JobEnrichWords, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload enrichWordsJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid word enrichment job: %v", err))
+		}
+		result, err := s.EnrichWords(ctx, payload.Force)
+		return result, jobError(err)
+	})
+	s.runner.Register(JobEmbedWords, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload embedWordsJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid word embedding job: %v", err))
+		}
+		result, err := s.EmbedWords(ctx, payload.Force, payload.WordIDs)
+		return result, jobError(err)
+	})
+	s.runner.Register(JobLinkSentences, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload linkSentencesJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid sentence linking job: %v", err))
+		}
+		links, err := s.LinkSentences(ctx, payload.Language)
+		return links, jobError(err)
+	})
+	s.runner.Register(JobGroupSync, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		var payload groupSyncJob
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, jobs.Permanent(fmt.Errorf("invalid group sync job: %v", err))
+		}
+		// The job runs as the user who owns the sync
+		report, err := s.ForUser(job.UserID).RunGroupSync(ctx, payload.SyncID, false)
+		return report, jobError(err)
+	})
+	s.runner.Register(JobDispatchNotifications, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		dispatch, err := s.DispatchNotifications(ctx, time.Now())
+		return dispatch, jobError(err)
+	})
+	s.runner.Register(JobSendBotQuizzes, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		dispatch, err := s.SendBotQuizzes(ctx, time.Now())
+		return dispatch, jobError(err)
+	})
+	s.runner.Register(JobDispatchPush, func(ctx context.Context, job *models.Job) (interface{}, error) {
+		dispatch, err := s.DispatchPush(ctx, time.Now())
+		return dispatch, jobError(err)
+	})
+}
+
+// jobError marks the service errors retrying a job won't fix as permanent
+func jobError(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrValidation, ErrConflict, ErrForbidden, ErrUnsupported} {
+		if errors.Is(err, kind) {
+			return jobs.Permanent(err)
+		}
+	}
+	return err
+}
+
+// StartJobs starts running background jobs, first queueing again those that
+// were running when the server last stopped. Close stops them.
+func (s *Service) StartJobs() error {
+	return s.runner.Start(context.Background())
+}
+
+// GetJob returns a job the service's user queued
+func (s *Service) GetJob(ctx context.Context, id int64) (*models.Job, error) {
+	job, err := s.runner.Get(ctx, id)
+	if err == sql.ErrNoRows || err == nil && job.UserID != s.userID {
+		return nil, notFound("job %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %v", err)
+	}
+	return job, nil
+}
+
+// EnqueueBackup queues a backup of the database
+func (s *Service) EnqueueBackup(ctx context.Context) (*models.Job, error) {
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("backups are only supported on SQLite")
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobBackup, nil)
+}
+
+// EnqueueStatsRollup queues a rollup of the review stats
+func (s *Service) EnqueueStatsRollup(ctx context.Context) (*models.Job, error) {
+	return s.runner.Enqueue(ctx, s.userID, JobRollupStats, nil)
+}
+
+// EnqueueSeedPack queues applying a seed pack. The pack is checked with a
+// dry run first, so a missing or invalid pack is reported at once.
+func (s *Service) EnqueueSeedPack(ctx context.Context, name string) (*models.Job, error) {
+	if _, err := s.ApplySeedPack(ctx, name, true); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobSeedPack, seedPackJob{Name: name})
+}
+
+// EnqueueBootstrap queues downloading and installing a starter catalog.
+// Empty arguments fall back to the environment, as for Bootstrap.
+func (s *Service) EnqueueBootstrap(ctx context.Context, url, checksum string) (*models.Job, error) {
+	url, checksum, err := catalogSource(url, checksum)
+	if err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobBootstrap, bootstrapJob{URL: url, SHA256: checksum})
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"regexp"
+	"strings"
+)
+
+// DefaultLanguage is the language of words and groups created without one,
+// and of everything created before there were other languages
+const DefaultLanguage = models.DefaultLanguage
+
+// Directions a language's script is written in
+const (
+	DirectionLTR = "ltr"
+	DirectionRTL = "rtl"
+)
+
+// languageCodePattern is what language codes look like: an ISO 639 code,
+// optionally with a region or variant, e.g. ja or pt-BR
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// scriptCodePattern is what ISO 15924 script codes look like, e.g. Arab
+var scriptCodePattern = regexp.MustCompile(`^[A-Z][a-z]{3}$`)
+
+// sessionInLanguage is a condition on study sessions selecting those of the
+// groups of the language bound to its two parameters, or every session if
+// the language is ""
+const sessionInLanguage = `(? = '' OR group_id IN (SELECT id FROM groups WHERE language = ?))`
+
+// ForLanguage returns a copy of the service whose word and group lists,
+// statistics and review queue only cover the given language, and which
+// creates words in it. The empty code covers every language.
+func (s *Service) ForLanguage(code string) *Service {
+	scoped := *s
+	scoped.language = code
+	return &scoped
+}
+
+// Language returns the language the service is scoped to, "" for every
+// language
+func (s *Service) Language() string {
+	return s.language
+}
+
+// newWordLanguage returns the language a word is created in: the one it
+// was given, or else the service's, or else the default
+func (s *Service) newWordLanguage(given string) string {
+	switch {
+	case given != "":
+		return given
+	case s.language != "":
+		return s.language
+	default:
+		return DefaultLanguage
+	}
+}
+
+// ListLanguages returns the languages words can be studied in
+func (s *Service) ListLanguages(ctx context.Context) ([]models.Language, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT code, name, script, direction, transliteration, created_at
+		FROM languages
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list languages: %v", err)
+	}
+	defer rows.Close()
+
+	languages := []models.Language{}
+	for rows.Next() {
+		var language models.Language
+		if err := rows.Scan(&language.Code, &language.Name, &language.Script, &language.Direction,
+			&language.Transliteration, &language.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan language: %v", err)
+		}
+		languages = append(languages, language)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating languages: %v", err)
+	}
+	return languages, nil
+}
+
+// GetLanguage returns a language by its code
+func (s *Service) GetLanguage(ctx context.Context, code string) (*models.Language, error) {
+	var language models.Language
+	err := s.db.QueryRowContext(ctx, `
+		SELECT code, name, script, direction, transliteration, created_at
+		FROM languages
+		WHERE code = ?
+	`, code).Scan(&language.Code, &language.Name, &language.Script, &language.Direction,
+		&language.Transliteration, &language.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("language %q not found", code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get language: %v", err)
+	}
+	return &language, nil
+}
+
+// KnownLanguage reports whether words can be studied in a language
+func (s *Service) KnownLanguage(ctx context.Context, code string) (bool, error) {
+	err := s.checkLanguage(ctx, s.db, code)
+	if errors.Is(err, ErrValidation) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkLanguage returns a validation error for a language that doesn't exist
+func (s *Service) checkLanguage(ctx context.Context, q repository.Querier, code string) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM languages WHERE code = ?)
+	`, code).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up language: %v", err)
+	}
+	if !exists {
+		return invalid("unknown language %q", code)
+	}
+	return nil
+}
+
+// SetLanguage adds a language words can be studied in, or changes how one is
+// written. A language can't be removed, as words and groups refer to it.
+func (s *Service) SetLanguage(ctx context.Context, language models.Language) (*models.Language, error) {
+	language.Name = strings.TrimSpace(language.Name)
+	language.Transliteration = strings.TrimSpace(language.Transliteration)
+	if language.Direction == "" {
+		language.Direction = DirectionLTR
+	}
+	switch {
+	case !languageCodePattern.MatchString(language.Code):
+		return nil, invalid("invalid language code %q: use an ISO 639 code, e.g. ja", language.Code)
+	case language.Name == "":
+		return nil, invalid("a language needs a name")
+	case !scriptCodePattern.MatchString(language.Script):
+		return nil, invalid("invalid script %q: use an ISO 15924 code, e.g. Arab", language.Script)
+	case language.Direction != DirectionLTR && language.Direction != DirectionRTL:
+		return nil, invalid("invalid direction %q: use %s or %s", language.Direction, DirectionLTR, DirectionRTL)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO languages (code, name, script, direction, transliteration) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO UPDATE SET
+			name = excluded.name,
+			script = excluded.script,
+			direction = excluded.direction,
+			transliteration = excluded.transliteration
+	`, language.Code, language.Name, language.Script, language.Direction, language.Transliteration); err != nil {
+		return nil, fmt.Errorf("failed to set language: %v", err)
+	}
+	return s.GetLanguage(ctx, language.Code)
+}
+
+// checkGroupLanguage returns a validation error if any of the words isn't of
+// the group's language. Words or a group that don't exist are left for the
+// foreign keys to reject.
+func checkGroupLanguage(ctx context.Context, q queryRower, groupID int64, wordIDs []int64) error {
+	for _, id := range wordIDs {
+		var wordLanguage, groupLanguage string
+		err := q.QueryRowContext(ctx, `
+			SELECT w.language, g.language FROM words w, groups g WHERE w.id = ? AND g.id = ?
+		`, id, groupID).Scan(&wordLanguage, &groupLanguage)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up word language: %v", err)
+		}
+		if wordLanguage != groupLanguage {
+			return invalid("word %d is %s but group %d is %s", id, wordLanguage, groupID, groupLanguage)
+		}
+	}
+	return nil
+}
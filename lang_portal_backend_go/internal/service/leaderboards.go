@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Leaderboard metrics
+const (
+	// LeaderboardReviews ranks by reviews completed in the week
+	LeaderboardReviews = "reviews"
+	// LeaderboardAccuracy ranks by the share of the week's reviews answered
+	// correctly
+	LeaderboardAccuracy = "accuracy"
+	// LeaderboardStreak ranks by consecutive days with reviews, ending on the
+	// last day of the week
+	LeaderboardStreak = "streak"
+)
+
+// minLeaderboardAccuracyReviews is how many reviews a user needs in a week to
+// be ranked by accuracy
+const minLeaderboardAccuracyReviews = 20
+
+// maxLeaderboardNameLength is the longest display name, in characters
+const maxLeaderboardNameLength = 32
+
+// GetLeaderboardSettings returns whether the user appears on leaderboards and
+// the name they appear under
+func (s *Service) GetLeaderboardSettings(ctx context.Context) (*models.LeaderboardSettings, error) {
+	var (
+		settings models.LeaderboardSettings
+		name     sql.NullString
+		username string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT leaderboard_opt_in, leaderboard_name, username FROM users WHERE id = ?
+	`, s.userID).Scan(&settings.OptIn, &name, &username)
+	if err == sql.ErrNoRows {
+		return nil, notFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard settings: %v", err)
+	}
+	settings.DisplayName = username
+	if name.Valid {
+		settings.DisplayName = name.String
+	}
+	return &settings, nil
+}
+
+// UpdateLeaderboardSettings opts the user in to or out of leaderboards. An
+// empty display name shows their username.
+func (s *Service) UpdateLeaderboardSettings(ctx context.Context, optIn bool, displayName string) (*models.LeaderboardSettings, error) {
+	var name sql.NullString
+	if displayName = strings.TrimSpace(displayName); displayName != "" {
+		if utf8.RuneCountInString(displayName) > maxLeaderboardNameLength {
+			return nil, invalid("invalid display name")
+		}
+		name = sql.NullString{String: displayName, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users SET leaderboard_opt_in = ?, leaderboard_name = ? WHERE id = ?
+	`, optIn, name, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update leaderboard settings: %v", err)
+	}
+	return s.GetLeaderboardSettings(ctx)
+}
+
+// GetLeaderboard ranks the users who opted in by a metric over the week
+// (starting Monday) containing day, from the rolled-up daily stats. Days that
+// haven't been rolled up yet don't count. Users with nothing to rank by are
+// left out and equal values share a rank.
+func (s *Service) GetLeaderboard(ctx context.Context, metric string, day time.Time, limit int) (*models.Leaderboard, error) {
+	if limit < 1 {
+		return nil, invalid("invalid limit: %d", limit)
+	}
+
+	weekStart := startOfWeek(day)
+	board := models.Leaderboard{
+		Metric:    metric,
+		WeekStart: weekStart.Format("2006-01-02"),
+		WeekEnd:   weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+		Entries:   []models.LeaderboardEntry{},
+	}
+
+	rolledUpTo, err := statsRolledUpTo(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if rolledUpTo != "" {
+		board.RolledUpTo = &rolledUpTo
+	}
+
+	// The week's days that have been rolled up
+	from := board.WeekStart
+	to := weekStart.AddDate(0, 0, 7).Format("2006-01-02")
+	if rolledUpTo < to {
+		to = rolledUpTo
+	}
+
+	// Each query scores users over the days from ?1 up to ?2
+	var query string
+	switch metric {
+	case LeaderboardReviews:
+		query = `
+			SELECT ds.user_id, SUM(ds.reviews)
+			FROM daily_stats ds
+			WHERE ds.day >= ?1 AND ds.day < ?2
+			GROUP BY ds.user_id
+			HAVING SUM(ds.reviews) > 0`
+	case LeaderboardAccuracy:
+		query = `
+			SELECT ds.user_id, CAST(SUM(ds.correct) AS REAL) / SUM(ds.reviews)
+			FROM daily_stats ds
+			WHERE ds.day >= ?1 AND ds.day < ?2
+			GROUP BY ds.user_id
+			HAVING SUM(ds.reviews) >= ?3`
+	case LeaderboardStreak:
+		// A day is part of the streak when the days between it and the
+		// week's last rolled-up day all had reviews
+		query = `
+			WITH days AS (
+				SELECT user_id, day,
+					   ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY day DESC) AS n
+				FROM daily_stats
+				WHERE reviews > 0 AND day < ?2
+			)
+			SELECT user_id, COUNT(*)
+			FROM days
+			WHERE julianday(?2) - julianday(day) = n AND ?1 < ?2
+			GROUP BY user_id`
+	default:
+		return nil, invalid("invalid metric")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH scores(user_id, value) AS (`+query+`)
+		SELECT COALESCE(u.leaderboard_name, u.username), scores.value, u.id = ?4
+		FROM scores
+		JOIN users u ON u.id = scores.user_id
+		WHERE u.leaderboard_opt_in
+		ORDER BY scores.value DESC, COALESCE(u.leaderboard_name, u.username), u.id
+	`, from, to, minLeaderboardAccuracyReviews, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		rank     int
+		previous float64
+	)
+	for position := 1; rows.Next(); position++ {
+		var entry models.LeaderboardEntry
+		if err := rows.Scan(&entry.DisplayName, &entry.Value, &entry.You); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %v", err)
+		}
+		if position == 1 || entry.Value != previous {
+			rank = position
+		}
+		entry.Rank = rank
+		previous = entry.Value
+
+		if len(board.Entries) < limit {
+			board.Entries = append(board.Entries, entry)
+		}
+		if entry.You {
+			you := entry
+			board.You = &you
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leaderboard: %v", err)
+	}
+	return &board, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/listening"
+	"lang_portal/internal/models"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ListeningActivity is the study activity listening attempts are filed under
+const ListeningActivity = "Listening Practice"
+
+// CreateListeningClip stores a clip and generates up to questionCount
+// questions about the words of its group heard in the transcript
+func (s *Service) CreateListeningClip(ctx context.Context, clip *models.ListeningClip, questionCount int) error {
+	if !listening.ValidDifficulty(clip.Difficulty) {
+		return invalid("invalid difficulty")
+	}
+	if _, err := s.GetGroup(ctx, clip.GroupID); err != nil {
+		return notFound("group not found")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script
+		FROM words_groups wg
+		JOIN words w ON w.id = wg.word_id
+		WHERE wg.group_id = ?
+		ORDER BY w.id
+	`, clip.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group words: %v", err)
+	}
+	defer rows.Close()
+
+	var vocabulary []listening.Word
+	for rows.Next() {
+		var word listening.Word
+		if err := rows.Scan(&word.ID, &word.Text); err != nil {
+			return fmt.Errorf("failed to scan group word: %v", err)
+		}
+		vocabulary = append(vocabulary, word)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating group words: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	generated := listening.Generate(clip.Transcript, vocabulary, questionCount, clip.Difficulty, rng)
+	if len(generated) == 0 {
+		return invalid("no group words found in the transcript")
+	}
+
+	var clipID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO listening_clips (group_id, title, audio_url, transcript, difficulty)
+			VALUES (?, ?, ?, ?, ?)
+		`, clip.GroupID, clip.Title, clip.AudioURL, clip.Transcript, clip.Difficulty)
+		if err != nil {
+			return fmt.Errorf("failed to create listening clip: %v", err)
+		}
+		clipID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get clip id: %v", err)
+		}
+
+		for _, question := range generated {
+			options, err := json.Marshal(question.Options)
+			if err != nil {
+				return fmt.Errorf("failed to encode options: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO listening_questions (clip_id, word_id, prompt, options, correct_answer)
+				VALUES (?, ?, ?, ?, ?)
+			`, clipID, question.WordID, question.Prompt, string(options), question.Answer); err != nil {
+				return fmt.Errorf("failed to save listening question: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.GetListeningClip(ctx, clipID)
+	if err != nil {
+		return err
+	}
+	*clip = *stored
+	return nil
+}
+
+// GetListeningClips returns the clips of a difficulty level, or of every
+// level if difficulty is empty, newest first
+func (s *Service) GetListeningClips(ctx context.Context, difficulty string) ([]models.ListeningClip, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT lc.id, lc.group_id, lc.title, lc.audio_url, lc.transcript, lc.difficulty, lc.created_at,
+			(SELECT COUNT(*) FROM listening_questions lq WHERE lq.clip_id = lc.id)
+		FROM listening_clips lc
+		WHERE ? = '' OR lc.difficulty = ?
+		ORDER BY lc.created_at DESC, lc.id DESC
+	`, difficulty, difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening clips: %v", err)
+	}
+	defer rows.Close()
+
+	clips := []models.ListeningClip{}
+	for rows.Next() {
+		var clip models.ListeningClip
+		if err := rows.Scan(&clip.ID, &clip.GroupID, &clip.Title, &clip.AudioURL, &clip.Transcript,
+			&clip.Difficulty, &clip.CreatedAt, &clip.QuestionCount); err != nil {
+			return nil, fmt.Errorf("failed to scan listening clip: %v", err)
+		}
+		clips = append(clips, clip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating listening clips: %v", err)
+	}
+	return clips, nil
+}
+
+// GetListeningClip returns a clip with its questions
+func (s *Service) GetListeningClip(ctx context.Context, clipID int64) (*models.ListeningClip, error) {
+	var clip models.ListeningClip
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, group_id, title, audio_url, transcript, difficulty, created_at
+		FROM listening_clips
+		WHERE id = ?
+	`, clipID).Scan(&clip.ID, &clip.GroupID, &clip.Title, &clip.AudioURL, &clip.Transcript,
+		&clip.Difficulty, &clip.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, notFound("listening clip not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening clip: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, clip_id, word_id, prompt, options, correct_answer
+		FROM listening_questions
+		WHERE clip_id = ?
+		ORDER BY id
+	`, clipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening questions: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			question models.ListeningQuestion
+			wordID   sql.NullInt64
+			options  string
+		)
+		if err := rows.Scan(&question.ID, &question.ClipID, &wordID, &question.Prompt,
+			&options, &question.CorrectAnswer); err != nil {
+			return nil, fmt.Errorf("failed to scan listening question: %v", err)
+		}
+		if err := json.Unmarshal([]byte(options), &question.Options); err != nil {
+			return nil, fmt.Errorf("failed to decode options: %v", err)
+		}
+		if wordID.Valid {
+			question.WordID = &wordID.Int64
+		}
+		clip.Questions = append(clip.Questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating listening questions: %v", err)
+	}
+	clip.QuestionCount = len(clip.Questions)
+	return &clip, nil
+}
+
+// SubmitListeningAttempt scores answers to a clip's questions as a new
+// Listening Practice session. Answers are keyed by question ID; unanswered
+// questions count as wrong. Answered questions about a vocabulary word are
+// recorded as reviews of the word.
+func (s *Service) SubmitListeningAttempt(ctx context.Context, clipID int64, answers map[int64]string) (*models.ListeningAttempt, error) {
+	clip, err := s.GetListeningClip(ctx, clipID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempt models.ListeningAttempt
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		sessionID, err := s.startActivitySession(ctx, tx, ListeningActivity, clip.GroupID, clip.Difficulty)
+		if err != nil {
+			return err
+		}
+
+		attempt = models.ListeningAttempt{
+			SessionID:  sessionID,
+			ClipID:     clipID,
+			TotalCount: len(clip.Questions),
+			Transcript: clip.Transcript,
+			Results:    []models.ListeningResult{},
+		}
+		for _, question := range clip.Questions {
+			answer, answered := answers[question.ID]
+			correct := answered &&
+				strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(question.CorrectAnswer))
+			if correct {
+				attempt.CorrectCount++
+			}
+			attempt.Results = append(attempt.Results, models.ListeningResult{
+				QuestionID:    question.ID,
+				Answer:        answer,
+				Correct:       correct,
+				CorrectAnswer: question.CorrectAnswer,
+			})
+
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO listening_answers (study_session_id, question_id, answer, correct)
+				VALUES (?, ?, ?, ?)
+			`, sessionID, question.ID, answer, correct); err != nil {
+				return fmt.Errorf("failed to record answer: %v", err)
+			}
+
+			if !answered || question.WordID == nil {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO study_session_words (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, *question.WordID); err != nil {
+				return fmt.Errorf("failed to add word to study session: %v", err)
+			}
+			if _, err := s.reviewWord(ctx, tx, sessionID, *question.WordID, correct, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attempt.TotalCount > 0 {
+		attempt.Accuracy = float64(attempt.CorrectCount) / float64(attempt.TotalCount)
+	}
+	return &attempt, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"log"
+	"os"
+	"time"
+)
+
+// maintenanceInterval is how often the database is optimized and checked
+const maintenanceInterval = 24 * time.Hour
+
+// vacuumFragmentation is the share of free pages above which the
+// maintenance job recommends a vacuum
+const vacuumFragmentation = 0.25
+
+// DatabaseStats returns the size of the database, its write-ahead log and
+// its free pages
+func (s *Service) DatabaseStats(ctx context.Context) (*models.DatabaseStats, error) {
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("maintenance is only supported on SQLite")
+	}
+
+	var stats models.DatabaseStats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT p.page_size, c.page_count, f.freelist_count
+		FROM pragma_page_size AS p, pragma_page_count AS c, pragma_freelist_count AS f
+	`).Scan(&stats.PageSize, &stats.PageCount, &stats.FreePages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database stats: %v", err)
+	}
+	stats.SizeBytes = stats.PageSize * stats.PageCount
+	if stats.PageCount > 0 {
+		stats.Fragmentation = float64(stats.FreePages) / float64(stats.PageCount)
+	}
+
+	// An in-memory database has no file, so no write-ahead log
+	var file string
+	err = s.db.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database file: %v", err)
+	}
+	if file != "" {
+		if info, err := os.Stat(file + "-wal"); err == nil {
+			stats.WALBytes = info.Size()
+		}
+	}
+	return &stats, nil
+}
+
+// Analyze gathers the statistics the query planner uses to choose indexes
+func (s *Service) Analyze(ctx context.Context) error {
+	if s.dialect != dialect.SQLite {
+		return unsupported("maintenance is only supported on SQLite")
+	}
+	if _, err := s.db.ExecContext(ctx, `ANALYZE`); err != nil {
+		return fmt.Errorf("failed to analyze database: %v", err)
+	}
+	return nil
+}
+
+// Optimize analyzes the tables whose statistics are out of date, which is
+// cheaper than analyzing every table
+func (s *Service) Optimize(ctx context.Context) error {
+	if s.dialect != dialect.SQLite {
+		return unsupported("maintenance is only supported on SQLite")
+	}
+	if _, err := s.db.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return fmt.Errorf("failed to optimize database: %v", err)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database without its free pages and truncates the
+// write-ahead log. Writes wait until it is done.
+func (s *Service) Vacuum(ctx context.Context) error {
+	if s.dialect != dialect.SQLite {
+		return unsupported("maintenance is only supported on SQLite")
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("failed to vacuum database: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %v", err)
+	}
+	return nil
+}
+
+// CheckIntegrity runs SQLite's integrity check, which reads every page of
+// the database
+func (s *Service) CheckIntegrity(ctx context.Context) (*models.IntegrityCheck, error) {
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("maintenance is only supported on SQLite")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %v", err)
+	}
+	defer rows.Close()
+
+	check := &models.IntegrityCheck{Problems: []string{}}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check: %v", err)
+		}
+		if message != "ok" {
+			check.Problems = append(check.Problems, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %v", err)
+	}
+	check.OK = len(check.Problems) == 0
+	return check, nil
+}
+
+// StartMaintenance optimizes and checks the database now and then every day
+// until the service is closed, logging its size and fragmentation. It does
+// nothing on PostgreSQL.
+func (s *Service) StartMaintenance() {
+	if s.dialect != dialect.SQLite {
+		return
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			s.runMaintenance(ctx)
+
+			select {
+			case <-time.After(maintenanceInterval):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// runMaintenance optimizes and checks the database once, logging what it
+// finds
+func (s *Service) runMaintenance(ctx context.Context) {
+	if err := s.Optimize(ctx); err != nil {
+		log.Printf("Database maintenance failed: %v", err)
+	}
+
+	check, err := s.CheckIntegrity(ctx)
+	if err != nil {
+		log.Printf("Database maintenance failed: %v", err)
+	} else if !check.OK {
+		log.Printf("Database integrity check found %d problems, first: %s", len(check.Problems), check.Problems[0])
+	}
+
+	stats, err := s.DatabaseStats(ctx)
+	if err != nil {
+		log.Printf("Database maintenance failed: %v", err)
+		return
+	}
+	log.Printf("Database is %d bytes with a %d byte write-ahead log, %.0f%% free pages",
+		stats.SizeBytes, stats.WALBytes, stats.Fragmentation*100)
+	if stats.Fragmentation > vacuumFragmentation {
+		log.Printf("Database is fragmented, vacuum it with POST /api/v1/admin/maintenance/vacuum")
+	}
+}
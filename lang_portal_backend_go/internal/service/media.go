@@ -0,0 +1,18 @@
+package service
+
+import (
+	"lang_portal/internal/config"
+	"lang_portal/internal/storage"
+)
+
+// defaultMedia returns the storage of services not created from settings:
+// the default directory, with URLs signed by a random key
+func defaultMedia() storage.Storage {
+	cfg := config.Default().Storage
+	return storage.NewLocal(cfg.Dir, nil, cfg.URLExpiry)
+}
+
+// Media returns the storage media such as synthesized audio is kept in
+func (s *Service) Media() storage.Storage {
+	return s.media
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/db/queries"
+	"lang_portal/internal/models"
+	"lang_portal/internal/notify"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Channels notifications are delivered on
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// Statuses of notification deliveries
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+const (
+	// streakReminderHour is the hour (UTC) from which learners who haven't
+	// studied yet today are reminded of their streak
+	streakReminderHour = 18
+	// homeworkReminderWindow is how long before an assignment is due its
+	// students are reminded
+	homeworkReminderWindow = 24 * time.Hour
+	// maxDeliveryAttempts is how many times a notification is tried before
+	// it is given up on
+	maxDeliveryAttempts = 3
+	// maxNotificationDeliveries is how many deliveries are listed
+	maxNotificationDeliveries = 100
+)
+
+// notifySender returns the configured email sender, or an unsupported error
+// if there is none
+func notifySender() (notify.Sender, error) {
+	sender, err := notify.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure email: %v", err)
+	}
+	if sender == nil {
+		return nil, unsupported("no mail server is configured, set %s", notify.SMTPHostEnv)
+	}
+	return sender, nil
+}
+
+// StartNotifications queues a notification dispatch now and then at the
+// start of every hour until the service is closed. It does nothing if no
+// mail server is configured.
+func (s *Service) StartNotifications() {
+	if sender, err := notify.FromEnv(); err != nil || sender == nil {
+		if err != nil {
+			log.Printf("Notifications are off: %v", err)
+		}
+		return
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if _, err := s.runner.Enqueue(ctx, 0, JobDispatchNotifications, nil); err != nil {
+				log.Printf("Failed to queue notification dispatch: %v", err)
+			}
+
+			next := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+			select {
+			case <-time.After(time.Until(next)):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// GetNotificationPreferences returns the notifications the user wants.
+// Users who never set any want none.
+func (s *Service) GetNotificationPreferences(ctx context.Context) (*models.NotificationPreferences, error) {
+	row, err := s.queries.GetNotificationPreferences(ctx, s.userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+	prefs := models.NotificationPreferences{
+		StreakReminders:   row.StreakReminders,
+		WeeklyDigest:      row.WeeklyDigest,
+		HomeworkReminders: row.HomeworkReminders,
+	}
+	if row.Email != nil {
+		prefs.Email = *row.Email
+	}
+	return &prefs, nil
+}
+
+// UpdateNotificationPreferences sets the notifications the user wants. They
+// are emailed to prefs.Email or, if it is empty, the account's address;
+// turning any on needs one of them.
+func (s *Service) UpdateNotificationPreferences(ctx context.Context, prefs models.NotificationPreferences, now time.Time) (*models.NotificationPreferences, error) {
+	var email *string
+	if prefs.Email != "" {
+		address, err := normalizeEmail(prefs.Email)
+		if err != nil {
+			return nil, err
+		}
+		email = &address
+	}
+	if email == nil && (prefs.StreakReminders || prefs.WeeklyDigest || prefs.HomeworkReminders) {
+		account, err := s.queries.GetUserEmail(ctx, s.userID)
+		if err == sql.ErrNoRows {
+			return nil, notFound("user not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %v", err)
+		}
+		if account == nil {
+			return nil, invalid("an email address is required for notifications")
+		}
+	}
+
+	err := s.queries.UpsertNotificationPreferences(ctx, queries.UpsertNotificationPreferencesParams{
+		UserID:            s.userID,
+		Email:             email,
+		StreakReminders:   prefs.StreakReminders,
+		WeeklyDigest:      prefs.WeeklyDigest,
+		HomeworkReminders: prefs.HomeworkReminders,
+		UpdatedAt:         now.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %v", err)
+	}
+	return s.GetNotificationPreferences(ctx)
+}
+
+// ListNotificationDeliveries returns the notifications last sent to the
+// user, or attempted, newest first
+func (s *Service) ListNotificationDeliveries(ctx context.Context) ([]models.NotificationDelivery, error) {
+	rows, err := s.queries.ListNotificationDeliveries(ctx, queries.ListNotificationDeliveriesParams{
+		UserID: s.userID,
+		Limit:  maxNotificationDeliveries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %v", err)
+	}
+
+	deliveries := make([]models.NotificationDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, models.NotificationDelivery{
+			ID:        row.ID,
+			Kind:      row.Kind,
+			Channel:   row.Channel,
+			Recipient: row.Recipient,
+			Subject:   row.Subject,
+			Status:    row.Status,
+			Error:     row.Error,
+			CreatedAt: row.CreatedAt,
+			SentAt:    row.SentAt,
+		})
+	}
+	return deliveries, nil
+}
+
+// recipient is a user who wants notifications, and what they want
+type recipient struct {
+	userID   int64
+	username string
+	email    string
+	prefs    models.NotificationPreferences
+}
+
+// DispatchNotifications emails every user the notifications due as of now
+// that they want: a streak reminder in the evening of a day they haven't
+// studied yet after studying the day before, a digest of last week on
+// Mondays, and a reminder of each assignment due within a day that they
+// haven't finished. Each is sent once, or tried again by later dispatches
+// if sending failed.
+func (s *Service) DispatchNotifications(ctx context.Context, now time.Time) (*models.NotificationDispatch, error) {
+	sender, err := notifySender()
+	if err != nil {
+		return nil, err
+	}
+	now = now.UTC()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.username, COALESCE(np.email, u.email),
+			   np.streak_reminders, np.weekly_digest, np.homework_reminders
+		FROM notification_preferences np
+		JOIN users u ON u.id = np.user_id
+		WHERE (np.streak_reminders OR np.weekly_digest OR np.homework_reminders)
+		AND COALESCE(np.email, u.email) IS NOT NULL
+		ORDER BY u.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notification recipients: %v", err)
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.userID, &r.username, &r.email,
+			&r.prefs.StreakReminders, &r.prefs.WeeklyDigest, &r.prefs.HomeworkReminders); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan notification recipient: %v", err)
+		}
+		recipients = append(recipients, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification recipients: %v", err)
+	}
+
+	var dispatch models.NotificationDispatch
+	for _, r := range recipients {
+		user := s.ForUser(r.userID)
+		if r.prefs.StreakReminders && now.Hour() >= streakReminderHour {
+			if err := user.remindStreak(ctx, sender, r, now, &dispatch); err != nil {
+				return nil, err
+			}
+		}
+		if r.prefs.WeeklyDigest && now.Weekday() == time.Monday {
+			if err := user.sendWeeklyDigest(ctx, sender, r, now, &dispatch); err != nil {
+				return nil, err
+			}
+		}
+		if r.prefs.HomeworkReminders {
+			if err := user.remindHomework(ctx, sender, r, now, &dispatch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &dispatch, nil
+}
+
+// remindStreak reminds the user of their streak if they studied yesterday
+// but not yet today
+func (s *Service) remindStreak(ctx context.Context, sender notify.Sender, r recipient, now time.Time, dispatch *models.NotificationDispatch) error {
+	streak, err := s.streakAtRisk(ctx, now)
+	if err != nil || streak == 0 {
+		return err
+	}
+	data := notify.StreakData{Username: r.username, StreakDays: streak}
+	return s.notify(ctx, sender, r, notify.StreakAtRisk, now.Format("2006-01-02"), data, now, dispatch)
+}
+
+// streakAtRisk returns the user's streak if they studied yesterday but not
+// yet on the day of now, or 0
+func (s *Service) streakAtRisk(ctx context.Context, now time.Time) (int, error) {
+	var last sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT date(MAX(created_at)) FROM study_sessions WHERE user_id = ?
+	`, s.userID).Scan(&last)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last study session: %v", err)
+	}
+	if !last.Valid || last.String != now.AddDate(0, 0, -1).Format("2006-01-02") {
+		return 0, nil
+	}
+	return s.getStudyStreakDays(ctx)
+}
+
+// sendWeeklyDigest sums up the week before the one of now
+func (s *Service) sendWeeklyDigest(ctx context.Context, sender notify.Sender, r recipient, now time.Time, dispatch *models.NotificationDispatch) error {
+	end := startOfWeek(now)
+	start := end.AddDate(0, 0, -7)
+	from, to := rangeBounds(models.DateRange{From: &start, To: &end})
+
+	data := notify.DigestData{
+		Username:  r.username,
+		WeekStart: start.Format("2006-01-02"),
+		WeekEnd:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0), COUNT(DISTINCT word_id)
+		FROM word_review_items
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+	`, s.userID, from, to).Scan(&data.Reviews, &data.CorrectCount, &data.WordsReviewed)
+	if err != nil {
+		return fmt.Errorf("failed to get weekly reviews: %v", err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM study_sessions WHERE user_id = ? AND created_at >= ? AND created_at < ?
+	`, s.userID, from, to).Scan(&data.StudySessions)
+	if err != nil {
+		return fmt.Errorf("failed to get weekly study sessions: %v", err)
+	}
+	if data.Reviews > 0 {
+		data.Accuracy = data.CorrectCount * 100 / data.Reviews
+	}
+	if data.StreakDays, err = s.getStudyStreakDays(ctx); err != nil {
+		return err
+	}
+	return s.notify(ctx, sender, r, notify.WeeklyDigest, data.WeekStart, data, now, dispatch)
+}
+
+// remindHomework reminds the user of each assignment of their classes due
+// within homeworkReminderWindow that they haven't finished
+func (s *Service) remindHomework(ctx context.Context, sender notify.Sender, r recipient, now time.Time, dispatch *models.NotificationDispatch) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ca.id, c.name, g.name, ca.due_at,
+			   (SELECT COUNT(*) FROM words_groups wg WHERE wg.group_id = ca.group_id),
+			   (SELECT COUNT(DISTINCT wri.word_id)
+				FROM word_review_items wri
+				JOIN words_groups wg ON wg.word_id = wri.word_id AND wg.group_id = ca.group_id
+				WHERE wri.user_id = cs.user_id
+				AND datetime(wri.created_at) >= ca.created_at
+				AND datetime(wri.created_at) <= ca.due_at)
+		FROM class_students cs
+		JOIN class_assignments ca ON ca.class_id = cs.class_id
+		JOIN classes c ON c.id = ca.class_id
+		JOIN groups g ON g.id = ca.group_id
+		WHERE cs.user_id = ?1 AND ca.due_at > ?2 AND ca.due_at <= ?3
+		ORDER BY ca.due_at, ca.id
+	`, s.userID, now.Format(classDueAtLayout), now.Add(homeworkReminderWindow).Format(classDueAtLayout))
+	if err != nil {
+		return fmt.Errorf("failed to find assignments due: %v", err)
+	}
+	type due struct {
+		id   int64
+		data notify.HomeworkData
+	}
+	var assignments []due
+	for rows.Next() {
+		var (
+			assignment due
+			dueAt      time.Time
+			reviewed   int
+		)
+		if err := rows.Scan(&assignment.id, &assignment.data.ClassName, &assignment.data.GroupName, &dueAt,
+			&assignment.data.TotalWords, &reviewed); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan assignment: %v", err)
+		}
+		if reviewed >= assignment.data.TotalWords {
+			continue
+		}
+		assignment.data.Username = r.username
+		assignment.data.DueAt = dueAt.UTC().Format("Monday 2 January at 15:04 UTC")
+		assignment.data.WordsLeft = assignment.data.TotalWords - reviewed
+		assignments = append(assignments, assignment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating assignments: %v", err)
+	}
+
+	for _, assignment := range assignments {
+		key := strconv.FormatInt(assignment.id, 10)
+		if err := s.notify(ctx, sender, r, notify.HomeworkDue, key, assignment.data, now, dispatch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify emails the user a notification unless it was already sent, or
+// tried too often, logging the delivery under key
+func (s *Service) notify(ctx context.Context, sender notify.Sender, r recipient, kind, key string, data interface{}, now time.Time, dispatch *models.NotificationDispatch) error {
+	msg, err := notify.Render(kind, r.email, data)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, kind, ChannelEmail, key, msg.To, msg.Subject, now, dispatch, func() error {
+		return sender.Send(ctx, msg)
+	})
+}
+
+// deliver sends the user a notification on a channel with send, unless it
+// was already sent under key or tried too often, and logs the delivery. A
+// failure to send is logged and counted rather than returned.
+func (s *Service) deliver(ctx context.Context, kind, channel, key, to, subject string, now time.Time, dispatch *models.NotificationDispatch, send func() error) error {
+	// Claim the delivery, so concurrent dispatches don't both send it
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (user_id, kind, channel, dedupe_key, recipient, subject, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, kind, channel, dedupe_key) DO UPDATE SET
+			recipient = excluded.recipient,
+			subject = excluded.subject,
+			status = excluded.status,
+			attempts = notification_deliveries.attempts + 1
+		WHERE notification_deliveries.status = ? AND notification_deliveries.attempts < ?
+	`, s.userID, kind, channel, key, to, subject, DeliveryPending, now, DeliveryFailed, maxDeliveryAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to log notification: %v", err)
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed == 0 {
+		return err
+	}
+
+	finished := queries.FinishNotificationDeliveryParams{
+		Status:    DeliverySent,
+		SentAt:    &now,
+		UserID:    s.userID,
+		Kind:      kind,
+		Channel:   channel,
+		DedupeKey: key,
+	}
+	if err := send(); err != nil {
+		log.Printf("Failed to send %s notification to user %d by %s: %v", kind, s.userID, channel, err)
+		finished.Status, finished.Error, finished.SentAt = DeliveryFailed, err.Error(), nil
+		dispatch.Failed++
+	} else {
+		dispatch.Sent++
+	}
+	if err := s.queries.FinishNotificationDelivery(ctx, finished); err != nil {
+		return fmt.Errorf("failed to log notification: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"lang_portal/internal/srs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// syncTimeLayout is how change and deletion times are stored, so they
+	// compare as text
+	syncTimeLayout = "2006-01-02 15:04:05.000"
+	// syncOverlap is how long before a sync token was made changes are
+	// pulled again, so changes committed by transactions that were running
+	// when it was made aren't missed. Clients get them twice.
+	syncOverlap = time.Minute
+	// TombstoneTTL is how long deletions are kept for offline clients to
+	// pull. Older sync tokens pull everything.
+	TombstoneTTL = 90 * 24 * time.Hour
+	// tombstonePruneInterval is how often deletions older than TombstoneTTL
+	// are forgotten
+	tombstonePruneInterval = 24 * time.Hour
+	// maxPushSessions and maxPushReviews are how many sessions and reviews
+	// can be pushed at once
+	maxPushSessions = 100
+	maxPushReviews  = 1000
+	// maxClientIDLength is the longest ID a client can give a session
+	maxClientIDLength = 100
+)
+
+// GetChanges returns what changed since a sync token was made, for an
+// offline client to apply: the words and groups, which only the server
+// changes, the user's study sessions and reviews, and what was deleted. An
+// empty token, or one older than TombstoneTTL, gets a full copy instead.
+func (s *Service) GetChanges(ctx context.Context, token string, now time.Time) (*models.Changes, error) {
+	since, ok := models.DecodeSyncToken(token)
+	if !ok {
+		return nil, invalid("invalid sync token")
+	}
+	now = now.UTC()
+	changes := &models.Changes{
+		Token:    models.EncodeSyncToken(now),
+		Full:     since.IsZero() || since.Before(now.Add(-TombstoneTTL)),
+		Words:    []models.ChangedWord{},
+		Groups:   []models.ChangedGroup{},
+		Sessions: []models.ChangedSession{},
+		Reviews:  []models.ChangedReview{},
+		Deleted: models.DeletedIDs{
+			Words:    []int64{},
+			Groups:   []int64{},
+			Sessions: []int64{},
+		},
+	}
+	from := time.Time{}
+	if !changes.Full {
+		from = since.Add(-syncOverlap)
+	}
+	after := from.UTC().Format(syncTimeLayout)
+
+	// Reading in a transaction sees the tables as they were at one time
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := getChangedWords(ctx, tx, after, changes); err != nil {
+			return err
+		}
+		if err := getChangedGroups(ctx, tx, after, changes); err != nil {
+			return err
+		}
+		if err := s.getChangedSessions(ctx, tx, after, changes); err != nil {
+			return err
+		}
+		if err := s.getChangedReviews(ctx, tx, after, changes); err != nil {
+			return err
+		}
+		if changes.Full {
+			return nil
+		}
+		return s.getDeletions(ctx, tx, after, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func getChangedWords(ctx context.Context, tx *models.Tx, after string, changes *models.Changes) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, script, transliteration, english, COALESCE(parts, ''), language, updated_at
+		FROM words WHERE updated_at > ?
+		ORDER BY id
+	`, after)
+	if err != nil {
+		return fmt.Errorf("failed to get changed words: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var word models.ChangedWord
+		if err := rows.Scan(&word.ID, &word.Urdu, &word.Urdlish, &word.English, &word.Parts, &word.Language, &word.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan changed word: %v", err)
+		}
+		changes.Words = append(changes.Words, word)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating changed words: %v", err)
+	}
+	return nil
+}
+
+func getChangedGroups(ctx context.Context, tx *models.Tx, after string, changes *models.Changes) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, name, language, updated_at FROM groups WHERE updated_at > ?
+		ORDER BY id
+	`, after)
+	if err != nil {
+		return fmt.Errorf("failed to get changed groups: %v", err)
+	}
+	defer rows.Close()
+	index := map[int64]int{}
+	for rows.Next() {
+		group := models.ChangedGroup{WordIDs: []int64{}}
+		if err := rows.Scan(&group.ID, &group.Name, &group.Language, &group.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan changed group: %v", err)
+		}
+		index[group.ID] = len(changes.Groups)
+		changes.Groups = append(changes.Groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating changed groups: %v", err)
+	}
+	rows.Close()
+
+	// A changed group is sent with all of its words, not just the ones
+	// added since
+	rows, err = tx.QueryContext(ctx, `
+		SELECT DISTINCT wg.group_id, wg.word_id
+		FROM words_groups wg
+		JOIN groups g ON g.id = wg.group_id
+		WHERE g.updated_at > ?
+		ORDER BY wg.group_id, wg.word_id
+	`, after)
+	if err != nil {
+		return fmt.Errorf("failed to get changed group words: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var groupID, wordID int64
+		if err := rows.Scan(&groupID, &wordID); err != nil {
+			return fmt.Errorf("failed to scan changed group word: %v", err)
+		}
+		if i, ok := index[groupID]; ok {
+			changes.Groups[i].WordIDs = append(changes.Groups[i].WordIDs, wordID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating changed group words: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) getChangedSessions(ctx context.Context, tx *models.Tx, after string, changes *models.Changes) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(client_id, ''), group_id, study_activity_id, created_at, updated_at
+		FROM study_sessions WHERE user_id = ? AND updated_at > ?
+		ORDER BY id
+	`, s.userID, after)
+	if err != nil {
+		return fmt.Errorf("failed to get changed study sessions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var session models.ChangedSession
+		if err := rows.Scan(&session.ID, &session.ClientID, &session.GroupID, &session.StudyActivityID,
+			&session.CreatedAt, &session.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan changed study session: %v", err)
+		}
+		changes.Sessions = append(changes.Sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating changed study sessions: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) getChangedReviews(ctx context.Context, tx *models.Tx, after string, changes *models.Changes) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT study_session_id, word_id, correct, near_miss, created_at, updated_at
+		FROM word_review_items WHERE user_id = ? AND updated_at > ?
+		ORDER BY study_session_id, word_id
+	`, s.userID, after)
+	if err != nil {
+		return fmt.Errorf("failed to get changed reviews: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var review models.ChangedReview
+		if err := rows.Scan(&review.StudySessionID, &review.WordID, &review.Correct, &review.NearMiss,
+			&review.CreatedAt, &review.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan changed review: %v", err)
+		}
+		changes.Reviews = append(changes.Reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating changed reviews: %v", err)
+	}
+	return nil
+}
+
+// getDeletions adds the words, groups and sessions deleted since after.
+// IDs that were used again since, as the seeder does for groups after a
+// reset, are sent as changes instead.
+func (s *Service) getDeletions(ctx context.Context, tx *models.Tx, after string, changes *models.Changes) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT t.entity, t.entity_id
+		FROM sync_tombstones t
+		WHERE t.deleted_at > ? AND (t.user_id IS NULL OR t.user_id = ?)
+		AND NOT EXISTS (SELECT 1 FROM words WHERE t.entity = 'word' AND id = t.entity_id)
+		AND NOT EXISTS (SELECT 1 FROM groups WHERE t.entity = 'group' AND id = t.entity_id)
+		AND NOT EXISTS (SELECT 1 FROM study_sessions WHERE t.entity = 'session' AND id = t.entity_id)
+		ORDER BY t.entity_id
+	`, after, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to get deletions: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			entity string
+			id     int64
+		)
+		if err := rows.Scan(&entity, &id); err != nil {
+			return fmt.Errorf("failed to scan deletion: %v", err)
+		}
+		switch entity {
+		case "word":
+			changes.Deleted.Words = append(changes.Deleted.Words, id)
+		case "group":
+			changes.Deleted.Groups = append(changes.Deleted.Groups, id)
+		case "session":
+			changes.Deleted.Sessions = append(changes.Deleted.Sessions, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating deletions: %v", err)
+	}
+	return nil
+}
+
+// PushChanges records the study sessions and reviews an offline client
+// made. Conflicts are resolved by these rules:
+//
+//   - A session is identified by the ID its client gave it, so pushing it
+//     again is a duplicate of the session created the first time.
+//   - The server keeps the first review of a word in a session it gets, so
+//     a different review pushed later is a conflict and left out.
+//   - Reviews are scheduled in the order they were made, and only if the
+//     word wasn't reviewed after them already, which the server's schedule
+//     then reflects.
+//   - Sessions of groups or activities that don't exist, and reviews of
+//     words or sessions that don't, are rejected. Clients can't change
+//     words or groups.
+//
+// Times after now are taken as now, and missing ones as now too.
+func (s *Service) PushChanges(ctx context.Context, sessions []models.OfflineSession, reviews []models.OfflineReview, now time.Time) (*models.PushResult, error) {
+	if len(sessions) > maxPushSessions {
+		return nil, invalid("at most %d study sessions can be pushed at once", maxPushSessions)
+	}
+	if len(reviews) > maxPushReviews {
+		return nil, invalid("at most %d reviews can be pushed at once", maxPushReviews)
+	}
+	now = now.UTC()
+	clamp := func(t time.Time) time.Time {
+		if t.IsZero() || t.After(now) {
+			return now
+		}
+		return t.UTC()
+	}
+
+	result := &models.PushResult{
+		Sessions: make([]models.PushedSession, len(sessions)),
+		Reviews:  make([]models.PushedReview, len(reviews)),
+	}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for i, session := range sessions {
+			session.CreatedAt = clamp(session.CreatedAt)
+			pushed, err := s.pushSession(ctx, tx, session)
+			if err != nil {
+				return err
+			}
+			result.Sessions[i] = *pushed
+		}
+
+		// Reviews are recorded in the order they were made, so words are
+		// scheduled as they would have been online
+		order := make([]int, len(reviews))
+		for i := range order {
+			order[i] = i
+			reviews[i].CreatedAt = clamp(reviews[i].CreatedAt)
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return reviews[order[a]].CreatedAt.Before(reviews[order[b]].CreatedAt)
+		})
+		var earliest time.Time
+		for _, i := range order {
+			pushed, err := s.pushReview(ctx, tx, reviews[i])
+			if err != nil {
+				return err
+			}
+			result.Reviews[i] = *pushed
+			if pushed.Status == models.PushCreated && (earliest.IsZero() || reviews[i].CreatedAt.Before(earliest)) {
+				earliest = reviews[i].CreatedAt
+			}
+		}
+
+		// Reviews of days already rolled up are rolled up again
+		if !earliest.IsZero() {
+			day := earliest.Format("2006-01-02")
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE stats_rollup_state SET rolled_up_to = ?1 WHERE id = 1 AND rolled_up_to > ?1
+			`, day); err != nil {
+				return fmt.Errorf("failed to update stats rollup state: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pushSession creates a session an offline client pushed, unless it was
+// pushed before
+func (s *Service) pushSession(ctx context.Context, tx *models.Tx, session models.OfflineSession) (*models.PushedSession, error) {
+	pushed := &models.PushedSession{ClientID: strings.TrimSpace(session.ClientID)}
+	if pushed.ClientID == "" || len(pushed.ClientID) > maxClientIDLength {
+		pushed.Status = models.PushRejected
+		pushed.Error = fmt.Sprintf("client_id must be 1 to %d characters", maxClientIDLength)
+		return pushed, nil
+	}
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM study_sessions WHERE user_id = ? AND client_id = ?
+	`, s.userID, pushed.ClientID).Scan(&pushed.ID)
+	if err == nil {
+		pushed.Status = models.PushDuplicate
+		return pushed, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up study session: %v", err)
+	}
+
+	group, err := s.groupAccess(ctx, tx, session.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	var activityExists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM study_activities WHERE id = ?)
+	`, session.StudyActivityID).Scan(&activityExists); err != nil {
+		return nil, fmt.Errorf("failed to look up study activity: %v", err)
+	}
+	if !group.canView() {
+		pushed.Status, pushed.Error = models.PushRejected, "group not found"
+		return pushed, nil
+	}
+	if !activityExists {
+		pushed.Status, pushed.Error = models.PushRejected, "study activity not found"
+		return pushed, nil
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO study_sessions (group_id, study_activity_id, created_at, user_id, client_id)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, session.GroupID, session.StudyActivityID, session.CreatedAt, s.userID, pushed.ClientID).Scan(&pushed.ID); err != nil {
+		return nil, fmt.Errorf("failed to create study session: %v", err)
+	}
+
+	// The session's words are its group's, as for sessions created online
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT word_id FROM words_groups WHERE group_id = ? ORDER BY word_id
+	`, session.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group words: %v", err)
+	}
+	var wordIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan group word: %v", err)
+		}
+		wordIDs = append(wordIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group words: %v", err)
+	}
+	if err := s.sessions.SetWords(ctx, tx, pushed.ID, wordIDs); err != nil {
+		return nil, err
+	}
+	pushed.Status = models.PushCreated
+	return pushed, nil
+}
+
+// pushReview records a review an offline client pushed, unless the server
+// has one of the word in the session already
+func (s *Service) pushReview(ctx context.Context, tx *models.Tx, review models.OfflineReview) (*models.PushedReview, error) {
+	pushed := &models.PushedReview{StudySessionID: review.StudySessionID, WordID: review.WordID}
+	if clientID := strings.TrimSpace(review.SessionClientID); clientID != "" {
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM study_sessions WHERE user_id = ? AND client_id = ?
+		`, s.userID, clientID).Scan(&pushed.StudySessionID)
+		if err == sql.ErrNoRows {
+			pushed.Status, pushed.Error = models.PushRejected, "study session not found"
+			return pushed, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up study session: %v", err)
+		}
+	} else if err := s.checkSessionOwner(ctx, tx, pushed.StudySessionID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			pushed.Status, pushed.Error = models.PushRejected, "study session not found"
+			return pushed, nil
+		}
+		return nil, err
+	}
+
+	var wordExists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words WHERE id = ?)
+	`, pushed.WordID).Scan(&wordExists); err != nil {
+		return nil, fmt.Errorf("failed to look up word: %v", err)
+	}
+	if !wordExists {
+		pushed.Status, pushed.Error = models.PushRejected, "word not found"
+		return pushed, nil
+	}
+
+	var correct, nearMiss bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT correct, near_miss FROM word_review_items WHERE study_session_id = ? AND word_id = ?
+	`, pushed.StudySessionID, pushed.WordID).Scan(&correct, &nearMiss)
+	if err == nil {
+		pushed.Status = models.PushDuplicate
+		if correct != review.Correct || nearMiss != review.NearMiss {
+			pushed.Status = models.PushConflict
+			pushed.Error = "word already reviewed differently in this session"
+		}
+		return pushed, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up review: %v", err)
+	}
+
+	completes, err := completesSession(ctx, tx, pushed.StudySessionID, pushed.WordID)
+	if err != nil {
+		return nil, err
+	}
+	nearMiss = review.Correct && review.NearMiss
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO word_review_items (word_id, study_session_id, correct, near_miss, created_at, user_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, pushed.WordID, pushed.StudySessionID, review.Correct, nearMiss, review.CreatedAt, s.userID); err != nil {
+		return nil, fmt.Errorf("failed to review word: %v", err)
+	}
+
+	// A word reviewed after this review was made is already scheduled by
+	// the later review
+	state, err := getLearningState(ctx, tx, s.userID, pushed.WordID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get learning state: %v", err)
+	}
+	if state == nil || state.LastReviewedAt == nil || state.LastReviewedAt.Before(review.CreatedAt) {
+		grade := srs.GradeFromCorrect(review.Correct)
+		if nearMiss {
+			grade = srs.GradeHard
+		}
+		if _, err := s.recordLearningReview(ctx, tx, pushed.WordID, grade, review.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	s.publishAfterCommit(ctx, tx, events.WordReviewed{
+		UserID:    s.userID,
+		SessionID: pushed.StudySessionID,
+		WordID:    pushed.WordID,
+		Correct:   review.Correct,
+		At:        review.CreatedAt,
+	})
+	if completes {
+		event, err := s.sessionCompleted(ctx, tx, pushed.StudySessionID, review.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.publishAfterCommit(ctx, tx, event)
+	}
+	pushed.Status = models.PushCreated
+	return pushed, nil
+}
+
+// PruneTombstones forgets the deletions older than TombstoneTTL, which
+// clients with older sync tokens pull a full copy instead of, and returns
+// how many there were
+func (s *Service) PruneTombstones(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM sync_tombstones WHERE deleted_at < ?
+	`, now.UTC().Add(-TombstoneTTL).Format(syncTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune deletions: %v", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	return pruned, nil
+}
+
+// StartTombstonePrune prunes old deletions now and then every day until the
+// service is closed
+func (s *Service) StartTombstonePrune() {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if pruned, err := s.PruneTombstones(ctx, time.Now()); err != nil {
+				log.Printf("Deletion prune failed: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Pruned %d deletions older than offline clients sync", pruned)
+			}
+
+			select {
+			case <-time.After(tombstonePruneInterval):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
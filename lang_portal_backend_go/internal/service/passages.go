@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/corpus"
+	"lang_portal/internal/models"
+	"lang_portal/internal/srs"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxPassageLength is the longest a passage can be, in characters
+const MaxPassageLength = 20000
+
+// maxPassageTitleLength is the longest title of a passage
+const maxPassageTitleLength = 200
+
+// cefrLevels are the CEFR levels passages and grammar topics are graded by
+var cefrLevels = map[string]bool{"A1": true, "A2": true, "B1": true, "B2": true, "C1": true, "C2": true}
+
+// checkLevel checks that level is a CEFR level
+func checkLevel(level string) error {
+	if !cefrLevels[level] {
+		return invalid("invalid level %q: use a CEFR level, A1 to C2", level)
+	}
+	return nil
+}
+
+// Learning stages of the words of a passage. Unlisted words aren't in the
+// vocabulary; the others are the stages of the SRS statistics.
+const (
+	WordStageUnlisted = "unlisted"
+	WordStageNew      = "new"
+	WordStageLearning = "learning"
+	WordStageYoung    = "young"
+	WordStageMature   = "mature"
+)
+
+// wordStageRanks orders the learning stages from least to best known
+var wordStageRanks = map[string]int{
+	WordStageUnlisted: 0,
+	WordStageNew:      1,
+	WordStageLearning: 2,
+	WordStageYoung:    3,
+	WordStageMature:   4,
+}
+
+// Passage segment types
+const (
+	SegmentWord = "word"
+	SegmentText = "text"
+)
+
+// passageColumns are the columns scanPassage reads, without the text
+const passageColumns = `id, language, title, level, source, word_count, created_at`
+
+// CreatePassage stores a reading passage of the given language, or else of
+// the service's
+func (s *Service) CreatePassage(ctx context.Context, passage models.Passage) (*models.Passage, error) {
+	passage.Title = strings.TrimSpace(passage.Title)
+	passage.Level = strings.ToUpper(strings.TrimSpace(passage.Level))
+	passage.Text = strings.TrimSpace(passage.Text)
+	passage.Source = strings.TrimSpace(passage.Source)
+	passage.Language = s.newWordLanguage(passage.Language)
+	passage.WordCount = len(corpus.Tokenize(passage.Text))
+	switch {
+	case passage.Title == "" || utf8.RuneCountInString(passage.Title) > maxPassageTitleLength:
+		return nil, invalid("title must be 1 to %d characters", maxPassageTitleLength)
+	case passage.WordCount == 0:
+		return nil, invalid("a passage needs some words")
+	case utf8.RuneCountInString(passage.Text) > MaxPassageLength:
+		return nil, invalid("a passage can be at most %d characters", MaxPassageLength)
+	}
+	if err := checkLevel(passage.Level); err != nil {
+		return nil, err
+	}
+	if err := s.checkLanguage(ctx, s.db, passage.Language); err != nil {
+		return nil, err
+	}
+
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `
+		INSERT INTO passages (language, title, level, text, source, word_count)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, passage.Language, passage.Title, passage.Level, passage.Text, passage.Source, passage.WordCount).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to create passage: %v", err)
+	}
+	return s.GetPassage(ctx, id)
+}
+
+// GetPassage returns a passage with its text
+func (s *Service) GetPassage(ctx context.Context, id int64) (*models.Passage, error) {
+	var text string
+	passage, err := scanPassage(s.db.QueryRowContext(ctx, `
+		SELECT `+passageColumns+`, text FROM passages WHERE id = ?
+	`, id), &text)
+	if err == sql.ErrNoRows {
+		return nil, notFound("passage not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	passage.Text = text
+	return passage, nil
+}
+
+// scanPassage reads a passage selected by passageColumns, and any further
+// columns into extra
+func scanPassage(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.Passage, error) {
+	var passage models.Passage
+	err := row.Scan(append([]interface{}{&passage.ID, &passage.Language, &passage.Title, &passage.Level,
+		&passage.Source, &passage.WordCount, &passage.CreatedAt}, extra...)...)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan passage: %v", err)
+	}
+	return &passage, nil
+}
+
+// ListPassages returns the passages of the service's language, or of every
+// language, easiest first, only those of a level if it isn't empty. Their
+// texts are left out.
+func (s *Service) ListPassages(ctx context.Context, level string) ([]models.Passage, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level != "" {
+		if err := checkLevel(level); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+passageColumns+`
+		FROM passages
+		WHERE (?1 = '' OR language = ?1) AND (?2 = '' OR level = ?2)
+		ORDER BY level, title, id
+	`, s.language, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passages: %v", err)
+	}
+	defer rows.Close()
+
+	passages := []models.Passage{}
+	for rows.Next() {
+		passage, err := scanPassage(rows)
+		if err != nil {
+			return nil, err
+		}
+		passages = append(passages, *passage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating passages: %v", err)
+	}
+	return passages, nil
+}
+
+// DeletePassage deletes a passage
+func (s *Service) DeletePassage(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM passages WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete passage: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return notFound("passage not found")
+	}
+	return nil
+}
+
+// ReadPassage returns a passage split into words and the text between them,
+// each word linked to the words of the vocabulary it reads as and marked
+// with how well the service's user knows them. Words spanning several
+// tokens, such as compound verbs, are read as one. The words linked are
+// returned once each, with their senses, to gloss the segments with.
+func (s *Service) ReadPassage(ctx context.Context, id int64) (*models.PassageReading, error) {
+	passage, err := s.GetPassage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	index, err := vocabularyIndex(ctx, s.db, passage.Language)
+	if err != nil {
+		return nil, err
+	}
+	stages, err := s.wordStages(ctx, passage.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	reading := &models.PassageReading{Passage: *passage, Segments: []models.PassageSegment{}, Words: []models.PassageWord{}}
+	var wordIDs []int64
+	linked := map[int64]bool{}
+	for _, segment := range index.Segment(passage.Text) {
+		if !segment.Word {
+			reading.Segments = append(reading.Segments, models.PassageSegment{
+				Text:     segment.Text,
+				Position: segment.Position,
+				Type:     SegmentText,
+			})
+			continue
+		}
+
+		stage := WordStageUnlisted
+		for _, wordID := range segment.WordIDs {
+			if wordStageRanks[stages.of(wordID)] > wordStageRanks[stage] {
+				stage = stages.of(wordID)
+			}
+			if !linked[wordID] {
+				linked[wordID] = true
+				wordIDs = append(wordIDs, wordID)
+			}
+		}
+		unknown := wordStageRanks[stage] < wordStageRanks[WordStageYoung]
+		if unknown {
+			reading.UnknownCount++
+		}
+		reading.Segments = append(reading.Segments, models.PassageSegment{
+			Text:     segment.Text,
+			Position: segment.Position,
+			Type:     SegmentWord,
+			WordIDs:  segment.WordIDs,
+			Stage:    stage,
+			Unknown:  unknown,
+		})
+	}
+
+	for _, wordID := range wordIDs {
+		word, err := s.passageWord(ctx, wordID)
+		if err != nil {
+			return nil, err
+		}
+		word.Stage = stages.of(wordID)
+		reading.Words = append(reading.Words, *word)
+	}
+	return reading, nil
+}
+
+// passageWord returns a word of a passage, with its senses
+func (s *Service) passageWord(ctx context.Context, wordID int64) (*models.PassageWord, error) {
+	var word models.PassageWord
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, script, transliteration, english, COALESCE(parts, ''), language FROM words WHERE id = ?
+	`, wordID).Scan(&word.ID, &word.Urdu, &word.Urdlish, &word.English, &word.Parts, &word.Language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passage word: %v", err)
+	}
+	if word.Senses, err = wordSenses(ctx, s.db, wordID); err != nil {
+		return nil, err
+	}
+	return &word, nil
+}
+
+// wordStageMap is the learning stages of words the user has started
+// learning. Words missing from it are new.
+type wordStageMap map[int64]string
+
+// of returns the stage of a word
+func (m wordStageMap) of(wordID int64) string {
+	if stage, ok := m[wordID]; ok {
+		return stage
+	}
+	return WordStageNew
+}
+
+// wordStages returns the learning stages of the words of a language the
+// service's user has started learning, staged as the SRS statistics are
+func (s *Service) wordStages(ctx context.Context, language string) (wordStageMap, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT word_id, repetitions, relearning_step, interval_days
+		FROM word_learning_state
+		WHERE user_id = ? AND word_id IN (SELECT id FROM words WHERE language = ?)
+	`, s.userID, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learning states: %v", err)
+	}
+	defer rows.Close()
+
+	stages := wordStageMap{}
+	for rows.Next() {
+		var (
+			wordID                                    int64
+			repetitions, relearningStep, intervalDays int
+		)
+		if err := rows.Scan(&wordID, &repetitions, &relearningStep, &intervalDays); err != nil {
+			return nil, fmt.Errorf("failed to scan learning state: %v", err)
+		}
+		switch {
+		case repetitions == 0 || relearningStep > 0:
+			stages[wordID] = WordStageLearning
+		case intervalDays < srs.MatureIntervalDays:
+			stages[wordID] = WordStageYoung
+		default:
+			stages[wordID] = WordStageMature
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating learning states: %v", err)
+	}
+	return stages, nil
+}
+
+// AddPassageWord adds the word of a passage at a position, in characters,
+// to a group, or to the user's inbox group of the passage's language if
+// groupID is 0. wordID picks which word a segment reading as several is
+// added, by default the first. A word not in the vocabulary is first captured into
+// the inbox, as CaptureInboxWord does.
+func (s *Service) AddPassageWord(ctx context.Context, passageID int64, position int, wordID, groupID int64) (*models.AddedWord, error) {
+	passage, err := s.GetPassage(ctx, passageID)
+	if err != nil {
+		return nil, err
+	}
+	if groupID != 0 {
+		if err := s.checkGroupEditor(ctx, s.db, groupID); err != nil {
+			return nil, err
+		}
+	}
+	index, err := vocabularyIndex(ctx, s.db, passage.Language)
+	if err != nil {
+		return nil, err
+	}
+	var segment *corpus.Segment
+	for _, candidate := range index.Segment(passage.Text) {
+		if candidate.Word && candidate.Position <= position && position < candidate.Position+candidate.Length {
+			segment = &candidate
+			break
+		}
+	}
+	if segment == nil {
+		return nil, invalid("no word at position %d", position)
+	}
+
+	added := &models.AddedWord{GroupID: groupID}
+	if len(segment.WordIDs) == 0 {
+		if wordID != 0 {
+			return nil, invalid("word %d isn't at position %d", wordID, position)
+		}
+		captured, err := s.CaptureInboxWord(ctx, segment.Text, models.Word{Language: passage.Language})
+		if err != nil {
+			return nil, err
+		}
+		added.WordID, added.Created = captured.WordID, captured.Created
+		if groupID == 0 {
+			added.GroupID = captured.GroupID
+			return added, nil
+		}
+	} else {
+		added.WordID = segment.WordIDs[0]
+		if wordID != 0 {
+			found := false
+			for _, id := range segment.WordIDs {
+				found = found || id == wordID
+			}
+			if !found {
+				return nil, invalid("word %d isn't at position %d", wordID, position)
+			}
+			added.WordID = wordID
+		}
+	}
+
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if added.GroupID == 0 {
+			groupID, err := s.inboxGroupID(ctx, tx, passage.Language)
+			if err != nil {
+				return err
+			}
+			added.GroupID = groupID
+		}
+		if err := checkGroupLanguage(ctx, tx, added.GroupID, []int64{added.WordID}); err != nil {
+			return err
+		}
+		return s.addGroupWord(ctx, tx, added.GroupID, added.WordID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/spelling"
+	"lang_portal/internal/stt"
+	"strings"
+	"time"
+)
+
+// pronunciationActivity is the study activity pronunciation sessions are
+// filed under
+const pronunciationActivity = "Pronunciation"
+
+// Scores from which a transcript counts as the word said correctly, and as
+// said nearly correctly
+const (
+	pronunciationCorrectScore  = 0.9
+	pronunciationNearMissScore = 0.7
+)
+
+// sttProvider returns the speech-to-text provider configured by the
+// environment
+func sttProvider() (stt.Provider, error) {
+	provider, err := stt.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid speech-to-text settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no speech-to-text provider is configured, set %s", stt.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// pronunciationScore returns how alike a transcript and the expected Urdu
+// are. Transcripts often hold more than the word, e.g. a word said twice
+// or "um" first, so each run of as many words as the expected has is
+// scored too and the best score is kept.
+func pronunciationScore(transcript, expected string) float64 {
+	best := spelling.Similarity(transcript, expected)
+	words := strings.Fields(spelling.Normalize(transcript))
+	size := len(strings.Fields(spelling.Normalize(expected)))
+	for i := 0; size > 0 && i+size <= len(words); i++ {
+		best = max(best, spelling.Similarity(strings.Join(words[i:i+size], " "), expected))
+	}
+	return best
+}
+
+// pronunciationResult grades a score as a typed answer would be
+func pronunciationResult(score float64) spelling.Result {
+	switch {
+	case score >= pronunciationCorrectScore:
+		return spelling.Exact
+	case score >= pronunciationNearMissScore:
+		return spelling.NearMiss
+	default:
+		return spelling.Wrong
+	}
+}
+
+// CheckPronunciation transcribes a recording of a word with the configured
+// speech-to-text provider and scores how close it came to the word's Urdu.
+// The attempt is kept in a pronunciation session: sessionID's, or a new
+// one on groupID if sessionID is 0. The word must be in the session's
+// group. A word's first attempt in a session is recorded as a review of it,
+// near misses graded hard; later attempts are practice.
+func (s *Service) CheckPronunciation(ctx context.Context, wordID, sessionID, groupID int64, audio []byte) (*models.PronunciationCheck, error) {
+	if len(audio) == 0 {
+		return nil, invalid("audio is required")
+	}
+	contentType := stt.Detect(audio)
+	if contentType == "" {
+		return nil, invalid("unsupported recording: use WAV, FLAC, Ogg, WebM, MP3 or M4A")
+	}
+	word, err := s.getWord(ctx, wordID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID != 0 {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT ss.group_id FROM study_sessions ss
+			JOIN study_activities sa ON sa.id = ss.study_activity_id
+			WHERE ss.id = ? AND ss.user_id = ? AND sa.name = ?
+		`, sessionID, s.userID, pronunciationActivity).Scan(&groupID)
+		if err == sql.ErrNoRows {
+			return nil, notFound("pronunciation session not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get study session: %v", err)
+		}
+	} else if groupID == 0 {
+		return nil, invalid("session_id or group_id is required")
+	} else if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	var inGroup bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words_groups WHERE group_id = ? AND word_id = ?)
+	`, groupID, wordID).Scan(&inGroup); err != nil {
+		return nil, fmt.Errorf("failed to check group words: %v", err)
+	}
+	if !inGroup {
+		return nil, invalid("word %d isn't in group %d", wordID, groupID)
+	}
+
+	provider, err := sttProvider()
+	if err != nil {
+		return nil, err
+	}
+	transcript, err := provider.Transcribe(ctx, audio, contentType)
+	if errors.Is(err, stt.ErrUnsupportedType) {
+		return nil, unsupported("%v", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe recording: %v", err)
+	}
+
+	score := pronunciationScore(transcript, word.Urdu)
+	result := pronunciationResult(score)
+	check := &models.PronunciationCheck{
+		WordID:     wordID,
+		Expected:   word.Urdu,
+		Transcript: transcript,
+		Provider:   provider.Name(),
+		Score:      score,
+		Result:     string(result),
+	}
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if sessionID == 0 {
+			var err error
+			if sessionID, err = s.startActivitySession(ctx, tx, pronunciationActivity, groupID, ""); err != nil {
+				return err
+			}
+		}
+		check.SessionID = sessionID
+
+		var attempted bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM pronunciation_attempts WHERE study_session_id = ? AND word_id = ?)
+		`, sessionID, wordID).Scan(&attempted); err != nil {
+			return fmt.Errorf("failed to check pronunciation attempts: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pronunciation_attempts (study_session_id, word_id, provider, transcript, score, result, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, sessionID, wordID, check.Provider, transcript, score, check.Result, time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to save pronunciation attempt: %v", err)
+		}
+		if attempted {
+			return nil
+		}
+		var err error
+		check.Review, err = s.reviewWord(ctx, tx, sessionID, wordID, result != spelling.Wrong, result == spelling.NearMiss)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/notify"
+	"lang_portal/internal/push"
+	"log"
+	"strings"
+	"time"
+)
+
+// NotificationReviewsDue is the kind of notification of the words due for
+// review today
+const NotificationReviewsDue = "reviews_due"
+
+const (
+	// reviewsDueHour is the hour (UTC) from which learners are told of the
+	// words due that day
+	reviewsDueHour = 8
+	// pushTTL is how long a push service keeps a notification for a browser
+	// that is offline
+	pushTTL = 12 * time.Hour
+	// maxPushSubscriptions is how many browsers a user can subscribe
+	maxPushSubscriptions = 20
+)
+
+// pushSender returns the configured push sender, or an unsupported error if
+// there is none
+func pushSender() (*push.Sender, error) {
+	sender, err := push.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure push: %v", err)
+	}
+	if sender == nil {
+		return nil, unsupported("push notifications aren't configured, set %s", push.PrivateKeyEnv)
+	}
+	return sender, nil
+}
+
+// PushPublicKey returns the VAPID public key browsers subscribe with
+func (s *Service) PushPublicKey() (string, error) {
+	sender, err := pushSender()
+	if err != nil {
+		return "", err
+	}
+	return sender.PublicKey(), nil
+}
+
+// StartPush queues a push dispatch now and then at the start of every hour
+// until the service is closed. It does nothing if push isn't configured.
+func (s *Service) StartPush() {
+	if sender, err := push.FromEnv(); err != nil || sender == nil {
+		if err != nil {
+			log.Printf("Push notifications are off: %v", err)
+		}
+		return
+	}
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if _, err := s.runner.Enqueue(ctx, 0, JobDispatchPush, nil); err != nil {
+				log.Printf("Failed to queue push dispatch: %v", err)
+			}
+
+			next := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+			select {
+			case <-time.After(time.Until(next)):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// SubscribePush subscribes a browser to the user's notifications. A browser
+// subscribed before, by this user or another, is moved to this user with
+// its new keys.
+func (s *Service) SubscribePush(ctx context.Context, sub push.Subscription, reviewsDue, streakAtRisk bool, now time.Time) (*models.PushSubscription, error) {
+	if _, err := pushSender(); err != nil {
+		return nil, err
+	}
+	sub.Endpoint = strings.TrimSpace(sub.Endpoint)
+	if err := push.ValidEndpoint(sub.Endpoint); err != nil {
+		return nil, invalid("%v", err)
+	}
+	if sub.P256dh == "" || sub.Auth == "" {
+		return nil, invalid("keys.p256dh and keys.auth are required")
+	}
+
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM push_subscriptions WHERE user_id = ? AND endpoint != ?
+	`, s.userID, sub.Endpoint).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count push subscriptions: %v", err)
+	}
+	if count >= maxPushSubscriptions {
+		return nil, conflict("at most %d browsers can be subscribed", maxPushSubscriptions)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, reviews_due, streak_at_risk, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(endpoint) DO UPDATE SET
+			user_id = excluded.user_id,
+			p256dh = excluded.p256dh,
+			auth = excluded.auth,
+			reviews_due = excluded.reviews_due,
+			streak_at_risk = excluded.streak_at_risk
+	`, s.userID, sub.Endpoint, sub.P256dh, sub.Auth, reviewsDue, streakAtRisk, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save push subscription: %v", err)
+	}
+
+	var subscription models.PushSubscription
+	err = s.db.QueryRowContext(ctx, `
+		SELECT id, endpoint, reviews_due, streak_at_risk, created_at
+		FROM push_subscriptions WHERE endpoint = ?
+	`, sub.Endpoint).Scan(&subscription.ID, &subscription.Endpoint, &subscription.ReviewsDue,
+		&subscription.StreakAtRisk, &subscription.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push subscription: %v", err)
+	}
+	return &subscription, nil
+}
+
+// ListPushSubscriptions returns the browsers the user subscribed
+func (s *Service) ListPushSubscriptions(ctx context.Context) ([]models.PushSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, endpoint, reviews_due, streak_at_risk, created_at
+		FROM push_subscriptions
+		WHERE user_id = ?
+		ORDER BY id
+	`, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list push subscriptions: %v", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []models.PushSubscription{}
+	for rows.Next() {
+		var subscription models.PushSubscription
+		if err := rows.Scan(&subscription.ID, &subscription.Endpoint, &subscription.ReviewsDue,
+			&subscription.StreakAtRisk, &subscription.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %v", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating push subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// DeletePushSubscription unsubscribes one of the user's browsers
+func (s *Service) DeletePushSubscription(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM push_subscriptions WHERE id = ? AND user_id = ?
+	`, id, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if deleted == 0 {
+		return notFound("push subscription not found")
+	}
+	return nil
+}
+
+// pushMessage is the notification a service worker shows, as JSON
+type pushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	// Tag replaces an earlier notification of the same kind
+	Tag string `json:"tag"`
+}
+
+// pushTarget is a subscribed browser notifications are pushed to
+type pushTarget struct {
+	id           int64
+	sub          push.Subscription
+	reviewsDue   bool
+	streakAtRisk bool
+}
+
+// DispatchPush pushes every user's subscribed browsers the notifications
+// due as of now that they want: the number of words due for review, from
+// the morning of a day any are due, and a streak reminder in the evening of
+// a day the user hasn't studied yet after studying the day before. Each is
+// pushed once a day, or tried again by later dispatches if every browser
+// failed. Browsers whose subscriptions have gone are unsubscribed.
+func (s *Service) DispatchPush(ctx context.Context, now time.Time) (*models.NotificationDispatch, error) {
+	sender, err := pushSender()
+	if err != nil {
+		return nil, err
+	}
+	now = now.UTC()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, endpoint, p256dh, auth, reviews_due, streak_at_risk
+		FROM push_subscriptions
+		WHERE reviews_due OR streak_at_risk
+		ORDER BY user_id, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find push subscriptions: %v", err)
+	}
+	targets := map[int64][]pushTarget{}
+	var users []int64
+	for rows.Next() {
+		var (
+			target pushTarget
+			userID int64
+		)
+		if err := rows.Scan(&target.id, &userID, &target.sub.Endpoint, &target.sub.P256dh, &target.sub.Auth,
+			&target.reviewsDue, &target.streakAtRisk); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan push subscription: %v", err)
+		}
+		if _, ok := targets[userID]; !ok {
+			users = append(users, userID)
+		}
+		targets[userID] = append(targets[userID], target)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating push subscriptions: %v", err)
+	}
+
+	var dispatch models.NotificationDispatch
+	for _, userID := range users {
+		user := s.ForUser(userID)
+		if now.Hour() >= reviewsDueHour {
+			if err := user.pushReviewsDue(ctx, sender, targets[userID], now, &dispatch); err != nil {
+				return nil, err
+			}
+		}
+		if now.Hour() >= streakReminderHour {
+			if err := user.pushStreakAtRisk(ctx, sender, targets[userID], now, &dispatch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &dispatch, nil
+}
+
+// pushReviewsDue tells the user how many words are due for review by the
+// end of the day, if any
+func (s *Service) pushReviewsDue(ctx context.Context, sender *push.Sender, targets []pushTarget, now time.Time, dispatch *models.NotificationDispatch) error {
+	var wanting []pushTarget
+	for _, target := range targets {
+		if target.reviewsDue {
+			wanting = append(wanting, target)
+		}
+	}
+	if len(wanting) == 0 {
+		return nil
+	}
+	var due int
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM word_learning_state WHERE user_id = ? AND due_at <= ?
+	`, s.userID, endOfDay).Scan(&due)
+	if err != nil {
+		return fmt.Errorf("failed to count words due: %v", err)
+	}
+	if due == 0 {
+		return nil
+	}
+	msg := pushMessage{
+		Title: "Reviews due today",
+		Body:  fmt.Sprintf("%d words are due for review today.", due),
+		URL:   "/review",
+		Tag:   NotificationReviewsDue,
+	}
+	if due == 1 {
+		msg.Body = "1 word is due for review today."
+	}
+	return s.pushNotification(ctx, sender, wanting, NotificationReviewsDue, now, msg, dispatch)
+}
+
+// pushStreakAtRisk reminds the user of their streak if they studied
+// yesterday but not yet today
+func (s *Service) pushStreakAtRisk(ctx context.Context, sender *push.Sender, targets []pushTarget, now time.Time, dispatch *models.NotificationDispatch) error {
+	var wanting []pushTarget
+	for _, target := range targets {
+		if target.streakAtRisk {
+			wanting = append(wanting, target)
+		}
+	}
+	if len(wanting) == 0 {
+		return nil
+	}
+	streak, err := s.streakAtRisk(ctx, now)
+	if err != nil || streak == 0 {
+		return err
+	}
+	msg := pushMessage{
+		Title: "Your streak is at risk",
+		Body:  fmt.Sprintf("Study today to keep your %d-day streak going.", streak),
+		URL:   "/",
+		Tag:   notify.StreakAtRisk,
+	}
+	return s.pushNotification(ctx, sender, wanting, notify.StreakAtRisk, now, msg, dispatch)
+}
+
+// pushNotification pushes a notification to the user's browsers once a
+// day. It counts as sent if any browser took it.
+func (s *Service) pushNotification(ctx context.Context, sender *push.Sender, targets []pushTarget, kind string, now time.Time, msg pushMessage, dispatch *models.NotificationDispatch) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode push notification: %v", err)
+	}
+	to := fmt.Sprintf("%d browsers", len(targets))
+	if len(targets) == 1 {
+		to = "1 browser"
+	}
+	return s.deliver(ctx, kind, ChannelPush, now.Format("2006-01-02"), to, msg.Title, now, dispatch, func() error {
+		var (
+			errs []error
+			sent bool
+		)
+		for _, target := range targets {
+			err := sender.Send(ctx, target.sub, payload, pushTTL)
+			switch {
+			case errors.Is(err, push.ErrGone):
+				if _, err := s.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE id = ?`, target.id); err != nil {
+					log.Printf("Failed to delete push subscription %d: %v", target.id, err)
+				}
+				errs = append(errs, fmt.Errorf("subscription %d is gone", target.id))
+			case err != nil:
+				errs = append(errs, err)
+			default:
+				sent = true
+			}
+		}
+		if sent {
+			return nil
+		}
+		return errors.Join(errs...)
+	})
+}
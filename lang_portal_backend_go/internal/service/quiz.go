@@ -0,0 +1,572 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/db/queries"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/spelling"
+	"strings"
+	"time"
+)
+
+// VocabularyQuizActivity is the study activity of vocabulary quizzes
+const VocabularyQuizActivity = "Vocabulary Quiz"
+
+// Quiz answer modes
+const (
+	AnswerModeMultipleChoice = "multiple_choice"
+	AnswerModeTyped          = "typed"
+)
+
+// Quiz question results
+const (
+	QuizResultCorrect    = "correct"
+	QuizResultNearMiss   = "near_miss"
+	QuizResultWrong      = "wrong"
+	QuizResultUnanswered = "unanswered"
+)
+
+// SetStudySessionDifficulty records the difficulty a session is played at
+func (s *Service) SetStudySessionDifficulty(ctx context.Context, sessionID int64, difficulty string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE study_sessions SET difficulty = ? WHERE id = ? AND user_id = ?
+	`, difficulty, sessionID, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to set session difficulty: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return notFound("study session not found")
+	}
+	return nil
+}
+
+// GetStudySessionDifficulty returns the difficulty a session is played at,
+// or an empty string for sessions started without one
+func (s *Service) GetStudySessionDifficulty(ctx context.Context, sessionID int64) (string, error) {
+	var difficulty sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT difficulty FROM study_sessions WHERE id = ? AND user_id = ?
+	`, sessionID, s.userID).Scan(&difficulty)
+	if err == sql.ErrNoRows {
+		return "", notFound("study session not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get session difficulty: %v", err)
+	}
+	return difficulty.String, nil
+}
+
+// GetWordsOutsideGroup returns up to limit random words of the group's
+// language that are not in the given group, out of those the user can see
+func (s *Service) GetWordsOutsideGroup(ctx context.Context, groupID int64, limit int) ([]models.WordResponse, error) {
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM words w
+		WHERE w.id NOT IN (SELECT word_id FROM words_groups WHERE group_id = ?1)
+		  AND w.language = (SELECT language FROM groups WHERE id = ?1)
+		  AND `+repository.VisibleWord("?3")+`
+		ORDER BY RANDOM()
+		LIMIT ?2
+	`, groupID, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words outside group: %v", err)
+	}
+	defer rows.Close()
+
+	return scanWordResponses(rows)
+}
+
+// GetWordsOfSameType returns up to limit random words of the given word's
+// language sharing its part of speech ("type" in the word parts), out of
+// those the user can see
+func (s *Service) GetWordsOfSameType(ctx context.Context, wordID int64, limit int) ([]models.WordResponse, error) {
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM words w
+		JOIN words target ON target.id = ?1
+		WHERE w.id != target.id
+		  AND w.language = target.language
+		  AND CASE WHEN json_valid(w.parts) THEN json_extract(w.parts, '$.type') END =
+			  CASE WHEN json_valid(target.parts) THEN json_extract(target.parts, '$.type') END
+		  AND `+repository.VisibleWord("?3")+`
+		ORDER BY RANDOM()
+		LIMIT ?2
+	`, wordID, limit, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words of same type: %v", err)
+	}
+	defer rows.Close()
+
+	return scanWordResponses(rows)
+}
+
+func scanWordResponses(rows *models.Rows) ([]models.WordResponse, error) {
+	var words []models.WordResponse
+	for rows.Next() {
+		var word models.WordResponse
+		if err := rows.Scan(&word.ID, &word.Urdu, &word.Urdlish, &word.English); err != nil {
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		words = append(words, word)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %v", err)
+	}
+	return words, nil
+}
+
+// SaveQuizQuestion stores the question for a word in a quiz session. A
+// question that is already stored for the word is kept, and loaded back into
+// question so callers always serve the stored version.
+func (s *Service) SaveQuizQuestion(ctx context.Context, question *models.QuizQuestion) error {
+	options, err := json.Marshal(question.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode options: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO quiz_questions (study_session_id, word_id, direction, answer_mode, options, correct_answer)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(study_session_id, word_id) DO NOTHING
+	`, question.StudySessionID, question.WordID, question.Direction, question.AnswerMode,
+		string(options), question.CorrectAnswer)
+	if err != nil {
+		return fmt.Errorf("failed to save quiz question: %v", err)
+	}
+
+	stored, err := s.GetQuizQuestion(ctx, question.StudySessionID, question.WordID)
+	if err != nil {
+		return err
+	}
+	*question = *stored
+	return nil
+}
+
+// GetQuizQuestions returns the questions of a quiz session in the order they
+// were created
+func (s *Service) GetQuizQuestions(ctx context.Context, sessionID int64) ([]models.QuizQuestion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, study_session_id, word_id, direction, answer_mode, options, hints_used, correct_answer
+		FROM quiz_questions
+		WHERE study_session_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+		ORDER BY id
+	`, sessionID, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz questions: %v", err)
+	}
+	defer rows.Close()
+
+	var questions []models.QuizQuestion
+	for rows.Next() {
+		var (
+			question models.QuizQuestion
+			options  string
+		)
+		if err := rows.Scan(&question.ID, &question.StudySessionID, &question.WordID,
+			&question.Direction, &question.AnswerMode, &options, &question.HintsUsed, &question.CorrectAnswer); err != nil {
+			return nil, fmt.Errorf("failed to scan quiz question: %v", err)
+		}
+		if err := json.Unmarshal([]byte(options), &question.Options); err != nil {
+			return nil, fmt.Errorf("failed to decode options: %v", err)
+		}
+		questions = append(questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quiz questions: %v", err)
+	}
+	return questions, nil
+}
+
+// GetQuizQuestion returns the question served for a word in a quiz session
+func (s *Service) GetQuizQuestion(ctx context.Context, sessionID, wordID int64) (*models.QuizQuestion, error) {
+	var (
+		question models.QuizQuestion
+		options  string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, study_session_id, word_id, direction, answer_mode, options, hints_used, correct_answer
+		FROM quiz_questions
+		WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+	`, sessionID, wordID, s.userID).Scan(&question.ID, &question.StudySessionID, &question.WordID,
+		&question.Direction, &question.AnswerMode, &options, &question.HintsUsed, &question.CorrectAnswer)
+	if err == sql.ErrNoRows {
+		return nil, notFound("quiz question not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz question: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(options), &question.Options); err != nil {
+		return nil, fmt.Errorf("failed to decode options: %v", err)
+	}
+	return &question, nil
+}
+
+// AnswerQuizQuestion checks an answer against the question served for the
+// word and records the review. Questions asking for one of the word's
+// English meanings accept any of its senses. Typed answers forgive
+// diacritics and small typos; those with typos are recorded as near misses.
+// It returns the review and the correct answer. A question is answered
+// once; answering it again is a conflict, since the correct answer was
+// revealed.
+func (s *Service) AnswerQuizQuestion(ctx context.Context, sessionID, wordID int64, answer string) (*models.WordReviewItem, string, error) {
+	question, err := s.GetQuizQuestion(ctx, sessionID, wordID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A question whose answer is one of the word's senses asks for its
+	// English, and takes any of them
+	accepted := []models.WordSense{{English: question.CorrectAnswer}}
+	senses, err := wordSenses(ctx, s.db, wordID)
+	if err != nil {
+		return nil, "", err
+	}
+	if isSense(question.CorrectAnswer, senses) {
+		accepted = append(accepted, senses...)
+	}
+
+	var correct, nearMiss bool
+	if question.AnswerMode == AnswerModeTyped {
+		result := matchSenses(answer, accepted)
+		correct = result != spelling.Wrong
+		nearMiss = result == spelling.NearMiss
+	} else {
+		correct = isSense(answer, accepted)
+	}
+
+	var reviewItem *models.WordReviewItem
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		// Keep the answer itself for the score breakdown. The first answer
+		// is recorded before the review, so a second one reviews nothing.
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO quiz_answers (quiz_question_id, answer, correct, near_miss, answered_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, question.ID, answer, correct, nearMiss)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate key") {
+				return conflict("quiz question was already answered")
+			}
+			return fmt.Errorf("failed to record answer: %v", err)
+		}
+
+		reviewItem, err = s.reviewWord(ctx, tx, sessionID, wordID, correct, nearMiss)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return reviewItem, question.CorrectAnswer, nil
+}
+
+// GetQuizResults returns how each question of a quiz session was answered,
+// in the order the questions were created
+func (s *Service) GetQuizResults(ctx context.Context, sessionID int64) ([]models.QuizQuestionResult, error) {
+	rows, err := s.queries.ListQuizResults(ctx, queries.ListQuizResultsParams{StudySessionID: sessionID, UserID: s.userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz results: %v", err)
+	}
+
+	var results []models.QuizQuestionResult
+	for _, row := range rows {
+		result := models.QuizQuestionResult{
+			QuestionID:    row.ID,
+			WordID:        row.WordID,
+			Direction:     row.Direction,
+			AnswerMode:    row.AnswerMode,
+			CorrectAnswer: row.CorrectAnswer,
+			HintsUsed:     int(row.HintsUsed),
+			Answer:        row.Answer,
+			AnsweredAt:    row.AnsweredAt,
+		}
+		switch {
+		case row.Answer == nil:
+			result.Result = QuizResultUnanswered
+		case row.NearMiss != nil && *row.NearMiss:
+			result.Result = QuizResultNearMiss
+		case row.Correct != nil && *row.Correct:
+			result.Result = QuizResultCorrect
+		default:
+			result.Result = QuizResultWrong
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GetWrongWords returns the words answered incorrectly in a session
+func (s *Service) GetWrongWords(ctx context.Context, sessionID int64) ([]models.WordResponse, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM word_review_items wri
+		JOIN words w ON w.id = wri.word_id
+		WHERE wri.study_session_id = ? AND wri.user_id = ? AND wri.correct = 0
+		ORDER BY wri.created_at, w.id
+	`, sessionID, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wrong words: %v", err)
+	}
+	defer rows.Close()
+
+	return scanWordResponses(rows)
+}
+
+// CreateRetrySession starts a follow-up session over the words answered
+// incorrectly in a session. The new session keeps the original's group,
+// activity and difficulty and links back to it.
+func (s *Service) CreateRetrySession(ctx context.Context, sessionID int64) (*models.StudySessionResponse, []models.WordResponse, error) {
+	var (
+		groupID    int64
+		activityID int64
+		difficulty sql.NullString
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT group_id, study_activity_id, difficulty FROM study_sessions WHERE id = ? AND user_id = ?
+	`, sessionID, s.userID).Scan(&groupID, &activityID, &difficulty)
+	if err == sql.ErrNoRows {
+		return nil, nil, notFound("study session not found")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get study session: %v", err)
+	}
+
+	words, err := s.GetWrongWords(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(words) == 0 {
+		return nil, nil, invalid("no wrong answers to retry")
+	}
+
+	session, err := s.CreateStudySession(ctx, groupID, activityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wordIDs := make([]int64, len(words))
+	for i, word := range words {
+		wordIDs[i] = word.ID
+	}
+	if err := s.AddWordsToStudySession(ctx, session.ID, wordIDs); err != nil {
+		return nil, nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE study_sessions SET parent_session_id = ?, difficulty = ? WHERE id = ?
+	`, sessionID, difficulty, session.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to link retry session: %v", err)
+	}
+	session.ParentSessionID = &sessionID
+
+	return session, words, nil
+}
+
+// GetQuizHistory returns the scores of a group's past sessions of the named
+// activity, most recent first. Difficulty and direction are empty for
+// sessions that didn't record them.
+func (s *Service) GetQuizHistory(ctx context.Context, groupID int64, activityName string) ([]models.QuizHistoryEntry, error) {
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, notFound("group not found")
+	}
+
+	// Sessions with stored questions are scored over their questions, older
+	// ones over their session words
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ss.id, ss.created_at, ss.difficulty, ss.parent_session_id,
+			(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.study_session_id = ss.id),
+			(SELECT COUNT(*) FROM study_session_words ssw WHERE ssw.study_session_id = ss.id),
+			(SELECT COUNT(*) FROM word_review_items wri WHERE wri.study_session_id = ss.id AND wri.correct = 1),
+			(SELECT qq.direction FROM quiz_questions qq WHERE qq.study_session_id = ss.id ORDER BY qq.id LIMIT 1),
+			(SELECT COALESCE(SUM(qq.hints_used), 0) FROM quiz_questions qq
+			 JOIN quiz_answers qa ON qa.quiz_question_id = qq.id
+			 WHERE qq.study_session_id = ss.id AND qa.correct = 1)
+		FROM study_sessions ss
+		JOIN study_activities sa ON sa.id = ss.study_activity_id
+		WHERE ss.group_id = ? AND sa.name = ? AND ss.user_id = ?
+		ORDER BY ss.created_at DESC, ss.id DESC
+	`, groupID, activityName, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz history: %v", err)
+	}
+	defer rows.Close()
+
+	var history []models.QuizHistoryEntry
+	for rows.Next() {
+		var (
+			entry         models.QuizHistoryEntry
+			difficulty    sql.NullString
+			parentID      sql.NullInt64
+			questionCount int
+			wordCount     int
+			direction     sql.NullString
+		)
+		if err := rows.Scan(&entry.SessionID, &entry.CreatedAt, &difficulty, &parentID,
+			&questionCount, &wordCount, &entry.CorrectCount, &direction, &entry.HintsUsed); err != nil {
+			return nil, fmt.Errorf("failed to scan quiz history: %v", err)
+		}
+
+		entry.TotalWords = wordCount
+		if questionCount > 0 {
+			entry.TotalWords = questionCount
+		}
+		if entry.TotalWords > 0 {
+			entry.Accuracy = float64(entry.CorrectCount) / float64(entry.TotalWords)
+		}
+		entry.Difficulty = difficulty.String
+		entry.Direction = direction.String
+		if parentID.Valid {
+			entry.ParentSessionID = &parentID.Int64
+		}
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quiz history: %v", err)
+	}
+	return history, nil
+}
+
+// GetGroupLastReviewed returns when each reviewed word of a group was last
+// reviewed
+func (s *Service) GetGroupLastReviewed(ctx context.Context, groupID int64) (map[int64]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT wri.word_id, CAST(strftime('%s', MAX(wri.created_at)) AS INTEGER)
+		FROM word_review_items wri
+		JOIN words_groups wg ON wg.word_id = wri.word_id
+		WHERE wg.group_id = ? AND wri.user_id = ?
+		GROUP BY wri.word_id
+	`, groupID, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last reviews: %v", err)
+	}
+	defer rows.Close()
+
+	lastReviewed := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			wordID     int64
+			reviewedAt sql.NullInt64
+		)
+		if err := rows.Scan(&wordID, &reviewedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan last review: %v", err)
+		}
+		if reviewedAt.Valid {
+			lastReviewed[wordID] = time.Unix(reviewedAt.Int64, 0).UTC()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating last reviews: %v", err)
+	}
+	return lastReviewed, nil
+}
+
+// GetRecentlyQuizzedWords returns the words asked in a group's last sessions
+// of the named activity, leaving out the session excludeID. Retry rounds are
+// not counted as sessions.
+func (s *Service) GetRecentlyQuizzedWords(ctx context.Context, groupID int64, activityName string, sessions int, excludeID int64) (map[int64]bool, error) {
+	recent := make(map[int64]bool)
+	if sessions <= 0 {
+		return recent, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ssw.word_id
+		FROM study_session_words ssw
+		WHERE ssw.study_session_id IN (
+			SELECT ss.id FROM study_sessions ss
+			JOIN study_activities sa ON sa.id = ss.study_activity_id
+			WHERE ss.group_id = ? AND sa.name = ? AND ss.parent_session_id IS NULL
+			  AND ss.user_id = ? AND ss.id != ?
+			ORDER BY ss.created_at DESC, ss.id DESC
+			LIMIT ?
+		)
+	`, groupID, activityName, s.userID, excludeID, sessions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent quiz words: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wordID int64
+		if err := rows.Scan(&wordID); err != nil {
+			return nil, fmt.Errorf("failed to scan recent quiz word: %v", err)
+		}
+		recent[wordID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recent quiz words: %v", err)
+	}
+	return recent, nil
+}
+
+// GetWordExample returns the example sentence in a word's parts, or an empty
+// string if it has none
+func (s *Service) GetWordExample(ctx context.Context, wordID int64) (string, error) {
+	var example sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT CASE WHEN json_valid(parts) THEN json_extract(parts, '$.example') END
+		FROM words
+		WHERE id = ?
+	`, wordID).Scan(&example)
+	if err == sql.ErrNoRows {
+		return "", notFound("word not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get word example: %v", err)
+	}
+	return example.String, nil
+}
+
+// UseQuizHint records that the next hint of a quiz question was shown, up to
+// the number of hints available, and returns how many have been used. Hints
+// can't be taken once the question is answered.
+func (s *Service) UseQuizHint(ctx context.Context, questionID int64, available int) (int, error) {
+	var used int
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var answered bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM quiz_answers WHERE quiz_question_id = ?)
+		`, questionID).Scan(&answered)
+		if err != nil {
+			return fmt.Errorf("failed to check quiz answer: %v", err)
+		}
+		if answered {
+			return conflict("quiz question already answered")
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			UPDATE quiz_questions
+			SET hints_used = MIN(hints_used + 1, ?)
+			WHERE id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+			RETURNING hints_used
+		`, available, questionID, s.userID).Scan(&used)
+		if err == sql.ErrNoRows {
+			return notFound("quiz question not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record hint: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return used, nil
+}
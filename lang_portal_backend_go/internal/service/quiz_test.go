@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestQuizDistractors(t *testing.T) {
+	ctx := context.Background()
+	// Word 6 is Arabic; words 1, 4, 5 and 6 are nouns
+	svc := newTestService(t, testGroups, `
+		INSERT INTO words (id, script, transliteration, english, language) VALUES (6, 'ماء', 'maa', 'water', 'ar');
+		UPDATE words SET parts = '{"type": "noun"}' WHERE id IN (1, 4, 5, 6);
+	`)
+	tests := []struct {
+		name         string
+		userID       int64
+		wantOutside  []int64
+		wantSameType []int64
+	}{
+		{name: "admin", userID: testAdmin, wantOutside: []int64{1, 2, 3, 4, 5}, wantSameType: []int64{4, 5}},
+		{name: "viewer", userID: testViewer, wantOutside: []int64{1, 2, 3, 4, 5}, wantSameType: []int64{4, 5}},
+		{name: "user without a role", userID: testStranger, wantOutside: []int64{1, 2, 3, 5}, wantSameType: []int64{5}},
+	}
+
+	ids := func(words []models.WordResponse) []int64 {
+		var got []int64
+		for _, word := range words {
+			got = append(got, word.ID)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		return got
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := svc.ForUser(tt.userID)
+			// Group 3 has no words
+			outside, err := s.GetWordsOutsideGroup(ctx, 3, 100)
+			if err != nil {
+				t.Fatalf("GetWordsOutsideGroup() error = %v", err)
+			}
+			if got := ids(outside); !reflect.DeepEqual(got, tt.wantOutside) {
+				t.Errorf("GetWordsOutsideGroup() = words %v, want %v", got, tt.wantOutside)
+			}
+			sameType, err := s.GetWordsOfSameType(ctx, 1, 100)
+			if err != nil {
+				t.Fatalf("GetWordsOfSameType() error = %v", err)
+			}
+			if got := ids(sameType); !reflect.DeepEqual(got, tt.wantSameType) {
+				t.Errorf("GetWordsOfSameType() = words %v, want %v", got, tt.wantSameType)
+			}
+		})
+	}
+}
+
+func TestQuizActivityByName(t *testing.T) {
+	// The quiz activity isn't the first, so it must be found by name
+	svc := newTestService(t, `
+		UPDATE study_activities SET id = 99 WHERE name = 'Vocabulary Quiz';
+		INSERT INTO study_activities (id, name) VALUES (1, 'Other Activity');
+		INSERT INTO study_sessions (id, group_id, study_activity_id, user_id, created_at) VALUES
+		(10, 1, 99, 1, '2024-03-10 10:00:00'),
+		(11, 1, 1, 1, '2024-03-11 10:00:00');
+		INSERT INTO study_session_words (study_session_id, word_id) VALUES (10, 1), (11, 2);
+	`)
+
+	recent, err := svc.GetRecentlyQuizzedWords(context.Background(), 1, VocabularyQuizActivity, 5, 0)
+	if err != nil {
+		t.Fatalf("GetRecentlyQuizzedWords() error = %v", err)
+	}
+	if want := map[int64]bool{1: true}; !reflect.DeepEqual(recent, want) {
+		t.Errorf("GetRecentlyQuizzedWords() = %v, want %v", recent, want)
+	}
+
+	history, err := svc.GetQuizHistory(context.Background(), 1, VocabularyQuizActivity)
+	if err != nil {
+		t.Fatalf("GetQuizHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0].SessionID != 10 {
+		t.Errorf("GetQuizHistory() = %+v, want session 10 only", history)
+	}
+}
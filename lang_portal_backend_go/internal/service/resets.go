@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/cache"
+	"lang_portal/internal/db"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resetConfirmationTTL is how long a reset's confirmation token can be used
+const resetConfirmationTTL = 5 * time.Minute
+
+func resetConfirmationKey(token string) string {
+	return "reset_confirmation:" + token
+}
+
+// checkResetKind returns a validation error for kinds of reset that don't
+// exist
+func checkResetKind(kind string) error {
+	if kind != models.ResetHistory && kind != models.ResetFull {
+		return invalid("invalid kind %q: use %s or %s", kind, models.ResetHistory, models.ResetFull)
+	}
+	return nil
+}
+
+// CreateResetConfirmation gives the user a token to confirm a reset of the
+// kind with. It can be used once, within a few minutes. Anyone can reset
+// their own history; only admins can confirm full resets.
+func (s *Service) CreateResetConfirmation(ctx context.Context, kind string) (*models.ResetConfirmation, error) {
+	if err := checkResetKind(kind); err != nil {
+		return nil, err
+	}
+	if kind == models.ResetFull {
+		if err := s.CheckAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Resets are archived first, which only SQLite can do
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("resets are only supported on SQLite")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %v", err)
+	}
+	token := hex.EncodeToString(secret)
+	owner := strconv.FormatInt(s.userID, 10) + ":" + kind
+	if err := s.cache.Set(ctx, resetConfirmationKey(token), []byte(owner), resetConfirmationTTL); err != nil {
+		return nil, fmt.Errorf("failed to save confirmation token: %v", err)
+	}
+	return &models.ResetConfirmation{
+		Token:     token,
+		Kind:      kind,
+		ExpiresAt: time.Now().UTC().Add(resetConfirmationTTL),
+	}, nil
+}
+
+// confirmReset uses up the token, which must have been given to the
+// service's user for a reset of the kind
+func (s *Service) confirmReset(ctx context.Context, kind, token string) error {
+	if token == "" {
+		return invalid("confirm_token is required: create one with POST /resets/confirmations")
+	}
+	key := resetConfirmationKey(token)
+	owner, err := s.cache.Get(ctx, key)
+	if err == cache.ErrMiss {
+		return invalid("invalid or expired confirm_token")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation token: %v", err)
+	}
+	if string(owner) != strconv.FormatInt(s.userID, 10)+":"+kind {
+		return invalid("invalid or expired confirm_token")
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to use confirmation token: %v", err)
+	}
+	return nil
+}
+
+// logReset records a reset of the kind, archived to the backup
+func (s *Service) logReset(ctx context.Context, tx *models.Tx, kind, backup string) (*models.Reset, error) {
+	reset := &models.Reset{UserID: s.userID, Kind: kind, Backup: backup, CreatedAt: time.Now().UTC()}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO resets (user_id, kind, backup, created_at) VALUES (?, ?, ?, ?)
+	`, reset.UserID, reset.Kind, reset.Backup, reset.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log reset: %v", err)
+	}
+	if reset.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to log reset: %v", err)
+	}
+	return reset, nil
+}
+
+// ListResets returns the history resets of the service's user and, to
+// admins, the full resets, newest first
+func (s *Service) ListResets(ctx context.Context) ([]models.Reset, error) {
+	admin, err := s.isAdmin(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryResets(ctx, `
+		SELECT id, user_id, kind, backup, created_at, restored_at FROM resets
+		WHERE (user_id = ? AND kind = ?) OR (? AND kind = ?)
+		ORDER BY created_at DESC, id DESC
+	`, s.userID, models.ResetHistory, admin, models.ResetFull)
+}
+
+func (s *Service) queryResets(ctx context.Context, query string, args ...interface{}) ([]models.Reset, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resets: %v", err)
+	}
+	defer rows.Close()
+	resets := []models.Reset{}
+	for rows.Next() {
+		var (
+			reset      models.Reset
+			restoredAt sql.NullTime
+		)
+		if err := rows.Scan(&reset.ID, &reset.UserID, &reset.Kind, &reset.Backup, &reset.CreatedAt, &restoredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reset: %v", err)
+		}
+		if restoredAt.Valid {
+			reset.RestoredAt = &restoredAt.Time
+		}
+		resets = append(resets, reset)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating resets: %v", err)
+	}
+	return resets, nil
+}
+
+// RestoreReset puts back what a reset deleted from its archive. A history
+// reset, which only the user who did it can restore, replaces the user's
+// history with the one archived. A full reset, which only admins can
+// restore, replaces the whole database with its archive, so everything done
+// since is lost, except the resets log. Either way the database is backed
+// up first.
+func (s *Service) RestoreReset(ctx context.Context, id int64) (*models.Reset, error) {
+	resets, err := s.queryResets(ctx, `
+		SELECT id, user_id, kind, backup, created_at, restored_at FROM resets WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(resets) == 0 || (resets[0].Kind == models.ResetHistory && resets[0].UserID != s.userID) {
+		return nil, notFound("reset not found")
+	}
+	reset := resets[0]
+	if reset.Kind == models.ResetFull {
+		if err := s.CheckAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if reset.RestoredAt != nil {
+		return nil, conflict("reset was already restored")
+	}
+	path := filepath.Join(backupDir(), reset.Backup)
+	if _, err := os.Stat(path); err != nil {
+		return nil, notFound("archive %s of reset not found", reset.Backup)
+	}
+
+	if reset.Kind == models.ResetFull {
+		err = s.restoreFullReset(ctx, reset.Backup)
+	} else {
+		err = s.restoreHistory(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, `UPDATE resets SET restored_at = ? WHERE id = ?`, now, id); err != nil {
+		return nil, fmt.Errorf("failed to log restore: %v", err)
+	}
+	reset.RestoredAt = &now
+	log.Printf("Restored %s reset %d from %s", reset.Kind, reset.ID, path)
+	return &reset, nil
+}
+
+// restoreFullReset restores the database from the backup, keeping the
+// resets log, which the backup predates
+func (s *Service) restoreFullReset(ctx context.Context, backup string) error {
+	resets, err := s.queryResets(ctx, `
+		SELECT id, user_id, kind, backup, created_at, restored_at FROM resets ORDER BY id
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := s.RestoreBackup(ctx, backup); err != nil {
+		return err
+	}
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM resets`); err != nil {
+			return fmt.Errorf("failed to restore resets: %v", err)
+		}
+		for _, reset := range resets {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO resets (id, user_id, kind, backup, created_at, restored_at) VALUES (?, ?, ?, ?, ?, ?)
+			`, reset.ID, reset.UserID, reset.Kind, reset.Backup, reset.CreatedAt, reset.RestoredAt)
+			if err != nil {
+				return fmt.Errorf("failed to restore resets: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.index.reset()
+	s.invalidateStats(ctx, allUsers)
+	return nil
+}
+
+// restoreHistory replaces the study history of the service's user with the
+// one in the backup at path. Only the columns both the backup and the
+// database have are copied, so backups of older schemas can be restored.
+func (s *Service) restoreHistory(ctx context.Context, path string) error {
+	archive, err := db.OpenBackup(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer archive.Close()
+
+	if _, err := s.Backup(ctx, "pre-restore"); err != nil {
+		return err
+	}
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.deleteHistory(ctx, tx); err != nil {
+			return err
+		}
+		// Parents first
+		for i := len(historyTables) - 1; i >= 0; i-- {
+			t := historyTables[i]
+			if err := s.copyHistoryTable(ctx, archive, tx, t.table, t.where); err != nil {
+				if strings.Contains(err.Error(), "constraint failed") {
+					return conflict("can't restore %s: %v", t.table, err)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyHistoryTable inserts the rows of the table the condition picks for the
+// service's user from the archive
+func (s *Service) copyHistoryTable(ctx context.Context, archive *sql.DB, tx *models.Tx, table, where string) error {
+	current, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	archived, err := tableColumns(ctx, models.NewDB(archive), table)
+	if err != nil {
+		return err
+	}
+	var columns, selects, params []string
+	for _, column := range current {
+		for _, c := range archived {
+			if c == column {
+				columns = append(columns, `"`+column+`"`)
+				// Values are selected as expressions, with no declared
+				// type, so the driver doesn't turn dates into times that
+				// would be written back in another format
+				selects = append(selects, `+"`+column+`"`)
+				params = append(params, "?")
+				break
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	rows, err := archive.QueryContext(ctx, `SELECT `+strings.Join(selects, ", ")+` FROM `+table+` WHERE `+where, s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to read archived %s: %v", table, err)
+	}
+	defer rows.Close()
+	insert := `INSERT INTO ` + table + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(params, ", ") + `)`
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan archived %s: %v", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, insert, values...); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", table, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating archived %s: %v", table, err)
+	}
+	return nil
+}
+
+// tableColumns returns the columns of a SQLite table, none if it doesn't
+// exist
+func tableColumns(ctx context.Context, q repository.Querier, table string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %v", table, err)
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %v", table, err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns of %s: %v", table, err)
+	}
+	return columns, nil
+}
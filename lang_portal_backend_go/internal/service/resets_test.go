@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"lang_portal/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestResetPermissions(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, testGroups, `
+		INSERT INTO resets (id, user_id, kind, backup, created_at) VALUES
+		(1, 1, 'full', 'backup-full.db', '2024-03-11 10:00:00'),
+		(2, 2, 'history', 'backup-history-2.db', '2024-03-11 09:00:00'),
+		(3, 3, 'history', 'backup-history-3.db', '2024-03-11 08:00:00');
+	`)
+	learner, admin := svc.ForUser(testOwner), svc.ForUser(testAdmin)
+
+	if _, err := learner.CreateResetConfirmation(ctx, models.ResetHistory); err != nil {
+		t.Errorf("CreateResetConfirmation(history) by a learner error = %v", err)
+	}
+	if _, err := learner.CreateResetConfirmation(ctx, models.ResetFull); !errors.Is(err, ErrForbidden) {
+		t.Errorf("CreateResetConfirmation(full) by a learner error = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := admin.CreateResetConfirmation(ctx, models.ResetFull); err != nil {
+		t.Errorf("CreateResetConfirmation(full) by an admin error = %v", err)
+	}
+	if _, err := learner.FullReset(ctx, "token"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("FullReset() by a learner error = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := learner.RestoreReset(ctx, 1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("RestoreReset() of a full reset by a learner error = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := learner.RestoreReset(ctx, 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreReset() of another user's history reset error = %v, want %v", err, ErrNotFound)
+	}
+
+	tests := []struct {
+		name string
+		svc  *Service
+		want []int64
+	}{
+		{name: "learner sees their history resets", svc: learner, want: []int64{2}},
+		{name: "admin sees their history and full resets", svc: admin, want: []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resets, err := tt.svc.ListResets(ctx)
+			if err != nil {
+				t.Fatalf("ListResets() error = %v", err)
+			}
+			var got []int64
+			for _, reset := range resets {
+				got = append(got, reset.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListResets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
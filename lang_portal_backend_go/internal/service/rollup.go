@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"log"
+	"time"
+)
+
+// statsRollupDelay is how long after midnight UTC the nightly rollup runs
+const statsRollupDelay = 5 * time.Minute
+
+// dayTotal is the review activity of a day
+type dayTotal struct {
+	reviews int
+	correct int
+	learned int
+}
+
+// StartStatsRollup queues a rollup of the review history now and then every
+// night until the service is closed
+func (s *Service) StartStatsRollup() {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		ctx := context.Background()
+		for {
+			if job, err := s.runner.Enqueue(ctx, 0, JobRollupStats, nil); err != nil {
+				log.Printf("Failed to queue stats rollup: %v", err)
+			} else {
+				log.Printf("Queued stats rollup as job %d", job.ID)
+			}
+
+			next := startOfDay(time.Now()).AddDate(0, 0, 1).Add(statsRollupDelay)
+			select {
+			case <-time.After(time.Until(next)):
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// statsRolledUpTo returns the first day not yet rolled up, or "" if nothing
+// has been
+func statsRolledUpTo(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *models.Row
+}) (string, error) {
+	var day string
+	err := q.QueryRowContext(ctx, `SELECT rolled_up_to FROM stats_rollup_state WHERE id = 1`).Scan(&day)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get stats rollup state: %v", err)
+	}
+	return day, nil
+}
+
+// RollupStats materializes each user's daily per-word, per-group and overall
+// review stats for the days before today (UTC) that haven't been rolled up yet, and
+// returns the first day left out. Days after the last rollup are re-rolled,
+// so it is safe to run more than once a day.
+func (s *Service) RollupStats(ctx context.Context, now time.Time) (string, error) {
+	today := startOfDay(now).Format("2006-01-02")
+
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		from, err := statsRolledUpTo(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		statements := []struct {
+			query  string
+			action string
+		}{
+			{`DELETE FROM daily_word_stats WHERE day >= ?1`, "clear daily word stats"},
+			{`DELETE FROM daily_group_stats WHERE day >= ?1`, "clear daily group stats"},
+			{`DELETE FROM daily_stats WHERE day >= ?1`, "clear daily stats"},
+			{`INSERT INTO daily_word_stats (user_id, day, word_id, reviews, correct)
+				SELECT user_id, date(created_at) AS day, word_id, COUNT(*),
+					   COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0)
+				FROM word_review_items
+				WHERE date(created_at) >= ?1 AND date(created_at) < ?2
+				GROUP BY user_id, day, word_id`, "roll up word stats"},
+			// A word is learned on the first day it was answered correctly
+			{`UPDATE daily_word_stats SET learned = 1
+				WHERE day >= ?1 AND correct > 0
+				AND NOT EXISTS (
+					SELECT 1 FROM daily_word_stats prev
+					WHERE prev.user_id = daily_word_stats.user_id
+					AND prev.word_id = daily_word_stats.word_id
+					AND prev.day < daily_word_stats.day
+					AND prev.correct > 0
+				)`, "mark learned words"},
+			{`INSERT INTO daily_group_stats (user_id, day, group_id, reviews, correct, words_reviewed, new_words_learned)
+				SELECT d.user_id, d.day, wg.group_id, SUM(d.reviews), SUM(d.correct), COUNT(*), SUM(d.learned)
+				FROM daily_word_stats d
+				JOIN words_groups wg ON wg.word_id = d.word_id
+				WHERE d.day >= ?1
+				GROUP BY d.user_id, d.day, wg.group_id`, "roll up group stats"},
+			{`INSERT INTO daily_stats (user_id, day, reviews, correct, words_reviewed, new_words_learned)
+				SELECT user_id, day, SUM(reviews), SUM(correct), COUNT(*), SUM(learned)
+				FROM daily_word_stats
+				WHERE day >= ?1
+				GROUP BY user_id, day`, "roll up daily stats"},
+			{`INSERT OR REPLACE INTO stats_rollup_state (id, rolled_up_to) VALUES (1, ?2)`, "save stats rollup state"},
+		}
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt.query, from, today); err != nil {
+				return fmt.Errorf("failed to %s: %v", stmt.action, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return today, nil
+}
+
+// dailyTotals returns the review activity of each day from from to to
+// (YYYY-MM-DD, inclusive) on which the user reviewed any of the words of a
+// group or, if groupID is 0, any word. Rolled up days are read from the rollups and
+// later days from the review history.
+func (s *Service) dailyTotals(ctx context.Context, from, to string, groupID int64) (map[string]*dayTotal, error) {
+	rolledUpTo, err := statsRolledUpTo(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]*dayTotal{}
+	total := func(day string) *dayTotal {
+		if totals[day] == nil {
+			totals[day] = &dayTotal{}
+		}
+		return totals[day]
+	}
+
+	rolled := `
+		SELECT day, reviews, correct, new_words_learned
+		FROM daily_stats
+		WHERE day >= ?1 AND day <= ?2 AND day < ?3 AND user_id = ?5
+	`
+	if groupID != 0 {
+		rolled = `
+			SELECT day, reviews, correct, new_words_learned
+			FROM daily_group_stats
+			WHERE day >= ?1 AND day <= ?2 AND day < ?3 AND group_id = ?4 AND user_id = ?5
+		`
+	}
+	rows, err := s.db.QueryContext(ctx, rolled, from, to, rolledUpTo, groupID, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			day string
+			t   dayTotal
+		)
+		if err := rows.Scan(&day, &t.reviews, &t.correct, &t.learned); err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %v", err)
+		}
+		totals[day] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily stats: %v", err)
+	}
+
+	// Days not rolled up yet
+	if from < rolledUpTo {
+		from = rolledUpTo
+	}
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT date(created_at) AS day, COUNT(*),
+			   COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0)
+		FROM word_review_items
+		WHERE user_id = ?4 AND date(created_at) >= ?1 AND date(created_at) <= ?2
+		AND (?3 = 0 OR word_id IN (SELECT word_id FROM words_groups WHERE group_id = ?3))
+		GROUP BY day
+	`, from, to, groupID, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review counts: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day string
+		var reviews, correct int
+		if err := rows.Scan(&day, &reviews, &correct); err != nil {
+			return nil, fmt.Errorf("failed to scan review count: %v", err)
+		}
+		total(day).reviews = reviews
+		total(day).correct = correct
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review counts: %v", err)
+	}
+
+	// Words first answered correctly since the last rollup, leaving out those
+	// the rollups already count as learned
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT date(l.learned_at) AS day, COUNT(*)
+		FROM (
+			SELECT word_id, MIN(created_at) AS learned_at
+			FROM word_review_items
+			WHERE user_id = ?5 AND correct AND date(created_at) >= ?4
+			GROUP BY word_id
+		) l
+		WHERE date(l.learned_at) >= ?1 AND date(l.learned_at) <= ?2
+		AND (?3 = 0 OR l.word_id IN (SELECT word_id FROM words_groups WHERE group_id = ?3))
+		AND NOT EXISTS (
+			SELECT 1 FROM daily_word_stats d
+			WHERE d.user_id = ?5 AND d.word_id = l.word_id AND d.learned
+		)
+		GROUP BY day
+	`, from, to, groupID, rolledUpTo, s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learned words: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day string
+		var learned int
+		if err := rows.Scan(&day, &learned); err != nil {
+			return nil, fmt.Errorf("failed to scan learned words: %v", err)
+		}
+		total(day).learned = learned
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating learned words: %v", err)
+	}
+	return totals, nil
+}
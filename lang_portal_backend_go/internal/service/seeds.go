@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/db/seeder"
+)
+
+// defaultSeedDir is the directory the seed packs are read from unless the
+// server's settings name another
+const defaultSeedDir = "db/seeds"
+
+// SeedOnStartEnv turns off applying the startup seed packs when the server
+// starts if set to "false"
+const SeedOnStartEnv = "LANG_PORTAL_SEED_ON_START"
+
+// startupSeedPacks are the seed packs applied when the server starts
+var startupSeedPacks = []string{"study_activities", "word_groups"}
+
+// ListSeedPacks lists the seed packs that can be applied
+func (s *Service) ListSeedPacks() ([]seeder.SeedPack, error) {
+	return seeder.ListSeedPacks(s.seedDir)
+}
+
+// ApplySeedPack applies a seed pack by name or, on a dry run, reports what
+// applying it would change without changing anything
+func (s *Service) ApplySeedPack(ctx context.Context, name string, dryRun bool) (*seeder.SeedResult, error) {
+	// The seeder still writes SQLite SQL
+	if s.dialect != dialect.SQLite {
+		return nil, unsupported("seeding is only supported on SQLite")
+	}
+	result, err := s.seeder.ApplySeedPack(ctx, s.seedDir, name, dryRun)
+	switch {
+	case errors.Is(err, seeder.ErrNotFound):
+		return nil, notFound("%v", err)
+	case errors.Is(err, seeder.ErrInvalidPack):
+		return nil, invalid("%v", err)
+	case err != nil:
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/corpus"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxImportSentences is the most sentences imported at once
+const MaxImportSentences = 1000
+
+// MaxSentenceLength is the longest a sentence can be, in characters
+const MaxSentenceLength = 1000
+
+// sentenceColumns are the columns scanned by scanSentences
+const sentenceColumns = `s.id, s.language, s.text, s.transliteration, s.english, s.source, s.created_at`
+
+// vocabularyIndex returns an index of the words of a language, as they are
+// written and as their verbs are conjugated
+func vocabularyIndex(ctx context.Context, q repository.Querier, language string) (*corpus.Index, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, script FROM words WHERE language = ?
+		UNION ALL
+		SELECT c.word_id, c.form FROM conjugations c JOIN words w ON w.id = c.word_id WHERE w.language = ?
+	`, language, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word forms: %v", err)
+	}
+	defer rows.Close()
+
+	index := &corpus.Index{}
+	for rows.Next() {
+		var (
+			wordID int64
+			form   string
+		)
+		if err := rows.Scan(&wordID, &form); err != nil {
+			return nil, fmt.Errorf("failed to scan word form: %v", err)
+		}
+		index.Add(form, wordID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word forms: %v", err)
+	}
+	return index, nil
+}
+
+// linkSentence stores where the words of index occur in a sentence and
+// returns how many occurrences it found
+func linkSentence(ctx context.Context, tx *models.Tx, index *corpus.Index, sentenceID int64, text string) (int, error) {
+	occurrences := index.Find(corpus.Tokenize(text))
+	for _, occurrence := range occurrences {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO sentence_words (sentence_id, word_id, position, length) VALUES (?, ?, ?, ?)
+			ON CONFLICT(sentence_id, word_id, position) DO NOTHING
+		`, sentenceID, occurrence.WordID, occurrence.Position, occurrence.Length); err != nil {
+			return 0, fmt.Errorf("failed to link sentence: %v", err)
+		}
+	}
+	return len(occurrences), nil
+}
+
+// ImportSentences adds sentences to the corpus in one transaction, linking
+// each to the words of its language it contains. Sentences are of the given
+// language, or else the service's. Sentences already in the corpus are
+// skipped.
+func (s *Service) ImportSentences(ctx context.Context, language string, sentences []models.Sentence) (*models.SentenceImport, error) {
+	if len(sentences) == 0 || len(sentences) > MaxImportSentences {
+		return nil, invalid("give between 1 and %d sentences", MaxImportSentences)
+	}
+	for i := range sentences {
+		sentence := &sentences[i]
+		sentence.Text = strings.TrimSpace(sentence.Text)
+		sentence.Transliteration = strings.TrimSpace(sentence.Transliteration)
+		sentence.English = strings.TrimSpace(sentence.English)
+		sentence.Source = strings.TrimSpace(sentence.Source)
+		switch {
+		case corpus.Key(sentence.Text) == "":
+			return nil, invalid("sentence %d has no words", i+1)
+		case utf8.RuneCountInString(sentence.Text) > MaxSentenceLength:
+			return nil, invalid("sentence %d is longer than %d characters", i+1, MaxSentenceLength)
+		}
+	}
+
+	result := &models.SentenceImport{Language: s.newWordLanguage(language)}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkLanguage(ctx, tx, result.Language); err != nil {
+			return err
+		}
+		index, err := vocabularyIndex(ctx, tx, result.Language)
+		if err != nil {
+			return err
+		}
+
+		result.Created, result.Existing, result.Occurrences = 0, 0, 0
+		for _, sentence := range sentences {
+			var id int64
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO sentences (language, text, transliteration, english, source, normalized)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(language, text) DO NOTHING
+				RETURNING id
+			`, result.Language, sentence.Text, sentence.Transliteration, sentence.English, sentence.Source,
+				" "+corpus.Key(sentence.Text)+" ").Scan(&id)
+			if err == sql.ErrNoRows {
+				result.Existing++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to add sentence: %v", err)
+			}
+			result.Created++
+
+			found, err := linkSentence(ctx, tx, index, id, sentence.Text)
+			if err != nil {
+				return err
+			}
+			result.Occurrences += found
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EnqueueSentenceLinking queues linking the sentences of a language, or
+// else of the service's, to its words again, such as after words were added
+func (s *Service) EnqueueSentenceLinking(ctx context.Context, language string) (*models.Job, error) {
+	language = s.newWordLanguage(language)
+	if err := s.checkLanguage(ctx, s.db, language); err != nil {
+		return nil, err
+	}
+	return s.runner.Enqueue(ctx, s.userID, JobLinkSentences, linkSentencesJob{Language: language})
+}
+
+// LinkSentences finds the words of a language in each of its sentences
+// again, replacing the occurrences found before
+func (s *Service) LinkSentences(ctx context.Context, language string) (*models.SentenceLinks, error) {
+	links := &models.SentenceLinks{Language: language}
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		index, err := vocabularyIndex(ctx, tx, language)
+		if err != nil {
+			return err
+		}
+
+		type sentence struct {
+			id   int64
+			text string
+		}
+		var sentences []sentence
+		rows, err := tx.QueryContext(ctx, `SELECT id, text FROM sentences WHERE language = ? ORDER BY id`, language)
+		if err != nil {
+			return fmt.Errorf("failed to get sentences: %v", err)
+		}
+		for rows.Next() {
+			var sentence sentence
+			if err := rows.Scan(&sentence.id, &sentence.text); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan sentence: %v", err)
+			}
+			sentences = append(sentences, sentence)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating sentences: %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM sentence_words WHERE sentence_id IN (SELECT id FROM sentences WHERE language = ?)
+		`, language); err != nil {
+			return fmt.Errorf("failed to unlink sentences: %v", err)
+		}
+		links.Sentences, links.Occurrences = len(sentences), 0
+		for _, sentence := range sentences {
+			found, err := linkSentence(ctx, tx, index, sentence.id, sentence.text)
+			if err != nil {
+				return err
+			}
+			links.Occurrences += found
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetSentence returns a sentence of the corpus
+func (s *Service) GetSentence(ctx context.Context, id int64) (*models.Sentence, error) {
+	sentences, err := s.scanSentences(ctx, `
+		SELECT `+sentenceColumns+` FROM sentences s WHERE s.id = ?
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sentences) == 0 {
+		return nil, notFound("sentence not found")
+	}
+	return &sentences[0], nil
+}
+
+// GetWordSentences returns up to limit sentences a word occurs in, shortest
+// first, as they are the easiest to read
+func (s *Service) GetWordSentences(ctx context.Context, wordID int64, limit int) ([]models.Sentence, error) {
+	limit, err := checkSearchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words WHERE id = ?)
+	`, wordID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up word: %v", err)
+	}
+	if !exists {
+		return nil, notFound("word not found")
+	}
+
+	return s.scanSentences(ctx, `
+		SELECT `+sentenceColumns+` FROM sentences s
+		WHERE s.id IN (SELECT sentence_id FROM sentence_words WHERE word_id = ?)
+		ORDER BY length(s.text), s.id
+		LIMIT ?
+	`, wordID, limit)
+}
+
+// SearchSentences returns up to limit sentences of the service's language
+// containing a phrase, compared as words are found in sentences, or whose
+// English contains the query. Sentences containing the phrase come first,
+// shortest first.
+func (s *Service) SearchSentences(ctx context.Context, query string, limit int) ([]models.Sentence, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, invalid("q is required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, invalid("q must be at most %d characters", maxSearchQueryLength)
+	}
+	limit, err := checkSearchLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are letters, digits and spaces, so need no escaping
+	phrase := ""
+	if key := corpus.Key(query); key != "" {
+		phrase = "% " + key + " %"
+	}
+	english := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(query)) + "%"
+	return s.scanSentences(ctx, `
+		SELECT `+sentenceColumns+` FROM sentences s
+		WHERE (? = '' OR s.language = ?)
+		  AND ((? != '' AND s.normalized LIKE ?) OR lower(s.english) LIKE ? ESCAPE '\')
+		ORDER BY CASE WHEN ? != '' AND s.normalized LIKE ? THEN 0 ELSE 1 END, length(s.text), s.id
+		LIMIT ?
+	`, s.language, s.language, phrase, phrase, english, phrase, phrase, limit)
+}
+
+// scanSentences returns the sentences a query selecting sentenceColumns
+// returns, with where words occur in them
+func (s *Service) scanSentences(ctx context.Context, query string, args ...interface{}) ([]models.Sentence, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sentences: %v", err)
+	}
+	sentences := []models.Sentence{}
+	for rows.Next() {
+		var sentence models.Sentence
+		if err := rows.Scan(&sentence.ID, &sentence.Language, &sentence.Text, &sentence.Transliteration,
+			&sentence.English, &sentence.Source, &sentence.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan sentence: %v", err)
+		}
+		sentences = append(sentences, sentence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sentences: %v", err)
+	}
+
+	for i := range sentences {
+		if sentences[i].Occurrences, err = sentenceOccurrences(ctx, s.db, sentences[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return sentences, nil
+}
+
+// sentenceOccurrences returns where words occur in a sentence, in order
+func sentenceOccurrences(ctx context.Context, q repository.Querier, sentenceID int64) ([]models.WordOccurrence, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT word_id, position, length
+		FROM sentence_words
+		WHERE sentence_id = ?
+		ORDER BY position, length DESC, word_id
+	`, sentenceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word occurrences: %v", err)
+	}
+	defer rows.Close()
+
+	occurrences := []models.WordOccurrence{}
+	for rows.Next() {
+		var occurrence models.WordOccurrence
+		if err := rows.Scan(&occurrence.WordID, &occurrence.Position, &occurrence.Length); err != nil {
+			return nil, fmt.Errorf("failed to scan word occurrence: %v", err)
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word occurrences: %v", err)
+	}
+	return occurrences, nil
+}
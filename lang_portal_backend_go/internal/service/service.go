@@ -0,0 +1,1710 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/db/migrations"
+	"lang_portal/db/migrations/postgres"
+	"lang_portal/internal/cache"
+	"lang_portal/internal/config"
+	"lang_portal/internal/db"
+	"lang_portal/internal/db/dialect"
+	"lang_portal/internal/db/migrator"
+	"lang_portal/internal/db/queries"
+	"lang_portal/internal/db/seeder"
+	"lang_portal/internal/events"
+	"lang_portal/internal/jobs"
+	"lang_portal/internal/models"
+	"lang_portal/internal/quality"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/srs"
+	"lang_portal/internal/storage"
+	"lang_portal/internal/tracing"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QueryTimeoutEnv overrides how long a query may run before it is
+// cancelled, e.g. "30s", or "0" for no limit
+const QueryTimeoutEnv = "LANG_PORTAL_QUERY_TIMEOUT"
+
+type Service struct {
+	db        *models.DB
+	queries   *queries.Queries
+	seeder    *seeder.Seeder
+	scheduler *srs.Scheduler
+	// stop is closed when the service is closed, stopping background jobs
+	stop chan struct{}
+	// jobs counts the background jobs running, which Close waits for
+	jobs *sync.WaitGroup
+	// runner runs the jobs queued for the background
+	runner *jobs.Runner
+	// events is where the service announces what happened
+	events *events.Bus
+	// userID is the user whose study history the service reads and records
+	userID int64
+	// language is the code of the language the service's lists and
+	// statistics cover, or "" for every language
+	language string
+	// dialect is the SQL dialect of the database
+	dialect dialect.Dialect
+	// seedDir is the directory the seed packs are read from
+	seedDir string
+	// index holds word embeddings in memory for searching
+	index *wordIndex
+	// embedQueue holds the words waiting to be embedded
+	embedQueue *embeddingQueue
+	// media keeps media such as synthesized audio
+	media storage.Storage
+	// cache keeps dashboard statistics, idempotency keys and rate limits
+	cache cache.Cache
+	// serverErrors keeps the latest server errors for the admin dashboard
+	serverErrors *serverErrors
+
+	words    repository.WordRepository
+	groups   repository.GroupRepository
+	sessions repository.SessionRepository
+	reviews  repository.ReviewRepository
+}
+
+// NewService creates a new service with the given database path
+func NewService(dbPath string) (*Service, error) {
+	svc, err := openSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return newService(svc)
+}
+
+// NewServiceFromURL creates a new service for a database URL, the path of a
+// SQLite file
+func NewServiceFromURL(url string) (*Service, error) {
+	if err := checkDatabaseURL(url); err != nil {
+		return nil, err
+	}
+	return NewService(url)
+}
+
+// checkDatabaseURL refuses postgres:// URLs. The PostgreSQL migrations and
+// repositories are in place, but most of the service still issues SQLite
+// SQL, so it can't run against PostgreSQL yet.
+func checkDatabaseURL(url string) error {
+	if dialect.FromURL(url) == dialect.Postgres {
+		return fmt.Errorf("PostgreSQL databases aren't supported yet: set the database URL to the path of a SQLite file")
+	}
+	return nil
+}
+
+// NewServiceFromConfig creates a new service for the database and seed
+// packs of the server's settings
+func NewServiceFromConfig(cfg *config.Config) (*Service, error) {
+	if err := checkDatabaseURL(cfg.DatabaseURL); err != nil {
+		return nil, err
+	}
+	svc, err := openSQLite(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	svc.seedDir = cfg.SeedsDir
+	svc.runner.Workers = cfg.JobWorkers
+	if svc.media, err = storage.New(cfg.Storage); err != nil {
+		svc.db.Close()
+		return nil, fmt.Errorf("failed to open media storage: %v", err)
+	}
+	if svc.cache, err = cache.New(cfg.Cache); err != nil {
+		svc.db.Close()
+		return nil, fmt.Errorf("failed to open cache: %v", err)
+	}
+	return newService(svc)
+}
+
+// openSQLite creates a service for the SQLite file at path
+func openSQLite(path string) (*Service, error) {
+	conn, err := db.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	return NewServiceWithDB(conn), nil
+}
+
+// newService configures a new service from the environment and brings its
+// database up to date
+func newService(svc *Service) (*Service, error) {
+	// Relearning steps can be overridden, e.g. "10m,1d"
+	if value := os.Getenv(RelearningStepsEnv); value != "" {
+		steps, err := srs.ParseSteps(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", RelearningStepsEnv, err)
+		}
+		svc.scheduler.RelearningSteps = steps
+	}
+
+	if value := os.Getenv(QueryTimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("failed to parse %s: %q is not a duration", QueryTimeoutEnv, value)
+		}
+		svc.db.QueryTimeout = timeout
+	}
+
+	// Apply the migrations the database doesn't have yet
+	if err := svc.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	// Seed data from JSON files
+	if err := svc.seedData(); err != nil {
+		return nil, fmt.Errorf("failed to seed data: %v", err)
+	}
+
+	return svc, nil
+}
+
+// NewServiceWithDB creates a new service with an existing database connection
+func NewServiceWithDB(db *sql.DB) *Service {
+	modelDB := models.NewDB(db)
+	return newServiceWithRepositories(modelDB, repository.NewSQLite(modelDB), dialect.SQLite)
+}
+
+// NewServiceWithRepositories creates a new service that reads and writes
+// words, groups, study sessions and reviews through the given repositories,
+// e.g. mocks in tests. Transactions are still begun on db.
+func NewServiceWithRepositories(db *sql.DB, repos repository.Repositories) *Service {
+	return newServiceWithRepositories(models.NewDB(db), repos, dialect.SQLite)
+}
+
+func newServiceWithRepositories(db *models.DB, repos repository.Repositories, d dialect.Dialect) *Service {
+	svc := &Service{
+		db:           db,
+		queries:      queries.New(db.SQL()),
+		seeder:       seeder.NewSeeder(db),
+		scheduler:    srs.NewScheduler(),
+		stop:         make(chan struct{}),
+		jobs:         &sync.WaitGroup{},
+		runner:       jobs.NewRunner(db, d),
+		events:       events.NewBus(),
+		userID:       DefaultUserID,
+		dialect:      d,
+		seedDir:      defaultSeedDir,
+		index:        &wordIndex{},
+		embedQueue:   &embeddingQueue{},
+		media:        defaultMedia(),
+		cache:        cache.NewMemory(),
+		serverErrors: &serverErrors{},
+		words:        repos.Words,
+		groups:       repos.Groups,
+		sessions:     repos.Sessions,
+		reviews:      repos.Reviews,
+	}
+	svc.registerJobs()
+	svc.subscribeEmbeddings()
+	svc.subscribeDashboardCache()
+	return svc
+}
+
+// Close stops the background jobs, waiting for any that are running to
+// finish, and closes the database
+func (s *Service) Close() error {
+	close(s.stop)
+	s.jobs.Wait()
+	s.runner.Stop()
+	if err := s.cache.Close(); err != nil {
+		log.Printf("Failed to close cache: %v", err)
+	}
+	return s.db.Close()
+}
+
+// Ping checks that the database can be reached
+func (s *Service) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to reach database: %v", err)
+	}
+	return nil
+}
+
+// Dashboard date range presets
+const (
+	RangeLast7Days  = "7d"
+	RangeLast30Days = "30d"
+	RangeLast90Days = "90d"
+	RangeAll        = "all"
+)
+
+// presetDays is the length of each bounded preset
+var presetDays = map[string]int{
+	RangeLast7Days:  7,
+	RangeLast30Days: 30,
+	RangeLast90Days: 90,
+}
+
+// PresetRange returns the range a preset covers as of now
+func PresetRange(preset string, now time.Time) (models.DateRange, error) {
+	if preset == RangeAll {
+		return models.DateRange{Preset: preset}, nil
+	}
+	days, ok := presetDays[preset]
+	if !ok {
+		return models.DateRange{}, invalid("invalid range")
+	}
+	from := now.UTC().AddDate(0, 0, -days)
+	return models.DateRange{Preset: preset, From: &from}, nil
+}
+
+// CustomRange returns the range from the start of one day to the end of
+// another, both YYYY-MM-DD and either left empty for no bound
+func CustomRange(from, to string) (models.DateRange, error) {
+	var r models.DateRange
+	if from != "" {
+		start, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return r, invalid("invalid range")
+		}
+		r.From = &start
+	}
+	if to != "" {
+		end, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return r, invalid("invalid range")
+		}
+		end = end.AddDate(0, 0, 1)
+		r.To = &end
+	}
+	if r.From != nil && r.To != nil && !r.From.Before(*r.To) {
+		return r, invalid("invalid range")
+	}
+	return r, nil
+}
+
+// rangeBounds returns the bounds of a range for comparison with created_at
+// columns, as from <= created_at < to
+func rangeBounds(r models.DateRange) (string, string) {
+	from, to := "", "9999-12-31"
+	if r.From != nil {
+		from = r.From.UTC().Format("2006-01-02 15:04:05")
+	}
+	if r.To != nil {
+		to = r.To.UTC().Format("2006-01-02 15:04:05")
+	}
+	return from, to
+}
+
+// Dashboard methods
+func (s *Service) GetLastStudySession(ctx context.Context) (*models.StudySessionResponse, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetLastStudySession", tracing.KindInternal)
+	defer span.End()
+	var session models.StudySessionResponse
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ss.id, sa.name as activity_name, g.name as group_name,
+			   ss.created_at as start_time,
+			   datetime(ss.created_at, '+10 minutes') as end_time,
+			   COUNT(wri.word_id) as review_items_count
+		FROM study_sessions ss
+		JOIN study_activities sa ON ss.study_activity_id = sa.id
+		JOIN groups g ON ss.group_id = g.id
+		LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+		WHERE ss.user_id = ?
+		GROUP BY ss.id
+		ORDER BY ss.created_at DESC
+		LIMIT 1
+	`, s.userID).Scan(&session.ID, &session.ActivityName, &session.GroupName,
+		&session.StartTime, &session.EndTime, &session.ReviewItemsCount)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetStudyProgress counts the words reviewed within a date range, of the
+// service's language if it has one
+func (s *Service) GetStudyProgress(ctx context.Context, r models.DateRange) (*models.StudyProgress, error) {
+	var progress *models.StudyProgress
+	err := s.cachedStats(ctx, "study_progress:"+rangeKey(r), &progress, func() (err error) {
+		progress, err = s.getStudyProgress(ctx, r)
+		return err
+	})
+	return progress, err
+}
+
+func (s *Service) getStudyProgress(ctx context.Context, r models.DateRange) (*models.StudyProgress, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetStudyProgress", tracing.KindInternal)
+	defer span.End()
+	progress := models.StudyProgress{Range: r}
+	from, to := rangeBounds(r)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT word_id), (SELECT COUNT(*) FROM words WHERE ?4 = '' OR language = ?4)
+		FROM word_review_items
+		WHERE user_id = ?1 AND created_at >= ?2 AND created_at < ?3
+		AND (?4 = '' OR word_id IN (SELECT id FROM words WHERE language = ?4))
+	`, s.userID, from, to, s.language).Scan(&progress.TotalWordsStudied, &progress.TotalAvailableWords)
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// GetQuickStats summarises the study sessions within a date range, of the
+// groups of the service's language if it has one. Totals of words and
+// sessions, the streak and the learning stages are not limited to the range,
+// and the streak counts days of study in any language.
+func (s *Service) GetQuickStats(ctx context.Context, r models.DateRange) (*models.DashboardStats, error) {
+	var stats *models.DashboardStats
+	err := s.cachedStats(ctx, "quick_stats:"+rangeKey(r), &stats, func() (err error) {
+		stats, err = s.getQuickStats(ctx, r)
+		return err
+	})
+	return stats, err
+}
+
+func (s *Service) getQuickStats(ctx context.Context, r models.DateRange) (*models.DashboardStats, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetQuickStats", tracing.KindInternal)
+	defer span.End()
+	stats := models.DashboardStats{Range: r}
+	from, to := rangeBounds(r)
+
+	// Get total words studied with correct and wrong counts
+	err := s.db.QueryRowContext(ctx, `
+		SELECT 
+			COALESCE(COUNT(*), 0), 
+			COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN NOT correct THEN 1 ELSE 0 END), 0)
+		FROM word_review_items
+		WHERE study_session_id IN (
+			SELECT id FROM study_sessions WHERE user_id = ? AND created_at >= ? AND created_at < ?
+			AND `+sessionInLanguage+`
+		)
+	`, s.userID, from, to, s.language, s.language).Scan(&stats.TotalWordsStudied, &stats.CorrectCount, &stats.WrongCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get words that were part of a session but never answered
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM study_session_words ssw
+		LEFT JOIN word_review_items wri
+			ON wri.study_session_id = ssw.study_session_id AND wri.word_id = ssw.word_id
+		WHERE wri.word_id IS NULL
+		AND ssw.study_session_id IN (
+			SELECT id FROM study_sessions WHERE user_id = ? AND created_at >= ? AND created_at < ?
+			AND `+sessionInLanguage+`
+		)
+	`, s.userID, from, to, s.language, s.language).Scan(&stats.UnansweredCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate correct percentage over answered words only
+	if stats.TotalWordsStudied > 0 {
+		stats.CorrectPercentage = int((float64(stats.CorrectCount) / float64(stats.TotalWordsStudied)) * 100)
+	}
+
+	// Get total available words
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalAvailableWords, err = s.words.Count(ctx, viewerID, s.language)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get total study sessions
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM study_sessions WHERE user_id = ? AND `+sessionInLanguage+`
+	`, s.userID, s.language, s.language).Scan(&stats.TotalStudySessions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get total active groups
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT group_id) 
+		FROM study_sessions 
+		WHERE user_id = ? AND created_at >= ? AND created_at < ? AND `+sessionInLanguage+`
+	`, s.userID, from, to, s.language, s.language).Scan(&stats.TotalActiveGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate study streak
+	stats.StudyStreakDays, err = s.getStudyStreakDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Summarise spaced repetition progress
+	srsStats, err := s.getSRSStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	stats.SRS = *srsStats
+
+	return &stats, nil
+}
+
+// GetActivityHeatmap counts the reviews made on each day of a year. Days
+// without reviews are left out.
+func (s *Service) GetActivityHeatmap(ctx context.Context, year int) (*models.ActivityHeatmap, error) {
+	var heatmap *models.ActivityHeatmap
+	err := s.cachedStats(ctx, fmt.Sprintf("heatmap:%d", year), &heatmap, func() (err error) {
+		heatmap, err = s.getActivityHeatmap(ctx, year)
+		return err
+	})
+	return heatmap, err
+}
+
+func (s *Service) getActivityHeatmap(ctx context.Context, year int) (*models.ActivityHeatmap, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetActivityHeatmap", tracing.KindInternal)
+	defer span.End()
+	totals, err := s.dailyTotals(ctx, fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-12-31", year), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := models.ActivityHeatmap{Year: year, Days: []models.HeatmapDay{}}
+	for date, total := range totals {
+		if total.reviews == 0 {
+			continue
+		}
+		heatmap.TotalReviews += total.reviews
+		if total.reviews > heatmap.MaxCount {
+			heatmap.MaxCount = total.reviews
+		}
+		heatmap.Days = append(heatmap.Days, models.HeatmapDay{
+			Date:         date,
+			Count:        total.reviews,
+			CorrectCount: total.correct,
+		})
+	}
+	sort.Slice(heatmap.Days, func(i, j int) bool {
+		return heatmap.Days[i].Date < heatmap.Days[j].Date
+	})
+	return &heatmap, nil
+}
+
+// Trend granularities
+const (
+	TrendDay  = "day"
+	TrendWeek = "week"
+)
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// startOfWeek returns midnight UTC of the Monday of the week of t
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// GetTrends returns review counts, accuracy and newly learned words for the
+// last periods days or weeks up to now, oldest first, for the words of a
+// group or, if groupID is 0, for every word. Weeks start on Monday. Every
+// bucket is listed, including those without reviews. A word counts as
+// learned in the bucket of its first correct review.
+func (s *Service) GetTrends(ctx context.Context, granularity string, periods int, groupID int64, now time.Time) (*models.Trends, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetTrends", tracing.KindInternal)
+	defer span.End()
+	start := startOfDay(now).AddDate(0, 0, -(periods - 1))
+	step := 1
+	switch granularity {
+	case TrendDay:
+	case TrendWeek:
+		start = startOfWeek(now).AddDate(0, 0, -7*(periods-1))
+		step = 7
+	default:
+		return nil, invalid("invalid granularity")
+	}
+	if groupID != 0 {
+		if _, err := s.GetGroup(ctx, groupID); err != nil {
+			return nil, notFound("group not found")
+		}
+	}
+
+	totals, err := s.dailyTotals(ctx, start.Format("2006-01-02"), startOfDay(now).Format("2006-01-02"), groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := models.Trends{Granularity: granularity, Points: make([]models.TrendPoint, periods)}
+	for i := range trends.Points {
+		point := &trends.Points[i]
+		bucket := start.AddDate(0, 0, i*step)
+		point.Date = bucket.Format("2006-01-02")
+		for d := 0; d < step; d++ {
+			if total, ok := totals[bucket.AddDate(0, 0, d).Format("2006-01-02")]; ok {
+				point.Reviews += total.reviews
+				point.CorrectCount += total.correct
+				point.NewWordsLearned += total.learned
+			}
+		}
+		if point.Reviews > 0 {
+			point.Accuracy = float64(point.CorrectCount) / float64(point.Reviews)
+		}
+	}
+	return &trends, nil
+}
+
+// getStudyStreakDays counts consecutive days with at least one study session,
+// ending at the most recent session
+func (s *Service) getStudyStreakDays(ctx context.Context) (int, error) {
+	var streak int
+	err := s.db.QueryRowContext(ctx, `
+		WITH RECURSIVE dates(date) AS (
+			SELECT date(max(created_at)) FROM study_sessions WHERE user_id = ?1
+			UNION ALL
+			SELECT date(date, '-1 day')
+			FROM dates
+			WHERE EXISTS (
+				SELECT 1 FROM study_sessions 
+				WHERE user_id = ?1 AND date(created_at) = date(date, '-1 day')
+			)
+		)
+		SELECT COUNT(*) FROM dates
+	`, s.userID).Scan(&streak)
+	return streak, err
+}
+
+// masteredCorrectReviews is the number of correct reviews after which a word
+// counts as mastered on the session card
+const masteredCorrectReviews = 3
+
+// GetStudySessionCard gathers the summary shown on a shareable session card
+func (s *Service) GetStudySessionCard(ctx context.Context, id int64) (*models.SessionCard, error) {
+	session, err := s.GetStudySession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.SessionCard{
+		SessionID:    session.ID,
+		GroupName:    session.GroupName,
+		ActivityName: session.ActivityName,
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT 
+			(SELECT COUNT(*) FROM study_session_words WHERE study_session_id = ?),
+			COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0)
+		FROM word_review_items
+		WHERE study_session_id = ?
+	`, id, id).Scan(&card.TotalWords, &card.CorrectCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session score: %v", err)
+	}
+	if card.TotalWords > 0 {
+		card.Accuracy = float64(card.CorrectCount) / float64(card.TotalWords)
+	}
+
+	card.StudyStreakDays, err = s.getStudyStreakDays(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study streak: %v", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT word_id
+			FROM word_review_items
+			WHERE user_id = ? AND correct
+			GROUP BY word_id
+			HAVING COUNT(*) >= ?
+		)
+	`, s.userID, masteredCorrectReviews).Scan(&card.WordsMastered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count mastered words: %v", err)
+	}
+
+	// Show a few of the Urdu words answered correctly in this session
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.script
+		FROM word_review_items wri
+		JOIN words w ON w.id = wri.word_id
+		WHERE wri.study_session_id = ? AND wri.correct
+		ORDER BY wri.created_at
+		LIMIT 3
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session highlights: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urdu string
+		if err := rows.Scan(&urdu); err != nil {
+			return nil, fmt.Errorf("failed to scan session highlight: %v", err)
+		}
+		card.Highlights = append(card.Highlights, urdu)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// Study activities methods
+func (s *Service) GetStudyActivity(ctx context.Context, id int64) (*models.StudyActivityResponse, error) {
+	activity, err := s.db.GetStudyActivity(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, notFound("study activity %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StudyActivityResponse{
+		ID:           activity.ID,
+		Name:         activity.Name,
+		ThumbnailURL: activity.ThumbnailURL,
+		Description:  activity.Description,
+		CreatedAt:    activity.CreatedAt,
+	}, nil
+}
+
+func (s *Service) GetStudyActivitySessions(ctx context.Context, id int64, page int) (*models.PaginatedResponse, error) {
+	offset := (page - 1) * 100
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ss.id, g.name, sa.name,
+			   ss.created_at,
+			   strftime('%Y-%m-%dT%H:%M:%SZ', datetime(ss.created_at, '+10 minutes')),
+			   COUNT(wri.word_id)
+		FROM study_sessions ss
+		LEFT JOIN study_activities sa ON ss.study_activity_id = sa.id
+		LEFT JOIN groups g ON ss.group_id = g.id
+		LEFT JOIN word_review_items wri ON ss.id = wri.study_session_id
+		WHERE ss.study_activity_id = ? AND ss.user_id = ?
+		GROUP BY ss.id
+		ORDER BY ss.created_at DESC
+		LIMIT 100 OFFSET ?
+	`, id, s.userID, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.StudySessionResponse
+	for rows.Next() {
+		var session models.StudySessionResponse
+		var (
+			activityName sql.NullString
+			groupName    sql.NullString
+			startTime    sql.NullTime
+			endTimeStr   sql.NullString
+			reviewCount  sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&session.ID,
+			&groupName,
+			&activityName,
+			&startTime,
+			&endTimeStr,
+			&reviewCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if activityName.Valid {
+			session.ActivityName = activityName.String
+		}
+		if groupName.Valid {
+			session.GroupName = groupName.String
+		}
+		if startTime.Valid {
+			session.StartTime = startTime.Time.Format(time.RFC3339)
+		}
+		if endTimeStr.Valid {
+			session.EndTime = endTimeStr.String
+		}
+		if reviewCount.Valid {
+			session.ReviewItemsCount = int(reviewCount.Int64)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT ss.id)
+		FROM study_sessions ss
+		WHERE ss.study_activity_id = ? AND ss.user_id = ?
+	`, id, s.userID).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: sessions,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+func (s *Service) CreateStudySessionWithActivity(ctx context.Context, groupID int64, activityName string) (*models.StudySessionResponse, error) {
+	// First check if the group exists
+	_, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the activity ID
+	var activityID int64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT id FROM study_activities WHERE name = ?
+	`, activityName).Scan(&activityID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("activity not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %v", err)
+	}
+
+	return s.CreateStudySession(ctx, groupID, activityID)
+}
+
+// startActivitySession creates a study session of the named activity within
+// tx and returns its ID. The difficulty is left unset if empty.
+func (s *Service) startActivitySession(ctx context.Context, tx *models.Tx, activityName string, groupID int64, difficulty string) (int64, error) {
+	if err := s.checkGroupViewer(ctx, tx, groupID); err != nil {
+		return 0, err
+	}
+	var activityID int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM study_activities WHERE name = ?
+	`, activityName).Scan(&activityID)
+	if err != nil {
+		return 0, fmt.Errorf("activity not found: %v", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO study_sessions (group_id, study_activity_id, created_at, difficulty, user_id)
+		VALUES (?, ?, ?, ?, ?)
+	`, groupID, activityID, time.Now(), sql.NullString{String: difficulty, Valid: difficulty != ""}, s.userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create study session: %v", err)
+	}
+
+	sessionID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session id: %v", err)
+	}
+	return sessionID, nil
+}
+
+func (s *Service) CreateStudySession(ctx context.Context, groupID int64, studyActivityID int64) (*models.StudySessionResponse, error) {
+	// First check if group exists
+	_, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if group has words
+	wordIDs, err := s.groupWordIDs(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wordIDs) == 0 {
+		return nil, invalid("group has no words")
+	}
+
+	// Then check if study activity exists
+	_, err = s.GetStudyActivity(ctx, studyActivityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		// Create study session
+		now := time.Now()
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO study_sessions (group_id, study_activity_id, created_at, user_id)
+			VALUES (?, ?, ?, ?)
+		`, groupID, studyActivityID, now, s.userID)
+		if err != nil {
+			return fmt.Errorf("failed to create study session: %v", err)
+		}
+
+		sessionID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get session id: %v", err)
+		}
+
+		// Record the words studied in this session. Review items are only
+		// written once an answer is submitted, so unanswered words don't
+		// count as wrong.
+		return s.sessions.SetWords(ctx, tx, sessionID, wordIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the created session
+	return s.GetStudySession(ctx, sessionID)
+}
+
+// groupWordIDs returns the IDs of the words of a group
+func (s *Service) groupWordIDs(ctx context.Context, groupID int64) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT word_id FROM words_groups WHERE group_id = ? ORDER BY word_id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group words: %v", err)
+	}
+	defer rows.Close()
+
+	var wordIDs []int64
+	for rows.Next() {
+		var wordID int64
+		if err := rows.Scan(&wordID); err != nil {
+			return nil, fmt.Errorf("failed to scan group word: %v", err)
+		}
+		wordIDs = append(wordIDs, wordID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group words: %v", err)
+	}
+	return wordIDs, nil
+}
+
+func (s *Service) GetStudyActivities(ctx context.Context, page int) (*models.PaginatedResponse, error) {
+	itemsPerPage := 100
+	offset := (page - 1) * itemsPerPage
+
+	activities, err := s.db.GetStudyActivities(ctx, itemsPerPage, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.db.CountStudyActivities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: activities,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + itemsPerPage - 1) / itemsPerPage,
+			TotalItems:   total,
+			ItemsPerPage: itemsPerPage,
+		},
+	}, nil
+}
+
+func (s *Service) CreateStudyActivity(ctx context.Context, groupID int64, activityID int64) (*models.StudyActivityResponse, error) {
+	var activity models.StudyActivityResponse
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO study_activities (group_id, activity_id, created_at)
+			VALUES (?, ?, CURRENT_TIMESTAMP)
+			RETURNING id, group_id, activity_id, created_at
+		`, groupID, activityID).Scan(&activity.ID, &activity.Name, &activity.Description, &activity.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// Words methods
+
+// ListWords returns a page of words sorted by ID, or by script in Urdu
+// alphabetical order
+func (s *Service) ListWords(ctx context.Context, page int, sort string) (*models.PaginatedResponse, error) {
+	if page < 1 {
+		return nil, invalid("invalid page number: %d", page)
+	}
+	sort, err := s.listSort(ctx, wordSortKeys, sort)
+	if err != nil {
+		return nil, err
+	}
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * 100
+	words, err := s.words.List(ctx, s.userID, viewerID, s.language, sort, 100, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get total count for pagination
+	total, err := s.words.Count(ctx, viewerID, s.language)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: words,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+// cursorPageSize is how many items a page of a list read by cursor holds
+const cursorPageSize = 100
+
+// ListWordsByCursor returns the page of words after the one a cursor from
+// an earlier page points at, or the first page for an empty cursor. Unlike
+// page numbers, cursors don't make the database skip the earlier pages.
+// Cursors follow ID order only.
+func (s *Service) ListWordsByCursor(ctx context.Context, cursor, sort string) (*models.PaginatedResponse, error) {
+	if sort, err := checkSort(sort); err != nil {
+		return nil, err
+	} else if sort != SortID {
+		return nil, invalid("cursors list words by %s only: use page numbers to sort by %s", SortID, sort)
+	}
+	afterID, ok := models.DecodeCursor(cursor)
+	if !ok {
+		return nil, invalid("invalid cursor")
+	}
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// One more than a page tells whether another page follows
+	words, err := s.words.ListAfter(ctx, s.userID, viewerID, s.language, afterID, cursorPageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.words.Count(ctx, viewerID, s.language)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := cursorPagination(total)
+	if len(words) > cursorPageSize {
+		words = words[:cursorPageSize]
+		pagination.NextCursor = models.EncodeCursor(words[len(words)-1].ID)
+	}
+	if words == nil {
+		words = []models.WordResponse{}
+	}
+	return &models.PaginatedResponse{Items: words, Pagination: pagination}, nil
+}
+
+// cursorPagination describes a page of a list of total items read by cursor
+func cursorPagination(total int) models.Pagination {
+	return models.Pagination{
+		TotalPages:   (total + cursorPageSize - 1) / cursorPageSize,
+		TotalItems:   total,
+		ItemsPerPage: cursorPageSize,
+	}
+}
+
+func (s *Service) GetWord(ctx context.Context, id int64) (*models.WordResponse, error) {
+	word, err := s.getWord(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	word.AudioURL, err = s.GetWordAudioURL(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	image, err := s.GetWordImage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if image != nil {
+		word.ImageURL, word.ThumbnailURL = image.ImageURL, image.ThumbnailURL
+	}
+	word.Enrichment, err = s.GetWordEnrichment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	word.Senses, err = wordSenses(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return word, nil
+}
+
+// getWord returns a word the user can see, or sql.ErrNoRows
+func (s *Service) getWord(ctx context.Context, id int64) (*models.WordResponse, error) {
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.words.Get(ctx, s.userID, viewerID, id)
+}
+
+// CreateWord adds a word and returns any non-fatal data quality warnings
+// about it. Words without a language are created in the service's language,
+// or else in the default language. A word given senses takes its english
+// from the first.
+func (s *Service) CreateWord(ctx context.Context, word *models.Word) ([]quality.Warning, error) {
+	word.Language = s.newWordLanguage(word.Language)
+	if word.Senses != nil {
+		senses, err := cleanWordSenses(word.Senses)
+		if err != nil {
+			return nil, err
+		}
+		word.Senses, word.English = senses, senses[0].English
+	}
+	var warnings []quality.Warning
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkLanguage(ctx, tx, word.Language); err != nil {
+			return err
+		}
+		var err error
+		warnings, err = quality.NewDefaultEngine(quality.SQLDuplicateLookup(ctx, tx)).Check(word)
+		if err != nil {
+			return err
+		}
+		if err := s.words.Create(ctx, tx, word); err != nil {
+			return err
+		}
+		if word.Senses != nil {
+			if err := replaceWordSenses(ctx, tx, word.ID, word.Senses); err != nil {
+				return err
+			}
+		}
+		if word.Senses, err = wordSenses(ctx, tx, word.ID); err != nil {
+			return err
+		}
+		s.publishAfterCommit(ctx, tx, events.WordCreated{
+			UserID:  s.userID,
+			WordID:  word.ID,
+			Urdu:    word.Urdu,
+			English: word.English,
+			At:      time.Now().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// Groups methods
+
+// ListGroups returns a page of groups sorted by ID, or by name in Urdu
+// alphabetical order
+func (s *Service) ListGroups(ctx context.Context, page int, sort string) (*models.PaginatedResponse, error) {
+	sort, err := s.listSort(ctx, groupSortKeys, sort)
+	if err != nil {
+		return nil, err
+	}
+	viewerID, err := s.viewerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * 100
+	groups, err := s.groups.List(ctx, viewerID, s.language, sort, 100, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.groups.Count(ctx, viewerID, s.language)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: groups,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+func (s *Service) GetGroup(ctx context.Context, id int64) (*models.GroupResponse, error) {
+	if err := s.checkGroupViewer(ctx, s.db, id); err != nil {
+		return nil, err
+	}
+	group, err := s.groups.Get(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %v", err)
+	}
+	return group, nil
+}
+
+// GetGroupWords returns a page of the words of a group sorted by ID, or by
+// script in Urdu alphabetical order
+func (s *Service) GetGroupWords(ctx context.Context, id int64, page int, sort string) (*models.PaginatedResponse, error) {
+	sort, err := s.listSort(ctx, wordSortKeys, sort)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGroupViewer(ctx, s.db, id); err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * 100
+	words, err := s.groups.ListWords(ctx, s.userID, id, sort, 100, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.groups.CountWords(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: words,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+func (s *Service) GetGroupStudySessions(ctx context.Context, id int64, page int) (*models.PaginatedResponse, error) {
+	offset := (page - 1) * 100
+	sessions, err := s.sessions.List(ctx, s.userID, id, 100, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.sessions.Count(ctx, s.userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: sessions,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+func (s *Service) ListStudySessions(ctx context.Context, page int) (*models.PaginatedResponse, error) {
+	offset := (page - 1) * 100
+
+	// First, get total count
+	total, err := s.sessions.Count(ctx, s.userID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// If no records exist, return empty response with pagination
+	if total == 0 {
+		return &models.PaginatedResponse{
+			Items: []interface{}{},
+			Pagination: models.Pagination{
+				CurrentPage:  page,
+				TotalPages:   0,
+				TotalItems:   0,
+				ItemsPerPage: 100,
+			},
+		}, nil
+	}
+
+	sessions, err := s.sessions.List(ctx, s.userID, 0, 100, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Items: sessions,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   (total + 99) / 100,
+			TotalItems:   total,
+			ItemsPerPage: 100,
+		},
+	}, nil
+}
+
+// ListStudySessionsByCursor returns the page of the user's sessions after
+// the one a cursor from an earlier page points at, newest first, or the
+// first page for an empty cursor. Only sessions of the group are listed
+// unless groupID is 0.
+func (s *Service) ListStudySessionsByCursor(ctx context.Context, groupID int64, cursor string) (*models.PaginatedResponse, error) {
+	beforeID, ok := models.DecodeCursor(cursor)
+	if !ok {
+		return nil, invalid("invalid cursor")
+	}
+	sessions, err := s.sessions.ListBefore(ctx, s.userID, groupID, beforeID, cursorPageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.sessions.Count(ctx, s.userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := cursorPagination(total)
+	if len(sessions) > cursorPageSize {
+		sessions = sessions[:cursorPageSize]
+		pagination.NextCursor = models.EncodeCursor(sessions[len(sessions)-1].ID)
+	}
+	if sessions == nil {
+		sessions = []models.StudySessionResponse{}
+	}
+	return &models.PaginatedResponse{Items: sessions, Pagination: pagination}, nil
+}
+
+func (s *Service) GetStudySession(ctx context.Context, id int64) (*models.StudySessionResponse, error) {
+	session, err := s.sessions.Get(ctx, s.userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("study session not found")
+		}
+		return nil, fmt.Errorf("error getting study session: %v", err)
+	}
+	return session, nil
+}
+
+func (s *Service) GetStudySessionWords(ctx context.Context, id int64, page int, includeWords bool) (*models.PaginatedResponse, error) {
+	if err := s.checkSessionOwner(ctx, s.db, id); err != nil {
+		return nil, err
+	}
+
+	if includeWords {
+		words, err := s.sessions.ListWords(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get study session words: %v", err)
+		}
+		return &models.PaginatedResponse{
+			Items: words,
+			Pagination: models.Pagination{
+				CurrentPage:  page,
+				TotalPages:   1,
+				TotalItems:   len(words),
+				ItemsPerPage: len(words),
+			},
+		}, nil
+	}
+
+	items, err := s.reviews.ListBySession(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study session words: %v", err)
+	}
+	return &models.PaginatedResponse{
+		Items: items,
+		Pagination: models.Pagination{
+			CurrentPage:  page,
+			TotalPages:   1,
+			TotalItems:   len(items),
+			ItemsPerPage: len(items),
+		},
+	}, nil
+}
+
+func (s *Service) ReviewWord(ctx context.Context, sessionID int64, wordID int64, correct bool) (*models.WordReviewItem, error) {
+	var reviewItem *models.WordReviewItem
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkSessionOwner(ctx, tx, sessionID); err != nil {
+			return err
+		}
+		if err := checkSessionWord(ctx, tx, sessionID, wordID); err != nil {
+			return err
+		}
+
+		var err error
+		reviewItem, err = s.reviewWord(ctx, tx, sessionID, wordID, correct, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewItem, nil
+}
+
+// checkSessionWord returns "word not found in study session" unless the word
+// is one of the session's words or, for sessions without words of their
+// own, one of its group's
+func checkSessionWord(ctx context.Context, q repository.Querier, sessionID, wordID int64) error {
+	var found bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM study_session_words WHERE study_session_id = ?1 AND word_id = ?2)
+			OR (
+				NOT EXISTS (SELECT 1 FROM study_session_words WHERE study_session_id = ?1)
+				AND EXISTS (
+					SELECT 1 FROM study_sessions ss
+					JOIN words_groups wg ON wg.group_id = ss.group_id
+					WHERE ss.id = ?1 AND wg.word_id = ?2
+				)
+			)
+	`, sessionID, wordID).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to get study session word: %v", err)
+	}
+	if !found {
+		return notFound("word not found in study session")
+	}
+	return nil
+}
+
+// reviewWord records a review within tx. A near miss is a correct answer
+// with a small typo; it is scheduled as a hard recall.
+func (s *Service) reviewWord(ctx context.Context, tx *models.Tx, sessionID int64, wordID int64, correct bool, nearMiss bool) (*models.WordReviewItem, error) {
+	grade := srs.GradeFromCorrect(correct)
+	if correct && nearMiss {
+		grade = srs.GradeHard
+	}
+	return s.recordReviewItem(ctx, tx, sessionID, wordID, correct, nearMiss, grade)
+}
+
+// recordReviewItem adds a review item for a word in a study session within
+// tx and reschedules the word with the given grade. Once tx is committed the
+// review is published, and the session's completion if the word was the
+// last of its words to be reviewed. Reviewing a word again in the session,
+// as retries and drills do, only replaces the item's answer: the word is
+// scheduled and published once per session.
+func (s *Service) recordReviewItem(ctx context.Context, tx *models.Tx, sessionID int64, wordID int64, correct bool, nearMiss bool, grade srs.Grade) (*models.WordReviewItem, error) {
+	completes, err := completesSession(ctx, tx, sessionID, wordID)
+	if err != nil {
+		return nil, err
+	}
+	var reviewed bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM word_review_items WHERE study_session_id = ? AND word_id = ?)
+	`, sessionID, wordID).Scan(&reviewed); err != nil {
+		return nil, fmt.Errorf("failed to get review item: %v", err)
+	}
+
+	// Insert the review item
+	item := models.WordReviewItem{
+		WordID:         wordID,
+		StudySessionID: sessionID,
+		Correct:        correct,
+		NearMiss:       nearMiss,
+	}
+	if err := s.reviews.Record(ctx, tx, s.userID, item); err != nil {
+		return nil, err
+	}
+	item.CreatedAt = time.Now()
+	if reviewed {
+		return &item, nil
+	}
+
+	// Reschedule the word for spaced repetition
+	if _, err := s.recordLearningReview(ctx, tx, wordID, grade, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	s.publishAfterCommit(ctx, tx, events.WordReviewed{
+		UserID:    s.userID,
+		SessionID: sessionID,
+		WordID:    wordID,
+		Correct:   correct,
+		At:        item.CreatedAt.UTC(),
+	})
+	if completes {
+		event, err := s.sessionCompleted(ctx, tx, sessionID, item.CreatedAt.UTC())
+		if err != nil {
+			return nil, err
+		}
+		s.publishAfterCommit(ctx, tx, event)
+	}
+	return &item, nil
+}
+
+// AddWordsToGroup adds words to a group. Only words of the group's language
+// can be added.
+func (s *Service) AddWordsToGroup(ctx context.Context, groupID int64, wordIDs []int64) error {
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkGroupEditor(ctx, tx, groupID); err != nil {
+			return err
+		}
+		if err := checkGroupLanguage(ctx, tx, groupID, wordIDs); err != nil {
+			return err
+		}
+		return s.groups.AddWords(ctx, tx, groupID, wordIDs)
+	})
+}
+
+func (s *Service) AddWordsToStudySession(ctx context.Context, sessionID int64, wordIDs []int64) error {
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkSessionOwner(ctx, tx, sessionID); err != nil {
+			return err
+		}
+		return s.sessions.SetWords(ctx, tx, sessionID, wordIDs)
+	})
+}
+
+// System methods
+
+// historyTables are the tables holding a user's study history, children
+// first, with the condition picking the user's rows by ?1
+var historyTables = []struct {
+	table string
+	where string
+}{
+	{"word_review_items", `user_id = ?1`},
+	{"study_session_words", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"quiz_answers", `quiz_question_id IN (
+		SELECT qq.id FROM quiz_questions qq
+		JOIN study_sessions ss ON ss.id = qq.study_session_id
+		WHERE ss.user_id = ?1
+	)`},
+	{"quiz_questions", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"flashcards", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"listening_answers", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"word_game_rounds", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"pronunciation_attempts", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"conjugation_drill_rounds", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"cloze_questions", `study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?1)`},
+	{"bot_questions", `user_id = ?1`},
+	{"grammar_answers", `user_id = ?1`},
+	{"grammar_progress", `user_id = ?1`},
+	{"daily_word_stats", `user_id = ?1`},
+	{"daily_group_stats", `user_id = ?1`},
+	{"daily_stats", `user_id = ?1`},
+	{"word_learning_state", `user_id = ?1`},
+	{"study_sessions", `user_id = ?1`},
+}
+
+// deleteHistory deletes the study history of the service's user. Other
+// users' history and the launched study activities they share are kept.
+func (s *Service) deleteHistory(ctx context.Context, tx *models.Tx) error {
+	for _, t := range historyTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+t.table+` WHERE `+t.where, s.userID); err != nil {
+			return fmt.Errorf("failed to reset history: %v", err)
+		}
+	}
+	tx.AfterCommit(func() { s.invalidateStats(ctx, s.userID) })
+	return nil
+}
+
+// ResetHistory archives the database and then deletes the study history of
+// the service's user. The token must be one CreateResetConfirmation gave
+// the user for a history reset.
+func (s *Service) ResetHistory(ctx context.Context, token string) (*models.Reset, error) {
+	if err := s.confirmReset(ctx, models.ResetHistory, token); err != nil {
+		return nil, err
+	}
+	archive, err := s.Backup(ctx, "pre-reset-history")
+	if err != nil {
+		return nil, err
+	}
+
+	var reset *models.Reset
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.deleteHistory(ctx, tx); err != nil {
+			return err
+		}
+		reset, err = s.logReset(ctx, tx, models.ResetHistory, archive.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reset, nil
+}
+
+// fullResetTables are the tables a full reset empties, children first.
+// The users, their classes and the resets log are kept.
+var fullResetTables = []string{
+	"word_review_items",
+	"study_session_words",
+	"quiz_answers",
+	"quiz_questions",
+	"flashcards",
+	"listening_answers",
+	"word_game_rounds",
+	"pronunciation_attempts",
+	"conjugation_drill_rounds",
+	"cloze_questions",
+	"bot_questions",
+	"grammar_answers",
+	"grammar_progress",
+	"daily_word_stats",
+	"daily_group_stats",
+	"daily_stats",
+	"stats_rollup_state",
+	"listening_questions",
+	"listening_clips",
+	"word_learning_state",
+	"study_sessions",
+	"study_activities",
+	"goals",
+	"class_assignments",
+	"group_syncs",
+	"group_drafts",
+	"group_permissions",
+	"words_groups",
+	"word_audio",
+	"word_images",
+	"word_renderings",
+	"word_sort_keys",
+	"group_sort_keys",
+	"word_definitions",
+	"word_enrichments",
+	"word_embeddings",
+	"conjugations",
+	"sentence_words",
+	"sentences",
+	"passages",
+	"grammar_exercises",
+	"grammar_topics",
+	"words",
+	"groups",
+	"seed_versions",
+}
+
+// FullReset archives the database and then deletes the catalog and every
+// user's study history. The token must be one CreateResetConfirmation gave
+// the user for a full reset.
+func (s *Service) FullReset(ctx context.Context, token string) (*models.Reset, error) {
+	if err := s.CheckAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.confirmReset(ctx, models.ResetFull, token); err != nil {
+		return nil, err
+	}
+	archive, err := s.Backup(ctx, "pre-reset")
+	if err != nil {
+		return nil, err
+	}
+
+	var reset *models.Reset
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for _, table := range fullResetTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return fmt.Errorf("failed to reset %s: %v", table, err)
+			}
+		}
+		reset, err = s.logReset(ctx, tx, models.ResetFull, archive.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.index.reset()
+	s.invalidateStats(ctx, allUsers)
+	return reset, nil
+}
+
+// migrate applies the pending schema migrations
+func (s *Service) migrate() error {
+	fsys := migrations.FS
+	if s.dialect == dialect.Postgres {
+		fsys = postgres.FS
+	}
+	m, err := migrator.NewDialectMigrator(s.db.DB, s.dialect, fsys)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up()
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	log.Printf("Database schema is at version %d", version)
+	return nil
+}
+
+func (s *Service) seedData() error {
+	// Deployments without local seed files start empty and can be filled
+	// from the remote catalog with POST /api/system/bootstrap
+	if _, err := os.Stat(s.seedDir); os.IsNotExist(err) {
+		log.Printf("No seed directory found, skipping seeding")
+		return nil
+	}
+	if os.Getenv(SeedOnStartEnv) == "false" {
+		log.Printf("%s is false, skipping seeding", SeedOnStartEnv)
+		return nil
+	}
+	// The seeder still writes SQLite SQL
+	if s.dialect != dialect.SQLite {
+		log.Printf("Seeding is only supported on SQLite, skipping seeding")
+		return nil
+	}
+
+	for _, name := range startupSeedPacks {
+		result, err := s.ApplySeedPack(context.Background(), name, false)
+		if err != nil {
+			return fmt.Errorf("failed to apply seed pack %s: %v", name, err)
+		}
+		log.Printf("Applied seed pack %s: %d activities saved, %d groups and %d words created, %d words skipped",
+			name, result.ActivitiesSaved, result.GroupsCreated, result.WordsCreated, result.WordsSkipped)
+	}
+	return nil
+}
+
+// GetWordAudioURL returns the audio recording linked to a word, or an empty
+// string when it has none
+func (s *Service) GetWordAudioURL(ctx context.Context, wordID int64) (string, error) {
+	var url string
+	err := s.db.QueryRowContext(ctx, `SELECT url FROM word_audio WHERE word_id = ?`, wordID).Scan(&url)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get word audio: %v", err)
+	}
+	return url, nil
+}
+
+// GetQuestionPool returns the words a question about wordID draws its
+// options from: the given group, or the first group containing the word
+func (s *Service) GetQuestionPool(ctx context.Context, wordID int64, groupID int64) (*models.WordResponse, []models.WordResponse, error) {
+	word, err := s.GetWord(ctx, wordID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if groupID == 0 {
+		err = s.db.QueryRowContext(ctx, `
+			SELECT group_id FROM words_groups WHERE word_id = ? ORDER BY group_id LIMIT 1
+		`, wordID).Scan(&groupID)
+		if err == sql.ErrNoRows {
+			return word, []models.WordResponse{*word}, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get word group: %v", err)
+		}
+	}
+
+	groupWords, err := s.GetGroupWords(ctx, groupID, 1, SortID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get group words: %v", err)
+	}
+	pool, _ := groupWords.Items.([]models.WordResponse)
+
+	for _, w := range pool {
+		if w.ID == wordID {
+			return word, pool, nil
+		}
+	}
+	return word, append(pool, *word), nil
+}
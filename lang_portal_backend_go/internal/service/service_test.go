@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"testing"
+)
+
+// newTestService creates a service over an in-memory database, set up by
+// the given statements after the test data models.NewTestDB inserts. The
+// service serves the default user, an admin; ForUser serves others.
+func newTestService(t *testing.T, setup ...string) *Service {
+	t.Helper()
+	db, err := models.NewTestDB()
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	for _, query := range setup {
+		if _, err := db.Exec(query); err != nil {
+			db.Close()
+			t.Fatalf("failed to set up database: %v", err)
+		}
+	}
+	svc := NewServiceWithDB(db.DB)
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+// testGroups sets up roles on group 2 for users 2 to 5, with group 1 and 3
+// owned by nobody. Word 4 is only in group 2, word 5 in no group and words
+// 1 and 2 in groups 1 and 2.
+const testGroups = `
+	INSERT INTO users (id, username) VALUES
+	(2, 'owner'), (3, 'editor'), (4, 'viewer'), (5, 'stranger');
+	INSERT INTO group_permissions (group_id, user_id, role) VALUES
+	(2, 2, 'owner'), (2, 3, 'editor'), (2, 4, 'viewer');
+	INSERT INTO words (id, script, transliteration, english) VALUES
+	(4, 'پانی', 'paani', 'water'),
+	(5, 'کتاب', 'kitaab', 'book');
+	INSERT INTO words_groups (word_id, group_id) VALUES (4, 2);
+`
+
+// Users of testGroups
+const (
+	testAdmin    = DefaultUserID
+	testOwner    = int64(2)
+	testEditor   = int64(3)
+	testViewer   = int64(4)
+	testStranger = int64(5)
+)
+
+func TestReviewWordAgain(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, `
+		INSERT INTO study_sessions (id, group_id, study_activity_id, user_id, created_at)
+		VALUES (10, 1, 1, 1, CURRENT_TIMESTAMP);
+	`)
+	var published int
+	svc.events.Subscribe(events.NameWordReviewed, func(ctx context.Context, event events.Event) {
+		published++
+	})
+
+	if _, err := svc.ReviewWord(ctx, 10, 1, true); err != nil {
+		t.Fatalf("ReviewWord() error = %v", err)
+	}
+	first, err := svc.GetWordLearningState(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetWordLearningState() error = %v", err)
+	}
+	item, err := svc.ReviewWord(ctx, 10, 1, false)
+	if err != nil {
+		t.Fatalf("ReviewWord() again error = %v", err)
+	}
+	if item.Correct {
+		t.Error("second review didn't replace the answer")
+	}
+	again, err := svc.GetWordLearningState(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetWordLearningState() error = %v", err)
+	}
+
+	if again.Repetitions != first.Repetitions || again.Lapses != first.Lapses || !again.DueAt.Equal(first.DueAt) {
+		t.Errorf("learning state after reviewing again = %+v, want it unchanged from %+v", again, first)
+	}
+	if published != 1 {
+		t.Errorf("published %d WordReviewed events, want 1", published)
+	}
+}
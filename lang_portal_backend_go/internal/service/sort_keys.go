@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"lang_portal/internal/collation"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+)
+
+// Orders word and group lists can be sorted in: by ID, the default, or by
+// script or name in Urdu alphabetical order
+const (
+	SortID   = repository.SortID
+	SortUrdu = repository.SortUrdu
+)
+
+// sortKeyTable is a table of the sort keys of the rows of another, made
+// from one of their text columns
+type sortKeyTable struct {
+	table, id, source, text string
+}
+
+var (
+	wordSortKeys  = sortKeyTable{table: "word_sort_keys", id: "word_id", source: "words", text: "script"}
+	groupSortKeys = sortKeyTable{table: "group_sort_keys", id: "group_id", source: "groups", text: "name"}
+)
+
+// checkSort returns the order a list is to be sorted in, by ID if none is
+// given
+func checkSort(sort string) (string, error) {
+	switch sort {
+	case "", SortID:
+		return SortID, nil
+	case SortUrdu:
+		return SortUrdu, nil
+	default:
+		return "", invalid("invalid sort %q: use %s or %s", sort, SortID, SortUrdu)
+	}
+}
+
+// listSort checks the order a list of the rows of keys' source is to be
+// sorted in, and makes the keys sorting in Urdu order takes
+func (s *Service) listSort(ctx context.Context, keys sortKeyTable, sort string) (string, error) {
+	sort, err := checkSort(sort)
+	if err != nil {
+		return "", err
+	}
+	if sort == SortUrdu {
+		if err := s.refreshSortKeys(ctx, keys); err != nil {
+			return "", err
+		}
+	}
+	return sort, nil
+}
+
+// refreshSortKeys makes the keys of the rows that have none, or whose text
+// has changed since theirs was made. Words and groups are written in many
+// places, so their keys are made when they are next sorted rather than
+// each time they are.
+func (s *Service) refreshSortKeys(ctx context.Context, keys sortKeyTable) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.`+keys.text+`
+		FROM `+keys.source+` t
+		LEFT JOIN `+keys.table+` k ON k.`+keys.id+` = t.id
+		WHERE k.`+keys.id+` IS NULL OR k.`+keys.text+` <> t.`+keys.text+`
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to get %s without sort keys: %v", keys.source, err)
+	}
+	defer rows.Close()
+	texts := map[int64]string{}
+	for rows.Next() {
+		var (
+			id   int64
+			text string
+		)
+		if err := rows.Scan(&id, &text); err != nil {
+			return fmt.Errorf("failed to scan %s: %v", keys.source, err)
+		}
+		texts[id] = text
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s: %v", keys.source, err)
+	}
+	rows.Close()
+	if len(texts) == 0 {
+		return nil
+	}
+
+	return s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for id, text := range texts {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO `+keys.table+` (`+keys.id+`, `+keys.text+`, sort_key) VALUES (?, ?, ?)
+				ON CONFLICT (`+keys.id+`) DO UPDATE SET
+					`+keys.text+` = excluded.`+keys.text+`,
+					sort_key = excluded.sort_key
+			`, id, text, hex.EncodeToString(collation.UrduKey(text))); err != nil {
+				return fmt.Errorf("failed to set sort key: %v", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/srs"
+	"time"
+)
+
+// RelearningStepsEnv overrides the relearning steps of forgotten words
+const RelearningStepsEnv = "LANG_PORTAL_RELEARNING_STEPS"
+
+// GetWordLearningState returns the spaced repetition state of a word. Words
+// that have never been reviewed get a fresh state that is due immediately.
+func (s *Service) GetWordLearningState(ctx context.Context, wordID int64) (*models.WordLearningState, error) {
+	if _, err := s.GetWord(ctx, wordID); err != nil {
+		return nil, err
+	}
+
+	state, err := getLearningState(ctx, s.db, s.userID, wordID)
+	if err == sql.ErrNoRows {
+		return srs.NewState(wordID, time.Now().UTC()), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learning state: %v", err)
+	}
+	return state, nil
+}
+
+// queryRower is satisfied by both *models.DB and *models.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *models.Row
+}
+
+func getLearningState(ctx context.Context, q queryRower, userID, wordID int64) (*models.WordLearningState, error) {
+	var (
+		state          models.WordLearningState
+		lastReviewedAt sql.NullTime
+	)
+	err := q.QueryRowContext(ctx, `
+		SELECT word_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at,
+			   relearning_step, lapsed_interval_days
+		FROM word_learning_state
+		WHERE user_id = ? AND word_id = ?
+	`, userID, wordID).Scan(&state.WordID, &state.EaseFactor, &state.IntervalDays,
+		&state.Repetitions, &state.Lapses, &state.DueAt, &lastReviewedAt,
+		&state.RelearningStep, &state.LapsedIntervalDays)
+	if err != nil {
+		return nil, err
+	}
+	if lastReviewedAt.Valid {
+		state.LastReviewedAt = &lastReviewedAt.Time
+	}
+	return &state, nil
+}
+
+// recordLearningReview reschedules a word after it has been reviewed
+func (s *Service) recordLearningReview(ctx context.Context, tx *models.Tx, wordID int64, grade srs.Grade, now time.Time) (*models.WordLearningState, error) {
+	current, err := getLearningState(ctx, tx, s.userID, wordID)
+	if err == sql.ErrNoRows {
+		current = srs.NewState(wordID, now)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get learning state: %v", err)
+	}
+
+	next := s.scheduler.Schedule(*current, grade, now)
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO word_learning_state
+			(user_id, word_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at,
+			 relearning_step, lapsed_interval_days)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, word_id) DO UPDATE SET
+			ease_factor = excluded.ease_factor,
+			interval_days = excluded.interval_days,
+			repetitions = excluded.repetitions,
+			lapses = excluded.lapses,
+			due_at = excluded.due_at,
+			last_reviewed_at = excluded.last_reviewed_at,
+			relearning_step = excluded.relearning_step,
+			lapsed_interval_days = excluded.lapsed_interval_days
+	`, s.userID, next.WordID, next.EaseFactor, next.IntervalDays, next.Repetitions,
+		next.Lapses, next.DueAt, next.LastReviewedAt,
+		next.RelearningStep, next.LapsedIntervalDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update learning state: %v", err)
+	}
+
+	return &next, nil
+}
+
+// Review queue statuses
+const (
+	ReviewStatusNew    = "new"
+	ReviewStatusReview = "review"
+	ReviewStatusLapsed = "lapsed"
+)
+
+// DailyReviewActivity is the study activity that consumes the review queue
+const DailyReviewActivity = "Daily Review"
+
+// newWordsEvery controls how new words are mixed into the queue: one new
+// word after every newWordsEvery due words
+const newWordsEvery = 3
+
+// GetReviewQueue returns up to limit words that are due for review by the
+// end of today, most overdue first, with never-studied words mixed in. Only
+// words of the service's language are queued if it has one.
+func (s *Service) GetReviewQueue(ctx context.Context, limit int) ([]models.ReviewQueueItem, error) {
+	if limit < 1 {
+		return nil, invalid("invalid limit: %d", limit)
+	}
+
+	now := time.Now().UTC()
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english,
+			   wls.due_at, wls.relearning_step
+		FROM word_learning_state wls
+		JOIN words w ON w.id = wls.word_id
+		WHERE wls.user_id = ? AND wls.due_at <= ? AND (? = '' OR w.language = ?)
+		ORDER BY wls.due_at ASC
+		LIMIT ?
+	`, s.userID, endOfDay, s.language, s.language, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due words: %v", err)
+	}
+	defer rows.Close()
+
+	var due []models.ReviewQueueItem
+	for rows.Next() {
+		var (
+			item           models.ReviewQueueItem
+			dueAt          time.Time
+			relearningStep int
+		)
+		if err := rows.Scan(&item.Word.ID, &item.Word.Urdu, &item.Word.Urdlish, &item.Word.English,
+			&dueAt, &relearningStep); err != nil {
+			return nil, fmt.Errorf("failed to scan due word: %v", err)
+		}
+		item.Status = ReviewStatusReview
+		if relearningStep > 0 {
+			item.Status = ReviewStatusLapsed
+		}
+		item.DueAt = &dueAt
+		if overdue := now.Sub(dueAt); overdue > 0 {
+			item.OverdueDays = int(overdue.Hours() / 24)
+		}
+		due = append(due, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Leave room for new words, but let them fill the queue when few words are due
+	newLimit := limit / (newWordsEvery + 1)
+	if newLimit == 0 {
+		newLimit = 1
+	}
+	if len(due) > limit-newLimit {
+		due = due[:limit-newLimit]
+	}
+	newLimit = limit - len(due)
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration, w.english
+		FROM words w
+		LEFT JOIN word_learning_state wls ON wls.word_id = w.id AND wls.user_id = ?
+		WHERE wls.word_id IS NULL AND (? = '' OR w.language = ?)
+		ORDER BY w.id
+		LIMIT ?
+	`, s.userID, s.language, s.language, newLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get new words: %v", err)
+	}
+	defer rows.Close()
+
+	var fresh []models.ReviewQueueItem
+	for rows.Next() {
+		var item models.ReviewQueueItem
+		if err := rows.Scan(&item.Word.ID, &item.Word.Urdu, &item.Word.Urdlish, &item.Word.English); err != nil {
+			return nil, fmt.Errorf("failed to scan new word: %v", err)
+		}
+		item.Status = ReviewStatusNew
+		fresh = append(fresh, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Interleave so the learner isn't faced with all new words at the end
+	queue := make([]models.ReviewQueueItem, 0, len(due)+len(fresh))
+	for len(due) > 0 || len(fresh) > 0 {
+		for i := 0; i < newWordsEvery && len(due) > 0; i++ {
+			queue = append(queue, due[0])
+			due = due[1:]
+		}
+		if len(fresh) > 0 {
+			queue = append(queue, fresh[0])
+			fresh = fresh[1:]
+		}
+	}
+
+	return queue, nil
+}
+
+// CreateDailyReviewSession starts a Daily Review study session containing the
+// current review queue. The session is filed under the group that holds most
+// of the queued words.
+func (s *Service) CreateDailyReviewSession(ctx context.Context, limit int) (*models.StudySessionResponse, error) {
+	queue, err := s.GetReviewQueue(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(queue) == 0 {
+		return nil, notFound("no words are due for review")
+	}
+
+	wordIDs := make([]int64, len(queue))
+	args := make([]interface{}, len(queue))
+	placeholders := ""
+	for i, item := range queue {
+		wordIDs[i] = item.Word.ID
+		args[i] = item.Word.ID
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += "?"
+	}
+
+	var groupID int64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT group_id
+		FROM words_groups
+		WHERE word_id IN (`+placeholders+`)
+		GROUP BY group_id
+		ORDER BY COUNT(*) DESC, group_id
+		LIMIT 1
+	`, args...).Scan(&groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("review words do not belong to any group")
+		}
+		return nil, fmt.Errorf("failed to get review group: %v", err)
+	}
+
+	var activityID int64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT id FROM study_activities WHERE name = ?
+	`, DailyReviewActivity).Scan(&activityID)
+	if err != nil {
+		return nil, fmt.Errorf("activity not found: %v", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO study_sessions (group_id, study_activity_id, created_at, user_id)
+		VALUES (?, ?, ?, ?)
+	`, groupID, activityID, time.Now(), s.userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create study session: %v", err)
+	}
+
+	sessionID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session id: %v", err)
+	}
+
+	if err := s.AddWordsToStudySession(ctx, sessionID, wordIDs); err != nil {
+		return nil, err
+	}
+
+	return s.GetStudySession(ctx, sessionID)
+}
+
+// getSRSStats counts words by learning stage and measures retention: the
+// share of reviews between from and to that recalled a word seen before.
+// Only words of the service's language are counted if it has one.
+func (s *Service) getSRSStats(ctx context.Context, from, to string) (*models.SRSStats, error) {
+	var (
+		stats       models.SRSStats
+		avgInterval sql.NullFloat64
+	)
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM words w
+			 WHERE (?3 = '' OR w.language = ?3)
+			 AND NOT EXISTS (SELECT 1 FROM word_learning_state wls WHERE wls.word_id = w.id AND wls.user_id = ?1)),
+			COALESCE(SUM(CASE WHEN repetitions = 0 OR relearning_step > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN repetitions > 0 AND relearning_step = 0 AND interval_days < ?2 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN repetitions > 0 AND relearning_step = 0 AND interval_days >= ?2 THEN 1 ELSE 0 END), 0),
+			AVG(CASE WHEN repetitions > 0 AND relearning_step = 0 THEN interval_days END)
+		FROM word_learning_state
+		WHERE user_id = ?1 AND (?3 = '' OR word_id IN (SELECT id FROM words WHERE language = ?3))
+	`, s.userID, srs.MatureIntervalDays, s.language).Scan(&stats.NewWords, &stats.LearningWords,
+		&stats.YoungWords, &stats.MatureWords, &avgInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count words by learning stage: %v", err)
+	}
+	if avgInterval.Valid {
+		stats.AverageIntervalDays = avgInterval.Float64
+	}
+
+	var recalled int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN wri.correct THEN 1 ELSE 0 END), 0)
+		FROM word_review_items wri
+		WHERE wri.user_id = ? AND wri.created_at >= ? AND wri.created_at < ?
+		AND (? = '' OR wri.word_id IN (SELECT id FROM words WHERE language = ?))
+		AND EXISTS (
+			SELECT 1 FROM word_review_items prev
+			WHERE prev.user_id = wri.user_id
+			AND prev.word_id = wri.word_id
+			AND prev.study_session_id != wri.study_session_id
+			AND prev.created_at < wri.created_at
+		)
+	`, s.userID, from, to, s.language, s.language).Scan(&stats.RetentionReviews, &recalled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate retention: %v", err)
+	}
+	if stats.RetentionReviews > 0 {
+		stats.RetentionRate = float64(recalled) / float64(stats.RetentionReviews)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"lang_portal/internal/models"
+	"testing"
+)
+
+// Size of the database BenchmarkGetReviewQueue generates
+const (
+	benchWords   = 100000
+	benchLearned = 50000
+)
+
+func BenchmarkGetReviewQueue(b *testing.B) {
+	ctx := context.Background()
+	db, err := models.NewTestDB()
+	if err != nil {
+		b.Fatalf("failed to create database: %v", err)
+	}
+	// The default user has learned every other word, due from 30 days ago
+	// to 30 days ahead
+	for _, query := range []string{
+		`WITH RECURSIVE n(i) AS (SELECT 4 UNION ALL SELECT i + 1 FROM n WHERE i < ?1)
+		INSERT INTO words (id, script, transliteration, english) SELECT i, 'script ' || i, 'translit ' || i, 'english ' || i FROM n`,
+		`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?2)
+		INSERT INTO word_learning_state (user_id, word_id, interval_days, repetitions, due_at, last_reviewed_at)
+		SELECT 1, 2 * i, 1 + i % 30, 1 + i % 5,
+			datetime('now', (abs(random()) % 86400 - 43200) || ' minutes'), datetime('now', '-1 day')
+		FROM n`,
+		`ANALYZE`,
+	} {
+		if _, err := db.Exec(query, benchWords, benchLearned); err != nil {
+			db.Close()
+			b.Fatalf("failed to generate data: %v", err)
+		}
+	}
+	svc := NewServiceWithDB(db.DB)
+	b.Cleanup(func() { svc.Close() })
+
+	for _, bm := range []struct {
+		name  string
+		limit int
+	}{{"20 words", 20}, {"100 words", 100}} {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.GetReviewQueue(ctx, bm.limit); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
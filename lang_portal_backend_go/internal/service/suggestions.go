@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/quality"
+	"lang_portal/internal/spelling"
+	"lang_portal/internal/translate"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSuggestTermLength is the longest term fields can be suggested for
+const maxSuggestTermLength = 100
+
+// translateProvider returns the translation provider configured by the
+// environment
+func translateProvider() (translate.Provider, error) {
+	provider, err := translate.FromEnv()
+	if err != nil {
+		return nil, unsupported("invalid translation settings: %v", err)
+	}
+	if provider == nil {
+		return nil, unsupported("no translation provider is configured, set %s", translate.ProviderEnv)
+	}
+	return provider, nil
+}
+
+// SuggestWord proposes the fields of a word from its english or its urdu,
+// exactly one of which is given. Suggestions are cached per provider and
+// term, and list the words the suggested word may duplicate.
+func (s *Service) SuggestWord(ctx context.Context, english, urdu string) (*models.WordSuggestion, error) {
+	english = strings.TrimSpace(english)
+	urdu = strings.TrimSpace(urdu)
+	if (english == "") == (urdu == "") {
+		return nil, invalid("give either english or urdu")
+	}
+	language, text := translate.English, english
+	if urdu != "" {
+		language, text = translate.Urdu, urdu
+	}
+	if utf8.RuneCountInString(text) > maxSuggestTermLength {
+		return nil, invalid("term must be at most %d characters", maxSuggestTermLength)
+	}
+
+	provider, err := translateProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	suggested, cached, err := s.suggest(ctx, provider, language, text)
+	if err != nil {
+		return nil, err
+	}
+	suggestion := &models.WordSuggestion{
+		Urdu:     suggested.Urdu,
+		Urdlish:  suggested.Urdlish,
+		English:  suggested.English,
+		Provider: provider.Name(),
+		Cached:   cached,
+	}
+
+	// The cache is shared by spellings of the term, so the term is kept as
+	// given
+	if language == translate.Urdu {
+		suggestion.Urdu = text
+	} else {
+		suggestion.English = text
+	}
+
+	duplicates, err := quality.SQLDuplicateLookup(ctx, s.db)(&models.Word{Urdu: suggestion.Urdu, English: suggestion.English, Language: DefaultLanguage})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up duplicates: %v", err)
+	}
+	suggestion.Duplicates = append([]int64{}, duplicates...)
+	return suggestion, nil
+}
+
+// suggest proposes the fields of the word whose language field is text,
+// from the cache if provider suggested them before, and reports whether
+// they were cached
+func (s *Service) suggest(ctx context.Context, provider translate.Provider, language, text string) (*translate.Suggestion, bool, error) {
+	term := spelling.Normalize(text)
+	var suggestion translate.Suggestion
+	err := s.db.QueryRowContext(ctx, `
+		SELECT urdu, urdlish, english FROM translation_cache
+		WHERE provider = ? AND language = ? AND term = ?
+	`, provider.Name(), language, term).Scan(&suggestion.Urdu, &suggestion.Urdlish, &suggestion.English)
+	if err == nil {
+		return &suggestion, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to look up suggestion: %v", err)
+	}
+
+	suggested, err := provider.Suggest(ctx, language, text)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to suggest word: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO translation_cache (provider, language, term, urdu, urdlish, english, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider, language, term) DO UPDATE SET
+			urdu = excluded.urdu,
+			urdlish = excluded.urdlish,
+			english = excluded.english,
+			created_at = excluded.created_at
+	`, provider.Name(), language, term, suggested.Urdu, suggested.Urdlish, suggested.English, time.Now().UTC()); err != nil {
+		return nil, false, fmt.Errorf("failed to cache suggestion: %v", err)
+	}
+	return suggested, false, nil
+}
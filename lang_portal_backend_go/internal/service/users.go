@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"lang_portal/internal/db/queries"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultUserID is the user requests are served as when they aren't
+// authenticated. It owns all history recorded before users existed.
+const DefaultUserID int64 = 1
+
+// DefaultUserPasswordEnv sets the password of the default user, so the
+// history recorded before users existed can be logged in to
+const DefaultUserPasswordEnv = "LANG_PORTAL_DEFAULT_USER_PASSWORD"
+
+// Password length limits. bcrypt ignores anything past 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// ForUser returns a copy of the service whose methods read and record the
+// study history of the given user
+func (s *Service) ForUser(userID int64) *Service {
+	scoped := *s
+	scoped.userID = userID
+	return &scoped
+}
+
+// UserID returns the user the service is scoped to
+func (s *Service) UserID() int64 {
+	return s.userID
+}
+
+// CreateUser adds a user with a unique username and no password
+func (s *Service) CreateUser(ctx context.Context, username string) (*models.User, error) {
+	return s.createUser(ctx, username, sql.NullString{}, sql.NullString{})
+}
+
+// RegisterUser adds a user who logs in with a password. The email address is
+// optional and isn't verified, so Google logins of the same address aren't
+// linked to the user.
+func (s *Service) RegisterUser(ctx context.Context, username, password, email string) (*models.User, error) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	var address sql.NullString
+	if email != "" {
+		if address.String, err = normalizeEmail(email); err != nil {
+			return nil, err
+		}
+		address.Valid = true
+	}
+	return s.createUser(ctx, username, address, sql.NullString{String: hash, Valid: true})
+}
+
+// LoginWithEmail returns the user with a verified email address, creating
+// one named after the address if there is none. Usernames already taken get
+// a number appended. A user who registered with the address without
+// verifying it loses it to the new user, since whoever logged in proved
+// they own it.
+func (s *Service) LoginWithEmail(ctx context.Context, email string) (*models.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		id       int64
+		verified sql.NullTime
+	)
+	err = s.db.QueryRowContext(ctx, `SELECT id, email_verified_at FROM users WHERE email = ?`, email).Scan(&id, &verified)
+	if err == nil && verified.Valid {
+		return s.GetUser(ctx, id)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if err == nil {
+		if _, err := s.db.ExecContext(ctx, `UPDATE users SET email = NULL WHERE id = ? AND email_verified_at IS NULL`, id); err != nil {
+			return nil, fmt.Errorf("failed to release unverified email: %v", err)
+		}
+		log.Printf("Released the unverified email of user %d to a Google login", id)
+	}
+
+	base := email[:strings.Index(email, "@")]
+	for i := 1; ; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+		user, err := s.createUser(ctx, username, sql.NullString{String: email, Valid: true}, sql.NullString{})
+		if err != nil && err.Error() == "username already taken" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE users SET email_verified_at = ? WHERE id = ?`, time.Now().UTC(), user.ID); err != nil {
+			return nil, fmt.Errorf("failed to verify email: %v", err)
+		}
+		return user, nil
+	}
+}
+
+// normalizeEmail lower-cases an email address after checking it has a local
+// part and a domain
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.Index(email, "@")
+	if at < 1 || at == len(email)-1 || strings.ContainsAny(email, " \t") {
+		return "", invalid("invalid email")
+	}
+	return email, nil
+}
+
+// SetUserPassword changes the password a user logs in with
+func (s *Service) SetUserPassword(ctx context.Context, id int64, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE users SET password_hash = ? WHERE id = ?`, hash, id)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+	if updated == 0 {
+		return notFound("user not found")
+	}
+	return nil
+}
+
+// hashPassword checks a password's length and hashes it with bcrypt
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", invalid("invalid password")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// AuthenticateUser returns the user with the given username and password.
+// Unknown usernames, users without a password and wrong passwords all give
+// "invalid credentials".
+func (s *Service) AuthenticateUser(ctx context.Context, username, password string) (*models.User, error) {
+	row, err := s.queries.GetUserPasswordHash(ctx, strings.TrimSpace(username))
+	if err == sql.ErrNoRows {
+		return nil, unauthorized("invalid credentials")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if row.PasswordHash == nil || bcrypt.CompareHashAndPassword([]byte(*row.PasswordHash), []byte(password)) != nil {
+		return nil, unauthorized("invalid credentials")
+	}
+	return s.GetUser(ctx, row.ID)
+}
+
+func (s *Service) createUser(ctx context.Context, username string, email, passwordHash sql.NullString) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, invalid("invalid username")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)
+	`, username, email, passwordHash)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: users.email") {
+			return nil, conflict("email already registered")
+		}
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, conflict("username already taken")
+		}
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user id: %v", err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+// GetUser returns a user by ID. Expired guests aren't found.
+func (s *Service) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	row, err := s.queries.GetUser(ctx, queries.GetUserParams{
+		ID:  id,
+		Now: time.Now().UTC().Format(guestExpiresAtLayout),
+	})
+	if err == sql.ErrNoRows {
+		return nil, notFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	user := models.User{
+		ID:             row.ID,
+		Username:       row.Username,
+		Email:          row.Email,
+		Guest:          row.GuestExpiresAt != nil,
+		GuestExpiresAt: row.GuestExpiresAt,
+	}
+	if row.CreatedAt != nil {
+		user.CreatedAt = *row.CreatedAt
+	}
+	return &user, nil
+}
+
+// checkSessionOwner returns "study session not found" unless the session
+// exists and belongs to the service's user
+func (s *Service) checkSessionOwner(ctx context.Context, q repository.Querier, sessionID int64) error {
+	owned, err := s.sessions.Owns(ctx, q, s.userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get study session: %v", err)
+	}
+	if !owned {
+		return notFound("study session not found")
+	}
+	return nil
+}
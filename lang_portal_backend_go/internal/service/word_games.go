@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/models"
+	"lang_portal/internal/wordgame"
+	"math/rand"
+	"time"
+)
+
+// Word games are played on the transliteration or the Urdu script
+const (
+	WordGameScriptUrdlish = "urdlish"
+	WordGameScriptUrdu    = "urdu"
+)
+
+// wordGameActivities are the study activities word game sessions are filed
+// under
+var wordGameActivities = map[string]string{
+	wordgame.Scramble: "Word Scramble",
+	wordgame.Hangman:  "Hangman",
+}
+
+// StartWordGame starts a word scramble or hangman session with up to
+// wordCount words of a group, picking the words the learner struggles with
+// most: those being relearned, then those with the lowest ease and the most
+// lapses. Ties, including words never studied, are broken at random.
+func (s *Service) StartWordGame(ctx context.Context, game string, groupID int64, wordCount int, script string) (*models.WordGameSession, error) {
+	activity, ok := wordGameActivities[game]
+	if !ok {
+		return nil, invalid("invalid game")
+	}
+	if script != WordGameScriptUrdlish && script != WordGameScriptUrdu {
+		return nil, invalid("invalid script")
+	}
+	if _, err := s.GetGroup(ctx, groupID); err != nil {
+		return nil, notFound("group not found")
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.script, w.transliteration
+		FROM words_groups wg
+		JOIN words w ON w.id = wg.word_id
+		LEFT JOIN word_learning_state wls ON wls.word_id = w.id AND wls.user_id = ?
+		WHERE wg.group_id = ?
+		ORDER BY COALESCE(wls.relearning_step, 0) > 0 DESC,
+			COALESCE(wls.ease_factor, 2.5),
+			COALESCE(wls.lapses, 0) DESC,
+			RANDOM()
+		LIMIT ?
+	`, s.userID, groupID, wordCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word game words: %v", err)
+	}
+	defer rows.Close()
+
+	type gameWord struct {
+		id     int64
+		answer string
+	}
+	var words []gameWord
+	for rows.Next() {
+		var (
+			word          gameWord
+			urdu, urdlish string
+		)
+		if err := rows.Scan(&word.id, &urdu, &urdlish); err != nil {
+			return nil, fmt.Errorf("failed to scan word game word: %v", err)
+		}
+		word.answer = urdlish
+		if script == WordGameScriptUrdu {
+			word.answer = urdu
+		}
+		words = append(words, word)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word game words: %v", err)
+	}
+	if len(words) == 0 {
+		return nil, notFound("no words found in the group")
+	}
+
+	var sessionID int64
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		sessionID, err = s.startActivitySession(ctx, tx, activity, groupID, "")
+		if err != nil {
+			return err
+		}
+
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for _, word := range words {
+			var puzzle string
+			if game == wordgame.Scramble {
+				puzzle = wordgame.Shuffle(word.answer, rng)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO study_session_words (study_session_id, word_id)
+				VALUES (?, ?)
+			`, sessionID, word.id); err != nil {
+				return fmt.Errorf("failed to add word to study session: %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO word_game_rounds (study_session_id, word_id, game, answer, puzzle)
+				VALUES (?, ?, ?, ?, ?)
+			`, sessionID, word.id, game, word.answer, puzzle); err != nil {
+				return fmt.Errorf("failed to add word game round: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWordGameSession(ctx, sessionID)
+}
+
+// GetWordGameSession returns a word game session and its rounds
+func (s *Service) GetWordGameSession(ctx context.Context, sessionID int64) (*models.WordGameSession, error) {
+	session := models.WordGameSession{SessionID: sessionID, Rounds: []models.WordGameRound{}}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT group_id FROM study_sessions WHERE id = ? AND user_id = ?
+	`, sessionID, s.userID).Scan(&session.GroupID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word game not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study session: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT wgr.word_id, w.english, wgr.game, wgr.answer, wgr.puzzle,
+			   wgr.guesses, wgr.wrong_guesses, wgr.status
+		FROM word_game_rounds wgr
+		JOIN words w ON w.id = wgr.word_id
+		WHERE wgr.study_session_id = ?
+		ORDER BY wgr.id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word game rounds: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		round, err := scanWordGameRound(rows)
+		if err != nil {
+			return nil, err
+		}
+		session.Rounds = append(session.Rounds, *round)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word game rounds: %v", err)
+	}
+	if len(session.Rounds) == 0 {
+		return nil, notFound("word game not found")
+	}
+	session.Game = session.Rounds[0].Game
+	return &session, nil
+}
+
+// scanWordGameRound reads a round as selected by GetWordGameSession, hiding
+// what the learner hasn't found out yet
+func scanWordGameRound(row interface{ Scan(...interface{}) error }) (*models.WordGameRound, error) {
+	var (
+		round   models.WordGameRound
+		answer  string
+		puzzle  string
+		guesses string
+	)
+	if err := row.Scan(&round.WordID, &round.Clue, &round.Game, &answer, &puzzle,
+		&guesses, &round.WrongGuesses, &round.Status); err != nil {
+		return nil, fmt.Errorf("failed to scan word game round: %v", err)
+	}
+	if err := json.Unmarshal([]byte(guesses), &round.Guesses); err != nil {
+		return nil, fmt.Errorf("failed to decode guesses: %v", err)
+	}
+
+	round.MaxWrongGuesses = wordgame.MaxWrong(round.Game)
+	round.Puzzle = puzzle
+	if round.Game == wordgame.Hangman {
+		round.Puzzle = wordgame.Mask(answer, round.Guesses)
+	}
+	if round.Status != wordgame.StatusPlaying {
+		round.Answer = &answer
+	}
+	return &round, nil
+}
+
+// GuessWordGame checks a guess in a word game round. Scramble guesses are
+// the whole word; hangman guesses are a letter or the whole word. When a
+// round is won or lost it is recorded as a review of the word.
+func (s *Service) GuessWordGame(ctx context.Context, sessionID, wordID int64, guess string) (*models.WordGameRound, error) {
+	if wordgame.Same(guess, "") {
+		return nil, invalid("invalid guess")
+	}
+
+	var round *models.WordGameRound
+	err := s.db.WithTx(ctx, func(tx *models.Tx) error {
+		var (
+			game, answer, status, guessesJSON string
+			wrong                             int
+			guesses                           []string
+		)
+		err := tx.QueryRowContext(ctx, `
+			SELECT game, answer, guesses, wrong_guesses, status
+			FROM word_game_rounds
+			WHERE study_session_id = ? AND word_id = ? AND study_session_id IN (SELECT id FROM study_sessions WHERE user_id = ?)
+		`, sessionID, wordID, s.userID).Scan(&game, &answer, &guessesJSON, &wrong, &status)
+		if err == sql.ErrNoRows {
+			return notFound("word game round not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get word game round: %v", err)
+		}
+		if status != wordgame.StatusPlaying {
+			return conflict("word game round already finished")
+		}
+		if err := json.Unmarshal([]byte(guessesJSON), &guesses); err != nil {
+			return fmt.Errorf("failed to decode guesses: %v", err)
+		}
+
+		repeated := false
+		for _, g := range guesses {
+			if wordgame.Same(g, guess) {
+				repeated = true
+			}
+		}
+
+		// Repeating a guess costs nothing
+		if !repeated {
+			guesses = append(guesses, guess)
+			switch {
+			case wordgame.Same(guess, answer):
+				status = wordgame.StatusWon
+			case game == wordgame.Hangman && wordgame.IsLetterGuess(guess):
+				if !wordgame.Contains(answer, guess) {
+					wrong++
+				} else if wordgame.Solved(answer, guesses) {
+					status = wordgame.StatusWon
+				}
+			default:
+				wrong++
+			}
+			if status == wordgame.StatusPlaying && wrong >= wordgame.MaxWrong(game) {
+				status = wordgame.StatusLost
+			}
+		}
+
+		encoded, err := json.Marshal(guesses)
+		if err != nil {
+			return fmt.Errorf("failed to encode guesses: %v", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE word_game_rounds
+			SET guesses = ?, wrong_guesses = ?, status = ?
+			WHERE study_session_id = ? AND word_id = ?
+		`, string(encoded), wrong, status, sessionID, wordID)
+		if err != nil {
+			return fmt.Errorf("failed to update word game round: %v", err)
+		}
+
+		if status != wordgame.StatusPlaying {
+			if _, err := s.reviewWord(ctx, tx, sessionID, wordID, status == wordgame.StatusWon, false); err != nil {
+				return err
+			}
+		}
+
+		round, err = scanWordGameRound(tx.QueryRowContext(ctx, `
+			SELECT wgr.word_id, w.english, wgr.game, wgr.answer, wgr.puzzle,
+				   wgr.guesses, wgr.wrong_guesses, wgr.status
+			FROM word_game_rounds wgr
+			JOIN words w ON w.id = wgr.word_id
+			WHERE wgr.study_session_id = ? AND wgr.word_id = ?
+		`, sessionID, wordID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return round, nil
+}
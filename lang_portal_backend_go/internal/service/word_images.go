@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"lang_portal/internal/imaging"
+	"lang_portal/internal/models"
+	"log"
+	"regexp"
+	"time"
+)
+
+// ImageURLPrefix is the path pictures of words are served under. Like
+// AudioURLPrefix, it redirects to a signed URL of the file in the media
+// storage.
+const ImageURLPrefix = "/word-images/"
+
+// imageKeyPrefix is where pictures of words are kept in the media storage
+const imageKeyPrefix = "images/"
+
+// ThumbnailSize is the most pixels wide and high thumbnails of pictures are
+const ThumbnailSize = 256
+
+// MaxImageSize is the largest picture, in bytes, that can be uploaded
+const MaxImageSize = 10 << 20
+
+// imageNamePattern is what the names of pictures and their thumbnails look
+// like
+var imageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}(-thumb\.jpg|\.(png|jpg|gif))$`)
+
+// ValidImageName reports whether name could be a picture of a word or its
+// thumbnail
+func ValidImageName(name string) bool {
+	return imageNamePattern.MatchString(name)
+}
+
+// WordImageFileURL returns a signed URL of the picture or thumbnail name in
+// the media storage
+func (s *Service) WordImageFileURL(ctx context.Context, name string) (string, error) {
+	if !ValidImageName(name) {
+		return "", notFound("image not found")
+	}
+	key := imageKeyPrefix + name
+	exists, err := s.media.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check image: %v", err)
+	}
+	if !exists {
+		return "", notFound("image not found")
+	}
+	url, err := s.media.SignedURL(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign image URL: %v", err)
+	}
+	return url, nil
+}
+
+// SetWordImage stores a PNG, JPEG or GIF picture of a word with a JPEG
+// thumbnail of it, replacing the picture the word had. Files are named
+// after what they contain, so a picture shared by words is stored once.
+func (s *Service) SetWordImage(ctx context.Context, wordID int64, data []byte) (*models.WordImage, error) {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, invalid("image is empty")
+	}
+	if len(data) > MaxImageSize {
+		return nil, invalid("image must be at most %d MB", MaxImageSize>>20)
+	}
+	info, err := imaging.Inspect(data)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+	name, thumbnail := hash+"."+info.Extension, hash+"-thumb.jpg"
+	exists, err := s.media.Exists(ctx, imageKeyPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check image: %v", err)
+	}
+	if !exists {
+		if err := s.media.Put(ctx, imageKeyPrefix+name, data, info.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to store image: %v", err)
+		}
+	}
+	exists, err = s.media.Exists(ctx, imageKeyPrefix+thumbnail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check thumbnail: %v", err)
+	}
+	if !exists {
+		thumb, err := imaging.Thumbnail(data, ThumbnailSize)
+		if err != nil {
+			if errors.Is(err, imaging.ErrUnsupported) {
+				return nil, invalid("%v", err)
+			}
+			return nil, fmt.Errorf("failed to make thumbnail: %v", err)
+		}
+		if err := s.media.Put(ctx, imageKeyPrefix+thumbnail, thumb, imaging.JPEG); err != nil {
+			return nil, fmt.Errorf("failed to store thumbnail: %v", err)
+		}
+	}
+
+	previous, err := s.GetWordImage(ctx, wordID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO word_images (word_id, name, thumbnail, content_type, width, height, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(word_id) DO UPDATE SET
+			name = excluded.name,
+			thumbnail = excluded.thumbnail,
+			content_type = excluded.content_type,
+			width = excluded.width,
+			height = excluded.height,
+			created_at = excluded.created_at
+	`, wordID, name, thumbnail, info.ContentType, info.Width, info.Height, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to link word image: %v", err)
+	}
+	if previous != nil && previous.ImageURL != ImageURLPrefix+name {
+		s.removeUnusedImages(ctx, previous)
+	}
+	return s.GetWordImage(ctx, wordID)
+}
+
+// GetWordImage returns the picture of a word, or nil when it has none
+func (s *Service) GetWordImage(ctx context.Context, wordID int64) (*models.WordImage, error) {
+	image := &models.WordImage{WordID: wordID}
+	var name, thumbnail string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, thumbnail, content_type, width, height, created_at
+		FROM word_images WHERE word_id = ?
+	`, wordID).Scan(&name, &thumbnail, &image.ContentType, &image.Width, &image.Height, &image.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word image: %v", err)
+	}
+	image.ImageURL = ImageURLPrefix + name
+	image.ThumbnailURL = ImageURLPrefix + thumbnail
+	return image, nil
+}
+
+// DeleteWordImage removes the picture of a word. Its files are deleted
+// unless another word has the same picture.
+func (s *Service) DeleteWordImage(ctx context.Context, wordID int64) error {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return err
+	}
+	image, err := s.GetWordImage(ctx, wordID)
+	if err != nil {
+		return err
+	}
+	if image == nil {
+		return notFound("word image not found")
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM word_images WHERE word_id = ?`, wordID); err != nil {
+		return fmt.Errorf("failed to delete word image: %v", err)
+	}
+	s.removeUnusedImages(ctx, image)
+	return nil
+}
+
+// removeUnusedImages deletes the files of a picture no word has any more.
+// Files left behind only take space, so failures are logged rather than
+// returned.
+func (s *Service) removeUnusedImages(ctx context.Context, image *models.WordImage) {
+	name := image.ImageURL[len(ImageURLPrefix):]
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM word_images WHERE name = ?`, name).Scan(&count); err != nil {
+		log.Printf("Failed to check word image %s is unused: %v", name, err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+	for _, url := range []string{image.ImageURL, image.ThumbnailURL} {
+		key := imageKeyPrefix + url[len(ImageURLPrefix):]
+		if err := s.media.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete %s: %v", key, err)
+		}
+	}
+}
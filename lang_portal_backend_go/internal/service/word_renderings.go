@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/corpus"
+	"lang_portal/internal/models"
+	"strings"
+)
+
+// Font styles words can be shown in
+const (
+	FontStyleNastaleeq = "nastaleeq"
+	FontStyleNaskh     = "naskh"
+)
+
+// MaxLigatureSpellings is the most ligature-sensitive spellings a word can
+// have
+const MaxLigatureSpellings = 5
+
+// MaxImportDiacritizedForms is the most diacritized forms added at once
+const MaxImportDiacritizedForms = 1000
+
+// isSpelling reports whether text is the word written as script, once marks,
+// joiners and letter variants are set aside
+func isSpelling(text, script string) bool {
+	key := corpus.Key(text)
+	return key != "" && key == corpus.Key(script)
+}
+
+// cleanWordRendering trims the hints for rendering a word written as
+// script, and checks they are of a known font style and spellings of the
+// word
+func cleanWordRendering(script string, rendering *models.WordRendering) error {
+	rendering.FontStyle = strings.ToLower(strings.TrimSpace(rendering.FontStyle))
+	rendering.Diacritized = strings.TrimSpace(rendering.Diacritized)
+	switch rendering.FontStyle {
+	case "", FontStyleNastaleeq, FontStyleNaskh:
+	default:
+		return invalid("invalid font style %q: use %s or %s", rendering.FontStyle, FontStyleNastaleeq, FontStyleNaskh)
+	}
+	if rendering.Diacritized != "" && !isSpelling(rendering.Diacritized, script) {
+		return invalid("%q isn't a spelling of %q", rendering.Diacritized, script)
+	}
+
+	if len(rendering.LigatureSpellings) > MaxLigatureSpellings {
+		return invalid("a word can have at most %d ligature spellings", MaxLigatureSpellings)
+	}
+	seen := map[string]bool{}
+	spellings := []string{}
+	for _, spelling := range rendering.LigatureSpellings {
+		spelling = strings.TrimSpace(spelling)
+		if !isSpelling(spelling, script) {
+			return invalid("%q isn't a spelling of %q", spelling, script)
+		}
+		if !seen[spelling] {
+			seen[spelling] = true
+			spellings = append(spellings, spelling)
+		}
+	}
+	rendering.LigatureSpellings = spellings
+
+	if rendering.FontStyle == "" && rendering.Diacritized == "" && len(spellings) == 0 {
+		return invalid("give a font style, diacritized form or ligature spellings")
+	}
+	return nil
+}
+
+// GetWordRendering returns the hints for rendering a word, or nil when it
+// has none
+func (s *Service) GetWordRendering(ctx context.Context, wordID int64) (*models.WordRendering, error) {
+	var (
+		rendering models.WordRendering
+		spellings string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT font_style, diacritized, ligature_spellings FROM word_renderings WHERE word_id = ?
+	`, wordID).Scan(&rendering.FontStyle, &rendering.Diacritized, &spellings)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word rendering: %v", err)
+	}
+	if err := json.Unmarshal([]byte(spellings), &rendering.LigatureSpellings); err != nil {
+		return nil, fmt.Errorf("failed to decode ligature spellings: %v", err)
+	}
+	return &rendering, nil
+}
+
+// SetWordRendering replaces the hints for rendering a word. The diacritized
+// form and ligature spellings must be the word once their marks and joiners
+// are set aside.
+func (s *Service) SetWordRendering(ctx context.Context, wordID int64, rendering models.WordRendering) (*models.WordRendering, error) {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return nil, err
+	}
+	var script string
+	err := s.db.QueryRowContext(ctx, `SELECT script FROM words WHERE id = ?`, wordID).Scan(&script)
+	if err == sql.ErrNoRows {
+		return nil, notFound("word not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word: %v", err)
+	}
+	if err := cleanWordRendering(script, &rendering); err != nil {
+		return nil, err
+	}
+	spellings, err := json.Marshal(rendering.LigatureSpellings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ligature spellings: %v", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO word_renderings (word_id, font_style, diacritized, ligature_spellings)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (word_id) DO UPDATE SET
+			font_style = excluded.font_style,
+			diacritized = excluded.diacritized,
+			ligature_spellings = excluded.ligature_spellings,
+			updated_at = CURRENT_TIMESTAMP
+	`, wordID, rendering.FontStyle, rendering.Diacritized, string(spellings)); err != nil {
+		return nil, fmt.Errorf("failed to set word rendering: %v", err)
+	}
+	return s.GetWordRendering(ctx, wordID)
+}
+
+// DeleteWordRendering removes the hints for rendering a word
+func (s *Service) DeleteWordRendering(ctx context.Context, wordID int64) error {
+	if err := s.checkWordEditor(ctx, s.db, wordID); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `DELETE FROM word_renderings WHERE word_id = ?`, wordID)
+	if err != nil {
+		return fmt.Errorf("failed to delete word rendering: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return notFound("word rendering not found")
+	}
+	return nil
+}
+
+// ImportDiacritizedForms sets the diacritized forms of words of a language,
+// by default the service's, keeping their other rendering hints. A form
+// without a word ID is matched to the word written the same once marks are
+// set aside; forms no word matches, or several do, are reported rather
+// than added.
+func (s *Service) ImportDiacritizedForms(ctx context.Context, language string, forms []models.DiacritizedForm) (*models.DiacritizedImport, error) {
+	if err := s.CheckAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if len(forms) == 0 || len(forms) > MaxImportDiacritizedForms {
+		return nil, invalid("add 1 to %d diacritized forms at a time", MaxImportDiacritizedForms)
+	}
+	language = s.newWordLanguage(language)
+	if err := s.checkLanguage(ctx, s.db, language); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, script FROM words WHERE language = ?`, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words: %v", err)
+	}
+	defer rows.Close()
+	scripts := map[int64]string{}
+	words := map[string][]int64{}
+	for rows.Next() {
+		var (
+			id     int64
+			script string
+		)
+		if err := rows.Scan(&id, &script); err != nil {
+			return nil, fmt.Errorf("failed to scan word: %v", err)
+		}
+		scripts[id] = script
+		key := corpus.Key(script)
+		words[key] = append(words[key], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %v", err)
+	}
+
+	result := &models.DiacritizedImport{Language: language, Unmatched: []string{}, Ambiguous: []string{}}
+	diacritized := map[int64]string{}
+	var wordIDs []int64
+	for i, form := range forms {
+		form.Diacritized = strings.TrimSpace(form.Diacritized)
+		if form.Diacritized == "" {
+			return nil, invalid("form %d: diacritized is required", i+1)
+		}
+		wordID := form.WordID
+		if wordID != 0 {
+			script, ok := scripts[wordID]
+			if !ok {
+				return nil, invalid("form %d: word %d isn't a word of %s", i+1, wordID, language)
+			}
+			if !isSpelling(form.Diacritized, script) {
+				return nil, invalid("form %d: %q isn't a spelling of %q", i+1, form.Diacritized, script)
+			}
+		} else {
+			switch matches := words[corpus.Key(form.Diacritized)]; len(matches) {
+			case 0:
+				result.Unmatched = append(result.Unmatched, form.Diacritized)
+				continue
+			case 1:
+				wordID = matches[0]
+			default:
+				result.Ambiguous = append(result.Ambiguous, form.Diacritized)
+				continue
+			}
+		}
+		if _, ok := diacritized[wordID]; !ok {
+			wordIDs = append(wordIDs, wordID)
+		}
+		diacritized[wordID] = form.Diacritized
+	}
+
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		for _, wordID := range wordIDs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO word_renderings (word_id, diacritized) VALUES (?, ?)
+				ON CONFLICT (word_id) DO UPDATE SET
+					diacritized = excluded.diacritized,
+					updated_at = CURRENT_TIMESTAMP
+			`, wordID, diacritized[wordID]); err != nil {
+				return fmt.Errorf("failed to set diacritized form: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Updated = len(wordIDs)
+	return result, nil
+}
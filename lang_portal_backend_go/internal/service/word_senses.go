@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/events"
+	"lang_portal/internal/models"
+	"lang_portal/internal/repository"
+	"lang_portal/internal/spelling"
+	"strings"
+	"time"
+)
+
+// MaxWordSenses is the most senses a word can have
+const MaxWordSenses = 20
+
+// senseRegisters are the registers a word sense can be marked with. A sense
+// without one is neutral.
+var senseRegisters = map[string]bool{
+	"formal":     true,
+	"informal":   true,
+	"colloquial": true,
+	"literary":   true,
+	"poetic":     true,
+	"religious":  true,
+	"slang":      true,
+	"archaic":    true,
+}
+
+// GetWordSenses returns the English meanings of a word, most common first
+func (s *Service) GetWordSenses(ctx context.Context, wordID int64) ([]models.WordSense, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM words WHERE id = ?)
+	`, wordID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up word: %v", err)
+	}
+	if !exists {
+		return nil, notFound("word not found")
+	}
+	return wordSenses(ctx, s.db, wordID)
+}
+
+// wordSenses returns the senses of a word, empty if it has none
+func wordSenses(ctx context.Context, q repository.Querier, wordID int64) ([]models.WordSense, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, english, register, usage_note
+		FROM word_senses
+		WHERE word_id = ?
+		ORDER BY position
+	`, wordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get word senses: %v", err)
+	}
+	defer rows.Close()
+
+	senses := []models.WordSense{}
+	for rows.Next() {
+		var sense models.WordSense
+		if err := rows.Scan(&sense.ID, &sense.English, &sense.Register, &sense.UsageNote); err != nil {
+			return nil, fmt.Errorf("failed to scan word sense: %v", err)
+		}
+		senses = append(senses, sense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating word senses: %v", err)
+	}
+	return senses, nil
+}
+
+// SetWordSenses replaces the English meanings of a word, in order of how
+// common they are. The first becomes the word's english.
+func (s *Service) SetWordSenses(ctx context.Context, wordID int64, senses []models.WordSense) ([]models.WordSense, error) {
+	senses, err := cleanWordSenses(senses)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithTx(ctx, func(tx *models.Tx) error {
+		if err := s.checkWordEditor(ctx, tx, wordID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE words SET english = ? WHERE id = ?
+		`, senses[0].English, wordID)
+		if err != nil {
+			return fmt.Errorf("failed to update word: %v", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %v", err)
+		}
+		if rows == 0 {
+			return notFound("word not found")
+		}
+		s.publishAfterCommit(ctx, tx, events.WordUpdated{UserID: s.userID, WordID: wordID, At: time.Now().UTC()})
+		return replaceWordSenses(ctx, tx, wordID, senses)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wordSenses(ctx, s.db, wordID)
+}
+
+// cleanWordSenses trims the fields of senses and checks each has an English
+// meaning, a known register and a meaning unlike the others'
+func cleanWordSenses(senses []models.WordSense) ([]models.WordSense, error) {
+	if len(senses) == 0 {
+		return nil, invalid("a word needs at least one sense")
+	}
+	if len(senses) > MaxWordSenses {
+		return nil, invalid("a word can have at most %d senses", MaxWordSenses)
+	}
+
+	cleaned := make([]models.WordSense, len(senses))
+	seen := map[string]bool{}
+	for i, sense := range senses {
+		sense.English = strings.TrimSpace(sense.English)
+		sense.Register = strings.ToLower(strings.TrimSpace(sense.Register))
+		sense.UsageNote = strings.TrimSpace(sense.UsageNote)
+		switch {
+		case sense.English == "":
+			return nil, invalid("sense %d has no english", i+1)
+		case sense.Register != "" && !senseRegisters[sense.Register]:
+			return nil, invalid("sense %d has unknown register %q", i+1, sense.Register)
+		case seen[strings.ToLower(sense.English)]:
+			return nil, invalid("sense %d repeats %q", i+1, sense.English)
+		}
+		seen[strings.ToLower(sense.English)] = true
+		cleaned[i] = sense
+	}
+	return cleaned, nil
+}
+
+// replaceWordSenses replaces the senses of a word in tx. The word's english
+// must already be the first's, as the triggers that keep it in step with the
+// senses ran when it was set.
+func replaceWordSenses(ctx context.Context, tx *models.Tx, wordID int64, senses []models.WordSense) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM word_senses WHERE word_id = ?`, wordID); err != nil {
+		return fmt.Errorf("failed to delete word senses: %v", err)
+	}
+	for i, sense := range senses {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO word_senses (word_id, position, english, register, usage_note)
+			VALUES (?, ?, ?, ?, ?)
+		`, wordID, i, sense.English, sense.Register, sense.UsageNote); err != nil {
+			return fmt.Errorf("failed to add word sense: %v", err)
+		}
+	}
+	return nil
+}
+
+// matchSenses returns how well answer matches the best of the English
+// meanings of a word, as spelling.Match does for one
+func matchSenses(answer string, senses []models.WordSense) spelling.Result {
+	best := spelling.Wrong
+	for _, sense := range senses {
+		switch spelling.Match(answer, sense.English) {
+		case spelling.Exact:
+			return spelling.Exact
+		case spelling.NearMiss:
+			best = spelling.NearMiss
+		}
+	}
+	return best
+}
+
+// isSense reports whether text is one of the English meanings of a word,
+// ignoring case and surrounding space
+func isSense(text string, senses []models.WordSense) bool {
+	for _, sense := range senses {
+		if strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(sense.English)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Package spelling checks typed answers, forgiving differences in case,
+// punctuation, diacritics and small typos.
+package spelling
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Result is the outcome of checking a typed answer
+type Result string
+
+const (
+	// Exact means the answer matches once normalized
+	Exact Result = "correct"
+	// NearMiss means the answer is within a few typos of the expected one
+	NearMiss Result = "near_miss"
+	// Wrong means the answer is too far from the expected one
+	Wrong Result = "wrong"
+)
+
+// urduVariants maps Arabic code points that Urdu keyboards often produce to
+// their Urdu equivalents
+var urduVariants = map[rune]rune{
+	'ي': 'ی', // Arabic yeh to Farsi yeh
+	'ى': 'ی', // alef maksura to Farsi yeh
+	'ك': 'ک', // Arabic kaf to keheh
+	'ه': 'ہ', // Arabic heh to heh goal
+}
+
+// Normalize lowercases s, strips diacritics (including Urdu harakat),
+// unifies Arabic and Urdu letter variants and collapses punctuation and
+// whitespace into single spaces
+func Normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == 'ـ': // marks and tatweel
+			continue
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			if v, ok := urduVariants[r]; ok {
+				r = v
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// MaxDistance is the number of typos tolerated in an answer of the given
+// length in runes. Very short answers must be exact.
+func MaxDistance(length int) int {
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Match checks a typed answer against the expected one
+func Match(answer, expected string) Result {
+	a := []rune(Normalize(answer))
+	e := []rune(Normalize(expected))
+	if len(a) == 0 {
+		return Wrong
+	}
+	if string(a) == string(e) {
+		return Exact
+	}
+	if Distance(a, e) <= MaxDistance(len(e)) {
+		return NearMiss
+	}
+	return Wrong
+}
+
+// Distance returns the number of insertions, deletions, substitutions and
+// adjacent transpositions needed to turn a into b
+func Distance(a, b []rune) int {
+	// d[i][j] is the distance between a[:i] and b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// Similarity returns how alike a and b are once normalized, from 0 for
+// nothing in common, or either being empty, to 1 for the same
+func Similarity(a, b string) float64 {
+	ra := []rune(Normalize(a))
+	rb := []rune(Normalize(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	return 1 - float64(Distance(ra, rb))/float64(max(len(ra), len(rb)))
+}
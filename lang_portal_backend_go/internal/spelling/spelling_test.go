@@ -0,0 +1,101 @@
+package spelling
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "case and punctuation", in: "Hello, World!", want: "hello world"},
+		{name: "surrounding and inner spaces", in: "  thank-you  ", want: "thank you"},
+		{name: "latin diacritics", in: "Café", want: "cafe"},
+		{name: "urdu harakat", in: "کِتاب", want: "کتاب"},
+		{name: "tatweel", in: "سـلام", want: "سلام"},
+		{name: "arabic letter variants", in: "شكريه", want: "شکریہ"},
+		{name: "empty", in: "", want: ""},
+		{name: "only punctuation", in: "?!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "same", b: "same", want: 0},
+		{a: "", b: "abc", want: 3},
+		{a: "abc", b: "", want: 3},
+		{a: "salaam", b: "salam", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "abcd", b: "abdc", want: 1},
+		{a: "شکریہ", b: "شکریا", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Distance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   string
+		expected string
+		want     Result
+	}{
+		{name: "exact once normalized", answer: "Hello!", expected: "hello", want: Exact},
+		{name: "urdu variants", answer: "كتاب", expected: "کِتاب", want: Exact},
+		{name: "one typo", answer: "helo", expected: "hello", want: NearMiss},
+		{name: "two typos in a short word", answer: "hlo", expected: "hello", want: Wrong},
+		{name: "two typos in a long word", answer: "shukria", expected: "shukriyaa", want: NearMiss},
+		{name: "transposition", answer: "thnak you", expected: "thank you", want: NearMiss},
+		{name: "very short words must be exact", answer: "teh", expected: "the", want: Wrong},
+		{name: "empty answer", answer: " ", expected: "", want: Wrong},
+		{name: "different word", answer: "goodbye", expected: "hello", want: Wrong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.answer, tt.expected); got != tt.want {
+				t.Errorf("Match(%q, %q) = %s, want %s", tt.answer, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "Hello", b: "hello", want: 1},
+		{a: "abcd", b: "abcx", want: 0.75},
+		{a: "", b: "hello", want: 0},
+		{a: "abc", b: "xyz", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package srs implements SM-2 spaced repetition scheduling for words.
+package srs
+
+import (
+	"fmt"
+	"lang_portal/internal/models"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grade is the SM-2 response quality, from 0 (complete blackout) to 5 (perfect)
+type Grade int
+
+const (
+	GradeBlackout Grade = 0
+	GradeWrong    Grade = 1
+	GradeHard     Grade = 3
+	GradeGood     Grade = 4
+	GradeEasy     Grade = 5
+)
+
+const (
+	// DefaultEaseFactor is the ease factor given to words seen for the first time
+	DefaultEaseFactor = 2.5
+	// MinEaseFactor stops difficult words from being scheduled ever more often
+	MinEaseFactor = 1.3
+)
+
+// MatureIntervalDays is the interval from which a word counts as mature
+// rather than young
+const MatureIntervalDays = 21
+
+// GradeFromCorrect maps a plain right/wrong answer onto an SM-2 grade
+func GradeFromCorrect(correct bool) Grade {
+	if correct {
+		return GradeGood
+	}
+	return GradeWrong
+}
+
+// Self-graded flashcard ratings
+const (
+	RatingAgain = "again"
+	RatingHard  = "hard"
+	RatingGood  = "good"
+	RatingEasy  = "easy"
+)
+
+// GradeFromRating maps a self-graded rating onto an SM-2 grade. It reports
+// false for unknown ratings.
+func GradeFromRating(rating string) (Grade, bool) {
+	switch rating {
+	case RatingAgain:
+		return GradeWrong, true
+	case RatingHard:
+		return GradeHard, true
+	case RatingGood:
+		return GradeGood, true
+	case RatingEasy:
+		return GradeEasy, true
+	}
+	return 0, false
+}
+
+// NewState returns the learning state of a word that has never been reviewed
+func NewState(wordID int64, now time.Time) *models.WordLearningState {
+	return &models.WordLearningState{
+		WordID:     wordID,
+		EaseFactor: DefaultEaseFactor,
+		DueAt:      now,
+	}
+}
+
+// DefaultRelearningSteps are the delays before a forgotten word is shown again
+var DefaultRelearningSteps = []time.Duration{10 * time.Minute, 24 * time.Hour}
+
+// DefaultLapseMultiplier shrinks the interval of a forgotten word once it has
+// been relearned
+const DefaultLapseMultiplier = 0.5
+
+// Scheduler applies SM-2 reviews. A word that was known and is then answered
+// wrong goes through RelearningSteps before its interval, shrunk by
+// LapseMultiplier, is restored.
+type Scheduler struct {
+	RelearningSteps []time.Duration
+	LapseMultiplier float64
+}
+
+// NewScheduler creates a scheduler with the default relearning steps
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		RelearningSteps: DefaultRelearningSteps,
+		LapseMultiplier: DefaultLapseMultiplier,
+	}
+}
+
+// Schedule applies a review with the given grade to a word's learning state
+// and returns the updated state with its next due date
+func (sc *Scheduler) Schedule(state models.WordLearningState, grade Grade, now time.Time) models.WordLearningState {
+	if grade < GradeBlackout {
+		grade = GradeBlackout
+	}
+	if grade > GradeEasy {
+		grade = GradeEasy
+	}
+
+	reviewedAt := now
+	state.LastReviewedAt = &reviewedAt
+
+	if state.RelearningStep > 0 {
+		return sc.relearn(state, grade, now)
+	}
+
+	if grade >= GradeHard {
+		switch state.Repetitions {
+		case 0:
+			state.IntervalDays = 1
+		case 1:
+			state.IntervalDays = 6
+		default:
+			state.IntervalDays = int(math.Round(float64(state.IntervalDays) * state.EaseFactor))
+		}
+		state.Repetitions++
+	} else if state.Repetitions > 0 {
+		// A known word was forgotten
+		state.Lapses++
+		state.LapsedIntervalDays = int(math.Round(float64(state.IntervalDays) * sc.LapseMultiplier))
+		if state.LapsedIntervalDays < 1 {
+			state.LapsedIntervalDays = 1
+		}
+	} else {
+		state.IntervalDays = 1
+	}
+
+	q := float64(GradeEasy - grade)
+	state.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if state.EaseFactor < MinEaseFactor {
+		state.EaseFactor = MinEaseFactor
+	}
+
+	if state.LapsedIntervalDays > 0 && len(sc.RelearningSteps) > 0 {
+		state.RelearningStep = 1
+		state.DueAt = now.Add(sc.RelearningSteps[0])
+		return state
+	}
+	if state.LapsedIntervalDays > 0 {
+		state.IntervalDays = state.LapsedIntervalDays
+		state.LapsedIntervalDays = 0
+	}
+
+	state.DueAt = now.AddDate(0, 0, state.IntervalDays)
+	return state
+}
+
+// relearn moves a forgotten word through the relearning steps. A wrong
+// answer starts the steps again; passing the last step restores the word's
+// shrunk interval.
+func (sc *Scheduler) relearn(state models.WordLearningState, grade Grade, now time.Time) models.WordLearningState {
+	if grade < GradeHard {
+		state.RelearningStep = 1
+	} else {
+		state.RelearningStep++
+	}
+
+	if state.RelearningStep > len(sc.RelearningSteps) {
+		state.RelearningStep = 0
+		state.IntervalDays = state.LapsedIntervalDays
+		if state.IntervalDays < 1 {
+			state.IntervalDays = 1
+		}
+		state.LapsedIntervalDays = 0
+		state.DueAt = now.AddDate(0, 0, state.IntervalDays)
+		return state
+	}
+
+	state.DueAt = now.Add(sc.RelearningSteps[state.RelearningStep-1])
+	return state
+}
+
+// ParseSteps parses a comma separated list of relearning steps such as
+// "10m,1d". Besides the units accepted by time.ParseDuration, "d" means days.
+func ParseSteps(value string) ([]time.Duration, error) {
+	var steps []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var (
+			step time.Duration
+			err  error
+		)
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			var n int
+			n, err = strconv.Atoi(days)
+			step = time.Duration(n) * 24 * time.Hour
+		} else {
+			step, err = time.ParseDuration(part)
+		}
+		if err != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid relearning step %q", part)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
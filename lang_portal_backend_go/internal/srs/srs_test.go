@@ -0,0 +1,200 @@
+package srs
+
+import (
+	"lang_portal/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     models.WordLearningState
+		grade     Grade
+		wantState models.WordLearningState
+	}{
+		{
+			name:      "first good review",
+			state:     models.WordLearningState{EaseFactor: 2.5},
+			grade:     GradeGood,
+			wantState: models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.5},
+		},
+		{
+			name:      "second good review",
+			state:     models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.5},
+			grade:     GradeGood,
+			wantState: models.WordLearningState{Repetitions: 2, IntervalDays: 6, EaseFactor: 2.5},
+		},
+		{
+			name:      "later good review multiplies by the ease factor",
+			state:     models.WordLearningState{Repetitions: 2, IntervalDays: 6, EaseFactor: 2.5},
+			grade:     GradeGood,
+			wantState: models.WordLearningState{Repetitions: 3, IntervalDays: 15, EaseFactor: 2.5},
+		},
+		{
+			name:      "easy review raises the ease factor",
+			state:     models.WordLearningState{EaseFactor: 2.5},
+			grade:     GradeEasy,
+			wantState: models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.6},
+		},
+		{
+			name:      "hard review lowers the ease factor",
+			state:     models.WordLearningState{EaseFactor: 2.5},
+			grade:     GradeHard,
+			wantState: models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.36},
+		},
+		{
+			name:      "wrong answer to a new word",
+			state:     models.WordLearningState{EaseFactor: 2.5},
+			grade:     GradeWrong,
+			wantState: models.WordLearningState{IntervalDays: 1, EaseFactor: 1.96},
+		},
+		{
+			name:      "ease factor doesn't fall below the minimum",
+			state:     models.WordLearningState{EaseFactor: 1.4},
+			grade:     GradeBlackout,
+			wantState: models.WordLearningState{IntervalDays: 1, EaseFactor: MinEaseFactor},
+		},
+		{
+			name:      "grades above easy count as easy",
+			state:     models.WordLearningState{EaseFactor: 2.5},
+			grade:     Grade(9),
+			wantState: models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewScheduler().Schedule(tt.state, tt.grade, now)
+
+			if got.Repetitions != tt.wantState.Repetitions || got.IntervalDays != tt.wantState.IntervalDays {
+				t.Errorf("repetitions, interval = %d, %d, want %d, %d",
+					got.Repetitions, got.IntervalDays, tt.wantState.Repetitions, tt.wantState.IntervalDays)
+			}
+			if math.Abs(got.EaseFactor-tt.wantState.EaseFactor) > 1e-9 {
+				t.Errorf("ease factor = %v, want %v", got.EaseFactor, tt.wantState.EaseFactor)
+			}
+			if want := now.AddDate(0, 0, tt.wantState.IntervalDays); !got.DueAt.Equal(want) {
+				t.Errorf("due at %v, want %v", got.DueAt, want)
+			}
+			if got.LastReviewedAt == nil || !got.LastReviewedAt.Equal(now) {
+				t.Errorf("last reviewed at %v, want %v", got.LastReviewedAt, now)
+			}
+		})
+	}
+}
+
+func TestGradeFromRating(t *testing.T) {
+	tests := []struct {
+		rating    string
+		wantGrade Grade
+		wantOK    bool
+	}{
+		{rating: RatingAgain, wantGrade: GradeWrong, wantOK: true},
+		{rating: RatingHard, wantGrade: GradeHard, wantOK: true},
+		{rating: RatingGood, wantGrade: GradeGood, wantOK: true},
+		{rating: RatingEasy, wantGrade: GradeEasy, wantOK: true},
+		{rating: "perfect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rating, func(t *testing.T) {
+			grade, ok := GradeFromRating(tt.rating)
+			if grade != tt.wantGrade || ok != tt.wantOK {
+				t.Errorf("GradeFromRating(%q) = %d, %v, want %d, %v", tt.rating, grade, ok, tt.wantGrade, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScheduleRelearning(t *testing.T) {
+	known := models.WordLearningState{Repetitions: 3, IntervalDays: 15, EaseFactor: 2.5}
+	forgotten := models.WordLearningState{Repetitions: 3, IntervalDays: 15, EaseFactor: 2.5,
+		Lapses: 1, LapsedIntervalDays: 8, RelearningStep: 1}
+	lastStep := forgotten
+	lastStep.RelearningStep = 2
+	tests := []struct {
+		name      string
+		steps     []time.Duration
+		state     models.WordLearningState
+		grade     Grade
+		wantStep  int
+		wantDueAt time.Time
+		// wantInterval is the interval once the word is relearned
+		wantInterval int
+	}{
+		{name: "forgetting a known word starts the steps", steps: DefaultRelearningSteps, state: known, grade: GradeWrong,
+			wantStep: 1, wantDueAt: now.Add(10 * time.Minute), wantInterval: 15},
+		{name: "passing a step moves to the next", steps: DefaultRelearningSteps, state: forgotten, grade: GradeGood,
+			wantStep: 2, wantDueAt: now.Add(24 * time.Hour), wantInterval: 15},
+		{name: "failing a step starts the steps again", steps: DefaultRelearningSteps,
+			state: lastStep, grade: GradeWrong,
+			wantStep: 1, wantDueAt: now.Add(10 * time.Minute), wantInterval: 15},
+		{name: "passing the last step restores the shrunk interval", steps: DefaultRelearningSteps,
+			state: lastStep, grade: GradeGood,
+			wantDueAt: now.AddDate(0, 0, 8), wantInterval: 8},
+		{name: "without steps the shrunk interval is restored at once", state: known, grade: GradeWrong,
+			wantDueAt: now.AddDate(0, 0, 8), wantInterval: 8},
+		{name: "a shrunk interval is at least a day",
+			state: models.WordLearningState{Repetitions: 1, IntervalDays: 1, EaseFactor: 2.5}, grade: GradeWrong,
+			wantDueAt: now.AddDate(0, 0, 1), wantInterval: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &Scheduler{RelearningSteps: tt.steps, LapseMultiplier: DefaultLapseMultiplier}
+			got := sc.Schedule(tt.state, tt.grade, now)
+
+			if got.RelearningStep != tt.wantStep {
+				t.Errorf("relearning step = %d, want %d", got.RelearningStep, tt.wantStep)
+			}
+			if !got.DueAt.Equal(tt.wantDueAt) {
+				t.Errorf("due at %v, want %v", got.DueAt, tt.wantDueAt)
+			}
+			if got.IntervalDays != tt.wantInterval {
+				t.Errorf("interval = %d, want %d", got.IntervalDays, tt.wantInterval)
+			}
+			if got.Repetitions != tt.state.Repetitions {
+				t.Errorf("repetitions = %d, want %d kept", got.Repetitions, tt.state.Repetitions)
+			}
+		})
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantSteps []time.Duration
+		wantErr   bool
+	}{
+		{value: "10m,1d", wantSteps: []time.Duration{10 * time.Minute, 24 * time.Hour}},
+		{value: " 1h , 2d ", wantSteps: []time.Duration{time.Hour, 48 * time.Hour}},
+		{value: "30s,,5m", wantSteps: []time.Duration{30 * time.Second, 5 * time.Minute}},
+		{value: ""},
+		{value: "10", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-5m", wantErr: true},
+		{value: "1w", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			steps, err := ParseSteps(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSteps(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if len(steps) != len(tt.wantSteps) {
+				t.Fatalf("ParseSteps(%q) = %v, want %v", tt.value, steps, tt.wantSteps)
+			}
+			for i := range steps {
+				if steps[i] != tt.wantSteps[i] {
+					t.Errorf("ParseSteps(%q) = %v, want %v", tt.value, steps, tt.wantSteps)
+				}
+			}
+		})
+	}
+}
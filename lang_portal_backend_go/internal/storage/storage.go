@@ -0,0 +1,55 @@
+// Package storage keeps media, such as synthesized audio, on local disk or
+// in an S3 bucket, and signs the URLs it is served from. Which backend is
+// used is set by the server's settings.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"lang_portal/internal/config"
+	"regexp"
+)
+
+// ErrNotFound is returned for media that isn't stored
+var ErrNotFound = errors.New("media not found")
+
+// keyPattern is what keys look like: slash-separated names of letters,
+// digits, dots, dashes and underscores. Names can't start with a dot, so
+// none is "." or "..".
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// Storage keeps media under keys such as "audio/<name>.mp3"
+type Storage interface {
+	// Put stores data of contentType under key, replacing what was there.
+	// Readers never see it half written.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens what is stored under key, or returns ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether anything is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes what is stored under key, if anything
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL anyone may fetch what is stored under key
+	// from until it expires
+	SignedURL(key string) (string, error)
+}
+
+// New returns the storage configured by cfg
+func New(cfg config.Storage) (Storage, error) {
+	switch cfg.Backend {
+	case config.StorageLocal:
+		return NewLocal(cfg.Dir, []byte(cfg.SigningKey), cfg.URLExpiry), nil
+	case config.StorageS3:
+		return NewS3(cfg.S3, cfg.URLExpiry), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// ValidKey reports whether key can name media. Valid keys can't reach
+// outside the local backend's directory.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
@@ -0,0 +1,100 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const googleURL = "https://speech.googleapis.com/v1"
+
+var client = &http.Client{Timeout: time.Minute}
+
+// google transcribes recordings with Google Cloud Speech-to-Text's
+// synchronous recognition, which takes up to a minute of audio
+type google struct {
+	url string
+	key string
+}
+
+func newGoogle(apiURL, key string) *google {
+	if apiURL == "" {
+		apiURL = googleURL
+	}
+	return &google{url: apiURL, key: key}
+}
+
+func (p *google) Name() string { return Google }
+
+func (p *google) Transcribe(ctx context.Context, data []byte, contentType string) (string, error) {
+	config := map[string]interface{}{
+		"languageCode":    "ur-PK",
+		"maxAlternatives": 1,
+	}
+	// WAV and FLAC headers give the encoding and sample rate; Opus is
+	// always decoded at 48 kHz
+	switch contentType {
+	case WAV, FLAC:
+	case OGG:
+		config["encoding"], config["sampleRateHertz"] = "OGG_OPUS", 48000
+	case WebM:
+		config["encoding"], config["sampleRateHertz"] = "WEBM_OPUS", 48000
+	default:
+		return "", fmt.Errorf("google: %w %s: use WAV, FLAC, Ogg or WebM", ErrUnsupportedType, contentType)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"config": config,
+		"audio":  map[string]interface{}{"content": data},
+	})
+	if err != nil {
+		return "", fmt.Errorf("google: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.url+"/speech:recognize?key="+url.QueryEscape(p.key), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("google: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var response struct {
+		Results []struct {
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+			} `json:"alternatives"`
+		} `json:"results"`
+	}
+	if err := do(req, &response); err != nil {
+		return "", fmt.Errorf("google: %v", err)
+	}
+	var transcript []string
+	for _, result := range response.Results {
+		if len(result.Alternatives) > 0 {
+			transcript = append(transcript, strings.TrimSpace(result.Alternatives[0].Transcript))
+		}
+	}
+	return strings.Join(transcript, " "), nil
+}
+
+// do sends req and decodes the JSON response into v
+func do(req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
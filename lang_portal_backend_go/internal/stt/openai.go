@@ -0,0 +1,88 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const (
+	openAIURL   = "https://api.openai.com/v1"
+	openAIModel = "whisper-1"
+)
+
+// extensions name uploaded recordings, as the transcriptions API tells their
+// format by the file name
+var extensions = map[string]string{
+	WAV:  "wav",
+	FLAC: "flac",
+	OGG:  "ogg",
+	WebM: "webm",
+	MP3:  "mp3",
+	MP4:  "m4a",
+}
+
+// openAI transcribes recordings with the transcriptions API of OpenAI, or
+// of a server compatible with it
+type openAI struct {
+	url   string
+	key   string
+	model string
+}
+
+func newOpenAI(url, key, model string) *openAI {
+	if url == "" {
+		url = openAIURL
+	}
+	if model == "" {
+		model = openAIModel
+	}
+	return &openAI{url: url, key: key, model: model}
+}
+
+func (p *openAI) Name() string { return OpenAI + "/" + p.model }
+
+func (p *openAI) Transcribe(ctx context.Context, data []byte, contentType string) (string, error) {
+	extension, ok := extensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("openai: %w %s", ErrUnsupportedType, contentType)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "recording."+extension)
+	if err == nil {
+		_, err = file.Write(data)
+	}
+	for _, field := range [][2]string{{"model", p.model}, {"language", "ur"}, {"response_format", "json"}} {
+		if err == nil {
+			err = form.WriteField(field[0], field[1])
+		}
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		return "", fmt.Errorf("openai: failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("openai: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if p.key != "" {
+		req.Header.Set("Authorization", "Bearer "+p.key)
+	}
+
+	var response struct {
+		Text string `json:"text"`
+	}
+	if err := do(req, &response); err != nil {
+		return "", fmt.Errorf("openai: %v", err)
+	}
+	return strings.TrimSpace(response.Text), nil
+}
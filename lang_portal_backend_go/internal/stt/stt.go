@@ -0,0 +1,97 @@
+// Package stt transcribes recorded speech, so learners' pronunciation can be
+// checked. Google Cloud Speech-to-Text and OpenAI's transcriptions API, or a
+// server compatible with it such as a local Whisper, are supported; which
+// one is used is set by the environment.
+package stt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// ProviderEnv selects the provider: google or openai
+	ProviderEnv = "LANG_PORTAL_STT_PROVIDER"
+	// ModelEnv is the OpenAI transcription model
+	ModelEnv = "LANG_PORTAL_STT_MODEL"
+	// APIKeyEnv is the provider's API key
+	APIKeyEnv = "LANG_PORTAL_STT_API_KEY"
+	// URLEnv overrides the provider's API URL
+	URLEnv = "LANG_PORTAL_STT_URL"
+)
+
+// Providers
+const (
+	Google = "google"
+	OpenAI = "openai"
+)
+
+// Content types of recordings that can be transcribed
+const (
+	WAV  = "audio/wav"
+	FLAC = "audio/flac"
+	OGG  = "audio/ogg"
+	WebM = "audio/webm"
+	MP3  = "audio/mpeg"
+	MP4  = "audio/mp4"
+)
+
+// ErrUnsupportedType is returned for recordings a provider can't read, such
+// as an MP3 given to Google Cloud Speech-to-Text
+var ErrUnsupportedType = errors.New("unsupported content type")
+
+// Provider transcribes speech
+type Provider interface {
+	// Name names the provider, e.g. "openai/whisper-1"
+	Name() string
+	// Transcribe returns the Urdu spoken in data, a recording of
+	// contentType
+	Transcribe(ctx context.Context, data []byte, contentType string) (string, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	url := strings.TrimSuffix(os.Getenv(URLEnv), "/")
+	key := os.Getenv(APIKeyEnv)
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case Google:
+		if key == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newGoogle(url, key), nil
+	case OpenAI:
+		if key == "" && url == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newOpenAI(url, key, os.Getenv(ModelEnv)), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s or %s", ProviderEnv, provider, Google, OpenAI)
+	}
+}
+
+// Detect returns the content type of a recording from its first bytes, or
+// "" if it isn't a recording that can be transcribed
+func Detect(data []byte) string {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE")):
+		return WAV
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return FLAC
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return OGG
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return WebM
+	case bytes.HasPrefix(data, []byte("ID3")), len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return MP3
+	case len(data) >= 8 && bytes.Equal(data[4:8], []byte("ftyp")):
+		return MP4
+	}
+	return ""
+}
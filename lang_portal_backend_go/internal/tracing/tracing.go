@@ -0,0 +1,205 @@
+// Package tracing records how long requests, service calls and SQL queries
+// take as OpenTelemetry spans and exports them to an OTLP/HTTP collector,
+// such as Jaeger or the OpenTelemetry Collector, in its JSON encoding.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds of the OTLP protocol
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// TraceParentHeader carries the trace a request is part of, as defined by
+// W3C Trace Context
+const TraceParentHeader = "traceparent"
+
+// Tracer starts traces and hands their ended spans to an exporter. A nil
+// Tracer records nothing.
+type Tracer struct {
+	exporter *exporter
+}
+
+// New returns a Tracer exporting spans to the OTLP/HTTP endpoint, e.g.
+// http://localhost:4318, as the given service, or nil if endpoint is empty
+func New(endpoint, service string) *Tracer {
+	if endpoint == "" {
+		return nil
+	}
+	return &Tracer{exporter: newExporter(endpoint, service)}
+}
+
+// Shutdown exports the spans that haven't been exported yet, giving up
+// when ctx is done
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.shutdown(ctx)
+}
+
+// StartTrace starts the root span of a request. If traceParent is a valid
+// W3C traceparent header, the span continues the caller's trace.
+func (t *Tracer) StartTrace(ctx context.Context, name string, kind int, traceParent string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if traceID, parentID, ok := parseTraceParent(traceParent); ok {
+		span.traceID, span.parentID = traceID, parentID
+	} else {
+		span.traceID = randomID(16)
+	}
+	span.spanID = randomID(8)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a span as a child of the span in ctx. Outside a trace it
+// records nothing and returns a nil Span, whose methods do nothing.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:   parent.tracer,
+		name:     name,
+		kind:     kind,
+		traceID:  parent.traceID,
+		spanID:   randomID(8),
+		parentID: parent.spanID,
+		start:    time.Now(),
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span in ctx, or nil
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+type spanKey struct{}
+
+// Span is a timed operation of a trace
+type Span struct {
+	tracer   *Tracer
+	name     string
+	kind     int
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	err        string
+	ended      bool
+}
+
+// TraceID returns the hex ID of the span's trace, or "" for a nil Span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// SetName renames the span, e.g. once a request's route is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute records a string, bool, int, int64 or float64 attribute of
+// the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with err, if err isn't nil
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End ends the span and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.exporter.enqueue(s)
+}
+
+// TraceParent returns the W3C traceparent header continuing the span's
+// trace, or "" for a nil Span
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.traceID, s.spanID)
+}
+
+// parseTraceParent returns the trace and parent span IDs of a version 00
+// traceparent header
+func parseTraceParent(header string) (traceID, parentID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	for _, part := range parts[1:] {
+		if _, err := hex.DecodeString(part); err != nil || part != strings.ToLower(part) {
+			return "", "", false
+		}
+	}
+	// All-zero IDs are invalid
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// randomID returns n random bytes in hex
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// A span with a clashing ID is better than none
+		b[0] = 1
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const googleURL = "https://translation.googleapis.com/language/translate/v2"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// google translates with Google Cloud Translation. It doesn't romanize, so
+// its suggestions have no urdlish.
+type google struct {
+	url string
+	key string
+}
+
+func newGoogle(apiURL, key string) *google {
+	if apiURL == "" {
+		apiURL = googleURL
+	}
+	return &google{url: strings.TrimSuffix(apiURL, "/"), key: key}
+}
+
+func (p *google) Name() string { return Google }
+
+func (p *google) Suggest(ctx context.Context, language, text string) (*Suggestion, error) {
+	target := Urdu
+	if language == Urdu {
+		target = English
+	}
+	translated, err := p.translate(ctx, text, language, target)
+	if err != nil {
+		return nil, err
+	}
+	suggestion := &Suggestion{}
+	if target == Urdu {
+		suggestion.Urdu = translated
+	} else {
+		suggestion.English = translated
+	}
+	return fill(suggestion, language, text), nil
+}
+
+func (p *google) translate(ctx context.Context, text, source, target string) (string, error) {
+	data, err := json.Marshal(map[string]string{
+		"q":      text,
+		"source": source,
+		"target": target,
+		"format": "text",
+	})
+	if err != nil {
+		return "", fmt.Errorf("google: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.url+"?key="+url.QueryEscape(p.key), bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("google: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("google: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("google: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var response struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("google: failed to decode response: %v", err)
+	}
+	if len(response.Data.Translations) == 0 || strings.TrimSpace(response.Data.Translations[0].TranslatedText) == "" {
+		return "", fmt.Errorf("google: no translation returned")
+	}
+	return response.Data.Translations[0].TranslatedText, nil
+}
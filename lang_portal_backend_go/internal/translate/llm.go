@@ -0,0 +1,45 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lang_portal/internal/llm"
+	"strings"
+)
+
+// system tells the model what to answer
+const system = `You help English speakers enter Urdu vocabulary.
+Given an English or Urdu word or short phrase, answer with a JSON object
+only, of the form {"urdu": "...", "urdlish": "...", "english": "..."}
+where urdu is the word in Urdu script, urdlish its romanized spelling in
+Latin letters, and english its most common English meaning.`
+
+// languageModel asks a language model, which also romanizes
+type languageModel struct {
+	model llm.Provider
+}
+
+func (p *languageModel) Name() string { return LLM + "/" + p.model.Name() }
+
+func (p *languageModel) Suggest(ctx context.Context, language, text string) (*Suggestion, error) {
+	name := "English"
+	if language == Urdu {
+		name = "Urdu"
+	}
+	answer, err := p.model.Complete(ctx, system, fmt.Sprintf("The %s term is %q.", name, text))
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("answer has no JSON object")
+	}
+	var suggestion Suggestion
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &suggestion); err != nil {
+		return nil, fmt.Errorf("failed to parse answer: %v", err)
+	}
+	return fill(&suggestion, language, text), nil
+}
@@ -0,0 +1,92 @@
+// Package translate proposes the missing fields of a word from its English
+// or its Urdu, so a word can be entered from one term and confirmed. Google
+// Cloud Translation and the language model of package llm are supported;
+// which one is used is set by the environment.
+package translate
+
+import (
+	"context"
+	"fmt"
+	"lang_portal/internal/llm"
+	"os"
+	"strings"
+)
+
+const (
+	// ProviderEnv selects the provider: google or llm
+	ProviderEnv = "LANG_PORTAL_TRANSLATE_PROVIDER"
+	// APIKeyEnv is the Google Cloud API key
+	APIKeyEnv = "LANG_PORTAL_TRANSLATE_API_KEY"
+	// URLEnv overrides the Google Cloud Translation API URL
+	URLEnv = "LANG_PORTAL_TRANSLATE_URL"
+)
+
+// Providers
+const (
+	Google = "google"
+	// LLM asks the language model configured by package llm
+	LLM = "llm"
+)
+
+// Languages a term can be given in
+const (
+	English = "en"
+	Urdu    = "ur"
+)
+
+// Suggestion is a word's fields as a provider proposes them. Providers that
+// can't romanize leave Urdlish empty.
+type Suggestion struct {
+	Urdu    string `json:"urdu"`
+	Urdlish string `json:"urdlish"`
+	English string `json:"english"`
+}
+
+// Provider proposes a word's fields from one term
+type Provider interface {
+	// Name names the provider, e.g. "google" or "llm/openai/gpt-4o-mini".
+	// Suggestions of providers of the same name are the same.
+	Name() string
+	// Suggest proposes the fields of the word whose language field is text.
+	// The returned suggestion has that field set to text.
+	Suggest(ctx context.Context, language, text string) (*Suggestion, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case Google:
+		key := os.Getenv(APIKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newGoogle(os.Getenv(URLEnv), key), nil
+	case LLM:
+		model, err := llm.FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if model == nil {
+			return nil, fmt.Errorf("%s is required for %s", llm.ProviderEnv, provider)
+		}
+		return &languageModel{model: model}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s or %s", ProviderEnv, provider, Google, LLM)
+	}
+}
+
+// fill sets the language field of suggestion to text and trims the others
+func fill(suggestion *Suggestion, language, text string) *Suggestion {
+	suggestion.Urdu = strings.TrimSpace(suggestion.Urdu)
+	suggestion.Urdlish = strings.TrimSpace(suggestion.Urdlish)
+	suggestion.English = strings.TrimSpace(suggestion.English)
+	if language == Urdu {
+		suggestion.Urdu = text
+	} else {
+		suggestion.English = text
+	}
+	return suggestion
+}
@@ -0,0 +1,133 @@
+// Package tts synthesizes speech from text, so words can be heard without a
+// recording of them. Google Cloud Text-to-Speech is supported; the provider
+// and its voice are set by the environment.
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderEnv selects the provider: google
+	ProviderEnv = "LANG_PORTAL_TTS_PROVIDER"
+	// VoiceEnv is the voice to speak with
+	VoiceEnv = "LANG_PORTAL_TTS_VOICE"
+	// APIKeyEnv is the provider's API key
+	APIKeyEnv = "LANG_PORTAL_TTS_API_KEY"
+	// URLEnv overrides the provider's API URL
+	URLEnv = "LANG_PORTAL_TTS_URL"
+)
+
+// Providers
+const (
+	Google = "google"
+)
+
+const (
+	googleURL   = "https://texttospeech.googleapis.com/v1"
+	googleVoice = "ur-IN-Wavenet-A"
+)
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Provider speaks text
+type Provider interface {
+	// Name names the provider and voice, e.g. "google/ur-IN-Wavenet-A".
+	// Speech of the same text by providers of the same name is the same.
+	Name() string
+	// Synthesize returns text spoken, as MP3
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// FromEnv returns the provider configured by the environment, or nil if
+// ProviderEnv isn't set
+func FromEnv() (Provider, error) {
+	switch provider := os.Getenv(ProviderEnv); provider {
+	case "":
+		return nil, nil
+	case Google:
+		key := os.Getenv(APIKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("%s is required for %s", APIKeyEnv, provider)
+		}
+		return newGoogle(os.Getenv(URLEnv), key, os.Getenv(VoiceEnv)), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q: use %s", ProviderEnv, provider, Google)
+	}
+}
+
+// google speaks text with Google Cloud Text-to-Speech
+type google struct {
+	url   string
+	key   string
+	voice string
+}
+
+func newGoogle(apiURL, key, voice string) *google {
+	if apiURL == "" {
+		apiURL = googleURL
+	}
+	if voice == "" {
+		voice = googleVoice
+	}
+	return &google{url: strings.TrimSuffix(apiURL, "/"), key: key, voice: voice}
+}
+
+func (p *google) Name() string { return Google + "/" + p.voice }
+
+func (p *google) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	// Voice names start with their language code, e.g. ur-IN
+	language := p.voice
+	if parts := strings.SplitN(p.voice, "-", 3); len(parts) == 3 {
+		language = parts[0] + "-" + parts[1]
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"input":       map[string]string{"text": text},
+		"voice":       map[string]string{"languageCode": language, "name": p.voice},
+		"audioConfig": map[string]string{"audioEncoding": "MP3"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google: failed to encode request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.url+"/text:synthesize?key="+url.QueryEscape(p.key), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("google: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("google: request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("google: request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var response struct {
+		AudioContent string `json:"audioContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("google: failed to decode response: %v", err)
+	}
+	audio, err := base64.StdEncoding.DecodeString(response.AudioContent)
+	if err != nil {
+		return nil, fmt.Errorf("google: failed to decode audio: %v", err)
+	}
+	if len(audio) == 0 {
+		return nil, fmt.Errorf("google: no audio returned")
+	}
+	return audio, nil
+}
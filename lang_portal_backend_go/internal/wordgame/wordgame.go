@@ -0,0 +1,137 @@
+// Package wordgame implements the rules of the word scramble and hangman
+// games. Letters are compared ignoring case and diacritics, and Arabic and
+// Urdu letter variants count as the same letter.
+package wordgame
+
+import (
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"lang_portal/internal/spelling"
+)
+
+// Games
+const (
+	// Scramble shows the letters of the word shuffled
+	Scramble = "scramble"
+	// Hangman reveals the word one guessed letter at a time
+	Hangman = "hangman"
+)
+
+// Round statuses
+const (
+	StatusPlaying = "playing"
+	StatusWon     = "won"
+	StatusLost    = "lost"
+)
+
+// MaxScrambleAttempts is how many answers a scramble round accepts
+const MaxScrambleAttempts = 3
+
+// MaxWrongGuesses is how many wrong guesses end a hangman round
+const MaxWrongGuesses = 6
+
+// Hidden stands for a letter that hasn't been guessed yet
+const Hidden = '_'
+
+// ValidGame reports whether game is a known game
+func ValidGame(game string) bool {
+	return game == Scramble || game == Hangman
+}
+
+// MaxWrong returns how many wrong guesses or answers end a round of game
+func MaxWrong(game string) int {
+	if game == Scramble {
+		return MaxScrambleAttempts
+	}
+	return MaxWrongGuesses
+}
+
+// letter returns the normalized form of r, or "" if r isn't a letter or digit
+func letter(r rune) string {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return ""
+	}
+	return spelling.Normalize(string(r))
+}
+
+// Shuffle scrambles the letters of each word in text, keeping spaces and
+// punctuation in place. The result differs from text whenever it can.
+func Shuffle(text string, rng *rand.Rand) string {
+	words := strings.Fields(text)
+	for attempt := 0; attempt < 10; attempt++ {
+		shuffled := make([]string, len(words))
+		for i, word := range words {
+			runes := []rune(word)
+			var positions []int
+			for j, r := range runes {
+				if letter(r) != "" {
+					positions = append(positions, j)
+				}
+			}
+			letters := make([]rune, len(positions))
+			for k, p := range positions {
+				letters[k] = runes[p]
+			}
+			rng.Shuffle(len(letters), func(a, b int) {
+				letters[a], letters[b] = letters[b], letters[a]
+			})
+			for k, p := range positions {
+				runes[p] = letters[k]
+			}
+			shuffled[i] = string(runes)
+		}
+		if result := strings.Join(shuffled, " "); !Same(result, text) {
+			return result
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Same reports whether two answers are the same word
+func Same(a, b string) bool {
+	return spelling.Normalize(a) == spelling.Normalize(b)
+}
+
+// IsLetterGuess reports whether guess is a single letter rather than a
+// guess at the whole word
+func IsLetterGuess(guess string) bool {
+	return len([]rune(spelling.Normalize(guess))) == 1
+}
+
+// Contains reports whether the letter guessed appears in text
+func Contains(text, guess string) bool {
+	key := spelling.Normalize(guess)
+	for _, r := range text {
+		if key != "" && letter(r) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask hides the letters of text that haven't been guessed. Spaces,
+// punctuation and diacritics are always shown.
+func Mask(text string, guessed []string) string {
+	known := make(map[string]bool, len(guessed))
+	for _, g := range guessed {
+		known[spelling.Normalize(g)] = true
+	}
+
+	var b strings.Builder
+	for _, r := range text {
+		key := letter(r)
+		if key == "" || known[key] {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(Hidden)
+		}
+	}
+	return b.String()
+}
+
+// Solved reports whether every letter of text has been guessed
+func Solved(text string, guessed []string) bool {
+	return !strings.ContainsRune(Mask(text, guessed), Hidden)
+}
@@ -0,0 +1,486 @@
+//go:build mage
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"lang_portal/db/migrations"
+	"lang_portal/internal/db"
+	"lang_portal/internal/db/migrator"
+	"lang_portal/internal/db/seeder"
+	"lang_portal/internal/models"
+	"lang_portal/internal/push"
+	"lang_portal/internal/repository"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const dbPath = "words.db"
+
+const seedDir = "db/seeds"
+
+// frontendDir is the React app the server embeds, and frontendDist where it
+// is built into for embedding
+const (
+	frontendDir  = "../lang_portal_frontend"
+	frontendDist = "web/dist"
+)
+
+type seedWord struct {
+	Urdu    string          `json:"urdu"`
+	Urdlish string          `json:"urdlish"`
+	English string          `json:"english"`
+	Parts   json.RawMessage `json:"parts"`
+}
+
+// InitDB creates a new SQLite database
+func InitDB() error {
+	fmt.Println("Creating new database...")
+
+	// Remove existing database if it exists
+	if _, err := os.Stat(dbPath); err == nil {
+		if err := os.Remove(dbPath); err != nil {
+			return fmt.Errorf("failed to remove existing database: %v", err)
+		}
+	}
+
+	// Create new database file
+	conn, err := db.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.Ping(); err != nil {
+		return fmt.Errorf("failed to create database: %v", err)
+	}
+
+	fmt.Println("Database created successfully")
+	return nil
+}
+
+// Migrate applies the migrations the database doesn't have yet
+func Migrate() error {
+	fmt.Println("Running migrations...")
+
+	conn, err := db.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	m, err := migrator.NewMigrator(conn, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up()
+	for _, migration := range applied {
+		fmt.Printf("Applied migration %04d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database schema is at version %d\n", version)
+	fmt.Println("Migrations completed successfully")
+	return nil
+}
+
+// Size of the database Bench generates
+const (
+	benchWords             = 100000
+	benchUsers             = 50
+	benchSessions          = 50000
+	benchReviewsPerSession = 20
+)
+
+// Bench times the hot list queries against a generated database of 100k
+// words and 1M reviews
+func Bench() error {
+	dir, err := os.MkdirTemp("", "lang_portal_bench")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := db.Open(filepath.Join(dir, "bench.db"))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	m, err := migrator.NewMigrator(conn, migrations.FS)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Generating %d words and %d reviews...\n", benchWords, benchSessions*benchReviewsPerSession)
+	start := time.Now()
+	_, err = conn.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?1)
+		INSERT INTO words (script, transliteration, english)
+		SELECT 'urdu ' || i, 'urdlish ' || i, 'english ' || i FROM n
+	`, benchWords)
+	if err != nil {
+		return fmt.Errorf("failed to generate words: %v", err)
+	}
+	_, err = conn.Exec(`INSERT INTO words_groups (word_id, group_id) SELECT id, 1 + id % 3 FROM words`)
+	if err != nil {
+		return fmt.Errorf("failed to generate group words: %v", err)
+	}
+	_, err = conn.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 2 UNION ALL SELECT i + 1 FROM n WHERE i < ?1)
+		INSERT INTO users (id, username) SELECT i, 'user ' || i FROM n
+	`, benchUsers)
+	if err != nil {
+		return fmt.Errorf("failed to generate users: %v", err)
+	}
+	_, err = conn.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?1)
+		INSERT INTO study_sessions (group_id, created_at, study_activity_id, user_id)
+		SELECT 1 + i % 3, datetime('now', '-' || i || ' minutes'), 1, 1 + i % ?2 FROM n
+	`, benchSessions, benchUsers)
+	if err != nil {
+		return fmt.Errorf("failed to generate study sessions: %v", err)
+	}
+	_, err = conn.Exec(`
+		WITH RECURSIVE k(j) AS (SELECT 1 UNION ALL SELECT j + 1 FROM k WHERE j < ?1)
+		INSERT OR IGNORE INTO word_review_items (word_id, study_session_id, correct, near_miss, created_at, user_id)
+		SELECT 1 + abs(random()) % ?2, ss.id, abs(random()) % 10 < 7, 0, ss.created_at, ss.user_id
+		FROM study_sessions ss, k
+	`, benchReviewsPerSession, benchWords)
+	if err != nil {
+		return fmt.Errorf("failed to generate reviews: %v", err)
+	}
+	if _, err := conn.Exec("ANALYZE"); err != nil {
+		return fmt.Errorf("failed to analyze database: %v", err)
+	}
+	fmt.Printf("Generated in %v\n", time.Since(start).Round(time.Millisecond))
+
+	ctx := context.Background()
+	repos := repository.NewSQLite(models.NewDB(conn))
+	queries := []struct {
+		name string
+		run  func() error
+	}{
+		{"words, middle page", func() error {
+			_, err := repos.Words.List(ctx, 1, 0, "", repository.SortID, 100, benchWords/2)
+			return err
+		}},
+		{"word", func() error {
+			_, err := repos.Words.Get(ctx, 1, 0, benchWords/2)
+			return err
+		}},
+		{"groups", func() error {
+			_, err := repos.Groups.List(ctx, 0, "", repository.SortID, 100, 0)
+			return err
+		}},
+		{"group words, middle page", func() error {
+			_, err := repos.Groups.ListWords(ctx, 1, 2, repository.SortID, 100, benchWords/6)
+			return err
+		}},
+		{"study sessions, first page", func() error {
+			_, err := repos.Sessions.List(ctx, 1, 0, 100, 0)
+			return err
+		}},
+		{"study session", func() error {
+			_, err := repos.Sessions.Get(ctx, 1, benchUsers)
+			return err
+		}},
+	}
+
+	const runs = 20
+	for _, q := range queries {
+		start := time.Now()
+		for i := 0; i < runs; i++ {
+			if err := q.run(); err != nil {
+				return fmt.Errorf("failed to query %s: %v", q.name, err)
+			}
+		}
+		fmt.Printf("%-28s %v\n", q.name, (time.Since(start) / runs).Round(time.Microsecond))
+	}
+	return nil
+}
+
+// Seed imports sample data
+func Seed() error {
+	fmt.Println("Seeding database...")
+
+	conn, err := db.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	// Start a transaction
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	// Import study activities from seed file
+	studyActivitiesFile := "db/seeds/study_activities.json"
+	if err := importStudyActivities(tx, studyActivitiesFile); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to import study activities: %v", err)
+	}
+
+	// Create default groups
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO groups (name) VALUES 
+		('Beginner Words'),
+		('Intermediate Words'),
+		('Advanced Words')
+	`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to insert groups: %v", err)
+	}
+
+	// Import words from JSON files
+	wordFiles := []string{
+		"db/seeds/basic_words.json",
+		"db/seeds/common_phrases.json",
+	}
+
+	// Get the beginner group ID
+	var beginnerGroupID int64
+	err = tx.QueryRow("SELECT id FROM groups WHERE name = 'Beginner Words'").Scan(&beginnerGroupID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to get beginner group ID: %v", err)
+	}
+
+	for _, file := range wordFiles {
+		if err := importSeedFile(tx, file, beginnerGroupID); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to import %s: %v", file, err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	fmt.Println("Database seeded successfully")
+	return nil
+}
+
+// Frontend builds the React app into web/dist, for the next build of the
+// server to embed and serve
+func Frontend() error {
+	for _, args := range [][]string{{"ci"}, {"run", "build"}} {
+		cmd := exec.Command("npm", args...)
+		cmd.Dir = frontendDir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run npm %s: %v", args[0], err)
+		}
+	}
+
+	// Replace the previous build, keeping the .gitignore that stops it being
+	// committed
+	entries, err := os.ReadDir(frontendDist)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", frontendDist, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".gitignore" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(frontendDist, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove previous build: %v", err)
+		}
+	}
+	if err := copyDir(filepath.Join(frontendDir, "dist"), frontendDist); err != nil {
+		return fmt.Errorf("failed to copy build: %v", err)
+	}
+
+	fmt.Println("Frontend built into " + frontendDist)
+	return nil
+}
+
+// sqlcVersion is the sqlc the typed queries of internal/db/queries are
+// generated with
+const sqlcVersion = "v1.27.0"
+
+// Generate regenerates the typed queries of internal/db/queries from
+// db/queries with the pinned sqlc
+func Generate() error {
+	cmd := exec.Command("go", "run", "github.com/sqlc-dev/sqlc/cmd/sqlc@"+sqlcVersion, "generate")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run sqlc: %v", err)
+	}
+	return nil
+}
+
+// copyDir copies the files under src to dst
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
+
+// SeedPacks lists the seed packs of db/seeds
+func SeedPacks() error {
+	packs, err := seeder.ListSeedPacks(seedDir)
+	if err != nil {
+		return err
+	}
+	for _, pack := range packs {
+		fmt.Printf("%-20s %-10s %s %d activities, %d groups, %d words\n",
+			pack.Name, pack.Kind, pack.Version, pack.Activities, pack.Groups, pack.Words)
+	}
+	return nil
+}
+
+// VapidKeys prints a new VAPID key pair for push notifications
+func VapidKeys() error {
+	public, private, err := push.GenerateKeys()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s=%s\n", push.PublicKeyEnv, public)
+	fmt.Printf("%s=%s\n", push.PrivateKeyEnv, private)
+	return nil
+}
+
+// SeedApply applies a seed pack of db/seeds by name, e.g. mage seedApply word_groups
+func SeedApply(name string) error {
+	return applySeedPack(name, false)
+}
+
+// SeedDryRun shows what applying a seed pack would insert without inserting it
+func SeedDryRun(name string) error {
+	return applySeedPack(name, true)
+}
+
+func applySeedPack(name string, dryRun bool) error {
+	conn, err := db.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	result, err := seeder.NewSeeder(models.NewDB(conn)).ApplySeedPack(context.Background(), seedDir, name, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run of %s, nothing was changed\n", name)
+	}
+	fmt.Printf("Activities saved: %d\n", result.ActivitiesSaved)
+	fmt.Printf("Groups created:   %d\n", result.GroupsCreated)
+	fmt.Printf("Words created:    %d\n", result.WordsCreated)
+	fmt.Printf("Words skipped:    %d\n", result.WordsSkipped)
+	for _, warning := range result.Warnings {
+		for _, w := range warning.Warnings {
+			fmt.Printf("Warning: %s (%s) in %s: %s\n", warning.Urdu, warning.English, warning.Group, w.Message)
+		}
+	}
+	return nil
+}
+
+func importStudyActivities(tx *sql.Tx, filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	var activities []struct {
+		ID           int     `json:"id"`
+		Name         string  `json:"name"`
+		ThumbnailURL string  `json:"thumbnail_url"`
+		Description  string  `json:"description"`
+	}
+	if err := json.Unmarshal(data, &activities); err != nil {
+		return fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	for _, activity := range activities {
+		_, err = tx.Exec(`
+			INSERT OR REPLACE INTO study_activities (id, name, thumbnail_url, description, created_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, activity.ID, activity.Name, activity.ThumbnailURL, activity.Description)
+		if err != nil {
+			return fmt.Errorf("error inserting activity: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func importSeedFile(tx *sql.Tx, filePath string, groupID int64) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	var words []seedWord
+	if err := json.Unmarshal(data, &words); err != nil {
+		return fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	for _, word := range words {
+		// Insert word
+		result, err := tx.Exec(`
+			INSERT INTO words (script, transliteration, english, parts)
+			VALUES (?, ?, ?, ?)
+		`, word.Urdu, word.Urdlish, word.English, word.Parts)
+		if err != nil {
+			return fmt.Errorf("error inserting word: %v", err)
+		}
+
+		// Get the word ID
+		wordID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %v", err)
+		}
+
+		// Link word to group
+		_, err = tx.Exec(`
+			INSERT INTO words_groups (word_id, group_id)
+			VALUES (?, ?)
+		`, wordID, groupID)
+		if err != nil {
+			return fmt.Errorf("error linking word to group: %v", err)
+		}
+	}
+
+	return nil
+}
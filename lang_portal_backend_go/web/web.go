@@ -0,0 +1,25 @@
+// Package web embeds the built frontend, so the server can serve the portal
+// without a separate web server. "mage frontend" builds it into dist; until
+// then the binary has no frontend.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// FS returns the files of the built frontend, or false if it wasn't built
+// when the binary was
+func FS() (fs.FS, bool) {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(files, "index.html"); err != nil {
+		return nil, false
+	}
+	return files, true
+}
@@ -8,7 +8,7 @@ export default function Settings() {
   const [resetting, setResetting] = useState(false)
 
   const handleResetHistory = async () => {
-    if (!window.confirm('Are you sure you want to reset all study history? This cannot be undone.')) {
+    if (!window.confirm('Are you sure you want to reset all study history? An archive is kept so it can be restored.')) {
       return
     }
 
@@ -25,7 +25,7 @@ export default function Settings() {
   }
 
   const handleFullReset = async () => {
-    if (!window.confirm('Are you sure you want to perform a full reset? This will delete ALL data and cannot be undone.')) {
+    if (!window.confirm('Are you sure you want to perform a full reset? This will delete ALL data. An archive is kept so it can be restored.')) {
       return
     }
 
@@ -90,9 +90,15 @@ export const vocabularyQuizApi = {
   },
 };
 
+// Resets must be confirmed with a single-use token asked for just before
+const confirmedReset = async (kind: 'history' | 'full', path: string) => {
+  const confirmation = await api.post('/resets/confirmations', { kind });
+  return api.post(path, { confirm_token: confirmation.data.confirm_token });
+};
+
 export const settingsApi = {
-  resetHistory: () => api.post('/reset_history'),
-  fullReset: () => api.post('/full_reset'),
+  resetHistory: () => confirmedReset('history', '/reset_history'),
+  fullReset: () => confirmedReset('full', '/full_reset'),
 };
 
 export const wordMatchingApi = {